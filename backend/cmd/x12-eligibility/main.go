@@ -0,0 +1,168 @@
+// Command x12-eligibility answers batches of X12 270 eligibility inquiries
+// with 271 responses, using the member and benefits services.
+//
+//	x12-eligibility [-config config/gateway.yaml] [-out dir] inquiry.x12...
+//
+// Each input file is answered in a file of the same name with a .271
+// extension.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/config"
+	"github.com/sydney-health-clone/backend/shared/logger"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/x12"
+	"github.com/sydney-health-clone/backend/shared/x12/eligibility"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	configPath    = flag.String("config", "config/gateway.yaml", "Configuration file with the member and benefits service endpoints")
+	outDir        = flag.String("out", "", "Directory for 271 files (default: next to each input)")
+	controlNumber = flag.Int("control-number", 1, "Interchange control number of the first 271")
+	timeout       = flag.Duration("timeout", time.Minute, "Time allowed to answer each file")
+	noBenefits    = flag.Bool("no-benefits", false, "Leave deductible and out-of-pocket amounts out of the 271s")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: x12-eligibility [flags] inquiry.x12...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		// Use the local development ports
+		cfg = &config.Config{
+			Server: config.ServerConfig{LogLevel: "info"},
+			Services: config.ServicesConfig{
+				MemberService:   config.ServiceEndpoint{Host: "localhost", Port: 50051},
+				BenefitsService: config.ServiceEndpoint{Host: "localhost", Port: 50052},
+			},
+		}
+	}
+
+	if err := logger.Init(cfg.Server.LogLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	// The member service only answers lookups for signed internal callers
+	if os.Getenv(access.ServiceSecretEnv) == "" {
+		logger.Fatal(access.ServiceSecretEnv + " must be set to call the member service")
+	}
+
+	memberConn, err := dial(cfg.Services.MemberService)
+	if err != nil {
+		logger.Fatal("Failed to connect to member service", zap.Error(err))
+	}
+	defer memberConn.Close()
+
+	var benefits pb.BenefitsServiceClient
+	if !*noBenefits {
+		benefitsConn, err := dial(cfg.Services.BenefitsService)
+		if err != nil {
+			logger.Fatal("Failed to connect to benefits service", zap.Error(err))
+		}
+		defer benefitsConn.Close()
+		benefits = pb.NewBenefitsServiceClient(benefitsConn)
+	}
+
+	responder := eligibility.NewResponder(pb.NewMemberServiceClient(memberConn), benefits, eligibility.Options{
+		FirstControlNumber: *controlNumber,
+	})
+
+	failed := 0
+	for _, path := range flag.Args() {
+		if err := answerFile(responder, path); err != nil {
+			logger.Error("Failed to answer inquiry file", zap.String("file", path), zap.Error(err))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		logger.Sync()
+		os.Exit(1)
+	}
+}
+
+// dial connects without blocking, so an unavailable service surfaces as
+// AAA rejections or missing amounts rather than a failed run. Calls are
+// signed as this command with INTERNAL_SERVICE_SECRET.
+func dial(endpoint config.ServiceEndpoint) (*grpc.ClientConn, error) {
+	target := fmt.Sprintf("%s:%d", endpoint.Host, endpoint.Port)
+	return grpc.Dial(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(access.ServiceInterceptor("x12-eligibility")),
+	)
+}
+
+// answerFile answers every interchange in the file into its .271
+func answerFile(responder *eligibility.Responder, path string) error {
+	dest := strings.TrimSuffix(path, filepath.Ext(path)) + ".271"
+	if *outDir != "" {
+		dest = filepath.Join(*outDir, filepath.Base(dest))
+	}
+	if filepath.Clean(dest) == filepath.Clean(path) {
+		return fmt.Errorf("response would overwrite the inquiry; use -out")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	interchanges, err := x12.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	transactions := 0
+	for _, ic := range interchanges {
+		reply, err := responder.Respond(ctx, ic)
+		if err != nil {
+			return fmt.Errorf("interchange %s: %w", ic.ControlNumber(), err)
+		}
+		if _, err := reply.WriteTo(&out); err != nil {
+			return err
+		}
+		for _, group := range reply.Groups {
+			transactions += len(group.Transactions)
+		}
+	}
+
+	if err := os.WriteFile(dest, out.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	logger.Info("Answered inquiry file",
+		zap.String("file", path),
+		zap.String("response", dest),
+		zap.Int("interchanges", len(interchanges)),
+		zap.Int("transactions", transactions),
+	)
+	return nil
+}
@@ -0,0 +1,124 @@
+// Command x12-enrollment applies X12 834 enrollment files from employer
+// groups to the member database and reports what became of each member loop.
+//
+//	x12-enrollment [-dry-run] [-report file] enrollment.x12...
+//
+// The database is configured with the DB_* environment variables, as for the
+// member service. Member update events are sent when KAFKA_BROKERS and
+// MEMBER_UPDATES_TOPIC are set.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sydney-health/backend/internal/database"
+	"github.com/sydney-health/backend/services/member/repository"
+	"github.com/sydney-health/backend/shared/kafka"
+	"github.com/sydney-health/backend/shared/x12"
+	"github.com/sydney-health/backend/shared/x12/enrollment"
+)
+
+var (
+	dryRun     = flag.Bool("dry-run", false, "Report the changes each file would make without writing them")
+	reportPath = flag.String("report", "", "File for the reconciliation report (default: standard output)")
+	timeout    = flag.Duration("timeout", 10*time.Minute, "Time allowed to apply each file")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: x12-enrollment [flags] enrollment.x12...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Events are only sent for changes that were written
+	var publisher enrollment.Publisher
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("MEMBER_UPDATES_TOPIC"); brokers != "" && topic != "" && !*dryRun {
+		producer := kafka.NewProducer(strings.Split(brokers, ","), topic)
+		defer producer.Close()
+		publisher = producer
+	}
+
+	loader := enrollment.NewLoader(repository.NewEnrollmentStore(db), publisher, enrollment.Options{
+		DryRun: *dryRun,
+	})
+
+	var out io.Writer = os.Stdout
+	if *reportPath != "" {
+		f, err := os.Create(*reportPath)
+		if err != nil {
+			log.Fatalf("Failed to create report: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		errored, err := loadFile(loader, path, out)
+		if err != nil {
+			log.Printf("Failed to load enrollment file %s: %v", path, err)
+			failed = true
+			continue
+		}
+		if errored > 0 {
+			log.Printf("%s: %d member loops could not be applied", path, errored)
+			failed = true
+		}
+	}
+
+	if failed {
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// loadFile applies every interchange in the file, writes their reports and
+// returns the number of errored loops
+func loadFile(loader *enrollment.Loader, path string, out io.Writer) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	interchanges, err := x12.Parse(data)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	errored := 0
+	fmt.Fprintf(out, "== %s\n", path)
+	for _, ic := range interchanges {
+		report, err := loader.Load(ctx, ic)
+		if report != nil {
+			if _, werr := report.WriteTo(out); werr != nil {
+				return errored, werr
+			}
+			errored += report.Errored
+		}
+		if err != nil {
+			return errored, fmt.Errorf("interchange %s: %w", ic.ControlNumber(), err)
+		}
+	}
+	return errored, nil
+}
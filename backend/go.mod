@@ -25,4 +25,8 @@ require (
 	github.com/grpc-ecosystem/go-grpc-middleware v1.4.0
 	golang.org/x/sync v0.5.0
 	golang.org/x/crypto v0.15.0
+	golang.org/x/image v0.14.0
+	github.com/boombuler/barcode v1.0.1
+	github.com/jung-kurt/gofpdf v1.16.2
+	go.mozilla.org/pkcs7 v0.0.0-20210826202110-33d05740a352
 )
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- Subscriber/dependent relationships and personal-representative grants
+
+-- Links each dependent to the subscriber whose policy covers them
+CREATE TABLE IF NOT EXISTS member_dependents (
+    id SERIAL PRIMARY KEY,
+    primary_member_id VARCHAR(50) NOT NULL REFERENCES members(member_id),
+    dependent_id VARCHAR(50) NOT NULL REFERENCES members(member_id),
+    relationship VARCHAR(20) NOT NULL DEFAULT 'CHILD',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (primary_member_id, dependent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_member_dependents_dependent ON member_dependents (dependent_id);
+
+-- Explicit, revocable proxy access granted by a member to another member
+CREATE TABLE IF NOT EXISTS member_proxy_grants (
+    grant_id VARCHAR(50) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL REFERENCES members(member_id),
+    proxy_member_id VARCHAR(50) NOT NULL REFERENCES members(member_id),
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_proxy_grants_member ON member_proxy_grants (member_id, proxy_member_id);
+CREATE INDEX IF NOT EXISTS idx_proxy_grants_proxy ON member_proxy_grants (proxy_member_id);
@@ -0,0 +1,20 @@
+-- Apple Wallet pass web service state
+
+-- Devices that have a member's pass installed, and where to push updates
+CREATE TABLE IF NOT EXISTS wallet_pass_registrations (
+    device_library_id VARCHAR(128) NOT NULL,
+    pass_type_id VARCHAR(128) NOT NULL,
+    serial_number VARCHAR(100) NOT NULL,
+    push_token VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (device_library_id, pass_type_id, serial_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_registrations_serial ON wallet_pass_registrations (pass_type_id, serial_number);
+
+-- Fingerprint of each issued pass; updated_at moves only when the content changes
+CREATE TABLE IF NOT EXISTS wallet_passes (
+    serial_number VARCHAR(100) PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,34 @@
+-- Effective-dated coverage history for eligibility checks
+
+-- One row per period of coverage. Dates are inclusive; a termination date
+-- before the effective date voids the span (rescission).
+CREATE TABLE IF NOT EXISTS member_coverage_spans (
+    span_id VARCHAR(50) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL REFERENCES members(member_id),
+    coverage_type VARCHAR(20) NOT NULL,
+    plan_id VARCHAR(50) NOT NULL DEFAULT '',
+    plan_name VARCHAR(255) NOT NULL DEFAULT '',
+    group_number VARCHAR(50) NOT NULL DEFAULT '',
+    tier VARCHAR(30) NOT NULL DEFAULT 'UNSPECIFIED',
+    effective_date DATE NOT NULL,
+    termination_date DATE,
+    termination_reason VARCHAR(30),
+    reinstated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coverage_spans_member ON member_coverage_spans (member_id, coverage_type, effective_date);
+
+-- Carry existing coverage rows over as the first span of each coverage
+INSERT INTO member_coverage_spans (
+    span_id, member_id, coverage_type, plan_name, group_number,
+    effective_date, termination_date, termination_reason
+)
+SELECT
+    'CS-' || mc.id, mc.member_id, UPPER(mc.coverage_type),
+    COALESCE(mc.plan_name, ''), COALESCE(mc.group_number, ''),
+    mc.effective_date, mc.termination_date,
+    CASE WHEN mc.termination_date IS NULL THEN NULL ELSE 'OTHER' END
+FROM member_coverages mc
+ON CONFLICT (span_id) DO NOTHING;
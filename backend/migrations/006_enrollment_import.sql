@@ -0,0 +1,10 @@
+-- Member columns maintained by the X12 834 enrollment import
+
+-- Dependents added from an 834 usually have no email address
+ALTER TABLE members ALTER COLUMN email DROP NOT NULL;
+
+-- DMG-03, stored as MALE, FEMALE or UNKNOWN like the seed data
+ALTER TABLE members ADD COLUMN IF NOT EXISTS gender VARCHAR(20);
+
+-- 834 loops are matched to members by card number regardless of case
+CREATE INDEX IF NOT EXISTS idx_members_subscriber_id_upper ON members (UPPER(subscriber_id));
@@ -0,0 +1,15 @@
+-- Email and phone changes awaiting verification
+
+-- At most one pending change per member and channel; a new change replaces
+-- the old one. Only a hash of the code is stored.
+CREATE TABLE IF NOT EXISTS contact_verifications (
+    verification_id VARCHAR(36) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL REFERENCES members(member_id),
+    channel VARCHAR(10) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (member_id, channel)
+);
@@ -0,0 +1,24 @@
+-- Communication and paperless preferences
+
+-- Members without a row have the default preferences
+CREATE TABLE IF NOT EXISTS member_preferences (
+    member_id VARCHAR(50) PRIMARY KEY REFERENCES members(member_id),
+    language VARCHAR(12) NOT NULL DEFAULT 'en',
+    quiet_hours_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    quiet_hours_start VARCHAR(5),
+    quiet_hours_end VARCHAR(5),
+    quiet_hours_time_zone VARCHAR(64),
+    paperless BOOLEAN NOT NULL DEFAULT FALSE,
+    paperless_consented_at TIMESTAMP,
+    paperless_revoked_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per channel a member has opted in to for a category. A category
+-- with a preferences row but no channels has been opted out of.
+CREATE TABLE IF NOT EXISTS member_notification_channels (
+    member_id VARCHAR(50) NOT NULL REFERENCES member_preferences(member_id) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL,
+    channel VARCHAR(10) NOT NULL,
+    PRIMARY KEY (member_id, category, channel)
+);
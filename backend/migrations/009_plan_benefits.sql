@@ -0,0 +1,101 @@
+-- Plan-specific benefits, limitations and exclusions
+
+-- Benefits belong to a plan. A row with an empty group number applies to
+-- every group on the plan; a group's own row with the same benefit code
+-- replaces it, for employers who have bought a rider or a richer copay.
+ALTER TABLE benefits ADD COLUMN IF NOT EXISTS plan_id VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE benefits ADD COLUMN IF NOT EXISTS group_number VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE benefits ADD COLUMN IF NOT EXISTS benefit_code VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE benefits ADD COLUMN IF NOT EXISTS display_order INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_benefits_plan ON benefits (plan_id, coverage_type, group_number);
+
+-- Limitations and exclusions, in the order the plan documents list them
+CREATE TABLE IF NOT EXISTS benefit_provisions (
+    benefit_id VARCHAR(50) NOT NULL REFERENCES benefits(benefit_id) ON DELETE CASCADE,
+    kind VARCHAR(12) NOT NULL, -- LIMITATION or EXCLUSION
+    position INT NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (benefit_id, kind, position)
+);
+
+-- In-network deductibles and out-of-pocket maximums. Group rows replace the
+-- plan-wide row (empty group number) the same way benefits do.
+CREATE TABLE IF NOT EXISTS plan_cost_shares (
+    plan_id VARCHAR(50) NOT NULL,
+    group_number VARCHAR(50) NOT NULL DEFAULT '',
+    coverage_type VARCHAR(20) NOT NULL,
+    individual_deductible_cents BIGINT NOT NULL DEFAULT 0,
+    family_deductible_cents BIGINT NOT NULL DEFAULT 0,
+    individual_oop_max_cents BIGINT NOT NULL DEFAULT 0,
+    family_oop_max_cents BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (plan_id, group_number, coverage_type)
+);
+
+-- Spans carried over from member_coverages only have a plan name
+UPDATE member_coverage_spans SET plan_id = CASE plan_name
+    WHEN 'Gold PPO Plan' THEN 'GOLD-PPO'
+    WHEN 'Standard Dental' THEN 'STD-DENTAL'
+    WHEN 'Premium Vision' THEN 'PREMIUM-VISION'
+    ELSE plan_id
+END
+WHERE plan_id = '';
+
+INSERT INTO benefits (benefit_id, plan_id, group_number, benefit_code, display_order, name, description, coverage_type, is_covered)
+VALUES
+    ('GOLD-PPO-PCP', 'GOLD-PPO', '', 'PCP', 1, 'Primary Care Visit', 'Visits to your primary care physician for routine care', 'MEDICAL', TRUE),
+    ('GOLD-PPO-SPEC', 'GOLD-PPO', '', 'SPECIALIST', 2, 'Specialist Visit', 'Visits to medical specialists', 'MEDICAL', TRUE),
+    ('GOLD-PPO-ER', 'GOLD-PPO', '', 'ER', 3, 'Emergency Room', 'Emergency room visits', 'MEDICAL', TRUE),
+    ('GOLD-PPO-PREV', 'GOLD-PPO', '', 'PREVENTIVE', 4, 'Preventive Care', 'Annual checkups, immunizations, and screenings', 'MEDICAL', TRUE),
+    ('GOLD-PPO-PT', 'GOLD-PPO', '', 'PHYSICAL_THERAPY', 5, 'Physical Therapy', 'Outpatient physical therapy', 'MEDICAL', TRUE),
+    ('GOLD-PPO-COSMETIC', 'GOLD-PPO', '', 'COSMETIC', 6, 'Cosmetic Surgery', 'Surgery to improve appearance', 'MEDICAL', FALSE),
+    ('GOLD-PPO-SPEC-GRP001', 'GOLD-PPO', 'GRP-001', 'SPECIALIST', 2, 'Specialist Visit', 'Visits to medical specialists', 'MEDICAL', TRUE),
+    ('STD-DENTAL-EXAM', 'STD-DENTAL', '', 'ROUTINE_EXAM', 1, 'Routine Dental Exam', 'Dental cleanings and exams', 'DENTAL', TRUE),
+    ('STD-DENTAL-BASIC', 'STD-DENTAL', '', 'BASIC', 2, 'Basic Dental Services', 'Fillings, extractions, and basic procedures', 'DENTAL', TRUE),
+    ('STD-DENTAL-ORTHO', 'STD-DENTAL', '', 'ORTHODONTIA', 3, 'Orthodontia', 'Braces and other orthodontic treatment', 'DENTAL', FALSE),
+    ('PREMIUM-VISION-EXAM', 'PREMIUM-VISION', '', 'EYE_EXAM', 1, 'Eye Exam', 'Annual comprehensive eye examination', 'VISION', TRUE),
+    ('PREMIUM-VISION-EYEWEAR', 'PREMIUM-VISION', '', 'EYEWEAR', 2, 'Prescription Eyewear', 'Eyeglasses or contact lenses', 'VISION', TRUE)
+ON CONFLICT (benefit_id) DO NOTHING;
+
+INSERT INTO coverage_levels (benefit_id, network_type, copay_cents, coinsurance_percentage, deductible_cents, annual_limit_cents, lifetime_limit_cents)
+VALUES
+    ('GOLD-PPO-PCP', 'IN_NETWORK', 2000, 0, NULL, NULL, NULL),
+    ('GOLD-PPO-PCP', 'OUT_OF_NETWORK', NULL, 40, 50000, NULL, NULL),
+    ('GOLD-PPO-SPEC', 'IN_NETWORK', 4000, 0, NULL, NULL, NULL),
+    ('GOLD-PPO-SPEC', 'OUT_OF_NETWORK', NULL, 40, 50000, NULL, NULL),
+    ('GOLD-PPO-ER', 'IN_NETWORK', 15000, 20, NULL, NULL, NULL),
+    ('GOLD-PPO-ER', 'OUT_OF_NETWORK', 15000, 20, NULL, NULL, NULL),
+    ('GOLD-PPO-PREV', 'IN_NETWORK', 0, 0, NULL, NULL, NULL),
+    ('GOLD-PPO-PT', 'IN_NETWORK', 3000, 0, NULL, NULL, NULL),
+    ('GOLD-PPO-PT', 'OUT_OF_NETWORK', NULL, 40, 50000, NULL, NULL),
+    ('GOLD-PPO-SPEC-GRP001', 'IN_NETWORK', 3000, 0, NULL, NULL, NULL),
+    ('GOLD-PPO-SPEC-GRP001', 'OUT_OF_NETWORK', NULL, 40, 50000, NULL, NULL),
+    ('STD-DENTAL-EXAM', 'IN_NETWORK', 0, 0, NULL, NULL, NULL),
+    ('STD-DENTAL-BASIC', 'IN_NETWORK', NULL, 20, 5000, 150000, NULL),
+    ('STD-DENTAL-BASIC', 'OUT_OF_NETWORK', NULL, 40, 5000, 150000, NULL),
+    ('PREMIUM-VISION-EXAM', 'IN_NETWORK', 1000, 0, NULL, NULL, NULL),
+    ('PREMIUM-VISION-EYEWEAR', 'IN_NETWORK', NULL, 0, NULL, 15000, NULL)
+ON CONFLICT (benefit_id, network_type) DO NOTHING;
+
+INSERT INTO benefit_provisions (benefit_id, kind, position, text)
+VALUES
+    ('GOLD-PPO-ER', 'LIMITATION', 1, 'Copay waived if admitted'),
+    ('GOLD-PPO-PREV', 'LIMITATION', 1, 'One routine physical per plan year'),
+    ('GOLD-PPO-PREV', 'EXCLUSION', 1, 'Diagnostic services during a preventive visit are billed separately'),
+    ('GOLD-PPO-PT', 'LIMITATION', 1, '20 visits per plan year'),
+    ('GOLD-PPO-PT', 'LIMITATION', 2, 'Prior authorization required after 12 visits'),
+    ('GOLD-PPO-COSMETIC', 'EXCLUSION', 1, 'Not covered unless reconstructive after injury or illness'),
+    ('STD-DENTAL-EXAM', 'LIMITATION', 1, 'Two cleanings and exams per plan year'),
+    ('STD-DENTAL-BASIC', 'LIMITATION', 1, '6-month waiting period for new enrollees'),
+    ('STD-DENTAL-ORTHO', 'EXCLUSION', 1, 'Orthodontia is not covered for members age 19 and over'),
+    ('PREMIUM-VISION-EXAM', 'LIMITATION', 1, 'One exam every 12 months'),
+    ('PREMIUM-VISION-EYEWEAR', 'LIMITATION', 1, 'Frames or contacts once every 24 months'),
+    ('PREMIUM-VISION-EYEWEAR', 'EXCLUSION', 1, 'Non-prescription sunglasses')
+ON CONFLICT (benefit_id, kind, position) DO NOTHING;
+
+INSERT INTO plan_cost_shares (plan_id, group_number, coverage_type, individual_deductible_cents, family_deductible_cents, individual_oop_max_cents, family_oop_max_cents)
+VALUES
+    ('GOLD-PPO', '', 'MEDICAL', 150000, 300000, 600000, 1200000),
+    ('STD-DENTAL', '', 'DENTAL', 5000, 15000, 0, 0),
+    ('PREMIUM-VISION', '', 'VISION', 0, 0, 0, 0)
+ON CONFLICT (plan_id, group_number, coverage_type) DO NOTHING;
@@ -0,0 +1,59 @@
+-- Deductible and out-of-pocket accumulators fed by adjudicated claims
+
+-- The latest version of each claim. Totals are summed from these rows, so an
+-- adjustment or reversal replaces the claim's earlier amounts rather than
+-- adding to them. family_id is the subscriber's member ID.
+CREATE TABLE IF NOT EXISTS claim_accumulations (
+    claim_id VARCHAR(50) PRIMARY KEY,
+    version BIGINT NOT NULL,
+    member_id VARCHAR(50) NOT NULL,
+    family_id VARCHAR(50) NOT NULL,
+    coverage_type VARCHAR(20) NOT NULL,
+    network_type VARCHAR(20) NOT NULL,
+    service_date DATE NOT NULL,
+    deductible_cents BIGINT NOT NULL DEFAULT 0,
+    oop_cents BIGINT NOT NULL DEFAULT 0,
+    reversed BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_accumulations_family ON claim_accumulations (family_id, service_date);
+
+-- Out-of-network limits and how amounts accumulate. EMBEDDED gives each
+-- family member their own individual limit; AGGREGATE has only the family
+-- limit. A shared_with coverage type's amounts also count toward this one's
+-- limit, such as pharmacy toward an integrated medical out-of-pocket maximum.
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS oon_individual_deductible_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS oon_family_deductible_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS oon_individual_oop_max_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS oon_family_oop_max_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS deductible_accumulation VARCHAR(10) NOT NULL DEFAULT 'EMBEDDED';
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS oop_accumulation VARCHAR(10) NOT NULL DEFAULT 'EMBEDDED';
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS deductible_shared_with VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS oop_shared_with VARCHAR(20) NOT NULL DEFAULT '';
+
+UPDATE plan_cost_shares SET
+    oon_individual_deductible_cents = 300000,
+    oon_family_deductible_cents = 600000,
+    oon_individual_oop_max_cents = 1200000,
+    oon_family_oop_max_cents = 2400000,
+    oop_shared_with = 'PHARMACY'
+WHERE plan_id = 'GOLD-PPO' AND coverage_type = 'MEDICAL';
+
+UPDATE plan_cost_shares SET
+    oon_individual_deductible_cents = 5000,
+    oon_family_deductible_cents = 15000,
+    deductible_accumulation = 'AGGREGATE'
+WHERE plan_id = 'STD-DENTAL' AND coverage_type = 'DENTAL';
+
+-- Pharmacy has its own deductible but shares the medical out-of-pocket maximum
+UPDATE member_coverage_spans SET plan_id = 'ENHANCED-RX' WHERE plan_id = '' AND plan_name = 'Enhanced Rx';
+
+INSERT INTO plan_cost_shares (
+    plan_id, group_number, coverage_type,
+    individual_deductible_cents, family_deductible_cents, individual_oop_max_cents, family_oop_max_cents,
+    oon_individual_deductible_cents, oon_family_deductible_cents, oon_individual_oop_max_cents, oon_family_oop_max_cents,
+    oop_shared_with
+)
+VALUES ('ENHANCED-RX', '', 'PHARMACY', 10000, 30000, 600000, 1200000, 10000, 30000, 1200000, 2400000, 'MEDICAL')
+ON CONFLICT (plan_id, group_number, coverage_type) DO NOTHING;
@@ -0,0 +1,56 @@
+-- Plan years, accumulator rollover and deductible carryover
+
+-- When each plan's year starts. Plans without a row run on the calendar
+-- year. carryover_months credits deductible met in the last months of a plan
+-- year to the next; transfers_accumulators counts a member's claims under
+-- earlier plans in the same year, for mid-year plan changes.
+CREATE TABLE IF NOT EXISTS plans (
+    plan_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    plan_year_start_month INT NOT NULL DEFAULT 1 CHECK (plan_year_start_month BETWEEN 1 AND 12),
+    plan_year_start_day INT NOT NULL DEFAULT 1 CHECK (plan_year_start_day BETWEEN 1 AND 28),
+    carryover_months INT NOT NULL DEFAULT 0 CHECK (carryover_months BETWEEN 0 AND 11),
+    transfers_accumulators BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The plan years the rollover job has opened. A closed period keeps its dates
+-- even if the plan year is later redefined.
+CREATE TABLE IF NOT EXISTS accumulator_periods (
+    plan_id VARCHAR(50) NOT NULL,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    PRIMARY KEY (plan_id, period_start)
+);
+
+-- Deductible credited to a period when the one before it closed
+CREATE TABLE IF NOT EXISTS accumulator_carryovers (
+    plan_id VARCHAR(50) NOT NULL,
+    period_start DATE NOT NULL,
+    member_id VARCHAR(50) NOT NULL,
+    family_id VARCHAR(50) NOT NULL,
+    coverage_type VARCHAR(20) NOT NULL,
+    network_type VARCHAR(20) NOT NULL,
+    deductible_cents BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (plan_id, period_start, member_id, coverage_type, network_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accumulator_carryovers_family ON accumulator_carryovers (family_id, plan_id, period_start);
+
+-- The plan the claim was adjudicated under; empty when the claims system
+-- didn't say, in which case it counts toward any plan
+ALTER TABLE claim_accumulations ADD COLUMN IF NOT EXISTS plan_id VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_claim_accumulations_plan ON claim_accumulations (plan_id, service_date);
+
+INSERT INTO plans (plan_id, name, plan_year_start_month, plan_year_start_day, carryover_months)
+VALUES
+    ('GOLD-PPO', 'Gold PPO Plan', 1, 1, 3),
+    ('STD-DENTAL', 'Standard Dental', 7, 1, 0),
+    ('PREMIUM-VISION', 'Premium Vision', 1, 1, 0),
+    ('ENHANCED-RX', 'Enhanced Rx', 1, 1, 0)
+ON CONFLICT (plan_id) DO NOTHING;
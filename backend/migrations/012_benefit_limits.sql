@@ -0,0 +1,44 @@
+-- Benefit usage limits and the claim services that count against them
+
+-- Limits on how often or how much of a benefit a member can use. quantity is
+-- visits for COUNT limits and cents of plan payments for DOLLAR limits.
+-- PLAN_YEAR limits reset with the plan year, ROLLING limits count the
+-- window_months up to the day checked, and LIFETIME limits never reset.
+CREATE TABLE IF NOT EXISTS benefit_limits (
+    benefit_id VARCHAR(50) NOT NULL REFERENCES benefits(benefit_id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    kind VARCHAR(10) NOT NULL, -- COUNT or DOLLAR
+    quantity BIGINT NOT NULL,
+    limit_window VARCHAR(10) NOT NULL DEFAULT 'PLAN_YEAR', -- PLAN_YEAR, ROLLING or LIFETIME
+    window_months INT NOT NULL DEFAULT 0,
+    description TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (benefit_id, position),
+    CHECK (limit_window <> 'ROLLING' OR window_months > 0)
+);
+
+-- The services on the latest version of each claim in claim_accumulations,
+-- replaced whenever the claim is. Reversed claims have none.
+CREATE TABLE IF NOT EXISTS claim_benefit_usage (
+    claim_id VARCHAR(50) NOT NULL REFERENCES claim_accumulations(claim_id) ON DELETE CASCADE,
+    line INT NOT NULL,
+    member_id VARCHAR(50) NOT NULL,
+    plan_id VARCHAR(50) NOT NULL DEFAULT '',
+    benefit_code VARCHAR(50) NOT NULL,
+    service_date DATE NOT NULL,
+    units BIGINT NOT NULL DEFAULT 1,
+    paid_cents BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (claim_id, line)
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_benefit_usage_member ON claim_benefit_usage (member_id, benefit_code, service_date);
+
+INSERT INTO benefit_limits (benefit_id, position, kind, quantity, limit_window, window_months, description)
+VALUES
+    ('GOLD-PPO-PREV', 1, 'COUNT', 1, 'PLAN_YEAR', 0, 'One routine physical per plan year'),
+    ('GOLD-PPO-PT', 1, 'COUNT', 20, 'PLAN_YEAR', 0, '20 visits per plan year'),
+    ('STD-DENTAL-EXAM', 1, 'COUNT', 2, 'PLAN_YEAR', 0, 'Two cleanings and exams per plan year'),
+    ('STD-DENTAL-EXAM', 2, 'COUNT', 1, 'ROLLING', 6, 'One cleaning every 6 months'),
+    ('STD-DENTAL-BASIC', 1, 'DOLLAR', 150000, 'PLAN_YEAR', 0, '$1,500 annual maximum'),
+    ('PREMIUM-VISION-EXAM', 1, 'COUNT', 1, 'ROLLING', 12, 'One exam every 12 months'),
+    ('PREMIUM-VISION-EYEWEAR', 1, 'COUNT', 1, 'ROLLING', 24, 'Frames or contacts once every 24 months')
+ON CONFLICT (benefit_id, position) DO NOTHING;
@@ -0,0 +1,34 @@
+-- Plan versions imported from Summary of Benefits and Coverage documents
+
+-- Benefits and cost shares belong to the plan version effective on a date.
+-- Members get the latest version effective on or before the day asked about;
+-- the rows already loaded become each plan's first version.
+ALTER TABLE benefits ADD COLUMN IF NOT EXISTS effective_date DATE NOT NULL DEFAULT '2024-01-01';
+ALTER TABLE benefits ALTER COLUMN effective_date DROP DEFAULT;
+
+DROP INDEX IF EXISTS idx_benefits_plan;
+CREATE INDEX IF NOT EXISTS idx_benefits_plan ON benefits (plan_id, coverage_type, group_number, effective_date);
+
+ALTER TABLE plan_cost_shares ADD COLUMN IF NOT EXISTS effective_date DATE NOT NULL DEFAULT '2024-01-01';
+ALTER TABLE plan_cost_shares ALTER COLUMN effective_date DROP DEFAULT;
+ALTER TABLE plan_cost_shares DROP CONSTRAINT IF EXISTS plan_cost_shares_pkey;
+ALTER TABLE plan_cost_shares ADD PRIMARY KEY (plan_id, group_number, coverage_type, effective_date);
+
+-- One row per imported SBC. A group's version holds only the benefits the
+-- group has replaced.
+CREATE TABLE IF NOT EXISTS plan_versions (
+    plan_id VARCHAR(50) NOT NULL,
+    group_number VARCHAR(50) NOT NULL DEFAULT '',
+    coverage_type VARCHAR(20) NOT NULL,
+    effective_date DATE NOT NULL,
+    plan_name VARCHAR(100) NOT NULL,
+    imported_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (plan_id, group_number, coverage_type, effective_date)
+);
+
+INSERT INTO plan_versions (plan_id, group_number, coverage_type, effective_date, plan_name)
+SELECT DISTINCT b.plan_id, b.group_number, b.coverage_type, b.effective_date, COALESCE(NULLIF(p.name, ''), b.plan_id)
+FROM benefits b
+LEFT JOIN plans p ON p.plan_id = b.plan_id
+WHERE b.plan_id <> ''
+ON CONFLICT (plan_id, group_number, coverage_type, effective_date) DO NOTHING;
@@ -0,0 +1,46 @@
+-- Prior authorization requirements by procedure, coverage type, network and plan
+
+-- Each load of the rules file is a new version replacing the last. The
+-- benefits service answers from the latest version and reports it with
+-- every answer, so older versions are kept to explain past answers.
+CREATE TABLE IF NOT EXISTS prior_auth_rule_versions (
+    version BIGSERIAL PRIMARY KEY,
+    source TEXT NOT NULL DEFAULT '',
+    rule_count INT NOT NULL DEFAULT 0,
+    loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- An empty network or plan_id matches any. procedure_code is a CPT or HCPCS
+-- code; channel is PORTAL, ELECTRONIC, FAX or PHONE.
+CREATE TABLE IF NOT EXISTS prior_auth_rules (
+    version BIGINT NOT NULL REFERENCES prior_auth_rule_versions(version) ON DELETE CASCADE,
+    line INT NOT NULL,
+    procedure_code VARCHAR(5) NOT NULL,
+    coverage_type VARCHAR(20) NOT NULL,
+    network VARCHAR(20) NOT NULL DEFAULT '',
+    plan_id VARCHAR(50) NOT NULL DEFAULT '',
+    required BOOLEAN NOT NULL,
+    reason TEXT NOT NULL,
+    channel VARCHAR(20) NOT NULL DEFAULT '',
+    contact TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (version, line),
+    UNIQUE (version, procedure_code, coverage_type, network, plan_id)
+);
+
+INSERT INTO prior_auth_rule_versions (version, source, rule_count) VALUES
+    (1, '014_prior_auth_rules.sql', 9)
+ON CONFLICT (version) DO NOTHING;
+
+SELECT setval('prior_auth_rule_versions_version_seq', GREATEST((SELECT MAX(version) FROM prior_auth_rule_versions), 1));
+
+INSERT INTO prior_auth_rules (version, line, procedure_code, coverage_type, network, plan_id, required, reason, channel, contact) VALUES
+    (1, 1, '70551', 'MEDICAL', '', '', true, 'Advanced imaging (MRI) requires prior authorization', 'PORTAL', 'https://provider.sydneyhealth.com/prior-auth'),
+    (1, 2, '70553', 'MEDICAL', '', '', true, 'Advanced imaging (MRI) requires prior authorization', 'PORTAL', 'https://provider.sydneyhealth.com/prior-auth'),
+    (1, 3, '72148', 'MEDICAL', '', '', true, 'Advanced imaging (MRI) requires prior authorization', 'PORTAL', 'https://provider.sydneyhealth.com/prior-auth'),
+    (1, 4, '73721', 'MEDICAL', '', '', true, 'Advanced imaging (MRI) requires prior authorization', 'PORTAL', 'https://provider.sydneyhealth.com/prior-auth'),
+    (1, 5, '27447', 'MEDICAL', '', '', true, 'Inpatient joint replacement requires prior authorization', 'ELECTRONIC', 'Payer ID 60054'),
+    (1, 6, 'E0601', 'MEDICAL', '', '', true, 'CPAP equipment requires a sleep study and prior authorization', 'FAX', '1-800-555-0142'),
+    (1, 7, '97110', 'MEDICAL', 'IN_NETWORK', 'GOLD-PPO', false, 'Authorization is needed only after 12 visits in a plan year', '', ''),
+    (1, 8, '97110', 'MEDICAL', 'OUT_OF_NETWORK', '', true, 'Out-of-network therapy requires prior authorization', 'PHONE', '1-800-555-0199'),
+    (1, 9, 'D8080', 'DENTAL', '', '', true, 'Orthodontic treatment requires a pre-treatment estimate', 'PORTAL', 'https://provider.sydneyhealth.com/dental')
+ON CONFLICT (version, line) DO NOTHING;
@@ -0,0 +1,56 @@
+-- Prior authorization requests, their status history and the claims drawing on them
+
+-- status is SUBMITTED, PENDED, APPROVED, PARTIALLY_APPROVED, DENIED or
+-- EXPIRED. Approved units and dates are set once approved or partially
+-- approved. version increases with every status change.
+CREATE TABLE IF NOT EXISTS prior_authorizations (
+    authorization_id VARCHAR(50) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL,
+    coverage_type VARCHAR(20) NOT NULL,
+    procedure_code VARCHAR(5) NOT NULL,
+    diagnosis_codes TEXT[] NOT NULL DEFAULT '{}',
+    requesting_provider_id VARCHAR(10) NOT NULL DEFAULT '',
+    servicing_provider_id VARCHAR(10) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    requested_units INT NOT NULL,
+    requested_start_date DATE NOT NULL,
+    requested_end_date DATE NOT NULL,
+    approved_units INT NOT NULL DEFAULT 0,
+    approved_start_date DATE,
+    approved_end_date DATE,
+    decision_reason TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    version BIGINT NOT NULL DEFAULT 1,
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (requested_end_date >= requested_start_date),
+    CHECK (approved_units <= requested_units)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prior_authorizations_member ON prior_authorizations(member_id, submitted_at DESC);
+CREATE INDEX IF NOT EXISTS idx_prior_authorizations_approved ON prior_authorizations(member_id, procedure_code)
+    WHERE status IN ('APPROVED', 'PARTIALLY_APPROVED');
+
+-- One row per status, keyed by the version it produced
+CREATE TABLE IF NOT EXISTS prior_authorization_history (
+    authorization_id VARCHAR(50) NOT NULL REFERENCES prior_authorizations(authorization_id) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_by VARCHAR(100) NOT NULL DEFAULT '',
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (authorization_id, version)
+);
+
+-- Approved units drawn by each claim line. A line draws from one
+-- authorization; matching it again replaces its draw.
+CREATE TABLE IF NOT EXISTS prior_authorization_uses (
+    claim_id VARCHAR(50) NOT NULL,
+    line INT NOT NULL,
+    authorization_id VARCHAR(50) NOT NULL REFERENCES prior_authorizations(authorization_id) ON DELETE CASCADE,
+    units INT NOT NULL,
+    matched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (claim_id, line)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prior_authorization_uses_authorization ON prior_authorization_uses(authorization_id);
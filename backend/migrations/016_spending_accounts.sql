@@ -0,0 +1,69 @@
+-- HSA, FSA and HRA accounts, their transaction ledgers and IRS contribution limits
+
+-- account_type is HSA, FSA or HRA. FSAs and HRAs cover one plan year, with
+-- annual_amount the FSA election or HRA allowance; HSAs leave them unset.
+CREATE TABLE IF NOT EXISTS spending_accounts (
+    account_id VARCHAR(50) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL,
+    account_type VARCHAR(10) NOT NULL,
+    plan_year_start DATE,
+    plan_year_end DATE,
+    annual_amount_cents BIGINT NOT NULL DEFAULT 0,
+    family_coverage BOOLEAN NOT NULL DEFAULT FALSE,
+    catch_up_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (plan_year_end >= plan_year_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spending_accounts_member ON spending_accounts(member_id, opened_at);
+
+-- The ledger. Balances are summed from it and never stored. amount_cents is
+-- positive for contributions and negative for purchases and reimbursements.
+-- external_id is the administrator's reference, or claim:<claim_id>:<n> for
+-- the account's nth payment toward a claim, so each is posted once per account.
+CREATE TABLE IF NOT EXISTS spending_transactions (
+    transaction_id VARCHAR(50) PRIMARY KEY,
+    account_id VARCHAR(50) NOT NULL REFERENCES spending_accounts(account_id),
+    transaction_type VARCHAR(30) NOT NULL,
+    amount_cents BIGINT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    claim_id VARCHAR(50),
+    tax_year INT,
+    transaction_date DATE NOT NULL,
+    external_id VARCHAR(100) NOT NULL,
+    posted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spending_transactions_account ON spending_transactions(account_id, transaction_date DESC);
+CREATE INDEX IF NOT EXISTS idx_spending_transactions_claim ON spending_transactions(claim_id) WHERE claim_id IS NOT NULL;
+
+-- IRS limits by tax year. HSAs use the self-only or family limit plus the
+-- catch-up for holders 55 or older; FSA elections are capped at self_only_cents.
+CREATE TABLE IF NOT EXISTS spending_contribution_limits (
+    year INT NOT NULL,
+    account_type VARCHAR(10) NOT NULL,
+    self_only_cents BIGINT NOT NULL,
+    family_cents BIGINT NOT NULL DEFAULT 0,
+    catch_up_cents BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (year, account_type)
+);
+
+INSERT INTO spending_contribution_limits (year, account_type, self_only_cents, family_cents, catch_up_cents) VALUES
+    (2025, 'HSA', 430000, 855000, 100000),
+    (2026, 'HSA', 440000, 875000, 100000),
+    (2025, 'FSA', 330000, 0, 0),
+    (2026, 'FSA', 340000, 0, 0)
+ON CONFLICT (year, account_type) DO NOTHING;
+
+-- What members owe on each adjudicated claim, kept from the claim
+-- adjudication events at their latest version so it can be paid from an
+-- account. Reversed claims owe nothing.
+CREATE TABLE IF NOT EXISTS spending_claims (
+    claim_id VARCHAR(50) PRIMARY KEY,
+    version BIGINT NOT NULL,
+    member_id VARCHAR(50) NOT NULL,
+    service_date DATE NOT NULL,
+    responsibility_cents BIGINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
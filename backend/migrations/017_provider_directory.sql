@@ -0,0 +1,15 @@
+-- Provider directory columns the provider service reads, and the index its
+-- radius searches use
+
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS gender VARCHAR(10);
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS languages TEXT[];
+
+ALTER TABLE provider_locations ADD COLUMN IF NOT EXISTS fax VARCHAR(20);
+ALTER TABLE provider_locations ADD COLUMN IF NOT EXISTS office_hours TEXT[];
+
+-- Radius searches first select the locations in a latitude and longitude box
+-- around the searched point, then measure the distance to each in the
+-- service. The schema declares this index inline, which Postgres doesn't.
+CREATE INDEX IF NOT EXISTS idx_location_geo ON provider_locations(latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_provider_location ON provider_locations(provider_id);
+CREATE INDEX IF NOT EXISTS idx_provider_specialty ON provider_specialties(provider_id, specialty);
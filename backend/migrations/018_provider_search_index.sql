@@ -0,0 +1,37 @@
+-- Keeps providers.updated_at current so the provider service's search index
+-- can read just the providers changed since it last looked
+
+CREATE OR REPLACE FUNCTION touch_provider() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS providers_touch ON providers;
+CREATE TRIGGER providers_touch BEFORE UPDATE ON providers
+    FOR EACH ROW EXECUTE FUNCTION touch_provider();
+
+-- Specialties and locations are indexed with their provider, so changing
+-- them changes the provider
+CREATE OR REPLACE FUNCTION touch_parent_provider() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE providers SET updated_at = CURRENT_TIMESTAMP WHERE provider_id = OLD.provider_id;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        UPDATE providers SET updated_at = CURRENT_TIMESTAMP WHERE provider_id = NEW.provider_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS provider_specialties_touch ON provider_specialties;
+CREATE TRIGGER provider_specialties_touch AFTER INSERT OR UPDATE OR DELETE ON provider_specialties
+    FOR EACH ROW EXECUTE FUNCTION touch_parent_provider();
+
+DROP TRIGGER IF EXISTS provider_locations_touch ON provider_locations;
+CREATE TRIGGER provider_locations_touch AFTER INSERT OR UPDATE OR DELETE ON provider_locations
+    FOR EACH ROW EXECUTE FUNCTION touch_parent_provider();
+
+CREATE INDEX IF NOT EXISTS idx_providers_updated ON providers(updated_at);
@@ -0,0 +1,27 @@
+-- NUCC taxonomy codes for provider specialties. The provider service names
+-- specialties from the code; rows still without one keep their free-text
+-- specialty.
+
+ALTER TABLE provider_specialties ADD COLUMN IF NOT EXISTS taxonomy_code VARCHAR(10);
+
+CREATE INDEX IF NOT EXISTS idx_provider_specialties_taxonomy ON provider_specialties(taxonomy_code, provider_id);
+
+-- Codes for the specialty names used before codes were kept
+UPDATE provider_specialties s
+SET taxonomy_code = m.taxonomy_code
+FROM (VALUES
+    ('primary care', '207Q00000X'),
+    ('family medicine', '207Q00000X'),
+    ('internal medicine', '207R00000X'),
+    ('general practice', '208D00000X'),
+    ('cardiology', '207RC0000X'),
+    ('dermatology', '207N00000X'),
+    ('orthopedics', '207X00000X'),
+    ('orthopedic surgery', '207X00000X'),
+    ('pediatrics', '208000000X'),
+    ('ob/gyn', '207V00000X'),
+    ('obstetrics & gynecology', '207V00000X'),
+    ('psychiatry', '2084P0800X'),
+    ('neurology', '2084N0400X')
+) AS m(specialty, taxonomy_code)
+WHERE s.taxonomy_code IS NULL AND LOWER(TRIM(s.specialty)) = m.specialty;
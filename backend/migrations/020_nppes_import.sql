@@ -0,0 +1,60 @@
+-- NPI validation and the NPPES registry import
+
+-- An NPI is ten digits starting with 1 or 2, the last a Luhn check digit
+-- over the other nine prefixed with 80840, which adds 24 to their sum
+CREATE OR REPLACE FUNCTION valid_npi(npi TEXT) RETURNS BOOLEAN AS $$
+DECLARE
+    total INT := 24;
+    digit INT;
+BEGIN
+    IF npi !~ '^[12][0-9]{9}$' THEN
+        RETURN FALSE;
+    END IF;
+    -- Digits are doubled from the one before the check digit
+    FOR i IN 1..9 LOOP
+        digit := substr(npi, 10 - i, 1)::INT;
+        IF i % 2 = 1 THEN
+            digit := digit * 2;
+            IF digit > 9 THEN
+                digit := digit - 9;
+            END IF;
+        END IF;
+        total := total + digit;
+    END LOOP;
+    RETURN substr(npi, 10, 1)::INT = (10 - total % 10) % 10;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Checked on every insert and update. Providers on record before the check
+-- keep their NPIs until they are next updated; VALIDATE the constraint once
+-- the invalid ones are corrected.
+ALTER TABLE providers DROP CONSTRAINT IF EXISTS providers_npi_valid;
+ALTER TABLE providers ADD CONSTRAINT providers_npi_valid
+    CHECK (npi IS NULL OR valid_npi(npi)) NOT VALID;
+
+-- INDIVIDUAL or ORGANIZATION, from the registry's entity type code
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS entity_type VARCHAR(12);
+-- The registry's last update date of the row imported, so unchanged rows
+-- are skipped when the next month's file is imported
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS nppes_updated_on DATE;
+-- Set while the NPI is deactivated. The provider service leaves these
+-- providers out of the directory.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS npi_deactivated_on DATE;
+
+-- Checkpoints of NPPES dissemination file imports. byte_offset is the first
+-- row not yet imported, written in the same transaction as the rows before
+-- it.
+CREATE TABLE IF NOT EXISTS nppes_imports (
+    file_name VARCHAR(255) NOT NULL,
+    file_size BIGINT NOT NULL,
+    byte_offset BIGINT NOT NULL DEFAULT 0,
+    rows_read BIGINT NOT NULL DEFAULT 0,
+    rows_upserted BIGINT NOT NULL DEFAULT 0,
+    rows_unchanged BIGINT NOT NULL DEFAULT 0,
+    rows_deactivated BIGINT NOT NULL DEFAULT 0,
+    rows_invalid BIGINT NOT NULL DEFAULT 0,
+    started_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (file_name, file_size)
+);
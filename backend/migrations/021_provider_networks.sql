@@ -0,0 +1,43 @@
+-- Provider networks. Plans use networks at a tier for each coverage type,
+-- and providers join networks by contract, at one of their locations or, with
+-- no location, at all of them.
+
+CREATE TABLE IF NOT EXISTS provider_networks (
+    network_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tier 1 is the plan's preferred networks, Tier 2 the rest
+CREATE TABLE IF NOT EXISTS plan_networks (
+    plan_id VARCHAR(50) NOT NULL,
+    coverage_type VARCHAR(20) NOT NULL,
+    network_id VARCHAR(50) NOT NULL REFERENCES provider_networks(network_id),
+    tier INTEGER NOT NULL CHECK (tier IN (1, 2)),
+    effective_date DATE NOT NULL,
+    termination_date DATE,
+    PRIMARY KEY (plan_id, coverage_type, network_id, effective_date),
+    CHECK (termination_date IS NULL OR termination_date >= effective_date)
+);
+
+CREATE TABLE IF NOT EXISTS network_contracts (
+    contract_id VARCHAR(50) PRIMARY KEY,
+    network_id VARCHAR(50) NOT NULL REFERENCES provider_networks(network_id),
+    provider_id VARCHAR(50) NOT NULL REFERENCES providers(provider_id),
+    location_id VARCHAR(50) REFERENCES provider_locations(location_id) ON DELETE CASCADE,
+    effective_date DATE NOT NULL,
+    termination_date DATE,
+    covered_services TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (termination_date IS NULL OR termination_date >= effective_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_network_contracts_provider ON network_contracts(provider_id, network_id);
+CREATE INDEX IF NOT EXISTS idx_network_contracts_network ON network_contracts(network_id, effective_date);
+
+-- Contracts are indexed with their provider's locations
+DROP TRIGGER IF EXISTS network_contracts_touch ON network_contracts;
+CREATE TRIGGER network_contracts_touch AFTER INSERT OR UPDATE OR DELETE ON network_contracts
+    FOR EACH ROW EXECUTE FUNCTION touch_parent_provider();
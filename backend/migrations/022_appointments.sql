@@ -0,0 +1,119 @@
+-- Appointment scheduling. Locations offer appointments in weekly hours cut
+-- into slots; members hold a slot, then book it.
+
+-- Exclusion constraints on a provider ID and a time range
+CREATE EXTENSION IF NOT EXISTS "btree_gist";
+
+-- Locations with a time zone and hours offer appointments
+ALTER TABLE provider_locations ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
+ALTER TABLE provider_locations ADD COLUMN IF NOT EXISTS slot_minutes INT NOT NULL DEFAULT 30
+    CHECK (slot_minutes BETWEEN 5 AND 240);
+
+-- day_of_week is 0 for Sunday through 6 for Saturday, in local time. A day
+-- can have several rows, e.g. either side of a lunch break.
+CREATE TABLE IF NOT EXISTS location_hours (
+    location_id VARCHAR(50) NOT NULL REFERENCES provider_locations(location_id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    opens TIME NOT NULL,
+    closes TIME NOT NULL,
+    PRIMARY KEY (location_id, day_of_week, opens),
+    CHECK (closes > opens)
+);
+
+-- Hours are indexed with their location's provider
+CREATE OR REPLACE FUNCTION touch_location_provider() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE providers SET updated_at = CURRENT_TIMESTAMP
+        WHERE provider_id = (SELECT provider_id FROM provider_locations WHERE location_id = OLD.location_id);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        UPDATE providers SET updated_at = CURRENT_TIMESTAMP
+        WHERE provider_id = (SELECT provider_id FROM provider_locations WHERE location_id = NEW.location_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS location_hours_touch ON location_hours;
+CREATE TRIGGER location_hours_touch AFTER INSERT OR UPDATE OR DELETE ON location_hours
+    FOR EACH ROW EXECUTE FUNCTION touch_location_provider();
+
+-- Hours from office_hours lines written like "Mon-Fri: 9:00 AM - 5:00 PM" or
+-- "Sat: 9:00 AM - 1:00 PM". Lines in other forms are left for the
+-- practice to enter as hours.
+INSERT INTO location_hours (location_id, day_of_week, opens, closes)
+SELECT DISTINCT l.location_id, d.day % 7, m.parts[3]::time, m.parts[4]::time
+FROM provider_locations l
+CROSS JOIN LATERAL UNNEST(l.office_hours) AS line(text)
+CROSS JOIN LATERAL (
+    SELECT regexp_match(line.text,
+        '^\s*(Mon|Tue|Wed|Thu|Fri|Sat|Sun)(?:\s*-\s*(Mon|Tue|Wed|Thu|Fri|Sat|Sun))?:\s*(\d{1,2}:\d{2}\s*[AP]M)\s*-\s*(\d{1,2}:\d{2}\s*[AP]M)\s*$',
+        'i') AS parts
+) m
+-- Days numbered from Monday as 1 through Sunday as 7
+CROSS JOIN LATERAL (
+    SELECT array_position(ARRAY['MON', 'TUE', 'WED', 'THU', 'FRI', 'SAT', 'SUN'], UPPER(m.parts[1])) AS first,
+           array_position(ARRAY['MON', 'TUE', 'WED', 'THU', 'FRI', 'SAT', 'SUN'], UPPER(COALESCE(m.parts[2], m.parts[1]))) AS last
+) r
+CROSS JOIN LATERAL generate_series(r.first, r.last) AS d(day)
+WHERE m.parts IS NOT NULL AND m.parts[4]::time > m.parts[3]::time
+ON CONFLICT DO NOTHING;
+
+-- Locations given hours take the usual time zone of their state
+UPDATE provider_locations l
+SET time_zone = z.time_zone
+FROM (VALUES
+    ('AL', 'America/Chicago'), ('AK', 'America/Anchorage'), ('AZ', 'America/Phoenix'),
+    ('AR', 'America/Chicago'), ('CA', 'America/Los_Angeles'), ('CO', 'America/Denver'),
+    ('CT', 'America/New_York'), ('DE', 'America/New_York'), ('DC', 'America/New_York'),
+    ('FL', 'America/New_York'), ('GA', 'America/New_York'), ('HI', 'Pacific/Honolulu'),
+    ('ID', 'America/Boise'), ('IL', 'America/Chicago'), ('IN', 'America/Indiana/Indianapolis'),
+    ('IA', 'America/Chicago'), ('KS', 'America/Chicago'), ('KY', 'America/New_York'),
+    ('LA', 'America/Chicago'), ('ME', 'America/New_York'), ('MD', 'America/New_York'),
+    ('MA', 'America/New_York'), ('MI', 'America/Detroit'), ('MN', 'America/Chicago'),
+    ('MS', 'America/Chicago'), ('MO', 'America/Chicago'), ('MT', 'America/Denver'),
+    ('NE', 'America/Chicago'), ('NV', 'America/Los_Angeles'), ('NH', 'America/New_York'),
+    ('NJ', 'America/New_York'), ('NM', 'America/Denver'), ('NY', 'America/New_York'),
+    ('NC', 'America/New_York'), ('ND', 'America/Chicago'), ('OH', 'America/New_York'),
+    ('OK', 'America/Chicago'), ('OR', 'America/Los_Angeles'), ('PA', 'America/New_York'),
+    ('PR', 'America/Puerto_Rico'), ('RI', 'America/New_York'), ('SC', 'America/New_York'),
+    ('SD', 'America/Chicago'), ('TN', 'America/Chicago'), ('TX', 'America/Chicago'),
+    ('UT', 'America/Denver'), ('VT', 'America/New_York'), ('VA', 'America/New_York'),
+    ('WA', 'America/Los_Angeles'), ('WV', 'America/New_York'), ('WI', 'America/Chicago'),
+    ('WY', 'America/Denver')
+) AS z(state, time_zone)
+WHERE l.time_zone IS NULL AND UPPER(l.state) = z.state
+  AND EXISTS (SELECT 1 FROM location_hours h WHERE h.location_id = l.location_id);
+
+-- status is HELD, BOOKED, CANCELLED or EXPIRED. Holds keep their slot until
+-- hold_expires_at; after that they're treated as expired and marked so when
+-- their slot is taken again. version increases with every change.
+CREATE TABLE IF NOT EXISTS appointments (
+    appointment_id VARCHAR(50) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL,
+    provider_id VARCHAR(50) NOT NULL REFERENCES providers(provider_id),
+    location_id VARCHAR(50) NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    time_zone VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    hold_expires_at TIMESTAMPTZ,
+    visit_reason TEXT NOT NULL DEFAULT '',
+    cancellation_reason TEXT NOT NULL DEFAULT '',
+    version BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time),
+    CHECK (status <> 'HELD' OR hold_expires_at IS NOT NULL),
+    -- A provider can't have two booked or held appointments at once, however
+    -- many requests race for the slot
+    CONSTRAINT appointments_no_overlap EXCLUDE USING gist (
+        provider_id WITH =,
+        tstzrange(start_time, end_time) WITH &&
+    ) WHERE (status IN ('HELD', 'BOOKED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointments_member ON appointments(member_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_appointments_provider ON appointments(provider_id, start_time)
+    WHERE status IN ('HELD', 'BOOKED');
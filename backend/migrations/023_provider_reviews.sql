@@ -0,0 +1,45 @@
+-- Members' reviews of providers, and the totals providers' ratings are
+-- computed from
+
+-- status is PENDING while flagged reviews wait for a moderator, then
+-- APPROVED or REJECTED. flags are what the filter found: PROFANITY or PHI.
+CREATE TABLE IF NOT EXISTS provider_reviews (
+    review_id VARCHAR(50) PRIMARY KEY,
+    provider_id VARCHAR(50) NOT NULL REFERENCES providers(provider_id),
+    member_id VARCHAR(50) NOT NULL,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title VARCHAR(120) NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    flags TEXT[] NOT NULL DEFAULT '{}',
+    rejection_reason TEXT NOT NULL DEFAULT '',
+    moderated_by VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    moderated_at TIMESTAMPTZ
+);
+
+-- One pending or published review per member and provider; a member whose
+-- review was rejected can write another
+CREATE UNIQUE INDEX IF NOT EXISTS idx_provider_reviews_member
+    ON provider_reviews(member_id, provider_id) WHERE status <> 'REJECTED';
+CREATE INDEX IF NOT EXISTS idx_provider_reviews_published
+    ON provider_reviews(provider_id, created_at) WHERE status = 'APPROVED';
+CREATE INDEX IF NOT EXISTS idx_provider_reviews_pending
+    ON provider_reviews(created_at) WHERE status = 'PENDING';
+
+-- Reviews are only taken from members with a paid claim with the provider
+CREATE INDEX IF NOT EXISTS idx_claims_member_provider ON claims(member_id, provider_id)
+    WHERE status = 'PAID';
+
+-- review_count and rating_total count the provider's approved reviews and
+-- their stars, and rating is their smoothed average. The service adjusts
+-- all three as reviews are approved or rejected.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS rating_total INTEGER NOT NULL DEFAULT 0;
+
+-- Ratings loaded with providers before reviews were taken aren't backed by
+-- any review, so start providers without approved reviews from nothing
+UPDATE providers p SET rating = NULL, review_count = 0, rating_total = 0
+WHERE (p.rating IS NOT NULL OR p.review_count <> 0)
+  AND NOT EXISTS (
+      SELECT 1 FROM provider_reviews r WHERE r.provider_id = p.provider_id AND r.status = 'APPROVED'
+  );
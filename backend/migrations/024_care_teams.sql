@@ -0,0 +1,46 @@
+-- Members' care teams: a designated primary care provider and favorite
+-- providers. Recently seen providers come from claims.
+
+-- Plans, such as HMOs, that route care through a designated primary care
+-- provider
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS requires_pcp BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- termination_date is the last day the assignment is in effect, or NULL
+-- while it is open-ended. Assigning a new provider terminates the current
+-- assignment the day before the new one starts.
+CREATE TABLE IF NOT EXISTS member_pcp_assignments (
+    assignment_id VARCHAR(50) PRIMARY KEY,
+    member_id VARCHAR(50) NOT NULL,
+    provider_id VARCHAR(50) NOT NULL REFERENCES providers(provider_id),
+    location_id VARCHAR(50) NOT NULL,
+    plan_id VARCHAR(50) NOT NULL,
+    effective_date DATE NOT NULL,
+    termination_date DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (termination_date IS NULL OR termination_date >= effective_date),
+    -- A member has one primary care provider on any day, however many
+    -- assignments race
+    CONSTRAINT member_pcp_assignments_no_overlap EXCLUDE USING gist (
+        member_id WITH =,
+        daterange(effective_date, termination_date, '[]') WITH &&
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_member_pcp_assignments_member
+    ON member_pcp_assignments(member_id, effective_date);
+
+CREATE TABLE IF NOT EXISTS member_favorite_providers (
+    member_id VARCHAR(50) NOT NULL,
+    provider_id VARCHAR(50) NOT NULL REFERENCES providers(provider_id) ON DELETE CASCADE,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (member_id, provider_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_member_favorite_providers_recent
+    ON member_favorite_providers(member_id, created_at DESC);
+
+-- Recently seen providers are read from the member's claims by service date
+CREATE INDEX IF NOT EXISTS idx_claims_member_service_date ON claims(member_id, service_date)
+    WHERE provider_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Deductible carryover is summed from the previous plan year's claims when
+-- accumulators are read, so claims that arrive after the rollover still carry
+-- over. The credits the rollover job used to snapshot are no longer read.
+DROP TABLE IF EXISTS accumulator_carryovers;
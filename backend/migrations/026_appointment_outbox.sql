@@ -0,0 +1,15 @@
+-- AppointmentUpdate events waiting to be published. Each is written in the
+-- same transaction as the appointment change it reports, and the provider
+-- service publishes them in event_id order, marking each sent_at once the
+-- broker has it.
+CREATE TABLE IF NOT EXISTS appointment_outbox (
+    event_id BIGSERIAL PRIMARY KEY,
+    appointment_id VARCHAR(50) NOT NULL,
+    version BIGINT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_outbox_unsent ON appointment_outbox(event_id)
+    WHERE sent_at IS NULL;
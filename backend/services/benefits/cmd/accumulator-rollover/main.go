@@ -0,0 +1,71 @@
+// Command accumulator-rollover closes the accumulator periods of plans whose
+// plan year has ended and opens the next. Run it daily from a scheduler; a
+// second run the same day does nothing. Deductible carryover isn't credited
+// here: the benefits service sums it from the closed year's claims whenever
+// totals are read, so late claims still carry over.
+//
+//	accumulator-rollover [-date YYYY-MM-DD]
+//
+// The database is configured with the DB_* environment variables, as for the
+// benefits service. Missed runs are caught up one plan year at a time.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/services/benefits/repository"
+)
+
+var (
+	date    = flag.String("date", "", "Day to roll over to, as YYYY-MM-DD (default: today)")
+	timeout = flag.Duration("timeout", 10*time.Minute, "Time allowed for the run")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: accumulator-rollover [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	today := time.Now()
+	if *date != "" {
+		day, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -date %q: %v\n", *date, err)
+			os.Exit(2)
+		}
+		today = day
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	result, err := accumulator.Rollover(ctx, repository.NewAccumulatorStore(db), today)
+	if result != nil {
+		for _, p := range result.Closed {
+			log.Printf("Closed %s period %s to %s", p.PlanID, p.Start.Format("2006-01-02"), p.End.Format("2006-01-02"))
+		}
+		for _, p := range result.Opened {
+			log.Printf("Opened %s period %s to %s", p.PlanID, p.Start.Format("2006-01-02"), p.End.Format("2006-01-02"))
+		}
+		log.Printf("Rollover closed %d periods and opened %d",
+			len(result.Closed), len(result.Opened))
+	}
+	if err != nil {
+		log.Fatalf("Rollover failed: %v", err)
+	}
+}
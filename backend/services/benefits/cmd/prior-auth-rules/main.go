@@ -0,0 +1,79 @@
+// Command prior-auth-rules publishes a new version of the prior
+// authorization rules from a CSV file.
+//
+//	prior-auth-rules [-dry-run] rules.csv
+//
+// The file is a complete replacement for the rules in effect. Running benefits
+// services pick up the new version the next time they check, within
+// PRIOR_AUTH_RULES_REFRESH. The database is configured with the DB_*
+// environment variables, as for the benefits service.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/priorauth"
+	"github.com/sydney-health-clone/backend/services/benefits/repository"
+)
+
+var (
+	dryRun  = flag.Bool("dry-run", false, "Validate the file without publishing it")
+	timeout = flag.Duration("timeout", time.Minute, "Time allowed to publish the rules")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: prior-auth-rules [flags] rules.csv\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open rules: %v", err)
+	}
+	rules, err := priorauth.ParseCSV(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+
+	required := 0
+	for _, rule := range rules {
+		if rule.Required {
+			required++
+		}
+	}
+	log.Printf("Read %d rules from %s, %d requiring authorization", len(rules), path, required)
+	if *dryRun {
+		log.Printf("Dry run; nothing was published")
+		return
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	version, err := repository.NewPriorAuthStore(db).Publish(ctx, filepath.Base(path), rules)
+	if err != nil {
+		log.Fatalf("Failed to publish rules: %v", err)
+	}
+	log.Printf("Published prior auth rules version %d", version)
+}
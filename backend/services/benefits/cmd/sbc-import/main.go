@@ -0,0 +1,124 @@
+// Command sbc-import loads plan benefits from Summary of Benefits and
+// Coverage documents, in the JSON or CSV template, and reports how each
+// differs from the plan version on record.
+//
+//	sbc-import [-apply] [-report file] plan.json plan.csv...
+//
+// Without -apply nothing is written, so a re-import can be reviewed before
+// it is applied. Each document is a plan version effective from its
+// effective date; importing one for a date already on record replaces that
+// version. The database is configured with the DB_* environment variables,
+// as for the benefits service.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/sbc"
+	"github.com/sydney-health-clone/backend/services/benefits/repository"
+)
+
+var (
+	apply      = flag.Bool("apply", false, "Save the documents; without it the changes are only reported")
+	reportPath = flag.String("report", "", "File for the diff report (default: standard output)")
+	timeout    = flag.Duration("timeout", time.Minute, "Time allowed to import each document")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: sbc-import [flags] plan.json plan.csv...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	importer := sbc.NewImporter(repository.NewPlanVersionStore(db), *apply)
+
+	var out io.Writer = os.Stdout
+	if *reportPath != "" {
+		f, err := os.Create(*reportPath)
+		if err != nil {
+			log.Fatalf("Failed to create report: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := importFile(importer, path, out); err != nil {
+			log.Printf("Failed to import %s: %v", path, err)
+			failed = true
+		}
+	}
+	if !*apply {
+		fmt.Fprintln(out, "Nothing was written; run with -apply to save the changes")
+	}
+
+	if failed {
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// importFile parses, validates and imports one document, writing its report
+func importFile(importer *sbc.Importer, path string, out io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc *sbc.Document
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		doc, err = sbc.ParseJSON(data)
+	case ".csv":
+		doc, err = sbc.ParseCSV(data)
+	default:
+		return fmt.Errorf("unknown format; expected .json or .csv")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "== %s\n", path)
+	version, err := doc.Version()
+	if err != nil {
+		var invalid *sbc.ValidationError
+		if errors.As(err, &invalid) {
+			for _, problem := range invalid.Problems {
+				fmt.Fprintf(out, "    ! %s\n", problem)
+			}
+		}
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report, err := importer.Import(ctx, version)
+	if err != nil {
+		return err
+	}
+	_, err = report.WriteTo(out)
+	return err
+}
@@ -0,0 +1,288 @@
+// Package accumulator keeps running deductible and out-of-pocket totals and
+// benefit usage from adjudicated claims and measures them against a plan's
+// limits.
+//
+// Totals are never incremented in place. The store keeps the latest version
+// of every claim and sums them when asked, so an adjustment replaces the
+// claim's earlier amounts, a reversal zeroes them, and a redelivered event
+// changes nothing.
+package accumulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Claim adjudication actions
+const (
+	ActionAdjudicated = "ADJUDICATED"
+	ActionAdjusted    = "ADJUSTED"
+	ActionReversed    = "REVERSED"
+)
+
+// ErrInvalidAdjudication is returned for events that can't be accumulated
+var ErrInvalidAdjudication = errors.New("invalid claim adjudication")
+
+// Entry is the latest version of one claim as it counts toward accumulators
+type Entry struct {
+	ClaimID         string
+	Version         int64
+	MemberID        string
+	FamilyID        string
+	PlanID          string // empty when the claims system didn't say
+	CoverageType    pb.CoverageType
+	Network         pb.NetworkTier
+	ServiceDate     time.Time
+	DeductibleCents int64
+	OOPCents        int64
+	Reversed        bool
+	// Services are left empty for a reversed claim
+	Services []Service
+}
+
+// Service is a claim's use of one benefit
+type Service struct {
+	BenefitCode string
+	Units       int64
+	PaidCents   int64
+}
+
+// Total is what one member has accumulated for a coverage type and network
+type Total struct {
+	MemberID        string
+	FamilyID        string
+	CoverageType    pb.CoverageType
+	Network         pb.NetworkTier
+	DeductibleCents int64
+	OOPCents        int64
+}
+
+// Store keeps the latest version of each claim
+type Store interface {
+	// Record saves the entry unless the claim is already stored at the same
+	// or a later version, and reports whether it was saved
+	Record(ctx context.Context, e *Entry) (bool, error)
+	// FamilyOf returns the family a member's amounts count toward, identified
+	// by the subscriber's member ID
+	FamilyOf(ctx context.Context, memberID string) (string, error)
+	// Totals sums the family's claims with service dates from start to end
+	// inclusive, per member, coverage type and network. When planID is set,
+	// claims under other plans are left out.
+	Totals(ctx context.Context, familyID, planID string, start, end time.Time) ([]Total, error)
+	// PlanYear returns the plan's year definition, or CalendarYear when the
+	// plan has none
+	PlanYear(ctx context.Context, planID string) (PlanYear, error)
+	// FindPeriod returns the plan's recorded period containing day, or nil
+	FindPeriod(ctx context.Context, planID string, day time.Time) (*Period, error)
+	// Uses returns the member's uses of the benefit with service dates from
+	// start to end inclusive, oldest first. When planID is set, claims under
+	// other plans are left out.
+	Uses(ctx context.Context, memberID, planID, benefitCode string, start, end time.Time) ([]Use, error)
+}
+
+// Ledger applies claim events to the store and reads totals back
+type Ledger struct {
+	store Store
+}
+
+// NewLedger creates a ledger over store
+func NewLedger(store Store) *Ledger {
+	return &Ledger{store: store}
+}
+
+// Apply records a claim adjudication and reports whether it changed the
+// totals. Versions at or below the one already recorded are ignored.
+func (l *Ledger) Apply(ctx context.Context, a *kafka.ClaimAdjudication) (bool, error) {
+	e, err := entryFor(a)
+	if err != nil {
+		return false, err
+	}
+
+	e.FamilyID, err = l.store.FamilyOf(ctx, e.MemberID)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve family: %w", err)
+	}
+	return l.store.Record(ctx, e)
+}
+
+// Accumulated is what a family has accumulated toward one plan's limits in
+// one period
+type Accumulated struct {
+	Period Period
+	// Totals include carryover credits
+	Totals    []Total
+	Carryover []Total
+}
+
+// Accumulated returns what every member of the member's family accumulated
+// toward the plan in the period containing day
+func (l *Ledger) Accumulated(ctx context.Context, memberID, planID string, day time.Time) (*Accumulated, error) {
+	familyID, err := l.store.FamilyOf(ctx, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve family: %w", err)
+	}
+
+	period, onlyPlan, err := l.period(ctx, planID, day)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := l.store.Totals(ctx, familyID, onlyPlan, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	carryover, err := l.carryover(ctx, familyID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Accumulated{
+		Period:    period,
+		Totals:    append(totals, carryover...),
+		Carryover: carryover,
+	}, nil
+}
+
+// carryover is the deductible the family met in the carryover window of the
+// period before this one. It is summed from the claims on every read, like
+// the totals, so claims for the window that arrive after the rollover still
+// count.
+func (l *Ledger) carryover(ctx context.Context, familyID string, period Period) ([]Total, error) {
+	year, err := l.store.PlanYear(ctx, period.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan year: %w", err)
+	}
+
+	last := period.Start.AddDate(0, 0, -1)
+	previous, err := l.store.FindPeriod(ctx, period.PlanID, last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find previous period: %w", err)
+	}
+	if previous == nil {
+		p := year.Period(period.PlanID, last)
+		previous = &p
+	}
+	start, end, ok := year.CarryoverWindow(*previous)
+	if !ok {
+		return nil, nil
+	}
+
+	totals, err := l.store.Totals(ctx, familyID, period.PlanID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total carryover: %w", err)
+	}
+	var carryover []Total
+	for _, t := range totals {
+		if t.DeductibleCents > 0 {
+			t.OOPCents = 0
+			carryover = append(carryover, t)
+		}
+	}
+	return carryover, nil
+}
+
+// period returns the plan's period containing day, and the plan to limit
+// claims to: planID, or empty when the plan transfers accumulators
+func (l *Ledger) period(ctx context.Context, planID string, day time.Time) (Period, string, error) {
+	year, err := l.store.PlanYear(ctx, planID)
+	if err != nil {
+		return Period{}, "", fmt.Errorf("failed to load plan year: %w", err)
+	}
+	// Recorded periods win, so history keeps its dates if the plan year is redefined
+	period, err := l.store.FindPeriod(ctx, planID, day)
+	if err != nil {
+		return Period{}, "", fmt.Errorf("failed to find period: %w", err)
+	}
+	if period == nil {
+		p := year.Period(planID, day)
+		period = &p
+	}
+
+	if year.TransfersAccumulators {
+		return *period, "", nil
+	}
+	return *period, planID, nil
+}
+
+func entryFor(a *kafka.ClaimAdjudication) (*Entry, error) {
+	if a.ClaimID == "" || a.MemberID == "" || a.Version <= 0 {
+		return nil, fmt.Errorf("%w: claim_id, member_id and a positive version are required", ErrInvalidAdjudication)
+	}
+
+	coverageType, ok := coverage.ParseType(a.CoverageType)
+	if !ok || coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		return nil, fmt.Errorf("%w: unknown coverage type %q", ErrInvalidAdjudication, a.CoverageType)
+	}
+	network := ParseNetwork(a.NetworkType)
+	if network == pb.NetworkTier_NETWORK_TIER_UNSPECIFIED {
+		return nil, fmt.Errorf("%w: unknown network type %q", ErrInvalidAdjudication, a.NetworkType)
+	}
+	serviceDate, err := time.Parse("2006-01-02", a.ServiceDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: service_date must be YYYY-MM-DD", ErrInvalidAdjudication)
+	}
+	if a.DeductibleCents < 0 || a.CopayCents < 0 || a.CoinsuranceCents < 0 {
+		return nil, fmt.Errorf("%w: amounts can't be negative", ErrInvalidAdjudication)
+	}
+	for _, svc := range a.Services {
+		if svc.BenefitCode == "" || svc.Units < 0 || svc.PaidCents < 0 {
+			return nil, fmt.Errorf("%w: services need a benefit code and can't be negative", ErrInvalidAdjudication)
+		}
+	}
+
+	e := &Entry{
+		ClaimID:      a.ClaimID,
+		Version:      a.Version,
+		MemberID:     a.MemberID,
+		PlanID:       a.PlanID,
+		CoverageType: coverageType,
+		Network:      network,
+		ServiceDate:  serviceDate,
+	}
+
+	switch strings.ToUpper(a.Action) {
+	case ActionAdjudicated, ActionAdjusted:
+		e.DeductibleCents = a.DeductibleCents
+		e.OOPCents = a.DeductibleCents + a.CopayCents + a.CoinsuranceCents
+		for _, svc := range a.Services {
+			units := int64(svc.Units)
+			if units == 0 {
+				units = 1
+			}
+			e.Services = append(e.Services, Service{BenefitCode: svc.BenefitCode, Units: units, PaidCents: svc.PaidCents})
+		}
+	case ActionReversed:
+		e.Reversed = true
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidAdjudication, a.Action)
+	}
+	return e, nil
+}
+
+// NetworkName returns the short name used in storage, e.g. "IN_NETWORK"
+func NetworkName(network pb.NetworkTier) string {
+	return strings.TrimPrefix(network.String(), "NETWORK_TIER_")
+}
+
+// ParseNetwork accepts either the short or the full enum name
+func ParseNetwork(name string) pb.NetworkTier {
+	value := pb.NetworkTier_value["NETWORK_TIER_"+strings.TrimPrefix(strings.ToUpper(name), "NETWORK_TIER_")]
+	return pb.NetworkTier(value)
+}
+
+// AccumulationName returns the short name used in storage, e.g. "EMBEDDED"
+func AccumulationName(accumulation pb.FamilyAccumulation) string {
+	return strings.TrimPrefix(accumulation.String(), "FAMILY_ACCUMULATION_")
+}
+
+// ParseAccumulation accepts either the short or the full enum name
+func ParseAccumulation(name string) pb.FamilyAccumulation {
+	value := pb.FamilyAccumulation_value["FAMILY_ACCUMULATION_"+strings.TrimPrefix(strings.ToUpper(name), "FAMILY_ACCUMULATION_")]
+	return pb.FamilyAccumulation(value)
+}
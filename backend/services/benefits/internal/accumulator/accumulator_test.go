@@ -0,0 +1,299 @@
+package accumulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/kafka"
+)
+
+// familyStore is a MemoryStore whose members share a family and whose plan
+// runs on the given plan year
+type familyStore struct {
+	*MemoryStore
+	families map[string]string
+	year     PlanYear
+}
+
+func newFamilyStore(year PlanYear) *familyStore {
+	return &familyStore{
+		MemoryStore: NewMemoryStore(),
+		families:    map[string]string{"A": "F1", "B": "F1"},
+		year:        year,
+	}
+}
+
+func (s *familyStore) FamilyOf(ctx context.Context, memberID string) (string, error) {
+	return s.families[memberID], nil
+}
+
+func (s *familyStore) PlanYear(ctx context.Context, planID string) (PlanYear, error) {
+	return s.year, nil
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// claim is an in-network medical adjudication for member A under PLN-1
+func claim(id string, version int64, action, serviceDate string, deductible, copay int64) *kafka.ClaimAdjudication {
+	return &kafka.ClaimAdjudication{
+		ClaimID:         id,
+		Version:         version,
+		Action:          action,
+		MemberID:        "A",
+		PlanID:          "PLN-1",
+		CoverageType:    "MEDICAL",
+		NetworkType:     "IN_NETWORK",
+		ServiceDate:     serviceDate,
+		DeductibleCents: deductible,
+		CopayCents:      copay,
+		Services:        []kafka.ClaimService{{BenefitCode: "PT", Units: 1, PaidCents: 8000}},
+	}
+}
+
+func TestApplyKeepsLatestVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		events         []*kafka.ClaimAdjudication
+		wantChanged    []bool
+		wantDeductible int64
+		wantOOP        int64
+		wantUses       int
+	}{
+		{
+			name: "redelivered adjudication counts once",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+			},
+			wantChanged:    []bool{true, false},
+			wantDeductible: 10000,
+			wantOOP:        12500,
+			wantUses:       1,
+		},
+		{
+			name: "adjustment replaces the earlier amounts",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+				claim("C1", 2, ActionAdjusted, "2025-03-01", 4000, 2500),
+			},
+			wantChanged:    []bool{true, true},
+			wantDeductible: 4000,
+			wantOOP:        6500,
+			wantUses:       1,
+		},
+		{
+			name: "older version arriving late is ignored",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 2, ActionAdjusted, "2025-03-01", 4000, 2500),
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+			},
+			wantChanged:    []bool{true, false},
+			wantDeductible: 4000,
+			wantOOP:        6500,
+			wantUses:       1,
+		},
+		{
+			name: "reversal zeroes the claim and is idempotent",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+				claim("C1", 2, ActionReversed, "2025-03-01", 0, 0),
+				claim("C1", 2, ActionReversed, "2025-03-01", 0, 0),
+			},
+			wantChanged: []bool{true, true, false},
+		},
+		{
+			name: "reversal ignores the amounts it carries",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+				claim("C1", 2, ActionReversed, "2025-03-01", 10000, 2500),
+			},
+			wantChanged: []bool{true, true},
+		},
+		{
+			name: "claim adjudicated again after a reversal counts its new amounts",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+				claim("C1", 2, ActionReversed, "2025-03-01", 0, 0),
+				claim("C1", 3, ActionAdjudicated, "2025-03-01", 7000, 2500),
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+			},
+			wantChanged:    []bool{true, true, true, false},
+			wantDeductible: 7000,
+			wantOOP:        9500,
+			wantUses:       1,
+		},
+		{
+			name: "each claim keeps its own latest version",
+			events: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500),
+				claim("C2", 1, ActionAdjudicated, "2025-04-01", 5000, 2500),
+				claim("C1", 2, ActionReversed, "2025-03-01", 0, 0),
+			},
+			wantChanged:    []bool{true, true, true},
+			wantDeductible: 5000,
+			wantOOP:        7500,
+			wantUses:       1,
+		},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFamilyStore(CalendarYear)
+			ledger := NewLedger(store)
+			for i, event := range tt.events {
+				changed, err := ledger.Apply(ctx, event)
+				if err != nil {
+					t.Fatalf("Apply(event %d) error = %v", i, err)
+				}
+				if changed != tt.wantChanged[i] {
+					t.Errorf("Apply(event %d) changed = %v, want %v", i, changed, tt.wantChanged[i])
+				}
+			}
+
+			acc, err := ledger.Accumulated(ctx, "A", "PLN-1", day(2025, time.June, 1))
+			if err != nil {
+				t.Fatalf("Accumulated() error = %v", err)
+			}
+			var deductible, oop int64
+			for _, total := range acc.Totals {
+				deductible += total.DeductibleCents
+				oop += total.OOPCents
+			}
+			if deductible != tt.wantDeductible || oop != tt.wantOOP {
+				t.Errorf("totals = %d deductible, %d out of pocket; want %d, %d", deductible, oop, tt.wantDeductible, tt.wantOOP)
+			}
+
+			uses, err := store.Uses(ctx, "A", "PLN-1", "PT", day(2025, time.January, 1), day(2025, time.December, 31))
+			if err != nil {
+				t.Fatalf("Uses() error = %v", err)
+			}
+			if len(uses) != tt.wantUses {
+				t.Errorf("Uses() = %d uses, want %d", len(uses), tt.wantUses)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsInvalidAdjudications(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(a *kafka.ClaimAdjudication)
+	}{
+		{"missing claim ID", func(a *kafka.ClaimAdjudication) { a.ClaimID = "" }},
+		{"zero version", func(a *kafka.ClaimAdjudication) { a.Version = 0 }},
+		{"unknown action", func(a *kafka.ClaimAdjudication) { a.Action = "DENIED" }},
+		{"unknown network", func(a *kafka.ClaimAdjudication) { a.NetworkType = "TIER_2" }},
+		{"bad service date", func(a *kafka.ClaimAdjudication) { a.ServiceDate = "03/01/2025" }},
+		{"negative amount", func(a *kafka.ClaimAdjudication) { a.CopayCents = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := claim("C1", 1, ActionAdjudicated, "2025-03-01", 10000, 2500)
+			tt.mutate(a)
+			if _, err := NewLedger(newFamilyStore(CalendarYear)).Apply(context.Background(), a); err == nil {
+				t.Fatal("Apply() error = nil, want an invalid adjudication error")
+			}
+		})
+	}
+}
+
+func TestAccumulatedCarryover(t *testing.T) {
+	withCarryover := PlanYear{StartMonth: time.January, StartDay: 1, CarryoverMonths: 3, TransfersAccumulators: true}
+	julyPlanYear := PlanYear{StartMonth: time.July, StartDay: 1, CarryoverMonths: 3, TransfersAccumulators: true}
+
+	tests := []struct {
+		name           string
+		year           PlanYear
+		claims         []*kafka.ClaimAdjudication
+		on             time.Time
+		wantCarryover  int64
+		wantDeductible int64
+		wantOOP        int64
+	}{
+		{
+			name: "deductible from the last three months carries into the next year",
+			year: withCarryover,
+			claims: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2024-05-01", 30000, 2500),
+				claim("C2", 1, ActionAdjudicated, "2024-11-15", 20000, 2500),
+				claim("C3", 1, ActionAdjudicated, "2025-02-01", 5000, 2500),
+			},
+			on:             day(2025, time.March, 1),
+			wantCarryover:  20000,
+			wantDeductible: 25000,
+			wantOOP:        7500,
+		},
+		{
+			name: "window starts on its first day",
+			year: withCarryover,
+			claims: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2024-09-30", 30000, 0),
+				claim("C2", 1, ActionAdjudicated, "2024-10-01", 20000, 0),
+			},
+			on:             day(2025, time.January, 1),
+			wantCarryover:  20000,
+			wantDeductible: 20000,
+		},
+		{
+			name: "reversed claim in the window doesn't carry over",
+			year: withCarryover,
+			claims: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2024-12-01", 20000, 0),
+				claim("C1", 2, ActionReversed, "2024-12-01", 0, 0),
+			},
+			on: day(2025, time.March, 1),
+		},
+		{
+			name: "plan year starting mid-year carries over April to June",
+			year: julyPlanYear,
+			claims: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2025-03-31", 30000, 0),
+				claim("C2", 1, ActionAdjudicated, "2025-06-30", 15000, 0),
+			},
+			on:             day(2025, time.July, 1),
+			wantCarryover:  15000,
+			wantDeductible: 15000,
+		},
+		{
+			name: "plan without carryover starts from zero",
+			year: CalendarYear,
+			claims: []*kafka.ClaimAdjudication{
+				claim("C1", 1, ActionAdjudicated, "2024-12-15", 20000, 0),
+			},
+			on: day(2025, time.March, 1),
+		},
+	}
+
+	ctx := context.Background()
+	rules := Rules{InNetwork: Limits{IndividualDeductibleCents: 100000, FamilyDeductibleCents: 200000}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := NewLedger(newFamilyStore(tt.year))
+			for _, c := range tt.claims {
+				if _, err := ledger.Apply(ctx, c); err != nil {
+					t.Fatalf("Apply(%s) error = %v", c.ClaimID, err)
+				}
+			}
+
+			acc, err := ledger.Accumulated(ctx, "A", "PLN-1", tt.on)
+			if err != nil {
+				t.Fatalf("Accumulated() error = %v", err)
+			}
+			q := Query{MemberID: "A", CoverageType: medical, Network: inNetwork, FamilyCoverage: true}
+			if got := q.Carryover(acc.Carryover); got != tt.wantCarryover {
+				t.Errorf("Carryover() = %d, want %d", got, tt.wantCarryover)
+			}
+			if got := rules.Deductible(q, acc.Totals).IndividualMetCents; got != tt.wantDeductible {
+				t.Errorf("deductible met = %d, want %d", got, tt.wantDeductible)
+			}
+			if got := rules.OutOfPocket(q, acc.Totals).IndividualMetCents; got != tt.wantOOP {
+				t.Errorf("out of pocket met = %d, want %d", got, tt.wantOOP)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package accumulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+)
+
+// PlanYear defines when a plan's benefit periods start and how accumulators
+// move between them
+type PlanYear struct {
+	// StartMonth and StartDay are the first day of every plan year; January 1
+	// for a calendar-year plan
+	StartMonth time.Month
+	StartDay   int
+	// CarryoverMonths credits deductible from the last months of a plan year
+	// to the next; zero means the plan has no carryover
+	CarryoverMonths int
+	// TransfersAccumulators counts claims from a member's earlier plan in the
+	// same period, for members who change plans mid-year
+	TransfersAccumulators bool
+}
+
+// CalendarYear is the plan year of plans without a definition
+var CalendarYear = PlanYear{StartMonth: time.January, StartDay: 1, TransfersAccumulators: true}
+
+// Validate rejects start days that don't fall in every year
+func (y PlanYear) Validate() error {
+	if y.StartMonth < time.January || y.StartMonth > time.December {
+		return fmt.Errorf("plan year start month %d is out of range", y.StartMonth)
+	}
+	if y.StartDay < 1 || y.StartDay > 28 {
+		return fmt.Errorf("plan year start day %d must be between 1 and 28", y.StartDay)
+	}
+	if y.CarryoverMonths < 0 || y.CarryoverMonths > 11 {
+		return fmt.Errorf("carryover of %d months is out of range", y.CarryoverMonths)
+	}
+	return nil
+}
+
+// Period is one plan year of a plan. Both ends are inclusive days in UTC.
+type Period struct {
+	PlanID string
+	Start  time.Time
+	End    time.Time
+	Closed bool
+}
+
+// Contains reports whether day falls in the period
+func (p Period) Contains(day time.Time) bool {
+	day = coverage.Day(day)
+	return !day.Before(p.Start) && !day.After(p.End)
+}
+
+// Period returns the plan year of planID containing day
+func (y PlanYear) Period(planID string, day time.Time) Period {
+	day = coverage.Day(day)
+	start := time.Date(day.Year(), y.StartMonth, y.StartDay, 0, 0, 0, 0, time.UTC)
+	if start.After(day) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	return Period{PlanID: planID, Start: start, End: start.AddDate(1, 0, -1)}
+}
+
+// Following returns the period after p. It starts the day after p ends even
+// when the plan year has since been redefined, in which case it runs to the
+// end of the redefined plan year.
+func (y PlanYear) Following(p Period) Period {
+	start := p.End.AddDate(0, 0, 1)
+	next := y.Period(p.PlanID, start)
+	next.Start = start
+	return next
+}
+
+// CarryoverWindow is the part of p whose deductible carries into the next
+// period
+func (y PlanYear) CarryoverWindow(p Period) (time.Time, time.Time, bool) {
+	if y.CarryoverMonths == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	start := p.End.AddDate(0, 0, 1).AddDate(0, -y.CarryoverMonths, 0)
+	if start.Before(p.Start) {
+		start = p.Start
+	}
+	return start, p.End, true
+}
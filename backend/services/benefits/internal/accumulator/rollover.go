@@ -0,0 +1,80 @@
+package accumulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+)
+
+// PeriodStore records the plan years that have been opened and closed
+type PeriodStore interface {
+	// ListPlanYears returns the year definition of every plan
+	ListPlanYears(ctx context.Context) (map[string]PlanYear, error)
+	// ListOpenPeriods returns the periods not yet closed
+	ListOpenPeriods(ctx context.Context) ([]Period, error)
+	// OpenPeriod records a period, unless the plan already has one starting
+	// the same day
+	OpenPeriod(ctx context.Context, p Period) error
+	// ClosePeriod closes p and opens next, all at once
+	ClosePeriod(ctx context.Context, p, next Period) error
+}
+
+// RolloverResult is what a rollover run did
+type RolloverResult struct {
+	Closed []Period
+	Opened []Period
+}
+
+// Rollover closes every open period that ended before today, opening the
+// period after it, and opens the current period of any plan without one.
+// Running it again the same day does nothing more. Carryover isn't credited
+// here: the ledger sums it from the previous period's claims when read.
+func Rollover(ctx context.Context, store PeriodStore, today time.Time) (*RolloverResult, error) {
+	today = coverage.Day(today)
+
+	years, err := store.ListPlanYears(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plan years: %w", err)
+	}
+	open, err := store.ListOpenPeriods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open periods: %w", err)
+	}
+
+	result := &RolloverResult{}
+	current := make(map[string]bool)
+	for _, p := range open {
+		year, ok := years[p.PlanID]
+		if !ok {
+			year = CalendarYear
+		}
+
+		// Catch up one period at a time if runs were missed
+		for p.End.Before(today) {
+			next := year.Following(p)
+			if err := store.ClosePeriod(ctx, p, next); err != nil {
+				return result, fmt.Errorf("failed to close %s period starting %s: %w", p.PlanID, p.Start.Format("2006-01-02"), err)
+			}
+
+			p.Closed = true
+			result.Closed = append(result.Closed, p)
+			result.Opened = append(result.Opened, next)
+			p = next
+		}
+		current[p.PlanID] = true
+	}
+
+	for planID, year := range years {
+		if current[planID] {
+			continue
+		}
+		p := year.Period(planID, today)
+		if err := store.OpenPeriod(ctx, p); err != nil {
+			return result, fmt.Errorf("failed to open %s period: %w", planID, err)
+		}
+		result.Opened = append(result.Opened, p)
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package accumulator
+
+import (
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Limits are one network's deductibles and out-of-pocket maximums. A zero
+// limit means the plan has none.
+type Limits struct {
+	IndividualDeductibleCents int64
+	FamilyDeductibleCents     int64
+	IndividualOOPMaxCents     int64
+	FamilyOOPMaxCents         int64
+}
+
+// Rules describe how one coverage type of a plan accumulates
+type Rules struct {
+	InNetwork              Limits
+	OutOfNetwork           Limits
+	DeductibleAccumulation pb.FamilyAccumulation
+	OOPAccumulation        pb.FamilyAccumulation
+	// Coverage types whose amounts also count toward this one's deductible
+	// or out-of-pocket maximum, such as pharmacy on an integrated plan
+	DeductibleSharedWith pb.CoverageType
+	OOPSharedWith        pb.CoverageType
+}
+
+// Progress is a member's standing against one kind of limit
+type Progress struct {
+	IndividualLimitCents int64
+	IndividualMetCents   int64
+	FamilyLimitCents     int64
+	FamilyMetCents       int64
+	Accumulation         pb.FamilyAccumulation
+	SharedWith           pb.CoverageType
+}
+
+// Query picks out the totals that count toward one member's status
+type Query struct {
+	MemberID     string
+	CoverageType pb.CoverageType
+	Network      pb.NetworkTier
+	// FamilyCoverage is false for coverage tiers that cover only the
+	// subscriber, where family limits don't apply
+	FamilyCoverage bool
+}
+
+// Deductible measures the member's deductible
+func (r Rules) Deductible(q Query, totals []Total) Progress {
+	limits := r.limits(q.Network)
+	return measure(q, totals, limits.IndividualDeductibleCents, limits.FamilyDeductibleCents,
+		r.DeductibleAccumulation, r.DeductibleSharedWith,
+		func(t Total) int64 { return t.DeductibleCents })
+}
+
+// OutOfPocket measures the member's out-of-pocket maximum
+func (r Rules) OutOfPocket(q Query, totals []Total) Progress {
+	limits := r.limits(q.Network)
+	return measure(q, totals, limits.IndividualOOPMaxCents, limits.FamilyOOPMaxCents,
+		r.OOPAccumulation, r.OOPSharedWith,
+		func(t Total) int64 { return t.OOPCents })
+}
+
+// Carryover is the member's own carryover credit toward the deductible
+func (q Query) Carryover(carryover []Total) int64 {
+	var cents int64
+	for _, t := range carryover {
+		if t.MemberID == q.MemberID && t.CoverageType == q.CoverageType && t.Network == q.Network {
+			cents += t.DeductibleCents
+		}
+	}
+	return cents
+}
+
+func (r Rules) limits(network pb.NetworkTier) Limits {
+	if network == pb.NetworkTier_NETWORK_TIER_OUT_OF_NETWORK {
+		return r.OutOfNetwork
+	}
+	return r.InNetwork
+}
+
+// capped is what was met, up to the limit. A zero limit means there is
+// none, so nothing is capped.
+func capped(met, limit int64) int64 {
+	if limit > 0 {
+		return min(met, limit)
+	}
+	return met
+}
+
+func measure(q Query, totals []Total, individualLimit, familyLimit int64, accumulation pb.FamilyAccumulation, sharedWith pb.CoverageType, amount func(Total) int64) Progress {
+	if accumulation == pb.FamilyAccumulation_FAMILY_ACCUMULATION_UNSPECIFIED {
+		accumulation = pb.FamilyAccumulation_FAMILY_ACCUMULATION_EMBEDDED
+	}
+
+	byMember := make(map[string]int64)
+	for _, t := range totals {
+		if t.Network != q.Network {
+			continue
+		}
+		if t.CoverageType != q.CoverageType && (sharedWith == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED || t.CoverageType != sharedWith) {
+			continue
+		}
+		byMember[t.MemberID] += amount(t)
+	}
+	own := max(byMember[q.MemberID], 0)
+
+	p := Progress{Accumulation: accumulation, SharedWith: sharedWith}
+	if !q.FamilyCoverage {
+		p.IndividualLimitCents = individualLimit
+		p.IndividualMetCents = capped(own, individualLimit)
+		return p
+	}
+
+	var family int64
+	for _, amount := range byMember {
+		amount = max(amount, 0)
+		// An embedded individual limit also caps what the member adds to the family's
+		if accumulation == pb.FamilyAccumulation_FAMILY_ACCUMULATION_EMBEDDED {
+			amount = capped(amount, individualLimit)
+		}
+		family += amount
+	}
+	p.FamilyLimitCents = familyLimit
+	p.FamilyMetCents = capped(family, familyLimit)
+
+	if accumulation == pb.FamilyAccumulation_FAMILY_ACCUMULATION_AGGREGATE {
+		// No one meets an aggregate deductible alone; the family limit is theirs too
+		p.IndividualLimitCents = familyLimit
+		p.IndividualMetCents = p.FamilyMetCents
+		return p
+	}
+
+	p.IndividualLimitCents = individualLimit
+	p.IndividualMetCents = capped(own, individualLimit)
+	if familyLimit > 0 && individualLimit > 0 && p.FamilyMetCents >= familyLimit {
+		// Once the family limit is met, it's met for everyone
+		p.IndividualMetCents = individualLimit
+	}
+	return p
+}
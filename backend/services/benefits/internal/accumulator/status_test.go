@@ -0,0 +1,159 @@
+package accumulator
+
+import (
+	"testing"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+const (
+	medical  = pb.CoverageType_COVERAGE_TYPE_MEDICAL
+	pharmacy = pb.CoverageType_COVERAGE_TYPE_PHARMACY
+	dental   = pb.CoverageType_COVERAGE_TYPE_DENTAL
+
+	inNetwork    = pb.NetworkTier_NETWORK_TIER_IN_NETWORK
+	outOfNetwork = pb.NetworkTier_NETWORK_TIER_OUT_OF_NETWORK
+
+	embedded  = pb.FamilyAccumulation_FAMILY_ACCUMULATION_EMBEDDED
+	aggregate = pb.FamilyAccumulation_FAMILY_ACCUMULATION_AGGREGATE
+)
+
+// total is one member's in-network amounts for a coverage type
+func total(memberID string, coverageType pb.CoverageType, deductible, oop int64) Total {
+	return Total{MemberID: memberID, FamilyID: "F1", CoverageType: coverageType, Network: inNetwork, DeductibleCents: deductible, OOPCents: oop}
+}
+
+func TestDeductibleFamilyAccumulation(t *testing.T) {
+	limits := Limits{IndividualDeductibleCents: 50000, FamilyDeductibleCents: 100000}
+
+	tests := []struct {
+		name         string
+		accumulation pb.FamilyAccumulation
+		family       bool
+		member       string
+		totals       []Total
+		want         Progress
+	}{
+		{
+			name:         "embedded individual limit caps what a member adds to the family",
+			accumulation: embedded,
+			family:       true,
+			member:       "B",
+			totals:       []Total{total("A", medical, 60000, 0), total("B", medical, 10000, 0)},
+			want:         Progress{IndividualLimitCents: 50000, IndividualMetCents: 10000, FamilyLimitCents: 100000, FamilyMetCents: 60000, Accumulation: embedded},
+		},
+		{
+			name:         "embedded member meets their own limit",
+			accumulation: embedded,
+			family:       true,
+			member:       "A",
+			totals:       []Total{total("A", medical, 60000, 0), total("B", medical, 10000, 0)},
+			want:         Progress{IndividualLimitCents: 50000, IndividualMetCents: 50000, FamilyLimitCents: 100000, FamilyMetCents: 60000, Accumulation: embedded},
+		},
+		{
+			name:         "embedded family limit met is met for everyone",
+			accumulation: embedded,
+			family:       true,
+			member:       "C",
+			totals:       []Total{total("A", medical, 50000, 0), total("B", medical, 45000, 0), total("C", medical, 5000, 0)},
+			want:         Progress{IndividualLimitCents: 50000, IndividualMetCents: 50000, FamilyLimitCents: 100000, FamilyMetCents: 100000, Accumulation: embedded},
+		},
+		{
+			name:   "unspecified accumulation is embedded",
+			family: true,
+			member: "B",
+			totals: []Total{total("A", medical, 60000, 0), total("B", medical, 10000, 0)},
+			want:   Progress{IndividualLimitCents: 50000, IndividualMetCents: 10000, FamilyLimitCents: 100000, FamilyMetCents: 60000, Accumulation: embedded},
+		},
+		{
+			name:         "aggregate counts everything toward the family limit, which is the member's too",
+			accumulation: aggregate,
+			family:       true,
+			member:       "B",
+			totals:       []Total{total("A", medical, 60000, 0), total("B", medical, 10000, 0)},
+			want:         Progress{IndividualLimitCents: 100000, IndividualMetCents: 70000, FamilyLimitCents: 100000, FamilyMetCents: 70000, Accumulation: aggregate},
+		},
+		{
+			name:         "aggregate family met caps at the limit",
+			accumulation: aggregate,
+			family:       true,
+			member:       "A",
+			totals:       []Total{total("A", medical, 80000, 0), total("B", medical, 40000, 0)},
+			want:         Progress{IndividualLimitCents: 100000, IndividualMetCents: 100000, FamilyLimitCents: 100000, FamilyMetCents: 100000, Accumulation: aggregate},
+		},
+		{
+			name:         "subscriber-only coverage has no family limit",
+			accumulation: aggregate,
+			family:       false,
+			member:       "A",
+			totals:       []Total{total("A", medical, 60000, 0)},
+			want:         Progress{IndividualLimitCents: 50000, IndividualMetCents: 50000, Accumulation: aggregate},
+		},
+		{
+			name:         "other networks and coverage types don't count",
+			accumulation: embedded,
+			family:       true,
+			member:       "A",
+			totals: []Total{
+				total("A", medical, 20000, 0),
+				{MemberID: "A", FamilyID: "F1", CoverageType: medical, Network: outOfNetwork, DeductibleCents: 30000},
+				total("A", dental, 5000, 0),
+			},
+			want: Progress{IndividualLimitCents: 50000, IndividualMetCents: 20000, FamilyLimitCents: 100000, FamilyMetCents: 20000, Accumulation: embedded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := Rules{InNetwork: limits, DeductibleAccumulation: tt.accumulation}
+			q := Query{MemberID: tt.member, CoverageType: medical, Network: inNetwork, FamilyCoverage: tt.family}
+			if got := rules.Deductible(q, tt.totals); got != tt.want {
+				t.Errorf("Deductible() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutOfPocketSharedWith(t *testing.T) {
+	limits := Limits{IndividualOOPMaxCents: 300000, FamilyOOPMaxCents: 600000}
+	totals := []Total{
+		total("A", medical, 0, 100000),
+		total("A", pharmacy, 0, 50000),
+		total("A", dental, 0, 20000),
+		total("B", pharmacy, 0, 30000),
+	}
+
+	tests := []struct {
+		name         string
+		coverageType pb.CoverageType
+		sharedWith   pb.CoverageType
+		want         Progress
+	}{
+		{
+			name:         "pharmacy counts toward an integrated medical maximum",
+			coverageType: medical,
+			sharedWith:   pharmacy,
+			want:         Progress{IndividualLimitCents: 300000, IndividualMetCents: 150000, FamilyLimitCents: 600000, FamilyMetCents: 180000, Accumulation: embedded, SharedWith: pharmacy},
+		},
+		{
+			name:         "separate maximums count only their own coverage",
+			coverageType: medical,
+			want:         Progress{IndividualLimitCents: 300000, IndividualMetCents: 100000, FamilyLimitCents: 600000, FamilyMetCents: 100000, Accumulation: embedded},
+		},
+		{
+			name:         "sharing is one way",
+			coverageType: pharmacy,
+			want:         Progress{IndividualLimitCents: 300000, IndividualMetCents: 50000, FamilyLimitCents: 600000, FamilyMetCents: 80000, Accumulation: embedded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := Rules{InNetwork: limits, OOPAccumulation: embedded, OOPSharedWith: tt.sharedWith}
+			q := Query{MemberID: "A", CoverageType: tt.coverageType, Network: inNetwork, FamilyCoverage: true}
+			if got := rules.OutOfPocket(q, totals); got != tt.want {
+				t.Errorf("OutOfPocket() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package accumulator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// MemoryStore is an in-process Store for demo mode. Every member is their
+// own family and every plan runs on the calendar year.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Entry)}
+}
+
+func (s *MemoryStore) Record(ctx context.Context, e *Entry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[e.ClaimID]; ok && existing.Version >= e.Version {
+		return false, nil
+	}
+	saved := *e
+	s.entries[e.ClaimID] = &saved
+	return true, nil
+}
+
+func (s *MemoryStore) FamilyOf(ctx context.Context, memberID string) (string, error) {
+	return memberID, nil
+}
+
+func (s *MemoryStore) Totals(ctx context.Context, familyID, planID string, start, end time.Time) ([]Total, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct {
+		memberID     string
+		coverageType pb.CoverageType
+		network      pb.NetworkTier
+	}
+	byKey := make(map[key]*Total)
+	var totals []*Total
+	for _, e := range s.entries {
+		if e.FamilyID != familyID || e.ServiceDate.Before(start) || e.ServiceDate.After(end) {
+			continue
+		}
+		if planID != "" && e.PlanID != "" && e.PlanID != planID {
+			continue
+		}
+		k := key{e.MemberID, e.CoverageType, e.Network}
+		t, ok := byKey[k]
+		if !ok {
+			t = &Total{MemberID: e.MemberID, FamilyID: e.FamilyID, CoverageType: e.CoverageType, Network: e.Network}
+			byKey[k] = t
+			totals = append(totals, t)
+		}
+		t.DeductibleCents += e.DeductibleCents
+		t.OOPCents += e.OOPCents
+	}
+
+	result := make([]Total, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) PlanYear(ctx context.Context, planID string) (PlanYear, error) {
+	return CalendarYear, nil
+}
+
+func (s *MemoryStore) FindPeriod(ctx context.Context, planID string, day time.Time) (*Period, error) {
+	return nil, nil
+}
+
+func (s *MemoryStore) Uses(ctx context.Context, memberID, planID, benefitCode string, start, end time.Time) ([]Use, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var uses []Use
+	for _, e := range s.entries {
+		if e.MemberID != memberID || e.ServiceDate.Before(start) || e.ServiceDate.After(end) {
+			continue
+		}
+		if planID != "" && e.PlanID != "" && e.PlanID != planID {
+			continue
+		}
+		for _, svc := range e.Services {
+			if svc.BenefitCode == benefitCode {
+				uses = append(uses, Use{ServiceDate: e.ServiceDate, Units: svc.Units, PaidCents: svc.PaidCents})
+			}
+		}
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].ServiceDate.Before(uses[j].ServiceDate) })
+	return uses, nil
+}
@@ -0,0 +1,125 @@
+package accumulator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Use is one claim's use of a benefit
+type Use struct {
+	ServiceDate time.Time
+	Units       int64
+	PaidCents   int64
+}
+
+// Usage is a member's standing against one benefit limit. Used and Remaining
+// are visits for count limits and cents for dollar limits.
+type Usage struct {
+	Used        int64
+	Remaining   int64
+	WindowStart time.Time
+	WindowEnd   time.Time
+	// NextEligible is zero once a lifetime limit is used up
+	NextEligible time.Time
+}
+
+// Usage measures the member's use of the benefit against limit as of day
+func (l *Ledger) Usage(ctx context.Context, memberID, planID, benefitCode string, limit *pb.BenefitLimit, day time.Time) (*Usage, error) {
+	day = coverage.Day(day)
+
+	var start, end time.Time
+	onlyPlan := planID
+	switch limit.Window {
+	case pb.LimitWindow_LIMIT_WINDOW_ROLLING:
+		if limit.WindowMonths <= 0 {
+			return nil, fmt.Errorf("rolling limit on %s has no window", benefitCode)
+		}
+		start, end = day.AddDate(0, -int(limit.WindowMonths), 1), day
+	case pb.LimitWindow_LIMIT_WINDOW_LIFETIME:
+		end = day
+	default:
+		period, plan, err := l.period(ctx, planID, day)
+		if err != nil {
+			return nil, err
+		}
+		start, end, onlyPlan = period.Start, period.End, plan
+	}
+
+	// Only uses up to day count, even when the window runs past it
+	uses, err := l.store.Uses(ctx, memberID, onlyPlan, benefitCode, start, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load uses of %s: %w", benefitCode, err)
+	}
+	return MeasureUsage(limit, uses, start, end, day), nil
+}
+
+// MeasureUsage measures uses, oldest first, against limit over the window
+// from start to end
+func MeasureUsage(limit *pb.BenefitLimit, uses []Use, start, end, day time.Time) *Usage {
+	allowed := int64(limit.Count)
+	amount := func(u Use) int64 { return u.Units }
+	if limit.Kind == pb.LimitKind_LIMIT_KIND_DOLLAR {
+		allowed = limit.GetAmount().GetCents()
+		amount = func(u Use) int64 { return u.PaidCents }
+	}
+
+	var used int64
+	for _, u := range uses {
+		used += amount(u)
+	}
+
+	usage := &Usage{
+		Used:        used,
+		Remaining:   max(allowed-used, 0),
+		WindowStart: start,
+		WindowEnd:   end,
+	}
+	if usage.Remaining > 0 {
+		usage.NextEligible = day
+		return usage
+	}
+
+	switch limit.Window {
+	case pb.LimitWindow_LIMIT_WINDOW_ROLLING:
+		// Eligible again once enough of the oldest uses have rolled out of
+		// the window to leave some of the limit
+		for _, u := range uses {
+			used -= amount(u)
+			if used < allowed {
+				usage.NextEligible = coverage.Day(u.ServiceDate).AddDate(0, int(limit.WindowMonths), 0)
+				break
+			}
+		}
+	case pb.LimitWindow_LIMIT_WINDOW_LIFETIME:
+	default:
+		usage.NextEligible = end.AddDate(0, 0, 1)
+	}
+	return usage
+}
+
+// LimitKindName returns the short name used in storage, e.g. "COUNT"
+func LimitKindName(kind pb.LimitKind) string {
+	return strings.TrimPrefix(kind.String(), "LIMIT_KIND_")
+}
+
+// ParseLimitKind accepts either the short or the full enum name
+func ParseLimitKind(name string) pb.LimitKind {
+	value := pb.LimitKind_value["LIMIT_KIND_"+strings.TrimPrefix(strings.ToUpper(name), "LIMIT_KIND_")]
+	return pb.LimitKind(value)
+}
+
+// LimitWindowName returns the short name used in storage, e.g. "PLAN_YEAR"
+func LimitWindowName(window pb.LimitWindow) string {
+	return strings.TrimPrefix(window.String(), "LIMIT_WINDOW_")
+}
+
+// ParseLimitWindow accepts either the short or the full enum name
+func ParseLimitWindow(name string) pb.LimitWindow {
+	value := pb.LimitWindow_value["LIMIT_WINDOW_"+strings.TrimPrefix(strings.ToUpper(name), "LIMIT_WINDOW_")]
+	return pb.LimitWindow(value)
+}
@@ -0,0 +1,141 @@
+package accumulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+func TestMeasureUsageNextEligible(t *testing.T) {
+	rolling := &pb.BenefitLimit{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 2, Window: pb.LimitWindow_LIMIT_WINDOW_ROLLING, WindowMonths: 12}
+	rollingDollars := &pb.BenefitLimit{Kind: pb.LimitKind_LIMIT_KIND_DOLLAR, Amount: &pb.Money{Cents: 50000}, Window: pb.LimitWindow_LIMIT_WINDOW_ROLLING, WindowMonths: 12}
+	planYear := &pb.BenefitLimit{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 1, Window: pb.LimitWindow_LIMIT_WINDOW_PLAN_YEAR}
+	lifetime := &pb.BenefitLimit{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 1, Window: pb.LimitWindow_LIMIT_WINDOW_LIFETIME}
+
+	today := day(2025, time.June, 1)
+	visit := func(serviceDate time.Time, units int64) Use {
+		return Use{ServiceDate: serviceDate, Units: units, PaidCents: 20000 * units}
+	}
+
+	tests := []struct {
+		name             string
+		limit            *pb.BenefitLimit
+		uses             []Use
+		start, end       time.Time
+		wantUsed         int64
+		wantRemaining    int64
+		wantNextEligible time.Time
+	}{
+		{
+			name:             "limit left is eligible today",
+			limit:            rolling,
+			uses:             []Use{visit(day(2025, time.March, 5), 1)},
+			start:            day(2024, time.June, 2),
+			end:              today,
+			wantUsed:         1,
+			wantRemaining:    1,
+			wantNextEligible: today,
+		},
+		{
+			name:             "rolling limit frees up when the oldest use leaves the window",
+			limit:            rolling,
+			uses:             []Use{visit(day(2024, time.September, 10), 1), visit(day(2025, time.March, 5), 1)},
+			start:            day(2024, time.June, 2),
+			end:              today,
+			wantUsed:         2,
+			wantNextEligible: day(2025, time.September, 10),
+		},
+		{
+			name:             "uses over the limit all have to roll out first",
+			limit:            rolling,
+			uses:             []Use{visit(day(2024, time.August, 1), 1), visit(day(2024, time.October, 1), 1), visit(day(2025, time.January, 1), 1)},
+			start:            day(2024, time.June, 2),
+			end:              today,
+			wantUsed:         3,
+			wantNextEligible: day(2025, time.October, 1),
+		},
+		{
+			name:             "one use of several units rolls out at once",
+			limit:            rolling,
+			uses:             []Use{visit(day(2025, time.January, 10), 2)},
+			start:            day(2024, time.June, 2),
+			end:              today,
+			wantUsed:         2,
+			wantNextEligible: day(2026, time.January, 10),
+		},
+		{
+			name:             "rolling dollar limit counts what the plan paid",
+			limit:            rollingDollars,
+			uses:             []Use{visit(day(2024, time.July, 15), 1), visit(day(2024, time.December, 1), 1), visit(day(2025, time.May, 1), 1)},
+			start:            day(2024, time.June, 2),
+			end:              today,
+			wantUsed:         60000,
+			wantNextEligible: day(2025, time.July, 15),
+		},
+		{
+			name:             "plan year limit resets the day after the period",
+			limit:            planYear,
+			uses:             []Use{visit(day(2025, time.February, 1), 1)},
+			start:            day(2025, time.January, 1),
+			end:              day(2025, time.December, 31),
+			wantUsed:         1,
+			wantNextEligible: day(2026, time.January, 1),
+		},
+		{
+			name:          "used up lifetime limit never frees up",
+			limit:         lifetime,
+			uses:          []Use{visit(day(2019, time.April, 1), 1)},
+			end:           today,
+			wantUsed:      1,
+			wantRemaining: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MeasureUsage(tt.limit, tt.uses, tt.start, tt.end, today)
+			if got.Used != tt.wantUsed || got.Remaining != tt.wantRemaining {
+				t.Errorf("MeasureUsage() used %d, remaining %d; want %d, %d", got.Used, got.Remaining, tt.wantUsed, tt.wantRemaining)
+			}
+			if !got.NextEligible.Equal(tt.wantNextEligible) {
+				t.Errorf("MeasureUsage() next eligible = %s, want %s", got.NextEligible, tt.wantNextEligible)
+			}
+		})
+	}
+}
+
+func TestUsageRollingWindow(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewLedger(newFamilyStore(CalendarYear))
+	for _, c := range []*kafka.ClaimAdjudication{
+		claim("C1", 1, ActionAdjudicated, "2024-05-31", 0, 0),
+		claim("C2", 1, ActionAdjudicated, "2024-06-02", 0, 0),
+		claim("C3", 1, ActionAdjudicated, "2025-03-05", 0, 0),
+		claim("C4", 1, ActionAdjudicated, "2025-06-15", 0, 0),
+	} {
+		if _, err := ledger.Apply(ctx, c); err != nil {
+			t.Fatalf("Apply(%s) error = %v", c.ClaimID, err)
+		}
+	}
+
+	limit := &pb.BenefitLimit{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 2, Window: pb.LimitWindow_LIMIT_WINDOW_ROLLING, WindowMonths: 12}
+	got, err := ledger.Usage(ctx, "A", "PLN-1", "PT", limit, day(2025, time.June, 1))
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+
+	// The window is the 12 months ending today: the use on the day before it
+	// and the one after today don't count
+	if !got.WindowStart.Equal(day(2024, time.June, 2)) || !got.WindowEnd.Equal(day(2025, time.June, 1)) {
+		t.Errorf("Usage() window = %s to %s, want 2024-06-02 to 2025-06-01", got.WindowStart, got.WindowEnd)
+	}
+	if got.Used != 2 || got.Remaining != 0 {
+		t.Errorf("Usage() used %d, remaining %d; want 2, 0", got.Used, got.Remaining)
+	}
+	if want := day(2025, time.June, 2); !got.NextEligible.Equal(want) {
+		t.Errorf("Usage() next eligible = %s, want %s", got.NextEligible, want)
+	}
+}
@@ -0,0 +1,194 @@
+package priorauth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Store is where published rules are kept
+type Store interface {
+	// ActiveVersion is the version lookups should use, or 0 before any has
+	// been published
+	ActiveVersion(ctx context.Context) (int64, error)
+	Rules(ctx context.Context, version int64) ([]Rule, error)
+}
+
+// retryBackoff is how long the cache waits before retrying a failed load. It
+// doubles with each failure in a row, up to the ttl.
+const retryBackoff = time.Second
+
+// loadTimeout bounds one load from the store
+const loadTimeout = 10 * time.Second
+
+// Cache holds the active rules in memory, checking the store for a newer
+// version at most once per ttl. If the store can't be reached it keeps
+// answering from the version it has and backs off before trying again.
+// Loads run outside the lock, one at a time, so lookups never wait on the
+// store once a version has been loaded. A load isn't tied to the lookup that
+// started it: that caller giving up neither cancels it nor counts as a
+// failure.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu       sync.Mutex
+	current  *RuleSet
+	next     time.Time // when to check the store again
+	failures int       // failed loads in a row
+	err      error     // why the last load failed
+	loading  chan struct{}
+}
+
+// NewCache creates a cache that loads rules from store on first use
+func NewCache(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl, now: time.Now}
+}
+
+// RuleSet returns the active rules
+func (c *Cache) RuleSet(ctx context.Context) (*RuleSet, error) {
+	c.mu.Lock()
+	if c.now().Before(c.next) {
+		rs, err := c.current, c.err
+		c.mu.Unlock()
+		if rs == nil {
+			return nil, err
+		}
+		return rs, nil
+	}
+	if c.loading != nil {
+		rs, loading := c.current, c.loading
+		c.mu.Unlock()
+		if rs != nil {
+			return rs, nil
+		}
+		// Nothing to serve until the first load finishes
+		return c.wait(ctx, loading, nil)
+	}
+	loading := make(chan struct{})
+	c.loading = loading
+	current := c.current
+	c.mu.Unlock()
+
+	go c.refresh(context.WithoutCancel(ctx), current, loading)
+	return c.wait(ctx, loading, current)
+}
+
+// wait returns the outcome of the load in progress once it finishes, or
+// current if the caller gives up first
+func (c *Cache) wait(ctx context.Context, loading chan struct{}, current *RuleSet) (*RuleSet, error) {
+	select {
+	case <-loading:
+	case <-ctx.Done():
+		if current != nil {
+			return current, nil
+		}
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return nil, c.err
+	}
+	return c.current, nil
+}
+
+// refresh loads from the store with its own timeout, records the outcome and
+// wakes the callers waiting on loading
+func (c *Cache) refresh(ctx context.Context, current *RuleSet, loading chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+	rs, err := c.load(ctx, current)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(loading)
+	c.loading = nil
+
+	now := c.now()
+	if err != nil {
+		c.failures++
+		c.err = err
+		c.next = now.Add(c.backoff())
+		if c.current != nil {
+			log.Printf("Serving prior auth rules version %d: %v", c.current.Version, err)
+		}
+		return
+	}
+	c.current, c.failures, c.err = rs, 0, nil
+	c.next = now.Add(c.ttl)
+}
+
+// backoff is how long to wait after the latest failed load
+func (c *Cache) backoff() time.Duration {
+	d := retryBackoff
+	for i := 1; i < c.failures && d < c.ttl; i++ {
+		d *= 2
+	}
+	if d > c.ttl {
+		d = c.ttl
+	}
+	return d
+}
+
+// load fetches the active version unless it is current
+func (c *Cache) load(ctx context.Context, current *RuleSet) (*RuleSet, error) {
+	version, err := c.store.ActiveVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check prior auth rules version: %w", err)
+	}
+	if current != nil && current.Version == version {
+		return current, nil
+	}
+
+	rules, err := c.store.Rules(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prior auth rules version %d: %w", version, err)
+	}
+	log.Printf("Loaded prior auth rules version %d (%d rules)", version, len(rules))
+	return NewRuleSet(version, rules), nil
+}
+
+// MemoryStore is an in-process Store for demo mode
+type MemoryStore struct {
+	mu       sync.Mutex
+	versions [][]Rule
+}
+
+// NewMemoryStore creates a store with no rules published
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Publish makes rules the active version and returns its number
+func (s *MemoryStore) Publish(rules []Rule) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions = append(s.versions, append([]Rule(nil), rules...))
+	return int64(len(s.versions))
+}
+
+func (s *MemoryStore) ActiveVersion(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.versions)), nil
+}
+
+func (s *MemoryStore) Rules(ctx context.Context, version int64) ([]Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if version == 0 {
+		return nil, nil
+	}
+	if version < 0 || version > int64(len(s.versions)) {
+		return nil, fmt.Errorf("no version %d", version)
+	}
+	return s.versions[version-1], nil
+}
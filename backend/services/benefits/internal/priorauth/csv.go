@@ -0,0 +1,111 @@
+package priorauth
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/procedure"
+)
+
+// csvColumns is the header every rules file starts with
+var csvColumns = []string{"procedure_code", "coverage_type", "network", "plan_id", "required", "reason", "channel", "contact"}
+
+// ParseCSV reads a complete set of rules, one per row after the header:
+//
+//	procedure_code,coverage_type,network,plan_id,required,reason,channel,contact
+//	70551,medical,in_network,GOLD-PPO,true,Advanced imaging,portal,https://auth.example.com
+//
+// A blank network or plan_id matches any. Rules that require authorization
+// must name a channel and contact. Blank lines and # comments are skipped.
+func ParseCSV(r io.Reader) ([]Rule, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("invalid prior auth rules: file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid prior auth rules: %w", err)
+	}
+	if strings.Join(header, ",") != strings.Join(csvColumns, ",") {
+		return nil, fmt.Errorf("invalid prior auth rules: header must be %s", strings.Join(csvColumns, ","))
+	}
+
+	type key struct {
+		code         string
+		coverageType pb.CoverageType
+		network      pb.NetworkTier
+		planID       string
+	}
+	seen := make(map[key]int)
+	var rules []Rule
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid prior auth rules: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		rule, err := parseRule(row)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prior auth rules line %d: %w", line, err)
+		}
+		k := key{rule.ProcedureCode, rule.CoverageType, rule.Network, rule.PlanID}
+		if first, ok := seen[k]; ok {
+			return nil, fmt.Errorf("invalid prior auth rules line %d: same procedure, coverage, network and plan as line %d", line, first)
+		}
+		seen[k] = line
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseRule(row []string) (Rule, error) {
+	code, err := procedure.Normalize(row[0])
+	if err != nil {
+		return Rule{}, err
+	}
+	rule := Rule{
+		ProcedureCode: code,
+		PlanID:        strings.TrimSpace(row[3]),
+		Reason:        strings.TrimSpace(row[5]),
+		Contact:       strings.TrimSpace(row[7]),
+	}
+
+	var ok bool
+	if rule.CoverageType, ok = coverage.ParseType(row[1]); !ok || rule.CoverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		return Rule{}, fmt.Errorf("unknown coverage_type %q", row[1])
+	}
+	if row[2] != "" {
+		if rule.Network = accumulator.ParseNetwork(row[2]); rule.Network == pb.NetworkTier_NETWORK_TIER_UNSPECIFIED {
+			return Rule{}, fmt.Errorf("unknown network %q", row[2])
+		}
+	}
+	if rule.Required, err = strconv.ParseBool(row[4]); err != nil {
+		return Rule{}, fmt.Errorf("required must be true or false, not %q", row[4])
+	}
+	if rule.Reason == "" {
+		return Rule{}, errors.New("reason is required")
+	}
+	if row[6] != "" {
+		if rule.Channel = ParseChannel(row[6]); rule.Channel == pb.PriorAuthChannel_PRIOR_AUTH_CHANNEL_UNSPECIFIED {
+			return Rule{}, fmt.Errorf("unknown channel %q", row[6])
+		}
+	}
+	if rule.Required && (rule.Channel == pb.PriorAuthChannel_PRIOR_AUTH_CHANNEL_UNSPECIFIED || rule.Contact == "") {
+		return Rule{}, errors.New("channel and contact are required when authorization is")
+	}
+	return rule, nil
+}
@@ -0,0 +1,102 @@
+// Package priorauth answers whether a procedure needs prior authorization
+// under a member's plan.
+//
+// Rules are published in versions, each a complete replacement for the last,
+// and looked up from an in-memory copy of the active version. A procedure
+// with no rule doesn't need authorization.
+package priorauth
+
+import (
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Rule says whether a procedure needs authorization. An unspecified network
+// matches both, and an empty plan matches every plan.
+type Rule struct {
+	ProcedureCode string
+	CoverageType  pb.CoverageType
+	Network       pb.NetworkTier
+	PlanID        string
+	Required      bool
+	Reason        string
+	Channel       pb.PriorAuthChannel
+	Contact       string
+}
+
+// Query is a procedure a member is asking about
+type Query struct {
+	ProcedureCode string
+	CoverageType  pb.CoverageType
+	Network       pb.NetworkTier
+	PlanID        string
+}
+
+// RuleSet is one published version of the rules, indexed by procedure code
+type RuleSet struct {
+	Version int64
+	rules   map[string][]Rule
+}
+
+// NewRuleSet indexes rules for lookup
+func NewRuleSet(version int64, rules []Rule) *RuleSet {
+	rs := &RuleSet{Version: version, rules: make(map[string][]Rule)}
+	for _, rule := range rules {
+		rs.rules[rule.ProcedureCode] = append(rs.rules[rule.ProcedureCode], rule)
+	}
+	return rs
+}
+
+// Len is the number of rules in the set
+func (rs *RuleSet) Len() int {
+	n := 0
+	for _, rules := range rs.rules {
+		n += len(rules)
+	}
+	return n
+}
+
+// Lookup finds the rule for q. A rule for the member's plan wins over one for
+// every plan, and then a rule for the network wins over one for both.
+func (rs *RuleSet) Lookup(q Query) (Rule, bool) {
+	var (
+		best      Rule
+		bestScore = -1
+	)
+	for _, rule := range rs.rules[q.ProcedureCode] {
+		if rule.CoverageType != q.CoverageType {
+			continue
+		}
+		score := 0
+		switch rule.PlanID {
+		case q.PlanID:
+			score += 2
+		case "":
+		default:
+			continue
+		}
+		switch rule.Network {
+		case q.Network:
+			score++
+		case pb.NetworkTier_NETWORK_TIER_UNSPECIFIED:
+		default:
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// ChannelName returns the short name used in storage, e.g. "PORTAL"
+func ChannelName(channel pb.PriorAuthChannel) string {
+	return strings.TrimPrefix(channel.String(), "PRIOR_AUTH_CHANNEL_")
+}
+
+// ParseChannel accepts either the short or the full enum name
+func ParseChannel(name string) pb.PriorAuthChannel {
+	value := pb.PriorAuthChannel_value["PRIOR_AUTH_CHANNEL_"+strings.TrimPrefix(strings.ToUpper(name), "PRIOR_AUTH_CHANNEL_")]
+	return pb.PriorAuthChannel(value)
+}
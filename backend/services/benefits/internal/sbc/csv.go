@@ -0,0 +1,256 @@
+package sbc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCSV reads the CSV form of a document. The first column of each row
+// names its section; blank lines, # comments and a "section" header row are
+// skipped:
+//
+//	plan,<field>,<value>                       plan_id, group_number, plan_name, coverage_type or effective_date
+//	deductible,<network>,<individual>,<family>  network is in_network or out_of_network
+//	out_of_pocket_limit,<network>,<individual>,<family>
+//	service,<event>,<code>,<name>,<description>,
+//	    <in copay>,<in coinsurance>,<in deductible>,<in annual limit>,<in lifetime limit>,
+//	    <out copay>,<out coinsurance>,<out deductible>,<out annual limit>,<out lifetime limit>,
+//	    <limitations>,<exclusions>
+//	limit,<code>,<kind>,<count or amount>,<window>,<window months>,<description>
+//	excluded,<code>,<name>,<description>
+//
+// Amounts are dollars. Limitations and exclusions are separated by "|". A
+// service with no out-of-network columns isn't covered out of network. A
+// bare "excluded" row declares that the plan lists no excluded services.
+func ParseCSV(data []byte) (*Document, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+
+	doc := &Document{}
+	rows := &csvRows{events: make(map[string]int), limits: make(map[string][]Limit)}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SBC CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		if err := doc.addRow(row, rows); err != nil {
+			return nil, fmt.Errorf("invalid SBC CSV line %d: %w", line, err)
+		}
+	}
+
+	// Limit rows may come before or after their service
+	for i := range doc.CommonMedicalEvents {
+		services := doc.CommonMedicalEvents[i].Services
+		for j := range services {
+			code := strings.ToUpper(services[j].Code)
+			services[j].Limits = rows.limits[code]
+			delete(rows.limits, code)
+		}
+	}
+	if len(rows.limits) > 0 {
+		codes := make([]string, 0, len(rows.limits))
+		for code := range rows.limits {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		return nil, fmt.Errorf("invalid SBC CSV: limits on %s, which are not services", strings.Join(codes, ", "))
+	}
+	return doc, nil
+}
+
+// csvRows tracks what earlier rows declared
+type csvRows struct {
+	events map[string]int     // index of each medical event
+	limits map[string][]Limit // by service code
+}
+
+func (d *Document) addRow(row []string, rows *csvRows) error {
+	field := func(i int) string {
+		if i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	switch section := strings.ToLower(field(0)); section {
+	case "", "section":
+		return nil
+
+	case "plan":
+		value := field(2)
+		switch field(1) {
+		case "plan_id":
+			d.PlanID = value
+		case "group_number":
+			d.GroupNumber = value
+		case "plan_name":
+			d.PlanName = value
+		case "coverage_type":
+			d.CoverageType = value
+		case "effective_date":
+			d.EffectiveDate = value
+		default:
+			return fmt.Errorf("unknown plan field %q", field(1))
+		}
+
+	case "deductible", "out_of_pocket_limit":
+		amounts, err := parseAmounts(field(2), field(3))
+		if err != nil {
+			return err
+		}
+		if d.ImportantQuestions == nil {
+			d.ImportantQuestions = &ImportantQuestions{}
+		}
+		q := d.ImportantQuestions
+		switch network := field(1); {
+		case network == "in_network" && section == "deductible":
+			q.Deductible = amounts
+		case network == "in_network":
+			q.OutOfPocketLimit = amounts
+		case network == "out_of_network" && section == "deductible":
+			q.OutOfNetworkDeductible = amounts
+		case network == "out_of_network":
+			q.OutOfNetworkOutOfPocketLimit = amounts
+		default:
+			return fmt.Errorf("network %q is not in_network or out_of_network", network)
+		}
+
+	case "service":
+		inNetwork, err := parseCost(row, 5)
+		if err != nil {
+			return fmt.Errorf("in-network cost: %w", err)
+		}
+		outOfNetwork, err := parseCost(row, 10)
+		if err != nil {
+			return fmt.Errorf("out-of-network cost: %w", err)
+		}
+		svc := Service{
+			Code:         field(2),
+			Name:         field(3),
+			Description:  field(4),
+			InNetwork:    inNetwork,
+			OutOfNetwork: outOfNetwork,
+			Limitations:  splitList(field(15)),
+			Exclusions:   splitList(field(16)),
+		}
+
+		event := field(1)
+		i, ok := rows.events[event]
+		if !ok {
+			i = len(d.CommonMedicalEvents)
+			rows.events[event] = i
+			d.CommonMedicalEvents = append(d.CommonMedicalEvents, MedicalEvent{Event: event})
+		}
+		d.CommonMedicalEvents[i].Services = append(d.CommonMedicalEvents[i].Services, svc)
+
+	case "limit":
+		limit := Limit{Kind: field(2), Window: field(4), Description: field(6)}
+		if strings.EqualFold(limit.Kind, "DOLLAR") {
+			cents, err := ParseDollars(field(3))
+			if err != nil {
+				return err
+			}
+			amount := Dollars(cents)
+			limit.Amount = &amount
+		} else {
+			count, err := strconv.Atoi(field(3))
+			if err != nil {
+				return fmt.Errorf("invalid limit count %q", field(3))
+			}
+			limit.Count = int32(count)
+		}
+		if months := field(5); months != "" {
+			n, err := strconv.Atoi(months)
+			if err != nil {
+				return fmt.Errorf("invalid window months %q", months)
+			}
+			limit.WindowMonths = int32(n)
+		}
+		code := strings.ToUpper(field(1))
+		rows.limits[code] = append(rows.limits[code], limit)
+
+	case "excluded":
+		if d.ExcludedServices == nil {
+			d.ExcludedServices = []ExcludedService{}
+		}
+		if field(1) != "" || field(2) != "" {
+			d.ExcludedServices = append(d.ExcludedServices, ExcludedService{Code: field(1), Name: field(2), Description: field(3)})
+		}
+
+	default:
+		return fmt.Errorf("unknown section %q", field(0))
+	}
+	return nil
+}
+
+func parseAmounts(individual, family string) (*Amounts, error) {
+	i, err := ParseDollars(individual)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseDollars(family)
+	if err != nil {
+		return nil, err
+	}
+	return &Amounts{Individual: Dollars(i), Family: Dollars(f)}, nil
+}
+
+// parseCost reads the five cost columns starting at from; nil when all are
+// empty
+func parseCost(row []string, from int) (*Cost, error) {
+	cols := make([]string, 5)
+	empty := true
+	for i := range cols {
+		if from+i < len(row) {
+			cols[i] = strings.TrimSpace(row[from+i])
+		}
+		if cols[i] != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return nil, nil
+	}
+
+	cost := &Cost{}
+	for i, dest := range []**Dollars{&cost.Copay, nil, &cost.Deductible, &cost.AnnualLimit, &cost.LifetimeLimit} {
+		if dest == nil || cols[i] == "" {
+			continue
+		}
+		cents, err := ParseDollars(cols[i])
+		if err != nil {
+			return nil, err
+		}
+		amount := Dollars(cents)
+		*dest = &amount
+	}
+	if cols[1] != "" {
+		percent, err := strconv.Atoi(strings.TrimSuffix(cols[1], "%"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid coinsurance %q", cols[1])
+		}
+		cost.Coinsurance = int32(percent)
+	}
+	return cost, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
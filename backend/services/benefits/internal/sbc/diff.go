@@ -0,0 +1,157 @@
+package sbc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Diff describes, line by line, how next differs from prev. prev may be nil
+// when the plan has no earlier version.
+func Diff(prev, next *Version) []string {
+	var diff []string
+	if prev == nil {
+		diff = append(diff, fmt.Sprintf("add plan %s %q", next.PlanID, next.PlanName))
+		prev = &Version{}
+	} else if prev.PlanName != next.PlanName {
+		diff = append(diff, fmt.Sprintf("plan_name: %q -> %q", prev.PlanName, next.PlanName))
+	}
+
+	diff = append(diff, diffLimits("in_network", prev.InNetwork, next.InNetwork)...)
+	diff = append(diff, diffLimits("out_of_network", prev.OutOfNetwork, next.OutOfNetwork)...)
+
+	before := make(map[string]*pb.Benefit)
+	for _, b := range prev.Benefits {
+		before[b.BenefitCode] = b
+	}
+	seen := make(map[string]bool)
+	for _, b := range next.Benefits {
+		seen[b.BenefitCode] = true
+		old, ok := before[b.BenefitCode]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("add benefit %s %q%s", b.BenefitCode, b.Name, notCovered(b)))
+			continue
+		}
+		if changes := diffBenefit(old, b); len(changes) > 0 {
+			diff = append(diff, fmt.Sprintf("change benefit %s: %s", b.BenefitCode, strings.Join(changes, ", ")))
+		}
+	}
+	for _, b := range prev.Benefits {
+		if !seen[b.BenefitCode] {
+			diff = append(diff, fmt.Sprintf("remove benefit %s %q", b.BenefitCode, b.Name))
+		}
+	}
+	return diff
+}
+
+func diffLimits(network string, prev, next accumulator.Limits) []string {
+	var diff []string
+	for _, f := range []struct {
+		name       string
+		prev, next int64
+	}{
+		{"individual_deductible", prev.IndividualDeductibleCents, next.IndividualDeductibleCents},
+		{"family_deductible", prev.FamilyDeductibleCents, next.FamilyDeductibleCents},
+		{"individual_out_of_pocket_limit", prev.IndividualOOPMaxCents, next.IndividualOOPMaxCents},
+		{"family_out_of_pocket_limit", prev.FamilyOOPMaxCents, next.FamilyOOPMaxCents},
+	} {
+		if f.prev != f.next {
+			diff = append(diff, fmt.Sprintf("%s %s: %s -> %s", network, f.name, dollars(f.prev), dollars(f.next)))
+		}
+	}
+	return diff
+}
+
+func diffBenefit(prev, next *pb.Benefit) []string {
+	var changes []string
+	if prev.Name != next.Name {
+		changes = append(changes, fmt.Sprintf("name %q -> %q", prev.Name, next.Name))
+	}
+	if prev.Description != next.Description {
+		changes = append(changes, "description")
+	}
+	if prev.IsCovered != next.IsCovered {
+		changes = append(changes, fmt.Sprintf("covered %t -> %t", prev.IsCovered, next.IsCovered))
+	}
+	changes = append(changes, diffLevel("in_network", prev.InNetwork, next.InNetwork)...)
+	changes = append(changes, diffLevel("out_of_network", prev.OutOfNetwork, next.OutOfNetwork)...)
+	if strings.Join(prev.Limitations, "\n") != strings.Join(next.Limitations, "\n") {
+		changes = append(changes, "limitations")
+	}
+	if strings.Join(prev.Exclusions, "\n") != strings.Join(next.Exclusions, "\n") {
+		changes = append(changes, "exclusions")
+	}
+	if !equalLimits(prev.Limits, next.Limits) {
+		changes = append(changes, "limits")
+	}
+	return changes
+}
+
+func diffLevel(network string, prev, next *pb.CoverageLevel) []string {
+	switch {
+	case prev == nil && next == nil:
+		return nil
+	case prev == nil:
+		return []string{network + " now covered"}
+	case next == nil:
+		return []string{network + " no longer covered"}
+	}
+
+	var changes []string
+	for _, f := range []struct {
+		name       string
+		prev, next *pb.Money
+	}{
+		{"copay", prev.Copay, next.Copay},
+		{"deductible", prev.Deductible, next.Deductible},
+		{"annual_limit", prev.AnnualLimit, next.AnnualLimit},
+		{"lifetime_limit", prev.LifetimeLimit, next.LifetimeLimit},
+	} {
+		if !proto.Equal(f.prev, f.next) {
+			changes = append(changes, fmt.Sprintf("%s %s %s -> %s", network, f.name, money(f.prev), money(f.next)))
+		}
+	}
+	if prev.CoinsurancePercentage != next.CoinsurancePercentage {
+		changes = append(changes, fmt.Sprintf("%s coinsurance %d%% -> %d%%", network, prev.CoinsurancePercentage, next.CoinsurancePercentage))
+	}
+	return changes
+}
+
+func equalLimits(prev, next []*pb.BenefitLimit) bool {
+	if len(prev) != len(next) {
+		return false
+	}
+	for i := range prev {
+		if !proto.Equal(prev[i], next[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func notCovered(b *pb.Benefit) string {
+	if b.IsCovered {
+		return ""
+	}
+	return " (not covered)"
+}
+
+func money(m *pb.Money) string {
+	if m == nil {
+		return "none"
+	}
+	return dollars(m.Cents)
+}
+
+// dollars formats cents as "$1,500.00"
+func dollars(cents int64) string {
+	whole := strconv.FormatInt(cents/100, 10)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("$%s.%02d", whole, cents%100)
+}
@@ -0,0 +1,356 @@
+// Package sbc imports plan benefits from Summary of Benefits and Coverage
+// documents.
+//
+// Documents are structured templates derived from the CMS SBC layout: the
+// "Important Questions" deductibles and out-of-pocket limits, the services
+// under each "Common Medical Event" with what the member pays in and out of
+// network, and the "Services Your Plan Generally Does NOT Cover". They come
+// as JSON or as the equivalent CSV. Each import is one version of a plan,
+// effective from its effective date.
+package sbc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Document is one SBC for one coverage type of a plan
+type Document struct {
+	PlanID        string `json:"plan_id"`
+	GroupNumber   string `json:"group_number"` // empty for every group on the plan
+	PlanName      string `json:"plan_name"`
+	CoverageType  string `json:"coverage_type"`
+	EffectiveDate string `json:"effective_date"` // YYYY-MM-DD
+
+	ImportantQuestions  *ImportantQuestions `json:"important_questions"`
+	CommonMedicalEvents []MedicalEvent      `json:"common_medical_events"`
+	ExcludedServices    []ExcludedService   `json:"excluded_services"`
+}
+
+// ImportantQuestions holds the plan's deductibles and out-of-pocket limits
+type ImportantQuestions struct {
+	Deductible       *Amounts `json:"deductible"`
+	OutOfPocketLimit *Amounts `json:"out_of_pocket_limit"`
+	// Out-of-network amounts, for plans that cover out-of-network care
+	OutOfNetworkDeductible       *Amounts `json:"out_of_network_deductible,omitempty"`
+	OutOfNetworkOutOfPocketLimit *Amounts `json:"out_of_network_out_of_pocket_limit,omitempty"`
+}
+
+// Amounts are an individual and a family limit
+type Amounts struct {
+	Individual Dollars `json:"individual"`
+	Family     Dollars `json:"family"`
+}
+
+// MedicalEvent groups the services a member might need in one situation,
+// such as "If you visit a health care provider's office or clinic"
+type MedicalEvent struct {
+	Event    string    `json:"event"`
+	Services []Service `json:"services"`
+}
+
+// Service is one row of a medical event: a benefit and what it costs
+type Service struct {
+	Code         string   `json:"code"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	InNetwork    *Cost    `json:"in_network"`
+	OutOfNetwork *Cost    `json:"out_of_network,omitempty"` // unset when not covered out of network
+	Limitations  []string `json:"limitations,omitempty"`
+	Exclusions   []string `json:"exclusions,omitempty"`
+	Limits       []Limit  `json:"limits,omitempty"`
+}
+
+// Cost is what the member pays for a service in one network
+type Cost struct {
+	Copay         *Dollars `json:"copay,omitempty"`
+	Coinsurance   int32    `json:"coinsurance,omitempty"` // percent
+	Deductible    *Dollars `json:"deductible,omitempty"`
+	AnnualLimit   *Dollars `json:"annual_limit,omitempty"`
+	LifetimeLimit *Dollars `json:"lifetime_limit,omitempty"`
+}
+
+// Limit is a usage limit on a service, e.g. 20 visits per plan year
+type Limit struct {
+	Kind         string   `json:"kind"` // COUNT or DOLLAR
+	Count        int32    `json:"count,omitempty"`
+	Amount       *Dollars `json:"amount,omitempty"`
+	Window       string   `json:"window"` // PLAN_YEAR, ROLLING or LIFETIME
+	WindowMonths int32    `json:"window_months,omitempty"`
+	Description  string   `json:"description,omitempty"`
+}
+
+// ExcludedService is a service the plan doesn't cover
+type ExcludedService struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Dollars is an amount in cents, written in documents as a number of
+// dollars or a string such as "$1,500.00"
+type Dollars int64
+
+// UnmarshalJSON accepts a number or a dollar string
+func (d *Dollars) UnmarshalJSON(data []byte) error {
+	s := string(bytes.TrimSpace(data))
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	cents, err := ParseDollars(s)
+	if err != nil {
+		return err
+	}
+	*d = Dollars(cents)
+	return nil
+}
+
+// ParseDollars reads "$1,500", "1500.00" or "20" as cents
+func ParseDollars(s string) (int64, error) {
+	clean := strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	value, err := strconv.ParseFloat(clean, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid dollar amount %q", s)
+	}
+	return int64(math.Round(value * 100)), nil
+}
+
+// ParseJSON reads a JSON document
+func ParseJSON(data []byte) (*Document, error) {
+	var doc Document
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid SBC document: %w", err)
+	}
+	return &doc, nil
+}
+
+// ValidationError lists everything wrong with a document
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid SBC document: " + strings.Join(e.Problems, "; ")
+}
+
+// Version is a validated document as it maps to plan benefits
+type Version struct {
+	PlanID        string
+	GroupNumber   string
+	PlanName      string
+	CoverageType  pb.CoverageType
+	EffectiveDate time.Time
+	InNetwork     accumulator.Limits
+	OutOfNetwork  accumulator.Limits
+	// Benefits carry their benefit code, in display order; the store assigns
+	// benefit IDs
+	Benefits []*pb.Benefit
+}
+
+// Version validates the document's required sections and maps it to a plan
+// version. Every problem found is reported, not just the first.
+func (d *Document) Version() (*Version, error) {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	v := &Version{
+		PlanID:      strings.TrimSpace(d.PlanID),
+		GroupNumber: strings.TrimSpace(d.GroupNumber),
+		PlanName:    strings.TrimSpace(d.PlanName),
+	}
+	if v.PlanID == "" {
+		problem("plan_id is required")
+	}
+	if v.PlanName == "" {
+		problem("plan_name is required")
+	}
+	coverageType, ok := coverage.ParseType(d.CoverageType)
+	if !ok || coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		problem("coverage_type %q is not MEDICAL, DENTAL, VISION or PHARMACY", d.CoverageType)
+	}
+	v.CoverageType = coverageType
+	effective, err := time.Parse("2006-01-02", d.EffectiveDate)
+	if err != nil {
+		problem("effective_date must be YYYY-MM-DD")
+	}
+	v.EffectiveDate = effective
+
+	if q := d.ImportantQuestions; q == nil {
+		problem("important_questions section is missing")
+	} else {
+		if q.Deductible == nil {
+			problem("important_questions.deductible is required")
+		} else {
+			v.InNetwork.IndividualDeductibleCents = int64(q.Deductible.Individual)
+			v.InNetwork.FamilyDeductibleCents = int64(q.Deductible.Family)
+		}
+		if q.OutOfPocketLimit == nil {
+			problem("important_questions.out_of_pocket_limit is required")
+		} else {
+			v.InNetwork.IndividualOOPMaxCents = int64(q.OutOfPocketLimit.Individual)
+			v.InNetwork.FamilyOOPMaxCents = int64(q.OutOfPocketLimit.Family)
+		}
+		if a := q.OutOfNetworkDeductible; a != nil {
+			v.OutOfNetwork.IndividualDeductibleCents = int64(a.Individual)
+			v.OutOfNetwork.FamilyDeductibleCents = int64(a.Family)
+		}
+		if a := q.OutOfNetworkOutOfPocketLimit; a != nil {
+			v.OutOfNetwork.IndividualOOPMaxCents = int64(a.Individual)
+			v.OutOfNetwork.FamilyOOPMaxCents = int64(a.Family)
+		}
+	}
+
+	if len(d.CommonMedicalEvents) == 0 {
+		problem("common_medical_events section is missing")
+	}
+	// An empty list is allowed, but the section must be there
+	if d.ExcludedServices == nil {
+		problem("excluded_services section is missing")
+	}
+
+	codes := make(map[string]bool)
+	addCode := func(code, where string) bool {
+		if code == "" {
+			problem("%s: code is required", where)
+			return false
+		}
+		// Benefit IDs are built from the code and must fit in 50 characters
+		if len(code) > 30 {
+			problem("%s: code %s is longer than 30 characters", where, code)
+			return false
+		}
+		if codes[code] {
+			problem("%s: code %s is listed more than once", where, code)
+			return false
+		}
+		codes[code] = true
+		return true
+	}
+
+	for i, event := range d.CommonMedicalEvents {
+		if len(event.Services) == 0 {
+			problem("common_medical_events[%d] has no services", i)
+		}
+		for j, svc := range event.Services {
+			where := fmt.Sprintf("common_medical_events[%d].services[%d]", i, j)
+			code := strings.ToUpper(strings.TrimSpace(svc.Code))
+			if !addCode(code, where) {
+				continue
+			}
+			if strings.TrimSpace(svc.Name) == "" {
+				problem("%s: name is required", where)
+			}
+			if svc.InNetwork == nil {
+				problem("%s: in_network cost is required", where)
+			}
+
+			benefit := &pb.Benefit{
+				BenefitCode:  code,
+				Name:         strings.TrimSpace(svc.Name),
+				Description:  strings.TrimSpace(svc.Description),
+				CoverageType: coverageType,
+				IsCovered:    true,
+				InNetwork:    svc.InNetwork.level(where+".in_network", problem),
+				OutOfNetwork: svc.OutOfNetwork.level(where+".out_of_network", problem),
+				Limitations:  svc.Limitations,
+				Exclusions:   svc.Exclusions,
+			}
+			for k, limit := range svc.Limits {
+				benefit.Limits = append(benefit.Limits, limit.limit(fmt.Sprintf("%s.limits[%d]", where, k), problem))
+			}
+			v.Benefits = append(v.Benefits, benefit)
+		}
+	}
+
+	for i, svc := range d.ExcludedServices {
+		where := fmt.Sprintf("excluded_services[%d]", i)
+		code := strings.ToUpper(strings.TrimSpace(svc.Code))
+		if !addCode(code, where) {
+			continue
+		}
+		if strings.TrimSpace(svc.Name) == "" {
+			problem("%s: name is required", where)
+		}
+		v.Benefits = append(v.Benefits, &pb.Benefit{
+			BenefitCode:  code,
+			Name:         strings.TrimSpace(svc.Name),
+			Description:  strings.TrimSpace(svc.Description),
+			CoverageType: coverageType,
+			IsCovered:    false,
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return v, nil
+}
+
+func (c *Cost) level(where string, problem func(string, ...interface{})) *pb.CoverageLevel {
+	if c == nil {
+		return nil
+	}
+	if c.Coinsurance < 0 || c.Coinsurance > 100 {
+		problem("%s: coinsurance %d is not a percentage", where, c.Coinsurance)
+	}
+	return &pb.CoverageLevel{
+		Copay:                 c.Copay.money(),
+		CoinsurancePercentage: c.Coinsurance,
+		Deductible:            c.Deductible.money(),
+		AnnualLimit:           c.AnnualLimit.money(),
+		LifetimeLimit:         c.LifetimeLimit.money(),
+	}
+}
+
+func (l Limit) limit(where string, problem func(string, ...interface{})) *pb.BenefitLimit {
+	limit := &pb.BenefitLimit{
+		Kind:         accumulator.ParseLimitKind(l.Kind),
+		Count:        l.Count,
+		Amount:       l.Amount.money(),
+		Window:       accumulator.ParseLimitWindow(l.Window),
+		WindowMonths: l.WindowMonths,
+		Description:  strings.TrimSpace(l.Description),
+	}
+	switch limit.Kind {
+	case pb.LimitKind_LIMIT_KIND_COUNT:
+		if limit.Count <= 0 {
+			problem("%s: count limits need a positive count", where)
+		}
+	case pb.LimitKind_LIMIT_KIND_DOLLAR:
+		if limit.Amount == nil || limit.Amount.Cents <= 0 {
+			problem("%s: dollar limits need a positive amount", where)
+		}
+	default:
+		problem("%s: kind %q is not COUNT or DOLLAR", where, l.Kind)
+	}
+	switch limit.Window {
+	case pb.LimitWindow_LIMIT_WINDOW_PLAN_YEAR, pb.LimitWindow_LIMIT_WINDOW_LIFETIME:
+	case pb.LimitWindow_LIMIT_WINDOW_ROLLING:
+		if limit.WindowMonths <= 0 {
+			problem("%s: rolling limits need window_months", where)
+		}
+	default:
+		problem("%s: window %q is not PLAN_YEAR, ROLLING or LIFETIME", where, l.Window)
+	}
+	return limit
+}
+
+func (d *Dollars) money() *pb.Money {
+	if d == nil {
+		return nil
+	}
+	return &pb.Money{Cents: int64(*d), Currency: "USD"}
+}
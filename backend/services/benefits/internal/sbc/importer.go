@@ -0,0 +1,117 @@
+package sbc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Store is where plan versions are kept
+type Store interface {
+	// FindVersion returns the version of the plan in force on day, the
+	// latest effective on or before it, or nil when there is none
+	FindVersion(ctx context.Context, planID, groupNumber string, coverageType pb.CoverageType, day time.Time) (*Version, error)
+	// SaveVersion replaces the version with the same effective date, or adds
+	// it, atomically
+	SaveVersion(ctx context.Context, v *Version) error
+}
+
+// Outcome is what became of one document
+type Outcome string
+
+const (
+	Applied   Outcome = "applied"
+	Pending   Outcome = "pending"
+	Unchanged Outcome = "unchanged"
+)
+
+// Report is what importing one document changed, or would change
+type Report struct {
+	PlanID        string
+	GroupNumber   string
+	CoverageType  pb.CoverageType
+	EffectiveDate time.Time
+	// Against is the effective date of the version compared against; zero
+	// for a new plan
+	Against time.Time
+	// Replaces is set when a version with the same effective date existed
+	Replaces bool
+	Outcome  Outcome
+	Diff     []string
+}
+
+// Importer compares documents to the stored plan versions and, when
+// applying, saves them
+type Importer struct {
+	store Store
+	apply bool
+}
+
+// NewImporter creates an importer. Unless apply is set it only reports.
+func NewImporter(store Store, apply bool) *Importer {
+	return &Importer{store: store, apply: apply}
+}
+
+// Import diffs the version against the one it replaces or follows, and saves
+// it when applying and something changed
+func (i *Importer) Import(ctx context.Context, v *Version) (*Report, error) {
+	report := &Report{
+		PlanID:        v.PlanID,
+		GroupNumber:   v.GroupNumber,
+		CoverageType:  v.CoverageType,
+		EffectiveDate: v.EffectiveDate,
+	}
+
+	prev, err := i.store.FindVersion(ctx, v.PlanID, v.GroupNumber, v.CoverageType, v.EffectiveDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current version: %w", err)
+	}
+	if prev != nil {
+		report.Against = prev.EffectiveDate
+		report.Replaces = prev.EffectiveDate.Equal(v.EffectiveDate)
+	}
+
+	report.Diff = Diff(prev, v)
+	switch {
+	case len(report.Diff) == 0:
+		report.Outcome = Unchanged
+	case !i.apply:
+		report.Outcome = Pending
+	default:
+		if err := i.store.SaveVersion(ctx, v); err != nil {
+			return nil, fmt.Errorf("failed to save version: %w", err)
+		}
+		report.Outcome = Applied
+	}
+	return report, nil
+}
+
+// WriteTo writes the report as text: a heading line, then the changes
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "%s", r.PlanID)
+	if r.GroupNumber != "" {
+		fmt.Fprintf(&b, " group %s", r.GroupNumber)
+	}
+	fmt.Fprintf(&b, " %s effective %s: %s", coverage.TypeName(r.CoverageType), r.EffectiveDate.Format("2006-01-02"), r.Outcome)
+	switch {
+	case r.Against.IsZero():
+		fmt.Fprintf(&b, " (new plan)")
+	case r.Replaces:
+		fmt.Fprintf(&b, " (replaces the version imported for that date)")
+	default:
+		fmt.Fprintf(&b, " (compared with the version effective %s)", r.Against.Format("2006-01-02"))
+	}
+	fmt.Fprintln(&b)
+
+	for _, diff := range r.Diff {
+		fmt.Fprintf(&b, "    %s\n", diff)
+	}
+	return b.WriteTo(w)
+}
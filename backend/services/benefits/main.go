@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/joho/godotenv"
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/priorauth"
+	"github.com/sydney-health-clone/backend/services/benefits/repository"
+	"github.com/sydney-health-clone/backend/services/benefits/service"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load("../../.env.development"); err != nil {
+		log.Printf("Warning: .env.development file not found")
+	}
+
+	// Demo mode serves the mock data benefits without a database
+	var (
+		repo      service.Repository
+		ledger    *accumulator.Ledger
+		authRules priorauth.Store
+	)
+	demoMode, _ := strconv.ParseBool(os.Getenv("BENEFITS_DEMO_MODE"))
+	if demoMode {
+		log.Printf("Demo mode: serving mock benefits")
+		repo = repository.NewMockRepository()
+		ledger = accumulator.NewLedger(accumulator.NewMemoryStore())
+		authRules = demoPriorAuthRules(os.Getenv("PRIOR_AUTH_RULES_FILE"))
+	} else {
+		db, err := database.InitDB()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+		repo = repository.NewBenefitsRepository(db)
+		ledger = accumulator.NewLedger(repository.NewAccumulatorStore(db))
+		authRules = repository.NewPriorAuthStore(db)
+	}
+
+	// Prior auth rules are reloaded when a new version is published
+	refresh := 5 * time.Minute
+	if v := os.Getenv("PRIOR_AUTH_RULES_REFRESH"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid PRIOR_AUTH_RULES_REFRESH %q: %v", v, err)
+		}
+		refresh = d
+	}
+
+	// Initialize service
+	benefitsService := service.NewBenefitsService(repo, ledger, priorauth.NewCache(authRules, refresh))
+
+	// Accumulate deductibles and out-of-pocket spending from adjudicated claims
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("CLAIM_ADJUDICATIONS_TOPIC"); brokers != "" && topic != "" {
+		consumer := kafka.NewConsumer(strings.Split(brokers, ","), topic, os.Getenv("KAFKA_CONSUMER_GROUP"), benefitsService.HandleClaimAdjudication)
+		consumer.SetDeadLetterTopic(os.Getenv("CLAIM_ADJUDICATIONS_DEAD_LETTER_TOPIC"))
+		defer consumer.Close()
+
+		go func() {
+			if err := consumer.Start(ctx); err != nil {
+				log.Printf("Claim adjudication consumer stopped: %v", err)
+			}
+		}()
+	}
+
+	// Get port from environment
+	port := os.Getenv("BENEFITS_SERVICE_PORT")
+	if port == "" {
+		port = "50052"
+	}
+
+	// Create gRPC server
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+
+	// Register benefits service
+	pb.RegisterBenefitsServiceServer(grpcServer, benefitsService)
+
+	// Register health service
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// Register reflection service for debugging
+	reflection.Register(grpcServer)
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Benefits service listening on port %s", port)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	// Setup graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("Shutting down benefits service...")
+	grpcServer.GracefulStop()
+	log.Println("Benefits service stopped")
+}
+
+// demoPriorAuthRules publishes the rules in path, when given, for demo mode.
+// Without them no procedure needs authorization.
+func demoPriorAuthRules(path string) *priorauth.MemoryStore {
+	store := priorauth.NewMemoryStore()
+	if path == "" {
+		return store
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open prior auth rules: %v", err)
+	}
+	defer f.Close()
+
+	rules, err := priorauth.ParseCSV(f)
+	if err != nil {
+		log.Fatalf("Failed to load prior auth rules: %v", err)
+	}
+	store.Publish(rules)
+	return store
+}
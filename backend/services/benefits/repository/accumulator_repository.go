@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+)
+
+// AccumulatorStore keeps claim accumulations in the claim_accumulations table
+// and their services in claim_benefit_usage
+type AccumulatorStore struct {
+	db *database.DB
+}
+
+// NewAccumulatorStore creates a new accumulator store
+func NewAccumulatorStore(db *database.DB) *AccumulatorStore {
+	return &AccumulatorStore{db: db}
+}
+
+// Record upserts the claim and replaces its services; the version guard
+// makes redelivery a no-op
+func (s *AccumulatorStore) Record(ctx context.Context, e *accumulator.Entry) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO claim_accumulations (
+			claim_id, version, member_id, family_id, plan_id, coverage_type, network_type,
+			service_date, deductible_cents, oop_cents, reversed, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (claim_id) DO UPDATE SET
+			version = EXCLUDED.version,
+			member_id = EXCLUDED.member_id,
+			family_id = EXCLUDED.family_id,
+			plan_id = EXCLUDED.plan_id,
+			coverage_type = EXCLUDED.coverage_type,
+			network_type = EXCLUDED.network_type,
+			service_date = EXCLUDED.service_date,
+			deductible_cents = EXCLUDED.deductible_cents,
+			oop_cents = EXCLUDED.oop_cents,
+			reversed = EXCLUDED.reversed,
+			updated_at = NOW()
+		WHERE claim_accumulations.version < EXCLUDED.version
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		e.ClaimID, e.Version, e.MemberID, e.FamilyID, e.PlanID,
+		coverage.TypeName(e.CoverageType), accumulator.NetworkName(e.Network),
+		e.ServiceDate, e.DeductibleCents, e.OOPCents, e.Reversed,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record claim accumulation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record claim accumulation: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM claim_benefit_usage WHERE claim_id = $1`, e.ClaimID); err != nil {
+		return false, fmt.Errorf("failed to clear claim services: %w", err)
+	}
+	for i, svc := range e.Services {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO claim_benefit_usage (
+				claim_id, line, member_id, plan_id, benefit_code, service_date, units, paid_cents
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, e.ClaimID, i+1, e.MemberID, e.PlanID, svc.BenefitCode, e.ServiceDate, svc.Units, svc.PaidCents); err != nil {
+			return false, fmt.Errorf("failed to record claim service: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to record claim accumulation: %w", err)
+	}
+	return true, nil
+}
+
+// FamilyOf returns the primary member a dependent is enrolled under, or the
+// member themself
+func (s *AccumulatorStore) FamilyOf(ctx context.Context, memberID string) (string, error) {
+	query := `
+		SELECT COALESCE(
+			(SELECT primary_member_id FROM member_dependents WHERE dependent_id = $1 LIMIT 1),
+			$1
+		)
+	`
+
+	var familyID string
+	if err := s.db.QueryRowContext(ctx, query, memberID).Scan(&familyID); err != nil {
+		return "", fmt.Errorf("failed to resolve family: %w", err)
+	}
+	return familyID, nil
+}
+
+// Totals sums the family's claim accumulations for the period. Claims the
+// claims system didn't attribute to a plan count toward any plan.
+func (s *AccumulatorStore) Totals(ctx context.Context, familyID, planID string, start, end time.Time) ([]accumulator.Total, error) {
+	query := `
+		SELECT member_id, family_id, coverage_type, network_type,
+			COALESCE(SUM(deductible_cents), 0), COALESCE(SUM(oop_cents), 0)
+		FROM claim_accumulations
+		WHERE family_id = $1 AND service_date BETWEEN $2 AND $3
+			AND ($4 = '' OR plan_id IN ('', $4))
+		GROUP BY member_id, family_id, coverage_type, network_type
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, familyID, start, end, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum claim accumulations: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTotals(rows)
+}
+
+// PlanYear returns the plan's year definition
+func (s *AccumulatorStore) PlanYear(ctx context.Context, planID string) (accumulator.PlanYear, error) {
+	query := `
+		SELECT plan_year_start_month, plan_year_start_day, carryover_months, transfers_accumulators
+		FROM plans
+		WHERE plan_id = $1
+	`
+
+	var (
+		year  accumulator.PlanYear
+		month int
+	)
+	err := s.db.QueryRowContext(ctx, query, planID).Scan(&month, &year.StartDay, &year.CarryoverMonths, &year.TransfersAccumulators)
+	if errors.Is(err, sql.ErrNoRows) {
+		return accumulator.CalendarYear, nil
+	}
+	if err != nil {
+		return accumulator.PlanYear{}, fmt.Errorf("failed to get plan year: %w", err)
+	}
+	year.StartMonth = time.Month(month)
+	return year, nil
+}
+
+// ListPlanYears returns the year definition of every plan
+func (s *AccumulatorStore) ListPlanYears(ctx context.Context) (map[string]accumulator.PlanYear, error) {
+	query := `
+		SELECT plan_id, plan_year_start_month, plan_year_start_day, carryover_months, transfers_accumulators
+		FROM plans
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plan years: %w", err)
+	}
+	defer rows.Close()
+
+	years := make(map[string]accumulator.PlanYear)
+	for rows.Next() {
+		var (
+			planID string
+			year   accumulator.PlanYear
+			month  int
+		)
+		if err := rows.Scan(&planID, &month, &year.StartDay, &year.CarryoverMonths, &year.TransfersAccumulators); err != nil {
+			return nil, fmt.Errorf("failed to scan plan year: %w", err)
+		}
+		year.StartMonth = time.Month(month)
+		years[planID] = year
+	}
+	return years, rows.Err()
+}
+
+const periodColumns = `plan_id, period_start, period_end, closed_at IS NOT NULL`
+
+// FindPeriod returns the plan's recorded period containing day, or nil
+func (s *AccumulatorStore) FindPeriod(ctx context.Context, planID string, day time.Time) (*accumulator.Period, error) {
+	query := `SELECT ` + periodColumns + `
+		FROM accumulator_periods
+		WHERE plan_id = $1 AND $2 BETWEEN period_start AND period_end
+		ORDER BY period_start DESC
+		LIMIT 1
+	`
+
+	var p accumulator.Period
+	err := s.db.QueryRowContext(ctx, query, planID, day).Scan(&p.PlanID, &p.Start, &p.End, &p.Closed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find period: %w", err)
+	}
+	return &p, nil
+}
+
+// ListOpenPeriods returns the periods not yet closed
+func (s *AccumulatorStore) ListOpenPeriods(ctx context.Context) ([]accumulator.Period, error) {
+	query := `SELECT ` + periodColumns + `
+		FROM accumulator_periods
+		WHERE closed_at IS NULL
+		ORDER BY plan_id, period_start
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open periods: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []accumulator.Period
+	for rows.Next() {
+		var p accumulator.Period
+		if err := rows.Scan(&p.PlanID, &p.Start, &p.End, &p.Closed); err != nil {
+			return nil, fmt.Errorf("failed to scan period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// OpenPeriod records a period unless the plan already has one starting that day
+func (s *AccumulatorStore) OpenPeriod(ctx context.Context, p accumulator.Period) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO accumulator_periods (plan_id, period_start, period_end, opened_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (plan_id, period_start) DO NOTHING
+	`, p.PlanID, p.Start, p.End)
+	if err != nil {
+		return fmt.Errorf("failed to open period: %w", err)
+	}
+	return nil
+}
+
+// ClosePeriod closes p and opens next in one transaction
+func (s *AccumulatorStore) ClosePeriod(ctx context.Context, p, next accumulator.Period) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE accumulator_periods SET closed_at = NOW()
+		WHERE plan_id = $1 AND period_start = $2 AND closed_at IS NULL
+	`, p.PlanID, p.Start)
+	if err != nil {
+		return fmt.Errorf("failed to close period: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to close period: %w", err)
+	} else if rows == 0 {
+		// Another run closed it first
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO accumulator_periods (plan_id, period_start, period_end, opened_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (plan_id, period_start) DO NOTHING
+	`, next.PlanID, next.Start, next.End); err != nil {
+		return fmt.Errorf("failed to open period: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Uses returns the member's uses of the benefit in the dates, oldest first
+func (s *AccumulatorStore) Uses(ctx context.Context, memberID, planID, benefitCode string, start, end time.Time) ([]accumulator.Use, error) {
+	query := `
+		SELECT service_date, units, paid_cents
+		FROM claim_benefit_usage
+		WHERE member_id = $1 AND benefit_code = $2 AND service_date BETWEEN $3 AND $4
+			AND ($5 = '' OR plan_id IN ('', $5))
+		ORDER BY service_date, claim_id, line
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID, benefitCode, start, end, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list benefit uses: %w", err)
+	}
+	defer rows.Close()
+
+	var uses []accumulator.Use
+	for rows.Next() {
+		var u accumulator.Use
+		if err := rows.Scan(&u.ServiceDate, &u.Units, &u.PaidCents); err != nil {
+			return nil, fmt.Errorf("failed to scan benefit use: %w", err)
+		}
+		uses = append(uses, u)
+	}
+	return uses, rows.Err()
+}
+
+func scanTotals(rows *sql.Rows) ([]accumulator.Total, error) {
+	var totals []accumulator.Total
+	for rows.Next() {
+		var (
+			t                     accumulator.Total
+			coverageType, network string
+		)
+		if err := rows.Scan(&t.MemberID, &t.FamilyID, &coverageType, &network, &t.DeductibleCents, &t.OOPCents); err != nil {
+			return nil, fmt.Errorf("failed to scan accumulator total: %w", err)
+		}
+		t.CoverageType, _ = coverage.ParseType(coverageType)
+		t.Network = accumulator.ParseNetwork(network)
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrCostSharesNotFound is returned when a plan has no deductible or
+// out-of-pocket maximum on file for the coverage type
+var ErrCostSharesNotFound = errors.New("cost shares not found")
+
+// Plan identifies the benefits a member receives for one coverage type
+type Plan struct {
+	PlanID       string
+	GroupNumber  string
+	CoverageType pb.CoverageType
+	// AsOf picks the plan version in force that day; today when zero
+	AsOf time.Time
+}
+
+func (p Plan) day() time.Time {
+	if p.AsOf.IsZero() {
+		return coverage.Day(time.Now())
+	}
+	return coverage.Day(p.AsOf)
+}
+
+// BenefitsRepository reads plan benefits and the coverage that selects them
+type BenefitsRepository struct {
+	db *database.DB
+}
+
+// NewBenefitsRepository creates a new benefits repository
+func NewBenefitsRepository(db *database.DB) *BenefitsRepository {
+	return &BenefitsRepository{db: db}
+}
+
+// ListCoverageSpans returns the member's coverage spans, which name the plan
+// and group each coverage type is under
+func (r *BenefitsRepository) ListCoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error) {
+	query := `
+		SELECT span_id, coverage_type, plan_id, plan_name, group_number, tier, effective_date, termination_date
+		FROM member_coverage_spans
+		WHERE member_id = $1
+		ORDER BY coverage_type, effective_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coverage spans: %w", err)
+	}
+	defer rows.Close()
+
+	var spans []*pb.CoverageSpan
+	for rows.Next() {
+		var (
+			coverageType    string
+			tier            string
+			effectiveDate   sql.NullTime
+			terminationDate sql.NullTime
+		)
+		span := &pb.CoverageSpan{MemberId: memberID}
+		if err := rows.Scan(&span.SpanId, &coverageType, &span.PlanId, &span.PlanName, &span.GroupNumber, &tier,
+			&effectiveDate, &terminationDate); err != nil {
+			return nil, fmt.Errorf("failed to scan coverage span: %w", err)
+		}
+		span.CoverageType, _ = coverage.ParseType(coverageType)
+		span.Tier = coverage.ParseTier(tier)
+		span.EffectiveDate = timestamppb.New(effectiveDate.Time)
+		if terminationDate.Valid {
+			span.TerminationDate = timestamppb.New(terminationDate.Time)
+		}
+		spans = append(spans, span)
+	}
+	return spans, rows.Err()
+}
+
+// ListBenefits returns the plan's benefits for the coverage type from the
+// version in force on plan.AsOf, with the group's own benefits in place of
+// the plan-wide ones they replace
+func (r *BenefitsRepository) ListBenefits(ctx context.Context, plan Plan) ([]*pb.Benefit, error) {
+	query := `
+		SELECT benefit_id, benefit_code, name, description, coverage_type, is_covered
+		FROM (
+			SELECT DISTINCT ON (COALESCE(NULLIF(benefit_code, ''), benefit_id))
+				benefit_id, benefit_code, name, COALESCE(description, '') AS description, coverage_type,
+				COALESCE(is_covered, TRUE) AS is_covered, display_order
+			FROM benefits b
+			WHERE plan_id = $1 AND coverage_type = $2 AND group_number IN ('', $3)
+				AND effective_date = (
+					SELECT MAX(effective_date) FROM benefits v
+					WHERE v.plan_id = b.plan_id AND v.coverage_type = b.coverage_type
+						AND v.group_number = b.group_number AND v.effective_date <= $4
+				)
+			ORDER BY COALESCE(NULLIF(benefit_code, ''), benefit_id), group_number DESC
+		) plan_benefits
+		ORDER BY display_order, name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, plan.PlanID, coverage.TypeName(plan.CoverageType), plan.GroupNumber, plan.day())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list benefits: %w", err)
+	}
+	defer rows.Close()
+
+	var benefits []*pb.Benefit
+	byID := make(map[string]*pb.Benefit)
+	for rows.Next() {
+		var coverageType string
+		benefit := &pb.Benefit{}
+		if err := rows.Scan(&benefit.BenefitId, &benefit.BenefitCode, &benefit.Name, &benefit.Description, &coverageType, &benefit.IsCovered); err != nil {
+			return nil, fmt.Errorf("failed to scan benefit: %w", err)
+		}
+		benefit.CoverageType, _ = coverage.ParseType(coverageType)
+		benefits = append(benefits, benefit)
+		byID[benefit.BenefitId] = benefit
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(benefits) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(benefits))
+	for _, benefit := range benefits {
+		ids = append(ids, benefit.BenefitId)
+	}
+	if err := r.loadCoverageLevels(ctx, ids, byID); err != nil {
+		return nil, err
+	}
+	if err := r.loadProvisions(ctx, ids, byID); err != nil {
+		return nil, err
+	}
+	if err := r.loadLimits(ctx, ids, byID); err != nil {
+		return nil, err
+	}
+	return benefits, nil
+}
+
+func (r *BenefitsRepository) loadCoverageLevels(ctx context.Context, ids []string, byID map[string]*pb.Benefit) error {
+	query := `
+		SELECT benefit_id, network_type, copay_cents, coinsurance_percentage,
+			deductible_cents, annual_limit_cents, lifetime_limit_cents
+		FROM coverage_levels
+		WHERE benefit_id = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load coverage levels: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			benefitID, networkType                   string
+			copay, deductible, annualLimit, lifetime sql.NullInt64
+			coinsurance                              sql.NullInt32
+		)
+		if err := rows.Scan(&benefitID, &networkType, &copay, &coinsurance, &deductible, &annualLimit, &lifetime); err != nil {
+			return fmt.Errorf("failed to scan coverage level: %w", err)
+		}
+
+		level := &pb.CoverageLevel{
+			Copay:                 nullMoney(copay),
+			CoinsurancePercentage: coinsurance.Int32,
+			Deductible:            nullMoney(deductible),
+			AnnualLimit:           nullMoney(annualLimit),
+			LifetimeLimit:         nullMoney(lifetime),
+		}
+		switch networkType {
+		case "IN_NETWORK":
+			byID[benefitID].InNetwork = level
+		case "OUT_OF_NETWORK":
+			byID[benefitID].OutOfNetwork = level
+		}
+	}
+	return rows.Err()
+}
+
+func (r *BenefitsRepository) loadProvisions(ctx context.Context, ids []string, byID map[string]*pb.Benefit) error {
+	query := `
+		SELECT benefit_id, kind, text
+		FROM benefit_provisions
+		WHERE benefit_id = ANY($1)
+		ORDER BY benefit_id, kind, position
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load limitations and exclusions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var benefitID, kind, text string
+		if err := rows.Scan(&benefitID, &kind, &text); err != nil {
+			return fmt.Errorf("failed to scan provision: %w", err)
+		}
+
+		benefit := byID[benefitID]
+		switch kind {
+		case "LIMITATION":
+			benefit.Limitations = append(benefit.Limitations, text)
+		case "EXCLUSION":
+			benefit.Exclusions = append(benefit.Exclusions, text)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *BenefitsRepository) loadLimits(ctx context.Context, ids []string, byID map[string]*pb.Benefit) error {
+	query := `
+		SELECT benefit_id, kind, quantity, limit_window, window_months, description
+		FROM benefit_limits
+		WHERE benefit_id = ANY($1)
+		ORDER BY benefit_id, position
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load benefit limits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			benefitID, kind, window string
+			quantity                int64
+		)
+		limit := &pb.BenefitLimit{}
+		if err := rows.Scan(&benefitID, &kind, &quantity, &window, &limit.WindowMonths, &limit.Description); err != nil {
+			return fmt.Errorf("failed to scan benefit limit: %w", err)
+		}
+
+		limit.Kind = accumulator.ParseLimitKind(kind)
+		limit.Window = accumulator.ParseLimitWindow(window)
+		if limit.Kind == pb.LimitKind_LIMIT_KIND_DOLLAR {
+			limit.Amount = &pb.Money{Cents: quantity, Currency: "USD"}
+		} else {
+			limit.Count = int32(quantity)
+		}
+		byID[benefitID].Limits = append(byID[benefitID].Limits, limit)
+	}
+	return rows.Err()
+}
+
+// GetCostShares returns the plan's deductibles, out-of-pocket maximums and
+// accumulation rules for the coverage type from the version in force on
+// plan.AsOf, preferring the group's own row
+func (r *BenefitsRepository) GetCostShares(ctx context.Context, plan Plan) (*accumulator.Rules, error) {
+	query := `
+		SELECT individual_deductible_cents, family_deductible_cents,
+			individual_oop_max_cents, family_oop_max_cents,
+			oon_individual_deductible_cents, oon_family_deductible_cents,
+			oon_individual_oop_max_cents, oon_family_oop_max_cents,
+			deductible_accumulation, oop_accumulation,
+			deductible_shared_with, oop_shared_with
+		FROM plan_cost_shares
+		WHERE plan_id = $1 AND coverage_type = $2 AND group_number IN ('', $3) AND effective_date <= $4
+		ORDER BY group_number DESC, effective_date DESC
+		LIMIT 1
+	`
+
+	var (
+		rules                                   accumulator.Rules
+		deductibleAccumulation, oopAccumulation string
+		deductibleSharedWith, oopSharedWith     string
+	)
+	err := r.db.QueryRowContext(ctx, query, plan.PlanID, coverage.TypeName(plan.CoverageType), plan.GroupNumber, plan.day()).Scan(
+		&rules.InNetwork.IndividualDeductibleCents, &rules.InNetwork.FamilyDeductibleCents,
+		&rules.InNetwork.IndividualOOPMaxCents, &rules.InNetwork.FamilyOOPMaxCents,
+		&rules.OutOfNetwork.IndividualDeductibleCents, &rules.OutOfNetwork.FamilyDeductibleCents,
+		&rules.OutOfNetwork.IndividualOOPMaxCents, &rules.OutOfNetwork.FamilyOOPMaxCents,
+		&deductibleAccumulation, &oopAccumulation,
+		&deductibleSharedWith, &oopSharedWith,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCostSharesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost shares: %w", err)
+	}
+
+	rules.DeductibleAccumulation = accumulator.ParseAccumulation(deductibleAccumulation)
+	rules.OOPAccumulation = accumulator.ParseAccumulation(oopAccumulation)
+	rules.DeductibleSharedWith, _ = coverage.ParseType(deductibleSharedWith)
+	rules.OOPSharedWith, _ = coverage.ParseType(oopSharedWith)
+	return &rules, nil
+}
+
+// nullMoney leaves amounts the plan doesn't set unset rather than zero
+func nullMoney(cents sql.NullInt64) *pb.Money {
+	if !cents.Valid {
+		return nil
+	}
+	return &pb.Money{Cents: cents.Int64, Currency: "USD"}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/mockdata"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DemoPlanID is the plan every member is enrolled in under demo mode
+const DemoPlanID = "DEMO"
+
+// MockRepository serves the mock data benefits for demo mode, where every
+// member holds open-ended coverage of each type the mock plan offers
+type MockRepository struct {
+	benefits []*pb.Benefit
+}
+
+// NewMockRepository creates a repository over the mock data benefits
+func NewMockRepository() *MockRepository {
+	return &MockRepository{benefits: mockdata.NewMockDataGenerator().GenerateBenefits()}
+}
+
+// ListCoverageSpans returns one demo span per coverage type
+func (r *MockRepository) ListCoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error) {
+	start := time.Date(time.Now().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var spans []*pb.CoverageSpan
+	seen := make(map[pb.CoverageType]bool)
+	for _, benefit := range r.benefits {
+		if seen[benefit.CoverageType] {
+			continue
+		}
+		seen[benefit.CoverageType] = true
+		spans = append(spans, &pb.CoverageSpan{
+			SpanId:        DemoPlanID + "-" + coverage.TypeName(benefit.CoverageType),
+			MemberId:      memberID,
+			CoverageType:  benefit.CoverageType,
+			PlanId:        DemoPlanID,
+			PlanName:      "Demo Plan",
+			EffectiveDate: timestamppb.New(start),
+		})
+	}
+	return spans, nil
+}
+
+// ListBenefits returns the mock benefits of the coverage type
+func (r *MockRepository) ListBenefits(ctx context.Context, plan Plan) ([]*pb.Benefit, error) {
+	var benefits []*pb.Benefit
+	for _, benefit := range r.benefits {
+		if benefit.CoverageType == plan.CoverageType {
+			benefits = append(benefits, proto.Clone(benefit).(*pb.Benefit))
+		}
+	}
+	return benefits, nil
+}
+
+// GetCostShares returns fixed demo deductibles and out-of-pocket maximums,
+// with pharmacy sharing the medical out-of-pocket maximum
+func (r *MockRepository) GetCostShares(ctx context.Context, plan Plan) (*accumulator.Rules, error) {
+	switch plan.CoverageType {
+	case pb.CoverageType_COVERAGE_TYPE_MEDICAL:
+		return &accumulator.Rules{
+			InNetwork: accumulator.Limits{
+				IndividualDeductibleCents: 150000,
+				FamilyDeductibleCents:     300000,
+				IndividualOOPMaxCents:     600000,
+				FamilyOOPMaxCents:         1200000,
+			},
+			OutOfNetwork: accumulator.Limits{
+				IndividualDeductibleCents: 300000,
+				FamilyDeductibleCents:     600000,
+				IndividualOOPMaxCents:     1200000,
+				FamilyOOPMaxCents:         2400000,
+			},
+			DeductibleAccumulation: pb.FamilyAccumulation_FAMILY_ACCUMULATION_EMBEDDED,
+			OOPAccumulation:        pb.FamilyAccumulation_FAMILY_ACCUMULATION_EMBEDDED,
+			OOPSharedWith:          pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+		}, nil
+	case pb.CoverageType_COVERAGE_TYPE_DENTAL:
+		return &accumulator.Rules{
+			InNetwork:              accumulator.Limits{IndividualDeductibleCents: 5000, FamilyDeductibleCents: 15000},
+			OutOfNetwork:           accumulator.Limits{IndividualDeductibleCents: 5000, FamilyDeductibleCents: 15000},
+			DeductibleAccumulation: pb.FamilyAccumulation_FAMILY_ACCUMULATION_AGGREGATE,
+		}, nil
+	default:
+		return nil, ErrCostSharesNotFound
+	}
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/sbc"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// PlanVersionStore keeps the versions of each plan imported from SBCs in
+// plan_versions, with their benefits and cost shares stored by effective date
+type PlanVersionStore struct {
+	db       *database.DB
+	benefits *BenefitsRepository
+}
+
+// NewPlanVersionStore creates a new plan version store
+func NewPlanVersionStore(db *database.DB) *PlanVersionStore {
+	return &PlanVersionStore{db: db, benefits: NewBenefitsRepository(db)}
+}
+
+// FindVersion returns the version of the plan in force on day, or nil
+func (s *PlanVersionStore) FindVersion(ctx context.Context, planID, groupNumber string, coverageType pb.CoverageType, day time.Time) (*sbc.Version, error) {
+	query := `
+		SELECT plan_name, effective_date
+		FROM plan_versions
+		WHERE plan_id = $1 AND group_number = $2 AND coverage_type = $3 AND effective_date <= $4
+		ORDER BY effective_date DESC
+		LIMIT 1
+	`
+
+	v := &sbc.Version{PlanID: planID, GroupNumber: groupNumber, CoverageType: coverageType}
+	err := s.db.QueryRowContext(ctx, query, planID, groupNumber, coverage.TypeName(coverageType), day).Scan(&v.PlanName, &v.EffectiveDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find plan version: %w", err)
+	}
+
+	if err := s.loadCostShares(ctx, v); err != nil {
+		return nil, err
+	}
+	if err := s.loadBenefits(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *PlanVersionStore) loadCostShares(ctx context.Context, v *sbc.Version) error {
+	query := `
+		SELECT individual_deductible_cents, family_deductible_cents,
+			individual_oop_max_cents, family_oop_max_cents,
+			oon_individual_deductible_cents, oon_family_deductible_cents,
+			oon_individual_oop_max_cents, oon_family_oop_max_cents
+		FROM plan_cost_shares
+		WHERE plan_id = $1 AND group_number = $2 AND coverage_type = $3 AND effective_date = $4
+	`
+
+	err := s.db.QueryRowContext(ctx, query, v.PlanID, v.GroupNumber, coverage.TypeName(v.CoverageType), v.EffectiveDate).Scan(
+		&v.InNetwork.IndividualDeductibleCents, &v.InNetwork.FamilyDeductibleCents,
+		&v.InNetwork.IndividualOOPMaxCents, &v.InNetwork.FamilyOOPMaxCents,
+		&v.OutOfNetwork.IndividualDeductibleCents, &v.OutOfNetwork.FamilyDeductibleCents,
+		&v.OutOfNetwork.IndividualOOPMaxCents, &v.OutOfNetwork.FamilyOOPMaxCents,
+	)
+	// Versions loaded by hand may have no cost shares
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to load cost shares: %w", err)
+	}
+	return nil
+}
+
+func (s *PlanVersionStore) loadBenefits(ctx context.Context, v *sbc.Version) error {
+	query := `
+		SELECT benefit_id, benefit_code, name, COALESCE(description, ''), COALESCE(is_covered, TRUE)
+		FROM benefits
+		WHERE plan_id = $1 AND group_number = $2 AND coverage_type = $3 AND effective_date = $4
+		ORDER BY display_order, name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, v.PlanID, v.GroupNumber, coverage.TypeName(v.CoverageType), v.EffectiveDate)
+	if err != nil {
+		return fmt.Errorf("failed to load version benefits: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]*pb.Benefit)
+	var ids []string
+	for rows.Next() {
+		benefit := &pb.Benefit{CoverageType: v.CoverageType}
+		if err := rows.Scan(&benefit.BenefitId, &benefit.BenefitCode, &benefit.Name, &benefit.Description, &benefit.IsCovered); err != nil {
+			return fmt.Errorf("failed to scan benefit: %w", err)
+		}
+		v.Benefits = append(v.Benefits, benefit)
+		byID[benefit.BenefitId] = benefit
+		ids = append(ids, benefit.BenefitId)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.benefits.loadCoverageLevels(ctx, ids, byID); err != nil {
+		return err
+	}
+	if err := s.benefits.loadProvisions(ctx, ids, byID); err != nil {
+		return err
+	}
+	return s.benefits.loadLimits(ctx, ids, byID)
+}
+
+// SaveVersion replaces the version's benefits and cost shares in one
+// transaction. Accumulation rules carry over from the previous version.
+func (s *PlanVersionStore) SaveVersion(ctx context.Context, v *sbc.Version) error {
+	coverageType := coverage.TypeName(v.CoverageType)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO plan_versions (plan_id, group_number, coverage_type, effective_date, plan_name, imported_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (plan_id, group_number, coverage_type, effective_date)
+		DO UPDATE SET plan_name = EXCLUDED.plan_name, imported_at = NOW()
+	`, v.PlanID, v.GroupNumber, coverageType, v.EffectiveDate, v.PlanName); err != nil {
+		return fmt.Errorf("failed to save plan version: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO plan_cost_shares (
+			plan_id, group_number, coverage_type, effective_date,
+			individual_deductible_cents, family_deductible_cents, individual_oop_max_cents, family_oop_max_cents,
+			oon_individual_deductible_cents, oon_family_deductible_cents, oon_individual_oop_max_cents, oon_family_oop_max_cents,
+			deductible_accumulation, oop_accumulation, deductible_shared_with, oop_shared_with
+		)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+			COALESCE(prev.deductible_accumulation, 'EMBEDDED'), COALESCE(prev.oop_accumulation, 'EMBEDDED'),
+			COALESCE(prev.deductible_shared_with, ''), COALESCE(prev.oop_shared_with, '')
+		FROM (SELECT 1) one
+		LEFT JOIN LATERAL (
+			SELECT deductible_accumulation, oop_accumulation, deductible_shared_with, oop_shared_with
+			FROM plan_cost_shares
+			WHERE plan_id = $1 AND group_number = $2 AND coverage_type = $3 AND effective_date < $4
+			ORDER BY effective_date DESC
+			LIMIT 1
+		) prev ON TRUE
+		ON CONFLICT (plan_id, group_number, coverage_type, effective_date) DO UPDATE SET
+			individual_deductible_cents = EXCLUDED.individual_deductible_cents,
+			family_deductible_cents = EXCLUDED.family_deductible_cents,
+			individual_oop_max_cents = EXCLUDED.individual_oop_max_cents,
+			family_oop_max_cents = EXCLUDED.family_oop_max_cents,
+			oon_individual_deductible_cents = EXCLUDED.oon_individual_deductible_cents,
+			oon_family_deductible_cents = EXCLUDED.oon_family_deductible_cents,
+			oon_individual_oop_max_cents = EXCLUDED.oon_individual_oop_max_cents,
+			oon_family_oop_max_cents = EXCLUDED.oon_family_oop_max_cents
+	`, v.PlanID, v.GroupNumber, coverageType, v.EffectiveDate,
+		v.InNetwork.IndividualDeductibleCents, v.InNetwork.FamilyDeductibleCents,
+		v.InNetwork.IndividualOOPMaxCents, v.InNetwork.FamilyOOPMaxCents,
+		v.OutOfNetwork.IndividualDeductibleCents, v.OutOfNetwork.FamilyDeductibleCents,
+		v.OutOfNetwork.IndividualOOPMaxCents, v.OutOfNetwork.FamilyOOPMaxCents); err != nil {
+		return fmt.Errorf("failed to save cost shares: %w", err)
+	}
+
+	// Limitations, exclusions and limits go with their benefits
+	versionBenefits := `SELECT benefit_id FROM benefits WHERE plan_id = $1 AND group_number = $2 AND coverage_type = $3 AND effective_date = $4`
+	if _, err := tx.ExecContext(ctx, `DELETE FROM coverage_levels WHERE benefit_id IN (`+versionBenefits+`)`,
+		v.PlanID, v.GroupNumber, coverageType, v.EffectiveDate); err != nil {
+		return fmt.Errorf("failed to clear coverage levels: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM benefits WHERE benefit_id IN (`+versionBenefits+`)`,
+		v.PlanID, v.GroupNumber, coverageType, v.EffectiveDate); err != nil {
+		return fmt.Errorf("failed to clear benefits: %w", err)
+	}
+
+	for i, benefit := range v.Benefits {
+		if err := insertBenefit(ctx, tx, v, i+1, benefit); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// VersionBenefitID is the benefit ID an imported benefit is stored under
+func VersionBenefitID(v *sbc.Version, code string) string {
+	id := v.PlanID + "-"
+	if v.GroupNumber != "" {
+		id += v.GroupNumber + "-"
+	}
+	return id + code + "-" + v.EffectiveDate.Format("20060102")
+}
+
+func insertBenefit(ctx context.Context, tx *sql.Tx, v *sbc.Version, position int, benefit *pb.Benefit) error {
+	id := VersionBenefitID(v, benefit.BenefitCode)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO benefits (
+			benefit_id, plan_id, group_number, benefit_code, effective_date, display_order,
+			name, description, coverage_type, is_covered
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, id, v.PlanID, v.GroupNumber, benefit.BenefitCode, v.EffectiveDate, position,
+		benefit.Name, benefit.Description, coverage.TypeName(v.CoverageType), benefit.IsCovered); err != nil {
+		return fmt.Errorf("failed to insert benefit %s: %w", benefit.BenefitCode, err)
+	}
+
+	for network, level := range map[string]*pb.CoverageLevel{"IN_NETWORK": benefit.InNetwork, "OUT_OF_NETWORK": benefit.OutOfNetwork} {
+		if level == nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO coverage_levels (
+				benefit_id, network_type, copay_cents, coinsurance_percentage,
+				deductible_cents, annual_limit_cents, lifetime_limit_cents
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, id, network, moneyCents(level.Copay), level.CoinsurancePercentage,
+			moneyCents(level.Deductible), moneyCents(level.AnnualLimit), moneyCents(level.LifetimeLimit)); err != nil {
+			return fmt.Errorf("failed to insert %s coverage level: %w", benefit.BenefitCode, err)
+		}
+	}
+
+	provisions := []struct {
+		kind  string
+		texts []string
+	}{{"LIMITATION", benefit.Limitations}, {"EXCLUSION", benefit.Exclusions}}
+	for _, p := range provisions {
+		for i, text := range p.texts {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO benefit_provisions (benefit_id, kind, position, text) VALUES ($1, $2, $3, $4)
+			`, id, p.kind, i+1, text); err != nil {
+				return fmt.Errorf("failed to insert %s provision: %w", benefit.BenefitCode, err)
+			}
+		}
+	}
+
+	for i, limit := range benefit.Limits {
+		quantity := int64(limit.Count)
+		if limit.Kind == pb.LimitKind_LIMIT_KIND_DOLLAR {
+			quantity = limit.GetAmount().GetCents()
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO benefit_limits (benefit_id, position, kind, quantity, limit_window, window_months, description)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, id, i+1, accumulator.LimitKindName(limit.Kind), quantity,
+			accumulator.LimitWindowName(limit.Window), limit.WindowMonths, limit.Description); err != nil {
+			return fmt.Errorf("failed to insert %s limit: %w", benefit.BenefitCode, err)
+		}
+	}
+	return nil
+}
+
+// moneyCents stores unset amounts as NULL, the way nullMoney reads them
+func moneyCents(m *pb.Money) sql.NullInt64 {
+	if m == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: m.Cents, Valid: true}
+}
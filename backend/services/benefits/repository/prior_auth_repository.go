@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/priorauth"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// PriorAuthStore keeps versions of the prior authorization rules in the
+// prior_auth_rule_versions and prior_auth_rules tables
+type PriorAuthStore struct {
+	db *database.DB
+}
+
+// NewPriorAuthStore creates a new prior authorization rules store
+func NewPriorAuthStore(db *database.DB) *PriorAuthStore {
+	return &PriorAuthStore{db: db}
+}
+
+// ActiveVersion is the latest version loaded
+func (s *PriorAuthStore) ActiveVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM prior_auth_rule_versions`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get prior auth rules version: %w", err)
+	}
+	return version, nil
+}
+
+// Rules lists the rules of a version in file order
+func (s *PriorAuthStore) Rules(ctx context.Context, version int64) ([]priorauth.Rule, error) {
+	query := `
+		SELECT procedure_code, coverage_type, network, plan_id, required, reason, channel, contact
+		FROM prior_auth_rules
+		WHERE version = $1
+		ORDER BY line
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prior auth rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []priorauth.Rule
+	for rows.Next() {
+		var (
+			rule                           priorauth.Rule
+			coverageType, network, channel string
+		)
+		if err := rows.Scan(&rule.ProcedureCode, &coverageType, &network, &rule.PlanID,
+			&rule.Required, &rule.Reason, &channel, &rule.Contact); err != nil {
+			return nil, fmt.Errorf("failed to scan prior auth rule: %w", err)
+		}
+		rule.CoverageType, _ = coverage.ParseType(coverageType)
+		rule.Network = accumulator.ParseNetwork(network)
+		rule.Channel = priorauth.ParseChannel(channel)
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// Publish saves rules as a new version, which becomes the active one
+func (s *PriorAuthStore) Publish(ctx context.Context, source string, rules []priorauth.Rule) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO prior_auth_rule_versions (source, rule_count) VALUES ($1, $2)
+		RETURNING version
+	`, source, len(rules)).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create prior auth rules version: %w", err)
+	}
+
+	query := `
+		INSERT INTO prior_auth_rules (
+			version, line, procedure_code, coverage_type, network, plan_id, required, reason, channel, contact
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	for i, rule := range rules {
+		var network, channel string
+		if rule.Network != pb.NetworkTier_NETWORK_TIER_UNSPECIFIED {
+			network = accumulator.NetworkName(rule.Network)
+		}
+		if rule.Channel != pb.PriorAuthChannel_PRIOR_AUTH_CHANNEL_UNSPECIFIED {
+			channel = priorauth.ChannelName(rule.Channel)
+		}
+		_, err := tx.ExecContext(ctx, query,
+			version, i+1, rule.ProcedureCode, coverage.TypeName(rule.CoverageType), network, rule.PlanID,
+			rule.Required, rule.Reason, channel, rule.Contact,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert prior auth rule for %s: %w", rule.ProcedureCode, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit prior auth rules: %w", err)
+	}
+	return version, nil
+}
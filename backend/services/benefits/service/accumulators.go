@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/segmentio/kafka-go"
+
+	sharedkafka "github.com/sydney-health-clone/backend/shared/kafka"
+)
+
+// HandleClaimAdjudication applies a claim event from the claims system to the
+// member's accumulators. Events arrive at least once and possibly out of
+// order; the ledger keeps only the latest version of each claim.
+func (s *BenefitsService) HandleClaimAdjudication(ctx context.Context, message kafka.Message) error {
+	adjudication, err := sharedkafka.UnmarshalClaimAdjudication(message.Value)
+	if err != nil {
+		return err
+	}
+
+	applied, err := s.ledger.Apply(ctx, adjudication)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		log.Printf("Ignoring claim %s version %d: already applied", adjudication.ClaimID, adjudication.Version)
+	}
+	return nil
+}
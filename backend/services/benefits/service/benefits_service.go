@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/services/benefits/internal/accumulator"
+	"github.com/sydney-health-clone/backend/services/benefits/internal/priorauth"
+	"github.com/sydney-health-clone/backend/services/benefits/repository"
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Repository is where benefits come from: the database, or mock data in demo mode
+type Repository interface {
+	ListCoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error)
+	ListBenefits(ctx context.Context, plan repository.Plan) ([]*pb.Benefit, error)
+	GetCostShares(ctx context.Context, plan repository.Plan) (*accumulator.Rules, error)
+}
+
+// BenefitsService implements the gRPC BenefitsService. Callers must be the
+// member, hold the access the gateway signed for them, or be internal.
+type BenefitsService struct {
+	pb.UnimplementedBenefitsServiceServer
+	repo      Repository
+	ledger    *accumulator.Ledger
+	priorAuth *priorauth.Cache
+	now       func() time.Time
+}
+
+// NewBenefitsService creates a new benefits service
+func NewBenefitsService(repo Repository, ledger *accumulator.Ledger, priorAuth *priorauth.Cache) *BenefitsService {
+	return &BenefitsService{
+		repo:      repo,
+		ledger:    ledger,
+		priorAuth: priorAuth,
+		now:       time.Now,
+	}
+}
+
+// GetBenefitsSummary lists the benefits of the plans the member holds today
+func (s *BenefitsService) GetBenefitsSummary(ctx context.Context, req *pb.GetBenefitsSummaryRequest) (*pb.GetBenefitsSummaryResponse, error) {
+	log.Printf("GetBenefitsSummary called for member ID: %s, coverage type: %s", req.MemberId, req.CoverageType)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if _, err := access.AuthorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	plans, err := s.activePlans(ctx, req.MemberId, req.CoverageType, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	benefits := []*pb.Benefit{}
+	for _, plan := range plans {
+		planBenefits, err := s.repo.ListBenefits(ctx, plan)
+		if err != nil {
+			log.Printf("Error listing benefits for plan %s: %v", plan.PlanID, err)
+			return nil, status.Error(codes.Internal, "failed to retrieve benefits")
+		}
+		benefits = append(benefits, planBenefits...)
+	}
+
+	return &pb.GetBenefitsSummaryResponse{
+		Benefits: benefits,
+	}, nil
+}
+
+// GetBenefitDetails returns one benefit of the plans the member holds today
+func (s *BenefitsService) GetBenefitDetails(ctx context.Context, req *pb.GetBenefitDetailsRequest) (*pb.GetBenefitDetailsResponse, error) {
+	log.Printf("GetBenefitDetails called for member ID: %s, benefit ID: %s", req.MemberId, req.BenefitId)
+
+	if req.MemberId == "" || req.BenefitId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and benefit_id are required")
+	}
+	if _, err := access.AuthorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	plans, err := s.activePlans(ctx, req.MemberId, pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	// A benefit ID from another plan, or one the member's group has replaced,
+	// is not one of this member's benefits
+	for _, plan := range plans {
+		benefits, err := s.repo.ListBenefits(ctx, plan)
+		if err != nil {
+			log.Printf("Error listing benefits for plan %s: %v", plan.PlanID, err)
+			return nil, status.Error(codes.Internal, "failed to retrieve benefit")
+		}
+		for _, benefit := range benefits {
+			if benefit.BenefitId == req.BenefitId {
+				return &pb.GetBenefitDetailsResponse{Benefit: benefit}, nil
+			}
+		}
+	}
+
+	return nil, status.Error(codes.NotFound, "benefit not found")
+}
+
+// GetDeductibleStatus reports how much of the member's deductible is met this period
+func (s *BenefitsService) GetDeductibleStatus(ctx context.Context, req *pb.GetDeductibleStatusRequest) (*pb.GetDeductibleStatusResponse, error) {
+	log.Printf("GetDeductibleStatus called for member ID: %s, coverage type: %s", req.MemberId, req.CoverageType)
+
+	a, err := s.accumulated(ctx, req.MemberId, req.CoverageType, req.Network, req.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	progress := a.rules.Deductible(a.query, a.totals)
+
+	return &pb.GetDeductibleStatusResponse{
+		Status: &pb.DeductibleStatus{
+			CoverageType:         a.query.CoverageType,
+			IndividualDeductible: usd(progress.IndividualLimitCents),
+			IndividualMet:        usd(progress.IndividualMetCents),
+			FamilyDeductible:     usd(progress.FamilyLimitCents),
+			FamilyMet:            usd(progress.FamilyMetCents),
+			PeriodStart:          timestamppb.New(a.start),
+			PeriodEnd:            timestamppb.New(a.end),
+			Network:              a.query.Network,
+			FamilyAccumulation:   progress.Accumulation,
+			SharedWith:           progress.SharedWith,
+			Carryover:            usd(a.query.Carryover(a.carryover)),
+		},
+	}, nil
+}
+
+// GetOutOfPocketStatus reports how much the member has spent toward their out-of-pocket maximum this period
+func (s *BenefitsService) GetOutOfPocketStatus(ctx context.Context, req *pb.GetOutOfPocketStatusRequest) (*pb.GetOutOfPocketStatusResponse, error) {
+	log.Printf("GetOutOfPocketStatus called for member ID: %s, coverage type: %s", req.MemberId, req.CoverageType)
+
+	a, err := s.accumulated(ctx, req.MemberId, req.CoverageType, req.Network, req.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	progress := a.rules.OutOfPocket(a.query, a.totals)
+
+	return &pb.GetOutOfPocketStatusResponse{
+		Status: &pb.OutOfPocketStatus{
+			CoverageType:       a.query.CoverageType,
+			IndividualLimit:    usd(progress.IndividualLimitCents),
+			IndividualSpent:    usd(progress.IndividualMetCents),
+			FamilyLimit:        usd(progress.FamilyLimitCents),
+			FamilySpent:        usd(progress.FamilyMetCents),
+			PeriodStart:        timestamppb.New(a.start),
+			PeriodEnd:          timestamppb.New(a.end),
+			Network:            a.query.Network,
+			FamilyAccumulation: progress.Accumulation,
+			SharedWith:         progress.SharedWith,
+		},
+	}, nil
+}
+
+// activePlans resolves the plan and group behind each coverage type the
+// member holds on day, or behind just coverageType when it is set
+func (s *BenefitsService) activePlans(ctx context.Context, memberID string, coverageType pb.CoverageType, day time.Time) ([]repository.Plan, error) {
+	spans, err := s.repo.ListCoverageSpans(ctx, memberID)
+	if err != nil {
+		log.Printf("Error listing coverage spans: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve coverage")
+	}
+	if len(spans) == 0 {
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	types := []pb.CoverageType{coverageType}
+	if coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		types = coverage.ActiveTypes(spans, day)
+	}
+
+	plans := make([]repository.Plan, 0, len(types))
+	for _, t := range types {
+		span := coverage.Find(spans, t, day)
+		if span == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "member has no active %s coverage", coverage.TypeName(t))
+		}
+		plans = append(plans, repository.Plan{
+			PlanID:       span.PlanId,
+			GroupNumber:  span.GroupNumber,
+			CoverageType: t,
+			AsOf:         day,
+		})
+	}
+	return plans, nil
+}
+
+// accumulation is everything needed to measure a member against their plan's limits
+type accumulation struct {
+	rules      *accumulator.Rules
+	query      accumulator.Query
+	totals     []accumulator.Total
+	carryover  []accumulator.Total
+	start, end time.Time
+}
+
+// accumulated loads the member's plan rules and their family's totals for
+// the plan year containing asOf, today when not given. Medical and
+// in-network are assumed when not given.
+func (s *BenefitsService) accumulated(ctx context.Context, memberID string, coverageType pb.CoverageType, network pb.NetworkTier, asOf *timestamppb.Timestamp) (*accumulation, error) {
+	if memberID == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if _, err := access.AuthorizeMember(ctx, memberID); err != nil {
+		return nil, err
+	}
+	if coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		coverageType = pb.CoverageType_COVERAGE_TYPE_MEDICAL
+	}
+	if network == pb.NetworkTier_NETWORK_TIER_UNSPECIFIED {
+		network = pb.NetworkTier_NETWORK_TIER_IN_NETWORK
+	}
+	day := s.now()
+	if asOf != nil {
+		day = asOf.AsTime()
+	}
+
+	spans, err := s.repo.ListCoverageSpans(ctx, memberID)
+	if err != nil {
+		log.Printf("Error listing coverage spans: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve coverage")
+	}
+	if len(spans) == 0 {
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+	span := coverage.Find(spans, coverageType, day)
+	if span == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "member has no active %s coverage", coverage.TypeName(coverageType))
+	}
+	plan := repository.Plan{PlanID: span.PlanId, GroupNumber: span.GroupNumber, CoverageType: coverageType, AsOf: day}
+
+	rules, err := s.repo.GetCostShares(ctx, plan)
+	if errors.Is(err, repository.ErrCostSharesNotFound) {
+		return nil, status.Errorf(codes.NotFound, "plan has no %s cost shares on file", coverage.TypeName(coverageType))
+	}
+	if err != nil {
+		log.Printf("Error retrieving cost shares for plan %s: %v", plan.PlanID, err)
+		return nil, status.Error(codes.Internal, "failed to retrieve cost shares")
+	}
+
+	acc, err := s.ledger.Accumulated(ctx, memberID, plan.PlanID, day)
+	if err != nil {
+		log.Printf("Error retrieving accumulator totals: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve accumulators")
+	}
+
+	return &accumulation{
+		rules: rules,
+		query: accumulator.Query{
+			MemberID:       memberID,
+			CoverageType:   coverageType,
+			Network:        network,
+			FamilyCoverage: span.Tier != pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_ONLY,
+		},
+		totals:    acc.Totals,
+		carryover: acc.Carryover,
+		start:     acc.Period.Start,
+		end:       acc.Period.End,
+	}, nil
+}
+
+func usd(cents int64) *pb.Money {
+	return &pb.Money{Cents: cents, Currency: "USD"}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health-clone/backend/services/benefits/internal/priorauth"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/procedure"
+)
+
+// CheckPriorAuthRequired tells the member whether a procedure needs prior
+// authorization under the plan they hold on the service date, and where
+// their provider submits the request
+func (s *BenefitsService) CheckPriorAuthRequired(ctx context.Context, req *pb.CheckPriorAuthRequiredRequest) (*pb.CheckPriorAuthRequiredResponse, error) {
+	log.Printf("CheckPriorAuthRequired called for member ID: %s, procedure code: %s", req.MemberId, req.ProcedureCode)
+
+	if req.MemberId == "" || req.ProcedureCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and procedure_code are required")
+	}
+	if _, err := access.AuthorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+	code, err := procedure.Normalize(req.ProcedureCode)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	coverageType := req.CoverageType
+	if coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		coverageType = pb.CoverageType_COVERAGE_TYPE_MEDICAL
+	}
+	network := req.Network
+	if network == pb.NetworkTier_NETWORK_TIER_UNSPECIFIED {
+		network = pb.NetworkTier_NETWORK_TIER_IN_NETWORK
+	}
+	day := s.now()
+	if req.ServiceDate != nil {
+		day = req.ServiceDate.AsTime()
+	}
+
+	plans, err := s.activePlans(ctx, req.MemberId, coverageType, day)
+	if err != nil {
+		return nil, err
+	}
+	plan := plans[0]
+
+	rules, err := s.priorAuth.RuleSet(ctx)
+	if err != nil {
+		log.Printf("Error loading prior auth rules: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve prior authorization rules")
+	}
+
+	resp := &pb.CheckPriorAuthRequiredResponse{
+		ProcedureCode: code,
+		PlanId:        plan.PlanID,
+		RulesVersion:  rules.Version,
+	}
+	rule, ok := rules.Lookup(priorauth.Query{
+		ProcedureCode: code,
+		CoverageType:  coverageType,
+		Network:       network,
+		PlanID:        plan.PlanID,
+	})
+	if !ok {
+		resp.Reason = "Prior authorization is not required for this procedure"
+		return resp, nil
+	}
+
+	resp.Required = rule.Required
+	resp.Reason = rule.Reason
+	if rule.Required {
+		resp.SubmissionChannel = rule.Channel
+		resp.SubmissionContact = rule.Contact
+	}
+	return resp, nil
+}
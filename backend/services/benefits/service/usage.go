@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// GetBenefitUsage reports the member's use of their benefits against each
+// benefit's limits, and when they can next use a benefit they've used up
+func (s *BenefitsService) GetBenefitUsage(ctx context.Context, req *pb.GetBenefitUsageRequest) (*pb.GetBenefitUsageResponse, error) {
+	log.Printf("GetBenefitUsage called for member ID: %s, benefit ID: %s", req.MemberId, req.BenefitId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	// Usage counts every claim, sensitive services included
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+	day := s.now()
+	if req.AsOf != nil {
+		day = req.AsOf.AsTime()
+	}
+
+	plans, err := s.activePlans(ctx, req.MemberId, req.CoverageType, day)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := []*pb.BenefitUsage{}
+	found := false
+	for _, plan := range plans {
+		benefits, err := s.repo.ListBenefits(ctx, plan)
+		if err != nil {
+			log.Printf("Error listing benefits for plan %s: %v", plan.PlanID, err)
+			return nil, status.Error(codes.Internal, "failed to retrieve benefits")
+		}
+
+		for _, benefit := range benefits {
+			if req.BenefitId != "" && benefit.BenefitId != req.BenefitId {
+				continue
+			}
+			found = true
+
+			for _, limit := range benefit.Limits {
+				u, err := s.ledger.Usage(ctx, req.MemberId, plan.PlanID, benefit.BenefitCode, limit, day)
+				if err != nil {
+					log.Printf("Error measuring usage of benefit %s: %v", benefit.BenefitId, err)
+					return nil, status.Error(codes.Internal, "failed to retrieve benefit usage")
+				}
+
+				bu := &pb.BenefitUsage{
+					BenefitId:   benefit.BenefitId,
+					BenefitName: benefit.Name,
+					Limit:       limit,
+					WindowEnd:   timestamppb.New(u.WindowEnd),
+				}
+				// Lifetime limits have no start
+				if !u.WindowStart.IsZero() {
+					bu.WindowStart = timestamppb.New(u.WindowStart)
+				}
+				if limit.Kind == pb.LimitKind_LIMIT_KIND_DOLLAR {
+					bu.UsedAmount = usd(u.Used)
+					bu.RemainingAmount = usd(u.Remaining)
+				} else {
+					bu.UsedCount = int32(u.Used)
+					bu.RemainingCount = int32(u.Remaining)
+				}
+				if !u.NextEligible.IsZero() {
+					bu.NextEligibleDate = timestamppb.New(u.NextEligible)
+				}
+				usage = append(usage, bu)
+			}
+		}
+	}
+
+	if req.BenefitId != "" && !found {
+		return nil, status.Error(codes.NotFound, "benefit not found")
+	}
+	return &pb.GetBenefitUsageResponse{Usage: usage}, nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/sydney-health-clone/backend/services/gateway/internal/handler"
 	"github.com/sydney-health-clone/backend/services/gateway/internal/proxy"
+	"github.com/sydney-health-clone/backend/shared/access"
 	"github.com/sydney-health-clone/backend/shared/config"
 	"github.com/sydney-health-clone/backend/shared/logger"
 	
@@ -45,6 +46,11 @@ func main() {
 		zap.Int("port", cfg.Server.Port),
 	)
 
+	// Backend services only trust member and agent IDs the gateway signs
+	if os.Getenv(access.ServiceSecretEnv) == "" {
+		logger.Fatal(access.ServiceSecretEnv + " must be set to sign caller identities for backend services")
+	}
+
 	// Initialize service proxy
 	serviceProxy, err := proxy.NewServiceProxy(cfg)
 	if err != nil {
@@ -109,25 +115,81 @@ func setupRoutes(proxy *proxy.ServiceProxy, cfg *config.Config) *mux.Router {
 	// Health check
 	r.HandleFunc("/health", handler.HealthCheck).Methods("GET")
 	
+	// Apple Wallet pass web service; authenticated per pass, not by JWT
+	wallet := r.PathPrefix("/wallet/v1").Subrouter()
+	wallet.HandleFunc("/devices/{deviceLibraryIdentifier}/registrations/{passTypeIdentifier}/{serialNumber}", proxy.RegisterWalletDevice).Methods("POST")
+	wallet.HandleFunc("/devices/{deviceLibraryIdentifier}/registrations/{passTypeIdentifier}/{serialNumber}", proxy.UnregisterWalletDevice).Methods("DELETE")
+	wallet.HandleFunc("/devices/{deviceLibraryIdentifier}/registrations/{passTypeIdentifier}", proxy.ListUpdatedWalletPasses).Methods("GET")
+	wallet.HandleFunc("/passes/{passTypeIdentifier}/{serialNumber}", proxy.GetLatestWalletPass).Methods("GET")
+	wallet.HandleFunc("/log", proxy.LogWalletErrors).Methods("POST")
+	
 	// API routes
 	api := r.PathPrefix("/api/v1").Subrouter()
 	
 	// Member routes
 	api.HandleFunc("/members/{memberId}", proxy.GetMember).Methods("GET")
 	api.HandleFunc("/members/{memberId}", proxy.UpdateMember).Methods("PUT")
+	api.HandleFunc("/members/{memberId}/contact-verifications/{verificationId}", proxy.VerifyContact).Methods("POST")
+	api.HandleFunc("/members/{memberId}/preferences", proxy.GetMemberPreferences).Methods("GET")
+	api.HandleFunc("/members/{memberId}/preferences", proxy.UpdateMemberPreferences).Methods("PUT")
 	api.HandleFunc("/members/{memberId}/card", proxy.GetMemberCard).Methods("GET")
+	api.HandleFunc("/members/{memberId}/card.png", proxy.GetMemberCardPNG).Methods("GET")
+	api.HandleFunc("/members/{memberId}/card.pdf", proxy.GetMemberCardPDF).Methods("GET")
+	api.HandleFunc("/members/{memberId}/wallet-pass", proxy.GetWalletPass).Methods("GET")
 	api.HandleFunc("/members/{memberId}/dependents", proxy.ListDependents).Methods("GET")
+	api.HandleFunc("/members/{memberId}/coverage-history", proxy.GetCoverageHistory).Methods("GET")
+	api.HandleFunc("/members/{memberId}/eligibility", proxy.CheckEligibility).Methods("GET")
+	api.HandleFunc("/members/{memberId}/proxy-grants", proxy.ListProxyGrants).Methods("GET")
+	api.HandleFunc("/members/{memberId}/proxy-grants", proxy.GrantProxyAccess).Methods("POST")
+	api.HandleFunc("/members/{memberId}/proxy-grants/{grantId}", proxy.RevokeProxyAccess).Methods("DELETE")
+	
+	// Support agent routes
+	api.HandleFunc("/agent/members/search", proxy.SearchMembers).Methods("GET")
+	api.HandleFunc("/agent/reviews/pending", proxy.ListPendingReviews).Methods("GET")
+	api.HandleFunc("/agent/reviews/{reviewId}/moderation", proxy.ModerateReview).Methods("POST")
 	
 	// Benefits routes
 	api.HandleFunc("/members/{memberId}/benefits", proxy.GetBenefitsSummary).Methods("GET")
 	api.HandleFunc("/members/{memberId}/benefits/{benefitId}", proxy.GetBenefitDetails).Methods("GET")
 	api.HandleFunc("/members/{memberId}/deductible", proxy.GetDeductibleStatus).Methods("GET")
 	api.HandleFunc("/members/{memberId}/out-of-pocket", proxy.GetOutOfPocketStatus).Methods("GET")
+	api.HandleFunc("/members/{memberId}/benefit-usage", proxy.GetBenefitUsage).Methods("GET")
+	api.HandleFunc("/members/{memberId}/prior-auth-requirements", proxy.CheckPriorAuthRequired).Methods("GET")
+	
+	// Prior authorization routes
+	api.HandleFunc("/members/{memberId}/authorizations", proxy.ListAuthorizations).Methods("GET")
+	api.HandleFunc("/members/{memberId}/authorizations", proxy.SubmitAuthorization).Methods("POST")
+	api.HandleFunc("/members/{memberId}/authorizations/{authorizationId}", proxy.GetAuthorization).Methods("GET")
+	
+	// Spending account routes
+	api.HandleFunc("/members/{memberId}/spending-accounts", proxy.ListSpendingAccounts).Methods("GET")
+	api.HandleFunc("/members/{memberId}/spending-accounts/{accountId}", proxy.GetSpendingAccount).Methods("GET")
+	api.HandleFunc("/members/{memberId}/spending-accounts/{accountId}/transactions", proxy.ListSpendingTransactions).Methods("GET")
+	api.HandleFunc("/members/{memberId}/spending-accounts/{accountId}/claim-payments", proxy.PayClaimFromAccount).Methods("POST")
 	
 	// Provider routes
 	api.HandleFunc("/providers/search", proxy.SearchProviders).Methods("GET")
+	api.HandleFunc("/providers/suggest", proxy.SuggestProviders).Methods("GET")
 	api.HandleFunc("/providers/{providerId}", proxy.GetProvider).Methods("GET")
 	api.HandleFunc("/providers/{providerId}/network-status", proxy.CheckNetworkStatus).Methods("GET")
+	api.HandleFunc("/providers/{providerId}/availability", proxy.GetAvailability).Methods("GET")
+	api.HandleFunc("/providers/{providerId}/reviews", proxy.ListReviews).Methods("GET")
+	api.HandleFunc("/members/{memberId}/reviews", proxy.SubmitReview).Methods("POST")
+	
+	// Appointment routes
+	api.HandleFunc("/members/{memberId}/appointments", proxy.ListAppointments).Methods("GET")
+	api.HandleFunc("/members/{memberId}/appointments", proxy.HoldAppointment).Methods("POST")
+	api.HandleFunc("/members/{memberId}/appointments.ics", proxy.ExportAppointments).Methods("GET")
+	api.HandleFunc("/members/{memberId}/appointments/{appointmentId}", proxy.GetAppointment).Methods("GET")
+	api.HandleFunc("/members/{memberId}/appointments/{appointmentId}/book", proxy.BookAppointment).Methods("POST")
+	api.HandleFunc("/members/{memberId}/appointments/{appointmentId}/reschedule", proxy.RescheduleAppointment).Methods("POST")
+	api.HandleFunc("/members/{memberId}/appointments/{appointmentId}/cancel", proxy.CancelAppointment).Methods("POST")
+	
+	// Care team routes
+	api.HandleFunc("/members/{memberId}/care-team", proxy.GetCareTeam).Methods("GET")
+	api.HandleFunc("/members/{memberId}/care-team/pcp", proxy.AssignPrimaryCareProvider).Methods("PUT")
+	api.HandleFunc("/members/{memberId}/care-team/favorites/{providerId}", proxy.SaveFavoriteProvider).Methods("PUT")
+	api.HandleFunc("/members/{memberId}/care-team/favorites/{providerId}", proxy.RemoveFavoriteProvider).Methods("DELETE")
 	
 	// Claims routes
 	api.HandleFunc("/members/{memberId}/claims", proxy.ListClaims).Methods("GET")
@@ -141,8 +203,9 @@ func setupRoutes(proxy *proxy.ServiceProxy, cfg *config.Config) *mux.Router {
 	api.HandleFunc("/conversations/{conversationId}/messages", proxy.SendMessage).Methods("POST")
 	api.HandleFunc("/messages/mark-read", proxy.MarkAsRead).Methods("POST")
 	
-	// Apply auth middleware to all API routes
+	// Apply auth middleware to all API routes, then the member access policy
 	api.Use(handler.AuthMiddleware(cfg.Auth))
+	api.Use(proxy.MemberAccessMiddleware)
 	
 	return r
 }
@@ -20,11 +20,31 @@ const (
 )
 
 type UserClaims struct {
-	MemberID string `json:"member_id"`
-	Email    string `json:"email"`
+	MemberID string   `json:"member_id"`
+	Email    string   `json:"email"`
+	Roles    []string `json:"roles"`
 	jwt.StandardClaims
 }
 
+// HasRole reports whether the token grants the role
+func (c *UserClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AgentID identifies a support agent in audit records: the token subject,
+// or its email when the issuer sets no subject
+func (c *UserClaims) AgentID() string {
+	if c.Subject != "" {
+		return c.Subject
+	}
+	return c.Email
+}
+
 func AuthMiddleware(authConfig config.AuthConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,174 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sydney-health-clone/backend/services/gateway/internal/handler"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type accessContextKey struct{}
+
+// MemberAccessMiddleware forwards the caller's identity to backend services and
+// evaluates every request addressed to another member's record against the
+// access policy. Must run after AuthMiddleware.
+func (p *ServiceProxy) MemberAccessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := handler.GetUserClaims(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := access.WithRequester(r.Context(), claims.MemberID)
+		if claims.HasRole(access.AgentRole) {
+			ctx = access.WithAgent(ctx, claims.AgentID())
+		}
+		level := pb.AccessLevel_ACCESS_LEVEL_FULL
+
+		if memberID := mux.Vars(r)["memberId"]; memberID != "" && memberID != claims.MemberID {
+			var err error
+			level, err = p.checkMemberAccess(ctx, claims.MemberID, memberID)
+			if err != nil {
+				handleError(w, err)
+				return
+			}
+			if level != pb.AccessLevel_ACCESS_LEVEL_FULL && level != pb.AccessLevel_ACCESS_LEVEL_RESTRICTED {
+				respondError(w, http.StatusForbidden, "Access to this member is not permitted")
+				return
+			}
+			ctx = access.WithMemberAccess(ctx, memberID, level)
+		}
+
+		ctx = context.WithValue(ctx, accessContextKey{}, level)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (p *ServiceProxy) checkMemberAccess(ctx context.Context, requesterID, memberID string) (pb.AccessLevel, error) {
+	resp, err := p.memberClient.CheckMemberAccess(ctx, &pb.CheckMemberAccessRequest{
+		RequesterMemberId: requesterID,
+		MemberId:          memberID,
+	})
+	if err != nil {
+		return pb.AccessLevel_ACCESS_LEVEL_NONE, err
+	}
+	return resp.AccessLevel, nil
+}
+
+// authorizeMember checks the caller's access to a member named outside the
+// request path, such as in the query string, which MemberAccessMiddleware
+// doesn't see. It returns the context to call backend services with, or
+// responds with the error and returns false when the caller may not see the
+// member.
+func (p *ServiceProxy) authorizeMember(w http.ResponseWriter, r *http.Request, memberID string) (context.Context, bool) {
+	ctx := r.Context()
+	claims, ok := handler.GetUserClaims(ctx)
+	if !ok || memberID == "" || memberID == claims.MemberID {
+		return ctx, true
+	}
+
+	level, err := p.checkMemberAccess(ctx, claims.MemberID, memberID)
+	if err != nil {
+		handleError(w, err)
+		return nil, false
+	}
+	if level != pb.AccessLevel_ACCESS_LEVEL_FULL && level != pb.AccessLevel_ACCESS_LEVEL_RESTRICTED {
+		respondError(w, http.StatusForbidden, "Access to this member is not permitted")
+		return nil, false
+	}
+	return access.WithMemberAccess(ctx, memberID, level), true
+}
+
+// accessLevel returns the level MemberAccessMiddleware granted for this request
+func accessLevel(ctx context.Context) pb.AccessLevel {
+	if level, ok := ctx.Value(accessContextKey{}).(pb.AccessLevel); ok {
+		return level
+	}
+	return pb.AccessLevel_ACCESS_LEVEL_NONE
+}
+
+// Proxy Grant Handlers
+
+func (p *ServiceProxy) ListProxyGrants(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	ctx := r.Context()
+	resp, err := p.memberClient.ListProxyGrants(ctx, &pb.ListProxyGrantsRequest{
+		MemberId:       memberID,
+		IncludeRevoked: r.URL.Query().Get("include_revoked") == "true",
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Grants)
+}
+
+func (p *ServiceProxy) GrantProxyAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	var req struct {
+		ProxyMemberID string `json:"proxy_member_id"`
+		ExpiresAt     string `json:"expires_at"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var expiresAt *timestamppb.Timestamp
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "expires_at must be an RFC 3339 timestamp")
+			return
+		}
+		expiresAt = timestamppb.New(t)
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.GrantProxyAccess(ctx, &pb.GrantProxyAccessRequest{
+		MemberId:      memberID,
+		ProxyMemberId: req.ProxyMemberID,
+		ExpiresAt:     expiresAt,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp.Grant)
+}
+
+func (p *ServiceProxy) RevokeProxyAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	grantID := vars["grantId"]
+
+	ctx := r.Context()
+	resp, err := p.memberClient.RevokeProxyAccess(ctx, &pb.RevokeProxyAccessRequest{
+		MemberId: memberID,
+		GrantId:  grantID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Grant)
+}
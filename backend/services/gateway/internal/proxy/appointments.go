@@ -0,0 +1,301 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+)
+
+// GetAvailability lists a provider's open appointment slots. start and end
+// are RFC 3339 times; the window defaults to the next 14 days and may span
+// at most 31.
+func (p *ServiceProxy) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	providerID := vars["providerId"]
+	query := r.URL.Query()
+
+	start, err := parseTime(query.Get("start"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "start must be an RFC 3339 time")
+		return
+	}
+	end, err := parseTime(query.Get("end"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "end must be an RFC 3339 time")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.GetAvailability(ctx, &pb.GetAvailabilityRequest{
+		ProviderId: providerID,
+		LocationId: query.Get("location_id"),
+		StartTime:  start,
+		EndTime:    end,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// HoldAppointment holds an open slot for the member for 10 minutes while
+// they confirm it
+func (p *ServiceProxy) HoldAppointment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	var req struct {
+		ProviderID  string `json:"provider_id"`
+		LocationID  string `json:"location_id"`
+		StartTime   string `json:"start_time"`
+		VisitReason string `json:"visit_reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	start, err := parseTime(req.StartTime)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "start_time must be an RFC 3339 time")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.HoldAppointment(ctx, &pb.HoldAppointmentRequest{
+		MemberId:    memberID,
+		ProviderId:  req.ProviderID,
+		LocationId:  req.LocationID,
+		StartTime:   start,
+		VisitReason: req.VisitReason,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp.Appointment)
+}
+
+// BookAppointment books the member's held slot. An empty body keeps the
+// visit reason given with the hold.
+func (p *ServiceProxy) BookAppointment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	appointmentID := vars["appointmentId"]
+
+	var req struct {
+		VisitReason string `json:"visit_reason"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.BookAppointment(ctx, &pb.BookAppointmentRequest{
+		AppointmentId: appointmentID,
+		MemberId:      memberID,
+		VisitReason:   req.VisitReason,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Appointment)
+}
+
+// RescheduleAppointment moves the member's booked appointment to another open
+// slot, at the same location unless location_id is given
+func (p *ServiceProxy) RescheduleAppointment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	appointmentID := vars["appointmentId"]
+
+	var req struct {
+		LocationID string `json:"location_id"`
+		StartTime  string `json:"start_time"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	start, err := parseTime(req.StartTime)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "start_time must be an RFC 3339 time")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.RescheduleAppointment(ctx, &pb.RescheduleAppointmentRequest{
+		AppointmentId: appointmentID,
+		MemberId:      memberID,
+		LocationId:    req.LocationID,
+		StartTime:     start,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Appointment)
+}
+
+// CancelAppointment cancels the member's booked appointment or releases their
+// hold
+func (p *ServiceProxy) CancelAppointment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	appointmentID := vars["appointmentId"]
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.CancelAppointment(ctx, &pb.CancelAppointmentRequest{
+		AppointmentId: appointmentID,
+		MemberId:      memberID,
+		Reason:        req.Reason,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Appointment)
+}
+
+// ListAppointments lists the member's appointments, soonest first. status
+// takes a comma-separated list such as booked,held, and start is an RFC 3339
+// time before which appointments are left out.
+func (p *ServiceProxy) ListAppointments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	query := r.URL.Query()
+
+	start, err := parseTime(query.Get("start"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "start must be an RFC 3339 time")
+		return
+	}
+
+	req := &pb.ListAppointmentsRequest{
+		MemberId:  memberID,
+		StartTime: start,
+		Page: &pb.PageRequest{
+			PageToken: query.Get("page_token"),
+		},
+	}
+
+	if v := query.Get("status"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			value, ok := pb.AppointmentStatus_value["APPOINTMENT_STATUS_"+strings.ToUpper(strings.TrimSpace(name))]
+			if !ok || value == 0 {
+				respondError(w, http.StatusBadRequest, "unknown status "+strconv.Quote(name))
+				return
+			}
+			req.Statuses = append(req.Statuses, pb.AppointmentStatus(value))
+		}
+	}
+
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			respondError(w, http.StatusBadRequest, "page_size must be a positive number")
+			return
+		}
+		req.Page.PageSize = int32(size)
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.ListAppointments(ctx, req)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// GetAppointment returns one of the member's appointments
+func (p *ServiceProxy) GetAppointment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	appointmentID := vars["appointmentId"]
+
+	ctx := r.Context()
+	resp, err := p.providerClient.GetAppointment(ctx, &pb.GetAppointmentRequest{
+		AppointmentId: appointmentID,
+		MemberId:      memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Appointment)
+}
+
+// ExportAppointments downloads the member's booked and cancelled
+// appointments as an iCalendar file
+func (p *ServiceProxy) ExportAppointments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	ctx := r.Context()
+	resp, err := p.providerClient.ExportAppointments(ctx, &pb.ExportAppointmentsRequest{
+		MemberId: memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="appointments.ics"`)
+	respondBinary(w, resp.ContentType, resp.Data)
+}
+
+// parseTime parses an RFC 3339 time, nil when s is empty
+func parseTime(s string) (*timestamppb.Timestamp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return timestamppb.New(t), nil
+}
@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+)
+
+// SubmitAuthorization records a prior authorization request for the member.
+// Dates are YYYY-MM-DD; end_date defaults to a 90-day window from start_date.
+func (p *ServiceProxy) SubmitAuthorization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	var req struct {
+		CoverageType         string   `json:"coverage_type"`
+		ProcedureCode        string   `json:"procedure_code"`
+		DiagnosisCodes       []string `json:"diagnosis_codes"`
+		RequestingProviderID string   `json:"requesting_provider_id"`
+		ServicingProviderID  string   `json:"servicing_provider_id"`
+		RequestedUnits       int32    `json:"requested_units"`
+		StartDate            string   `json:"start_date"`
+		EndDate              string   `json:"end_date"`
+		Notes                string   `json:"notes"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	startDate, err := parseAsOf(req.StartDate)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "start_date must be a date in YYYY-MM-DD format")
+		return
+	}
+	endDate, err := parseAsOf(req.EndDate)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "end_date must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.priorAuthClient.SubmitAuthorization(ctx, &pb.SubmitAuthorizationRequest{
+		MemberId:             memberID,
+		CoverageType:         parseCoverageType(req.CoverageType),
+		ProcedureCode:        req.ProcedureCode,
+		DiagnosisCodes:       req.DiagnosisCodes,
+		RequestingProviderId: req.RequestingProviderID,
+		ServicingProviderId:  req.ServicingProviderID,
+		RequestedUnits:       req.RequestedUnits,
+		RequestedStartDate:   startDate,
+		RequestedEndDate:     endDate,
+		Notes:                req.Notes,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp.Authorization)
+}
+
+// ListAuthorizations lists the member's authorizations, newest first. status
+// takes a comma-separated list such as approved,partially_approved.
+func (p *ServiceProxy) ListAuthorizations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	query := r.URL.Query()
+
+	req := &pb.ListAuthorizationsRequest{
+		MemberId: memberID,
+		Page: &pb.PageRequest{
+			PageToken: query.Get("page_token"),
+		},
+	}
+
+	if v := query.Get("status"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			value, ok := pb.AuthorizationStatus_value["AUTHORIZATION_STATUS_"+strings.ToUpper(strings.TrimSpace(name))]
+			if !ok || value == 0 {
+				respondError(w, http.StatusBadRequest, "unknown status "+strconv.Quote(name))
+				return
+			}
+			req.Statuses = append(req.Statuses, pb.AuthorizationStatus(value))
+		}
+	}
+
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			respondError(w, http.StatusBadRequest, "page_size must be a positive number")
+			return
+		}
+		req.Page.PageSize = int32(size)
+	}
+
+	ctx := r.Context()
+	resp, err := p.priorAuthClient.ListAuthorizations(ctx, req)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// GetAuthorization returns one of the member's authorizations with its status
+// history
+func (p *ServiceProxy) GetAuthorization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	authorizationID := vars["authorizationId"]
+
+	ctx := r.Context()
+	resp, err := p.priorAuthClient.GetAuthorization(ctx, &pb.GetAuthorizationRequest{
+		AuthorizationId: authorizationID,
+		MemberId:        memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Authorization)
+}
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+)
+
+// GetCareTeam returns the member's primary care provider, favorites and
+// recently seen providers
+func (p *ServiceProxy) GetCareTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	ctx := r.Context()
+	resp, err := p.providerClient.GetCareTeam(ctx, &pb.GetCareTeamRequest{
+		MemberId: memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// AssignPrimaryCareProvider designates the member's primary care provider.
+// effective_date is YYYY-MM-DD and defaults to today.
+func (p *ServiceProxy) AssignPrimaryCareProvider(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	var req struct {
+		ProviderID    string `json:"provider_id"`
+		LocationID    string `json:"location_id"`
+		EffectiveDate string `json:"effective_date"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	effective, err := parseAsOf(req.EffectiveDate)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "effective_date must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.AssignPrimaryCareProvider(ctx, &pb.AssignPrimaryCareProviderRequest{
+		MemberId:      memberID,
+		ProviderId:    req.ProviderID,
+		LocationId:    req.LocationID,
+		EffectiveDate: effective,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Assignment)
+}
+
+// SaveFavoriteProvider adds a provider to the member's favorites, or changes
+// the note on one already there
+func (p *ServiceProxy) SaveFavoriteProvider(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	providerID := vars["providerId"]
+
+	// The body is optional; a favorite needn't have a note
+	var req struct {
+		Note string `json:"note"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.SaveFavoriteProvider(ctx, &pb.SaveFavoriteProviderRequest{
+		MemberId:   memberID,
+		ProviderId: providerID,
+		Note:       req.Note,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Favorite)
+}
+
+// RemoveFavoriteProvider removes a provider from the member's favorites
+func (p *ServiceProxy) RemoveFavoriteProvider(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	providerID := vars["providerId"]
+
+	ctx := r.Context()
+	_, err := p.providerClient.RemoveFavoriteProvider(ctx, &pb.RemoveFavoriteProviderRequest{
+		MemberId:   memberID,
+		ProviderId: providerID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+)
+
+// VerifyContact confirms a pending email or phone change with the code sent
+// to the new address
+func (p *ServiceProxy) VerifyContact(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	verificationID := vars["verificationId"]
+
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Code == "" {
+		respondError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.VerifyContact(ctx, &pb.VerifyContactRequest{
+		MemberId:       memberID,
+		VerificationId: verificationID,
+		Code:           req.Code,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Member)
+}
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (p *ServiceProxy) GetCoverageHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	coverageType := r.URL.Query().Get("coverage_type")
+
+	ctx := r.Context()
+	resp, err := p.memberClient.GetCoverageHistory(ctx, &pb.GetCoverageHistoryRequest{
+		MemberId:     memberID,
+		CoverageType: parseCoverageType(coverageType),
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// CheckEligibility answers whether the member was covered on date_of_service
+// (YYYY-MM-DD, default today).
+func (p *ServiceProxy) CheckEligibility(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	query := r.URL.Query()
+
+	req := &pb.CheckEligibilityRequest{
+		MemberId:     memberID,
+		CoverageType: parseCoverageType(query.Get("coverage_type")),
+	}
+	if req.CoverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		respondError(w, http.StatusBadRequest, "coverage_type is required")
+		return
+	}
+
+	if v := query.Get("date_of_service"); v != "" {
+		dateOfService, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "date_of_service must be YYYY-MM-DD")
+			return
+		}
+		req.DateOfService = timestamppb.New(dateOfService)
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.CheckEligibility(ctx, req)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
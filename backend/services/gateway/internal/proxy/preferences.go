@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/preferences"
+
+	"github.com/gorilla/mux"
+)
+
+// preferencesBody is the JSON form of MemberPreferences, with categories and
+// channels by their lower-case short names, e.g. {"eob": ["mail"]}. Paperless
+// consent is a pointer so that a PUT leaving it out can be told from one
+// revoking it.
+type preferencesBody struct {
+	Channels   map[string][]string `json:"channels"`
+	QuietHours struct {
+		Enabled  bool   `json:"enabled"`
+		Start    string `json:"start,omitempty"`
+		End      string `json:"end,omitempty"`
+		TimeZone string `json:"time_zone,omitempty"`
+	} `json:"quiet_hours"`
+	Language  string `json:"language"`
+	Paperless struct {
+		Consented   *bool      `json:"consented"`
+		ConsentedAt *time.Time `json:"consented_at,omitempty"`
+		RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	} `json:"paperless"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func preferencesJSON(prefs *pb.MemberPreferences) preferencesBody {
+	var body preferencesBody
+	body.Channels = make(map[string][]string)
+	for _, pref := range prefs.Channels {
+		channels := []string{}
+		for _, channel := range pref.Channels {
+			channels = append(channels, strings.ToLower(preferences.ChannelName(channel)))
+		}
+		body.Channels[strings.ToLower(preferences.CategoryName(pref.Category))] = channels
+	}
+
+	body.QuietHours.Enabled = prefs.QuietHours.GetEnabled()
+	body.QuietHours.Start = prefs.QuietHours.GetStart()
+	body.QuietHours.End = prefs.QuietHours.GetEnd()
+	body.QuietHours.TimeZone = prefs.QuietHours.GetTimeZone()
+	body.Language = prefs.Language
+
+	consented := prefs.Paperless.GetConsented()
+	body.Paperless.Consented = &consented
+	if ts := prefs.Paperless.GetConsentedAt(); ts != nil {
+		t := ts.AsTime()
+		body.Paperless.ConsentedAt = &t
+	}
+	if ts := prefs.Paperless.GetRevokedAt(); ts != nil {
+		t := ts.AsTime()
+		body.Paperless.RevokedAt = &t
+	}
+	if prefs.UpdatedAt != nil {
+		t := prefs.UpdatedAt.AsTime()
+		body.UpdatedAt = &t
+	}
+	return body
+}
+
+func (p *ServiceProxy) GetMemberPreferences(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	ctx := r.Context()
+	resp, err := p.memberClient.GetMemberPreferences(ctx, &pb.GetMemberPreferencesRequest{
+		MemberId: memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preferencesJSON(resp.Preferences))
+}
+
+// UpdateMemberPreferences replaces the member's preferences. Categories left
+// out of channels are reset to their defaults; paperless.consented must be
+// given.
+func (p *ServiceProxy) UpdateMemberPreferences(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	var body preferencesBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if body.Paperless.Consented == nil {
+		respondError(w, http.StatusBadRequest, "paperless.consented is required; send false to revoke paperless consent")
+		return
+	}
+
+	prefs := &pb.MemberPreferences{
+		MemberId: memberID,
+		QuietHours: &pb.QuietHours{
+			Enabled:  body.QuietHours.Enabled,
+			Start:    body.QuietHours.Start,
+			End:      body.QuietHours.End,
+			TimeZone: body.QuietHours.TimeZone,
+		},
+		Language: body.Language,
+		Paperless: &pb.PaperlessConsent{
+			Consented: *body.Paperless.Consented,
+		},
+	}
+	for name, channelNames := range body.Channels {
+		category, ok := preferences.ParseCategory(name)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown notification category: "+name)
+			return
+		}
+		pref := &pb.ChannelPreference{Category: category}
+		for _, channelName := range channelNames {
+			channel, ok := preferences.ParseChannel(channelName)
+			if !ok {
+				respondError(w, http.StatusBadRequest, "Unknown notification channel: "+channelName)
+				return
+			}
+			pref.Channels = append(pref.Channels, channel)
+		}
+		prefs.Channels = append(prefs.Channels, pref)
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.UpdateMemberPreferences(ctx, &pb.UpdateMemberPreferencesRequest{
+		Preferences: prefs,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preferencesJSON(resp.Preferences))
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/sydney-health-clone/backend/services/gateway/internal/handler"
+	"github.com/sydney-health-clone/backend/shared/access"
 	"github.com/sydney-health-clone/backend/shared/config"
 	"github.com/sydney-health-clone/backend/shared/logger"
 	pb "github.com/sydney-health-clone/backend/shared/pb"
@@ -14,7 +17,10 @@ import (
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type ServiceProxy struct {
@@ -24,6 +30,8 @@ type ServiceProxy struct {
 	providerClient   pb.ProviderServiceClient
 	claimsClient     pb.ClaimsServiceClient
 	messagingClient  pb.MessagingServiceClient
+	priorAuthClient  pb.PriorAuthServiceClient
+	spendingClient   pb.SpendingAccountServiceClient
 }
 
 func NewServiceProxy(cfg *config.Config) (*ServiceProxy, error) {
@@ -64,6 +72,20 @@ func NewServiceProxy(cfg *config.Config) (*ServiceProxy, error) {
 	}
 	proxy.messagingClient = pb.NewMessagingServiceClient(messagingConn)
 	
+	// Connect to Prior Authorization Service
+	priorAuthConn, err := createConnection(cfg.Services.PriorAuthService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to prior authorization service: %w", err)
+	}
+	proxy.priorAuthClient = pb.NewPriorAuthServiceClient(priorAuthConn)
+	
+	// Connect to Spending Account Service
+	spendingConn, err := createConnection(cfg.Services.SpendingService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to spending account service: %w", err)
+	}
+	proxy.spendingClient = pb.NewSpendingAccountServiceClient(spendingConn)
+	
 	return proxy, nil
 }
 
@@ -76,6 +98,7 @@ func createConnection(endpoint config.ServiceEndpoint) (*grpc.ClientConn, error)
 	conn, err := grpc.DialContext(ctx, target,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(access.IdentityInterceptor()),
 	)
 	
 	if err != nil {
@@ -126,6 +149,12 @@ func (p *ServiceProxy) UpdateMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// New emails and phone numbers are accepted but not applied until verified
+	if len(resp.PendingContactChanges) > 0 {
+		respondJSON(w, http.StatusAccepted, resp)
+		return
+	}
+	
 	respondJSON(w, http.StatusOK, resp.Member)
 }
 
@@ -148,6 +177,40 @@ func (p *ServiceProxy) GetMemberCard(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp.Card)
 }
 
+func (p *ServiceProxy) GetMemberCardPNG(w http.ResponseWriter, r *http.Request) {
+	p.renderMemberCard(w, r, pb.CardFormat_CARD_FORMAT_PNG)
+}
+
+func (p *ServiceProxy) GetMemberCardPDF(w http.ResponseWriter, r *http.Request) {
+	p.renderMemberCard(w, r, pb.CardFormat_CARD_FORMAT_PDF)
+}
+
+func (p *ServiceProxy) renderMemberCard(w http.ResponseWriter, r *http.Request, format pb.CardFormat) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	coverageType := r.URL.Query().Get("coverage_type")
+	
+	side := pb.CardSide_CARD_SIDE_FRONT
+	if r.URL.Query().Get("side") == "back" {
+		side = pb.CardSide_CARD_SIDE_BACK
+	}
+	
+	ctx := r.Context()
+	resp, err := p.memberClient.RenderMemberCard(ctx, &pb.RenderMemberCardRequest{
+		MemberId:     memberID,
+		CoverageType: parseCoverageType(coverageType),
+		Format:       format,
+		Side:         side,
+	})
+	
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	
+	respondBinary(w, resp.ContentType, resp.Data)
+}
+
 func (p *ServiceProxy) ListDependents(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	memberID := vars["memberId"]
@@ -209,11 +272,18 @@ func (p *ServiceProxy) GetDeductibleStatus(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	memberID := vars["memberId"]
 	coverageType := r.URL.Query().Get("coverage_type")
+	asOf, err := parseAsOf(r.URL.Query().Get("as_of"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "as_of must be a date in YYYY-MM-DD format")
+		return
+	}
 	
 	ctx := r.Context()
 	resp, err := p.benefitsClient.GetDeductibleStatus(ctx, &pb.GetDeductibleStatusRequest{
 		MemberId:     memberID,
 		CoverageType: parseCoverageType(coverageType),
+		Network:      parseNetworkTier(r.URL.Query().Get("network")),
+		AsOf:         asOf,
 	})
 	
 	if err != nil {
@@ -228,11 +298,18 @@ func (p *ServiceProxy) GetOutOfPocketStatus(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	memberID := vars["memberId"]
 	coverageType := r.URL.Query().Get("coverage_type")
+	asOf, err := parseAsOf(r.URL.Query().Get("as_of"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "as_of must be a date in YYYY-MM-DD format")
+		return
+	}
 	
 	ctx := r.Context()
 	resp, err := p.benefitsClient.GetOutOfPocketStatus(ctx, &pb.GetOutOfPocketStatusRequest{
 		MemberId:     memberID,
 		CoverageType: parseCoverageType(coverageType),
+		Network:      parseNetworkTier(r.URL.Query().Get("network")),
+		AsOf:         asOf,
 	})
 	
 	if err != nil {
@@ -243,17 +320,158 @@ func (p *ServiceProxy) GetOutOfPocketStatus(w http.ResponseWriter, r *http.Reque
 	respondJSON(w, http.StatusOK, resp.Status)
 }
 
+func (p *ServiceProxy) GetBenefitUsage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	coverageType := r.URL.Query().Get("coverage_type")
+	asOf, err := parseAsOf(r.URL.Query().Get("as_of"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "as_of must be a date in YYYY-MM-DD format")
+		return
+	}
+	
+	ctx := r.Context()
+	resp, err := p.benefitsClient.GetBenefitUsage(ctx, &pb.GetBenefitUsageRequest{
+		MemberId:     memberID,
+		BenefitId:    r.URL.Query().Get("benefit_id"),
+		CoverageType: parseCoverageType(coverageType),
+		AsOf:         asOf,
+	})
+	
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (p *ServiceProxy) CheckPriorAuthRequired(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	procedureCode := r.URL.Query().Get("procedure_code")
+	if procedureCode == "" {
+		respondError(w, http.StatusBadRequest, "procedure_code is required")
+		return
+	}
+	serviceDate, err := parseAsOf(r.URL.Query().Get("service_date"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "service_date must be a date in YYYY-MM-DD format")
+		return
+	}
+	
+	ctx := r.Context()
+	resp, err := p.benefitsClient.CheckPriorAuthRequired(ctx, &pb.CheckPriorAuthRequiredRequest{
+		MemberId:      memberID,
+		ProcedureCode: procedureCode,
+		CoverageType:  parseCoverageType(r.URL.Query().Get("coverage_type")),
+		Network:       parseNetworkTier(r.URL.Query().Get("network")),
+		ServiceDate:   serviceDate,
+	})
+	
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, resp)
+}
+
 // Provider Service Handlers
 
+// SearchProviders searches the provider directory. q, provider_name and
+// specialty tolerate typos. With a location (a ZIP code, "City, ST" or
+// "latitude,longitude") only providers within radius miles are returned.
 func (p *ServiceProxy) SearchProviders(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement provider search with query parameters
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"providers": []interface{}{},
-		"page": map[string]interface{}{
-			"next_page_token": "",
-			"total_count":     0,
+	query := r.URL.Query()
+	
+	req := &pb.SearchProvidersRequest{
+		MemberId:     query.Get("member_id"),
+		Query:        query.Get("q"),
+		Specialty:    query.Get("specialty"),
+		Location:     query.Get("location"),
+		ProviderName: query.Get("provider_name"),
+		CoverageType: parseCoverageType(query.Get("coverage_type")),
+		Page: &pb.PageRequest{
+			PageToken: query.Get("page_token"),
 		},
-	})
+	}
+	
+	if v := query.Get("radius"); v != "" {
+		radius, err := strconv.ParseFloat(v, 64)
+		if err != nil || radius <= 0 {
+			respondError(w, http.StatusBadRequest, "radius must be a positive number of miles")
+			return
+		}
+		req.RadiusMiles = radius
+	}
+	
+	for name, flag := range map[string]*bool{
+		"in_network":             &req.InNetworkOnly,
+		"accepting_new_patients": &req.AcceptingNewPatients,
+	} {
+		if v := query.Get(name); v != "" {
+			value, err := strconv.ParseBool(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, name+" must be true or false")
+				return
+			}
+			*flag = value
+		}
+	}
+	
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			respondError(w, http.StatusBadRequest, "page_size must be a positive number")
+			return
+		}
+		req.Page.PageSize = int32(size)
+	}
+	
+	// A member's networks and care team are only searched for callers with
+	// access to the member
+	ctx, ok := p.authorizeMember(w, r, req.MemberId)
+	if !ok {
+		return
+	}
+	
+	resp, err := p.providerClient.SearchProviders(ctx, req)
+	
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// SuggestProviders autocompletes the search box with specialties, providers
+// and practices
+func (p *ServiceProxy) SuggestProviders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	
+	req := &pb.SuggestProvidersRequest{
+		Query: query.Get("q"),
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		req.Limit = int32(limit)
+	}
+	
+	ctx := r.Context()
+	resp, err := p.providerClient.SuggestProviders(ctx, req)
+	
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+	
+	respondJSON(w, http.StatusOK, resp)
 }
 
 func (p *ServiceProxy) GetProvider(w http.ResponseWriter, r *http.Request) {
@@ -278,12 +496,24 @@ func (p *ServiceProxy) CheckNetworkStatus(w http.ResponseWriter, r *http.Request
 	providerID := vars["providerId"]
 	memberID := r.URL.Query().Get("member_id")
 	coverageType := r.URL.Query().Get("coverage_type")
+	serviceDate, err := parseAsOf(r.URL.Query().Get("service_date"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "service_date must be a date in YYYY-MM-DD format")
+		return
+	}
+	
+	// The member's plan is only checked for callers with access to the member
+	ctx, ok := p.authorizeMember(w, r, memberID)
+	if !ok {
+		return
+	}
 	
-	ctx := r.Context()
 	resp, err := p.providerClient.CheckNetworkStatus(ctx, &pb.CheckNetworkStatusRequest{
 		ProviderId:   providerID,
 		MemberId:     memberID,
 		CoverageType: parseCoverageType(coverageType),
+		LocationId:   r.URL.Query().Get("location_id"),
+		ServiceDate:  serviceDate,
 	})
 	
 	if err != nil {
@@ -312,6 +542,15 @@ func (p *ServiceProxy) ListClaims(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Restricted access hides sensitive claim categories
+	if level := accessLevel(ctx); level != pb.AccessLevel_ACCESS_LEVEL_FULL {
+		visible := access.FilterClaims(resp.Claims, level)
+		if resp.Page != nil {
+			resp.Page.TotalCount -= int32(len(resp.Claims) - len(visible))
+		}
+		resp.Claims = visible
+	}
+	
 	respondJSON(w, http.StatusOK, resp)
 }
 
@@ -329,6 +568,19 @@ func (p *ServiceProxy) GetClaim(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Claims are not addressed by member, so evaluate access against the claim's owner
+	if claims, ok := handler.GetUserClaims(ctx); ok && resp.Claim.MemberId != claims.MemberID {
+		level, err := p.checkMemberAccess(ctx, claims.MemberID, resp.Claim.MemberId)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		if len(access.FilterClaims([]*pb.Claim{resp.Claim}, level)) == 0 {
+			respondError(w, http.StatusNotFound, "Claim not found")
+			return
+		}
+	}
+	
 	respondJSON(w, http.StatusOK, resp.Claim)
 }
 
@@ -469,8 +721,26 @@ func (p *ServiceProxy) MarkAsRead(w http.ResponseWriter, r *http.Request) {
 
 func handleError(w http.ResponseWriter, err error) {
 	logger.Error("Request failed", zap.Error(err))
-	// TODO: Map gRPC errors to HTTP status codes
-	respondError(w, http.StatusInternalServerError, "Internal server error")
+	
+	st, _ := status.FromError(err)
+	switch st.Code() {
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		respondError(w, http.StatusBadRequest, st.Message())
+	case codes.NotFound:
+		respondError(w, http.StatusNotFound, st.Message())
+	case codes.AlreadyExists, codes.Aborted:
+		respondError(w, http.StatusConflict, st.Message())
+	case codes.PermissionDenied:
+		respondError(w, http.StatusForbidden, st.Message())
+	case codes.Unauthenticated:
+		respondError(w, http.StatusUnauthorized, st.Message())
+	case codes.Unimplemented:
+		respondError(w, http.StatusNotImplemented, st.Message())
+	case codes.Unavailable:
+		respondError(w, http.StatusServiceUnavailable, st.Message())
+	default:
+		respondError(w, http.StatusInternalServerError, "Internal server error")
+	}
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -482,6 +752,18 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
+// respondBinary writes non-JSON content such as rendered cards. Member documents
+// carry PHI, so they must not be cached by shared caches.
+func respondBinary(w http.ResponseWriter, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	
+	if _, err := w.Write(data); err != nil {
+		logger.Error("Failed to write response", zap.Error(err))
+	}
+}
+
 func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{
 		"error": message,
@@ -501,4 +783,27 @@ func parseCoverageType(s string) pb.CoverageType {
 	default:
 		return pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED
 	}
+}
+
+func parseNetworkTier(s string) pb.NetworkTier {
+	switch s {
+	case "in_network":
+		return pb.NetworkTier_NETWORK_TIER_IN_NETWORK
+	case "out_of_network":
+		return pb.NetworkTier_NETWORK_TIER_OUT_OF_NETWORK
+	default:
+		return pb.NetworkTier_NETWORK_TIER_UNSPECIFIED
+	}
+}
+
+// parseAsOf reads an optional YYYY-MM-DD date; nil means today
+func parseAsOf(s string) (*timestamppb.Timestamp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	day, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, err
+	}
+	return timestamppb.New(day), nil
 }
\ No newline at end of file
@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sydney-health-clone/backend/services/gateway/internal/handler"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+)
+
+// SubmitReview reviews a provider the member has a paid claim with
+func (p *ServiceProxy) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	var req struct {
+		ProviderID string `json:"provider_id"`
+		Rating     int32  `json:"rating"`
+		Title      string `json:"title"`
+		Body       string `json:"body"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.SubmitReview(ctx, &pb.SubmitReviewRequest{
+		MemberId:   memberID,
+		ProviderId: req.ProviderID,
+		Rating:     req.Rating,
+		Title:      req.Title,
+		Body:       req.Body,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	// Flagged reviews are accepted but wait for moderation
+	status := http.StatusCreated
+	if resp.Review.Status == pb.ReviewStatus_REVIEW_STATUS_PENDING {
+		status = http.StatusAccepted
+	}
+	respondJSON(w, status, resp.Review)
+}
+
+// ListReviews lists a provider's published reviews, newest first, with its
+// rating
+func (p *ServiceProxy) ListReviews(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	providerID := vars["providerId"]
+
+	page, ok := pageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.ListReviews(ctx, &pb.ListReviewsRequest{
+		ProviderId: providerID,
+		Page:       page,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ListPendingReviews lists the reviews waiting for moderation, oldest first,
+// for support agents
+func (p *ServiceProxy) ListPendingReviews(w http.ResponseWriter, r *http.Request) {
+	if !isAgent(r) {
+		respondError(w, http.StatusForbidden, "Review moderation is restricted to support agents")
+		return
+	}
+
+	page, ok := pageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.ListPendingReviews(ctx, &pb.ListPendingReviewsRequest{
+		Page: page,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ModerateReview publishes or rejects a review for a support agent. decision
+// is approved or rejected; rejecting takes a reason.
+func (p *ServiceProxy) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	if !isAgent(r) {
+		respondError(w, http.StatusForbidden, "Review moderation is restricted to support agents")
+		return
+	}
+
+	vars := mux.Vars(r)
+	reviewID := vars["reviewId"]
+
+	var req struct {
+		Decision string `json:"decision"`
+		Reason   string `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	decision, ok := pb.ReviewStatus_value["REVIEW_STATUS_"+strings.ToUpper(strings.TrimSpace(req.Decision))]
+	if !ok || decision == 0 {
+		respondError(w, http.StatusBadRequest, "decision must be approved or rejected")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.providerClient.ModerateReview(ctx, &pb.ModerateReviewRequest{
+		ReviewId: reviewID,
+		Decision: pb.ReviewStatus(decision),
+		Reason:   req.Reason,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Review)
+}
+
+func isAgent(r *http.Request) bool {
+	claims, ok := handler.GetUserClaims(r.Context())
+	return ok && claims.HasRole(access.AgentRole)
+}
+
+// pageRequest reads page_token and page_size, responding with 400 for a bad
+// page_size
+func pageRequest(w http.ResponseWriter, r *http.Request) (*pb.PageRequest, bool) {
+	query := r.URL.Query()
+	page := &pb.PageRequest{PageToken: query.Get("page_token")}
+
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			respondError(w, http.StatusBadRequest, "page_size must be a positive number")
+			return nil, false
+		}
+		page.PageSize = int32(size)
+	}
+	return page, true
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sydney-health-clone/backend/services/gateway/internal/handler"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SearchMembers finds members for support agents by any combination of q,
+// first_name, last_name, date_of_birth (YYYY-MM-DD), subscriber_id,
+// group_number, email and phone. fuzzy=true tolerates typos and partial IDs.
+func (p *ServiceProxy) SearchMembers(w http.ResponseWriter, r *http.Request) {
+	claims, ok := handler.GetUserClaims(r.Context())
+	if !ok || !claims.HasRole(access.AgentRole) {
+		respondError(w, http.StatusForbidden, "Member search is restricted to support agents")
+		return
+	}
+
+	query := r.URL.Query()
+	req := &pb.SearchMembersRequest{
+		Query:        query.Get("q"),
+		FirstName:    query.Get("first_name"),
+		LastName:     query.Get("last_name"),
+		SubscriberId: query.Get("subscriber_id"),
+		GroupNumber:  query.Get("group_number"),
+		Email:        query.Get("email"),
+		Phone:        query.Get("phone"),
+		Fuzzy:        query.Get("fuzzy") == "true",
+		Page: &pb.PageRequest{
+			PageToken: query.Get("page_token"),
+		},
+	}
+
+	if v := query.Get("date_of_birth"); v != "" {
+		dob, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "date_of_birth must be YYYY-MM-DD")
+			return
+		}
+		req.DateOfBirth = timestamppb.New(dob)
+	}
+
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			respondError(w, http.StatusBadRequest, "page_size must be a positive number")
+			return
+		}
+		req.Page.PageSize = int32(size)
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.SearchMembers(ctx, req)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
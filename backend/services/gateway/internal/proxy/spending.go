@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+)
+
+// ListSpendingAccounts returns the member's HSA, FSA and HRA accounts with
+// their balances
+func (p *ServiceProxy) ListSpendingAccounts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+
+	ctx := r.Context()
+	resp, err := p.spendingClient.ListAccounts(ctx, &pb.ListAccountsRequest{
+		MemberId: memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (p *ServiceProxy) GetSpendingAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	accountID := vars["accountId"]
+
+	ctx := r.Context()
+	resp, err := p.spendingClient.GetAccount(ctx, &pb.GetAccountRequest{
+		AccountId: accountID,
+		MemberId:  memberID,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp.Account)
+}
+
+// ListSpendingTransactions lists an account's transactions, newest first.
+// type takes a comma-separated list such as contribution,card_purchase, and
+// start_date and end_date are YYYY-MM-DD.
+func (p *ServiceProxy) ListSpendingTransactions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	accountID := vars["accountId"]
+	query := r.URL.Query()
+
+	req := &pb.ListTransactionsRequest{
+		AccountId: accountID,
+		MemberId:  memberID,
+		Page: &pb.PageRequest{
+			PageToken: query.Get("page_token"),
+		},
+	}
+
+	if v := query.Get("type"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			value, ok := pb.SpendingTransactionType_value["SPENDING_TRANSACTION_TYPE_"+strings.ToUpper(strings.TrimSpace(name))]
+			if !ok || value == 0 {
+				respondError(w, http.StatusBadRequest, "unknown type "+strconv.Quote(name))
+				return
+			}
+			req.TransactionTypes = append(req.TransactionTypes, pb.SpendingTransactionType(value))
+		}
+	}
+
+	var err error
+	if req.StartDate, err = parseAsOf(query.Get("start_date")); err != nil {
+		respondError(w, http.StatusBadRequest, "start_date must be a date in YYYY-MM-DD format")
+		return
+	}
+	if req.EndDate, err = parseAsOf(query.Get("end_date")); err != nil {
+		respondError(w, http.StatusBadRequest, "end_date must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			respondError(w, http.StatusBadRequest, "page_size must be a positive number")
+			return
+		}
+		req.Page.PageSize = int32(size)
+	}
+
+	ctx := r.Context()
+	resp, err := p.spendingClient.ListTransactions(ctx, req)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PayClaimFromAccount pays what the member owes on a claim from the account.
+// amount_cents defaults to everything still owed.
+func (p *ServiceProxy) PayClaimFromAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	accountID := vars["accountId"]
+
+	var req struct {
+		ClaimID     string `json:"claim_id"`
+		AmountCents int64  `json:"amount_cents"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	payment := &pb.PayClaimRequest{
+		MemberId:  memberID,
+		AccountId: accountID,
+		ClaimId:   req.ClaimID,
+	}
+	if req.AmountCents != 0 {
+		payment.Amount = &pb.Money{Cents: req.AmountCents, Currency: "USD"}
+	}
+
+	ctx := r.Context()
+	resp, err := p.spendingClient.PayClaim(ctx, payment)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
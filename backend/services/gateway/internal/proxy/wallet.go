@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sydney-health-clone/backend/shared/logger"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetWalletPass returns the member's card as an Apple .pkpass download or,
+// for wallet=google, a "Save to Google Wallet" link.
+func (p *ServiceProxy) GetWalletPass(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	memberID := vars["memberId"]
+	query := r.URL.Query()
+
+	walletType := pb.WalletType_WALLET_TYPE_APPLE
+	switch query.Get("wallet") {
+	case "", "apple":
+	case "google":
+		walletType = pb.WalletType_WALLET_TYPE_GOOGLE
+	default:
+		respondError(w, http.StatusBadRequest, "wallet must be apple or google")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.GetWalletPass(ctx, &pb.GetWalletPassRequest{
+		MemberId:     memberID,
+		CoverageType: parseCoverageType(query.Get("coverage_type")),
+		Wallet:       walletType,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if walletType == pb.WalletType_WALLET_TYPE_GOOGLE {
+		respondJSON(w, http.StatusOK, map[string]string{
+			"serial_number": resp.SerialNumber,
+			"save_url":      resp.SaveUrl,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+resp.SerialNumber+`.pkpass"`)
+	respondBinary(w, resp.ContentType, resp.Data)
+}
+
+// The handlers below implement Apple's PassKit web service, which Wallet calls
+// directly. They sit outside the JWT middleware and authenticate with the
+// pass's own token instead.
+
+// passAuthToken extracts the token from "Authorization: ApplePass <token>"
+func passAuthToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "ApplePass ")
+}
+
+func (p *ServiceProxy) RegisterWalletDevice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var body struct {
+		PushToken string `json:"pushToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.RegisterWalletDevice(ctx, &pb.RegisterWalletDeviceRequest{
+		DeviceLibraryIdentifier: vars["deviceLibraryIdentifier"],
+		PassTypeIdentifier:      vars["passTypeIdentifier"],
+		SerialNumber:            vars["serialNumber"],
+		AuthenticationToken:     passAuthToken(r),
+		PushToken:               body.PushToken,
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if resp.Created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (p *ServiceProxy) UnregisterWalletDevice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	ctx := r.Context()
+	_, err := p.memberClient.UnregisterWalletDevice(ctx, &pb.UnregisterWalletDeviceRequest{
+		DeviceLibraryIdentifier: vars["deviceLibraryIdentifier"],
+		PassTypeIdentifier:      vars["passTypeIdentifier"],
+		SerialNumber:            vars["serialNumber"],
+		AuthenticationToken:     passAuthToken(r),
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *ServiceProxy) ListUpdatedWalletPasses(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	ctx := r.Context()
+	resp, err := p.memberClient.ListUpdatedWalletPasses(ctx, &pb.ListUpdatedWalletPassesRequest{
+		DeviceLibraryIdentifier: vars["deviceLibraryIdentifier"],
+		PassTypeIdentifier:      vars["passTypeIdentifier"],
+		PassesUpdatedSince:      r.URL.Query().Get("passesUpdatedSince"),
+	})
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if len(resp.SerialNumbers) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"serialNumbers": resp.SerialNumbers,
+		"lastUpdated":   resp.LastUpdated,
+	})
+}
+
+func (p *ServiceProxy) GetLatestWalletPass(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	req := &pb.GetLatestWalletPassRequest{
+		PassTypeIdentifier:  vars["passTypeIdentifier"],
+		SerialNumber:        vars["serialNumber"],
+		AuthenticationToken: passAuthToken(r),
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		req.IfModifiedSince = timestamppb.New(since)
+	}
+
+	ctx := r.Context()
+	resp, err := p.memberClient.GetLatestWalletPass(ctx, req)
+
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Last-Modified", resp.LastModified.AsTime().UTC().Format(http.TimeFormat))
+	if resp.NotModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	respondBinary(w, "application/vnd.apple.pkpass", resp.Data)
+}
+
+// LogWalletErrors records diagnostics Wallet reports about the web service
+func (p *ServiceProxy) LogWalletErrors(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Logs []string `json:"logs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	for _, entry := range body.Logs {
+		logger.Warn("Wallet reported an error", zap.String("log", entry))
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
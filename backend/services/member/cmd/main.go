@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
@@ -8,8 +9,12 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/sydney-health-clone/backend/services/member/internal/card"
+	"github.com/sydney-health-clone/backend/services/member/internal/contact"
 	"github.com/sydney-health-clone/backend/services/member/internal/service"
+	"github.com/sydney-health-clone/backend/services/member/internal/wallet"
 	"github.com/sydney-health-clone/backend/shared/config"
+	"github.com/sydney-health-clone/backend/shared/kafka"
 	"github.com/sydney-health-clone/backend/shared/logger"
 	pb "github.com/sydney-health-clone/backend/shared/pb"
 	
@@ -20,6 +25,7 @@ import (
 var (
 	configPath = flag.String("config", "config/member.yaml", "Path to configuration file")
 	port       = flag.Int("port", 50051, "gRPC server port")
+	logoDir    = flag.String("logo-dir", "assets/logos", "Directory of plan and network logos for member cards")
 )
 
 func main() {
@@ -46,9 +52,69 @@ func main() {
 	// Create gRPC server
 	grpcServer := grpc.NewServer()
 	
+	logos := card.DirLogos{Dir: *logoDir}
+	cardRenderer, err := card.NewRenderer(card.Options{
+		Logos: logos,
+	})
+	if err != nil {
+		logger.Fatal("Failed to create card renderer", zap.Error(err))
+	}
+	
+	walletPasses, err := wallet.NewService(wallet.Config{
+		PassTypeIdentifier:        cfg.Wallet.PassTypeIdentifier,
+		TeamIdentifier:            cfg.Wallet.TeamIdentifier,
+		OrganizationName:          cfg.Wallet.OrganizationName,
+		WebServiceURL:             cfg.Wallet.WebServiceURL,
+		CertFile:                  cfg.Wallet.CertFile,
+		KeyFile:                   cfg.Wallet.KeyFile,
+		WWDRFile:                  cfg.Wallet.WWDRFile,
+		AuthSecret:                cfg.Wallet.AuthSecret,
+		GoogleIssuerID:            cfg.Wallet.GoogleIssuerID,
+		GoogleClassSuffix:         cfg.Wallet.GoogleClassSuffix,
+		GoogleServiceAccountEmail: cfg.Wallet.GoogleServiceAccountEmail,
+		GoogleKeyFile:             cfg.Wallet.GoogleKeyFile,
+		Origins:                   cfg.Wallet.Origins,
+	}, logos, wallet.NewMemoryRegistry())
+	if err != nil {
+		logger.Fatal("Failed to create wallet pass service", zap.Error(err))
+	}
+	
+	// Contact changes are verified through the development notifiers until an
+	// email and SMS provider is configured
+	var notifier contact.Notifier = contact.LogNotifier{Logf: logger.Get().Sugar().Infof}
+	if cfg.Contact.OutboxDir != "" {
+		notifier = &contact.FileNotifier{Dir: cfg.Contact.OutboxDir}
+	}
+	contacts := contact.NewVerifier(contact.NewMemoryStore(), notifier, contact.Config{
+		CodeTTL:     cfg.Contact.CodeTTL,
+		MaxAttempts: cfg.Contact.MaxAttempts,
+	})
+	
+	// Agent searches are audited to Kafka when an audit topic is configured
+	var audit service.AuditPublisher
+	if cfg.Kafka.AuditTopic != "" {
+		producer := kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.AuditTopic)
+		defer producer.Close()
+		audit = producer
+	}
+	
 	// Initialize service with mock data
-	memberService := service.NewMemberService()
+	memberService := service.NewMemberService(cardRenderer, walletPasses, contacts, audit)
 	pb.RegisterMemberServiceServer(grpcServer, memberService)
+	
+	// Refresh wallet passes when coverage changes elsewhere
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.Kafka.MemberUpdatesTopic != "" {
+		consumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.MemberUpdatesTopic, cfg.Kafka.GroupID, memberService.HandleMemberUpdate)
+		defer consumer.Close()
+		
+		go func() {
+			if err := consumer.Start(ctx); err != nil {
+				logger.Error("Member update consumer stopped", zap.Error(err))
+			}
+		}()
+	}
 
 	// Start listening
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
@@ -0,0 +1,45 @@
+package card
+
+import (
+	"fmt"
+	"image"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/boombuler/barcode/qr"
+)
+
+// BarcodePayload is the text encoded in the card barcode
+func BarcodePayload(card *pb.MemberCard) string {
+	return fmt.Sprintf("SUB:%s;GRP:%s", card.MemberNumber, card.GroupNumber)
+}
+
+// barcodeImage encodes the card payload and scales it into the given box,
+// preserving whole-module scaling so scanners see crisp edges.
+func (r *Renderer) barcodeImage(card *pb.MemberCard, maxWidth, maxHeight int) (image.Image, error) {
+	var code barcode.Barcode
+	var err error
+
+	switch r.opts.Symbology {
+	case SymbologyQR:
+		code, err = qr.Encode(BarcodePayload(card), qr.M, qr.Auto)
+	default:
+		code, err = pdf417.Encode(BarcodePayload(card), 2)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode barcode: %w", err)
+	}
+
+	bounds := code.Bounds()
+	scale := maxWidth / bounds.Dx()
+	if s := maxHeight / bounds.Dy(); s < scale {
+		scale = s
+	}
+	if scale < 1 {
+		return nil, fmt.Errorf("barcode does not fit in %dx%d", maxWidth, maxHeight)
+	}
+
+	return barcode.Scale(code, bounds.Dx()*scale, bounds.Dy()*scale)
+}
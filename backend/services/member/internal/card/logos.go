@@ -0,0 +1,76 @@
+package card
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// LogoSlot identifies a reserved logo area on the card front
+type LogoSlot string
+
+const (
+	// Top-left: the health plan's brand
+	LogoSlotPlan LogoSlot = "plan"
+	// Top-right: the network or employer partner
+	LogoSlotNetwork LogoSlot = "network"
+)
+
+// LogoSource supplies logo artwork for a card. A nil image leaves the slot empty.
+type LogoSource interface {
+	Logo(slot LogoSlot, card *pb.MemberCard) image.Image
+}
+
+// NoLogos leaves every slot empty
+type NoLogos struct{}
+
+func (NoLogos) Logo(LogoSlot, *pb.MemberCard) image.Image {
+	return nil
+}
+
+// DirLogos loads PNG logos from a directory. A group-specific file
+// (<slot>_<group_number>.png) takes precedence over the default (<slot>.png).
+// Group numbers with anything but letters, digits and hyphens get the default,
+// so a group number can't name a file outside the directory.
+type DirLogos struct {
+	Dir string
+}
+
+func (d DirLogos) Logo(slot LogoSlot, card *pb.MemberCard) image.Image {
+	var candidates []string
+	if safeFileName(card.GroupNumber) {
+		candidates = append(candidates, filepath.Join(d.Dir, string(slot)+"_"+card.GroupNumber+".png"))
+	}
+	candidates = append(candidates, filepath.Join(d.Dir, string(slot)+".png"))
+
+	for _, path := range candidates {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err == nil {
+			return img
+		}
+	}
+	return nil
+}
+
+// safeFileName reports whether s is non-empty and only letters, digits and hyphens
+func safeFileName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package card
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDF renders a printable letter-size page with the card front and back at
+// actual size, each framed by a dashed cut line.
+func (r *Renderer) PDF(card *pb.MemberCard, coverageType pb.CoverageType) ([]byte, error) {
+	front, err := r.FrontPNG(card, coverageType)
+	if err != nil {
+		return nil, err
+	}
+	back, err := r.BackPNG(card, coverageType)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := gofpdf.New("P", "in", "Letter", "")
+	pdf.SetTitle("Member ID Card", true)
+	pdf.SetCatalogSort(true)
+
+	// Fixed dates keep output byte-identical for the same card
+	stamp := time.Unix(0, 0).UTC()
+	if card.IssueDate != nil {
+		stamp = card.IssueDate.AsTime()
+	}
+	pdf.SetCreationDate(stamp)
+	pdf.SetModificationDate(stamp)
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.Text(1.0, 1.0, "Member ID Card")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Text(1.0, 1.25, fmt.Sprintf("%s - %s", card.MemberName, card.PlanName))
+
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("front", opts, bytes.NewReader(front))
+	pdf.RegisterImageOptionsReader("back", opts, bytes.NewReader(back))
+
+	x := (8.5 - WidthInches) / 2
+	for i, name := range []string{"front", "back"} {
+		y := 1.75 + float64(i)*(HeightInches+0.75)
+
+		pdf.SetDrawColor(160, 160, 160)
+		pdf.SetDashPattern([]float64{0.05, 0.05}, 0)
+		pdf.Rect(x-0.0625, y-0.0625, WidthInches+0.125, HeightInches+0.125, "D")
+		pdf.SetDashPattern([]float64{}, 0)
+
+		pdf.ImageOptions(name, x, y, WidthInches, HeightInches, false, opts, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.Text(1.0, 9.5, "Cut along the dashed lines for a wallet-size card.")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write card PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
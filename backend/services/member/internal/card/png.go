@@ -0,0 +1,241 @@
+package card
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	colorInk    = color.RGBA{0x1f, 0x29, 0x37, 0xff}
+	colorMuted  = color.RGBA{0x6b, 0x72, 0x80, 0xff}
+	colorRule   = color.RGBA{0xd1, 0xd5, 0xdb, 0xff}
+	colorPanel  = color.RGBA{0xf3, 0xf4, 0xf6, 0xff}
+	colorWhite  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	logoSlotBox = map[LogoSlot]image.Rectangle{
+		LogoSlotPlan:    image.Rect(32, 20, 272, 100),
+		LogoSlotNetwork: image.Rect(740, 20, 980, 100),
+	}
+)
+
+// Labels for well-known AdditionalInfo keys; others are derived from the key
+var infoLabels = map[string]string{
+	"copay_primary":    "Primary Care",
+	"copay_specialist": "Specialist",
+	"emergency_room":   "Emergency Room",
+	"provider_network": "Network",
+	"generic_copay":    "Generic",
+	"brand_copay":      "Brand",
+	"specialty_copay":  "Specialty",
+	"eye_exam":         "Eye Exam",
+}
+
+// FrontPNG renders the card front: logos, member identity, plan and copays
+func (r *Renderer) FrontPNG(card *pb.MemberCard, coverageType pb.CoverageType) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := newCanvas()
+	accent, title := Theme(coverageType)
+
+	for slot, box := range logoSlotBox {
+		if logo := r.opts.Logos.Logo(slot, card); logo != nil {
+			c.drawFit(logo, box)
+		} else {
+			c.outline(box, colorRule)
+		}
+	}
+
+	c.fill(image.Rect(0, 120, Width, 176), accent)
+	c.text(r.faces.title, colorWhite, 32, 163, title)
+	c.textRight(r.faces.label, colorWhite, Width-32, 158, c.fit(r.faces.label, card.PlanName, 560))
+
+	c.text(r.faces.label, colorMuted, 32, 226, "Member")
+	c.text(r.faces.value, colorInk, 32, 260, c.fit(r.faces.value, card.MemberName, 480))
+
+	c.text(r.faces.label, colorMuted, 32, 312, "Member ID")
+	c.text(r.faces.value, colorInk, 32, 346, card.MemberNumber)
+
+	c.text(r.faces.label, colorMuted, 292, 312, "Group")
+	c.text(r.faces.value, colorInk, 292, 346, card.GroupNumber)
+
+	if coverageType == pb.CoverageType_COVERAGE_TYPE_PHARMACY {
+		rows := [][2]string{{"RxBIN", card.BinNumber}, {"RxPCN", card.PcnNumber}, {"RxGrp", card.RxGroup}}
+		y := 400
+		for _, row := range rows {
+			c.text(r.faces.label, colorMuted, 32, y, row[0])
+			c.text(r.faces.value, colorInk, 140, y, row[1])
+			y += 40
+		}
+	}
+
+	panel := image.Rect(536, 200, Width-32, 540)
+	c.fill(panel, colorPanel)
+	y := panel.Min.Y + 40
+	for _, row := range infoRows(card.AdditionalInfo) {
+		if y > panel.Max.Y-12 {
+			break
+		}
+		c.text(r.faces.small, colorMuted, panel.Min.X+20, y, row[0])
+		c.textRight(r.faces.small, colorInk, panel.Max.X-20, y, c.fit(r.faces.small, row[1], 200))
+		y += 44
+	}
+
+	if card.IssueDate != nil {
+		c.text(r.faces.small, colorMuted, 32, Height-36, "Issued "+card.IssueDate.AsTime().Format("01/02/2006"))
+	}
+	c.fill(image.Rect(0, Height-12, Width, Height), accent)
+
+	return c.encode()
+}
+
+// BackPNG renders the card back: contact details, claims address and barcode
+func (r *Renderer) BackPNG(card *pb.MemberCard, coverageType pb.CoverageType) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := newCanvas()
+	accent, _ := Theme(coverageType)
+
+	c.fill(image.Rect(0, 0, Width, 12), accent)
+
+	lines := [][2]string{
+		{"Member Services", r.opts.MemberPhone},
+		{"Providers", r.opts.ProviderPhone},
+		{"Submit claims to", r.opts.ClaimsAddress},
+	}
+	y := 64
+	for _, line := range lines {
+		c.text(r.faces.label, colorMuted, 32, y, line[0])
+		c.textRight(r.faces.label, colorInk, Width-32, y, c.fit(r.faces.label, line[1], 620))
+		y += 44
+	}
+	c.hline(32, Width-32, y-16, colorRule)
+
+	code, err := r.barcodeImage(card, 600, 220)
+	if err != nil {
+		return nil, err
+	}
+	b := code.Bounds()
+	origin := image.Pt((Width-b.Dx())/2, 232+(220-b.Dy())/2)
+	draw.Draw(c.img, b.Add(origin), code, b.Min, draw.Src)
+
+	c.text(r.faces.small, colorMuted, 32, Height-40, "Possession of this card does not guarantee eligibility or payment.")
+
+	return c.encode()
+}
+
+// infoRows returns AdditionalInfo as labelled rows in stable key order
+func infoRows(info map[string]string) [][2]string {
+	keys := make([]string, 0, len(info))
+	for key := range info {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		label, ok := infoLabels[key]
+		if !ok {
+			words := strings.Fields(strings.ReplaceAll(key, "_", " "))
+			for i, word := range words {
+				words[i] = strings.ToUpper(word[:1]) + word[1:]
+			}
+			label = strings.Join(words, " ")
+		}
+		rows = append(rows, [2]string{label, info[key]})
+	}
+	return rows
+}
+
+type canvas struct {
+	img *image.RGBA
+}
+
+func newCanvas() *canvas {
+	c := &canvas{img: image.NewRGBA(image.Rect(0, 0, Width, Height))}
+	c.fill(c.img.Bounds(), colorWhite)
+	return c
+}
+
+func (c *canvas) fill(rect image.Rectangle, col color.Color) {
+	draw.Draw(c.img, rect, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+func (c *canvas) hline(x0, x1, y int, col color.Color) {
+	c.fill(image.Rect(x0, y, x1, y+2), col)
+}
+
+func (c *canvas) outline(rect image.Rectangle, col color.Color) {
+	c.fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+2), col)
+	c.fill(image.Rect(rect.Min.X, rect.Max.Y-2, rect.Max.X, rect.Max.Y), col)
+	c.fill(image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+2, rect.Max.Y), col)
+	c.fill(image.Rect(rect.Max.X-2, rect.Min.Y, rect.Max.X, rect.Max.Y), col)
+}
+
+// drawFit scales img into box, preserving aspect ratio and centring it
+func (c *canvas) drawFit(img image.Image, box image.Rectangle) {
+	src := img.Bounds()
+	if src.Dx() == 0 || src.Dy() == 0 {
+		return
+	}
+
+	w, h := box.Dx(), src.Dy()*box.Dx()/src.Dx()
+	if h > box.Dy() {
+		w, h = src.Dx()*box.Dy()/src.Dy(), box.Dy()
+	}
+
+	x := box.Min.X + (box.Dx()-w)/2
+	y := box.Min.Y + (box.Dy()-h)/2
+	draw.CatmullRom.Scale(c.img, image.Rect(x, y, x+w, y+h), img, src, draw.Over, nil)
+}
+
+// text draws s with its baseline at y
+func (c *canvas) text(face font.Face, col color.Color, x, y int, s string) {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+func (c *canvas) textRight(face font.Face, col color.Color, right, y int, s string) {
+	c.text(face, col, right-font.MeasureString(face, s).Ceil(), y, s)
+}
+
+// fit truncates s with an ellipsis so it renders within maxWidth pixels
+func (c *canvas) fit(face font.Face, s string, maxWidth int) string {
+	if font.MeasureString(face, s).Ceil() <= maxWidth {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "…"
+		if font.MeasureString(face, candidate).Ceil() <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func (c *canvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("failed to encode card image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
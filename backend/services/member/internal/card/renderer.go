@@ -0,0 +1,138 @@
+package card
+
+import (
+	"fmt"
+	"image/color"
+	"sync"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+// Cards are rendered at CR80 size (3.375in x 2.125in) and 300 DPI
+const (
+	Width  = 1012
+	Height = 638
+	DPI    = 300
+
+	WidthInches  = 3.375
+	HeightInches = 2.125
+)
+
+const (
+	ContentTypePNG = "image/png"
+	ContentTypePDF = "application/pdf"
+)
+
+// Symbology selects the 2D barcode printed on the back of the card
+type Symbology int
+
+const (
+	SymbologyPDF417 Symbology = iota
+	SymbologyQR
+)
+
+// Options configures a Renderer
+type Options struct {
+	Logos         LogoSource
+	Symbology     Symbology
+	MemberPhone   string
+	ProviderPhone string
+	ClaimsAddress string
+}
+
+// Renderer draws member ID cards as PNG images and printable PDFs.
+// Font faces are not safe for concurrent use, so drawing is serialised.
+type Renderer struct {
+	opts  Options
+	mu    sync.Mutex
+	faces faces
+}
+
+type faces struct {
+	title font.Face
+	value font.Face
+	label font.Face
+	small font.Face
+}
+
+// NewRenderer creates a card renderer with the bundled Go fonts
+func NewRenderer(opts Options) (*Renderer, error) {
+	if opts.Logos == nil {
+		opts.Logos = NoLogos{}
+	}
+	if opts.MemberPhone == "" {
+		opts.MemberPhone = "1-800-555-0100"
+	}
+	if opts.ProviderPhone == "" {
+		opts.ProviderPhone = "1-800-555-0199"
+	}
+	if opts.ClaimsAddress == "" {
+		opts.ClaimsAddress = "PO Box 1000, San Francisco, CA 94105"
+	}
+
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse regular font: %w", err)
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bold font: %w", err)
+	}
+
+	newFace := func(f *opentype.Font, size float64) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	}
+
+	r := &Renderer{opts: opts}
+	if r.faces.title, err = newFace(bold, 40); err != nil {
+		return nil, err
+	}
+	if r.faces.value, err = newFace(bold, 28); err != nil {
+		return nil, err
+	}
+	if r.faces.label, err = newFace(regular, 22); err != nil {
+		return nil, err
+	}
+	if r.faces.small, err = newFace(regular, 18); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Render produces the requested format. PNG renders one side; PDF renders both.
+func (r *Renderer) Render(card *pb.MemberCard, coverageType pb.CoverageType, format pb.CardFormat, side pb.CardSide) (data []byte, contentType string, err error) {
+	switch format {
+	case pb.CardFormat_CARD_FORMAT_PDF:
+		data, err = r.PDF(card, coverageType)
+		return data, ContentTypePDF, err
+	case pb.CardFormat_CARD_FORMAT_PNG, pb.CardFormat_CARD_FORMAT_UNSPECIFIED:
+		if side == pb.CardSide_CARD_SIDE_BACK {
+			data, err = r.BackPNG(card, coverageType)
+		} else {
+			data, err = r.FrontPNG(card, coverageType)
+		}
+		return data, ContentTypePNG, err
+	default:
+		return nil, "", fmt.Errorf("unsupported card format: %s", format)
+	}
+}
+
+// Theme returns the banner colour and title for a coverage type
+func Theme(coverageType pb.CoverageType) (color.RGBA, string) {
+	switch coverageType {
+	case pb.CoverageType_COVERAGE_TYPE_DENTAL:
+		return color.RGBA{0x00, 0x7c, 0x89, 0xff}, "DENTAL"
+	case pb.CoverageType_COVERAGE_TYPE_VISION:
+		return color.RGBA{0x5b, 0x3f, 0x9e, 0xff}, "VISION"
+	case pb.CoverageType_COVERAGE_TYPE_PHARMACY:
+		return color.RGBA{0xc2, 0x5b, 0x0a, 0xff}, "PHARMACY"
+	default:
+		return color.RGBA{0x0b, 0x4f, 0x9c, 0xff}, "MEDICAL"
+	}
+}
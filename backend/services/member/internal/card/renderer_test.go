@@ -0,0 +1,233 @@
+package card
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testCard is the card every coverage type is rendered from, with the
+// details that coverage type prints
+func testCard(coverageType pb.CoverageType) *pb.MemberCard {
+	card := &pb.MemberCard{
+		MemberId:       "MEM123456",
+		MemberName:     "Jordan A. Rivera",
+		MemberNumber:   "SUB123456-01",
+		GroupNumber:    "GRP-00417",
+		PlanName:       "Premium Health Plan",
+		IssueDate:      timestamppb.New(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		AdditionalInfo: map[string]string{},
+	}
+
+	switch coverageType {
+	case pb.CoverageType_COVERAGE_TYPE_MEDICAL:
+		card.AdditionalInfo["copay_primary"] = "$20"
+		card.AdditionalInfo["copay_specialist"] = "$40"
+		card.AdditionalInfo["emergency_room"] = "$250"
+		card.AdditionalInfo["provider_network"] = "PPO"
+	case pb.CoverageType_COVERAGE_TYPE_DENTAL:
+		card.AdditionalInfo["preventive"] = "100%"
+		card.AdditionalInfo["basic"] = "80%"
+		card.AdditionalInfo["major"] = "50%"
+	case pb.CoverageType_COVERAGE_TYPE_VISION:
+		card.AdditionalInfo["eye_exam"] = "$10 copay"
+		card.AdditionalInfo["frames"] = "$150 allowance"
+		card.AdditionalInfo["lenses"] = "$20 copay"
+	case pb.CoverageType_COVERAGE_TYPE_PHARMACY:
+		card.BinNumber = "610014"
+		card.PcnNumber = "MEDDPRIME"
+		card.RxGroup = "RX4417"
+		card.AdditionalInfo["generic_copay"] = "$10"
+		card.AdditionalInfo["brand_copay"] = "$35"
+		card.AdditionalInfo["specialty_copay"] = "$100"
+	}
+	return card
+}
+
+// TestCardImages renders the front and back of a fixed card for each coverage
+// type and checks the decoded images: card size, the coverage's accent colour
+// in the banner and edge stripes, and ink where the member and barcode go.
+func TestCardImages(t *testing.T) {
+	r, err := NewRenderer(Options{})
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+
+	tests := []struct {
+		coverageType pb.CoverageType
+	}{
+		{pb.CoverageType_COVERAGE_TYPE_MEDICAL},
+		{pb.CoverageType_COVERAGE_TYPE_DENTAL},
+		{pb.CoverageType_COVERAGE_TYPE_VISION},
+		{pb.CoverageType_COVERAGE_TYPE_PHARMACY},
+	}
+
+	for _, tt := range tests {
+		accent, title := Theme(tt.coverageType)
+
+		t.Run(strings.ToLower(title), func(t *testing.T) {
+			card := testCard(tt.coverageType)
+
+			front, err := r.FrontPNG(card, tt.coverageType)
+			if err != nil {
+				t.Fatalf("FrontPNG: %v", err)
+			}
+			img := decodeCard(t, front)
+			assertColor(t, "front banner", img, image.Pt(Width/2, 122), accent)
+			assertColor(t, "front bottom stripe", img, image.Pt(Width/2, Height-6), accent)
+			assertColor(t, "empty plan logo outline", img, logoSlotBox[LogoSlotPlan].Min, colorRule)
+			assertInk(t, "member name", img, image.Rect(32, 230, 512, 266))
+
+			back, err := r.BackPNG(card, tt.coverageType)
+			if err != nil {
+				t.Fatalf("BackPNG: %v", err)
+			}
+			img = decodeCard(t, back)
+			assertColor(t, "back top stripe", img, image.Pt(Width/2, 6), accent)
+			assertInk(t, "barcode", img, image.Rect(Width/2-300, 232, Width/2+300, 452))
+		})
+	}
+}
+
+// TestCardPDF checks the printable PDF is a single letter-size page holding
+// both sides of the card at full resolution
+func TestCardPDF(t *testing.T) {
+	r, err := NewRenderer(Options{})
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+
+	data, err := r.PDF(testCard(pb.CoverageType_COVERAGE_TYPE_PHARMACY), pb.CoverageType_COVERAGE_TYPE_PHARMACY)
+	if err != nil {
+		t.Fatalf("PDF: %v", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(bytes.TrimSpace(data), []byte("%%EOF")) {
+		t.Fatalf("output is not a complete PDF")
+	}
+	for _, want := range []struct {
+		marker string
+		count  int
+	}{
+		{"/Count 1", 1},
+		{"/MediaBox [0 0 612.00 792.00]", 1},
+		{"/Subtype /Image", 2},
+		{"/Width 1012", 2},
+		{"/Height 638", 2},
+	} {
+		if got := bytes.Count(data, []byte(want.marker)); got != want.count {
+			t.Errorf("PDF has %d of %q, want %d", got, want.marker, want.count)
+		}
+	}
+}
+
+func TestDirLogos(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "logos")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	writeLogo(t, filepath.Join(dir, "plan.png"), red)
+	writeLogo(t, filepath.Join(dir, "plan_GRP-00417.png"), blue)
+	writeLogo(t, filepath.Join(root, "secret.png"), green)
+
+	tests := []struct {
+		name  string
+		group string
+		want  color.RGBA
+	}{
+		{"group logo takes precedence", "GRP-00417", blue},
+		{"unknown group gets the default", "GRP-99999", red},
+		{"group number can't leave the directory", "x/../../secret", red},
+		{"empty group number gets the default", "", red},
+	}
+
+	logos := DirLogos{Dir: dir}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := logos.Logo(LogoSlotPlan, &pb.MemberCard{GroupNumber: tt.group})
+			if img == nil {
+				t.Fatal("Logo() = nil, want an image")
+			}
+			assertColor(t, "logo", img, img.Bounds().Min, tt.want)
+		})
+	}
+
+	if img := (DirLogos{Dir: root}).Logo(LogoSlotNetwork, &pb.MemberCard{GroupNumber: "GRP-00417"}); img != nil {
+		t.Error("Logo() for a slot with no files returned an image, want nil")
+	}
+}
+
+func decodeCard(t *testing.T, data []byte) image.Image {
+	t.Helper()
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+		t.Fatalf("card is %dx%d, want %dx%d", b.Dx(), b.Dy(), Width, Height)
+	}
+	return img
+}
+
+func assertColor(t *testing.T, what string, img image.Image, at image.Point, want color.RGBA) {
+	t.Helper()
+
+	if got := color.RGBAModel.Convert(img.At(at.X, at.Y)).(color.RGBA); got != want {
+		t.Errorf("%s at %v = %v, want %v", what, at, got, want)
+	}
+}
+
+// assertInk checks rect holds dark pixels on a light background, as text and
+// barcodes do
+func assertInk(t *testing.T, what string, img image.Image, rect image.Rectangle) {
+	t.Helper()
+
+	var dark, light int
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if g := color.GrayModel.Convert(img.At(x, y)).(color.Gray); g.Y < 0x80 {
+				dark++
+			} else {
+				light++
+			}
+		}
+	}
+	if dark == 0 || light == 0 {
+		t.Errorf("%s area has %d dark and %d light pixels, want both", what, dark, light)
+	}
+}
+
+func writeLogo(t *testing.T, path string, col color.RGBA) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetRGBA(x, y, col)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,62 @@
+package contact
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+var (
+	ErrInvalidEmail = errors.New("email is not a valid address")
+	ErrInvalidPhone = errors.New("phone must have 10 to 15 digits")
+	ErrEmailInUse   = errors.New("email is already used by another member")
+)
+
+// Change is a requested change to one contact field
+type Change struct {
+	Channel pb.ContactChannel
+	Value   string
+}
+
+// Changes compares the requested member with the stored one and returns the
+// contact fields that would change. An empty field in the request leaves
+// the stored value alone.
+func Changes(existing, requested *pb.Member) ([]Change, error) {
+	var changes []Change
+
+	if email := strings.TrimSpace(requested.Email); email != "" && !strings.EqualFold(email, existing.Email) {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return nil, ErrInvalidEmail
+		}
+		changes = append(changes, Change{Channel: pb.ContactChannel_CONTACT_CHANNEL_EMAIL, Value: email})
+	}
+
+	if phone := strings.TrimSpace(requested.Phone); phone != "" && Digits(phone) != Digits(existing.Phone) {
+		if n := len(Digits(phone)); n < 10 || n > 15 {
+			return nil, ErrInvalidPhone
+		}
+		changes = append(changes, Change{Channel: pb.ContactChannel_CONTACT_CHANNEL_PHONE, Value: phone})
+	}
+
+	return changes, nil
+}
+
+// Hold keeps the member's stored contact fields on requested, so the rest of
+// the update can be saved while the changes wait for verification
+func Hold(requested, existing *pb.Member) {
+	requested.Email = existing.Email
+	requested.Phone = existing.Phone
+}
+
+// Apply sets the verified value on the member and returns the one it replaced
+func Apply(member *pb.Member, p *Pending) (old string) {
+	switch p.Channel {
+	case pb.ContactChannel_CONTACT_CHANNEL_EMAIL:
+		old, member.Email = member.Email, p.Value
+	case pb.ContactChannel_CONTACT_CHANNEL_PHONE:
+		old, member.Phone = member.Phone, p.Value
+	}
+	return old
+}
@@ -0,0 +1,73 @@
+package contact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Message is an email or text message to a member
+type Message struct {
+	Channel pb.ContactChannel
+	To      string
+	Subject string // emails only
+	Body    string
+}
+
+// Notifier delivers messages to members. Production deployments plug in an
+// email and SMS provider; LogNotifier and FileNotifier are for development.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes each message, code included, to a log
+type LogNotifier struct {
+	Logf func(format string, args ...interface{})
+}
+
+func (n LogNotifier) Notify(ctx context.Context, msg Message) error {
+	n.Logf("%s to %s: %s %s", channelName(msg.Channel), msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier appends each message to a file per recipient in Dir, so
+// developers can read codes sent to test addresses
+type FileNotifier struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := os.MkdirAll(n.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	name := msg.To
+	if msg.Channel == pb.ContactChannel_CONTACT_CHANNEL_PHONE {
+		name = Digits(name)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)
+	f, err := os.OpenFile(filepath.Join(n.Dir, name+".txt"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,63 @@
+package contact
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending map[string]*Pending
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{pending: make(map[string]*Pending)}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, p *Pending) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, existing := range s.pending {
+		if existing.MemberID == p.MemberID && existing.Channel == p.Channel {
+			delete(s.pending, id)
+		}
+	}
+	saved := *p
+	s.pending[p.ID] = &saved
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, memberID, id string) (*Pending, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[id]
+	if !ok || p.MemberID != memberID {
+		return nil, nil
+	}
+	found := *p
+	return &found, nil
+}
+
+func (s *MemoryStore) RecordAttempt(ctx context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	p.Attempts++
+	return p.Attempts, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pending, id)
+	return nil
+}
@@ -0,0 +1,255 @@
+// Package contact holds changes to a member's email and phone number until
+// the member proves they control the new address, so a hijacked session
+// can't quietly redirect a member's notices and password resets.
+package contact
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DefaultCodeTTL     = 15 * time.Minute
+	DefaultMaxAttempts = 5
+	codeLength         = 6
+)
+
+var (
+	ErrNotFound      = errors.New("no pending contact change")
+	ErrExpired       = errors.New("verification code has expired")
+	ErrTooManyTries  = errors.New("too many incorrect codes")
+	ErrIncorrectCode = errors.New("incorrect verification code")
+)
+
+// Pending is a contact change awaiting verification. Only a hash of the code
+// is kept.
+type Pending struct {
+	ID        string
+	MemberID  string
+	Channel   pb.ContactChannel
+	Value     string // the new email or phone number
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Store keeps pending contact changes
+type Store interface {
+	// Save stores the change, replacing any pending change to the same
+	// member's same channel
+	Save(ctx context.Context, p *Pending) error
+	// Get returns the member's pending change, or nil if there is none
+	Get(ctx context.Context, memberID, id string) (*Pending, error)
+	// RecordAttempt counts an incorrect code and returns the attempts so far
+	RecordAttempt(ctx context.Context, id string) (int, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// Config controls how long codes last and how many guesses they allow
+type Config struct {
+	CodeTTL     time.Duration
+	MaxAttempts int
+}
+
+// Verifier starts and completes contact changes
+type Verifier struct {
+	store    Store
+	notifier Notifier
+	cfg      Config
+	now      func() time.Time
+}
+
+// NewVerifier creates a verifier. Zero config values take the defaults.
+func NewVerifier(store Store, notifier Notifier, cfg Config) *Verifier {
+	if cfg.CodeTTL <= 0 {
+		cfg.CodeTTL = DefaultCodeTTL
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	return &Verifier{store: store, notifier: notifier, cfg: cfg, now: time.Now}
+}
+
+// Start holds a change to value and sends a verification code to it. A
+// second change to the same channel replaces the first, whose code stops
+// working.
+func (v *Verifier) Start(ctx context.Context, memberID string, channel pb.ContactChannel, value string) (*pb.PendingContactChange, error) {
+	code, err := newCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	now := v.now()
+	p := &Pending{
+		ID:        uuid.NewString(),
+		MemberID:  memberID,
+		Channel:   channel,
+		Value:     value,
+		ExpiresAt: now.Add(v.cfg.CodeTTL),
+		CreatedAt: now,
+	}
+	p.CodeHash = hashCode(p.ID, code)
+
+	if err := v.store.Save(ctx, p); err != nil {
+		return nil, err
+	}
+
+	msg := Message{
+		Channel: channel,
+		To:      value,
+		Subject: "Confirm your new " + channelName(channel),
+		Body: fmt.Sprintf("Your verification code is %s. It expires in %d minutes. "+
+			"If you didn't ask to change your %s, ignore this message.",
+			code, int(v.cfg.CodeTTL.Minutes()), channelName(channel)),
+	}
+	if err := v.notifier.Notify(ctx, msg); err != nil {
+		// A code nobody received can't be verified
+		v.store.Delete(ctx, p.ID)
+		return nil, fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	return v.describe(p), nil
+}
+
+// Verify checks the code for a pending change and, when it matches, removes
+// and returns the change for the caller to commit. Incorrect codes count
+// against the attempt limit; a change that reaches it is discarded.
+func (v *Verifier) Verify(ctx context.Context, memberID, id, code string) (*Pending, error) {
+	p, err := v.store.Get(ctx, memberID, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotFound
+	}
+
+	if !v.now().Before(p.ExpiresAt) {
+		v.store.Delete(ctx, p.ID)
+		return nil, ErrExpired
+	}
+
+	want := []byte(p.CodeHash)
+	got := []byte(hashCode(p.ID, strings.TrimSpace(code)))
+	if subtle.ConstantTimeCompare(want, got) != 1 {
+		attempts, err := v.store.RecordAttempt(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= v.cfg.MaxAttempts {
+			v.store.Delete(ctx, p.ID)
+			return nil, ErrTooManyTries
+		}
+		return nil, &AttemptError{Remaining: v.cfg.MaxAttempts - attempts}
+	}
+
+	if err := v.store.Delete(ctx, p.ID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NotifyChanged tells the member at their old address that it was replaced,
+// so they can act if they didn't make the change
+func (v *Verifier) NotifyChanged(ctx context.Context, channel pb.ContactChannel, oldValue, newValue string) error {
+	if oldValue == "" {
+		return nil
+	}
+	return v.notifier.Notify(ctx, Message{
+		Channel: channel,
+		To:      oldValue,
+		Subject: "Your " + channelName(channel) + " was changed",
+		Body: fmt.Sprintf("The %s on your account was changed to %s. "+
+			"If you didn't make this change, call Member Services right away.",
+			channelName(channel), Mask(channel, newValue)),
+	})
+}
+
+func (v *Verifier) describe(p *Pending) *pb.PendingContactChange {
+	return &pb.PendingContactChange{
+		VerificationId:    p.ID,
+		Channel:           p.Channel,
+		Destination:       Mask(p.Channel, p.Value),
+		ExpiresAt:         timestamppb.New(p.ExpiresAt),
+		AttemptsRemaining: int32(v.cfg.MaxAttempts - p.Attempts),
+	}
+}
+
+// AttemptError is an incorrect code that still leaves attempts
+type AttemptError struct {
+	Remaining int
+}
+
+func (e *AttemptError) Error() string {
+	return fmt.Sprintf("%s; %d attempts remaining", ErrIncorrectCode, e.Remaining)
+}
+
+func (e *AttemptError) Unwrap() error { return ErrIncorrectCode }
+
+// Mask hides most of an address for display: j***@example.com, ***-***-4567
+func Mask(channel pb.ContactChannel, value string) string {
+	switch channel {
+	case pb.ContactChannel_CONTACT_CHANNEL_EMAIL:
+		mailbox, domain, ok := strings.Cut(value, "@")
+		if !ok || mailbox == "" {
+			return "***"
+		}
+		return mailbox[:1] + "***@" + domain
+	case pb.ContactChannel_CONTACT_CHANNEL_PHONE:
+		digits := Digits(value)
+		if len(digits) < 4 {
+			return "***"
+		}
+		return "***-***-" + digits[len(digits)-4:]
+	default:
+		return "***"
+	}
+}
+
+// Digits strips a phone number to its digits
+func Digits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func channelName(channel pb.ContactChannel) string {
+	if channel == pb.ContactChannel_CONTACT_CHANNEL_PHONE {
+		return "phone number"
+	}
+	return "email address"
+}
+
+func newCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < codeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeLength, n), nil
+}
+
+// hashCode binds the code to its change, so equal codes hash differently
+func hashCode(id, code string) string {
+	sum := sha256.Sum256([]byte(id + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
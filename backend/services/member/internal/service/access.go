@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// authorize evaluates the calling member's access to member. Callers must hold s.mu.
+// Internal callers are trusted; callers that are neither are denied.
+func (s *MemberService) authorize(ctx context.Context, member *pb.Member) access.Decision {
+	if requesterID, ok := access.RequesterFromContext(ctx); ok {
+		return access.Evaluate(requesterID, member, s.grants[member.MemberId], time.Now())
+	}
+	if caller, ok := access.InternalFromContext(ctx); ok {
+		return access.Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "internal caller " + caller}
+	}
+	return access.Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "caller not identified"}
+}
+
+// requireSelf rejects calls that act on someone else's grants
+func requireSelf(ctx context.Context, memberID string) error {
+	if requesterID, ok := access.RequesterFromContext(ctx); ok {
+		if requesterID != memberID {
+			return status.Error(codes.PermissionDenied, "only the member can manage their proxy grants")
+		}
+		return nil
+	}
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "caller not identified")
+	}
+	return nil
+}
+
+// requireRequester rejects calls asking about a requester other than the
+// caller, so members can't probe other members' relationships, grants or age
+// bands. Internal callers may ask about anyone.
+func requireRequester(ctx context.Context, requesterID string) error {
+	if callerID, ok := access.RequesterFromContext(ctx); ok {
+		if callerID != requesterID {
+			return status.Error(codes.PermissionDenied, "members can only check their own access")
+		}
+		return nil
+	}
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "caller not identified")
+	}
+	return nil
+}
+
+func (s *MemberService) CheckMemberAccess(ctx context.Context, req *pb.CheckMemberAccessRequest) (*pb.CheckMemberAccessResponse, error) {
+	if req.RequesterMemberId == "" || req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "requester_member_id and member_id are required")
+	}
+	if err := requireRequester(ctx, req.RequesterMemberId); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, exists := s.members[req.MemberId]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+
+	decision := access.Evaluate(req.RequesterMemberId, member, s.grants[member.MemberId], time.Now())
+
+	return &pb.CheckMemberAccessResponse{
+		AccessLevel: decision.Level,
+		Reason:      decision.Reason,
+	}, nil
+}
+
+func (s *MemberService) GrantProxyAccess(ctx context.Context, req *pb.GrantProxyAccessRequest) (*pb.GrantProxyAccessResponse, error) {
+	if req.MemberId == "" || req.ProxyMemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and proxy_member_id are required")
+	}
+	if req.MemberId == req.ProxyMemberId {
+		return nil, status.Error(codes.InvalidArgument, "a member cannot be their own proxy")
+	}
+	if err := requireSelf(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.AsTime().After(now) {
+		return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.members[req.MemberId]; !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+	if _, exists := s.members[req.ProxyMemberId]; !exists {
+		return nil, status.Errorf(codes.NotFound, "proxy member not found: %s", req.ProxyMemberId)
+	}
+
+	for _, grant := range s.grants[req.MemberId] {
+		if grant.ProxyMemberId == req.ProxyMemberId && access.GrantActive(grant, now) {
+			return nil, status.Errorf(codes.AlreadyExists, "active grant already exists: %s", grant.GrantId)
+		}
+	}
+
+	grant := &pb.ProxyGrant{
+		GrantId:       uuid.New().String(),
+		MemberId:      req.MemberId,
+		ProxyMemberId: req.ProxyMemberId,
+		GrantedAt:     timestamppb.New(now),
+		ExpiresAt:     req.ExpiresAt,
+	}
+	s.grants[req.MemberId] = append(s.grants[req.MemberId], grant)
+
+	return &pb.GrantProxyAccessResponse{
+		Grant: grant,
+	}, nil
+}
+
+func (s *MemberService) RevokeProxyAccess(ctx context.Context, req *pb.RevokeProxyAccessRequest) (*pb.RevokeProxyAccessResponse, error) {
+	if req.MemberId == "" || req.GrantId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and grant_id are required")
+	}
+	if err := requireSelf(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, grant := range s.grants[req.MemberId] {
+		if grant.GrantId != req.GrantId {
+			continue
+		}
+		if grant.RevokedAt == nil {
+			grant.RevokedAt = timestamppb.Now()
+		}
+		return &pb.RevokeProxyAccessResponse{
+			Grant: grant,
+		}, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "grant not found: %s", req.GrantId)
+}
+
+func (s *MemberService) ListProxyGrants(ctx context.Context, req *pb.ListProxyGrantsRequest) (*pb.ListProxyGrantsResponse, error) {
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if err := requireSelf(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	grants := []*pb.ProxyGrant{}
+	for _, grant := range s.grants[req.MemberId] {
+		if req.IncludeRevoked || access.GrantActive(grant, now) {
+			grants = append(grants, grant)
+		}
+	}
+
+	return &pb.ListProxyGrantsResponse{
+		Grants: grants,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sydney-health-clone/backend/services/member/internal/contact"
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/logger"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// contactError maps verification failures to gRPC errors
+func contactError(err error) error {
+	var attempt *contact.AttemptError
+	switch {
+	case errors.As(err, &attempt):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, contact.ErrInvalidEmail), errors.Is(err, contact.ErrInvalidPhone):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, contact.ErrEmailInUse):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, contact.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, contact.ErrExpired), errors.Is(err, contact.ErrTooManyTries):
+		return status.Errorf(codes.FailedPrecondition, "%v; update the member again for a new code", err)
+	default:
+		logger.Error("Contact verification failed", zap.Error(err))
+		return status.Error(codes.Internal, "failed to verify contact")
+	}
+}
+
+// checkContactChanges rejects an email another member already uses, before
+// a code is sent to it. Callers must hold s.mu.
+func (s *MemberService) checkContactChanges(memberID string, changes []contact.Change) error {
+	for _, change := range changes {
+		if change.Channel != pb.ContactChannel_CONTACT_CHANNEL_EMAIL {
+			continue
+		}
+		for id, member := range s.members {
+			if id != memberID && strings.EqualFold(member.Email, change.Value) {
+				return contact.ErrEmailInUse
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyContact commits a pending email or phone change once the member
+// enters the code sent to the new address, and tells the old address
+func (s *MemberService) VerifyContact(ctx context.Context, req *pb.VerifyContactRequest) (*pb.VerifyContactResponse, error) {
+	if req.MemberId == "" || req.VerificationId == "" || req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id, verification_id and code are required")
+	}
+
+	s.mu.RLock()
+	existing, exists := s.members[req.MemberId]
+	var decision access.Decision
+	if exists {
+		decision = s.authorize(ctx, existing)
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+
+	if !decision.Full() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	pending, err := s.contacts.Verify(ctx, req.MemberId, req.VerificationId, req.Code)
+	if err != nil {
+		return nil, contactError(err)
+	}
+
+	s.mu.Lock()
+	current, exists := s.members[req.MemberId]
+	if !exists {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+	// Another member may have taken the email since the code was sent
+	if err := s.checkContactChanges(req.MemberId, []contact.Change{{Channel: pending.Channel, Value: pending.Value}}); err != nil {
+		s.mu.Unlock()
+		return nil, contactError(err)
+	}
+	member := proto.Clone(current).(*pb.Member)
+	old := contact.Apply(member, pending)
+	s.members[req.MemberId] = member
+	s.mu.Unlock()
+
+	// The change stands even if the notice can't be sent
+	if err := s.contacts.NotifyChanged(ctx, pending.Channel, old, pending.Value); err != nil {
+		logger.Warn("Failed to notify previous contact address", zap.String("member_id", req.MemberId), zap.Error(err))
+	}
+
+	return &pb.VerifyContactResponse{
+		Member: member,
+	}, nil
+}
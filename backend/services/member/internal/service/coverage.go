@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// requireInternal rejects calls to administrative RPCs from anyone but
+// internal callers
+func requireInternal(ctx context.Context) error {
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "coverage changes are restricted to internal callers")
+	}
+	return nil
+}
+
+func date(year int, month time.Month, day int) *timestamppb.Timestamp {
+	return timestamppb.New(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// initCoverageSpans seeds coverage history for the mock members
+func (s *MemberService) initCoverageSpans() {
+	family := func(memberID, prefix string, types ...pb.CoverageType) {
+		for _, coverageType := range types {
+			name := coverage.TypeName(coverageType)
+			span := &pb.CoverageSpan{
+				SpanId:        fmt.Sprintf("CS-%s-%s", memberID, name),
+				MemberId:      memberID,
+				CoverageType:  coverageType,
+				PlanId:        prefix + name,
+				PlanName:      "Premium Health Plan",
+				GroupNumber:   "GRP001234",
+				Tier:          pb.CoverageTier_COVERAGE_TIER_FAMILY,
+				EffectiveDate: date(2020, time.January, 1),
+				UpdatedAt:     date(2020, time.January, 1),
+			}
+			s.spans[memberID] = append(s.spans[memberID], span)
+		}
+	}
+
+	all := []pb.CoverageType{
+		pb.CoverageType_COVERAGE_TYPE_MEDICAL,
+		pb.CoverageType_COVERAGE_TYPE_DENTAL,
+		pb.CoverageType_COVERAGE_TYPE_VISION,
+		pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	}
+	family("M123456", "PLN-PREM-", all[1:]...)
+	family("M123457", "PLN-PREM-", all...)
+	family("M123458", "PLN-PREM-", all[:3]...)
+
+	// The subscriber moved from the standard to the premium medical plan in 2024
+	s.spans["M123456"] = append(s.spans["M123456"],
+		&pb.CoverageSpan{
+			SpanId:            "CS-M123456-MEDICAL-2020",
+			MemberId:          "M123456",
+			CoverageType:      pb.CoverageType_COVERAGE_TYPE_MEDICAL,
+			PlanId:            "PLN-STD-MEDICAL",
+			PlanName:          "Standard Health Plan",
+			GroupNumber:       "GRP001234",
+			Tier:              pb.CoverageTier_COVERAGE_TIER_FAMILY,
+			EffectiveDate:     date(2020, time.January, 1),
+			TerminationDate:   date(2023, time.December, 31),
+			TerminationReason: pb.TerminationReason_TERMINATION_REASON_PLAN_CHANGE,
+			UpdatedAt:         date(2023, time.November, 15),
+		},
+		&pb.CoverageSpan{
+			SpanId:        "CS-M123456-MEDICAL",
+			MemberId:      "M123456",
+			CoverageType:  pb.CoverageType_COVERAGE_TYPE_MEDICAL,
+			PlanId:        "PLN-PREM-MEDICAL",
+			PlanName:      "Premium Health Plan",
+			GroupNumber:   "GRP001234",
+			Tier:          pb.CoverageTier_COVERAGE_TIER_FAMILY,
+			EffectiveDate: date(2024, time.January, 1),
+			UpdatedAt:     date(2023, time.November, 15),
+		},
+	)
+
+	for _, spans := range s.spans {
+		coverage.Sort(spans)
+	}
+}
+
+// syncActiveCoverages recomputes the member's current coverage list from
+// their spans. Callers must hold s.mu for writing.
+func (s *MemberService) syncActiveCoverages(memberID string) {
+	if member, exists := s.members[memberID]; exists {
+		member.ActiveCoverages = coverage.ActiveTypes(s.spans[memberID], time.Now())
+	}
+}
+
+func (s *MemberService) GetCoverageHistory(ctx context.Context, req *pb.GetCoverageHistoryRequest) (*pb.GetCoverageHistoryResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, exists := s.members[req.MemberId]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+
+	if decision := s.authorize(ctx, member); !decision.Allowed() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	spans := []*pb.CoverageSpan{}
+	for _, span := range s.spans[req.MemberId] {
+		if req.CoverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED || span.CoverageType == req.CoverageType {
+			spans = append(spans, proto.Clone(span).(*pb.CoverageSpan))
+		}
+	}
+
+	return &pb.GetCoverageHistoryResponse{
+		Spans: spans,
+	}, nil
+}
+
+func (s *MemberService) CheckEligibility(ctx context.Context, req *pb.CheckEligibilityRequest) (*pb.CheckEligibilityResponse, error) {
+	if req.CoverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "coverage_type is required")
+	}
+
+	dateOfService := time.Now()
+	if req.DateOfService != nil {
+		dateOfService = req.DateOfService.AsTime()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, exists := s.members[req.MemberId]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+
+	if decision := s.authorize(ctx, member); !decision.Allowed() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	span, eligible, reason := coverage.Check(s.spans[req.MemberId], req.CoverageType, dateOfService)
+	if span != nil {
+		span = proto.Clone(span).(*pb.CoverageSpan)
+	}
+
+	return &pb.CheckEligibilityResponse{
+		Eligible: eligible,
+		Span:     span,
+		Reason:   reason,
+	}, nil
+}
+
+func (s *MemberService) TerminateCoverage(ctx context.Context, req *pb.TerminateCoverageRequest) (*pb.TerminateCoverageResponse, error) {
+	if req.SpanId == "" || req.TerminationDate == nil {
+		return nil, status.Error(codes.InvalidArgument, "span_id and termination_date are required")
+	}
+	if err := requireInternal(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := s.findSpan(req.MemberId, req.SpanId)
+	if span == nil {
+		return nil, status.Errorf(codes.NotFound, "coverage span not found: %s", req.SpanId)
+	}
+
+	if err := coverage.ValidateTermination(span, req.TerminationDate.AsTime()); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	coverage.Terminate(span, req.TerminationDate.AsTime(), req.Reason, time.Now())
+	s.syncActiveCoverages(req.MemberId)
+	s.refreshWalletPassesAsync(req.MemberId)
+
+	return &pb.TerminateCoverageResponse{
+		Span: proto.Clone(span).(*pb.CoverageSpan),
+	}, nil
+}
+
+func (s *MemberService) ReinstateCoverage(ctx context.Context, req *pb.ReinstateCoverageRequest) (*pb.ReinstateCoverageResponse, error) {
+	if req.SpanId == "" {
+		return nil, status.Error(codes.InvalidArgument, "span_id is required")
+	}
+	if err := requireInternal(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := s.findSpan(req.MemberId, req.SpanId)
+	if span == nil {
+		return nil, status.Errorf(codes.NotFound, "coverage span not found: %s", req.SpanId)
+	}
+
+	if err := coverage.ValidateReinstatement(s.spans[req.MemberId], span); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	coverage.Reinstate(span, time.Now())
+	s.syncActiveCoverages(req.MemberId)
+	s.refreshWalletPassesAsync(req.MemberId)
+
+	return &pb.ReinstateCoverageResponse{
+		Span: proto.Clone(span).(*pb.CoverageSpan),
+	}, nil
+}
+
+// findSpan looks up a member's span by ID. Callers must hold s.mu.
+func (s *MemberService) findSpan(memberID, spanID string) *pb.CoverageSpan {
+	for _, span := range s.spans[memberID] {
+		if span.SpanId == spanID {
+			return span
+		}
+	}
+	return nil
+}
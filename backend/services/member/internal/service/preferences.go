@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/preferences"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// GetMemberPreferences returns the member's communication preferences, or the
+// defaults if they have not set any
+func (s *MemberService) GetMemberPreferences(ctx context.Context, req *pb.GetMemberPreferencesRequest) (*pb.GetMemberPreferencesResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, exists := s.members[req.MemberId]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
+	}
+
+	if decision := s.authorize(ctx, member); !decision.Full() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	prefs, ok := s.prefs[req.MemberId]
+	if !ok {
+		prefs = preferences.Defaults(req.MemberId)
+	}
+
+	return &pb.GetMemberPreferencesResponse{
+		Preferences: proto.Clone(prefs).(*pb.MemberPreferences),
+	}, nil
+}
+
+// UpdateMemberPreferences replaces the member's communication preferences
+func (s *MemberService) UpdateMemberPreferences(ctx context.Context, req *pb.UpdateMemberPreferencesRequest) (*pb.UpdateMemberPreferencesResponse, error) {
+	if req.Preferences == nil || req.Preferences.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "preferences and member_id are required")
+	}
+	memberID := req.Preferences.MemberId
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, exists := s.members[memberID]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", memberID)
+	}
+
+	if decision := s.authorize(ctx, member); !decision.Full() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	prefs, err := preferences.Update(s.prefs[memberID], req.Preferences, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := preferences.CheckContact(prefs, member); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	s.prefs[memberID] = prefs
+
+	return &pb.UpdateMemberPreferencesResponse{
+		Preferences: proto.Clone(prefs).(*pb.MemberPreferences),
+	}, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	"github.com/sydney-health-clone/backend/shared/logger"
+	"github.com/sydney-health-clone/backend/shared/membersearch"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AuditPublisher sends audit events; satisfied by *kafka.Producer
+type AuditPublisher interface {
+	SendMessage(ctx context.Context, key string, value interface{}) error
+}
+
+// SearchMembers finds members for support agents whose ID the gateway signed;
+// an unsigned agent header is refused. Results are audited before they are
+// returned, and the search fails rather than go unaudited.
+func (s *MemberService) SearchMembers(ctx context.Context, req *pb.SearchMembersRequest) (*pb.SearchMembersResponse, error) {
+	agentID, ok := access.AgentFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "member search is restricted to support agents")
+	}
+
+	criteria, err := membersearch.FromRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.mu.RLock()
+	var results []*pb.MemberSearchResult
+	for _, member := range s.members {
+		if result, ok := membersearch.Match(member, criteria); ok {
+			result.Member = proto.Clone(member).(*pb.Member)
+			results = append(results, result)
+		}
+	}
+	s.mu.RUnlock()
+
+	membersearch.Sort(results)
+	results, page, err := membersearch.Page(results, req.Page)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	event := membersearch.AuditEvent(agentID, criteria, results, page)
+	logger.Info("Member search",
+		zap.String("search_id", event.EntityID),
+		zap.String("agent_id", agentID),
+		zap.Strings("criteria", criteria.Fields()),
+		zap.Int32("total_count", page.TotalCount),
+	)
+	if err := s.auditSearch(ctx, event); err != nil {
+		logger.Error("Failed to audit member search", zap.String("agent_id", agentID), zap.Error(err))
+		return nil, status.Error(codes.Unavailable, "member search could not be audited")
+	}
+
+	return &pb.SearchMembersResponse{
+		Results: results,
+		Page:    page,
+	}, nil
+}
+
+// auditSearch publishes the search's audit event. The event carries the
+// criteria and member IDs, so only the search ID is logged.
+func (s *MemberService) auditSearch(ctx context.Context, event *kafka.AuditEvent) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.SendMessage(ctx, event.EntityID, event)
+}
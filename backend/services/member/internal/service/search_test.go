@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// incoming returns the metadata a backend service receives for a call the
+// gateway made with the given outgoing metadata, signed or not
+func incoming(t *testing.T, signed bool, kv ...string) context.Context {
+	t.Helper()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), kv...)
+	if signed {
+		invoker := func(signedCtx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			ctx = signedCtx
+			return nil
+		}
+		if err := access.IdentityInterceptor()(ctx, "/health.member.MemberService/SearchMembers", nil, nil, nil, invoker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestSearchMembersRequiresSignedAgent(t *testing.T) {
+	t.Setenv(access.ServiceSecretEnv, "test-secret")
+
+	tampered := incoming(t, true, access.RequesterMetadataKey, "M123456", access.AgentMetadataKey, "agent-1")
+	md, _ := metadata.FromIncomingContext(tampered)
+	md = md.Copy()
+	md.Set(access.AgentMetadataKey, "agent-2")
+	tampered = metadata.NewIncomingContext(context.Background(), md)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{
+			name: "signed agent",
+			ctx:  incoming(t, true, access.RequesterMetadataKey, "M123456", access.AgentMetadataKey, "agent-1"),
+			want: codes.OK,
+		},
+		{
+			name: "unsigned agent header",
+			ctx:  incoming(t, false, access.RequesterMetadataKey, "M123456", access.AgentMetadataKey, "agent-1"),
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "agent header changed after signing",
+			ctx:  tampered,
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "signed member without the agent role",
+			ctx:  incoming(t, true, access.RequesterMetadataKey, "M123456"),
+			want: codes.PermissionDenied,
+		},
+		{
+			name: "signed internal service",
+			ctx:  access.WithInternal(context.Background(), "test"),
+			want: codes.PermissionDenied,
+		},
+	}
+
+	svc := NewMemberService(nil, nil, nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.SearchMembers(tt.ctx, &pb.SearchMembersRequest{SubscriberId: "SUB123456"})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("SearchMembers() code = %v, want %v (err %v)", got, tt.want, err)
+			}
+			if tt.want == codes.OK && len(resp.Results) == 0 {
+				t.Fatal("SearchMembers() returned no results for a signed agent")
+			}
+		})
+	}
+}
+
+func TestSearchMembersRequiresSecret(t *testing.T) {
+	t.Setenv(access.ServiceSecretEnv, "")
+
+	ctx := incoming(t, true, access.RequesterMetadataKey, "M123456", access.AgentMetadataKey, "agent-1")
+	_, err := NewMemberService(nil, nil, nil, nil).SearchMembers(ctx, &pb.SearchMembersRequest{SubscriberId: "SUB123456"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("SearchMembers() without a secret: err = %v, want PermissionDenied", err)
+	}
+}
@@ -3,26 +3,55 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/sydney-health-clone/backend/services/member/internal/card"
+	"github.com/sydney-health-clone/backend/services/member/internal/contact"
+	"github.com/sydney-health-clone/backend/services/member/internal/wallet"
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/logger"
 	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type MemberService struct {
 	pb.UnimplementedMemberServiceServer
-	members map[string]*pb.Member
+	mu       sync.RWMutex
+	members  map[string]*pb.Member
+	grants   map[string][]*pb.ProxyGrant
+	spans    map[string][]*pb.CoverageSpan
+	prefs    map[string]*pb.MemberPreferences // members without one have the defaults
+	cards    *card.Renderer
+	passes   *wallet.Service
+	contacts *contact.Verifier
+	audit    AuditPublisher
 }
 
-func NewMemberService() *MemberService {
+// NewMemberService creates the service. audit may be nil, in which case
+// member searches are only logged.
+func NewMemberService(cards *card.Renderer, passes *wallet.Service, contacts *contact.Verifier, audit AuditPublisher) *MemberService {
 	svc := &MemberService{
-		members: make(map[string]*pb.Member),
+		members:  make(map[string]*pb.Member),
+		grants:   make(map[string][]*pb.ProxyGrant),
+		spans:    make(map[string][]*pb.CoverageSpan),
+		prefs:    make(map[string]*pb.MemberPreferences),
+		cards:    cards,
+		passes:   passes,
+		contacts: contacts,
+		audit:    audit,
 	}
 	
 	// Initialize with mock data
 	svc.initMockData()
+	svc.initCoverageSpans()
 	
 	return svc
 }
@@ -75,6 +104,7 @@ func (s *MemberService) initMockData() {
 		GroupNumber:    "GRP001234",
 		SubscriberId:   "SUB123456-01",
 		EnrollmentDate: timestamppb.New(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		SubscriberMemberId: "M123456",
 		ActiveCoverages: []pb.CoverageType{
 			pb.CoverageType_COVERAGE_TYPE_MEDICAL,
 			pb.CoverageType_COVERAGE_TYPE_DENTAL,
@@ -101,6 +131,7 @@ func (s *MemberService) initMockData() {
 		GroupNumber:    "GRP001234",
 		SubscriberId:   "SUB123456-02",
 		EnrollmentDate: timestamppb.New(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		SubscriberMemberId: "M123456",
 		ActiveCoverages: []pb.CoverageType{
 			pb.CoverageType_COVERAGE_TYPE_MEDICAL,
 			pb.CoverageType_COVERAGE_TYPE_DENTAL,
@@ -110,11 +141,18 @@ func (s *MemberService) initMockData() {
 }
 
 func (s *MemberService) GetMember(ctx context.Context, req *pb.GetMemberRequest) (*pb.GetMemberResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
 	member, exists := s.members[req.MemberId]
 	if !exists {
 		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
 	}
 	
+	if decision := s.authorize(ctx, member); !decision.Allowed() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+	
 	return &pb.GetMemberResponse{
 		Member: member,
 	}, nil
@@ -125,25 +163,97 @@ func (s *MemberService) UpdateMember(ctx context.Context, req *pb.UpdateMemberRe
 		return nil, status.Error(codes.InvalidArgument, "member is required")
 	}
 	
-	_, exists := s.members[req.Member.MemberId]
+	s.mu.RLock()
+	existing, exists := s.members[req.Member.MemberId]
+	var decision access.Decision
+	var changes []contact.Change
+	var err error
+	if exists {
+		decision = s.authorize(ctx, existing)
+		changes, err = contact.Changes(existing, req.Member)
+		if err == nil {
+			err = s.checkContactChanges(req.Member.MemberId, changes)
+		}
+	}
+	s.mu.RUnlock()
+	
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.Member.MemberId)
+	}
+	
+	if !decision.Full() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+	
+	// New contact details wait for verification; everything else is saved now
+	if err != nil {
+		return nil, contactError(err)
+	}
+	
+	// Codes are sent without holding the lock, so a slow notifier doesn't
+	// stall every other request
+	var pending []*pb.PendingContactChange
+	for _, change := range changes {
+		p, err := s.contacts.Start(ctx, req.Member.MemberId, change.Channel, change.Value)
+		if err != nil {
+			logger.Error("Failed to start contact verification", zap.String("member_id", req.Member.MemberId), zap.Error(err))
+			return nil, status.Error(codes.Unavailable, "failed to send verification code")
+		}
+		pending = append(pending, p)
+	}
+	
+	s.mu.Lock()
+	current, exists := s.members[req.Member.MemberId]
 	if !exists {
+		s.mu.Unlock()
 		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.Member.MemberId)
 	}
 	
+	// The policy relationship is not editable by the member
+	req.Member.SubscriberMemberId = current.SubscriberMemberId
+	contact.Hold(req.Member, current)
+	
 	// Update member (in real implementation, this would update the database)
 	s.members[req.Member.MemberId] = req.Member
 	
+	// Coverage comes from the member's coverage spans, not the request
+	s.syncActiveCoverages(req.Member.MemberId)
+	s.mu.Unlock()
+	
+	// Installed wallet passes are only pushed when their content changed
+	s.refreshWalletPassesAsync(req.Member.MemberId)
+	
 	return &pb.UpdateMemberResponse{
-		Member: req.Member,
+		Member:                req.Member,
+		PendingContactChanges: pending,
 	}, nil
 }
 
 func (s *MemberService) GetMemberCard(ctx context.Context, req *pb.GetMemberCardRequest) (*pb.GetMemberCardResponse, error) {
+	s.mu.RLock()
 	member, exists := s.members[req.MemberId]
+	var decision access.Decision
+	var current *pb.CoverageSpan
+	if exists {
+		decision = s.authorize(ctx, member)
+		day := time.Now()
+		if req.DateOfService != nil {
+			day = req.DateOfService.AsTime()
+		}
+		if span := coverage.Find(s.spans[req.MemberId], req.CoverageType, day); span != nil {
+			current = proto.Clone(span).(*pb.CoverageSpan)
+		}
+	}
+	s.mu.RUnlock()
+	
 	if !exists {
 		return nil, status.Errorf(codes.NotFound, "member not found: %s", req.MemberId)
 	}
 	
+	if !decision.Allowed() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+	
 	// Generate member card based on coverage type
 	card := &pb.MemberCard{
 		MemberId:     member.MemberId,
@@ -155,6 +265,12 @@ func (s *MemberService) GetMemberCard(ctx context.Context, req *pb.GetMemberCard
 		AdditionalInfo: make(map[string]string),
 	}
 	
+	// Print the plan in effect for this coverage on the date of service
+	if current != nil {
+		card.PlanName = current.PlanName
+		card.GroupNumber = current.GroupNumber
+	}
+	
 	// Add coverage-specific information
 	switch req.CoverageType {
 	case pb.CoverageType_COVERAGE_TYPE_MEDICAL:
@@ -181,25 +297,84 @@ func (s *MemberService) GetMemberCard(ctx context.Context, req *pb.GetMemberCard
 		card.AdditionalInfo["contacts"] = "$150 allowance"
 	}
 	
+	// The standard medical plan charges more per visit than the premium one
+	if current != nil && current.PlanId == "PLN-STD-MEDICAL" {
+		card.AdditionalInfo["copay_primary"] = "$30"
+		card.AdditionalInfo["copay_specialist"] = "$60"
+		card.AdditionalInfo["emergency_room"] = "$250"
+	}
+	
 	return &pb.GetMemberCardResponse{
 		Card: card,
 	}, nil
 }
 
-func (s *MemberService) ListDependents(ctx context.Context, req *pb.ListDependentsRequest) (*pb.ListDependentsResponse, error) {
-	// For demo purposes, return hardcoded dependents for primary member
-	if req.MemberId != "M123456" {
-		return &pb.ListDependentsResponse{
-			Dependents: []*pb.Member{},
-		}, nil
+func (s *MemberService) RenderMemberCard(ctx context.Context, req *pb.RenderMemberCardRequest) (*pb.RenderMemberCardResponse, error) {
+	resp, err := s.GetMemberCard(ctx, &pb.GetMemberCardRequest{
+		MemberId:     req.MemberId,
+		CoverageType: req.CoverageType,
+	})
+	if err != nil {
+		return nil, err
 	}
 	
-	dependents := []*pb.Member{
-		s.members["M123457"], // Spouse
-		s.members["M123458"], // Child
+	data, contentType, err := s.cards.Render(resp.Card, req.CoverageType, req.Format, req.Side)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to render member card: %v", err)
 	}
 	
+	return &pb.RenderMemberCardResponse{
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+func (s *MemberService) ListDependents(ctx context.Context, req *pb.ListDependentsRequest) (*pb.ListDependentsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
+	dependents := []*pb.Member{}
+	for _, member := range s.members {
+		if member.SubscriberMemberId != req.MemberId {
+			continue
+		}
+		
+		// Dependents the requester may not see are listed by name only
+		if decision := s.authorize(ctx, member); !decision.Allowed() {
+			member = access.Redact(member)
+		}
+		dependents = append(dependents, member)
+	}
+	
+	sort.Slice(dependents, func(i, j int) bool {
+		return dependents[i].MemberId < dependents[j].MemberId
+	})
+	
 	return &pb.ListDependentsResponse{
 		Dependents: dependents,
 	}, nil
+}
+
+// LookupMember resolves the member ID printed on a card, which is what
+// trading partners quote in EDI transactions
+func (s *MemberService) LookupMember(ctx context.Context, req *pb.LookupMemberRequest) (*pb.LookupMemberResponse, error) {
+	if req.SubscriberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "subscriber_id is required")
+	}
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return nil, status.Error(codes.PermissionDenied, "member lookup is restricted to internal callers")
+	}
+	
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
+	for _, member := range s.members {
+		if strings.EqualFold(member.SubscriberId, req.SubscriberId) {
+			return &pb.LookupMemberResponse{
+				Member: proto.Clone(member).(*pb.Member),
+			}, nil
+		}
+	}
+	
+	return nil, status.Errorf(codes.NotFound, "member not found: %s", req.SubscriberId)
 }
\ No newline at end of file
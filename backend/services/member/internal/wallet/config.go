@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Config describes the pass issuer and where its signing material lives.
+// Apple passes are disabled when the certificate files are not set, and
+// Google passes when the service account key is not set.
+type Config struct {
+	// Apple Wallet
+	PassTypeIdentifier string
+	TeamIdentifier     string
+	OrganizationName   string
+	WebServiceURL      string
+	CertFile           string
+	KeyFile            string
+	WWDRFile           string
+
+	// Google Wallet
+	GoogleIssuerID            string
+	GoogleClassSuffix         string
+	GoogleServiceAccountEmail string
+	GoogleKeyFile             string
+	Origins                   []string
+
+	// Secret used to derive per-pass authentication tokens
+	AuthSecret string
+}
+
+// signer holds the Apple pass signing certificate chain
+type signer struct {
+	cert *x509.Certificate
+	key  crypto.PrivateKey
+	wwdr *x509.Certificate
+}
+
+func loadSigner(cfg Config) (*signer, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" || cfg.WWDRFile == "" {
+		return nil, nil
+	}
+
+	cert, err := loadCertificate(cfg.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	wwdr, err := loadCertificate(cfg.WWDRFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pass key: %w", err)
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pass key: %w", err)
+	}
+
+	return &signer{cert: cert, key: key, wwdr: wwdr}, nil
+}
+
+func loadGoogleKey(cfg Config) (*rsa.PrivateKey, error) {
+	if cfg.GoogleKeyFile == "" {
+		return nil, nil
+	}
+
+	keyPEM, err := os.ReadFile(cfg.GoogleKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google Wallet key: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Google Wallet key: %w", err)
+	}
+	return key, nil
+}
+
+func loadCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sydney-health-clone/backend/services/member/internal/card"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// GoogleSaveURLPrefix is followed by the signed JWT to form a "Save to Google Wallet" link
+const GoogleSaveURLPrefix = "https://pay.google.com/gp/v/save/"
+
+type googleText struct {
+	ID     string `json:"id"`
+	Header string `json:"header"`
+	Body   string `json:"body"`
+}
+
+type googleLocalized struct {
+	DefaultValue googleValue `json:"defaultValue"`
+}
+
+type googleValue struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+type googleBarcode struct {
+	Type          string `json:"type"`
+	Value         string `json:"value"`
+	AlternateText string `json:"alternateText,omitempty"`
+}
+
+type googleGenericObject struct {
+	ID                 string          `json:"id"`
+	ClassID            string          `json:"classId"`
+	State              string          `json:"state"`
+	CardTitle          googleLocalized `json:"cardTitle"`
+	Header             googleLocalized `json:"header"`
+	Subheader          googleLocalized `json:"subheader"`
+	HexBackgroundColor string          `json:"hexBackgroundColor"`
+	Barcode            googleBarcode   `json:"barcode"`
+	TextModulesData    []googleText    `json:"textModulesData"`
+}
+
+func localized(value string) googleLocalized {
+	return googleLocalized{DefaultValue: googleValue{Language: "en-US", Value: value}}
+}
+
+// googleObjectFor builds the Google Wallet generic object for a card
+func (s *Service) googleObjectFor(mc *pb.MemberCard, coverageType pb.CoverageType) googleGenericObject {
+	accent, title := card.Theme(coverageType)
+	serial := SerialNumber(mc.MemberId, coverageType)
+
+	modules := []googleText{
+		{ID: "member_id", Header: "Member ID", Body: mc.MemberNumber},
+		{ID: "group", Header: "Group", Body: mc.GroupNumber},
+		{ID: "coverage", Header: "Coverage", Body: title},
+	}
+	if mc.BinNumber != "" {
+		modules = append(modules,
+			googleText{ID: "rx_bin", Header: "RxBIN", Body: mc.BinNumber},
+			googleText{ID: "rx_pcn", Header: "RxPCN", Body: mc.PcnNumber},
+			googleText{ID: "rx_group", Header: "RxGrp", Body: mc.RxGroup},
+		)
+	}
+
+	keys := make([]string, 0, len(mc.AdditionalInfo))
+	for key := range mc.AdditionalInfo {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		modules = append(modules, googleText{ID: key, Header: key, Body: mc.AdditionalInfo[key]})
+	}
+
+	return googleGenericObject{
+		ID:                 fmt.Sprintf("%s.%s", s.cfg.GoogleIssuerID, serial),
+		ClassID:            fmt.Sprintf("%s.%s", s.cfg.GoogleIssuerID, s.cfg.GoogleClassSuffix),
+		State:              "ACTIVE",
+		CardTitle:          localized(mc.PlanName),
+		Header:             localized(mc.MemberName),
+		Subheader:          localized(strings.ToUpper(title[:1]) + strings.ToLower(title[1:]) + " coverage"),
+		HexBackgroundColor: fmt.Sprintf("#%02x%02x%02x", accent.R, accent.G, accent.B),
+		Barcode: googleBarcode{
+			Type:          "PDF_417",
+			Value:         card.BarcodePayload(mc),
+			AlternateText: mc.MemberNumber,
+		},
+		TextModulesData: modules,
+	}
+}
+
+// googleSaveURL signs the generic object into a "Save to Google Wallet" link
+func (s *Service) googleSaveURL(mc *pb.MemberCard, coverageType pb.CoverageType) (string, error) {
+	claims := jwt.MapClaims{
+		"iss":     s.cfg.GoogleServiceAccountEmail,
+		"aud":     "google",
+		"typ":     "savetowallet",
+		"origins": s.cfg.Origins,
+		"payload": map[string]interface{}{
+			"genericObjects": []googleGenericObject{s.googleObjectFor(mc, coverageType)},
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(s.googleKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Google Wallet pass: %w", err)
+	}
+	return GoogleSaveURLPrefix + token, nil
+}
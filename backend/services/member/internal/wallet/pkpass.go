@@ -0,0 +1,241 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+
+	"github.com/sydney-health-clone/backend/services/member/internal/card"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+
+	"go.mozilla.org/pkcs7"
+	"golang.org/x/image/draw"
+)
+
+// ContentTypePKPass is the MIME type Wallet expects for pass bundles
+const ContentTypePKPass = "application/vnd.apple.pkpass"
+
+type passField struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type passBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+type passStructure struct {
+	PrimaryFields   []passField `json:"primaryFields"`
+	SecondaryFields []passField `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []passField `json:"auxiliaryFields,omitempty"`
+	BackFields      []passField `json:"backFields,omitempty"`
+}
+
+type passJSON struct {
+	FormatVersion       int           `json:"formatVersion"`
+	PassTypeIdentifier  string        `json:"passTypeIdentifier"`
+	SerialNumber        string        `json:"serialNumber"`
+	TeamIdentifier      string        `json:"teamIdentifier"`
+	OrganizationName    string        `json:"organizationName"`
+	Description         string        `json:"description"`
+	LogoText            string        `json:"logoText,omitempty"`
+	WebServiceURL       string        `json:"webServiceURL,omitempty"`
+	AuthenticationToken string        `json:"authenticationToken,omitempty"`
+	BackgroundColor     string        `json:"backgroundColor"`
+	ForegroundColor     string        `json:"foregroundColor"`
+	LabelColor          string        `json:"labelColor"`
+	Voided              bool          `json:"voided,omitempty"`
+	Barcodes            []passBarcode `json:"barcodes"`
+	Generic             passStructure `json:"generic"`
+}
+
+// passJSONFor builds the pass.json document for a card. The output contains
+// no timestamps, so it changes only when the card content or voided changes.
+func (s *Service) passJSONFor(mc *pb.MemberCard, coverageType pb.CoverageType, voided bool) ([]byte, error) {
+	accent, title := card.Theme(coverageType)
+	serial := SerialNumber(mc.MemberId, coverageType)
+
+	pass := passJSON{
+		FormatVersion:       1,
+		PassTypeIdentifier:  s.cfg.PassTypeIdentifier,
+		SerialNumber:        serial,
+		TeamIdentifier:      s.cfg.TeamIdentifier,
+		OrganizationName:    s.cfg.OrganizationName,
+		Description:         fmt.Sprintf("%s %s ID card", s.cfg.OrganizationName, title),
+		LogoText:            mc.PlanName,
+		WebServiceURL:       s.cfg.WebServiceURL,
+		AuthenticationToken: s.AuthenticationToken(serial),
+		BackgroundColor:     rgb(accent),
+		ForegroundColor:     "rgb(255, 255, 255)",
+		LabelColor:          "rgb(230, 236, 245)",
+		Voided:              voided,
+		Barcodes: []passBarcode{{
+			Format:          "PKBarcodeFormatPDF417",
+			Message:         card.BarcodePayload(mc),
+			MessageEncoding: "iso-8859-1",
+			AltText:         mc.MemberNumber,
+		}},
+		Generic: passStructure{
+			PrimaryFields: []passField{
+				{Key: "member", Label: "MEMBER", Value: mc.MemberName},
+			},
+			SecondaryFields: []passField{
+				{Key: "memberNumber", Label: "MEMBER ID", Value: mc.MemberNumber},
+				{Key: "group", Label: "GROUP", Value: mc.GroupNumber},
+			},
+			AuxiliaryFields: []passField{
+				{Key: "coverage", Label: "COVERAGE", Value: title},
+				{Key: "plan", Label: "PLAN", Value: mc.PlanName},
+			},
+			BackFields: backFields(mc),
+		},
+	}
+
+	return json.MarshalIndent(pass, "", "  ")
+}
+
+func backFields(mc *pb.MemberCard) []passField {
+	var fields []passField
+	if mc.BinNumber != "" {
+		fields = append(fields,
+			passField{Key: "rxBin", Label: "RxBIN", Value: mc.BinNumber},
+			passField{Key: "rxPcn", Label: "RxPCN", Value: mc.PcnNumber},
+			passField{Key: "rxGroup", Label: "RxGrp", Value: mc.RxGroup},
+		)
+	}
+
+	keys := make([]string, 0, len(mc.AdditionalInfo))
+	for key := range mc.AdditionalInfo {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, passField{Key: key, Label: key, Value: mc.AdditionalInfo[key]})
+	}
+	return fields
+}
+
+// buildPKPass assembles and signs a .pkpass bundle
+func (s *Service) buildPKPass(mc *pb.MemberCard, coverageType pb.CoverageType, passJSON []byte) ([]byte, error) {
+	accent, _ := card.Theme(coverageType)
+
+	files := map[string][]byte{
+		"pass.json": passJSON,
+	}
+
+	for name, size := range map[string]int{"icon.png": 29, "icon@2x.png": 58, "icon@3x.png": 87} {
+		icon, err := encodePNG(solidImage(size, size, accent))
+		if err != nil {
+			return nil, err
+		}
+		files[name] = icon
+	}
+
+	if logo := s.logos.Logo(card.LogoSlotPlan, mc); logo != nil {
+		for name, scale := range map[string]int{"logo.png": 1, "logo@2x.png": 2} {
+			data, err := encodePNG(fitImage(logo, 160*scale, 50*scale))
+			if err != nil {
+				return nil, err
+			}
+			files[name] = data
+		}
+	}
+
+	manifest := make(map[string]string, len(files))
+	for name, data := range files {
+		sum := sha1.Sum(data)
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	files["manifest.json"] = manifestJSON
+
+	signature, err := s.sign(manifestJSON)
+	if err != nil {
+		return nil, err
+	}
+	files["signature"] = signature
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to pass: %w", name, err)
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, fmt.Errorf("failed to write %s to pass: %w", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish pass archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// sign produces the detached PKCS#7 signature over the manifest, including
+// the Apple WWDR intermediate as Wallet requires.
+func (s *Service) sign(manifest []byte) ([]byte, error) {
+	sd, err := pkcs7.NewSignedData(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature: %w", err)
+	}
+	if err := sd.AddSignerChain(s.signer.cert, s.signer.key, []*x509.Certificate{s.signer.wwdr}, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, fmt.Errorf("failed to sign manifest: %w", err)
+	}
+	sd.Detach()
+
+	return sd.Finish()
+}
+
+func rgb(c color.RGBA) string {
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.R, c.G, c.B)
+}
+
+func solidImage(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// fitImage scales src to fit within w x h, preserving aspect ratio
+func fitImage(src image.Image, w, h int) image.Image {
+	b := src.Bounds()
+	dw, dh := w, b.Dy()*w/b.Dx()
+	if dh > h {
+		dw, dh = b.Dx()*h/b.Dy(), h
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode pass image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
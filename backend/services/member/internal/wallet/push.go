@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APNsHost is the production APNs endpoint Wallet updates are sent through
+const APNsHost = "https://api.push.apple.com"
+
+// Pusher notifies a device that one of its passes has changed
+type Pusher interface {
+	Push(ctx context.Context, pushToken string) error
+}
+
+// APNsPusher sends Wallet's empty update notification over APNs,
+// authenticating with the pass type certificate.
+type APNsPusher struct {
+	client *http.Client
+	host   string
+	topic  string
+}
+
+func newAPNsPusher(s *signer, topic string) *APNsPusher {
+	cert := tls.Certificate{
+		Certificate: [][]byte{s.cert.Raw},
+		PrivateKey:  s.key,
+		Leaf:        s.cert,
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:   &tls.Config{Certificates: []tls.Certificate{cert}},
+		ForceAttemptHTTP2: true,
+	}
+
+	return &APNsPusher{
+		client: &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		host:   APNsHost,
+		topic:  topic,
+	}
+}
+
+func (p *APNsPusher) Push(ctx context.Context, pushToken string) error {
+	url := fmt.Sprintf("%s/3/device/%s", p.host, pushToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader("{}"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "background")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push pass update: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("APNs rejected pass update: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registration links a device's Wallet app to an installed pass
+type Registration struct {
+	DeviceLibraryID string
+	PassTypeID      string
+	SerialNumber    string
+	PushToken       string
+}
+
+// Registry stores device registrations and pass update times for the
+// PassKit web service.
+type Registry interface {
+	// Register records the registration and reports whether it is new
+	Register(ctx context.Context, reg Registration) (bool, error)
+	Unregister(ctx context.Context, deviceLibraryID, passTypeID, serialNumber string) error
+	// SerialsUpdatedSince lists the device's passes updated after since (all
+	// of them when since is zero) and the latest update time among them.
+	SerialsUpdatedSince(ctx context.Context, deviceLibraryID, passTypeID string, since time.Time) ([]string, time.Time, error)
+	PushTokens(ctx context.Context, passTypeID, serialNumber string) ([]string, error)
+	// MemberSerials lists the serials starting with memberID and a dash that
+	// are installed on any device; callers confirm them with ParseSerialNumber.
+	MemberSerials(ctx context.Context, passTypeID, memberID string) ([]string, error)
+	// MarkUpdated stores the pass fingerprint and reports whether it changed
+	MarkUpdated(ctx context.Context, serialNumber, fingerprint string, at time.Time) (bool, error)
+	LastUpdated(ctx context.Context, serialNumber string) (time.Time, error)
+}
+
+type passState struct {
+	fingerprint string
+	updatedAt   time.Time
+}
+
+// MemoryRegistry is an in-process Registry
+type MemoryRegistry struct {
+	mu            sync.RWMutex
+	registrations map[Registration]struct{}
+	passes        map[string]passState
+}
+
+// NewMemoryRegistry creates an empty in-process registry
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		registrations: make(map[Registration]struct{}),
+		passes:        make(map[string]passState),
+	}
+}
+
+func (r *MemoryRegistry) Register(ctx context.Context, reg Registration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := true
+	for existing := range r.registrations {
+		if sameDevicePass(existing, reg) {
+			delete(r.registrations, existing)
+			created = false
+		}
+	}
+	r.registrations[reg] = struct{}{}
+	return created, nil
+}
+
+func (r *MemoryRegistry) Unregister(ctx context.Context, deviceLibraryID, passTypeID, serialNumber string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := Registration{DeviceLibraryID: deviceLibraryID, PassTypeID: passTypeID, SerialNumber: serialNumber}
+	for existing := range r.registrations {
+		if sameDevicePass(existing, key) {
+			delete(r.registrations, existing)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRegistry) SerialsUpdatedSince(ctx context.Context, deviceLibraryID, passTypeID string, since time.Time) ([]string, time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var serials []string
+	var latest time.Time
+	for reg := range r.registrations {
+		if reg.DeviceLibraryID != deviceLibraryID || reg.PassTypeID != passTypeID {
+			continue
+		}
+		updatedAt := r.passes[reg.SerialNumber].updatedAt
+		if !since.IsZero() && !updatedAt.After(since) {
+			continue
+		}
+		serials = append(serials, reg.SerialNumber)
+		if updatedAt.After(latest) {
+			latest = updatedAt
+		}
+	}
+	sort.Strings(serials)
+	return serials, latest, nil
+}
+
+func (r *MemoryRegistry) PushTokens(ctx context.Context, passTypeID, serialNumber string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []string
+	for reg := range r.registrations {
+		if reg.PassTypeID == passTypeID && reg.SerialNumber == serialNumber {
+			tokens = append(tokens, reg.PushToken)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *MemoryRegistry) MemberSerials(ctx context.Context, passTypeID, memberID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var serials []string
+	for reg := range r.registrations {
+		if reg.PassTypeID != passTypeID || !strings.HasPrefix(reg.SerialNumber, memberID+"-") || seen[reg.SerialNumber] {
+			continue
+		}
+		seen[reg.SerialNumber] = true
+		serials = append(serials, reg.SerialNumber)
+	}
+	sort.Strings(serials)
+	return serials, nil
+}
+
+func (r *MemoryRegistry) MarkUpdated(ctx context.Context, serialNumber, fingerprint string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state, ok := r.passes[serialNumber]; ok && state.fingerprint == fingerprint {
+		return false, nil
+	}
+	r.passes[serialNumber] = passState{fingerprint: fingerprint, updatedAt: at}
+	return true, nil
+}
+
+func (r *MemoryRegistry) LastUpdated(ctx context.Context, serialNumber string) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.passes[serialNumber].updatedAt, nil
+}
+
+func sameDevicePass(a, b Registration) bool {
+	return a.DeviceLibraryID == b.DeviceLibraryID && a.PassTypeID == b.PassTypeID && a.SerialNumber == b.SerialNumber
+}
@@ -0,0 +1,265 @@
+package wallet
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/services/member/internal/card"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+var (
+	ErrAppleDisabled   = errors.New("apple wallet passes are not configured")
+	ErrGoogleDisabled  = errors.New("google wallet passes are not configured")
+	ErrUnauthenticated = errors.New("invalid pass authentication token")
+	ErrInvalidSerial   = errors.New("invalid pass serial number")
+)
+
+// Service issues wallet passes for member ID cards and backs the PassKit
+// web service that keeps installed passes current.
+type Service struct {
+	cfg       Config
+	signer    *signer
+	googleKey *rsa.PrivateKey
+	logos     card.LogoSource
+	registry  Registry
+	pusher    Pusher
+}
+
+// NewService loads the configured signing material. Wallets whose
+// credentials are not configured are disabled rather than failing startup.
+func NewService(cfg Config, logos card.LogoSource, registry Registry) (*Service, error) {
+	if logos == nil {
+		logos = card.NoLogos{}
+	}
+	if registry == nil {
+		registry = NewMemoryRegistry()
+	}
+
+	s := &Service{cfg: cfg, logos: logos, registry: registry}
+
+	var err error
+	if s.signer, err = loadSigner(cfg); err != nil {
+		return nil, err
+	}
+	if s.signer != nil {
+		if cfg.PassTypeIdentifier == "" || cfg.TeamIdentifier == "" {
+			return nil, fmt.Errorf("pass type and team identifiers are required for Apple Wallet")
+		}
+		if len(cfg.AuthSecret) < 16 {
+			return nil, fmt.Errorf("wallet auth secret must be at least 16 characters")
+		}
+		s.pusher = newAPNsPusher(s.signer, cfg.PassTypeIdentifier)
+	}
+
+	if s.googleKey, err = loadGoogleKey(cfg); err != nil {
+		return nil, err
+	}
+	if s.googleKey != nil && (cfg.GoogleIssuerID == "" || cfg.GoogleServiceAccountEmail == "") {
+		return nil, fmt.Errorf("issuer ID and service account email are required for Google Wallet")
+	}
+
+	return s, nil
+}
+
+func (s *Service) AppleEnabled() bool {
+	return s.signer != nil
+}
+
+func (s *Service) GoogleEnabled() bool {
+	return s.googleKey != nil
+}
+
+// PassTypeIdentifier returns the Apple pass type the service issues
+func (s *Service) PassTypeIdentifier() string {
+	return s.cfg.PassTypeIdentifier
+}
+
+// SerialNumber identifies the pass for one member and coverage, e.g. M123456-MEDICAL
+func SerialNumber(memberID string, coverageType pb.CoverageType) string {
+	_, title := card.Theme(coverageType)
+	return memberID + "-" + title
+}
+
+// ParseSerialNumber reverses SerialNumber
+func ParseSerialNumber(serial string) (string, pb.CoverageType, error) {
+	i := strings.LastIndex(serial, "-")
+	if i <= 0 {
+		return "", pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED, ErrInvalidSerial
+	}
+
+	value, ok := pb.CoverageType_value["COVERAGE_TYPE_"+serial[i+1:]]
+	if !ok || value == int32(pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED) {
+		return "", pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED, ErrInvalidSerial
+	}
+	return serial[:i], pb.CoverageType(value), nil
+}
+
+// AuthenticationToken derives the pass's web service token from its serial,
+// so tokens need not be stored.
+func (s *Service) AuthenticationToken(serial string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.AuthSecret))
+	mac.Write([]byte(serial))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Authenticate checks a token presented by a device for the given pass
+func (s *Service) Authenticate(passTypeID, serial, token string) error {
+	if !s.AppleEnabled() || passTypeID != s.cfg.PassTypeIdentifier {
+		return ErrUnauthenticated
+	}
+	if !hmac.Equal([]byte(token), []byte(s.AuthenticationToken(serial))) {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+// ApplePass builds the signed .pkpass bundle and returns it with the time
+// its content last changed. A voided pass is one for coverage that has
+// ended; Wallet greys it out.
+func (s *Service) ApplePass(ctx context.Context, mc *pb.MemberCard, coverageType pb.CoverageType, voided bool) ([]byte, time.Time, error) {
+	if !s.AppleEnabled() {
+		return nil, time.Time{}, ErrAppleDisabled
+	}
+
+	passJSON, err := s.passJSONFor(mc, coverageType, voided)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to build pass.json: %w", err)
+	}
+	// Only Refresh records new content, so that it still sees the change and
+	// notifies the other devices holding the pass. A pass Refresh has never
+	// recorded is current as of now.
+	updatedAt, err := s.registry.LastUpdated(ctx, SerialNumber(mc.MemberId, coverageType))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load pass update time: %w", err)
+	}
+	if updatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+
+	data, err := s.buildPKPass(mc, coverageType, passJSON)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data, updatedAt, nil
+}
+
+// GoogleSaveURL returns the "Save to Google Wallet" link for a card
+func (s *Service) GoogleSaveURL(mc *pb.MemberCard, coverageType pb.CoverageType) (string, error) {
+	if !s.GoogleEnabled() {
+		return "", ErrGoogleDisabled
+	}
+	return s.googleSaveURL(mc, coverageType)
+}
+
+// Refresh regenerates the pass content for a card and, if it changed,
+// notifies every device that has the pass installed.
+func (s *Service) Refresh(ctx context.Context, mc *pb.MemberCard, coverageType pb.CoverageType, voided bool) error {
+	if !s.AppleEnabled() {
+		return nil
+	}
+
+	passJSON, err := s.passJSONFor(mc, coverageType, voided)
+	if err != nil {
+		return fmt.Errorf("failed to build pass.json: %w", err)
+	}
+	serial := SerialNumber(mc.MemberId, coverageType)
+	changed, err := s.markUpdated(ctx, serial, passJSON)
+	if err != nil || !changed {
+		return err
+	}
+	return s.push(ctx, serial)
+}
+
+// RegisteredCoverages lists the coverage types of the member's passes that
+// are installed on at least one device
+func (s *Service) RegisteredCoverages(ctx context.Context, memberID string) ([]pb.CoverageType, error) {
+	if !s.AppleEnabled() {
+		return nil, nil
+	}
+
+	serials, err := s.registry.MemberSerials(ctx, s.cfg.PassTypeIdentifier, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registered passes: %w", err)
+	}
+
+	var coverages []pb.CoverageType
+	for _, serial := range serials {
+		// A member ID ending in a dash and more could share the prefix
+		if id, coverageType, err := ParseSerialNumber(serial); err == nil && id == memberID {
+			coverages = append(coverages, coverageType)
+		}
+	}
+	return coverages, nil
+}
+
+// markUpdated records the pass content and reports whether it changed
+func (s *Service) markUpdated(ctx context.Context, serial string, passJSON []byte) (bool, error) {
+	sum := sha256.Sum256(passJSON)
+	changed, err := s.registry.MarkUpdated(ctx, serial, hex.EncodeToString(sum[:]), time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to record pass update: %w", err)
+	}
+	return changed, nil
+}
+
+func (s *Service) push(ctx context.Context, serial string) error {
+	tokens, err := s.registry.PushTokens(ctx, s.cfg.PassTypeIdentifier, serial)
+	if err != nil {
+		return fmt.Errorf("failed to load push tokens: %w", err)
+	}
+
+	var errs []error
+	for _, token := range tokens {
+		if err := s.pusher.Push(ctx, token); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Register records a device registration after checking the pass token
+func (s *Service) Register(ctx context.Context, reg Registration, token string) (bool, error) {
+	if err := s.Authenticate(reg.PassTypeID, reg.SerialNumber, token); err != nil {
+		return false, err
+	}
+	return s.registry.Register(ctx, reg)
+}
+
+// Unregister removes a device registration after checking the pass token
+func (s *Service) Unregister(ctx context.Context, deviceLibraryID, passTypeID, serial, token string) error {
+	if err := s.Authenticate(passTypeID, serial, token); err != nil {
+		return err
+	}
+	return s.registry.Unregister(ctx, deviceLibraryID, passTypeID, serial)
+}
+
+// UpdatedSince lists the device's passes changed after the tag from a
+// previous call, and the tag to send next time.
+func (s *Service) UpdatedSince(ctx context.Context, deviceLibraryID, passTypeID, tag string) ([]string, string, error) {
+	var since time.Time
+	if tag != "" {
+		nanos, err := strconv.ParseInt(tag, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid update tag: %s", tag)
+		}
+		since = time.Unix(0, nanos).UTC()
+	}
+
+	serials, latest, err := s.registry.SerialsUpdatedSince(ctx, deviceLibraryID, passTypeID, since)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(serials) == 0 {
+		return nil, tag, nil
+	}
+	return serials, strconv.FormatInt(latest.UnixNano(), 10), nil
+}
@@ -7,7 +7,10 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
@@ -16,8 +19,12 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/sydney-health/backend/internal/database"
+	"github.com/sydney-health/backend/services/member/internal/card"
+	"github.com/sydney-health/backend/services/member/internal/contact"
+	"github.com/sydney-health/backend/services/member/internal/wallet"
 	"github.com/sydney-health/backend/services/member/repository"
 	"github.com/sydney-health/backend/services/member/service"
+	"github.com/sydney-health/backend/shared/kafka"
 	pb "github.com/sydney-health/backend/shared/pb"
 )
 
@@ -37,8 +44,82 @@ func main() {
 	// Initialize repository
 	memberRepo := repository.NewMemberRepository(db)
 
+	// Initialize card renderer
+	logoDir := os.Getenv("MEMBER_CARD_LOGO_DIR")
+	if logoDir == "" {
+		logoDir = "assets/logos"
+	}
+	logos := card.DirLogos{Dir: logoDir}
+	cardRenderer, err := card.NewRenderer(card.Options{
+		Logos: logos,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create card renderer: %v", err)
+	}
+
+	// Initialize wallet passes; each wallet is disabled until its credentials are set
+	var origins []string
+	if v := os.Getenv("WALLET_GOOGLE_ORIGINS"); v != "" {
+		origins = strings.Split(v, ",")
+	}
+	walletPasses, err := wallet.NewService(wallet.Config{
+		PassTypeIdentifier:        os.Getenv("WALLET_PASS_TYPE_ID"),
+		TeamIdentifier:            os.Getenv("WALLET_TEAM_ID"),
+		OrganizationName:          os.Getenv("WALLET_ORGANIZATION_NAME"),
+		WebServiceURL:             os.Getenv("WALLET_WEB_SERVICE_URL"),
+		CertFile:                  os.Getenv("WALLET_CERT_FILE"),
+		KeyFile:                   os.Getenv("WALLET_KEY_FILE"),
+		WWDRFile:                  os.Getenv("WALLET_WWDR_FILE"),
+		AuthSecret:                os.Getenv("WALLET_AUTH_SECRET"),
+		GoogleIssuerID:            os.Getenv("WALLET_GOOGLE_ISSUER_ID"),
+		GoogleClassSuffix:         os.Getenv("WALLET_GOOGLE_CLASS_SUFFIX"),
+		GoogleServiceAccountEmail: os.Getenv("WALLET_GOOGLE_SERVICE_ACCOUNT"),
+		GoogleKeyFile:             os.Getenv("WALLET_GOOGLE_KEY_FILE"),
+		Origins:                   origins,
+	}, logos, repository.NewWalletRegistry(db))
+	if err != nil {
+		log.Fatalf("Failed to create wallet pass service: %v", err)
+	}
+
+	// Contact changes are verified through the development notifiers until an
+	// email and SMS provider is configured
+	var notifier contact.Notifier = contact.LogNotifier{Logf: log.Printf}
+	if dir := os.Getenv("CONTACT_OUTBOX_DIR"); dir != "" {
+		notifier = &contact.FileNotifier{Dir: dir}
+	}
+	var contactConfig contact.Config
+	if v := os.Getenv("CONTACT_CODE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid CONTACT_CODE_TTL: %v", err)
+		}
+		contactConfig.CodeTTL = ttl
+	}
+	if v := os.Getenv("CONTACT_MAX_ATTEMPTS"); v != "" {
+		attempts, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("Invalid CONTACT_MAX_ATTEMPTS: %v", err)
+		}
+		contactConfig.MaxAttempts = attempts
+	}
+	contacts := contact.NewVerifier(repository.NewContactVerificationStore(db), notifier, contactConfig)
+
 	// Initialize service
-	memberService := service.NewMemberService(memberRepo)
+	memberService := service.NewMemberService(memberRepo, cardRenderer, walletPasses, contacts)
+
+	// Refresh wallet passes when coverage changes elsewhere
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("MEMBER_UPDATES_TOPIC"); brokers != "" && topic != "" {
+		consumer := kafka.NewConsumer(strings.Split(brokers, ","), topic, os.Getenv("KAFKA_CONSUMER_GROUP"), memberService.HandleMemberUpdate)
+		defer consumer.Close()
+
+		go func() {
+			if err := consumer.Start(ctx); err != nil {
+				log.Printf("Member update consumer stopped: %v", err)
+			}
+		}()
+	}
 
 	// Get port from environment
 	port := os.Getenv("MEMBER_SERVICE_PORT")
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/sydney-health/backend/pkg/database"
+	"github.com/sydney-health/backend/services/member/internal/contact"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// ContactVerificationStore stores email and phone changes awaiting verification
+type ContactVerificationStore struct {
+	db *database.DB
+}
+
+// NewContactVerificationStore creates a new contact verification store
+func NewContactVerificationStore(db *database.DB) *ContactVerificationStore {
+	return &ContactVerificationStore{db: db}
+}
+
+var _ contact.Store = (*ContactVerificationStore)(nil)
+
+// Channels as stored
+var channels = map[pb.ContactChannel]string{
+	pb.ContactChannel_CONTACT_CHANNEL_EMAIL: "EMAIL",
+	pb.ContactChannel_CONTACT_CHANNEL_PHONE: "PHONE",
+}
+
+// Save stores a pending change, replacing the member's pending change to the same channel
+func (s *ContactVerificationStore) Save(ctx context.Context, p *contact.Pending) error {
+	query := `
+		INSERT INTO contact_verifications (verification_id, member_id, channel, value, code_hash, attempts, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (member_id, channel) DO UPDATE SET
+			verification_id = EXCLUDED.verification_id,
+			value = EXCLUDED.value,
+			code_hash = EXCLUDED.code_hash,
+			attempts = EXCLUDED.attempts,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		p.ID,
+		p.MemberID,
+		channels[p.Channel],
+		p.Value,
+		p.CodeHash,
+		p.Attempts,
+		p.ExpiresAt,
+		p.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save contact verification: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a member's pending change, or nil if there is none
+func (s *ContactVerificationStore) Get(ctx context.Context, memberID, id string) (*contact.Pending, error) {
+	query := `
+		SELECT verification_id, member_id, channel, value, code_hash, attempts, expires_at, created_at
+		FROM contact_verifications
+		WHERE verification_id = $1 AND member_id = $2
+	`
+
+	var p contact.Pending
+	var channel string
+	err := s.db.QueryRowContext(ctx, query, id, memberID).Scan(
+		&p.ID,
+		&p.MemberID,
+		&channel,
+		&p.Value,
+		&p.CodeHash,
+		&p.Attempts,
+		&p.ExpiresAt,
+		&p.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact verification: %w", err)
+	}
+
+	for c, name := range channels {
+		if name == channel {
+			p.Channel = c
+		}
+	}
+	return &p, nil
+}
+
+// RecordAttempt counts an incorrect code and returns the attempts so far
+func (s *ContactVerificationStore) RecordAttempt(ctx context.Context, id string) (int, error) {
+	query := `
+		UPDATE contact_verifications
+		SET attempts = attempts + 1
+		WHERE verification_id = $1
+		RETURNING attempts
+	`
+
+	var attempts int
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, contact.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record verification attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Delete removes a pending change
+func (s *ContactVerificationStore) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM contact_verifications WHERE verification_id = $1`
+
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete contact verification: %w", err)
+	}
+
+	return nil
+}
+
+// EmailInUse reports whether a member other than memberID has the email
+func (r *MemberRepository) EmailInUse(ctx context.Context, memberID, email string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM members WHERE LOWER(email) = LOWER($1) AND member_id <> $2)`
+
+	var inUse bool
+	if err := r.db.QueryRowContext(ctx, query, email, memberID).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return inUse, nil
+}
+
+// UpdateContact sets a member's verified email or phone number
+func (r *MemberRepository) UpdateContact(ctx context.Context, memberID string, channel pb.ContactChannel, value string) error {
+	var query string
+	switch channel {
+	case pb.ContactChannel_CONTACT_CHANNEL_EMAIL:
+		query = `UPDATE members SET email = $2, updated_at = CURRENT_TIMESTAMP WHERE member_id = $1`
+	case pb.ContactChannel_CONTACT_CHANNEL_PHONE:
+		query = `UPDATE members SET phone = $2, updated_at = CURRENT_TIMESTAMP WHERE member_id = $1`
+	default:
+		return fmt.Errorf("unknown contact channel: %v", channel)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, memberID, value)
+	if err != nil {
+		return fmt.Errorf("failed to update contact: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health/backend/shared/coverage"
+	pb "github.com/sydney-health/backend/shared/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrCoverageSpanNotFound is returned when a span does not belong to the member
+var ErrCoverageSpanNotFound = errors.New("coverage span not found")
+
+const coverageSpanColumns = `
+	span_id, member_id, coverage_type, plan_id, plan_name, group_number, tier,
+	effective_date, termination_date, termination_reason, reinstated_at, updated_at
+`
+
+// ListCoverageSpans retrieves a member's coverage history, optionally for one coverage type
+func (r *MemberRepository) ListCoverageSpans(ctx context.Context, memberID string, coverageType pb.CoverageType) ([]*pb.CoverageSpan, error) {
+	query := `SELECT ` + coverageSpanColumns + `
+		FROM member_coverage_spans
+		WHERE member_id = $1 AND ($2 = '' OR coverage_type = $2)
+		ORDER BY coverage_type, effective_date
+	`
+
+	var typeFilter string
+	if coverageType != pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		typeFilter = coverage.TypeName(coverageType)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, memberID, typeFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coverage spans: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCoverageSpans(rows)
+}
+
+// ListActiveCoverageTypes returns the coverage types the member holds today
+func (r *MemberRepository) ListActiveCoverageTypes(ctx context.Context, memberID string) ([]pb.CoverageType, error) {
+	spans, err := r.ListCoverageSpans(ctx, memberID, pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED)
+	if err != nil {
+		return nil, err
+	}
+
+	return coverage.ActiveTypes(spans, time.Now()), nil
+}
+
+// UpdateCoverageSpan applies change to one of the member's spans inside a
+// transaction that locks all of the member's spans, so checks against the
+// rest of the history (such as reinstatement overlaps) cannot race.
+func (r *MemberRepository) UpdateCoverageSpan(ctx context.Context, memberID, spanID string, change func(spans []*pb.CoverageSpan, span *pb.CoverageSpan) error) (*pb.CoverageSpan, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + coverageSpanColumns + `
+		FROM member_coverage_spans
+		WHERE member_id = $1
+		ORDER BY coverage_type, effective_date
+		FOR UPDATE
+	`
+
+	rows, err := tx.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock coverage spans: %w", err)
+	}
+	spans, err := scanCoverageSpans(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var span *pb.CoverageSpan
+	for _, candidate := range spans {
+		if candidate.SpanId == spanID {
+			span = candidate
+		}
+	}
+	if span == nil {
+		return nil, ErrCoverageSpanNotFound
+	}
+
+	if err := change(spans, span); err != nil {
+		return nil, err
+	}
+
+	update := `
+		UPDATE member_coverage_spans
+		SET termination_date = $1, termination_reason = $2, reinstated_at = $3, updated_at = $4
+		WHERE span_id = $5
+	`
+
+	var terminationDate, reinstatedAt sql.NullTime
+	var terminationReason sql.NullString
+	if span.TerminationDate != nil {
+		terminationDate = sql.NullTime{Time: span.TerminationDate.AsTime(), Valid: true}
+		terminationReason = sql.NullString{String: coverage.ReasonName(span.TerminationReason), Valid: true}
+	}
+	if span.ReinstatedAt != nil {
+		reinstatedAt = sql.NullTime{Time: span.ReinstatedAt.AsTime(), Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, update,
+		terminationDate,
+		terminationReason,
+		reinstatedAt,
+		span.UpdatedAt.AsTime(),
+		span.SpanId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update coverage span: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit coverage span: %w", err)
+	}
+
+	return span, nil
+}
+
+func scanCoverageSpans(rows *sql.Rows) ([]*pb.CoverageSpan, error) {
+	spans := []*pb.CoverageSpan{}
+	for rows.Next() {
+		var span pb.CoverageSpan
+		var coverageType, tier string
+		var effectiveDate, updatedAt time.Time
+		var terminationDate, reinstatedAt sql.NullTime
+		var terminationReason sql.NullString
+
+		err := rows.Scan(
+			&span.SpanId,
+			&span.MemberId,
+			&coverageType,
+			&span.PlanId,
+			&span.PlanName,
+			&span.GroupNumber,
+			&tier,
+			&effectiveDate,
+			&terminationDate,
+			&terminationReason,
+			&reinstatedAt,
+			&updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan coverage span: %w", err)
+		}
+
+		span.CoverageType, _ = coverage.ParseType(coverageType)
+		span.Tier = coverage.ParseTier(tier)
+		span.EffectiveDate = timestamppb.New(effectiveDate)
+		span.UpdatedAt = timestamppb.New(updatedAt)
+		if terminationDate.Valid {
+			span.TerminationDate = timestamppb.New(terminationDate.Time)
+		}
+		if terminationReason.Valid {
+			span.TerminationReason = coverage.ParseReason(terminationReason.String)
+		}
+		if reinstatedAt.Valid {
+			span.ReinstatedAt = timestamppb.New(reinstatedAt.Time)
+		}
+
+		spans = append(spans, &span)
+	}
+
+	return spans, rows.Err()
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sydney-health/backend/pkg/database"
+	"github.com/sydney-health/backend/shared/coverage"
+	pb "github.com/sydney-health/backend/shared/pb"
+	"github.com/sydney-health/backend/shared/x12/enrollment"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EnrollmentStore reads and writes the member records 834 enrollments are applied to
+type EnrollmentStore struct {
+	db *database.DB
+}
+
+// NewEnrollmentStore creates a new enrollment store
+func NewEnrollmentStore(db *database.DB) *EnrollmentStore {
+	return &EnrollmentStore{db: db}
+}
+
+var _ enrollment.Store = (*EnrollmentStore)(nil)
+
+const enrolleeColumns = `
+	m.member_id, m.first_name, m.last_name, COALESCE(m.middle_name, ''), m.date_of_birth,
+	COALESCE(m.email, ''), COALESCE(m.phone, ''), COALESCE(m.gender, ''),
+	COALESCE(m.street1, ''), COALESCE(m.street2, ''), COALESCE(m.city, ''),
+	COALESCE(m.state, ''), COALESCE(m.zip_code, ''),
+	m.group_number, m.subscriber_id, m.enrollment_date
+`
+
+// Genders as stored, keyed by DMG-03
+var genders = map[string]string{
+	"M": "MALE",
+	"F": "FEMALE",
+	"U": "UNKNOWN",
+}
+
+// FindMember retrieves the member whose card carries the subscriber ID, or nil if none does
+func (s *EnrollmentStore) FindMember(ctx context.Context, subscriberID string) (*enrollment.Enrollee, error) {
+	query := `SELECT ` + enrolleeColumns + `, COALESCE(md.primary_member_id, '')
+		FROM members m
+		LEFT JOIN member_dependents md ON md.dependent_id = m.member_id
+		WHERE UPPER(m.subscriber_id) = UPPER($1)
+		LIMIT 1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, subscriberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find member: %w", err)
+	}
+	defer rows.Close()
+
+	enrollees, err := scanEnrollees(rows)
+	if err != nil || len(enrollees) == 0 {
+		return nil, err
+	}
+	return enrollees[0], nil
+}
+
+// ListDependents retrieves the members covered under a subscriber
+func (s *EnrollmentStore) ListDependents(ctx context.Context, memberID string) ([]*enrollment.Enrollee, error) {
+	query := `SELECT ` + enrolleeColumns + `, md.primary_member_id
+		FROM members m
+		JOIN member_dependents md ON md.dependent_id = m.member_id
+		WHERE md.primary_member_id = $1
+		ORDER BY m.subscriber_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependents: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEnrollees(rows)
+}
+
+// ListCoverageSpans retrieves all of a member's coverage spans
+func (s *EnrollmentStore) ListCoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error) {
+	members := &MemberRepository{db: s.db}
+	return members.ListCoverageSpans(ctx, memberID, pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED)
+}
+
+// Apply writes one member loop's change in a single transaction
+func (s *EnrollmentStore) Apply(ctx context.Context, change *enrollment.Change) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	member := change.Enrollee.Member
+	address := member.Address
+	if address == nil {
+		address = &pb.Address{}
+	}
+	values := []interface{}{
+		member.MemberId,
+		member.FirstName,
+		member.LastName,
+		nullString(member.MiddleName),
+		member.DateOfBirth.AsTime(),
+		nullString(member.Email),
+		nullString(member.Phone),
+		nullString(genders[strings.ToUpper(change.Enrollee.Gender)]),
+		nullString(address.Street1),
+		nullString(address.Street2),
+		nullString(address.City),
+		nullString(address.State),
+		nullString(address.ZipCode),
+		member.GroupNumber,
+		member.SubscriberId,
+		member.EnrollmentDate.AsTime(),
+	}
+
+	switch {
+	case change.Created:
+		query := `
+			INSERT INTO members (
+				member_id, first_name, last_name, middle_name, date_of_birth,
+				email, phone, gender, street1, street2, city, state, zip_code,
+				group_number, subscriber_id, enrollment_date
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		`
+		if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+			return fmt.Errorf("failed to create member: %w", err)
+		}
+
+		if member.SubscriberMemberId != "" {
+			link := `
+				INSERT INTO member_dependents (primary_member_id, dependent_id, relationship)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (primary_member_id, dependent_id) DO NOTHING
+			`
+			if _, err := tx.ExecContext(ctx, link, member.SubscriberMemberId, member.MemberId, change.Relationship); err != nil {
+				return fmt.Errorf("failed to link dependent: %w", err)
+			}
+		}
+
+	case len(change.Fields) > 0:
+		query := `
+			UPDATE members
+			SET first_name = $2, last_name = $3, middle_name = $4, date_of_birth = $5,
+				email = $6, phone = $7, gender = $8, street1 = $9, street2 = $10,
+				city = $11, state = $12, zip_code = $13, group_number = $14,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE member_id = $1
+		`
+		// Subscriber ID and enrollment date stay as first enrolled
+		if _, err := tx.ExecContext(ctx, query, values[:14]...); err != nil {
+			return fmt.Errorf("failed to update member: %w", err)
+		}
+	}
+
+	upsert := `
+		INSERT INTO member_coverage_spans (
+			span_id, member_id, coverage_type, plan_id, plan_name, group_number, tier,
+			effective_date, termination_date, termination_reason, reinstated_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (span_id) DO UPDATE SET
+			plan_id = EXCLUDED.plan_id,
+			plan_name = EXCLUDED.plan_name,
+			group_number = EXCLUDED.group_number,
+			tier = EXCLUDED.tier,
+			termination_date = EXCLUDED.termination_date,
+			termination_reason = EXCLUDED.termination_reason,
+			reinstated_at = EXCLUDED.reinstated_at,
+			updated_at = EXCLUDED.updated_at
+	`
+	for _, span := range change.Spans {
+		var terminationDate, reinstatedAt sql.NullTime
+		var terminationReason sql.NullString
+		if span.TerminationDate != nil {
+			terminationDate = sql.NullTime{Time: span.TerminationDate.AsTime(), Valid: true}
+			terminationReason = sql.NullString{String: coverage.ReasonName(span.TerminationReason), Valid: true}
+		}
+		if span.ReinstatedAt != nil {
+			reinstatedAt = sql.NullTime{Time: span.ReinstatedAt.AsTime(), Valid: true}
+		}
+
+		_, err := tx.ExecContext(ctx, upsert,
+			span.SpanId,
+			span.MemberId,
+			coverage.TypeName(span.CoverageType),
+			span.PlanId,
+			span.PlanName,
+			span.GroupNumber,
+			coverage.TierName(span.Tier),
+			span.EffectiveDate.AsTime(),
+			terminationDate,
+			terminationReason,
+			reinstatedAt,
+			span.UpdatedAt.AsTime(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save coverage span: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit enrollment change: %w", err)
+	}
+
+	return nil
+}
+
+func scanEnrollees(rows *sql.Rows) ([]*enrollment.Enrollee, error) {
+	var enrollees []*enrollment.Enrollee
+	for rows.Next() {
+		var member pb.Member
+		var address pb.Address
+		var gender string
+		var dob, enrollmentDate time.Time
+
+		err := rows.Scan(
+			&member.MemberId,
+			&member.FirstName,
+			&member.LastName,
+			&member.MiddleName,
+			&dob,
+			&member.Email,
+			&member.Phone,
+			&gender,
+			&address.Street1,
+			&address.Street2,
+			&address.City,
+			&address.State,
+			&address.ZipCode,
+			&member.GroupNumber,
+			&member.SubscriberId,
+			&enrollmentDate,
+			&member.SubscriberMemberId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+
+		member.DateOfBirth = timestamppb.New(dob)
+		member.EnrollmentDate = timestamppb.New(enrollmentDate)
+		if address.Street1 != "" {
+			member.Address = &address
+		}
+
+		enrollee := &enrollment.Enrollee{Member: &member}
+		for code, name := range genders {
+			if strings.EqualFold(gender, name) {
+				enrollee.Gender = code
+			}
+		}
+		enrollees = append(enrollees, enrollee)
+	}
+
+	return enrollees, rows.Err()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sydney-health/backend/pkg/database"
+	"github.com/sydney-health/backend/shared/coverage"
 	pb "github.com/sydney-health/backend/shared/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // MemberRepository handles database operations for members
@@ -51,45 +54,12 @@ func (r *MemberRepository) GetMember(ctx context.Context, memberID string) (*pb.
 		return nil, fmt.Errorf("failed to get member: %w", err)
 	}
 
-	member.DateOfBirth = dob.Format("2006-01-02")
+	member.DateOfBirth = timestamppb.New(dob)
 
-	// Get member's coverage
-	coverageQuery := `
-		SELECT coverage_type, plan_name, group_number, effective_date, termination_date
-		FROM member_coverages
-		WHERE member_id = $1 AND status = 'active'
-	`
-
-	rows, err := r.db.QueryContext(ctx, coverageQuery, memberID)
+	// Coverage in effect today comes from the member's span history
+	member.ActiveCoverages, err = r.ListActiveCoverageTypes(ctx, memberID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get coverage: %w", err)
-	}
-	defer rows.Close()
-
-	member.Coverages = make([]*pb.MemberCoverage, 0)
-	for rows.Next() {
-		var coverage pb.MemberCoverage
-		var effectiveDate, terminationDate sql.NullTime
-
-		err := rows.Scan(
-			&coverage.CoverageType,
-			&coverage.PlanName,
-			&coverage.GroupNumber,
-			&effectiveDate,
-			&terminationDate,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan coverage: %w", err)
-		}
-
-		if effectiveDate.Valid {
-			coverage.EffectiveDate = effectiveDate.Time.Format("2006-01-02")
-		}
-		if terminationDate.Valid {
-			coverage.TerminationDate = terminationDate.Time.Format("2006-01-02")
-		}
-
-		member.Coverages = append(member.Coverages, &coverage)
+		return nil, err
 	}
 
 	// Get address
@@ -118,6 +88,12 @@ func (r *MemberRepository) GetMember(ctx context.Context, memberID string) (*pb.
 		member.Address = &address
 	}
 
+	// Dependents carry their subscriber so access policy can be evaluated
+	member.SubscriberMemberId, err = r.GetSubscriberID(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &member, nil
 }
 
@@ -167,8 +143,20 @@ func (r *MemberRepository) UpdateMember(ctx context.Context, member *pb.Member)
 	return nil
 }
 
-// GetMemberCard retrieves member card information
-func (r *MemberRepository) GetMemberCard(ctx context.Context, memberID string, coverageType pb.CoverageType) (*pb.MemberCard, error) {
+// GetMemberCard retrieves member card information for coverage the member
+// holds on day. The plan and group printed come from the span in effect; card
+// details such as BIN and copays come from the member's coverage record, and
+// only when that record is for the same plan.
+func (r *MemberRepository) GetMemberCard(ctx context.Context, memberID string, coverageType pb.CoverageType, day time.Time) (*pb.MemberCard, error) {
+	spans, err := r.ListCoverageSpans(ctx, memberID, coverageType)
+	if err != nil {
+		return nil, err
+	}
+	span := coverage.Find(spans, coverageType, day)
+	if span == nil {
+		return nil, fmt.Errorf("member card not found")
+	}
+
 	query := `
 		SELECT 
 			m.member_id, m.first_name, m.last_name,
@@ -176,13 +164,13 @@ func (r *MemberRepository) GetMemberCard(ctx context.Context, memberID string, c
 			mc.pcn_number, mc.copay_primary, mc.copay_specialist, mc.copay_er
 		FROM members m
 		JOIN member_coverages mc ON m.member_id = mc.member_id
-		WHERE m.member_id = $1 AND mc.coverage_type = $2 AND mc.status = 'active'
+		WHERE m.member_id = $1 AND mc.coverage_type = $2
 	`
 
 	var card pb.MemberCard
 	var copayPrimary, copaySpecialist, copayER sql.NullFloat64
 
-	err := r.db.QueryRowContext(ctx, query, memberID, coverageType.String()).Scan(
+	err = r.db.QueryRowContext(ctx, query, memberID, coverage.TypeName(coverageType)).Scan(
 		&card.MemberId,
 		&card.MemberName,
 		&card.MemberName, // Will concatenate first and last
@@ -202,9 +190,20 @@ func (r *MemberRepository) GetMemberCard(ctx context.Context, memberID string, c
 	if err != nil {
 		return nil, fmt.Errorf("failed to get member card: %w", err)
 	}
+	// The coverage record holds the current plan's details; a card for an
+	// earlier plan must not print them
+	card.CopayInfo = make(map[string]string)
+	if !strings.EqualFold(card.PlanName, span.PlanName) {
+		card.PlanName = span.PlanName
+		card.GroupNumber = span.GroupNumber
+		card.BinNumber = ""
+		card.PcnNumber = ""
+		return &card, nil
+	}
+	card.PlanName = span.PlanName
+	card.GroupNumber = span.GroupNumber
 
 	// Build copay information
-	card.CopayInfo = make(map[string]string)
 	if copayPrimary.Valid {
 		card.CopayInfo["primary"] = fmt.Sprintf("$%.0f", copayPrimary.Float64)
 	}
@@ -255,9 +254,31 @@ func (r *MemberRepository) ListDependents(ctx context.Context, memberID string)
 			return nil, fmt.Errorf("failed to scan dependent: %w", err)
 		}
 
-		member.DateOfBirth = dob.Format("2006-01-02")
+		member.DateOfBirth = timestamppb.New(dob)
+		member.SubscriberMemberId = memberID
 		dependents = append(dependents, &member)
 	}
 
 	return dependents, nil
+}
+
+// FindMemberIDBySubscriberID resolves the member ID printed on a card, or "" if none matches
+func (r *MemberRepository) FindMemberIDBySubscriberID(ctx context.Context, subscriberID string) (string, error) {
+	query := `
+		SELECT member_id
+		FROM members
+		WHERE UPPER(subscriber_id) = UPPER($1)
+		LIMIT 1
+	`
+
+	var memberID string
+	err := r.db.QueryRowContext(ctx, query, subscriberID).Scan(&memberID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find member: %w", err)
+	}
+
+	return memberID, nil
 }
\ No newline at end of file
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/sydney-health/backend/shared/pb"
+	"github.com/sydney-health/backend/shared/preferences"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetPreferences retrieves a member's communication preferences, or nil if they have not set any
+func (r *MemberRepository) GetPreferences(ctx context.Context, memberID string) (*pb.MemberPreferences, error) {
+	query := `
+		SELECT language, quiet_hours_enabled, COALESCE(quiet_hours_start, ''), COALESCE(quiet_hours_end, ''),
+			COALESCE(quiet_hours_time_zone, ''), paperless, paperless_consented_at, paperless_revoked_at, updated_at
+		FROM member_preferences
+		WHERE member_id = $1
+	`
+
+	prefs := &pb.MemberPreferences{
+		MemberId:   memberID,
+		QuietHours: &pb.QuietHours{},
+		Paperless:  &pb.PaperlessConsent{},
+	}
+	var consentedAt, revokedAt sql.NullTime
+	var updatedAt time.Time
+
+	err := r.db.QueryRowContext(ctx, query, memberID).Scan(
+		&prefs.Language,
+		&prefs.QuietHours.Enabled,
+		&prefs.QuietHours.Start,
+		&prefs.QuietHours.End,
+		&prefs.QuietHours.TimeZone,
+		&prefs.Paperless.Consented,
+		&consentedAt,
+		&revokedAt,
+		&updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	if consentedAt.Valid {
+		prefs.Paperless.ConsentedAt = timestamppb.New(consentedAt.Time)
+	}
+	if revokedAt.Valid {
+		prefs.Paperless.RevokedAt = timestamppb.New(revokedAt.Time)
+	}
+	prefs.UpdatedAt = timestamppb.New(updatedAt)
+
+	rows, err := r.db.QueryContext(ctx, `SELECT category, channel FROM member_notification_channels WHERE member_id = $1`, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	chosen := make(map[pb.NotificationCategory][]pb.NotificationChannel)
+	for rows.Next() {
+		var categoryName, channelName string
+		if err := rows.Scan(&categoryName, &channelName); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		category, ok := preferences.ParseCategory(categoryName)
+		if !ok {
+			continue
+		}
+		if channel, ok := preferences.ParseChannel(channelName); ok {
+			chosen[category] = append(chosen[category], channel)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notification channels: %w", err)
+	}
+
+	for _, category := range preferences.Categories {
+		prefs.Channels = append(prefs.Channels, &pb.ChannelPreference{
+			Category: category,
+			Channels: chosen[category],
+		})
+	}
+
+	return prefs, nil
+}
+
+// SavePreferences replaces a member's communication preferences
+func (r *MemberRepository) SavePreferences(ctx context.Context, prefs *pb.MemberPreferences) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var consentedAt, revokedAt sql.NullTime
+	if ts := prefs.Paperless.GetConsentedAt(); ts != nil {
+		consentedAt = sql.NullTime{Time: ts.AsTime(), Valid: true}
+	}
+	if ts := prefs.Paperless.GetRevokedAt(); ts != nil {
+		revokedAt = sql.NullTime{Time: ts.AsTime(), Valid: true}
+	}
+
+	upsert := `
+		INSERT INTO member_preferences (
+			member_id, language, quiet_hours_enabled, quiet_hours_start, quiet_hours_end,
+			quiet_hours_time_zone, paperless, paperless_consented_at, paperless_revoked_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (member_id) DO UPDATE SET
+			language = EXCLUDED.language,
+			quiet_hours_enabled = EXCLUDED.quiet_hours_enabled,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			quiet_hours_time_zone = EXCLUDED.quiet_hours_time_zone,
+			paperless = EXCLUDED.paperless,
+			paperless_consented_at = EXCLUDED.paperless_consented_at,
+			paperless_revoked_at = EXCLUDED.paperless_revoked_at,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = tx.ExecContext(ctx, upsert,
+		prefs.MemberId,
+		prefs.Language,
+		prefs.QuietHours.GetEnabled(),
+		nullString(prefs.QuietHours.GetStart()),
+		nullString(prefs.QuietHours.GetEnd()),
+		nullString(prefs.QuietHours.GetTimeZone()),
+		prefs.Paperless.GetConsented(),
+		consentedAt,
+		revokedAt,
+		prefs.UpdatedAt.AsTime(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM member_notification_channels WHERE member_id = $1`, prefs.MemberId); err != nil {
+		return fmt.Errorf("failed to clear notification channels: %w", err)
+	}
+
+	insert := `INSERT INTO member_notification_channels (member_id, category, channel) VALUES ($1, $2, $3)`
+	for _, pref := range prefs.Channels {
+		for _, channel := range pref.Channels {
+			_, err := tx.ExecContext(ctx, insert,
+				prefs.MemberId,
+				preferences.CategoryName(pref.Category),
+				preferences.ChannelName(channel),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save notification channel: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit preferences: %w", err)
+	}
+
+	return nil
+}
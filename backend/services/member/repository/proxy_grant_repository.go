@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	pb "github.com/sydney-health/backend/shared/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetSubscriberID returns the subscriber whose policy covers a dependent, or "" for subscribers
+func (r *MemberRepository) GetSubscriberID(ctx context.Context, memberID string) (string, error) {
+	query := `
+		SELECT primary_member_id
+		FROM member_dependents
+		WHERE dependent_id = $1
+		LIMIT 1
+	`
+
+	var subscriberID string
+	err := r.db.QueryRowContext(ctx, query, memberID).Scan(&subscriberID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get subscriber: %w", err)
+	}
+
+	return subscriberID, nil
+}
+
+// CreateProxyGrant stores a new proxy grant
+func (r *MemberRepository) CreateProxyGrant(ctx context.Context, grant *pb.ProxyGrant) error {
+	query := `
+		INSERT INTO member_proxy_grants (grant_id, member_id, proxy_member_id, granted_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	var expiresAt sql.NullTime
+	if grant.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: grant.ExpiresAt.AsTime(), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		grant.GrantId,
+		grant.MemberId,
+		grant.ProxyMemberId,
+		grant.GrantedAt.AsTime(),
+		expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create proxy grant: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeProxyGrant marks a grant revoked and returns it. Revoking twice keeps the first timestamp.
+func (r *MemberRepository) RevokeProxyGrant(ctx context.Context, memberID, grantID string, revokedAt time.Time) (*pb.ProxyGrant, error) {
+	query := `
+		UPDATE member_proxy_grants
+		SET revoked_at = COALESCE(revoked_at, $3)
+		WHERE member_id = $1 AND grant_id = $2
+		RETURNING grant_id, member_id, proxy_member_id, granted_at, expires_at, revoked_at
+	`
+
+	grant, err := scanProxyGrant(r.db.QueryRowContext(ctx, query, memberID, grantID, revokedAt))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("proxy grant not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke proxy grant: %w", err)
+	}
+
+	return grant, nil
+}
+
+// ListProxyGrants retrieves all grants stored against a member, including revoked ones
+func (r *MemberRepository) ListProxyGrants(ctx context.Context, memberID string) ([]*pb.ProxyGrant, error) {
+	query := `
+		SELECT grant_id, member_id, proxy_member_id, granted_at, expires_at, revoked_at
+		FROM member_proxy_grants
+		WHERE member_id = $1
+		ORDER BY granted_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy grants: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []*pb.ProxyGrant
+	for rows.Next() {
+		grant, err := scanProxyGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy grant: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProxyGrant(row rowScanner) (*pb.ProxyGrant, error) {
+	var grant pb.ProxyGrant
+	var grantedAt time.Time
+	var expiresAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&grant.GrantId,
+		&grant.MemberId,
+		&grant.ProxyMemberId,
+		&grantedAt,
+		&expiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	grant.GrantedAt = timestamppb.New(grantedAt)
+	if expiresAt.Valid {
+		grant.ExpiresAt = timestamppb.New(expiresAt.Time)
+	}
+	if revokedAt.Valid {
+		grant.RevokedAt = timestamppb.New(revokedAt.Time)
+	}
+
+	return &grant, nil
+}
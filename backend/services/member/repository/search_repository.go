@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sydney-health/backend/shared/kafka"
+	"github.com/sydney-health/backend/shared/membersearch"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// maxSearchCandidates caps the rows a search reads for scoring. Searches that
+// go past it are too broad to be useful and fail with ErrTooBroad rather than
+// return a page of an arbitrary subset.
+const maxSearchCandidates = 500
+
+// SearchMembers retrieves the members that may match the criteria. The query
+// is deliberately looser than membersearch.Match, which makes the final call
+// and scores the results. It returns membersearch.ErrTooBroad when more than
+// maxSearchCandidates members may match.
+func (r *MemberRepository) SearchMembers(ctx context.Context, c membersearch.Criteria) ([]*pb.Member, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	name := func(column, want string) string {
+		if c.Fuzzy {
+			return fmt.Sprintf("LEFT(LOWER(%s), 1) = %s", column, arg(membersearch.FirstLetter(want)))
+		}
+		return fmt.Sprintf("LOWER(%s) = %s", column, arg(want))
+	}
+
+	if c.FirstName != "" {
+		conditions = append(conditions, name("m.first_name", c.FirstName))
+	}
+	if c.LastName != "" {
+		conditions = append(conditions, name("m.last_name", c.LastName))
+	}
+	for _, token := range c.Names {
+		conditions = append(conditions, "("+strings.Join([]string{
+			name("m.first_name", token),
+			name("COALESCE(m.middle_name, '')", token),
+			name("m.last_name", token),
+		}, " OR ")+")")
+	}
+	if !c.DateOfBirth.IsZero() {
+		dates := []time.Time{c.DateOfBirth}
+		if swapped := c.SwappedDateOfBirth(); c.Fuzzy && !swapped.IsZero() {
+			dates = append(dates, swapped)
+		}
+		var placeholders []string
+		for _, date := range dates {
+			placeholders = append(placeholders, arg(date))
+		}
+		conditions = append(conditions, "m.date_of_birth IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if c.SubscriberID != "" {
+		if c.Fuzzy {
+			conditions = append(conditions, "UPPER(m.subscriber_id) LIKE "+arg("%"+escapeLike(c.SubscriberID)+"%"))
+		} else {
+			conditions = append(conditions, "UPPER(m.subscriber_id) = "+arg(c.SubscriberID))
+		}
+	}
+	if c.GroupNumber != "" {
+		if c.Fuzzy {
+			conditions = append(conditions, "UPPER(m.group_number) LIKE "+arg(escapeLike(c.GroupNumber)+"%"))
+		} else {
+			conditions = append(conditions, "UPPER(m.group_number) = "+arg(c.GroupNumber))
+		}
+	}
+	if c.Email != "" {
+		if c.Fuzzy {
+			conditions = append(conditions, "LOWER(m.email) LIKE "+arg(escapeLike(membersearch.Mailbox(c.Email))+"%"))
+		} else {
+			conditions = append(conditions, "LOWER(m.email) = "+arg(c.Email))
+		}
+	}
+	if c.Phone != "" {
+		// Phone numbers are stored as entered; Match compares their digits
+		conditions = append(conditions, "REGEXP_REPLACE(m.phone, '[^0-9]', '', 'g') LIKE "+arg("%"+c.Phone))
+	}
+	if len(conditions) == 0 {
+		return nil, membersearch.ErrNoCriteria
+	}
+
+	query := `SELECT ` + enrolleeColumns + `, COALESCE(md.primary_member_id, '')
+		FROM members m
+		LEFT JOIN member_dependents md ON md.dependent_id = m.member_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY m.last_name, m.first_name, m.member_id
+		LIMIT ` + fmt.Sprint(maxSearchCandidates+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search members: %w", err)
+	}
+	defer rows.Close()
+
+	enrollees, err := scanEnrollees(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(enrollees) > maxSearchCandidates {
+		return nil, membersearch.ErrTooBroad
+	}
+
+	members := make([]*pb.Member, 0, len(enrollees))
+	for _, enrollee := range enrollees {
+		members = append(members, enrollee.Member)
+	}
+	return members, nil
+}
+
+// CreateAuditLog records an audit event
+func (r *MemberRepository) CreateAuditLog(ctx context.Context, event *kafka.AuditEvent) error {
+	data, err := json.Marshal(event.EventData)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (event_type, entity_type, entity_id, user_id, ip_address, user_agent, event_data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		event.EventType,
+		event.EntityType,
+		event.EntityID,
+		event.UserID,
+		nullString(event.IPAddress),
+		nullString(event.UserAgent),
+		data,
+		time.Unix(event.Timestamp, 0),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
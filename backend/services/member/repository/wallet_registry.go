@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health/backend/pkg/database"
+	"github.com/sydney-health/backend/services/member/internal/wallet"
+)
+
+// WalletRegistry stores wallet device registrations and pass update times
+type WalletRegistry struct {
+	db *database.DB
+}
+
+// NewWalletRegistry creates a new wallet registry
+func NewWalletRegistry(db *database.DB) *WalletRegistry {
+	return &WalletRegistry{db: db}
+}
+
+var _ wallet.Registry = (*WalletRegistry)(nil)
+
+// Register records a device registration, updating the push token if it already exists
+func (r *WalletRegistry) Register(ctx context.Context, reg wallet.Registration) (bool, error) {
+	query := `
+		INSERT INTO wallet_pass_registrations (device_library_id, pass_type_id, serial_number, push_token)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (device_library_id, pass_type_id, serial_number)
+		DO UPDATE SET push_token = EXCLUDED.push_token
+		RETURNING (xmax = 0)
+	`
+
+	var created bool
+	err := r.db.QueryRowContext(ctx, query,
+		reg.DeviceLibraryID,
+		reg.PassTypeID,
+		reg.SerialNumber,
+		reg.PushToken,
+	).Scan(&created)
+	if err != nil {
+		return false, fmt.Errorf("failed to register wallet device: %w", err)
+	}
+
+	return created, nil
+}
+
+// Unregister removes a device registration
+func (r *WalletRegistry) Unregister(ctx context.Context, deviceLibraryID, passTypeID, serialNumber string) error {
+	query := `
+		DELETE FROM wallet_pass_registrations
+		WHERE device_library_id = $1 AND pass_type_id = $2 AND serial_number = $3
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, deviceLibraryID, passTypeID, serialNumber); err != nil {
+		return fmt.Errorf("failed to unregister wallet device: %w", err)
+	}
+
+	return nil
+}
+
+// SerialsUpdatedSince lists the device's passes updated after since
+func (r *WalletRegistry) SerialsUpdatedSince(ctx context.Context, deviceLibraryID, passTypeID string, since time.Time) ([]string, time.Time, error) {
+	query := `
+		SELECT reg.serial_number, COALESCE(p.updated_at, 'epoch')
+		FROM wallet_pass_registrations reg
+		LEFT JOIN wallet_passes p ON p.serial_number = reg.serial_number
+		WHERE reg.device_library_id = $1 AND reg.pass_type_id = $2
+			AND ($3::timestamp IS NULL OR p.updated_at > $3)
+		ORDER BY reg.serial_number
+	`
+
+	var sinceParam sql.NullTime
+	if !since.IsZero() {
+		sinceParam = sql.NullTime{Time: since, Valid: true}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, deviceLibraryID, passTypeID, sinceParam)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to list updated wallet passes: %w", err)
+	}
+	defer rows.Close()
+
+	var serials []string
+	var latest time.Time
+	for rows.Next() {
+		var serial string
+		var updatedAt time.Time
+		if err := rows.Scan(&serial, &updatedAt); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to scan wallet pass: %w", err)
+		}
+		serials = append(serials, serial)
+		if updatedAt.After(latest) {
+			latest = updatedAt
+		}
+	}
+
+	return serials, latest, rows.Err()
+}
+
+// PushTokens returns the push tokens of every device holding the pass
+func (r *WalletRegistry) PushTokens(ctx context.Context, passTypeID, serialNumber string) ([]string, error) {
+	query := `
+		SELECT push_token
+		FROM wallet_pass_registrations
+		WHERE pass_type_id = $1 AND serial_number = $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, passTypeID, serialNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, fmt.Errorf("failed to scan push token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// MemberSerials lists the serials of the member's passes installed on any device
+func (r *WalletRegistry) MemberSerials(ctx context.Context, passTypeID, memberID string) ([]string, error) {
+	query := `
+		SELECT DISTINCT serial_number
+		FROM wallet_pass_registrations
+		WHERE pass_type_id = $1 AND left(serial_number, length($2)) = $2
+		ORDER BY serial_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, passTypeID, memberID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list member wallet passes: %w", err)
+	}
+	defer rows.Close()
+
+	var serials []string
+	for rows.Next() {
+		var serial string
+		if err := rows.Scan(&serial); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet pass: %w", err)
+		}
+		serials = append(serials, serial)
+	}
+
+	return serials, rows.Err()
+}
+
+// MarkUpdated stores the pass fingerprint, moving updated_at only when it changed
+func (r *WalletRegistry) MarkUpdated(ctx context.Context, serialNumber, fingerprint string, at time.Time) (bool, error) {
+	query := `
+		INSERT INTO wallet_passes (serial_number, fingerprint, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (serial_number)
+		DO UPDATE SET fingerprint = EXCLUDED.fingerprint, updated_at = EXCLUDED.updated_at
+		WHERE wallet_passes.fingerprint <> EXCLUDED.fingerprint
+	`
+
+	result, err := r.db.ExecContext(ctx, query, serialNumber, fingerprint, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to record wallet pass update: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record wallet pass update: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// LastUpdated returns when the pass content last changed, or zero if never issued
+func (r *WalletRegistry) LastUpdated(ctx context.Context, serialNumber string) (time.Time, error) {
+	query := `SELECT updated_at FROM wallet_passes WHERE serial_number = $1`
+
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, serialNumber).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get wallet pass: %w", err)
+	}
+
+	return updatedAt, nil
+}
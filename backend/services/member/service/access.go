@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health/backend/shared/access"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// authorize evaluates the calling member's access to member. Internal
+// callers are trusted; callers that are neither are denied.
+func (s *MemberService) authorize(ctx context.Context, member *pb.Member) (access.Decision, error) {
+	if requesterID, ok := access.RequesterFromContext(ctx); ok {
+		return s.evaluate(ctx, requesterID, member)
+	}
+	if caller, ok := access.InternalFromContext(ctx); ok {
+		return access.Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "internal caller " + caller}, nil
+	}
+	return access.Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "caller not identified"}, nil
+}
+
+func (s *MemberService) evaluate(ctx context.Context, requesterID string, member *pb.Member) (access.Decision, error) {
+	if requesterID == member.MemberId {
+		return access.Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "self"}, nil
+	}
+
+	grants, err := s.repo.ListProxyGrants(ctx, member.MemberId)
+	if err != nil {
+		return access.Decision{}, err
+	}
+
+	return access.Evaluate(requesterID, member, grants, time.Now()), nil
+}
+
+// requireSelf rejects calls that act on someone else's grants
+func requireSelf(ctx context.Context, memberID string) error {
+	if requesterID, ok := access.RequesterFromContext(ctx); ok {
+		if requesterID != memberID {
+			return status.Error(codes.PermissionDenied, "only the member can manage their proxy grants")
+		}
+		return nil
+	}
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "caller not identified")
+	}
+	return nil
+}
+
+// requireRequester rejects calls asking about a requester other than the
+// caller, so members can't probe other members' relationships, grants or age
+// bands. Internal callers may ask about anyone.
+func requireRequester(ctx context.Context, requesterID string) error {
+	if callerID, ok := access.RequesterFromContext(ctx); ok {
+		if callerID != requesterID {
+			return status.Error(codes.PermissionDenied, "members can only check their own access")
+		}
+		return nil
+	}
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "caller not identified")
+	}
+	return nil
+}
+
+// requireFullAccess rejects callers who may not act on the member's behalf
+func (s *MemberService) requireFullAccess(ctx context.Context, member *pb.Member) error {
+	decision, err := s.authorize(ctx, member)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return status.Error(codes.Internal, "failed to evaluate access")
+	}
+	if !decision.Full() {
+		return status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+	return nil
+}
+
+// CheckMemberAccess reports what one member may see of another
+func (s *MemberService) CheckMemberAccess(ctx context.Context, req *pb.CheckMemberAccessRequest) (*pb.CheckMemberAccessResponse, error) {
+	log.Printf("CheckMemberAccess called for requester %s, member %s", req.RequesterMemberId, req.MemberId)
+
+	if req.RequesterMemberId == "" || req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "requester_member_id and member_id are required")
+	}
+	if err := requireRequester(ctx, req.RequesterMemberId); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.GetMember(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	decision, err := s.evaluate(ctx, req.RequesterMemberId, member)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return nil, status.Error(codes.Internal, "failed to evaluate access")
+	}
+
+	return &pb.CheckMemberAccessResponse{
+		AccessLevel: decision.Level,
+		Reason:      decision.Reason,
+	}, nil
+}
+
+// GrantProxyAccess lets another member act as personal representative for the caller
+func (s *MemberService) GrantProxyAccess(ctx context.Context, req *pb.GrantProxyAccessRequest) (*pb.GrantProxyAccessResponse, error) {
+	log.Printf("GrantProxyAccess called for member %s, proxy %s", req.MemberId, req.ProxyMemberId)
+
+	if req.MemberId == "" || req.ProxyMemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and proxy_member_id are required")
+	}
+	if req.MemberId == req.ProxyMemberId {
+		return nil, status.Error(codes.InvalidArgument, "a member cannot be their own proxy")
+	}
+	if err := requireSelf(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.AsTime().After(now) {
+		return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	}
+
+	if _, err := s.repo.GetMember(ctx, req.ProxyMemberId); err != nil {
+		log.Printf("Error retrieving proxy member: %v", err)
+		return nil, status.Error(codes.NotFound, "proxy member not found")
+	}
+
+	existing, err := s.repo.ListProxyGrants(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error listing proxy grants: %v", err)
+		return nil, status.Error(codes.Internal, "failed to list proxy grants")
+	}
+	for _, grant := range existing {
+		if grant.ProxyMemberId == req.ProxyMemberId && access.GrantActive(grant, now) {
+			return nil, status.Errorf(codes.AlreadyExists, "active grant already exists: %s", grant.GrantId)
+		}
+	}
+
+	grant := &pb.ProxyGrant{
+		GrantId:       uuid.New().String(),
+		MemberId:      req.MemberId,
+		ProxyMemberId: req.ProxyMemberId,
+		GrantedAt:     timestamppb.New(now),
+		ExpiresAt:     req.ExpiresAt,
+	}
+
+	if err := s.repo.CreateProxyGrant(ctx, grant); err != nil {
+		log.Printf("Error creating proxy grant: %v", err)
+		return nil, status.Error(codes.Internal, "failed to create proxy grant")
+	}
+
+	return &pb.GrantProxyAccessResponse{
+		Grant: grant,
+	}, nil
+}
+
+// RevokeProxyAccess ends a proxy grant immediately
+func (s *MemberService) RevokeProxyAccess(ctx context.Context, req *pb.RevokeProxyAccessRequest) (*pb.RevokeProxyAccessResponse, error) {
+	log.Printf("RevokeProxyAccess called for member %s, grant %s", req.MemberId, req.GrantId)
+
+	if req.MemberId == "" || req.GrantId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and grant_id are required")
+	}
+	if err := requireSelf(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	grant, err := s.repo.RevokeProxyGrant(ctx, req.MemberId, req.GrantId, time.Now())
+	if err != nil {
+		log.Printf("Error revoking proxy grant: %v", err)
+		return nil, status.Error(codes.NotFound, "proxy grant not found")
+	}
+
+	return &pb.RevokeProxyAccessResponse{
+		Grant: grant,
+	}, nil
+}
+
+// ListProxyGrants lists the proxy grants a member has issued
+func (s *MemberService) ListProxyGrants(ctx context.Context, req *pb.ListProxyGrantsRequest) (*pb.ListProxyGrantsResponse, error) {
+	log.Printf("ListProxyGrants called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if err := requireSelf(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	all, err := s.repo.ListProxyGrants(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error listing proxy grants: %v", err)
+		return nil, status.Error(codes.Internal, "failed to list proxy grants")
+	}
+
+	now := time.Now()
+	grants := []*pb.ProxyGrant{}
+	for _, grant := range all {
+		if req.IncludeRevoked || access.GrantActive(grant, now) {
+			grants = append(grants, grant)
+		}
+	}
+
+	return &pb.ListProxyGrantsResponse{
+		Grants: grants,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health/backend/services/member/internal/contact"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// contactError maps verification failures to gRPC errors
+func contactError(err error) error {
+	var attempt *contact.AttemptError
+	switch {
+	case errors.As(err, &attempt):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, contact.ErrInvalidEmail), errors.Is(err, contact.ErrInvalidPhone):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, contact.ErrEmailInUse):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, contact.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, contact.ErrExpired), errors.Is(err, contact.ErrTooManyTries):
+		return status.Errorf(codes.FailedPrecondition, "%v; update the member again for a new code", err)
+	default:
+		log.Printf("Error verifying contact: %v", err)
+		return status.Error(codes.Internal, "failed to verify contact")
+	}
+}
+
+// checkContactChanges rejects an email another member already uses, before
+// a code is sent to it
+func (s *MemberService) checkContactChanges(ctx context.Context, memberID string, changes []contact.Change) error {
+	for _, change := range changes {
+		if change.Channel != pb.ContactChannel_CONTACT_CHANNEL_EMAIL {
+			continue
+		}
+		inUse, err := s.repo.EmailInUse(ctx, memberID, change.Value)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return contact.ErrEmailInUse
+		}
+	}
+	return nil
+}
+
+// VerifyContact commits a pending email or phone change once the member
+// enters the code sent to the new address, and tells the old address
+func (s *MemberService) VerifyContact(ctx context.Context, req *pb.VerifyContactRequest) (*pb.VerifyContactResponse, error) {
+	log.Printf("VerifyContact called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" || req.VerificationId == "" || req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id, verification_id and code are required")
+	}
+
+	existing, err := s.repo.GetMember(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	decision, err := s.authorize(ctx, existing)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return nil, status.Error(codes.Internal, "failed to evaluate access")
+	}
+	if !decision.Full() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	pending, err := s.contacts.Verify(ctx, req.MemberId, req.VerificationId, req.Code)
+	if err != nil {
+		return nil, contactError(err)
+	}
+
+	// Another member may have taken the email since the code was sent
+	if err := s.checkContactChanges(ctx, req.MemberId, []contact.Change{{Channel: pending.Channel, Value: pending.Value}}); err != nil {
+		return nil, contactError(err)
+	}
+
+	if err := s.repo.UpdateContact(ctx, req.MemberId, pending.Channel, pending.Value); err != nil {
+		log.Printf("Error updating contact: %v", err)
+		return nil, status.Error(codes.Internal, "failed to update contact")
+	}
+	old := contact.Apply(existing, pending)
+
+	// The change stands even if the notice can't be sent
+	if err := s.contacts.NotifyChanged(ctx, pending.Channel, old, pending.Value); err != nil {
+		log.Printf("Error notifying previous contact address: %v", err)
+	}
+
+	return &pb.VerifyContactResponse{
+		Member: existing,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health/backend/services/member/repository"
+	"github.com/sydney-health/backend/shared/access"
+	"github.com/sydney-health/backend/shared/coverage"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// requireInternal rejects calls to administrative RPCs from anyone but
+// internal callers
+func requireInternal(ctx context.Context) error {
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "coverage changes are restricted to internal callers")
+	}
+	return nil
+}
+
+// authorizeMember loads the member and checks the caller may read their record
+func (s *MemberService) authorizeMember(ctx context.Context, memberID string) error {
+	member, err := s.repo.GetMember(ctx, memberID)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return status.Error(codes.NotFound, "member not found")
+	}
+
+	decision, err := s.authorize(ctx, member)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return status.Error(codes.Internal, "failed to evaluate access")
+	}
+	if !decision.Allowed() {
+		return status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+	return nil
+}
+
+// GetCoverageHistory lists a member's effective-dated coverage spans
+func (s *MemberService) GetCoverageHistory(ctx context.Context, req *pb.GetCoverageHistoryRequest) (*pb.GetCoverageHistoryResponse, error) {
+	log.Printf("GetCoverageHistory called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if err := s.authorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	spans, err := s.repo.ListCoverageSpans(ctx, req.MemberId, req.CoverageType)
+	if err != nil {
+		log.Printf("Error listing coverage spans: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve coverage history")
+	}
+
+	return &pb.GetCoverageHistoryResponse{
+		Spans: spans,
+	}, nil
+}
+
+// CheckEligibility reports whether the member held a coverage type on the date of service
+func (s *MemberService) CheckEligibility(ctx context.Context, req *pb.CheckEligibilityRequest) (*pb.CheckEligibilityResponse, error) {
+	log.Printf("CheckEligibility called for member ID: %s, coverage type: %s", req.MemberId, req.CoverageType)
+
+	if req.MemberId == "" || req.CoverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "member_id and coverage_type are required")
+	}
+	if err := s.authorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	dateOfService := time.Now()
+	if req.DateOfService != nil {
+		dateOfService = req.DateOfService.AsTime()
+	}
+
+	spans, err := s.repo.ListCoverageSpans(ctx, req.MemberId, req.CoverageType)
+	if err != nil {
+		log.Printf("Error listing coverage spans: %v", err)
+		return nil, status.Error(codes.Internal, "failed to check eligibility")
+	}
+
+	span, eligible, reason := coverage.Check(spans, req.CoverageType, dateOfService)
+
+	return &pb.CheckEligibilityResponse{
+		Eligible: eligible,
+		Span:     span,
+		Reason:   reason,
+	}, nil
+}
+
+// TerminateCoverage ends a coverage span, retroactively if the date is in the past
+func (s *MemberService) TerminateCoverage(ctx context.Context, req *pb.TerminateCoverageRequest) (*pb.TerminateCoverageResponse, error) {
+	log.Printf("TerminateCoverage called for member ID: %s, span ID: %s", req.MemberId, req.SpanId)
+
+	if req.MemberId == "" || req.SpanId == "" || req.TerminationDate == nil {
+		return nil, status.Error(codes.InvalidArgument, "member_id, span_id and termination_date are required")
+	}
+	if err := requireInternal(ctx); err != nil {
+		return nil, err
+	}
+
+	span, err := s.repo.UpdateCoverageSpan(ctx, req.MemberId, req.SpanId, func(spans []*pb.CoverageSpan, span *pb.CoverageSpan) error {
+		if err := coverage.ValidateTermination(span, req.TerminationDate.AsTime()); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		coverage.Terminate(span, req.TerminationDate.AsTime(), req.Reason, time.Now())
+		return nil
+	})
+	if err != nil {
+		return nil, coverageUpdateError(err)
+	}
+
+	s.refreshWalletPassesAsync(req.MemberId)
+
+	return &pb.TerminateCoverageResponse{
+		Span: span,
+	}, nil
+}
+
+// ReinstateCoverage reopens a terminated span so coverage continues without a gap
+func (s *MemberService) ReinstateCoverage(ctx context.Context, req *pb.ReinstateCoverageRequest) (*pb.ReinstateCoverageResponse, error) {
+	log.Printf("ReinstateCoverage called for member ID: %s, span ID: %s", req.MemberId, req.SpanId)
+
+	if req.MemberId == "" || req.SpanId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and span_id are required")
+	}
+	if err := requireInternal(ctx); err != nil {
+		return nil, err
+	}
+
+	span, err := s.repo.UpdateCoverageSpan(ctx, req.MemberId, req.SpanId, func(spans []*pb.CoverageSpan, span *pb.CoverageSpan) error {
+		if err := coverage.ValidateReinstatement(spans, span); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		coverage.Reinstate(span, time.Now())
+		return nil
+	})
+	if err != nil {
+		return nil, coverageUpdateError(err)
+	}
+
+	s.refreshWalletPassesAsync(req.MemberId)
+
+	return &pb.ReinstateCoverageResponse{
+		Span: span,
+	}, nil
+}
+
+func coverageUpdateError(err error) error {
+	if errors.Is(err, repository.ErrCoverageSpanNotFound) {
+		return status.Error(codes.NotFound, "coverage span not found")
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	log.Printf("Error updating coverage span: %v", err)
+	return status.Error(codes.Internal, "failed to update coverage")
+}
@@ -3,24 +3,35 @@ package service
 import (
 	"context"
 	"log"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/sydney-health/backend/services/member/internal/card"
+	"github.com/sydney-health/backend/services/member/internal/contact"
+	"github.com/sydney-health/backend/services/member/internal/wallet"
 	"github.com/sydney-health/backend/services/member/repository"
+	"github.com/sydney-health/backend/shared/access"
 	pb "github.com/sydney-health/backend/shared/pb"
 )
 
 // MemberService implements the gRPC MemberService
 type MemberService struct {
 	pb.UnimplementedMemberServiceServer
-	repo *repository.MemberRepository
+	repo     *repository.MemberRepository
+	cards    *card.Renderer
+	passes   *wallet.Service
+	contacts *contact.Verifier
 }
 
 // NewMemberService creates a new member service
-func NewMemberService(repo *repository.MemberRepository) *MemberService {
+func NewMemberService(repo *repository.MemberRepository, cards *card.Renderer, passes *wallet.Service, contacts *contact.Verifier) *MemberService {
 	return &MemberService{
-		repo: repo,
+		repo:     repo,
+		cards:    cards,
+		passes:   passes,
+		contacts: contacts,
 	}
 }
 
@@ -38,6 +49,15 @@ func (s *MemberService) GetMember(ctx context.Context, req *pb.GetMemberRequest)
 		return nil, status.Error(codes.NotFound, "member not found")
 	}
 
+	decision, err := s.authorize(ctx, member)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return nil, status.Error(codes.Internal, "failed to evaluate access")
+	}
+	if !decision.Allowed() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
 	return &pb.GetMemberResponse{
 		Member: member,
 	}, nil
@@ -56,7 +76,42 @@ func (s *MemberService) UpdateMember(ctx context.Context, req *pb.UpdateMemberRe
 		return nil, status.Error(codes.InvalidArgument, "update_mask cannot be empty")
 	}
 
-	err := s.repo.UpdateMember(ctx, req.Member)
+	existing, err := s.repo.GetMember(ctx, req.Member.MemberId)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	decision, err := s.authorize(ctx, existing)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return nil, status.Error(codes.Internal, "failed to evaluate access")
+	}
+	if !decision.Full() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
+	// New contact details wait for verification; everything else is saved now
+	changes, err := contact.Changes(existing, req.Member)
+	if err == nil {
+		err = s.checkContactChanges(ctx, req.Member.MemberId, changes)
+	}
+	if err != nil {
+		return nil, contactError(err)
+	}
+	contact.Hold(req.Member, existing)
+
+	var pending []*pb.PendingContactChange
+	for _, change := range changes {
+		p, err := s.contacts.Start(ctx, req.Member.MemberId, change.Channel, change.Value)
+		if err != nil {
+			log.Printf("Error starting contact verification: %v", err)
+			return nil, status.Error(codes.Unavailable, "failed to send verification code")
+		}
+		pending = append(pending, p)
+	}
+
+	err = s.repo.UpdateMember(ctx, req.Member)
 	if err != nil {
 		log.Printf("Error updating member: %v", err)
 		return nil, status.Error(codes.Internal, "failed to update member")
@@ -69,8 +124,12 @@ func (s *MemberService) UpdateMember(ctx context.Context, req *pb.UpdateMemberRe
 		return nil, status.Error(codes.Internal, "failed to retrieve updated member")
 	}
 
+	// Installed wallet passes are only pushed when their content changed
+	s.refreshWalletPassesAsync(req.Member.MemberId)
+
 	return &pb.UpdateMemberResponse{
-		Member: updatedMember,
+		Member:                updatedMember,
+		PendingContactChanges: pending,
 	}, nil
 }
 
@@ -82,13 +141,33 @@ func (s *MemberService) GetMemberCard(ctx context.Context, req *pb.GetMemberCard
 		return nil, status.Error(codes.InvalidArgument, "member_id is required")
 	}
 
+	member, err := s.repo.GetMember(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	decision, err := s.authorize(ctx, member)
+	if err != nil {
+		log.Printf("Error evaluating access: %v", err)
+		return nil, status.Error(codes.Internal, "failed to evaluate access")
+	}
+	if !decision.Allowed() {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+
 	// Default to medical if not specified
 	coverageType := req.CoverageType
 	if coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
 		coverageType = pb.CoverageType_MEDICAL
 	}
 
-	card, err := s.repo.GetMemberCard(ctx, req.MemberId, coverageType)
+	day := time.Now()
+	if req.DateOfService != nil {
+		day = req.DateOfService.AsTime()
+	}
+
+	card, err := s.repo.GetMemberCard(ctx, req.MemberId, coverageType, day)
 	if err != nil {
 		log.Printf("Error retrieving member card: %v", err)
 		return nil, status.Error(codes.NotFound, "member card not found")
@@ -99,6 +178,30 @@ func (s *MemberService) GetMemberCard(ctx context.Context, req *pb.GetMemberCard
 	}, nil
 }
 
+// RenderMemberCard renders the member insurance card as a PNG or printable PDF
+func (s *MemberService) RenderMemberCard(ctx context.Context, req *pb.RenderMemberCardRequest) (*pb.RenderMemberCardResponse, error) {
+	log.Printf("RenderMemberCard called for member ID: %s, format: %s", req.MemberId, req.Format)
+
+	resp, err := s.GetMemberCard(ctx, &pb.GetMemberCardRequest{
+		MemberId:     req.MemberId,
+		CoverageType: req.CoverageType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, contentType, err := s.cards.Render(resp.Card, req.CoverageType, req.Format, req.Side)
+	if err != nil {
+		log.Printf("Error rendering member card: %v", err)
+		return nil, status.Error(codes.Internal, "failed to render member card")
+	}
+
+	return &pb.RenderMemberCardResponse{
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
 // ListDependents lists all dependents for a member
 func (s *MemberService) ListDependents(ctx context.Context, req *pb.ListDependentsRequest) (*pb.ListDependentsResponse, error) {
 	log.Printf("ListDependents called for member ID: %s", req.MemberId)
@@ -113,7 +216,50 @@ func (s *MemberService) ListDependents(ctx context.Context, req *pb.ListDependen
 		return nil, status.Error(codes.Internal, "failed to list dependents")
 	}
 
+	// Dependents the requester may not see are listed by name only
+	for i, dependent := range dependents {
+		decision, err := s.authorize(ctx, dependent)
+		if err != nil {
+			log.Printf("Error evaluating access: %v", err)
+			return nil, status.Error(codes.Internal, "failed to evaluate access")
+		}
+		if !decision.Allowed() {
+			dependents[i] = access.Redact(dependent)
+		}
+	}
+
 	return &pb.ListDependentsResponse{
 		Dependents: dependents,
 	}, nil
+}
+
+// LookupMember resolves the member ID printed on a card, as trading partners quote it in EDI
+func (s *MemberService) LookupMember(ctx context.Context, req *pb.LookupMemberRequest) (*pb.LookupMemberResponse, error) {
+	log.Printf("LookupMember called for subscriber ID: %s", req.SubscriberId)
+
+	if req.SubscriberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "subscriber_id is required")
+	}
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return nil, status.Error(codes.PermissionDenied, "member lookup is restricted to internal callers")
+	}
+
+	memberID, err := s.repo.FindMemberIDBySubscriberID(ctx, req.SubscriberId)
+	if err != nil {
+		log.Printf("Error looking up member: %v", err)
+		return nil, status.Error(codes.Internal, "failed to look up member")
+	}
+	if memberID == "" {
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	member, err := s.repo.GetMember(ctx, memberID)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	return &pb.LookupMemberResponse{
+		Member: member,
+	}, nil
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/sydney-health/backend/shared/pb"
+	"github.com/sydney-health/backend/shared/preferences"
+)
+
+// GetMemberPreferences retrieves the member's communication preferences, or
+// the defaults if they have not set any
+func (s *MemberService) GetMemberPreferences(ctx context.Context, req *pb.GetMemberPreferencesRequest) (*pb.GetMemberPreferencesResponse, error) {
+	log.Printf("GetMemberPreferences called for member ID: %s", req.MemberId)
+
+	member, err := s.repo.GetMember(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	if err := s.requireFullAccess(ctx, member); err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.repo.GetPreferences(ctx, req.MemberId)
+	if err != nil {
+		log.Printf("Error retrieving preferences: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve preferences")
+	}
+	if prefs == nil {
+		prefs = preferences.Defaults(req.MemberId)
+	}
+
+	return &pb.GetMemberPreferencesResponse{
+		Preferences: prefs,
+	}, nil
+}
+
+// UpdateMemberPreferences replaces the member's communication preferences
+func (s *MemberService) UpdateMemberPreferences(ctx context.Context, req *pb.UpdateMemberPreferencesRequest) (*pb.UpdateMemberPreferencesResponse, error) {
+	if req.Preferences == nil || req.Preferences.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "preferences and member_id are required")
+	}
+	memberID := req.Preferences.MemberId
+
+	log.Printf("UpdateMemberPreferences called for member ID: %s", memberID)
+
+	member, err := s.repo.GetMember(ctx, memberID)
+	if err != nil {
+		log.Printf("Error retrieving member: %v", err)
+		return nil, status.Error(codes.NotFound, "member not found")
+	}
+
+	if err := s.requireFullAccess(ctx, member); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetPreferences(ctx, memberID)
+	if err != nil {
+		log.Printf("Error retrieving preferences: %v", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve preferences")
+	}
+
+	prefs, err := preferences.Update(existing, req.Preferences, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := preferences.CheckContact(prefs, member); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		log.Printf("Error saving preferences: %v", err)
+		return nil, status.Error(codes.Internal, "failed to save preferences")
+	}
+
+	return &pb.UpdateMemberPreferencesResponse{
+		Preferences: prefs,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health/backend/shared/access"
+	"github.com/sydney-health/backend/shared/membersearch"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// SearchMembers finds members for support agents whose ID the gateway signed;
+// an unsigned agent header is refused. Every search is written to the audit
+// log before its results are returned.
+func (s *MemberService) SearchMembers(ctx context.Context, req *pb.SearchMembersRequest) (*pb.SearchMembersResponse, error) {
+	agentID, ok := access.AgentFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "member search is restricted to support agents")
+	}
+
+	criteria, err := membersearch.FromRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.Printf("SearchMembers called by agent %s for %v", agentID, criteria.Fields())
+
+	candidates, err := s.repo.SearchMembers(ctx, criteria)
+	if errors.Is(err, membersearch.ErrTooBroad) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		log.Printf("Error searching members: %v", err)
+		return nil, status.Error(codes.Internal, "failed to search members")
+	}
+
+	var results []*pb.MemberSearchResult
+	for _, member := range candidates {
+		if result, ok := membersearch.Match(member, criteria); ok {
+			results = append(results, result)
+		}
+	}
+
+	membersearch.Sort(results)
+	results, page, err := membersearch.Page(results, req.Page)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.repo.CreateAuditLog(ctx, membersearch.AuditEvent(agentID, criteria, results, page)); err != nil {
+		log.Printf("Error auditing member search: %v", err)
+		return nil, status.Error(codes.Unavailable, "member search could not be audited")
+	}
+
+	return &pb.SearchMembersResponse{
+		Results: results,
+		Page:    page,
+	}, nil
+}
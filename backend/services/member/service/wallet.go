@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health/backend/services/member/internal/wallet"
+	"github.com/sydney-health/backend/shared/access"
+	"github.com/sydney-health/backend/shared/coverage"
+	sharedkafka "github.com/sydney-health/backend/shared/kafka"
+	pb "github.com/sydney-health/backend/shared/pb"
+)
+
+// walletError maps wallet package errors to gRPC status errors
+func walletError(err error) error {
+	switch {
+	case errors.Is(err, wallet.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, "invalid pass authentication token")
+	case errors.Is(err, wallet.ErrInvalidSerial):
+		return status.Error(codes.InvalidArgument, "invalid pass serial number")
+	case errors.Is(err, wallet.ErrAppleDisabled), errors.Is(err, wallet.ErrGoogleDisabled):
+		return status.Error(codes.Unimplemented, err.Error())
+	default:
+		log.Printf("Error generating wallet pass: %v", err)
+		return status.Error(codes.Internal, "failed to generate wallet pass")
+	}
+}
+
+// GetWalletPass issues an Apple .pkpass bundle or a Google Wallet save link for a member card
+func (s *MemberService) GetWalletPass(ctx context.Context, req *pb.GetWalletPassRequest) (*pb.GetWalletPassResponse, error) {
+	log.Printf("GetWalletPass called for member ID: %s, wallet: %s", req.MemberId, req.Wallet)
+
+	if req.CoverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "coverage_type is required")
+	}
+
+	resp, err := s.GetMemberCard(ctx, &pb.GetMemberCardRequest{
+		MemberId:     req.MemberId,
+		CoverageType: req.CoverageType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pb.GetWalletPassResponse{
+		SerialNumber: wallet.SerialNumber(req.MemberId, req.CoverageType),
+	}
+
+	switch req.Wallet {
+	case pb.WalletType_WALLET_TYPE_GOOGLE:
+		if out.SaveUrl, err = s.passes.GoogleSaveURL(resp.Card, req.CoverageType); err != nil {
+			return nil, walletError(err)
+		}
+	case pb.WalletType_WALLET_TYPE_APPLE, pb.WalletType_WALLET_TYPE_UNSPECIFIED:
+		if out.Data, _, err = s.passes.ApplePass(ctx, resp.Card, req.CoverageType, false); err != nil {
+			return nil, walletError(err)
+		}
+		out.ContentType = wallet.ContentTypePKPass
+	default:
+		return nil, status.Error(codes.InvalidArgument, "unsupported wallet")
+	}
+
+	return out, nil
+}
+
+// RegisterWalletDevice registers a device to receive updates for a pass
+func (s *MemberService) RegisterWalletDevice(ctx context.Context, req *pb.RegisterWalletDeviceRequest) (*pb.RegisterWalletDeviceResponse, error) {
+	log.Printf("RegisterWalletDevice called for pass: %s", req.SerialNumber)
+
+	if req.DeviceLibraryIdentifier == "" || req.PushToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "device_library_identifier and push_token are required")
+	}
+
+	created, err := s.passes.Register(ctx, wallet.Registration{
+		DeviceLibraryID: req.DeviceLibraryIdentifier,
+		PassTypeID:      req.PassTypeIdentifier,
+		SerialNumber:    req.SerialNumber,
+		PushToken:       req.PushToken,
+	}, req.AuthenticationToken)
+	if err != nil {
+		return nil, walletError(err)
+	}
+
+	return &pb.RegisterWalletDeviceResponse{Created: created}, nil
+}
+
+// UnregisterWalletDevice stops updates for a pass on a device
+func (s *MemberService) UnregisterWalletDevice(ctx context.Context, req *pb.UnregisterWalletDeviceRequest) (*pb.UnregisterWalletDeviceResponse, error) {
+	log.Printf("UnregisterWalletDevice called for pass: %s", req.SerialNumber)
+
+	err := s.passes.Unregister(ctx, req.DeviceLibraryIdentifier, req.PassTypeIdentifier, req.SerialNumber, req.AuthenticationToken)
+	if err != nil {
+		return nil, walletError(err)
+	}
+
+	return &pb.UnregisterWalletDeviceResponse{}, nil
+}
+
+// ListUpdatedWalletPasses lists a device's passes changed since its last check
+func (s *MemberService) ListUpdatedWalletPasses(ctx context.Context, req *pb.ListUpdatedWalletPassesRequest) (*pb.ListUpdatedWalletPassesResponse, error) {
+	serials, tag, err := s.passes.UpdatedSince(ctx, req.DeviceLibraryIdentifier, req.PassTypeIdentifier, req.PassesUpdatedSince)
+	if err != nil {
+		log.Printf("Error listing updated wallet passes: %v", err)
+		return nil, status.Error(codes.InvalidArgument, "failed to list updated passes")
+	}
+
+	return &pb.ListUpdatedWalletPassesResponse{
+		SerialNumbers: serials,
+		LastUpdated:   tag,
+	}, nil
+}
+
+// GetLatestWalletPass returns the current version of an installed pass
+func (s *MemberService) GetLatestWalletPass(ctx context.Context, req *pb.GetLatestWalletPassRequest) (*pb.GetLatestWalletPassResponse, error) {
+	log.Printf("GetLatestWalletPass called for pass: %s", req.SerialNumber)
+
+	if err := s.passes.Authenticate(req.PassTypeIdentifier, req.SerialNumber, req.AuthenticationToken); err != nil {
+		return nil, walletError(err)
+	}
+
+	memberID, coverageType, err := wallet.ParseSerialNumber(req.SerialNumber)
+	if err != nil {
+		return nil, walletError(err)
+	}
+
+	// The pass's authentication token stands in for a member session
+	card, voided, err := s.walletCard(access.WithInternal(ctx, "wallet pass "+req.SerialNumber), memberID, coverageType)
+	if err != nil {
+		return nil, err
+	}
+
+	data, updatedAt, err := s.passes.ApplePass(ctx, card, coverageType, voided)
+	if err != nil {
+		return nil, walletError(err)
+	}
+
+	// HTTP dates have second precision
+	if req.IfModifiedSince != nil && !updatedAt.Truncate(time.Second).After(req.IfModifiedSince.AsTime()) {
+		return &pb.GetLatestWalletPassResponse{NotModified: true, LastModified: timestamppb.New(updatedAt)}, nil
+	}
+
+	return &pb.GetLatestWalletPassResponse{
+		Data:         data,
+		LastModified: timestamppb.New(updatedAt),
+	}, nil
+}
+
+// walletCard returns the card a pass for the coverage shows: today's card,
+// or once the coverage has ended, the card as of its last day and a pass to
+// be voided.
+func (s *MemberService) walletCard(ctx context.Context, memberID string, coverageType pb.CoverageType) (*pb.MemberCard, bool, error) {
+	spans, err := s.repo.ListCoverageSpans(ctx, memberID, coverageType)
+	if err != nil {
+		log.Printf("Error listing coverage spans: %v", err)
+		return nil, false, status.Error(codes.Internal, "failed to retrieve coverage")
+	}
+
+	now := time.Now()
+	var ended *timestamppb.Timestamp
+	if coverage.Find(spans, coverageType, now) == nil {
+		if span := coverage.Ended(spans, coverageType, now); span != nil {
+			ended = span.TerminationDate
+		}
+	}
+
+	resp, err := s.GetMemberCard(ctx, &pb.GetMemberCardRequest{
+		MemberId:      memberID,
+		CoverageType:  coverageType,
+		DateOfService: ended,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Card, ended != nil, nil
+}
+
+// RefreshWalletPasses regenerates the member's passes for each active
+// coverage and each pass installed on a device, voiding those whose coverage
+// has ended, and pushes changes to the devices holding them.
+func (s *MemberService) RefreshWalletPasses(ctx context.Context, memberID string) error {
+	active, err := s.repo.ListActiveCoverageTypes(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	registered, err := s.passes.RegisteredCoverages(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	ctx = access.WithInternal(ctx, "wallet refresh")
+	var errs []error
+	for _, coverageType := range walletCoverages(active, registered) {
+		card, voided, err := s.walletCard(ctx, memberID, coverageType)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.passes.Refresh(ctx, card, coverageType, voided); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// walletCoverages merges the active coverage types with those of installed
+// passes, without repeats
+func walletCoverages(active, registered []pb.CoverageType) []pb.CoverageType {
+	seen := make(map[pb.CoverageType]bool)
+	var coverages []pb.CoverageType
+	for _, types := range [][]pb.CoverageType{active, registered} {
+		for _, coverageType := range types {
+			if !seen[coverageType] {
+				seen[coverageType] = true
+				coverages = append(coverages, coverageType)
+			}
+		}
+	}
+	return coverages
+}
+
+// refreshWalletPassesAsync refreshes passes off the request path
+func (s *MemberService) refreshWalletPassesAsync(memberID string) {
+	go func() {
+		if err := s.RefreshWalletPasses(context.Background(), memberID); err != nil {
+			log.Printf("Error refreshing wallet passes: %v", err)
+		}
+	}()
+}
+
+// HandleMemberUpdate refreshes wallet passes when another service reports a
+// coverage change for a member.
+func (s *MemberService) HandleMemberUpdate(ctx context.Context, message kafka.Message) error {
+	update, err := sharedkafka.UnmarshalMemberUpdate(message.Value)
+	if err != nil {
+		return err
+	}
+	if update.UpdateType != "coverage" {
+		return nil
+	}
+	return s.RefreshWalletPasses(ctx, update.MemberID)
+}
@@ -0,0 +1,72 @@
+// Command authorization-expiry moves approved prior authorizations whose
+// approved end date has passed to expired and publishes their updates. Run it
+// daily from a scheduler; a second run the same day does nothing.
+//
+//	authorization-expiry [-date YYYY-MM-DD]
+//
+// The database is configured with the DB_* environment variables, and events
+// with KAFKA_BROKERS and AUTHORIZATION_UPDATES_TOPIC, as for the prior
+// authorization service. Claims are never matched to an authorization past
+// its approved end date, whether or not this has run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/priorauth/internal/authorization"
+	"github.com/sydney-health-clone/backend/services/priorauth/repository"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+)
+
+var (
+	date    = flag.String("date", "", "Expire authorizations that ended before this day, as YYYY-MM-DD (default: today)")
+	timeout = flag.Duration("timeout", 10*time.Minute, "Time allowed for the run")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: authorization-expiry [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	today := time.Now()
+	if *date != "" {
+		day, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -date %q: %v\n", *date, err)
+			os.Exit(2)
+		}
+		today = day
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	var events authorization.Publisher
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("AUTHORIZATION_UPDATES_TOPIC"); brokers != "" && topic != "" {
+		producer := kafka.NewProducer(strings.Split(brokers, ","), topic)
+		defer producer.Close()
+		events = producer
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	tracker := authorization.NewTracker(repository.NewAuthorizationStore(db), events)
+	expired, err := tracker.Expire(ctx, today)
+	log.Printf("Expired %d authorizations", expired)
+	if err != nil {
+		log.Fatalf("Expiry failed: %v", err)
+	}
+}
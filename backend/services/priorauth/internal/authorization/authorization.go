@@ -0,0 +1,444 @@
+// Package authorization tracks prior authorization requests through their
+// statuses and matches claims to the approved ones.
+//
+// Every status change is kept in the authorization's history, raises its
+// version and is published as an AuthorizationUpdate event. Claims draw
+// approved units one claim line at a time; matching a line again replaces
+// its earlier draw, so readjudicated claims don't use units twice.
+package authorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	"github.com/sydney-health-clone/backend/shared/npi"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/procedure"
+)
+
+// DefaultValidity is how long a request covers when it gives no end date
+const DefaultValidity = 90 * 24 * time.Hour
+
+// Page sizes for List
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	// ErrNotFound is returned for an unknown authorization ID
+	ErrNotFound = errors.New("authorization not found")
+	// ErrInvalid is returned, wrapped with the problem, for requests that
+	// can't be accepted
+	ErrInvalid = errors.New("invalid authorization request")
+	// ErrInvalidTransition is returned for a status change the state machine
+	// doesn't allow
+	ErrInvalidTransition = errors.New("invalid status change")
+	// ErrConflict is returned when the authorization changed while it was
+	// being updated
+	ErrConflict = errors.New("authorization was changed concurrently")
+)
+
+// Claim is a claim line looking for an authorization
+type Claim struct {
+	ClaimID             string
+	Line                int32
+	MemberID            string
+	ProcedureCode       string
+	ServicingProviderID string
+	ServiceDate         time.Time
+	Units               int32
+}
+
+// Store keeps authorizations, their history and the units claims draw
+type Store interface {
+	Create(ctx context.Context, a *pb.Authorization) error
+	// Get returns the authorization with its history and used units, or
+	// ErrNotFound
+	Get(ctx context.Context, id string) (*pb.Authorization, error)
+	// List returns a page of the member's authorizations, newest first, and
+	// how many there are in all. No statuses means every status.
+	List(ctx context.Context, memberID string, statuses []pb.AuthorizationStatus, offset, limit int) ([]*pb.Authorization, int, error)
+	// Update saves a, whose version has been raised by one and whose last
+	// history entry is new, or returns ErrConflict if the stored version
+	// isn't the one before
+	Update(ctx context.Context, a *pb.Authorization) error
+	// Expiring returns approved authorizations whose approved end date is
+	// before day
+	Expiring(ctx context.Context, day time.Time) ([]*pb.Authorization, error)
+	// Approved returns the approved authorizations for the claim's member and
+	// procedure whose approved dates cover its service date. Their used units
+	// leave out what the claim line itself drew before.
+	Approved(ctx context.Context, c Claim) ([]*pb.Authorization, error)
+	// Draw replaces the claim line's earlier draw, if any, with its units
+	// drawn from the authorization, or reports false and changes nothing if
+	// the authorization has too few units left
+	Draw(ctx context.Context, authorizationID string, c Claim) (bool, error)
+}
+
+// Publisher sends events; satisfied by *kafka.Producer
+type Publisher interface {
+	SendMessage(ctx context.Context, key string, value interface{}) error
+}
+
+// Tracker applies submissions, decisions, expiry and claim matches
+type Tracker struct {
+	store  Store
+	events Publisher
+	now    func() time.Time
+}
+
+// NewTracker creates a tracker over store. events may be nil when no topic
+// is configured.
+func NewTracker(store Store, events Publisher) *Tracker {
+	return &Tracker{store: store, events: events, now: time.Now}
+}
+
+// Submit validates and records a new request. by is who submitted it.
+func (t *Tracker) Submit(ctx context.Context, req *pb.SubmitAuthorizationRequest, by string) (*pb.Authorization, error) {
+	if req.MemberId == "" {
+		return nil, fmt.Errorf("%w: member_id is required", ErrInvalid)
+	}
+	code, err := procedure.Normalize(req.ProcedureCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	diagnoses := make([]string, 0, len(req.DiagnosisCodes))
+	for _, d := range req.DiagnosisCodes {
+		d = strings.ToUpper(strings.TrimSpace(d))
+		if !icd10Code.MatchString(d) {
+			return nil, fmt.Errorf("%w: %q is not an ICD-10 diagnosis code", ErrInvalid, d)
+		}
+		diagnoses = append(diagnoses, d)
+	}
+	for _, id := range []string{req.RequestingProviderId, req.ServicingProviderId} {
+		if id == "" {
+			continue
+		}
+		if err := npi.Validate(id); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+
+	units := req.RequestedUnits
+	if units == 0 {
+		units = 1
+	}
+	if units < 0 {
+		return nil, fmt.Errorf("%w: requested_units must be positive", ErrInvalid)
+	}
+	if req.RequestedStartDate == nil {
+		return nil, fmt.Errorf("%w: requested_start_date is required", ErrInvalid)
+	}
+	start := coverage.Day(req.RequestedStartDate.AsTime())
+	end := start.Add(DefaultValidity - 24*time.Hour)
+	if req.RequestedEndDate != nil {
+		end = coverage.Day(req.RequestedEndDate.AsTime())
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: requested_end_date is before requested_start_date", ErrInvalid)
+	}
+
+	coverageType := req.CoverageType
+	if coverageType == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		coverageType = pb.CoverageType_COVERAGE_TYPE_MEDICAL
+	}
+
+	now := timestamppb.New(t.now())
+	a := &pb.Authorization{
+		AuthorizationId:      uuid.NewString(),
+		MemberId:             req.MemberId,
+		CoverageType:         coverageType,
+		ProcedureCode:        code,
+		DiagnosisCodes:       diagnoses,
+		RequestingProviderId: req.RequestingProviderId,
+		ServicingProviderId:  req.ServicingProviderId,
+		Status:               pb.AuthorizationStatus_AUTHORIZATION_STATUS_SUBMITTED,
+		RequestedUnits:       units,
+		RequestedStartDate:   timestamppb.New(start),
+		RequestedEndDate:     timestamppb.New(end),
+		Notes:                strings.TrimSpace(req.Notes),
+		SubmittedAt:          now,
+		UpdatedAt:            now,
+		Version:              1,
+		History: []*pb.AuthorizationStatusChange{{
+			Status:    pb.AuthorizationStatus_AUTHORIZATION_STATUS_SUBMITTED,
+			ChangedBy: by,
+			ChangedAt: now,
+		}},
+	}
+	if err := t.store.Create(ctx, a); err != nil {
+		return nil, fmt.Errorf("failed to save authorization: %w", err)
+	}
+
+	t.publish(ctx, a, pb.AuthorizationStatus_AUTHORIZATION_STATUS_UNSPECIFIED)
+	return a, nil
+}
+
+// Get returns one authorization
+func (t *Tracker) Get(ctx context.Context, id string) (*pb.Authorization, error) {
+	return t.store.Get(ctx, id)
+}
+
+// List returns a page of the member's authorizations, newest first. Page
+// tokens are the offset of the page's first authorization.
+func (t *Tracker) List(ctx context.Context, memberID string, statuses []pb.AuthorizationStatus, page *pb.PageRequest) ([]*pb.Authorization, *pb.PageResponse, error) {
+	size, offset := DefaultPageSize, 0
+	if page != nil {
+		if page.PageSize > 0 {
+			size = min(int(page.PageSize), MaxPageSize)
+		}
+		if page.PageToken != "" {
+			n, err := strconv.Atoi(page.PageToken)
+			if err != nil || n < 0 {
+				return nil, nil, fmt.Errorf("%w: invalid page token", ErrInvalid)
+			}
+			offset = n
+		}
+	}
+
+	authorizations, total, err := t.store.List(ctx, memberID, statuses, offset, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp := &pb.PageResponse{TotalCount: int32(total)}
+	if end := offset + len(authorizations); end < total {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return authorizations, resp, nil
+}
+
+// Decide records a utilization management decision. Approvals default to the
+// requested units and dates; a partial approval must narrow at least one of
+// them, and pended, partial and denied decisions must give a reason.
+func (t *Tracker) Decide(ctx context.Context, req *pb.DecideAuthorizationRequest) (*pb.Authorization, error) {
+	switch req.Status {
+	case pb.AuthorizationStatus_AUTHORIZATION_STATUS_PENDED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_PARTIALLY_APPROVED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_DENIED:
+	default:
+		return nil, fmt.Errorf("%w: a decision is pended, approved, partially approved or denied", ErrInvalid)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" && req.Status != pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED {
+		return nil, fmt.Errorf("%w: reason is required for %s decisions", ErrInvalid, strings.ToLower(StatusName(req.Status)))
+	}
+
+	a, err := t.store.Get(ctx, req.AuthorizationId)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(a.Status, req.Status) {
+		return nil, fmt.Errorf("%w: %s authorizations can't be %s", ErrInvalidTransition,
+			strings.ToLower(StatusName(a.Status)), strings.ToLower(StatusName(req.Status)))
+	}
+	updated := proto.Clone(a).(*pb.Authorization)
+
+	if Approved(req.Status) {
+		if err := approve(updated, req); err != nil {
+			return nil, err
+		}
+	}
+	updated.Status = req.Status
+	updated.DecisionReason = reason
+
+	if err := t.change(ctx, updated, reason, req.Reviewer); err != nil {
+		return nil, err
+	}
+	t.publish(ctx, updated, a.Status)
+	return updated, nil
+}
+
+// approve sets the approved units and dates on a, checking them against the
+// request
+func approve(a *pb.Authorization, req *pb.DecideAuthorizationRequest) error {
+	units := req.ApprovedUnits
+	if units == 0 {
+		units = a.RequestedUnits
+	}
+	requestedStart := coverage.Day(a.RequestedStartDate.AsTime())
+	requestedEnd := coverage.Day(a.RequestedEndDate.AsTime())
+	start, end := requestedStart, requestedEnd
+	if req.ApprovedStartDate != nil {
+		start = coverage.Day(req.ApprovedStartDate.AsTime())
+	}
+	if req.ApprovedEndDate != nil {
+		end = coverage.Day(req.ApprovedEndDate.AsTime())
+	}
+
+	switch {
+	case units < 0 || units > a.RequestedUnits:
+		return fmt.Errorf("%w: approved_units must be from 1 to the %d requested", ErrInvalid, a.RequestedUnits)
+	case start.Before(requestedStart) || end.After(requestedEnd):
+		return fmt.Errorf("%w: approved dates must fall within the requested dates", ErrInvalid)
+	case end.Before(start):
+		return fmt.Errorf("%w: approved_end_date is before approved_start_date", ErrInvalid)
+	}
+
+	narrowed := units < a.RequestedUnits || start.After(requestedStart) || end.Before(requestedEnd)
+	if req.Status == pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED && narrowed {
+		return fmt.Errorf("%w: approving fewer units or a shorter period than requested is a partial approval", ErrInvalid)
+	}
+	if req.Status == pb.AuthorizationStatus_AUTHORIZATION_STATUS_PARTIALLY_APPROVED && !narrowed {
+		return fmt.Errorf("%w: a partial approval must approve fewer units or a shorter period than requested", ErrInvalid)
+	}
+
+	a.ApprovedUnits = units
+	a.ApprovedStartDate = timestamppb.New(start)
+	a.ApprovedEndDate = timestamppb.New(end)
+	return nil
+}
+
+// Expire moves approved authorizations whose approved end date is before
+// day to expired, returning how many it expired
+func (t *Tracker) Expire(ctx context.Context, day time.Time) (int, error) {
+	expiring, err := t.store.Expiring(ctx, coverage.Day(day))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expiring authorizations: %w", err)
+	}
+
+	expired := 0
+	for _, a := range expiring {
+		updated := proto.Clone(a).(*pb.Authorization)
+		updated.Status = pb.AuthorizationStatus_AUTHORIZATION_STATUS_EXPIRED
+		err := t.change(ctx, updated, "Approved end date passed", "")
+		if errors.Is(err, ErrConflict) {
+			// Changed since it was read; the next run will see it again
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire authorization %s: %w", a.AuthorizationId, err)
+		}
+		t.publish(ctx, updated, a.Status)
+		expired++
+	}
+	return expired, nil
+}
+
+// Match finds the approved authorization covering the claim line and draws
+// its units. When none does it returns nil and why.
+func (t *Tracker) Match(ctx context.Context, c Claim) (*pb.Authorization, string, error) {
+	if c.MemberID == "" || c.ClaimID == "" {
+		return nil, "", fmt.Errorf("%w: member_id and claim_id are required", ErrInvalid)
+	}
+	code, err := procedure.Normalize(c.ProcedureCode)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	c.ProcedureCode = code
+	c.ServiceDate = coverage.Day(c.ServiceDate)
+	if c.Units == 0 {
+		c.Units = 1
+	}
+	if c.Units < 0 {
+		return nil, "", fmt.Errorf("%w: units must be positive", ErrInvalid)
+	}
+
+	candidates, err := t.store.Approved(ctx, c)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find approved authorizations: %w", err)
+	}
+	// Draw from the approval ending soonest, so units aren't left to expire
+	sortByApprovedEnd(candidates)
+
+	reason := "No approved authorization covers the service date"
+	for _, a := range candidates {
+		if c.ServicingProviderID != "" && a.ServicingProviderId != "" && a.ServicingProviderId != c.ServicingProviderID {
+			reason = "The approved authorization is for a different servicing provider"
+			continue
+		}
+		if a.ApprovedUnits-a.UsedUnits < c.Units {
+			reason = "The approved units are used up"
+			continue
+		}
+
+		drawn, err := t.store.Draw(ctx, a.AuthorizationId, c)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to draw authorized units: %w", err)
+		}
+		if !drawn {
+			// Another claim took the last units first
+			reason = "The approved units are used up"
+			continue
+		}
+		matched, err := t.store.Get(ctx, a.AuthorizationId)
+		if err != nil {
+			return nil, "", err
+		}
+		return matched, "", nil
+	}
+	return nil, reason, nil
+}
+
+// change saves a status change already applied to a
+func (t *Tracker) change(ctx context.Context, a *pb.Authorization, reason, by string) error {
+	now := timestamppb.New(t.now())
+	a.Version++
+	a.UpdatedAt = now
+	a.History = append(a.History, &pb.AuthorizationStatusChange{
+		Status:    a.Status,
+		Reason:    reason,
+		ChangedBy: by,
+		ChangedAt: now,
+	})
+	return t.store.Update(ctx, a)
+}
+
+// publish sends the AuthorizationUpdate for a's latest change. The change is
+// already saved, so a failure to publish is logged rather than returned.
+func (t *Tracker) publish(ctx context.Context, a *pb.Authorization, previous pb.AuthorizationStatus) {
+	if t.events == nil {
+		return
+	}
+
+	update := kafka.AuthorizationUpdate{
+		AuthorizationID:     a.AuthorizationId,
+		Version:             a.Version,
+		MemberID:            a.MemberId,
+		CoverageType:        coverage.TypeName(a.CoverageType),
+		ProcedureCode:       a.ProcedureCode,
+		ServicingProviderID: a.ServicingProviderId,
+		Status:              StatusName(a.Status),
+		ApprovedUnits:       a.ApprovedUnits,
+		ApprovedStartDate:   coverage.FormatDate(a.ApprovedStartDate),
+		ApprovedEndDate:     coverage.FormatDate(a.ApprovedEndDate),
+		Reason:              a.History[len(a.History)-1].Reason,
+		Timestamp:           a.UpdatedAt.AsTime().Unix(),
+	}
+	if previous != pb.AuthorizationStatus_AUTHORIZATION_STATUS_UNSPECIFIED {
+		update.PreviousStatus = StatusName(previous)
+	}
+	if err := t.events.SendMessage(ctx, a.AuthorizationId, update); err != nil {
+		log.Printf("Failed to publish update of authorization %s version %d: %v", a.AuthorizationId, a.Version, err)
+	}
+}
+
+// sortByApprovedEnd orders authorizations by approved end date, then by
+// when they were submitted
+func sortByApprovedEnd(authorizations []*pb.Authorization) {
+	sort.SliceStable(authorizations, func(i, j int) bool {
+		a, b := authorizations[i], authorizations[j]
+		if !a.ApprovedEndDate.AsTime().Equal(b.ApprovedEndDate.AsTime()) {
+			return a.ApprovedEndDate.AsTime().Before(b.ApprovedEndDate.AsTime())
+		}
+		return a.SubmittedAt.AsTime().Before(b.SubmittedAt.AsTime())
+	})
+}
+
+var (
+	icd10Code = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z](\.?[0-9A-Z]{1,4})?$`)
+)
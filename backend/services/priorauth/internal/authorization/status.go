@@ -0,0 +1,56 @@
+package authorization
+
+import (
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// transitions lists the statuses each status can move to. Denied and
+// expired authorizations are final.
+var transitions = map[pb.AuthorizationStatus][]pb.AuthorizationStatus{
+	pb.AuthorizationStatus_AUTHORIZATION_STATUS_SUBMITTED: {
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_PENDED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_PARTIALLY_APPROVED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_DENIED,
+	},
+	pb.AuthorizationStatus_AUTHORIZATION_STATUS_PENDED: {
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_PARTIALLY_APPROVED,
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_DENIED,
+	},
+	pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED: {
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_EXPIRED,
+	},
+	pb.AuthorizationStatus_AUTHORIZATION_STATUS_PARTIALLY_APPROVED: {
+		pb.AuthorizationStatus_AUTHORIZATION_STATUS_EXPIRED,
+	},
+}
+
+// CanTransition reports whether an authorization in status from can move to to
+func CanTransition(from, to pb.AuthorizationStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Approved reports whether claims can be matched to an authorization in status
+func Approved(status pb.AuthorizationStatus) bool {
+	return status == pb.AuthorizationStatus_AUTHORIZATION_STATUS_APPROVED ||
+		status == pb.AuthorizationStatus_AUTHORIZATION_STATUS_PARTIALLY_APPROVED
+}
+
+// StatusName returns the short name used in storage and events, e.g. "PENDED"
+func StatusName(status pb.AuthorizationStatus) string {
+	return strings.TrimPrefix(status.String(), "AUTHORIZATION_STATUS_")
+}
+
+// ParseStatus accepts either the short or the full enum name
+func ParseStatus(name string) pb.AuthorizationStatus {
+	value := pb.AuthorizationStatus_value["AUTHORIZATION_STATUS_"+strings.TrimPrefix(strings.ToUpper(name), "AUTHORIZATION_STATUS_")]
+	return pb.AuthorizationStatus(value)
+}
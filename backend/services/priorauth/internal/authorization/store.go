@@ -0,0 +1,171 @@
+package authorization
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// MemoryStore is an in-process Store for demo mode
+type MemoryStore struct {
+	mu             sync.Mutex
+	authorizations map[string]*pb.Authorization
+	draws          map[drawKey]draw
+}
+
+type drawKey struct {
+	claimID string
+	line    int32
+}
+
+type draw struct {
+	authorizationID string
+	units           int32
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		authorizations: make(map[string]*pb.Authorization),
+		draws:          make(map[drawKey]draw),
+	}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, a *pb.Authorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authorizations[a.AuthorizationId] = proto.Clone(a).(*pb.Authorization)
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*pb.Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.authorizations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.withUse(a, drawKey{}), nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, memberID string, statuses []pb.AuthorizationStatus, offset, limit int) ([]*pb.Authorization, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*pb.Authorization
+	for _, a := range s.authorizations {
+		if a.MemberId != memberID || (len(statuses) > 0 && !hasStatus(statuses, a.Status)) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].SubmittedAt.AsTime().After(matched[j].SubmittedAt.AsTime())
+	})
+
+	total := len(matched)
+	if offset >= total {
+		return nil, total, nil
+	}
+	matched = matched[offset:min(offset+limit, total)]
+	page := make([]*pb.Authorization, len(matched))
+	for i, a := range matched {
+		page[i] = s.withUse(a, drawKey{})
+	}
+	return page, total, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, a *pb.Authorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.authorizations[a.AuthorizationId]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != a.Version-1 {
+		return ErrConflict
+	}
+	s.authorizations[a.AuthorizationId] = proto.Clone(a).(*pb.Authorization)
+	return nil
+}
+
+func (s *MemoryStore) Expiring(ctx context.Context, day time.Time) ([]*pb.Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expiring []*pb.Authorization
+	for _, a := range s.authorizations {
+		if Approved(a.Status) && coverage.Day(a.ApprovedEndDate.AsTime()).Before(day) {
+			expiring = append(expiring, s.withUse(a, drawKey{}))
+		}
+	}
+	return expiring, nil
+}
+
+func (s *MemoryStore) Approved(ctx context.Context, c Claim) ([]*pb.Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var approved []*pb.Authorization
+	for _, a := range s.authorizations {
+		if a.MemberId != c.MemberID || a.ProcedureCode != c.ProcedureCode || !Approved(a.Status) {
+			continue
+		}
+		if c.ServiceDate.Before(coverage.Day(a.ApprovedStartDate.AsTime())) || c.ServiceDate.After(coverage.Day(a.ApprovedEndDate.AsTime())) {
+			continue
+		}
+		approved = append(approved, s.withUse(a, drawKey{c.ClaimID, c.Line}))
+	}
+	return approved, nil
+}
+
+func (s *MemoryStore) Draw(ctx context.Context, authorizationID string, c Claim) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.authorizations[authorizationID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	key := drawKey{c.ClaimID, c.Line}
+	if a.ApprovedUnits-s.used(authorizationID, key) < c.Units {
+		return false, nil
+	}
+	s.draws[key] = draw{authorizationID: authorizationID, units: c.Units}
+	return true, nil
+}
+
+// withUse copies a with its used units, leaving out the draw of except
+func (s *MemoryStore) withUse(a *pb.Authorization, except drawKey) *pb.Authorization {
+	a = proto.Clone(a).(*pb.Authorization)
+	a.UsedUnits = s.used(a.AuthorizationId, except)
+	return a
+}
+
+func (s *MemoryStore) used(authorizationID string, except drawKey) int32 {
+	var units int32
+	for key, d := range s.draws {
+		if d.authorizationID == authorizationID && key != except {
+			units += d.units
+		}
+	}
+	return units
+}
+
+func hasStatus(statuses []pb.AuthorizationStatus, status pb.AuthorizationStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
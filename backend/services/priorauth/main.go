@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/joho/godotenv"
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/priorauth/internal/authorization"
+	"github.com/sydney-health-clone/backend/services/priorauth/repository"
+	"github.com/sydney-health-clone/backend/services/priorauth/service"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load("../../.env.development"); err != nil {
+		log.Printf("Warning: .env.development file not found")
+	}
+
+	// Demo mode keeps authorizations in memory without a database
+	var store authorization.Store
+	demoMode, _ := strconv.ParseBool(os.Getenv("PRIOR_AUTH_DEMO_MODE"))
+	if demoMode {
+		log.Printf("Demo mode: keeping authorizations in memory")
+		store = authorization.NewMemoryStore()
+	} else {
+		db, err := database.InitDB()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+		store = repository.NewAuthorizationStore(db)
+	}
+
+	// Publish AuthorizationUpdate events when a topic is configured
+	var events authorization.Publisher
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("AUTHORIZATION_UPDATES_TOPIC"); brokers != "" && topic != "" {
+		producer := kafka.NewProducer(strings.Split(brokers, ","), topic)
+		defer producer.Close()
+		events = producer
+	}
+
+	// Initialize service
+	priorAuthService := service.NewPriorAuthService(authorization.NewTracker(store, events))
+
+	// Get port from environment
+	port := os.Getenv("PRIOR_AUTH_SERVICE_PORT")
+	if port == "" {
+		port = "50056"
+	}
+
+	// Create gRPC server
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+
+	// Register prior authorization service
+	pb.RegisterPriorAuthServiceServer(grpcServer, priorAuthService)
+
+	// Register health service
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// Register reflection service for debugging
+	reflection.Register(grpcServer)
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Prior authorization service listening on port %s", port)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	// Setup graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("Shutting down prior authorization service...")
+	grpcServer.GracefulStop()
+	log.Println("Prior authorization service stopped")
+}
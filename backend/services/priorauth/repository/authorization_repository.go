@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/priorauth/internal/authorization"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// AuthorizationStore keeps authorizations in prior_authorizations, their
+// status changes in prior_authorization_history and claim draws in
+// prior_authorization_uses
+type AuthorizationStore struct {
+	db *database.DB
+}
+
+// NewAuthorizationStore creates a new authorization store
+func NewAuthorizationStore(db *database.DB) *AuthorizationStore {
+	return &AuthorizationStore{db: db}
+}
+
+// authorizationColumns are read by scanAuthorization. used_units counts the
+// draws of every claim line but the one given as $1 and $2.
+const authorizationColumns = `
+	a.authorization_id, a.member_id, a.coverage_type, a.procedure_code, a.diagnosis_codes,
+	a.requesting_provider_id, a.servicing_provider_id, a.status,
+	a.requested_units, a.requested_start_date, a.requested_end_date,
+	a.approved_units, a.approved_start_date, a.approved_end_date,
+	a.decision_reason, a.notes, a.version, a.submitted_at, a.updated_at,
+	(SELECT COALESCE(SUM(u.units), 0) FROM prior_authorization_uses u
+	 WHERE u.authorization_id = a.authorization_id AND NOT (u.claim_id = $1 AND u.line = $2)) AS used_units
+`
+
+func (s *AuthorizationStore) Create(ctx context.Context, a *pb.Authorization) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO prior_authorizations (
+			authorization_id, member_id, coverage_type, procedure_code, diagnosis_codes,
+			requesting_provider_id, servicing_provider_id, status,
+			requested_units, requested_start_date, requested_end_date,
+			notes, version, submitted_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err = tx.ExecContext(ctx, query,
+		a.AuthorizationId, a.MemberId, coverage.TypeName(a.CoverageType), a.ProcedureCode, pq.Array(a.DiagnosisCodes),
+		a.RequestingProviderId, a.ServicingProviderId, authorization.StatusName(a.Status),
+		a.RequestedUnits, a.RequestedStartDate.AsTime(), a.RequestedEndDate.AsTime(),
+		a.Notes, a.Version, a.SubmittedAt.AsTime(), a.UpdatedAt.AsTime(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert authorization: %w", err)
+	}
+
+	if err := insertChange(ctx, tx, a); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *AuthorizationStore) Get(ctx context.Context, id string) (*pb.Authorization, error) {
+	query := `SELECT ` + authorizationColumns + ` FROM prior_authorizations a WHERE a.authorization_id = $3`
+
+	a, err := scanAuthorization(s.db.QueryRowContext(ctx, query, "", 0, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, authorization.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadHistory(ctx, []*pb.Authorization{a}); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *AuthorizationStore) List(ctx context.Context, memberID string, statuses []pb.AuthorizationStatus, offset, limit int) ([]*pb.Authorization, int, error) {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = authorization.StatusName(status)
+	}
+	filter := `a.member_id = $3 AND (cardinality($4::text[]) = 0 OR a.status = ANY($4))`
+
+	var total int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM prior_authorizations a WHERE a.member_id = $1 AND (cardinality($2::text[]) = 0 OR a.status = ANY($2))`,
+		memberID, pq.Array(names),
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count authorizations: %w", err)
+	}
+
+	query := `
+		SELECT ` + authorizationColumns + `
+		FROM prior_authorizations a
+		WHERE ` + filter + `
+		ORDER BY a.submitted_at DESC, a.authorization_id
+		OFFSET $5 LIMIT $6
+	`
+	authorizations, err := s.query(ctx, query, "", 0, memberID, pq.Array(names), offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return authorizations, total, nil
+}
+
+func (s *AuthorizationStore) Update(ctx context.Context, a *pb.Authorization) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE prior_authorizations SET
+			status = $2,
+			approved_units = $3,
+			approved_start_date = $4,
+			approved_end_date = $5,
+			decision_reason = $6,
+			version = $7,
+			updated_at = $8
+		WHERE authorization_id = $1 AND version = $7 - 1
+	`
+	result, err := tx.ExecContext(ctx, query,
+		a.AuthorizationId, authorization.StatusName(a.Status),
+		a.ApprovedUnits, nullDate(a.ApprovedStartDate), nullDate(a.ApprovedEndDate),
+		a.DecisionReason, a.Version, a.UpdatedAt.AsTime(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update authorization: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update authorization: %w", err)
+	} else if n == 0 {
+		return authorization.ErrConflict
+	}
+
+	if err := insertChange(ctx, tx, a); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *AuthorizationStore) Expiring(ctx context.Context, day time.Time) ([]*pb.Authorization, error) {
+	query := `
+		SELECT ` + authorizationColumns + `
+		FROM prior_authorizations a
+		WHERE a.status IN ('APPROVED', 'PARTIALLY_APPROVED') AND a.approved_end_date < $3
+		ORDER BY a.approved_end_date, a.authorization_id
+	`
+	return s.query(ctx, query, "", 0, day)
+}
+
+func (s *AuthorizationStore) Approved(ctx context.Context, c authorization.Claim) ([]*pb.Authorization, error) {
+	query := `
+		SELECT ` + authorizationColumns + `
+		FROM prior_authorizations a
+		WHERE a.member_id = $3 AND a.procedure_code = $4
+			AND a.status IN ('APPROVED', 'PARTIALLY_APPROVED')
+			AND a.approved_start_date <= $5 AND a.approved_end_date >= $5
+	`
+	return s.query(ctx, query, c.ClaimID, c.Line, c.MemberID, c.ProcedureCode, c.ServiceDate)
+}
+
+// Draw locks the authorization so concurrent claims can't both take its last
+// units
+func (s *AuthorizationStore) Draw(ctx context.Context, authorizationID string, c authorization.Claim) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var approved int32
+	err = tx.QueryRowContext(ctx,
+		`SELECT approved_units FROM prior_authorizations WHERE authorization_id = $1 FOR UPDATE`,
+		authorizationID,
+	).Scan(&approved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, authorization.ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock authorization: %w", err)
+	}
+
+	var used int32
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(units), 0) FROM prior_authorization_uses
+		WHERE authorization_id = $1 AND NOT (claim_id = $2 AND line = $3)
+	`, authorizationID, c.ClaimID, c.Line).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("failed to sum authorization uses: %w", err)
+	}
+	if approved-used < c.Units {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO prior_authorization_uses (claim_id, line, authorization_id, units, matched_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (claim_id, line) DO UPDATE SET
+			authorization_id = EXCLUDED.authorization_id,
+			units = EXCLUDED.units,
+			matched_at = NOW()
+	`, c.ClaimID, c.Line, authorizationID, c.Units)
+	if err != nil {
+		return false, fmt.Errorf("failed to record authorization use: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+func (s *AuthorizationStore) query(ctx context.Context, query string, args ...interface{}) ([]*pb.Authorization, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authorizations: %w", err)
+	}
+	defer rows.Close()
+
+	var authorizations []*pb.Authorization
+	for rows.Next() {
+		a, err := scanAuthorization(rows)
+		if err != nil {
+			return nil, err
+		}
+		authorizations = append(authorizations, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadHistory(ctx, authorizations); err != nil {
+		return nil, err
+	}
+	return authorizations, nil
+}
+
+// loadHistory fills in each authorization's status changes, oldest first
+func (s *AuthorizationStore) loadHistory(ctx context.Context, authorizations []*pb.Authorization) error {
+	if len(authorizations) == 0 {
+		return nil
+	}
+	byID := make(map[string]*pb.Authorization, len(authorizations))
+	ids := make([]string, 0, len(authorizations))
+	for _, a := range authorizations {
+		byID[a.AuthorizationId] = a
+		ids = append(ids, a.AuthorizationId)
+	}
+
+	query := `
+		SELECT authorization_id, status, reason, changed_by, changed_at
+		FROM prior_authorization_history
+		WHERE authorization_id = ANY($1)
+		ORDER BY authorization_id, version
+	`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query authorization history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id, status string
+			changedAt  time.Time
+			change     pb.AuthorizationStatusChange
+		)
+		if err := rows.Scan(&id, &status, &change.Reason, &change.ChangedBy, &changedAt); err != nil {
+			return fmt.Errorf("failed to scan authorization history: %w", err)
+		}
+		change.Status = authorization.ParseStatus(status)
+		change.ChangedAt = timestamppb.New(changedAt)
+		byID[id].History = append(byID[id].History, &change)
+	}
+	return rows.Err()
+}
+
+func insertChange(ctx context.Context, tx *sql.Tx, a *pb.Authorization) error {
+	change := a.History[len(a.History)-1]
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO prior_authorization_history (authorization_id, version, status, reason, changed_by, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, a.AuthorizationId, a.Version, authorization.StatusName(change.Status), change.Reason, change.ChangedBy, change.ChangedAt.AsTime())
+	if err != nil {
+		return fmt.Errorf("failed to insert authorization history: %w", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAuthorization(row scanner) (*pb.Authorization, error) {
+	var (
+		a                            pb.Authorization
+		coverageType, status         string
+		requestedStart, requestedEnd time.Time
+		approvedStart, approvedEnd   sql.NullTime
+		submittedAt, updatedAt       time.Time
+	)
+	err := row.Scan(
+		&a.AuthorizationId, &a.MemberId, &coverageType, &a.ProcedureCode, pq.Array(&a.DiagnosisCodes),
+		&a.RequestingProviderId, &a.ServicingProviderId, &status,
+		&a.RequestedUnits, &requestedStart, &requestedEnd,
+		&a.ApprovedUnits, &approvedStart, &approvedEnd,
+		&a.DecisionReason, &a.Notes, &a.Version, &submittedAt, &updatedAt,
+		&a.UsedUnits,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan authorization: %w", err)
+	}
+
+	a.CoverageType, _ = coverage.ParseType(coverageType)
+	a.Status = authorization.ParseStatus(status)
+	a.RequestedStartDate = timestamppb.New(requestedStart)
+	a.RequestedEndDate = timestamppb.New(requestedEnd)
+	if approvedStart.Valid {
+		a.ApprovedStartDate = timestamppb.New(approvedStart.Time)
+	}
+	if approvedEnd.Valid {
+		a.ApprovedEndDate = timestamppb.New(approvedEnd.Time)
+	}
+	a.SubmittedAt = timestamppb.New(submittedAt)
+	a.UpdatedAt = timestamppb.New(updatedAt)
+	return &a, nil
+}
+
+func nullDate(ts *timestamppb.Timestamp) interface{} {
+	if ts == nil {
+		return nil
+	}
+	return ts.AsTime()
+}
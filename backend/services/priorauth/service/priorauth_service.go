@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health-clone/backend/services/priorauth/internal/authorization"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// PriorAuthService implements the gRPC PriorAuthService. Callers must be the
+// member, hold the access the gateway signed for them, or be internal;
+// decisions and claim matches come only from internal callers.
+type PriorAuthService struct {
+	pb.UnimplementedPriorAuthServiceServer
+	tracker *authorization.Tracker
+}
+
+// NewPriorAuthService creates a new prior authorization service
+func NewPriorAuthService(tracker *authorization.Tracker) *PriorAuthService {
+	return &PriorAuthService{tracker: tracker}
+}
+
+// SubmitAuthorization records a new prior authorization request
+func (s *PriorAuthService) SubmitAuthorization(ctx context.Context, req *pb.SubmitAuthorizationRequest) (*pb.SubmitAuthorizationResponse, error) {
+	log.Printf("SubmitAuthorization called for member ID: %s, procedure code: %s", req.MemberId, req.ProcedureCode)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	// Submitting acts on the member's behalf
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.tracker.Submit(ctx, req, submitter(ctx))
+	if err != nil {
+		return nil, statusError(err, "failed to submit authorization")
+	}
+	return &pb.SubmitAuthorizationResponse{Authorization: a}, nil
+}
+
+// GetAuthorization returns one authorization with its history
+func (s *PriorAuthService) GetAuthorization(ctx context.Context, req *pb.GetAuthorizationRequest) (*pb.GetAuthorizationResponse, error) {
+	log.Printf("GetAuthorization called for authorization ID: %s", req.AuthorizationId)
+
+	if req.AuthorizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "authorization_id is required")
+	}
+	// Only internal callers may look an authorization up without its member
+	decision := access.Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL}
+	if req.MemberId != "" {
+		var err error
+		if decision, err = access.AuthorizeMember(ctx, req.MemberId); err != nil {
+			return nil, err
+		}
+	} else if _, ok := access.InternalFromContext(ctx); !ok {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+
+	a, err := s.tracker.Get(ctx, req.AuthorizationId)
+	if err != nil {
+		return nil, statusError(err, "failed to retrieve authorization")
+	}
+	if req.MemberId != "" && a.MemberId != req.MemberId {
+		return nil, status.Error(codes.NotFound, "authorization not found")
+	}
+	if !visible(a, decision) {
+		return nil, status.Error(codes.NotFound, "authorization not found")
+	}
+	return &pb.GetAuthorizationResponse{Authorization: a}, nil
+}
+
+// ListAuthorizations lists the member's authorizations, newest first
+func (s *PriorAuthService) ListAuthorizations(ctx context.Context, req *pb.ListAuthorizationsRequest) (*pb.ListAuthorizationsResponse, error) {
+	log.Printf("ListAuthorizations called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	decision, err := access.AuthorizeMember(ctx, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+
+	all, page, err := s.tracker.List(ctx, req.MemberId, req.Statuses, req.Page)
+	if err != nil {
+		return nil, statusError(err, "failed to list authorizations")
+	}
+	authorizations := []*pb.Authorization{}
+	for _, a := range all {
+		if visible(a, decision) {
+			authorizations = append(authorizations, a)
+		}
+	}
+	if page != nil {
+		page.TotalCount -= int32(len(all) - len(authorizations))
+	}
+	return &pb.ListAuthorizationsResponse{Authorizations: authorizations, Page: page}, nil
+}
+
+// DecideAuthorization records a utilization management decision
+func (s *PriorAuthService) DecideAuthorization(ctx context.Context, req *pb.DecideAuthorizationRequest) (*pb.DecideAuthorizationResponse, error) {
+	log.Printf("DecideAuthorization called for authorization ID: %s, status: %s", req.AuthorizationId, req.Status)
+
+	if err := internalOnly(ctx); err != nil {
+		return nil, err
+	}
+	if req.AuthorizationId == "" {
+		return nil, status.Error(codes.InvalidArgument, "authorization_id is required")
+	}
+
+	a, err := s.tracker.Decide(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to record decision")
+	}
+	return &pb.DecideAuthorizationResponse{Authorization: a}, nil
+}
+
+// MatchAuthorization finds the approved authorization covering a claim line
+// for claims adjudication, drawing the line's units from it
+func (s *PriorAuthService) MatchAuthorization(ctx context.Context, req *pb.MatchAuthorizationRequest) (*pb.MatchAuthorizationResponse, error) {
+	log.Printf("MatchAuthorization called for claim ID: %s, line: %d", req.ClaimId, req.ClaimLine)
+
+	if err := internalOnly(ctx); err != nil {
+		return nil, err
+	}
+	if req.ServiceDate == nil {
+		return nil, status.Error(codes.InvalidArgument, "service_date is required")
+	}
+
+	a, reason, err := s.tracker.Match(ctx, authorization.Claim{
+		ClaimID:             req.ClaimId,
+		Line:                req.ClaimLine,
+		MemberID:            req.MemberId,
+		ProcedureCode:       req.ProcedureCode,
+		ServicingProviderID: req.ServicingProviderId,
+		ServiceDate:         req.ServiceDate.AsTime(),
+		Units:               req.Units,
+	})
+	if err != nil {
+		return nil, statusError(err, "failed to match authorization")
+	}
+	if a == nil {
+		return &pb.MatchAuthorizationResponse{Reason: reason}, nil
+	}
+	return &pb.MatchAuthorizationResponse{Matched: true, Authorization: a}, nil
+}
+
+// visible reports whether a caller with the decided access may see the
+// authorization. Restricted access hides services in sensitive categories, as
+// access.FilterClaims does for claims.
+func visible(a *pb.Authorization, decision access.Decision) bool {
+	return decision.Full() || access.SensitiveCategory(a.ProcedureCode) == ""
+}
+
+// submitter is who the request came from: the support agent, the member
+// session, or empty for providers and internal systems
+func submitter(ctx context.Context) string {
+	if agentID, ok := access.AgentFromContext(ctx); ok {
+		return agentID
+	}
+	if memberID, ok := access.RequesterFromContext(ctx); ok {
+		return memberID
+	}
+	return ""
+}
+
+// internalOnly rejects calls from anyone but internal callers. A call without
+// a member session isn't internal for that alone; it must carry a backend
+// service's signature.
+func internalOnly(ctx context.Context) error {
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "restricted to internal callers")
+	}
+	return nil
+}
+
+// statusError maps tracker errors to gRPC statuses, logging unexpected ones
+func statusError(err error, message string) error {
+	switch {
+	case errors.Is(err, authorization.ErrNotFound):
+		return status.Error(codes.NotFound, "authorization not found")
+	case errors.Is(err, authorization.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, authorization.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, authorization.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	log.Printf("Error: %s: %v", message, err)
+	return status.Error(codes.Internal, message)
+}
@@ -0,0 +1,114 @@
+// Command nppes-import loads the NPPES NPI registry's monthly dissemination
+// file into the provider directory.
+//
+//	nppes-import [-dry-run] [-restart] npidata_pfile.csv
+//
+// Providers are matched by NPI, so each month's full file updates the
+// directory in place; rows no newer than the last import are skipped and
+// deactivated NPIs leave the directory. An interrupted import resumes where
+// it stopped when run again on the same file. Practices are located with the
+// bundled ZIP centroid table, or the one PROVIDER_GEO_DATA names, and
+// taxonomy codes named with PROVIDER_TAXONOMY_FILE, and
+// the database is configured with the DB_* environment variables, as for the
+// provider service.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/geo"
+	"github.com/sydney-health-clone/backend/services/provider/internal/nppes"
+	"github.com/sydney-health-clone/backend/services/provider/internal/taxonomy"
+	"github.com/sydney-health-clone/backend/services/provider/repository"
+)
+
+var (
+	batchSize = flag.Int("batch", nppes.DefaultBatchSize, "Rows written per transaction")
+	dryRun    = flag.Bool("dry-run", false, "Read and check the file without writing anything")
+	progress  = flag.Duration("progress", nppes.DefaultProgress, "How often to log progress")
+	restart   = flag.Bool("restart", false, "Import the file from the start even if it was imported before")
+	timeout   = flag.Duration("timeout", 12*time.Hour, "Time allowed for the import")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: nppes-import [flags] npidata_pfile.csv\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open registry file: %v", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatalf("Failed to read registry file: %v", err)
+	}
+
+	places, err := geo.OpenGazetteer(os.Getenv("PROVIDER_GEO_DATA"))
+	if err != nil {
+		log.Fatalf("Failed to load ZIP centroids: %v", err)
+	}
+	codes, err := taxonomy.Open(os.Getenv("PROVIDER_TAXONOMY_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load provider taxonomy: %v", err)
+	}
+
+	var store nppes.Store
+	if !*dryRun {
+		db, err := database.InitDB()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+		store = repository.NewNPPESStore(db)
+	}
+
+	importer := nppes.NewImporter(store, places, codes, nppes.Options{
+		BatchSize: *batchSize,
+		Progress:  *progress,
+		Restart:   *restart,
+		DryRun:    *dryRun,
+	})
+
+	// Interrupting stops after the batch being written, which the next run
+	// resumes after
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cp, err := importer.Import(ctx, f, filepath.Base(path), info.Size())
+	if errors.Is(err, nppes.ErrImported) {
+		log.Printf("Nothing to do: %v; use -restart to import it again", err)
+		return
+	}
+	if err != nil {
+		if cp != nil && !*dryRun {
+			log.Printf("Stopped at byte %d of %d; run again to resume", cp.Offset, cp.Size)
+		}
+		log.Fatalf("Failed to import %s: %v", path, err)
+	}
+	log.Printf("Imported %s: %d rows, %d upserted, %d unchanged, %d deactivated, %d invalid",
+		path, cp.Rows, cp.Upserted, cp.Unchanged, cp.Deactivated, cp.Invalid)
+	if *dryRun {
+		log.Printf("Dry run; nothing was written")
+	}
+}
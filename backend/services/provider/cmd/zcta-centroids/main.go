@@ -0,0 +1,175 @@
+// Command zcta-centroids builds the ZIP centroid table bundled with the
+// provider service's geo package.
+//
+//	zcta-centroids -places US.txt 2023_Gaz_zcta_national.txt > zip_centroids.csv.gz
+//
+// Coordinates are the internal point of each ZIP Code Tabulation Area in the
+// Census Bureau's national ZCTA gazetteer file. City and state names come
+// from the GeoNames US postal code file given with -places; ZCTAs it doesn't
+// name are kept for ZIP searches but can't be found by city. The table is
+// written gzipped, as geo embeds it, unless -gzip=false.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	places   = flag.String("places", "", "GeoNames US postal code file naming each ZIP's city and state")
+	compress = flag.Bool("gzip", true, "Write the table gzipped")
+)
+
+// place is a ZIP's USPS city and state abbreviation
+type place struct {
+	city  string
+	state string
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: zcta-centroids [flags] zcta_national.txt > zip_centroids.csv.gz\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *places == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	names, err := readPlaces(*places)
+	if err != nil {
+		log.Fatalf("Failed to read places: %v", err)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to open gazetteer: %v", err)
+	}
+	defer f.Close()
+	rows, err := readGazetteer(f, names)
+	if err != nil {
+		log.Fatalf("Failed to read gazetteer: %v", err)
+	}
+
+	var out io.WriteCloser = nopCloser{os.Stdout}
+	if *compress {
+		out = gzip.NewWriter(os.Stdout)
+	}
+	if err := writeTable(out, rows, filepath.Base(flag.Arg(0))); err != nil {
+		log.Fatalf("Failed to write table: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("Failed to write table: %v", err)
+	}
+	log.Printf("Wrote %d ZIP centroids", len(rows))
+}
+
+// nopCloser leaves stdout open when the table isn't gzipped
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// readPlaces reads the tab-separated GeoNames postal code file: country,
+// postal code, place name, state name, state code, then county and
+// coordinates, which the gazetteer's take the place of
+func readPlaces(path string) (map[string]place, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names := make(map[string]place)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 || fields[0] != "US" {
+			continue
+		}
+		names[fields[1]] = place{city: fields[2], state: fields[4]}
+	}
+	return names, scanner.Err()
+}
+
+// readGazetteer reads the tab-separated ZCTA gazetteer, finding its columns
+// by header name since their order has changed between vintages
+func readGazetteer(r io.Reader, names map[string]place) ([][]string, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty file")
+	}
+	columns := make(map[string]int)
+	for i, name := range strings.Split(scanner.Text(), "\t") {
+		columns[strings.TrimSpace(name)] = i
+	}
+	zipCol, ok1 := columns["GEOID"]
+	latCol, ok2 := columns["INTPTLAT"]
+	lngCol, ok3 := columns["INTPTLONG"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("missing GEOID, INTPTLAT or INTPTLONG column")
+	}
+
+	var rows [][]string
+	line := 1
+	for scanner.Scan() {
+		line++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) <= zipCol || len(fields) <= latCol || len(fields) <= lngCol {
+			return nil, fmt.Errorf("line %d: too few columns", line)
+		}
+		zip := strings.TrimSpace(fields[zipCol])
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(fields[latCol]), 64)
+		lng, lngErr := strconv.ParseFloat(strings.TrimSpace(fields[lngCol]), 64)
+		if len(zip) != 5 || latErr != nil || lngErr != nil {
+			return nil, fmt.Errorf("line %d: invalid ZCTA %q", line, zip)
+		}
+		name := names[zip]
+		rows = append(rows, []string{
+			zip,
+			name.city,
+			name.state,
+			strconv.FormatFloat(lat, 'f', 6, 64),
+			strconv.FormatFloat(lng, 'f', 6, 64),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return rows, nil
+}
+
+// writeTable writes the zip,city,state,latitude,longitude table geo reads
+func writeTable(w io.Writer, rows [][]string, source string) error {
+	header := fmt.Sprintf(`# ZIP code centroids: the internal point of each Census ZIP Code Tabulation
+# Area, with the USPS city name from GeoNames (CC BY 4.0). City centroids are
+# the mean of their ZIPs. Generated by zcta-centroids from %s on %s.
+`, source, time.Now().UTC().Format("2006-01-02"))
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"zip", "city", "state", "latitude", "longitude"}); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
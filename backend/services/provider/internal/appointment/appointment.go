@@ -0,0 +1,572 @@
+// Package appointment books members into providers' appointment slots.
+//
+// Slots are cut from each location's weekly schedule. A member first holds
+// an open slot, which keeps it from everyone else for HoldDuration, then
+// books it. Holding, booking and rescheduling all reserve the slot in the
+// store, which refuses any that overlap another appointment the provider
+// has booked or still holds, so two members can't take the same time.
+// Bookings, reschedules and cancellations are published as
+// AppointmentUpdate events. Each is queued in the store with the change it
+// reports and published from there by Relay, so an event is never lost
+// because the broker was down when the change was saved.
+package appointment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/schedule"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// HoldDuration is how long a held slot is kept for the member
+const HoldDuration = 10 * time.Minute
+
+// Availability windows: the default, and the longest one request may ask for
+const (
+	DefaultWindow = 14 * 24 * time.Hour
+	MaxWindow     = 31 * 24 * time.Hour
+)
+
+// Page sizes for List
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	// ErrNotFound is returned for an unknown appointment ID
+	ErrNotFound = errors.New("appointment not found")
+	// ErrLocationNotFound is returned for a location the provider doesn't have
+	ErrLocationNotFound = errors.New("provider location not found")
+	// ErrInvalid is returned, wrapped with the problem, for requests that
+	// can't be accepted
+	ErrInvalid = errors.New("invalid appointment request")
+	// ErrSlotTaken is returned when another appointment already has the slot
+	ErrSlotTaken = errors.New("slot is no longer available")
+	// ErrHoldExpired is returned for booking a hold that has run out
+	ErrHoldExpired = errors.New("hold has expired")
+	// ErrInvalidTransition is returned for a change the appointment's status
+	// doesn't allow
+	ErrInvalidTransition = errors.New("invalid appointment change")
+	// ErrConflict is returned when the appointment changed while it was
+	// being updated
+	ErrConflict = errors.New("appointment was changed concurrently")
+)
+
+// Store keeps appointments
+type Store interface {
+	// Reserve saves a new held appointment, or returns ErrSlotTaken if it
+	// overlaps another of the provider's appointments active at now
+	Reserve(ctx context.Context, a *pb.Appointment, now time.Time) error
+	// Get returns the appointment, or ErrNotFound
+	Get(ctx context.Context, id string) (*pb.Appointment, error)
+	// List returns a page of the member's appointments starting at or after
+	// from, soonest first, and how many there are in all. Holds that have
+	// run out by now count as expired; no statuses means every status.
+	List(ctx context.Context, memberID string, statuses []pb.AppointmentStatus, from, now time.Time, offset, limit int) ([]*pb.Appointment, int, error)
+	// Active returns the provider's appointments active at now that overlap
+	// the time from from to to
+	Active(ctx context.Context, providerID string, from, to, now time.Time) ([]*pb.Appointment, error)
+	// Update saves a, whose version has been raised by one, and queues event
+	// with it when it isn't nil; neither is saved without the other. It
+	// returns ErrConflict if the stored version isn't the one before, and
+	// ErrSlotTaken if a is active and overlaps another of the provider's
+	// appointments active at now.
+	Update(ctx context.Context, a *pb.Appointment, now time.Time, event *kafka.AppointmentUpdate) error
+	// Unsent returns up to limit queued events not yet published, oldest
+	// first
+	Unsent(ctx context.Context, limit int) ([]Queued, error)
+	// MarkSent records that a queued event was published
+	MarkSent(ctx context.Context, id int64) error
+}
+
+// Queued is an event waiting in the store to be published
+type Queued struct {
+	ID    int64
+	Event kafka.AppointmentUpdate
+}
+
+// Directory finds providers and their locations; satisfied by
+// *directory.Directory
+type Directory interface {
+	Provider(ctx context.Context, id string) (*pb.Provider, error)
+}
+
+// Publisher sends events; satisfied by *kafka.Producer
+type Publisher interface {
+	SendMessage(ctx context.Context, key string, value interface{}) error
+}
+
+// Scheduler finds open slots and holds, books, reschedules and cancels
+// appointments
+type Scheduler struct {
+	providers Directory
+	store     Store
+	events    Publisher
+	now       func() time.Time
+	// queued wakes Relay when a change queues an event
+	queued chan struct{}
+}
+
+// NewScheduler creates a scheduler over store. events may be nil when no
+// topic is configured, in which case no events are queued.
+func NewScheduler(providers Directory, store Store, events Publisher) *Scheduler {
+	return &Scheduler{providers: providers, store: store, events: events, now: time.Now, queued: make(chan struct{}, 1)}
+}
+
+// Availability returns the provider's open slots, earliest first, at one
+// location or all of them
+func (s *Scheduler) Availability(ctx context.Context, req *pb.GetAvailabilityRequest) ([]*pb.AppointmentSlot, error) {
+	if req.ProviderId == "" {
+		return nil, fmt.Errorf("%w: provider_id is required", ErrInvalid)
+	}
+	now := s.now()
+	from := now
+	if req.StartTime != nil && req.StartTime.AsTime().After(now) {
+		from = req.StartTime.AsTime()
+	}
+	to := from.Add(DefaultWindow)
+	if req.EndTime != nil {
+		to = req.EndTime.AsTime()
+	}
+	switch {
+	case !to.After(from):
+		return nil, fmt.Errorf("%w: end_time must be after start_time and now", ErrInvalid)
+	case to.Sub(from) > MaxWindow:
+		return nil, fmt.Errorf("%w: availability can be asked for at most %d days at a time", ErrInvalid, int(MaxWindow.Hours()/24))
+	}
+
+	provider, err := s.providers.Provider(ctx, req.ProviderId)
+	if err != nil {
+		return nil, err
+	}
+	locations := provider.Locations
+	if req.LocationId != "" {
+		l, err := location(provider, req.LocationId)
+		if err != nil {
+			return nil, err
+		}
+		locations = []*pb.ProviderLocation{l}
+	}
+
+	taken, err := s.store.Active(ctx, provider.ProviderId, from, to, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read booked appointments: %w", err)
+	}
+
+	var open []*pb.AppointmentSlot
+	for _, l := range locations {
+		slots, err := schedule.Slots(l, from, to)
+		if err != nil {
+			// One location's bad schedule shouldn't hide the others
+			log.Printf("Skipping the schedule of provider %s: %v", provider.ProviderId, err)
+			continue
+		}
+		for _, slot := range slots {
+			if free(slot, taken) {
+				open = append(open, &pb.AppointmentSlot{
+					LocationId: slot.LocationID,
+					StartTime:  timestamppb.New(slot.Start),
+					EndTime:    timestamppb.New(slot.End),
+				})
+			}
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].StartTime.AsTime().Before(open[j].StartTime.AsTime())
+	})
+	return open, nil
+}
+
+// Hold reserves an open slot for the member until HoldDuration from now
+func (s *Scheduler) Hold(ctx context.Context, req *pb.HoldAppointmentRequest) (*pb.Appointment, error) {
+	switch {
+	case req.MemberId == "":
+		return nil, fmt.Errorf("%w: member_id is required", ErrInvalid)
+	case req.ProviderId == "" || req.LocationId == "":
+		return nil, fmt.Errorf("%w: provider_id and location_id are required", ErrInvalid)
+	case req.StartTime == nil:
+		return nil, fmt.Errorf("%w: start_time is required", ErrInvalid)
+	}
+
+	now := s.now()
+	provider, err := s.providers.Provider(ctx, req.ProviderId)
+	if err != nil {
+		return nil, err
+	}
+	l, slot, err := s.slot(provider, req.LocationId, req.StartTime.AsTime(), now)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &pb.Appointment{
+		AppointmentId: uuid.NewString(),
+		MemberId:      req.MemberId,
+		ProviderId:    provider.ProviderId,
+		LocationId:    l.LocationId,
+		StartTime:     timestamppb.New(slot.Start),
+		EndTime:       timestamppb.New(slot.End),
+		TimeZone:      l.Schedule.TimeZone,
+		Status:        pb.AppointmentStatus_APPOINTMENT_STATUS_HELD,
+		HoldExpiresAt: timestamppb.New(now.Add(HoldDuration)),
+		VisitReason:   strings.TrimSpace(req.VisitReason),
+		CreatedAt:     timestamppb.New(now),
+		UpdatedAt:     timestamppb.New(now),
+		Version:       1,
+	}
+	if err := s.store.Reserve(ctx, a, now); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Book confirms the member's hold
+func (s *Scheduler) Book(ctx context.Context, req *pb.BookAppointmentRequest) (*pb.Appointment, error) {
+	a, err := s.owned(ctx, req.AppointmentId, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case a.Status == pb.AppointmentStatus_APPOINTMENT_STATUS_EXPIRED:
+		return nil, fmt.Errorf("%w; hold the slot again", ErrHoldExpired)
+	case a.Status != pb.AppointmentStatus_APPOINTMENT_STATUS_HELD:
+		return nil, fmt.Errorf("%w: %s appointments can't be booked", ErrInvalidTransition, strings.ToLower(StatusName(a.Status)))
+	}
+
+	updated := proto.Clone(a).(*pb.Appointment)
+	updated.Status = pb.AppointmentStatus_APPOINTMENT_STATUS_BOOKED
+	updated.HoldExpiresAt = nil
+	if reason := strings.TrimSpace(req.VisitReason); reason != "" {
+		updated.VisitReason = reason
+	}
+	if err := s.change(ctx, updated, "BOOKED", nil); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Reschedule moves the member's booked appointment to another open slot, at
+// the same location unless another is given
+func (s *Scheduler) Reschedule(ctx context.Context, req *pb.RescheduleAppointmentRequest) (*pb.Appointment, error) {
+	if req.StartTime == nil {
+		return nil, fmt.Errorf("%w: start_time is required", ErrInvalid)
+	}
+	a, err := s.owned(ctx, req.AppointmentId, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if err := changeable(a, now, "rescheduled"); err != nil {
+		return nil, err
+	}
+
+	locationID := req.LocationId
+	if locationID == "" {
+		locationID = a.LocationId
+	}
+	start := req.StartTime.AsTime()
+	if locationID == a.LocationId && start.Equal(a.StartTime.AsTime()) {
+		return nil, fmt.Errorf("%w: the appointment is already at that time", ErrInvalid)
+	}
+	provider, err := s.providers.Provider(ctx, a.ProviderId)
+	if err != nil {
+		return nil, err
+	}
+	l, slot, err := s.slot(provider, locationID, start, now)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := a.StartTime
+	updated := proto.Clone(a).(*pb.Appointment)
+	updated.LocationId = l.LocationId
+	updated.StartTime = timestamppb.New(slot.Start)
+	updated.EndTime = timestamppb.New(slot.End)
+	updated.TimeZone = l.Schedule.TimeZone
+	if err := s.change(ctx, updated, "RESCHEDULED", previous); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Cancel cancels the member's booked appointment, or releases their hold
+func (s *Scheduler) Cancel(ctx context.Context, req *pb.CancelAppointmentRequest) (*pb.Appointment, error) {
+	a, err := s.owned(ctx, req.AppointmentId, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := proto.Clone(a).(*pb.Appointment)
+	if a.Status == pb.AppointmentStatus_APPOINTMENT_STATUS_HELD {
+		updated.Status = pb.AppointmentStatus_APPOINTMENT_STATUS_EXPIRED
+		updated.HoldExpiresAt = nil
+		if err := s.change(ctx, updated, "", nil); err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+
+	if err := changeable(a, s.now(), "cancelled"); err != nil {
+		return nil, err
+	}
+	updated.Status = pb.AppointmentStatus_APPOINTMENT_STATUS_CANCELLED
+	updated.CancellationReason = strings.TrimSpace(req.Reason)
+	if err := s.change(ctx, updated, "CANCELLED", nil); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Get returns one appointment
+func (s *Scheduler) Get(ctx context.Context, id string) (*pb.Appointment, error) {
+	a, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return settle(a, s.now()), nil
+}
+
+// List returns a page of the member's appointments starting at or after
+// from, soonest first. Page tokens are the offset of the page's first
+// appointment.
+func (s *Scheduler) List(ctx context.Context, memberID string, statuses []pb.AppointmentStatus, from time.Time, page *pb.PageRequest) ([]*pb.Appointment, *pb.PageResponse, error) {
+	size, offset := DefaultPageSize, 0
+	if page != nil {
+		if page.PageSize > 0 {
+			size = min(int(page.PageSize), MaxPageSize)
+		}
+		if page.PageToken != "" {
+			n, err := strconv.Atoi(page.PageToken)
+			if err != nil || n < 0 {
+				return nil, nil, fmt.Errorf("%w: invalid page token", ErrInvalid)
+			}
+			offset = n
+		}
+	}
+
+	now := s.now()
+	appointments, total, err := s.store.List(ctx, memberID, statuses, from, now, offset, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, a := range appointments {
+		settle(a, now)
+	}
+	resp := &pb.PageResponse{TotalCount: int32(total)}
+	if end := offset + len(appointments); end < total {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return appointments, resp, nil
+}
+
+// Export renders the member's booked and cancelled appointments as an
+// iCalendar file. Cancelled ones are kept so calendars subscribed to the
+// export remove them.
+func (s *Scheduler) Export(ctx context.Context, memberID string) ([]byte, error) {
+	statuses := []pb.AppointmentStatus{
+		pb.AppointmentStatus_APPOINTMENT_STATUS_BOOKED,
+		pb.AppointmentStatus_APPOINTMENT_STATUS_CANCELLED,
+	}
+	now := s.now()
+	var appointments []*pb.Appointment
+	for offset := 0; ; offset += MaxPageSize {
+		page, total, err := s.store.List(ctx, memberID, statuses, time.Time{}, now, offset, MaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, page...)
+		if len(page) == 0 || offset+len(page) >= total {
+			break
+		}
+	}
+
+	providers := make(map[string]*pb.Provider)
+	for _, a := range appointments {
+		if _, ok := providers[a.ProviderId]; ok {
+			continue
+		}
+		p, err := s.providers.Provider(ctx, a.ProviderId)
+		if err != nil {
+			// Providers who have left the directory are shown without
+			// their details
+			log.Printf("Exporting appointment %s without its provider: %v", a.AppointmentId, err)
+		}
+		providers[a.ProviderId] = p
+	}
+	return Calendar(appointments, providers, now), nil
+}
+
+// owned returns the appointment if it belongs to the member
+func (s *Scheduler) owned(ctx context.Context, id, memberID string) (*pb.Appointment, error) {
+	if id == "" || memberID == "" {
+		return nil, fmt.Errorf("%w: appointment_id and member_id are required", ErrInvalid)
+	}
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.MemberId != memberID {
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+// slot finds the provider's location and the open slot there starting at
+// start
+func (s *Scheduler) slot(provider *pb.Provider, locationID string, start, now time.Time) (*pb.ProviderLocation, schedule.Slot, error) {
+	l, err := location(provider, locationID)
+	if err != nil {
+		return nil, schedule.Slot{}, err
+	}
+	if l.Schedule == nil {
+		return nil, schedule.Slot{}, fmt.Errorf("%w: location %s doesn't offer appointments", ErrInvalid, l.LocationId)
+	}
+	if !start.After(now) {
+		return nil, schedule.Slot{}, fmt.Errorf("%w: start_time has passed", ErrInvalid)
+	}
+	slot, ok, err := schedule.SlotAt(l, start)
+	if err != nil {
+		return nil, schedule.Slot{}, err
+	}
+	if !ok {
+		return nil, schedule.Slot{}, fmt.Errorf("%w: no appointment slot starts at %s", ErrInvalid, start.Format(time.RFC3339))
+	}
+	return l, slot, nil
+}
+
+// change saves a change already applied to a, queueing the AppointmentUpdate
+// for it with the action unless that is empty
+func (s *Scheduler) change(ctx context.Context, a *pb.Appointment, action string, previousStart *timestamppb.Timestamp) error {
+	now := s.now()
+	a.Version++
+	a.UpdatedAt = timestamppb.New(now)
+
+	var event *kafka.AppointmentUpdate
+	if action != "" && s.events != nil {
+		event = update(a, action, previousStart)
+	}
+	if err := s.store.Update(ctx, a, now, event); err != nil {
+		return err
+	}
+	if event != nil {
+		select {
+		case s.queued <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// update is the AppointmentUpdate for a's latest change
+func update(a *pb.Appointment, action string, previousStart *timestamppb.Timestamp) *kafka.AppointmentUpdate {
+	update := &kafka.AppointmentUpdate{
+		AppointmentID: a.AppointmentId,
+		Version:       a.Version,
+		Action:        action,
+		MemberID:      a.MemberId,
+		ProviderID:    a.ProviderId,
+		LocationID:    a.LocationId,
+		StartTime:     a.StartTime.AsTime().Format(time.RFC3339),
+		EndTime:       a.EndTime.AsTime().Format(time.RFC3339),
+		TimeZone:      a.TimeZone,
+		Reason:        a.CancellationReason,
+		Timestamp:     a.UpdatedAt.AsTime().Unix(),
+	}
+	if previousStart != nil {
+		update.PreviousStartTime = previousStart.AsTime().Format(time.RFC3339)
+	}
+	return update
+}
+
+// relayBatch is how many queued events Relay reads at a time
+const relayBatch = 100
+
+// Relay publishes queued events, oldest first, as changes queue them and
+// every interval, until ctx is done. An event that can't be published stops
+// the round and is tried again on the next, so events go out in order. One
+// published but not marked sent is published again; consumers ignore
+// versions they have already seen.
+func (s *Scheduler) Relay(ctx context.Context, interval time.Duration) {
+	if s.events == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.relay(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.queued:
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes what is queued, stopping at the first failure
+func (s *Scheduler) relay(ctx context.Context) {
+	for {
+		queued, err := s.store.Unsent(ctx, relayBatch)
+		if err != nil {
+			log.Printf("Failed to read queued appointment updates: %v", err)
+			return
+		}
+		for _, q := range queued {
+			if err := s.events.SendMessage(ctx, q.Event.AppointmentID, q.Event); err != nil {
+				log.Printf("Failed to publish update of appointment %s version %d: %v", q.Event.AppointmentID, q.Event.Version, err)
+				return
+			}
+			if err := s.store.MarkSent(ctx, q.ID); err != nil {
+				log.Printf("Failed to mark update of appointment %s version %d sent: %v", q.Event.AppointmentID, q.Event.Version, err)
+				return
+			}
+		}
+		if len(queued) < relayBatch {
+			return
+		}
+	}
+}
+
+// changeable checks that a booked appointment can still be changed
+func changeable(a *pb.Appointment, now time.Time, change string) error {
+	if a.Status != pb.AppointmentStatus_APPOINTMENT_STATUS_BOOKED {
+		return fmt.Errorf("%w: %s appointments can't be %s", ErrInvalidTransition, strings.ToLower(StatusName(a.Status)), change)
+	}
+	if !now.Before(a.StartTime.AsTime()) {
+		return fmt.Errorf("%w: appointments can't be %s once they start", ErrInvalidTransition, change)
+	}
+	return nil
+}
+
+// location returns the provider's location with the ID
+func location(provider *pb.Provider, id string) (*pb.ProviderLocation, error) {
+	for _, l := range provider.Locations {
+		if l.LocationId == id {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrLocationNotFound, id)
+}
+
+// free reports whether no taken appointment overlaps the slot
+func free(slot schedule.Slot, taken []*pb.Appointment) bool {
+	for _, a := range taken {
+		if overlaps(a, slot.Start, slot.End) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package appointment
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// CalendarContentType is the content type of Calendar's output
+const CalendarContentType = "text/calendar; charset=utf-8"
+
+// icalTime is the UTC date-time format of RFC 5545
+const icalTime = "20060102T150405Z"
+
+// maxLine is the longest a content line may be, in octets, before folding
+const maxLine = 75
+
+// Calendar renders appointments as an RFC 5545 iCalendar file, one event per
+// appointment. Events keep the appointment ID as their UID and its version as
+// their sequence, so calendar apps update rescheduled appointments and
+// cancel cancelled ones in place. providers gives each appointment's
+// provider, nil for ones no longer in the directory.
+func Calendar(appointments []*pb.Appointment, providers map[string]*pb.Provider, stamp time.Time) []byte {
+	var b bytes.Buffer
+	line := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Sydney Health//Appointments//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", "Appointments")
+	for _, a := range appointments {
+		p := providers[a.ProviderId]
+		line("BEGIN", "VEVENT")
+		line("UID", a.AppointmentId+"@sydney-health")
+		line("SEQUENCE", fmt.Sprint(a.Version))
+		line("DTSTAMP", stamp.UTC().Format(icalTime))
+		line("DTSTART", a.StartTime.AsTime().UTC().Format(icalTime))
+		line("DTEND", a.EndTime.AsTime().UTC().Format(icalTime))
+		if a.UpdatedAt != nil {
+			line("LAST-MODIFIED", a.UpdatedAt.AsTime().UTC().Format(icalTime))
+		}
+		line("SUMMARY", escape("Appointment with "+providerName(p)))
+		var phone string
+		if l := providerLocation(p, a.LocationId); l != nil {
+			line("LOCATION", escape(address(l.Address)))
+			phone = l.Phone
+		}
+		if text := description(a, phone); text != "" {
+			line("DESCRIPTION", escape(text))
+		}
+		if a.Status == pb.AppointmentStatus_APPOINTMENT_STATUS_CANCELLED {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+// writeLine writes a content line ending in CRLF, folding it onto
+// continuation lines starting with a space at maxLine octets without
+// splitting a character
+func writeLine(b *bytes.Buffer, s string) {
+	limit := maxLine
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts toward the continuation line
+		limit = maxLine - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func description(a *pb.Appointment, phone string) string {
+	var parts []string
+	if a.VisitReason != "" {
+		parts = append(parts, "Reason: "+a.VisitReason)
+	}
+	if phone != "" {
+		parts = append(parts, "Office phone: "+phone)
+	}
+	if a.CancellationReason != "" {
+		parts = append(parts, "Cancelled: "+a.CancellationReason)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func providerName(p *pb.Provider) string {
+	if p == nil {
+		return "your provider"
+	}
+	if name := strings.TrimSpace(p.FirstName + " " + p.LastName); name != "" {
+		return name
+	}
+	return p.PracticeName
+}
+
+func providerLocation(p *pb.Provider, id string) *pb.ProviderLocation {
+	if p == nil {
+		return nil
+	}
+	for _, l := range p.Locations {
+		if l.LocationId == id {
+			return l
+		}
+	}
+	return nil
+}
+
+func address(a *pb.Address) string {
+	if a == nil {
+		return ""
+	}
+	var parts []string
+	for _, part := range []string{a.Street1, a.Street2, a.City, strings.TrimSpace(a.State + " " + a.ZipCode)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,45 @@
+package appointment
+
+import (
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// StatusName returns the short name used in storage, e.g. "BOOKED"
+func StatusName(status pb.AppointmentStatus) string {
+	return strings.TrimPrefix(status.String(), "APPOINTMENT_STATUS_")
+}
+
+// ParseStatus accepts either the short or the full enum name
+func ParseStatus(name string) pb.AppointmentStatus {
+	value := pb.AppointmentStatus_value["APPOINTMENT_STATUS_"+strings.TrimPrefix(strings.ToUpper(name), "APPOINTMENT_STATUS_")]
+	return pb.AppointmentStatus(value)
+}
+
+// Active reports whether an appointment in status keeps its slot at now:
+// booked, or held until its hold expires
+func Active(a *pb.Appointment, now time.Time) bool {
+	switch a.Status {
+	case pb.AppointmentStatus_APPOINTMENT_STATUS_BOOKED:
+		return true
+	case pb.AppointmentStatus_APPOINTMENT_STATUS_HELD:
+		return a.HoldExpiresAt != nil && now.Before(a.HoldExpiresAt.AsTime())
+	}
+	return false
+}
+
+// settle shows a hold that has run out as expired. Holds are only marked
+// expired in storage when their slot is taken again.
+func settle(a *pb.Appointment, now time.Time) *pb.Appointment {
+	if a.Status == pb.AppointmentStatus_APPOINTMENT_STATUS_HELD && !Active(a, now) {
+		a.Status = pb.AppointmentStatus_APPOINTMENT_STATUS_EXPIRED
+	}
+	return a
+}
+
+// overlaps reports whether an appointment overlaps the time from start to end
+func overlaps(a *pb.Appointment, start, end time.Time) bool {
+	return a.StartTime.AsTime().Before(end) && start.Before(a.EndTime.AsTime())
+}
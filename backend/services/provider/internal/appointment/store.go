@@ -0,0 +1,155 @@
+package appointment
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// MemoryStore is an in-process Store for demo mode
+type MemoryStore struct {
+	mu           sync.Mutex
+	appointments map[string]*pb.Appointment
+	// outbox holds queued events until they're sent
+	outbox []Queued
+	nextID int64
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{appointments: make(map[string]*pb.Appointment)}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, a *pb.Appointment, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(a, now) {
+		return ErrSlotTaken
+	}
+	s.appointments[a.AppointmentId] = proto.Clone(a).(*pb.Appointment)
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*pb.Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.appointments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return proto.Clone(a).(*pb.Appointment), nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, memberID string, statuses []pb.AppointmentStatus, from, now time.Time, offset, limit int) ([]*pb.Appointment, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*pb.Appointment
+	for _, a := range s.appointments {
+		if a.MemberId != memberID || a.StartTime.AsTime().Before(from) {
+			continue
+		}
+		a = settle(proto.Clone(a).(*pb.Appointment), now)
+		if len(statuses) > 0 && !hasStatus(statuses, a.Status) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !a.StartTime.AsTime().Equal(b.StartTime.AsTime()) {
+			return a.StartTime.AsTime().Before(b.StartTime.AsTime())
+		}
+		return a.AppointmentId < b.AppointmentId
+	})
+
+	total := len(matched)
+	if offset >= total {
+		return nil, total, nil
+	}
+	return matched[offset:min(offset+limit, total)], total, nil
+}
+
+func (s *MemoryStore) Active(ctx context.Context, providerID string, from, to, now time.Time) ([]*pb.Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var active []*pb.Appointment
+	for _, a := range s.appointments {
+		if a.ProviderId == providerID && Active(a, now) && overlaps(a, from, to) {
+			active = append(active, proto.Clone(a).(*pb.Appointment))
+		}
+	}
+	return active, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, a *pb.Appointment, now time.Time, event *kafka.AppointmentUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.appointments[a.AppointmentId]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != a.Version-1 {
+		return ErrConflict
+	}
+	if Active(a, now) && s.taken(a, now) {
+		return ErrSlotTaken
+	}
+	s.appointments[a.AppointmentId] = proto.Clone(a).(*pb.Appointment)
+	if event != nil {
+		s.nextID++
+		s.outbox = append(s.outbox, Queued{ID: s.nextID, Event: *event})
+	}
+	return nil
+}
+
+func (s *MemoryStore) Unsent(ctx context.Context, limit int) ([]Queued, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Queued(nil), s.outbox[:min(limit, len(s.outbox))]...), nil
+}
+
+func (s *MemoryStore) MarkSent(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, q := range s.outbox {
+		if q.ID == id {
+			s.outbox = append(s.outbox[:i], s.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// taken reports whether another of the provider's appointments active at now
+// overlaps a
+func (s *MemoryStore) taken(a *pb.Appointment, now time.Time) bool {
+	for id, other := range s.appointments {
+		if id != a.AppointmentId && other.ProviderId == a.ProviderId && Active(other, now) &&
+			overlaps(other, a.StartTime.AsTime(), a.EndTime.AsTime()) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasStatus(statuses []pb.AppointmentStatus, status pb.AppointmentStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
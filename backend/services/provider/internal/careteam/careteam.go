@@ -0,0 +1,440 @@
+// Package careteam keeps the providers a member counts on: their designated
+// primary care provider, the providers they have saved as favorites, and
+// those they have seen recently.
+//
+// Some HMO plans route care through a designated primary care provider.
+// Assignments are effective-dated: assigning a new provider ends the one in
+// effect the day before the new one starts, and replaces any that hadn't
+// started yet. The provider must practice primary care and, under a plan
+// that uses networks, be in network at the location on the effective date.
+// Recently seen providers are read from the member's claims; callers with
+// restricted access don't see those from claims for sensitive services.
+package careteam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/network"
+	"github.com/sydney-health-clone/backend/shared/access"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// PrimaryCareSpecialties are the specialties a primary care provider must
+// practice. Pediatricians act as children's primary care providers.
+var PrimaryCareSpecialties = []string{"Primary Care", "Pediatrics"}
+
+// RecentMonths is how far back claims count a provider as recently seen, and
+// MaxRecent how many recently seen providers the care team lists
+const (
+	RecentMonths = 18
+	MaxRecent    = 10
+)
+
+// MaxNoteLength is the longest a favorite's note may be, in characters
+const MaxNoteLength = 500
+
+var (
+	// ErrNotFound is returned for removing a provider that isn't a favorite
+	ErrNotFound = errors.New("provider is not a favorite")
+	// ErrLocationNotFound is returned for a location the provider doesn't have
+	ErrLocationNotFound = errors.New("provider location not found")
+	// ErrInvalid is returned, wrapped with the problem, for requests that
+	// can't be accepted
+	ErrInvalid = errors.New("invalid care team request")
+	// ErrNotPrimaryCare is returned for assigning a provider who doesn't
+	// practice primary care
+	ErrNotPrimaryCare = errors.New("provider does not practice primary care")
+	// ErrOutOfNetwork is returned for assigning a provider who is out of the
+	// member's network at the location on the effective date
+	ErrOutOfNetwork = errors.New("provider is not in network for the member's plan")
+	// ErrConflict is returned when the member's assignments changed while a
+	// new one was being saved
+	ErrConflict = errors.New("primary care provider was assigned concurrently")
+)
+
+// Seen is a provider on a member's claims: the latest service date and how
+// many claims there are
+type Seen struct {
+	ProviderID string
+	LastSeen   time.Time
+	Claims     int
+}
+
+// Visit is one claim from a provider: when the services were and their
+// CPT/HCPCS codes
+type Visit struct {
+	ClaimID      string
+	ProviderID   string
+	ServiceDate  time.Time
+	ServiceCodes []string
+}
+
+// sensitive reports whether any service on the visit is in a sensitive
+// category, as access.FilterClaims decides for claims
+func (v Visit) sensitive() bool {
+	for _, code := range v.ServiceCodes {
+		if access.SensitiveCategory(code) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps members' care teams
+type Store interface {
+	// RequiresPCP reports whether the plan requires a designated primary
+	// care provider
+	RequiresPCP(ctx context.Context, planID string) (bool, error)
+	// Assignments returns the member's primary care assignments, earliest
+	// first
+	Assignments(ctx context.Context, memberID string) ([]*pb.PcpAssignment, error)
+	// Assign saves a, ending the member's assignment in effect on a's
+	// effective date the day before and removing any starting on or after
+	// it. It returns ErrConflict if another assignment was saved at the same
+	// time.
+	Assign(ctx context.Context, a *pb.PcpAssignment) error
+	// Favorites returns the member's favorites, most recently saved first
+	Favorites(ctx context.Context, memberID string) ([]*pb.FavoriteProvider, error)
+	// SaveFavorite adds the favorite or, when the member already has the
+	// provider as one, changes its note and updated_at and returns it with
+	// the created_at it was first saved at
+	SaveFavorite(ctx context.Context, memberID string, f *pb.FavoriteProvider) (*pb.FavoriteProvider, error)
+	// RemoveFavorite removes the favorite, or returns ErrNotFound
+	RemoveFavorite(ctx context.Context, memberID, providerID string) error
+	// Visits returns the member's claims with a provider for services on or
+	// after since. Denied claims don't count.
+	Visits(ctx context.Context, memberID string, since time.Time) ([]Visit, error)
+}
+
+// Directory finds providers; satisfied by *directory.Directory
+type Directory interface {
+	Provider(ctx context.Context, id string) (*pb.Provider, error)
+}
+
+// Plans finds the networks members' plans use; satisfied by
+// *network.Checker
+type Plans interface {
+	Plan(ctx context.Context, memberID string, coverageType pb.CoverageType, day time.Time) (*network.Plan, error)
+}
+
+// Specialties names the specialties covering a taxonomy code; satisfied by
+// *taxonomy.Taxonomy
+type Specialties interface {
+	Covering(code string) []string
+}
+
+// Roster reads and changes members' care teams
+type Roster struct {
+	providers   Directory
+	plans       Plans
+	specialties Specialties
+	store       Store
+	now         func() time.Time
+}
+
+// NewRoster creates a roster over store
+func NewRoster(providers Directory, plans Plans, specialties Specialties, store Store) *Roster {
+	return &Roster{providers: providers, plans: plans, specialties: specialties, store: store, now: time.Now}
+}
+
+// Get returns the member's care team, with the details of each provider
+// still in the directory, as a caller with the given access level may see it
+func (r *Roster) Get(ctx context.Context, memberID string, level pb.AccessLevel) (*pb.GetCareTeamResponse, error) {
+	if memberID == "" {
+		return nil, fmt.Errorf("%w: member_id is required", ErrInvalid)
+	}
+	today := coverage.Day(r.now())
+	team := &pb.GetCareTeamResponse{}
+
+	assignments, err := r.store.Assignments(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	team.PrimaryCareProvider, team.UpcomingPrimaryCareProvider = current(assignments, today)
+	for _, a := range []*pb.PcpAssignment{team.PrimaryCareProvider, team.UpcomingPrimaryCareProvider} {
+		if a != nil {
+			a.Provider = r.provider(ctx, a.ProviderId)
+		}
+	}
+
+	team.PcpRequired, err = r.requiresPCP(ctx, memberID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	team.Favorites, err = r.store.Favorites(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range team.Favorites {
+		f.Provider = r.provider(ctx, f.ProviderId)
+	}
+
+	seen, err := r.seen(ctx, memberID, today, level)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range seen[:min(len(seen), MaxRecent)] {
+		team.RecentlySeen = append(team.RecentlySeen, &pb.RecentProvider{
+			ProviderId: s.ProviderID,
+			LastSeen:   timestamppb.New(s.LastSeen),
+			ClaimCount: int32(s.Claims),
+			Provider:   r.provider(ctx, s.ProviderID),
+		})
+	}
+	return team, nil
+}
+
+// AssignPCP designates the member's primary care provider from the
+// effective date, today by default
+func (r *Roster) AssignPCP(ctx context.Context, req *pb.AssignPrimaryCareProviderRequest) (*pb.PcpAssignment, error) {
+	if req.MemberId == "" || req.ProviderId == "" {
+		return nil, fmt.Errorf("%w: member_id and provider_id are required", ErrInvalid)
+	}
+	now := r.now()
+	effective := coverage.Day(now)
+	if req.EffectiveDate != nil {
+		day := coverage.Day(req.EffectiveDate.AsTime())
+		if day.Before(effective) {
+			return nil, fmt.Errorf("%w: effective_date can't be in the past", ErrInvalid)
+		}
+		effective = day
+	}
+
+	provider, err := r.providers.Provider(ctx, req.ProviderId)
+	if err != nil {
+		return nil, err
+	}
+	location, err := pcpLocation(provider, req.LocationId)
+	if err != nil {
+		return nil, err
+	}
+	if !r.primaryCare(provider) {
+		return nil, ErrNotPrimaryCare
+	}
+
+	// Plans without networks let members choose any provider
+	plan, err := r.plans.Plan(ctx, req.MemberId, pb.CoverageType_COVERAGE_TYPE_MEDICAL, effective)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Networks()) > 0 && !plan.Location(location).InNetwork {
+		return nil, fmt.Errorf("%w on %s", ErrOutOfNetwork, effective.Format("2006-01-02"))
+	}
+
+	a := &pb.PcpAssignment{
+		AssignmentId:  uuid.NewString(),
+		MemberId:      req.MemberId,
+		ProviderId:    provider.ProviderId,
+		LocationId:    location.LocationId,
+		PlanId:        plan.PlanID,
+		EffectiveDate: timestamppb.New(effective),
+		CreatedAt:     timestamppb.New(now),
+	}
+	if err := r.store.Assign(ctx, a); err != nil {
+		return nil, err
+	}
+	a.Provider = provider
+	return a, nil
+}
+
+// SaveFavorite adds a provider to the member's favorites, or changes the
+// note on one already there
+func (r *Roster) SaveFavorite(ctx context.Context, req *pb.SaveFavoriteProviderRequest) (*pb.FavoriteProvider, error) {
+	note := strings.TrimSpace(req.Note)
+	switch {
+	case req.MemberId == "" || req.ProviderId == "":
+		return nil, fmt.Errorf("%w: member_id and provider_id are required", ErrInvalid)
+	case utf8.RuneCountInString(note) > MaxNoteLength:
+		return nil, fmt.Errorf("%w: note can be at most %d characters", ErrInvalid, MaxNoteLength)
+	}
+
+	provider, err := r.providers.Provider(ctx, req.ProviderId)
+	if err != nil {
+		return nil, err
+	}
+	now := timestamppb.New(r.now())
+	f, err := r.store.SaveFavorite(ctx, req.MemberId, &pb.FavoriteProvider{
+		ProviderId: provider.ProviderId,
+		Note:       note,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.Provider = provider
+	return f, nil
+}
+
+// RemoveFavorite removes a provider from the member's favorites
+func (r *Roster) RemoveFavorite(ctx context.Context, memberID, providerID string) error {
+	if memberID == "" || providerID == "" {
+		return fmt.Errorf("%w: member_id and provider_id are required", ErrInvalid)
+	}
+	return r.store.RemoveFavorite(ctx, memberID, providerID)
+}
+
+// Flag marks the providers on the member's care team with how they are on
+// it, as a caller with the given access level may see it, so search results
+// can show them
+func (r *Roster) Flag(ctx context.Context, memberID string, level pb.AccessLevel, providers []*pb.Provider) error {
+	today := coverage.Day(r.now())
+	flags := make(map[string]*pb.CareTeamFlags)
+	flag := func(id string) *pb.CareTeamFlags {
+		if flags[id] == nil {
+			flags[id] = &pb.CareTeamFlags{}
+		}
+		return flags[id]
+	}
+
+	assignments, err := r.store.Assignments(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	if pcp, _ := current(assignments, today); pcp != nil {
+		flag(pcp.ProviderId).PrimaryCareProvider = true
+	}
+	favorites, err := r.store.Favorites(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	for _, f := range favorites {
+		flag(f.ProviderId).Favorite = true
+	}
+	seen, err := r.seen(ctx, memberID, today, level)
+	if err != nil {
+		return err
+	}
+	for _, s := range seen {
+		flag(s.ProviderID).LastSeen = timestamppb.New(s.LastSeen)
+	}
+
+	for _, p := range providers {
+		p.CareTeam = flags[p.ProviderId]
+	}
+	return nil
+}
+
+// seen returns the providers the member has seen in the last RecentMonths,
+// most recently seen first. Anything less than full access leaves out claims
+// for sensitive services, so a parent can't learn when an adolescent saw a
+// provider for one.
+func (r *Roster) seen(ctx context.Context, memberID string, today time.Time, level pb.AccessLevel) ([]Seen, error) {
+	visits, err := r.store.Visits(ctx, memberID, today.AddDate(0, -RecentMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	byProvider := make(map[string]*Seen)
+	for _, v := range visits {
+		if level != pb.AccessLevel_ACCESS_LEVEL_FULL && v.sensitive() {
+			continue
+		}
+		s := byProvider[v.ProviderID]
+		if s == nil {
+			s = &Seen{ProviderID: v.ProviderID}
+			byProvider[v.ProviderID] = s
+		}
+		if v.ServiceDate.After(s.LastSeen) {
+			s.LastSeen = v.ServiceDate
+		}
+		s.Claims++
+	}
+
+	seen := make([]Seen, 0, len(byProvider))
+	for _, s := range byProvider {
+		seen = append(seen, *s)
+	}
+	sort.Slice(seen, func(i, j int) bool {
+		if !seen[i].LastSeen.Equal(seen[j].LastSeen) {
+			return seen[i].LastSeen.After(seen[j].LastSeen)
+		}
+		return seen[i].ProviderID < seen[j].ProviderID
+	})
+	return seen, nil
+}
+
+// requiresPCP reports whether the member's medical plan today requires a
+// designated primary care provider. Members without medical coverage today
+// aren't required to have one.
+func (r *Roster) requiresPCP(ctx context.Context, memberID string, today time.Time) (bool, error) {
+	plan, err := r.plans.Plan(ctx, memberID, pb.CoverageType_COVERAGE_TYPE_MEDICAL, today)
+	if errors.Is(err, network.ErrMemberNotFound) || errors.Is(err, network.ErrNoCoverage) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return r.store.RequiresPCP(ctx, plan.PlanID)
+}
+
+// primaryCare reports whether any of the provider's taxonomy codes is
+// covered by a primary care specialty
+func (r *Roster) primaryCare(p *pb.Provider) bool {
+	for _, t := range p.Taxonomies {
+		for _, name := range r.specialties.Covering(t.Code) {
+			for _, specialty := range PrimaryCareSpecialties {
+				if name == specialty {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// provider returns the provider's details, or nil for one who has left the
+// directory
+func (r *Roster) provider(ctx context.Context, id string) *pb.Provider {
+	p, err := r.providers.Provider(ctx, id)
+	if err != nil {
+		log.Printf("Showing care team provider %s without details: %v", id, err)
+		return nil
+	}
+	return p
+}
+
+// current returns the assignment in effect on the day and the one that
+// follows it, either of which may be nil
+func current(assignments []*pb.PcpAssignment, day time.Time) (now, next *pb.PcpAssignment) {
+	for _, a := range assignments {
+		switch {
+		case day.Before(a.EffectiveDate.AsTime()):
+			if next == nil || a.EffectiveDate.AsTime().Before(next.EffectiveDate.AsTime()) {
+				next = a
+			}
+		case a.TerminationDate == nil || !a.TerminationDate.AsTime().Before(day):
+			now = a
+		}
+	}
+	return now, next
+}
+
+// pcpLocation returns the location with the ID, or the provider's only
+// location when id is empty
+func pcpLocation(p *pb.Provider, id string) (*pb.ProviderLocation, error) {
+	if id == "" {
+		if len(p.Locations) != 1 {
+			return nil, fmt.Errorf("%w: location_id is required for providers with more than one location", ErrInvalid)
+		}
+		return p.Locations[0], nil
+	}
+	for _, l := range p.Locations {
+		if l.LocationId == id {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrLocationNotFound, id)
+}
@@ -0,0 +1,116 @@
+package careteam
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// MemoryStore is an in-process Store for demo mode. Demo plans don't require
+// a primary care provider, and demo claims carry no service history, so
+// nobody has recently seen providers.
+type MemoryStore struct {
+	mu          sync.Mutex
+	assignments map[string][]*pb.PcpAssignment
+	favorites   map[string]map[string]*pb.FavoriteProvider
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		assignments: make(map[string][]*pb.PcpAssignment),
+		favorites:   make(map[string]map[string]*pb.FavoriteProvider),
+	}
+}
+
+func (s *MemoryStore) RequiresPCP(ctx context.Context, planID string) (bool, error) {
+	return false, nil
+}
+
+func (s *MemoryStore) Assignments(ctx context.Context, memberID string) ([]*pb.PcpAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var assignments []*pb.PcpAssignment
+	for _, a := range s.assignments[memberID] {
+		assignments = append(assignments, proto.Clone(a).(*pb.PcpAssignment))
+	}
+	return assignments, nil
+}
+
+func (s *MemoryStore) Assign(ctx context.Context, a *pb.PcpAssignment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	effective := a.EffectiveDate.AsTime()
+	var kept []*pb.PcpAssignment
+	for _, other := range s.assignments[a.MemberId] {
+		if !other.EffectiveDate.AsTime().Before(effective) {
+			continue
+		}
+		if other.TerminationDate == nil || !other.TerminationDate.AsTime().Before(effective) {
+			other.TerminationDate = timestamppb.New(effective.AddDate(0, 0, -1))
+		}
+		kept = append(kept, other)
+	}
+
+	stored := proto.Clone(a).(*pb.PcpAssignment)
+	stored.Provider = nil
+	s.assignments[a.MemberId] = append(kept, stored)
+	return nil
+}
+
+func (s *MemoryStore) Favorites(ctx context.Context, memberID string) ([]*pb.FavoriteProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var favorites []*pb.FavoriteProvider
+	for _, f := range s.favorites[memberID] {
+		favorites = append(favorites, proto.Clone(f).(*pb.FavoriteProvider))
+	}
+	sort.Slice(favorites, func(i, j int) bool {
+		a, b := favorites[i].CreatedAt.AsTime(), favorites[j].CreatedAt.AsTime()
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return favorites[i].ProviderId < favorites[j].ProviderId
+	})
+	return favorites, nil
+}
+
+func (s *MemoryStore) SaveFavorite(ctx context.Context, memberID string, f *pb.FavoriteProvider) (*pb.FavoriteProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.favorites[memberID] == nil {
+		s.favorites[memberID] = make(map[string]*pb.FavoriteProvider)
+	}
+	stored := proto.Clone(f).(*pb.FavoriteProvider)
+	stored.Provider = nil
+	if existing, ok := s.favorites[memberID][f.ProviderId]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	s.favorites[memberID][f.ProviderId] = stored
+	return proto.Clone(stored).(*pb.FavoriteProvider), nil
+}
+
+func (s *MemoryStore) RemoveFavorite(ctx context.Context, memberID, providerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.favorites[memberID][providerID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.favorites[memberID], providerID)
+	return nil
+}
+
+func (s *MemoryStore) Visits(ctx context.Context, memberID string, since time.Time) ([]Visit, error) {
+	return nil, nil
+}
@@ -0,0 +1,517 @@
+// Package directory searches the provider directory, by name, specialty and
+// distance from where the member is searching.
+//
+// Specialties are modelled with the NUCC provider taxonomy. A specialty
+// filter naming a taxonomy node, or a broader specialty such as Primary
+// Care, matches providers with any code beneath it.
+//
+// Names and specialties are matched by an in-process text index, which
+// tolerates typos and understands synonyms such as "heart doctor". It is
+// built from the store on first use and then kept current by reading only
+// the providers changed since, at most once per refresh interval. Text
+// searches rank providers by relevance, distance and rating. Providers whose
+// NPI has been deactivated leave the directory and the index.
+//
+// Distance searches geocode the member's location from a bundled table of
+// ZIP and city centroids, ask the store for the locations inside a bounding
+// box around it, which the store can answer from an index on latitude and
+// longitude, and then drop the box's corners by measuring the haversine
+// distance to each location.
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/geo"
+	"github.com/sydney-health-clone/backend/services/provider/internal/network"
+	"github.com/sydney-health-clone/backend/services/provider/internal/search"
+	"github.com/sydney-health-clone/backend/services/provider/internal/taxonomy"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Page sizes for Search
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Search radii in miles
+const (
+	DefaultRadiusMiles = 25
+	MaxRadiusMiles     = 100
+)
+
+// Suggestion counts for Suggest
+const (
+	DefaultSuggestions = 10
+	MaxSuggestions     = 25
+)
+
+// Weights of text searches' ranking. Without a location, distance's weight
+// goes to relevance.
+const (
+	relevanceWeight = 0.6
+	distanceWeight  = 0.25
+	ratingWeight    = 0.15
+	maxRating       = 5.0
+)
+
+var (
+	// ErrNotFound is returned for an unknown provider ID
+	ErrNotFound = errors.New("provider not found")
+	// ErrInvalid is returned, wrapped with the problem, for searches that
+	// can't be run
+	ErrInvalid = errors.New("invalid provider search")
+)
+
+// Criteria narrow a search beyond its text. Providers match Taxonomies when
+// they have any of the codes, and Networks when a location has a contract in
+// force On with any of them; only those locations are returned.
+type Criteria struct {
+	AcceptingNewPatients bool
+	Taxonomies           []string
+	Networks             []string
+	On                   time.Time
+}
+
+// Store reads the provider directory. Providers are returned with their
+// taxonomy codes and locations, locations carrying their coordinates and
+// network contracts.
+// Specialties without a code are returned as specialties alone.
+type Store interface {
+	Provider(ctx context.Context, id string) (*pb.Provider, error)
+	// List returns a page of the matching providers ordered by name, and
+	// how many match in all
+	List(ctx context.Context, c Criteria, offset, limit int) ([]*pb.Provider, int, error)
+	// Within returns the matching providers with a location inside the
+	// bounds, each with only those locations
+	Within(ctx context.Context, c Criteria, b geo.Bounds) ([]*pb.Provider, error)
+	// Changed returns the providers changed at or after since, every
+	// provider for the zero time
+	Changed(ctx context.Context, since time.Time) (*Changes, error)
+}
+
+// Changes are what changed in a Store since a time
+type Changes struct {
+	// Providers are the changed providers as they are now
+	Providers []*pb.Provider
+	// Removed are the IDs of changed providers no longer listed, such as
+	// those whose NPI was deactivated
+	Removed []string
+	// Latest is the time of the latest change
+	Latest time.Time
+}
+
+// Directory searches a Store
+type Directory struct {
+	store   Store
+	places  *geo.Gazetteer
+	codes   *taxonomy.Taxonomy
+	index   *search.Index
+	refresh time.Duration
+	now     func() time.Time
+
+	mu sync.Mutex
+	// synced is the time of the latest change indexed, and checked when the
+	// store was last asked for changes. loading is closed when the refresh
+	// in flight finishes.
+	synced  time.Time
+	checked time.Time
+	loaded  bool
+	loading chan struct{}
+}
+
+// NewDirectory creates a directory over the store, geocoding searches with
+// places, naming specialties with codes and checking for changed providers at
+// most once per refresh
+func NewDirectory(store Store, places *geo.Gazetteer, codes *taxonomy.Taxonomy, refresh time.Duration) *Directory {
+	return &Directory{
+		store:   store,
+		places:  places,
+		codes:   codes,
+		index:   search.NewIndex(),
+		refresh: refresh,
+		now:     time.Now,
+	}
+}
+
+// Provider returns one provider with all of its locations
+func (d *Directory) Provider(ctx context.Context, id string) (*pb.Provider, error) {
+	p, err := d.store.Provider(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	d.codes.Describe(p)
+	return p, nil
+}
+
+// Search returns a page of providers. With a location, providers are those
+// within the radius with their locations in the radius sorted by
+// distance_miles. Text searches are ranked by relevance, distance and
+// rating; others are nearest first with a location and ordered by name
+// without one. Page tokens are the offset of the page's first provider.
+// With a plan, only the providers and locations in its networks are returned.
+func (d *Directory) Search(ctx context.Context, req *pb.SearchProvidersRequest, plan *network.Plan) ([]*pb.Provider, *pb.PageResponse, error) {
+	size, offset := DefaultPageSize, 0
+	if page := req.Page; page != nil {
+		if page.PageSize > 0 {
+			size = min(int(page.PageSize), MaxPageSize)
+		}
+		if page.PageToken != "" {
+			n, err := strconv.Atoi(page.PageToken)
+			if err != nil || n < 0 {
+				return nil, nil, fmt.Errorf("%w: invalid page token", ErrInvalid)
+			}
+			offset = n
+		}
+	}
+
+	c := Criteria{AcceptingNewPatients: req.AcceptingNewPatients}
+	q := search.Query{Text: req.Query, Name: req.ProviderName}
+	if codes, ok := d.specialty(req.Specialty); ok {
+		c.Taxonomies = codes
+	} else {
+		q.Specialty = req.Specialty
+	}
+	located := strings.TrimSpace(req.Location) != ""
+	if !located && req.RadiusMiles != 0 {
+		return nil, nil, fmt.Errorf("%w: radius_miles requires a location", ErrInvalid)
+	}
+	if plan != nil {
+		c.Networks, c.On = plan.Networks(), plan.Day
+		if len(c.Networks) == 0 {
+			return nil, &pb.PageResponse{}, nil
+		}
+	}
+
+	var providers []*pb.Provider
+	var total int
+	switch {
+	case !q.Empty():
+		var err error
+		providers, total, err = d.match(ctx, q, c, req.Location, req.RadiusMiles, offset, size)
+		if err != nil {
+			return nil, nil, err
+		}
+	case !located:
+		var err error
+		providers, total, err = d.store.List(ctx, c, offset, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range providers {
+			inNetwork(p, c)
+			d.codes.Describe(p)
+		}
+	default:
+		nearby, err := d.nearby(ctx, c, req.Location, req.RadiusMiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		total = len(nearby)
+		if offset < total {
+			providers = nearby[offset:min(offset+size, total)]
+		}
+	}
+
+	resp := &pb.PageResponse{TotalCount: int32(total)}
+	if end := offset + len(providers); end < total {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return providers, resp, nil
+}
+
+// specialty returns the taxonomy codes a specialty filter matches, trying
+// the specialty a synonym such as "heart doctor" means when nothing is
+// named. It reports false for filters to match as text.
+func (d *Directory) specialty(name string) ([]string, bool) {
+	if strings.TrimSpace(name) == "" {
+		return nil, false
+	}
+	if codes, ok := d.codes.Resolve(name); ok {
+		return codes, true
+	}
+	if specialty, ok := search.Synonym(name); ok {
+		return d.codes.Resolve(specialty)
+	}
+	return nil, false
+}
+
+// Suggest completes a partly typed search
+func (d *Directory) Suggest(ctx context.Context, text string, limit int) ([]search.Suggestion, error) {
+	switch {
+	case limit == 0:
+		limit = DefaultSuggestions
+	case limit < 0:
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalid)
+	}
+
+	if err := d.sync(ctx); err != nil {
+		return nil, err
+	}
+	return d.index.Suggest(text, min(limit, MaxSuggestions)), nil
+}
+
+// match returns a page of the providers matching the text query and
+// criteria, within radius miles of the location when given, ranked by
+// relevance, distance and rating, and how many match in all. The index's
+// providers are only read while ranking; just the page is copied.
+func (d *Directory) match(ctx context.Context, q search.Query, c Criteria, location string, radius float64, offset, size int) ([]*pb.Provider, int, error) {
+	var center geo.Point
+	var bounds geo.Bounds
+	located := strings.TrimSpace(location) != ""
+	if located {
+		var err error
+		if center, radius, err = d.locate(location, radius); err != nil {
+			return nil, 0, err
+		}
+		bounds = geo.Around(center, radius)
+	}
+	if err := d.sync(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	type ranked struct {
+		provider *pb.Provider
+		score    float64
+	}
+	var results []ranked
+	for _, hit := range d.index.Search(q) {
+		p := hit.Provider
+		if !matches(p, c) {
+			continue
+		}
+
+		score := relevanceWeight*hit.Relevance + ratingWeight*min(p.Rating/maxRating, 1)
+		if located {
+			miles, ok := nearest(p, c, center, radius, &bounds)
+			if !ok {
+				continue
+			}
+			score += distanceWeight * (1 - miles/radius)
+		} else {
+			score += distanceWeight * hit.Relevance
+		}
+		results = append(results, ranked{p, score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+	total := len(results)
+	if offset >= total {
+		return nil, total, nil
+	}
+	results = results[offset:min(offset+size, total)]
+	providers := make([]*pb.Provider, len(results))
+	for i, r := range results {
+		p := proto.Clone(r.provider).(*pb.Provider)
+		inNetwork(p, c)
+		if located {
+			within(p, center, radius, &bounds)
+		}
+		providers[i] = p
+	}
+	return providers, total, nil
+}
+
+// locate geocodes a search location and checks its radius, returning the
+// default radius for zero
+func (d *Directory) locate(location string, radius float64) (geo.Point, float64, error) {
+	switch {
+	case radius == 0:
+		radius = DefaultRadiusMiles
+	case radius < 0 || radius > MaxRadiusMiles:
+		return geo.Point{}, 0, fmt.Errorf("%w: radius_miles must be between 0 and %d", ErrInvalid, MaxRadiusMiles)
+	}
+
+	center, err := d.places.Locate(location)
+	if err != nil {
+		return geo.Point{}, 0, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return center, radius, nil
+}
+
+// sync brings the text index up to date with the store. One search at a
+// time reads the changes, outside the lock; once the index is loaded, the
+// others search what it has meanwhile. If the store can't be reached they
+// carry on with it until the next refresh.
+func (d *Directory) sync(ctx context.Context) error {
+	d.mu.Lock()
+	now := d.now()
+	if d.loaded && now.Sub(d.checked) < d.refresh {
+		d.mu.Unlock()
+		return nil
+	}
+	if d.loading != nil {
+		loaded, loading := d.loaded, d.loading
+		d.mu.Unlock()
+		if loaded {
+			return nil
+		}
+		// Nothing to search until the first load finishes
+		select {
+		case <-loading:
+			return d.sync(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	loading := make(chan struct{})
+	d.loading = loading
+	since := d.synced
+	d.mu.Unlock()
+
+	changes, err := d.store.Changed(ctx, since)
+	if err == nil {
+		d.apply(changes)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	close(loading)
+	d.loading = nil
+
+	if err != nil {
+		if !d.loaded {
+			return fmt.Errorf("failed to load provider index: %w", err)
+		}
+		log.Printf("Searching provider index as of %s: %v", d.synced.Format(time.RFC3339), err)
+		d.checked = now
+		return nil
+	}
+	if changes.Latest.After(d.synced) {
+		d.synced = changes.Latest
+	}
+	d.checked, d.loaded = now, true
+	return nil
+}
+
+// apply indexes changed providers
+func (d *Directory) apply(changes *Changes) {
+	for _, p := range changes.Providers {
+		d.codes.Describe(p)
+		var aliases []string
+		for _, t := range p.Taxonomies {
+			aliases = append(aliases, d.codes.Covering(t.Code)...)
+		}
+		d.index.Put(p, aliases...)
+	}
+	for _, id := range changes.Removed {
+		d.index.Remove(id)
+	}
+	if n := len(changes.Providers) + len(changes.Removed); n > 0 {
+		log.Printf("Indexed %d changed providers (%d in all)", n, d.index.Len())
+	}
+}
+
+// nearby returns every matching provider within radius miles of the
+// location, nearest first
+func (d *Directory) nearby(ctx context.Context, c Criteria, location string, radius float64) ([]*pb.Provider, error) {
+	center, radius, err := d.locate(location, radius)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := d.store.Within(ctx, c, geo.Around(center, radius))
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []*pb.Provider
+	for _, p := range candidates {
+		if inNetwork(p, c) && within(p, center, radius, nil) {
+			d.codes.Describe(p)
+			providers = append(providers, p)
+		}
+	}
+
+	// Ties are broken by ID so pages don't shift between requests
+	sort.Slice(providers, func(i, j int) bool {
+		a, b := providers[i].Locations[0].DistanceMiles, providers[j].Locations[0].DistanceMiles
+		if a != b {
+			return a < b
+		}
+		return providers[i].ProviderId < providers[j].ProviderId
+	})
+	return providers, nil
+}
+
+// within keeps the provider's locations within radius miles of center,
+// nearest first with distance_miles set, and reports whether any are.
+// Locations outside bounds, when given, are skipped without measuring.
+func within(p *pb.Provider, center geo.Point, radius float64, bounds *geo.Bounds) bool {
+	var locations []*pb.ProviderLocation
+	for _, l := range p.Locations {
+		point := geo.Point{Lat: l.Latitude, Lng: l.Longitude}
+		if bounds != nil && !bounds.Contains(point) {
+			continue
+		}
+		miles := geo.Distance(center, point)
+		if miles > radius {
+			continue
+		}
+		l.DistanceMiles = math.Round(miles*100) / 100
+		locations = append(locations, l)
+	}
+	if len(locations) == 0 {
+		return false
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].DistanceMiles < locations[j].DistanceMiles
+	})
+	p.Locations = locations
+	return true
+}
+
+// nearest returns the distance to the provider's nearest location in the
+// criteria's networks within radius miles of center, as within would set it,
+// without changing the provider. Locations outside bounds are skipped.
+func nearest(p *pb.Provider, c Criteria, center geo.Point, radius float64, bounds *geo.Bounds) (float64, bool) {
+	best, found := 0.0, false
+	for _, l := range p.Locations {
+		if len(c.Networks) > 0 && !network.Participates(l, c.Networks, c.On) {
+			continue
+		}
+		point := geo.Point{Lat: l.Latitude, Lng: l.Longitude}
+		if !bounds.Contains(point) {
+			continue
+		}
+		miles := geo.Distance(center, point)
+		if miles > radius {
+			continue
+		}
+		if miles = math.Round(miles*100) / 100; !found || miles < best {
+			best, found = miles, true
+		}
+	}
+	return best, found
+}
+
+// inNetwork keeps the provider's locations in the criteria's networks, when
+// it names any, and reports whether any are
+func inNetwork(p *pb.Provider, c Criteria) bool {
+	if len(c.Networks) == 0 {
+		return true
+	}
+	var locations []*pb.ProviderLocation
+	for _, l := range p.Locations {
+		if network.Participates(l, c.Networks, c.On) {
+			locations = append(locations, l)
+		}
+	}
+	p.Locations = locations
+	return len(locations) > 0
+}
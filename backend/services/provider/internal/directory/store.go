@@ -0,0 +1,133 @@
+package directory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/geo"
+	"github.com/sydney-health-clone/backend/services/provider/internal/network"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// MemoryStore is an in-process Store for demo mode. Its providers never
+// change after it is created, and each call returns copies.
+type MemoryStore struct {
+	providers []*pb.Provider
+	loaded    time.Time
+}
+
+// NewMemoryStore creates a store over the providers, which should have their
+// location coordinates set
+func NewMemoryStore(providers []*pb.Provider) *MemoryStore {
+	s := &MemoryStore{loaded: time.Now()}
+	for _, p := range providers {
+		s.providers = append(s.providers, proto.Clone(p).(*pb.Provider))
+	}
+	sort.Slice(s.providers, func(i, j int) bool {
+		return byName(s.providers[i], s.providers[j])
+	})
+	return s
+}
+
+func (s *MemoryStore) Provider(ctx context.Context, id string) (*pb.Provider, error) {
+	for _, p := range s.providers {
+		if p.ProviderId == id {
+			return proto.Clone(p).(*pb.Provider), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) List(ctx context.Context, c Criteria, offset, limit int) ([]*pb.Provider, int, error) {
+	var matched []*pb.Provider
+	for _, p := range s.providers {
+		if matches(p, c) {
+			matched = append(matched, p)
+		}
+	}
+
+	var page []*pb.Provider
+	for i := offset; i < len(matched) && i < offset+limit; i++ {
+		page = append(page, proto.Clone(matched[i]).(*pb.Provider))
+	}
+	return page, len(matched), nil
+}
+
+func (s *MemoryStore) Changed(ctx context.Context, since time.Time) (*Changes, error) {
+	if !since.IsZero() {
+		return &Changes{Latest: since}, nil
+	}
+
+	providers := make([]*pb.Provider, len(s.providers))
+	for i, p := range s.providers {
+		providers[i] = proto.Clone(p).(*pb.Provider)
+	}
+	return &Changes{Providers: providers, Latest: s.loaded}, nil
+}
+
+func (s *MemoryStore) Within(ctx context.Context, c Criteria, b geo.Bounds) ([]*pb.Provider, error) {
+	var providers []*pb.Provider
+	for _, p := range s.providers {
+		if !matches(p, c) {
+			continue
+		}
+
+		p = proto.Clone(p).(*pb.Provider)
+		var locations []*pb.ProviderLocation
+		for _, l := range p.Locations {
+			if b.Contains(geo.Point{Lat: l.Latitude, Lng: l.Longitude}) {
+				locations = append(locations, l)
+			}
+		}
+		if len(locations) > 0 {
+			p.Locations = locations
+			providers = append(providers, p)
+		}
+	}
+	return providers, nil
+}
+
+func matches(p *pb.Provider, c Criteria) bool {
+	if c.AcceptingNewPatients && !p.AcceptingNewPatients {
+		return false
+	}
+	if len(c.Networks) > 0 && !participates(p, c) {
+		return false
+	}
+	if len(c.Taxonomies) == 0 {
+		return true
+	}
+	for _, t := range p.Taxonomies {
+		for _, code := range c.Taxonomies {
+			if t.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// byName orders providers by last name, first name and then ID
+func byName(a, b *pb.Provider) bool {
+	if a.LastName != b.LastName {
+		return a.LastName < b.LastName
+	}
+	if a.FirstName != b.FirstName {
+		return a.FirstName < b.FirstName
+	}
+	return a.ProviderId < b.ProviderId
+}
+
+// participates reports whether any of the provider's locations are in the
+// criteria's networks
+func participates(p *pb.Provider, c Criteria) bool {
+	for _, l := range p.Locations {
+		if network.Participates(l, c.Networks, c.On) {
+			return true
+		}
+	}
+	return false
+}
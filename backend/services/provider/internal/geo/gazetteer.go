@@ -0,0 +1,197 @@
+package geo
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownLocation is returned for locations that can't be found
+var ErrUnknownLocation = errors.New("unknown location")
+
+// bundledCentroids is the table zcta-centroids builds from the Census ZCTA
+// gazetteer, gzipped
+//
+//go:embed data/zip_centroids.csv.gz
+var bundledCentroids []byte
+
+var (
+	coordinatesPattern = regexp.MustCompile(`^(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)$`)
+	zipPattern         = regexp.MustCompile(`(?:^|[\s,])(\d{5})(?:-\d{4})?$`)
+	statePattern       = regexp.MustCompile(`^[A-Za-z]{2}$`)
+)
+
+// Gazetteer finds the coordinates of ZIP codes and cities offline from a
+// table of ZIP centroids. Each row is zip,city,state,latitude,longitude; a
+// city's centroid is the mean of its ZIPs'.
+type Gazetteer struct {
+	zips   map[string]Point
+	cities map[string]Point
+	// states lists the states with a city of each name, for searches that
+	// leave the state out
+	states map[string][]string
+}
+
+// OpenGazetteer loads the centroid table at path, gzipped when it ends in
+// .gz, or the bundled table when path is empty
+func OpenGazetteer(path string) (*Gazetteer, error) {
+	if path == "" {
+		return loadGzipped(bytes.NewReader(bundledCentroids))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open centroids: %w", err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(path, ".gz") {
+		return loadGzipped(f)
+	}
+	return LoadGazetteer(f)
+}
+
+func loadGzipped(r io.Reader) (*Gazetteer, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read centroids: %w", err)
+	}
+	defer zr.Close()
+	return LoadGazetteer(zr)
+}
+
+// LoadGazetteer reads a centroid table. Lines starting with # and a header
+// row starting with zip are skipped. ZIPs without a city are found by ZIP
+// alone.
+func LoadGazetteer(r io.Reader) (*Gazetteer, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+
+	type sum struct {
+		lat, lng float64
+		n        int
+	}
+	g := &Gazetteer{
+		zips:   make(map[string]Point),
+		cities: make(map[string]Point),
+		states: make(map[string][]string),
+	}
+	sums := make(map[string]*sum)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read centroids: %w", err)
+		}
+		if strings.EqualFold(record[0], "zip") {
+			continue
+		}
+
+		lat, latErr := strconv.ParseFloat(record[3], 64)
+		lng, lngErr := strconv.ParseFloat(record[4], 64)
+		if latErr != nil || lngErr != nil || !valid(Point{lat, lng}) {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("invalid centroid for ZIP %s on line %d", record[0], line)
+		}
+		g.zips[record[0]] = Point{lat, lng}
+		if record[1] == "" || record[2] == "" {
+			continue
+		}
+
+		city, state := normalize(record[1]), strings.ToUpper(record[2])
+		key := city + "|" + state
+		s, ok := sums[key]
+		if !ok {
+			s = &sum{}
+			sums[key] = s
+			g.states[city] = append(g.states[city], state)
+		}
+		s.lat += lat
+		s.lng += lng
+		s.n++
+	}
+
+	for key, s := range sums {
+		g.cities[key] = Point{s.lat / float64(s.n), s.lng / float64(s.n)}
+	}
+	return g, nil
+}
+
+// Locate returns the coordinates of a ZIP code ("94105" or "94105-1234"), a
+// city ("San Francisco, CA", "Oakland CA" or just "Oakland" when only one
+// state has a city of that name) or a "latitude,longitude" pair. A city
+// followed by a ZIP uses the ZIP when it is known.
+func (g *Gazetteer) Locate(location string) (Point, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return Point{}, fmt.Errorf("%w: location is empty", ErrUnknownLocation)
+	}
+
+	if m := coordinatesPattern.FindStringSubmatch(location); m != nil {
+		lat, _ := strconv.ParseFloat(m[1], 64)
+		lng, _ := strconv.ParseFloat(m[2], 64)
+		p := Point{lat, lng}
+		if !valid(p) {
+			return Point{}, fmt.Errorf("%w: coordinates out of range", ErrUnknownLocation)
+		}
+		return p, nil
+	}
+
+	city := location
+	if m := zipPattern.FindStringSubmatchIndex(location); m != nil {
+		if p, ok := g.zips[location[m[2]:m[3]]]; ok {
+			return p, nil
+		}
+		city = strings.TrimRight(strings.TrimSpace(location[:m[0]]), ",")
+		if city == "" {
+			return Point{}, fmt.Errorf("%w: ZIP code %s", ErrUnknownLocation, location)
+		}
+	}
+
+	if p, ok := g.city(city); ok {
+		return p, nil
+	}
+	return Point{}, fmt.Errorf("%w: %s", ErrUnknownLocation, location)
+}
+
+// city looks up "City, ST", "City ST" or a city name alone
+func (g *Gazetteer) city(location string) (Point, bool) {
+	name, state := location, ""
+	if i := strings.LastIndex(location, ","); i >= 0 {
+		name, state = location[:i], strings.TrimSpace(location[i+1:])
+	} else if i := strings.LastIndex(location, " "); i >= 0 && statePattern.MatchString(location[i+1:]) {
+		name, state = location[:i], location[i+1:]
+	}
+	name = normalize(name)
+
+	if state == "" {
+		// A name alone is only found when it isn't ambiguous
+		states := g.states[name]
+		if len(states) != 1 {
+			return Point{}, false
+		}
+		state = states[0]
+	}
+	p, ok := g.cities[name+"|"+strings.ToUpper(state)]
+	return p, ok
+}
+
+// normalize upper-cases a city name and collapses its spaces
+func normalize(city string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(city), " "))
+}
+
+func valid(p Point) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
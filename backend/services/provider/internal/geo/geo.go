@@ -0,0 +1,68 @@
+// Package geo finds the distance between points on the earth and the
+// coordinates of the places members search from.
+package geo
+
+import "math"
+
+// earthRadiusMiles is the mean radius of the earth
+const earthRadiusMiles = 3958.8
+
+// milesPerDegree is the length of a degree of latitude, or of longitude at
+// the equator
+const milesPerDegree = earthRadiusMiles * math.Pi / 180
+
+// Point is a latitude and longitude in degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Distance returns the great-circle distance between two points in miles
+// using the haversine formula
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bounds is a latitude and longitude box. It is a cheap prefilter for a
+// radius search: every point within the radius is inside the box, but the
+// corners of the box are beyond it.
+type Bounds struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+// Around returns the bounds of every point within miles of center. Degrees
+// of longitude shrink away from the equator, so the box is widened by the
+// latitude of the edge nearest a pole. Boxes that would cross a pole or the
+// antimeridian are clamped, which no search in the US comes near.
+func Around(center Point, miles float64) Bounds {
+	dLat := miles / milesPerDegree
+	b := Bounds{
+		MinLat: math.Max(center.Lat-dLat, -90),
+		MaxLat: math.Min(center.Lat+dLat, 90),
+	}
+
+	widest := math.Max(math.Abs(b.MinLat), math.Abs(b.MaxLat))
+	dLng := 180.0
+	if cos := math.Cos(radians(widest)); cos > 0 {
+		dLng = math.Min(miles/(milesPerDegree*cos), 180)
+	}
+	b.MinLng = math.Max(center.Lng-dLng, -180)
+	b.MaxLng = math.Min(center.Lng+dLng, 180)
+	return b
+}
+
+// Contains reports whether the point is inside the bounds
+func (b Bounds) Contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
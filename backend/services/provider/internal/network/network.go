@@ -0,0 +1,253 @@
+// Package network decides whether providers are in network for a member.
+//
+// Providers join networks by contract. A contract covers one of a provider's
+// locations, or all of them, from its effective date through its termination
+// date, so a provider can be in network at one office and not another. Plans
+// use networks at a tier: Tier 1 for the plan's preferred networks, with the
+// lowest cost shares, and Tier 2 for the rest. A location is in network for a
+// member when, on the day, it has a contract with a network the member's
+// plan uses that day, at the best tier of those networks.
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Tier is how a plan treats a network, better tiers being greater
+type Tier int
+
+// Tiers, worst first
+const (
+	OutOfNetwork Tier = iota
+	Tier2
+	Tier1
+)
+
+// String returns the tier as network_tier reports it, e.g. "TIER_1"
+func (t Tier) String() string {
+	switch t {
+	case Tier1:
+		return "TIER_1"
+	case Tier2:
+		return "TIER_2"
+	default:
+		return "OUT_OF_NETWORK"
+	}
+}
+
+var (
+	// ErrMemberNotFound is returned for members with no coverage on record
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrNoCoverage is returned, wrapped with the coverage type, for members
+	// without that coverage on the day
+	ErrNoCoverage = errors.New("member has no active coverage")
+	// ErrLocationNotFound is returned for a location the provider doesn't have
+	ErrLocationNotFound = errors.New("provider location not found")
+)
+
+// PlanNetwork is a network a plan uses for a coverage type from Effective
+// through Termination, which is zero while open-ended
+type PlanNetwork struct {
+	PlanID       string
+	CoverageType pb.CoverageType
+	NetworkID    string
+	Tier         Tier
+	Effective    time.Time
+	Termination  time.Time
+}
+
+// Store reads members' coverage and the networks their plans use
+type Store interface {
+	CoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error)
+	PlanNetworks(ctx context.Context, planID string, coverageType pb.CoverageType) ([]PlanNetwork, error)
+}
+
+// Checker finds the networks members' plans use
+type Checker struct {
+	store Store
+}
+
+// NewChecker creates a checker over the store
+func NewChecker(store Store) *Checker {
+	return &Checker{store: store}
+}
+
+// Plan is the networks a member's plan uses on a day
+type Plan struct {
+	PlanID string
+	Day    time.Time
+	tiers  map[string]Tier
+}
+
+// Plan returns the networks the member's plan for the coverage type uses on
+// the day
+func (c *Checker) Plan(ctx context.Context, memberID string, coverageType pb.CoverageType, day time.Time) (*Plan, error) {
+	spans, err := c.store.CoverageSpans(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, ErrMemberNotFound
+	}
+	day = coverage.Day(day)
+	span := coverage.Find(spans, coverageType, day)
+	if span == nil {
+		return nil, fmt.Errorf("%w: no %s coverage on %s", ErrNoCoverage, coverage.TypeName(coverageType), day.Format("2006-01-02"))
+	}
+
+	networks, err := c.store.PlanNetworks(ctx, span.PlanId, coverageType)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{PlanID: span.PlanId, Day: day, tiers: make(map[string]Tier)}
+	for _, n := range networks {
+		if inForce(n.Effective, n.Termination, day) && n.Tier > plan.tiers[n.NetworkID] {
+			plan.tiers[n.NetworkID] = n.Tier
+		}
+	}
+	return plan, nil
+}
+
+// Networks returns the IDs of the networks the plan uses
+func (p *Plan) Networks() []string {
+	ids := make([]string, 0, len(p.tiers))
+	for id := range p.tiers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Location returns a location's status under the plan. Of the contracts at
+// the best tier, the one running longest is reported.
+func (p *Plan) Location(l *pb.ProviderLocation) *pb.LocationNetworkStatus {
+	status := &pb.LocationNetworkStatus{LocationId: l.LocationId, NetworkTier: OutOfNetwork.String()}
+	best, bestEnd := OutOfNetwork, time.Time{}
+	for _, n := range l.Networks {
+		tier := p.tiers[n.NetworkId]
+		if tier == OutOfNetwork || !InForce(n, p.Day) {
+			continue
+		}
+		end := endOf(n)
+		if tier < best || (tier == best && !later(end, bestEnd)) {
+			continue
+		}
+		best, bestEnd = tier, end
+		status.InNetwork = true
+		status.NetworkTier = tier.String()
+		status.NetworkId = n.NetworkId
+		status.NetworkName = n.NetworkName
+		status.CoveredServices = n.CoveredServices
+		status.ContractEndDate = n.TerminationDate
+	}
+	return status
+}
+
+// Status answers for the provider at one location, or at its best location
+// when locationID is empty, listing every location, best tier first
+func (p *Plan) Status(provider *pb.Provider, locationID string) (*pb.CheckNetworkStatusResponse, error) {
+	locations := make([]*pb.LocationNetworkStatus, len(provider.Locations))
+	var found *pb.LocationNetworkStatus
+	for i, l := range provider.Locations {
+		locations[i] = p.Location(l)
+		if l.LocationId == locationID {
+			found = locations[i]
+		}
+	}
+	if locationID != "" && found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLocationNotFound, locationID)
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		return tierOf(locations[i]) > tierOf(locations[j])
+	})
+	if found == nil && len(locations) > 0 {
+		found = locations[0]
+	}
+
+	resp := &pb.CheckNetworkStatusResponse{
+		NetworkTier: OutOfNetwork.String(),
+		PlanId:      p.PlanID,
+		Locations:   locations,
+	}
+	if found != nil {
+		resp.InNetwork = found.InNetwork
+		resp.NetworkTier = found.NetworkTier
+		resp.CoveredServices = found.CoveredServices
+		resp.LocationId = found.LocationId
+		resp.NetworkId = found.NetworkId
+		resp.NetworkName = found.NetworkName
+		resp.ContractEndDate = found.ContractEndDate
+	}
+	return resp, nil
+}
+
+// Participates reports whether the location has a contract in force on the
+// day with any of the networks
+func Participates(l *pb.ProviderLocation, networks []string, day time.Time) bool {
+	for _, n := range l.Networks {
+		for _, id := range networks {
+			if n.NetworkId == id && InForce(n, day) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InForce reports whether a contract covers the day
+func InForce(n *pb.NetworkParticipation, day time.Time) bool {
+	var effective, termination time.Time
+	if n.EffectiveDate != nil {
+		effective = n.EffectiveDate.AsTime()
+	}
+	if n.TerminationDate != nil {
+		termination = n.TerminationDate.AsTime()
+	}
+	return n.EffectiveDate != nil && inForce(effective, termination, day)
+}
+
+// inForce reports whether dates from effective through termination, zero
+// for open-ended, cover the day
+func inForce(effective, termination time.Time, day time.Time) bool {
+	day = coverage.Day(day)
+	if day.Before(coverage.Day(effective)) {
+		return false
+	}
+	return termination.IsZero() || !day.After(coverage.Day(termination))
+}
+
+// endOf returns the contract's last day, zero while open-ended
+func endOf(n *pb.NetworkParticipation) time.Time {
+	if n.TerminationDate == nil {
+		return time.Time{}
+	}
+	return n.TerminationDate.AsTime()
+}
+
+// later reports whether end a runs past end b, zero being open-ended
+func later(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() && !b.IsZero()
+	}
+	return a.After(b)
+}
+
+// tierOf returns the tier a status reports
+func tierOf(s *pb.LocationNetworkStatus) Tier {
+	switch s.NetworkTier {
+	case Tier1.String():
+		return Tier1
+	case Tier2.String():
+		return Tier2
+	default:
+		return OutOfNetwork
+	}
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Demo networks. The demo plan uses the preferred network at Tier 1 and the
+// broader PPO network at Tier 2 for medical coverage.
+const (
+	DemoPlanID             = "DEMO"
+	DemoPreferredNetworkID = "DEMO-PREFERRED"
+	DemoPPONetworkID       = "DEMO-PPO"
+)
+
+// MemoryStore gives every member the demo plan's medical, dental and vision
+// coverage from the start of the year, for demo mode
+type MemoryStore struct {
+	start time.Time
+}
+
+// NewMemoryStore creates a demo store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{start: time.Date(time.Now().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (s *MemoryStore) CoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error) {
+	var spans []*pb.CoverageSpan
+	for _, t := range []pb.CoverageType{
+		pb.CoverageType_COVERAGE_TYPE_MEDICAL,
+		pb.CoverageType_COVERAGE_TYPE_DENTAL,
+		pb.CoverageType_COVERAGE_TYPE_VISION,
+	} {
+		spans = append(spans, &pb.CoverageSpan{
+			SpanId:        DemoPlanID + "-" + t.String(),
+			MemberId:      memberID,
+			CoverageType:  t,
+			PlanId:        DemoPlanID,
+			PlanName:      "Demo Plan",
+			EffectiveDate: timestamppb.New(s.start),
+		})
+	}
+	return spans, nil
+}
+
+func (s *MemoryStore) PlanNetworks(ctx context.Context, planID string, coverageType pb.CoverageType) ([]PlanNetwork, error) {
+	if planID != DemoPlanID || coverageType != pb.CoverageType_COVERAGE_TYPE_MEDICAL {
+		return nil, nil
+	}
+	return []PlanNetwork{
+		{PlanID: planID, CoverageType: coverageType, NetworkID: DemoPreferredNetworkID, Tier: Tier1, Effective: s.start},
+		{PlanID: planID, CoverageType: coverageType, NetworkID: DemoPPONetworkID, Tier: Tier2, Effective: s.start},
+	}, nil
+}
+
+// Enroll gives demo providers contracts with the demo networks: most are in
+// the PPO network, every third is also preferred and every tenth is in
+// neither, and one in five contracts ends at the end of the year
+func (s *MemoryStore) Enroll(providers []*pb.Provider) {
+	end := timestamppb.New(s.start.AddDate(1, 0, -1))
+	for i, p := range providers {
+		if i%10 == 9 {
+			continue
+		}
+		for _, l := range p.Locations {
+			ppo := &pb.NetworkParticipation{
+				NetworkId:     DemoPPONetworkID,
+				NetworkName:   "Demo PPO",
+				EffectiveDate: timestamppb.New(s.start),
+			}
+			if i%5 == 4 {
+				ppo.TerminationDate = end
+			}
+			l.Networks = append(l.Networks, ppo)
+			if i%3 == 0 {
+				l.Networks = append(l.Networks, &pb.NetworkParticipation{
+					NetworkId:     DemoPreferredNetworkID,
+					NetworkName:   "Demo Preferred",
+					EffectiveDate: timestamppb.New(s.start),
+				})
+			}
+		}
+	}
+}
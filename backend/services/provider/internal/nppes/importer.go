@@ -0,0 +1,233 @@
+package nppes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/geo"
+	"github.com/sydney-health-clone/backend/services/provider/internal/taxonomy"
+	"github.com/sydney-health-clone/backend/shared/npi"
+)
+
+// Defaults for Options
+const (
+	DefaultBatchSize = 1000
+	DefaultProgress  = 30 * time.Second
+)
+
+// ErrImported is returned for a file that has already been imported in full
+var ErrImported = errors.New("file already imported")
+
+// Checkpoint is how far the import of a file has got. Files are told apart by
+// name and size, since each month's file has its own name.
+type Checkpoint struct {
+	File string
+	Size int64
+	// Offset is the byte offset of the first row not yet imported
+	Offset int64
+	// Rows counts the rows read. Upserted, Unchanged and Deactivated count
+	// the rows written, those already up to date and the deactivated NPIs
+	// taken out of the directory. Invalid rows have NPIs that fail the
+	// check digit and are skipped.
+	Rows        int64
+	Upserted    int64
+	Unchanged   int64
+	Deactivated int64
+	Invalid     int64
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// Completed reports whether every row of the file has been imported
+func (c *Checkpoint) Completed() bool {
+	return !c.CompletedAt.IsZero()
+}
+
+// Store writes imported providers
+type Store interface {
+	// Checkpoint returns the checkpoint of a file's import, or nil when it
+	// hasn't begun
+	Checkpoint(ctx context.Context, file string, size int64) (*Checkpoint, error)
+	// Apply writes a batch of records and then the checkpoint in one
+	// transaction, first adding what became of each record to its counts.
+	// Providers are matched by NPI and left alone when the record is no
+	// newer than what was last imported. Deactivated NPIs are taken out of
+	// the directory; other records put them back.
+	Apply(ctx context.Context, cp *Checkpoint, records []*Record) error
+}
+
+// Options configures an Importer
+type Options struct {
+	// BatchSize is how many rows are written per transaction
+	BatchSize int
+	// Progress is how often progress is logged
+	Progress time.Duration
+	// Restart imports the file from the start even if it was imported, or
+	// partly imported, before
+	Restart bool
+	// DryRun reads and checks every row without writing anything
+	DryRun bool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Importer imports dissemination files into a Store
+type Importer struct {
+	store  Store
+	places *geo.Gazetteer
+	codes  *taxonomy.Taxonomy
+	opts   Options
+}
+
+// NewImporter creates an importer that locates practices with places and
+// names taxonomy codes with codes
+func NewImporter(store Store, places *geo.Gazetteer, codes *taxonomy.Taxonomy, opts Options) *Importer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Progress <= 0 {
+		opts.Progress = DefaultProgress
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Importer{store: store, places: places, codes: codes, opts: opts}
+}
+
+// Import imports the named file of the given size, resuming an earlier
+// import of it unless Restart is set. It returns the checkpoint reached,
+// which on error is the last one written.
+func (im *Importer) Import(ctx context.Context, r io.ReadSeeker, file string, size int64) (*Checkpoint, error) {
+	cp := &Checkpoint{File: file, Size: size, StartedAt: im.opts.Now()}
+	if !im.opts.DryRun && !im.opts.Restart {
+		saved, err := im.store.Checkpoint(ctx, file, size)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && saved.Completed() {
+			return saved, fmt.Errorf("%w: %s on %s", ErrImported, file, saved.CompletedAt.Format("2006-01-02"))
+		}
+		if saved != nil {
+			cp = saved
+			log.Printf("Resuming %s at byte %d of %d, %d rows read", file, cp.Offset, size, cp.Rows)
+		}
+	}
+
+	rd, err := Resume(r, cp.Offset)
+	if err != nil {
+		return cp, err
+	}
+
+	progress := newProgress(im.opts.Now(), cp)
+	batch := make([]*Record, 0, im.opts.BatchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return cp, err
+		}
+
+		rec, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return cp, fmt.Errorf("row at byte %d: %w", rd.Offset(), err)
+		}
+
+		if err := npi.Validate(rec.NPI()); err != nil {
+			log.Printf("Skipping row at byte %d: %v", rd.Offset(), err)
+			cp.Rows++
+			cp.Invalid++
+			continue
+		}
+		im.prepare(rec)
+		batch = append(batch, rec)
+		if len(batch) < im.opts.BatchSize {
+			continue
+		}
+
+		if err := im.apply(ctx, cp, batch, rd.Offset()); err != nil {
+			return cp, err
+		}
+		batch = batch[:0]
+		if now := im.opts.Now(); now.Sub(progress.logged) >= im.opts.Progress {
+			progress.log(now, cp)
+		}
+	}
+
+	cp.CompletedAt = im.opts.Now()
+	if err := im.apply(ctx, cp, batch, rd.Offset()); err != nil {
+		cp.CompletedAt = time.Time{}
+		return cp, err
+	}
+	progress.log(cp.CompletedAt, cp)
+	return cp, nil
+}
+
+// apply writes a batch and the checkpoint after it. Dry runs only count the
+// rows, as if into an empty directory.
+func (im *Importer) apply(ctx context.Context, cp *Checkpoint, batch []*Record, offset int64) error {
+	next := *cp
+	next.Offset = offset
+	next.Rows += int64(len(batch))
+	if im.opts.DryRun {
+		for _, rec := range batch {
+			if rec.Deactivated.IsZero() {
+				next.Upserted++
+			} else {
+				next.Deactivated++
+			}
+		}
+		*cp = next
+		return nil
+	}
+
+	// Counts only move once the batch is written, so a failed batch is
+	// read again on resuming
+	if err := im.store.Apply(ctx, &next, batch); err != nil {
+		return fmt.Errorf("failed to import rows before byte %d: %w", offset, err)
+	}
+	*cp = next
+	return nil
+}
+
+// prepare names a record's taxonomy codes and locates its practice by ZIP
+// code. Practices in unknown ZIP codes have no coordinates and are left out
+// of distance searches.
+func (im *Importer) prepare(rec *Record) {
+	p := rec.Provider
+	im.codes.Describe(p)
+	for _, l := range p.Locations {
+		if pt, err := im.places.Locate(l.Address.ZipCode); err == nil {
+			l.Latitude, l.Longitude = pt.Lat, pt.Lng
+		}
+	}
+}
+
+// progress logs how far through the file an import is and how fast it's
+// going
+type progress struct {
+	logged time.Time
+	rows   int64
+}
+
+func newProgress(now time.Time, cp *Checkpoint) *progress {
+	return &progress{logged: now, rows: cp.Rows}
+}
+
+func (p *progress) log(now time.Time, cp *Checkpoint) {
+	rate := 0.0
+	if elapsed := now.Sub(p.logged).Seconds(); elapsed > 0 {
+		rate = float64(cp.Rows-p.rows) / elapsed
+	}
+	percent := 100.0
+	if cp.Size > 0 {
+		percent = 100 * float64(cp.Offset) / float64(cp.Size)
+	}
+	log.Printf("%s: %.1f%% (%d rows, %.0f rows/s): %d upserted, %d unchanged, %d deactivated, %d invalid",
+		cp.File, percent, cp.Rows, rate, cp.Upserted, cp.Unchanged, cp.Deactivated, cp.Invalid)
+	p.logged, p.rows = now, cp.Rows
+}
@@ -0,0 +1,317 @@
+// Package nppes imports the NPPES NPI registry into the provider directory.
+//
+// CMS publishes the registry monthly as a full replacement dissemination
+// file: a CSV of several gigabytes with a row for every NPI ever issued and
+// over 300 columns. The file is streamed a row at a time, and only the
+// columns the directory keeps are read: names, the practice location, up to
+// 15 taxonomy codes and the deactivation date.
+//
+// Rows are written in batches, each batch in one transaction with the byte
+// offset of the row after it, so an interrupted import resumes from the last
+// batch written rather than from the start of the file.
+package nppes
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Entity type codes
+const (
+	EntityIndividual   = "1"
+	EntityOrganization = "2"
+)
+
+// taxonomySlots is how many taxonomy codes a row has columns for
+const taxonomySlots = 15
+
+// dateLayout is the format of the file's dates
+const dateLayout = "01/02/2006"
+
+const byteOrderMark = "\xef\xbb\xbf"
+
+// ErrHeader is returned, wrapped with the problem, for files without the
+// columns of a dissemination file
+var ErrHeader = errors.New("not an NPPES dissemination file")
+
+// Record is one row of the file
+type Record struct {
+	// Provider has the NPI as its ID and at most one location, the primary
+	// practice location. Taxonomies have codes but no names. Deactivated
+	// NPIs have only their NPI.
+	Provider   *pb.Provider
+	EntityType string
+	// Updated is the row's last update date
+	Updated time.Time
+	// Deactivated is set when the NPI is deactivated and hasn't since been
+	// reactivated
+	Deactivated time.Time
+}
+
+// NPI returns the record's NPI
+func (r *Record) NPI() string {
+	return r.Provider.Npi
+}
+
+// PracticeID returns the location ID of an NPI's practice location
+func PracticeID(npi string) string {
+	return npi + "-PRACTICE"
+}
+
+// columns are the positions of the columns read, -1 for absent optional ones
+type columns struct {
+	npi, entityType                          int
+	organization, lastName, firstName        int
+	street1, street2, city, state, zip       int
+	country, phone, fax                      int
+	gender, updated, deactivated, reactivate int
+	taxonomy, primary                        [taxonomySlots]int
+}
+
+// Reader reads records from a dissemination file
+type Reader struct {
+	csv     *csv.Reader
+	columns columns
+	fields  int
+	// base is the file offset the csv reader started at
+	base int64
+}
+
+// NewReader reads the file's header and prepares to read its records
+func NewReader(r io.Reader) (*Reader, error) {
+	// A byte order mark would make the quoted first column malformed
+	br := bufio.NewReader(r)
+	var base int64
+	if bom, _ := br.Peek(len(byteOrderMark)); string(bom) == byteOrderMark {
+		br.Discard(len(byteOrderMark))
+		base = int64(len(byteOrderMark))
+	}
+
+	cr := newCSVReader(br)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHeader, err)
+	}
+	cols, err := parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	cr.FieldsPerRecord = len(header)
+	return &Reader{csv: cr, columns: cols, fields: len(header), base: base}, nil
+}
+
+// Resume reads the file's header and then its records from offset, which
+// Offset returned for an earlier reader of the same file. An offset of zero
+// reads every record.
+func Resume(r io.ReadSeeker, offset int64) (*Reader, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rd, err := NewReader(r)
+	if err != nil || offset == 0 {
+		return rd, err
+	}
+	if offset < rd.Offset() {
+		return nil, fmt.Errorf("offset %d is inside the header", offset)
+	}
+
+	// The csv reader buffers ahead, so reading on means starting another
+	// at the offset
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rd.csv = newCSVReader(r)
+	rd.csv.FieldsPerRecord = rd.fields
+	rd.base = offset
+	return rd, nil
+}
+
+func newCSVReader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	return cr
+}
+
+// Offset returns the byte offset of the next record in the file
+func (r *Reader) Offset() int64 {
+	return r.base + r.csv.InputOffset()
+}
+
+// Next returns the next record, or io.EOF after the last
+func (r *Reader) Next() (*Record, error) {
+	row, err := r.csv.Read()
+	if err != nil {
+		return nil, err
+	}
+	c := &r.columns
+	field := func(i int) string {
+		if i < 0 {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	rec := &Record{
+		Provider:   &pb.Provider{Npi: field(c.npi), ProviderId: field(c.npi)},
+		EntityType: field(c.entityType),
+	}
+	if rec.Updated, err = parseDate(field(c.updated)); err != nil {
+		return nil, fmt.Errorf("NPI %s: last update date: %w", rec.NPI(), err)
+	}
+	deactivated, err := parseDate(field(c.deactivated))
+	if err != nil {
+		return nil, fmt.Errorf("NPI %s: deactivation date: %w", rec.NPI(), err)
+	}
+	reactivated, err := parseDate(field(c.reactivate))
+	if err != nil {
+		return nil, fmt.Errorf("NPI %s: reactivation date: %w", rec.NPI(), err)
+	}
+	if !deactivated.IsZero() && reactivated.Before(deactivated) {
+		rec.Deactivated = deactivated
+		return rec, nil
+	}
+
+	p := rec.Provider
+	if rec.EntityType == EntityOrganization {
+		p.PracticeName = field(c.organization)
+	} else {
+		p.FirstName = titleCase(field(c.firstName))
+		p.LastName = titleCase(field(c.lastName))
+		p.Gender = field(c.gender)
+	}
+
+	// Practice locations outside the US can't be searched by distance
+	if country := field(c.country); country == "" || country == "US" {
+		zip := field(c.zip)
+		if len(zip) == 9 {
+			zip = zip[:5] + "-" + zip[5:]
+		}
+		p.Locations = []*pb.ProviderLocation{{
+			LocationId: PracticeID(p.Npi),
+			Address: &pb.Address{
+				Street1: titleCase(field(c.street1)),
+				Street2: titleCase(field(c.street2)),
+				City:    titleCase(field(c.city)),
+				State:   field(c.state),
+				ZipCode: zip,
+				Country: "USA",
+			},
+			Phone: phone(field(c.phone)),
+			Fax:   phone(field(c.fax)),
+		}}
+	}
+
+	for i := 0; i < taxonomySlots; i++ {
+		code := field(c.taxonomy[i])
+		if code == "" {
+			continue
+		}
+		p.Taxonomies = append(p.Taxonomies, &pb.ProviderTaxonomy{
+			Code:    code,
+			Primary: field(c.primary[i]) == "Y",
+		})
+	}
+	return rec, nil
+}
+
+// parseHeader finds the columns read. Later files call the gender column
+// the sex code.
+func parseHeader(header []string) (columns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+
+	var missing []string
+	find := func(required bool, names ...string) int {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				return i
+			}
+		}
+		if required {
+			missing = append(missing, names[0])
+		}
+		return -1
+	}
+
+	c := columns{
+		npi:          find(true, "NPI"),
+		entityType:   find(true, "Entity Type Code"),
+		organization: find(true, "Provider Organization Name (Legal Business Name)"),
+		lastName:     find(true, "Provider Last Name (Legal Name)"),
+		firstName:    find(true, "Provider First Name"),
+		street1:      find(true, "Provider First Line Business Practice Location Address"),
+		street2:      find(false, "Provider Second Line Business Practice Location Address"),
+		city:         find(true, "Provider Business Practice Location Address City Name"),
+		state:        find(true, "Provider Business Practice Location Address State Name"),
+		zip:          find(true, "Provider Business Practice Location Address Postal Code"),
+		country:      find(false, "Provider Business Practice Location Address Country Code (If outside U.S.)"),
+		phone:        find(false, "Provider Business Practice Location Address Telephone Number"),
+		fax:          find(false, "Provider Business Practice Location Address Fax Number"),
+		gender:       find(false, "Provider Gender Code", "Provider Sex Code"),
+		updated:      find(false, "Last Update Date"),
+		deactivated:  find(true, "NPI Deactivation Date"),
+		reactivate:   find(false, "NPI Reactivation Date"),
+	}
+	for i := 0; i < taxonomySlots; i++ {
+		c.taxonomy[i] = find(false, fmt.Sprintf("Healthcare Provider Taxonomy Code_%d", i+1))
+		c.primary[i] = find(false, fmt.Sprintf("Healthcare Provider Primary Taxonomy Switch_%d", i+1))
+	}
+	if c.taxonomy[0] < 0 {
+		missing = append(missing, "Healthcare Provider Taxonomy Code_1")
+	}
+
+	if len(missing) > 0 {
+		return c, fmt.Errorf("%w: no %s column", ErrHeader, strings.Join(missing, ", "))
+	}
+	return c, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateLayout, s)
+}
+
+// phone formats a 10-digit number like the directory's other numbers and
+// leaves anything else as it is
+func phone(s string) string {
+	if len(s) != 10 || strings.Trim(s, "0123456789") != "" {
+		return s
+	}
+	return fmt.Sprintf("+1-%s-%s-%s", s[:3], s[3:6], s[6:])
+}
+
+// titleCase turns the file's upper-case names and addresses into "Main St"
+// and "O'Brien-Smith", leaving words that are already mixed case
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		if w != strings.ToUpper(w) {
+			continue
+		}
+		lower := strings.ToLower(w)
+		b := []byte(lower)
+		for j := range b {
+			if j == 0 || !isAlphanumeric(lower[j-1]) {
+				b[j] = byte(unicode.ToUpper(rune(b[j])))
+			}
+		}
+		words[i] = string(b)
+	}
+	return strings.Join(words, " ")
+}
+
+func isAlphanumeric(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+}
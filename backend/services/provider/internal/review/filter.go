@@ -0,0 +1,101 @@
+package review
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Flags the filter raises
+const (
+	FlagProfanity = "PROFANITY"
+	FlagPHI       = "PHI"
+)
+
+// profaneStems are flagged wherever they appear in a word, catching
+// compounds such as "bullshit"
+var profaneStems = []string{"fuck", "shit"}
+
+// profaneWords are flagged only as whole words, since they are also parts of
+// harmless ones ("Scunthorpe") or names ("Dick")
+var profaneWords = map[string]bool{
+	"ass": true, "asshole": true, "bastard": true, "bitch": true, "bitches": true,
+	"bitchy": true, "cunt": true, "cunts": true, "dickhead": true, "douche": true,
+	"douchebag": true, "jackass": true, "piss": true, "pissed": true, "prick": true,
+	"slut": true, "twat": true, "wanker": true, "whore": true,
+}
+
+// leet undoes the usual letter substitutions before words are matched
+var leet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+// phiPatterns find details that could identify the member or their records:
+// SSNs, phone numbers, email addresses, dates such as a date of birth,
+// member, policy and record numbers, and other long account-like numbers
+var phiPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b\d{3}[- ]\d{2}[- ]\d{4}\b`),
+	regexp.MustCompile(`\(?\b\d{3}\)?[-. ]?\d{3}[-. ]\d{4}\b`),
+	regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.-]+`),
+	regexp.MustCompile(`\b\d{1,2}[/-]\d{1,2}[/-](\d{4}|\d{2})\b`),
+	regexp.MustCompile(`(?i)\b(ssn|social security|dob|date of birth|mrn|medical record|member (id|number|#)|policy (number|#)|subscriber id|account (number|#))\b`),
+	regexp.MustCompile(`(?i)\b[a-z]{0,4}\d{7,}\b`),
+}
+
+// Screen checks a review's text, returning the flags it raises in a fixed
+// order, or none for text that can be published as is
+func Screen(texts ...string) []string {
+	var flags []string
+	if profane(texts) {
+		flags = append(flags, FlagProfanity)
+	}
+	if identifying(texts) {
+		flags = append(flags, FlagPHI)
+	}
+	return flags
+}
+
+func profane(texts []string) bool {
+	for _, text := range texts {
+		for _, word := range words(text) {
+			if profaneWords[word] {
+				return true
+			}
+			for _, stem := range profaneStems {
+				if strings.Contains(word, stem) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// words splits text into lowercase words with leet substitutions undone.
+// Tokens without letters are left out, since numbers such as "455" aren't
+// words in disguise.
+func words(text string) []string {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isLetter(r) && !(r >= '0' && r <= '9') && !strings.ContainsRune("@$!", r)
+	})
+	var words []string
+	for _, token := range tokens {
+		token = strings.TrimRight(token, "!")
+		if strings.ContainsFunc(token, isLetter) {
+			words = append(words, leet.Replace(token))
+		}
+	}
+	return words
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z'
+}
+
+func identifying(texts []string) bool {
+	for _, text := range texts {
+		for _, pattern := range phiPatterns {
+			if pattern.MatchString(text) {
+				return true
+			}
+		}
+	}
+	return false
+}
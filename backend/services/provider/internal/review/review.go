@@ -0,0 +1,325 @@
+// Package review takes members' reviews of providers and keeps providers'
+// ratings.
+//
+// Members may review a provider once they have a paid claim with them. Each
+// review is screened for profanity and for details that could identify the
+// member, and waits in the moderation queue, with any flags, until a support
+// agent approves or rejects it. Deployments that trust the screen can
+// publish clean reviews right away with Options.AutoPublish. A
+// provider's rating is the average of its published reviews, smoothed
+// toward PriorMean so a provider with a handful of reviews isn't ranked on
+// one or two of them. Stores keep each provider's review count and star
+// total, adjusting them as reviews are published or taken down rather than
+// reading every review again.
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Ratings are whole stars
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Bayesian smoothing: a provider's average is taken as if it also had
+// PriorWeight reviews of PriorMean stars, so it starts near PriorMean and
+// moves to its own average as reviews come in
+const (
+	PriorMean   = 4.0
+	PriorWeight = 5
+)
+
+// Longest title and body, in characters
+const (
+	MaxTitleLength = 120
+	MaxBodyLength  = 4000
+)
+
+// Page sizes for listing
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	// ErrNotFound is returned for an unknown review ID
+	ErrNotFound = errors.New("review not found")
+	// ErrInvalid is returned, wrapped with the problem, for requests that
+	// can't be accepted
+	ErrInvalid = errors.New("invalid review")
+	// ErrNotEligible is returned when the member has no paid claim with the
+	// provider. It doesn't say which, so it can't be used to learn about the
+	// member's visits.
+	ErrNotEligible = errors.New("member is not eligible to review this provider")
+	// ErrAlreadyReviewed is returned when the member already has a pending or
+	// published review of the provider
+	ErrAlreadyReviewed = errors.New("member has already reviewed this provider")
+	// ErrInvalidTransition is returned for a decision the review's status
+	// doesn't allow
+	ErrInvalidTransition = errors.New("invalid moderation decision")
+	// ErrConflict is returned when the review was moderated while it was
+	// being updated
+	ErrConflict = errors.New("review was moderated concurrently")
+)
+
+// Store keeps reviews and providers' review totals
+type Store interface {
+	// PaidClaim reports whether the member has a paid claim with the
+	// provider
+	PaidClaim(ctx context.Context, memberID, providerID string) (bool, error)
+	// Create saves a new review, counting it in the provider's totals if it
+	// is approved. It returns ErrAlreadyReviewed if the member has a pending
+	// or approved review of the provider.
+	Create(ctx context.Context, r *pb.ProviderReview) error
+	// Get returns the review, or ErrNotFound
+	Get(ctx context.Context, id string) (*pb.ProviderReview, error)
+	// Published returns a page of the provider's approved reviews, newest
+	// first, and how many there are in all
+	Published(ctx context.Context, providerID string, offset, limit int) ([]*pb.ProviderReview, int, error)
+	// Pending returns a page of the reviews waiting for moderation, oldest
+	// first, and how many there are in all
+	Pending(ctx context.Context, offset, limit int) ([]*pb.ProviderReview, int, error)
+	// Moderate saves r's new status if the stored review still has status
+	// from, returning ErrConflict otherwise, and adds r to or takes it out of
+	// the provider's totals as it is approved or leaves approval
+	Moderate(ctx context.Context, r *pb.ProviderReview, from pb.ReviewStatus) error
+}
+
+// Directory finds providers; satisfied by *directory.Directory
+type Directory interface {
+	Provider(ctx context.Context, id string) (*pb.Provider, error)
+}
+
+// Options configure a Moderator
+type Options struct {
+	// AutoPublish publishes reviews the screen doesn't flag without waiting
+	// for a support agent. Off, every review is moderated.
+	AutoPublish bool
+}
+
+// Moderator takes, screens, lists and moderates reviews
+type Moderator struct {
+	providers Directory
+	store     Store
+	opts      Options
+	now       func() time.Time
+}
+
+// NewModerator creates a moderator over store
+func NewModerator(providers Directory, store Store, opts Options) *Moderator {
+	return &Moderator{providers: providers, store: store, opts: opts, now: time.Now}
+}
+
+// Submit takes a member's review of a provider they have a paid claim with,
+// queueing it for moderation unless it is clean and AutoPublish is set
+func (m *Moderator) Submit(ctx context.Context, req *pb.SubmitReviewRequest) (*pb.ProviderReview, error) {
+	title, body := strings.TrimSpace(req.Title), strings.TrimSpace(req.Body)
+	switch {
+	case req.MemberId == "" || req.ProviderId == "":
+		return nil, fmt.Errorf("%w: member_id and provider_id are required", ErrInvalid)
+	case req.Rating < MinRating || req.Rating > MaxRating:
+		return nil, fmt.Errorf("%w: rating must be %d to %d stars", ErrInvalid, MinRating, MaxRating)
+	case utf8.RuneCountInString(title) > MaxTitleLength:
+		return nil, fmt.Errorf("%w: title can be at most %d characters", ErrInvalid, MaxTitleLength)
+	case utf8.RuneCountInString(body) > MaxBodyLength:
+		return nil, fmt.Errorf("%w: body can be at most %d characters", ErrInvalid, MaxBodyLength)
+	}
+
+	if _, err := m.providers.Provider(ctx, req.ProviderId); err != nil {
+		return nil, err
+	}
+	paid, err := m.store.PaidClaim(ctx, req.MemberId, req.ProviderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check claims: %w", err)
+	}
+	if !paid {
+		return nil, ErrNotEligible
+	}
+
+	now := m.now()
+	r := &pb.ProviderReview{
+		ReviewId:   uuid.NewString(),
+		ProviderId: req.ProviderId,
+		MemberId:   req.MemberId,
+		Rating:     req.Rating,
+		Title:      title,
+		Body:       body,
+		Status:     pb.ReviewStatus_REVIEW_STATUS_PENDING,
+		Flags:      Screen(title, body),
+		CreatedAt:  timestamppb.New(now),
+	}
+	if m.opts.AutoPublish && len(r.Flags) == 0 {
+		r.Status = pb.ReviewStatus_REVIEW_STATUS_APPROVED
+		r.ModeratedAt = timestamppb.New(now)
+	}
+	if err := m.store.Create(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// List returns a page of the provider's published reviews, newest first,
+// with the provider so its rating can be shown alongside. Page tokens are
+// the offset of the page's first review.
+func (m *Moderator) List(ctx context.Context, providerID string, page *pb.PageRequest) ([]*pb.ProviderReview, *pb.PageResponse, *pb.Provider, error) {
+	if providerID == "" {
+		return nil, nil, nil, fmt.Errorf("%w: provider_id is required", ErrInvalid)
+	}
+	offset, size, err := bounds(page)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	provider, err := m.providers.Provider(ctx, providerID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	reviews, total, err := m.store.Published(ctx, providerID, offset, size)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, r := range reviews {
+		anonymize(r)
+	}
+	return reviews, pageResponse(offset, len(reviews), total), provider, nil
+}
+
+// Queue returns a page of the reviews waiting for moderation, oldest first
+func (m *Moderator) Queue(ctx context.Context, page *pb.PageRequest) ([]*pb.ProviderReview, *pb.PageResponse, error) {
+	offset, size, err := bounds(page)
+	if err != nil {
+		return nil, nil, err
+	}
+	reviews, total, err := m.store.Pending(ctx, offset, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reviews, pageResponse(offset, len(reviews), total), nil
+}
+
+// Moderate records an agent's decision: approving a pending review publishes
+// it, and rejecting a pending or published one keeps it off the provider's
+// page and out of its rating
+func (m *Moderator) Moderate(ctx context.Context, req *pb.ModerateReviewRequest, agentID string) (*pb.ProviderReview, error) {
+	reason := strings.TrimSpace(req.Reason)
+	switch {
+	case req.ReviewId == "":
+		return nil, fmt.Errorf("%w: review_id is required", ErrInvalid)
+	case req.Decision != pb.ReviewStatus_REVIEW_STATUS_APPROVED && req.Decision != pb.ReviewStatus_REVIEW_STATUS_REJECTED:
+		return nil, fmt.Errorf("%w: decision must be APPROVED or REJECTED", ErrInvalid)
+	case req.Decision == pb.ReviewStatus_REVIEW_STATUS_REJECTED && reason == "":
+		return nil, fmt.Errorf("%w: reason is required to reject a review", ErrInvalid)
+	}
+
+	r, err := m.store.Get(ctx, req.ReviewId)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed(r.Status, req.Decision) {
+		return nil, fmt.Errorf("%w: %s reviews can't be %s", ErrInvalidTransition, statusWord(r.Status), statusWord(req.Decision))
+	}
+
+	updated := proto.Clone(r).(*pb.ProviderReview)
+	updated.Status = req.Decision
+	updated.ModeratedBy = agentID
+	updated.ModeratedAt = timestamppb.New(m.now())
+	updated.RejectionReason = ""
+	if req.Decision == pb.ReviewStatus_REVIEW_STATUS_REJECTED {
+		updated.RejectionReason = reason
+	}
+	if err := m.store.Moderate(ctx, updated, r.Status); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Smoothed is the rating shown for a provider with count published reviews
+// totalling stars, rounded to a tenth of a star, or 0 with none
+func Smoothed(stars, count int) float64 {
+	if count <= 0 {
+		return 0
+	}
+	mean := (PriorMean*PriorWeight + float64(stars)) / float64(PriorWeight+count)
+	return math.Round(mean*10) / 10
+}
+
+// Counted reports whether a review with the status counts toward its
+// provider's rating
+func Counted(status pb.ReviewStatus) bool {
+	return status == pb.ReviewStatus_REVIEW_STATUS_APPROVED
+}
+
+// StatusName is the status as stored, e.g. "APPROVED"
+func StatusName(s pb.ReviewStatus) string {
+	return strings.TrimPrefix(s.String(), "REVIEW_STATUS_")
+}
+
+// ParseStatus parses a stored status
+func ParseStatus(name string) pb.ReviewStatus {
+	return pb.ReviewStatus(pb.ReviewStatus_value["REVIEW_STATUS_"+name])
+}
+
+// allowed reports whether a review can move from one status to another.
+// Rejected reviews stay rejected, since the member may have reviewed the
+// provider again since.
+func allowed(from, to pb.ReviewStatus) bool {
+	switch from {
+	case pb.ReviewStatus_REVIEW_STATUS_PENDING:
+		return true
+	case pb.ReviewStatus_REVIEW_STATUS_APPROVED:
+		return to == pb.ReviewStatus_REVIEW_STATUS_REJECTED
+	}
+	return false
+}
+
+func statusWord(s pb.ReviewStatus) string {
+	return strings.ToLower(StatusName(s))
+}
+
+// anonymize leaves out who wrote and moderated a published review, and why
+// it was flagged
+func anonymize(r *pb.ProviderReview) {
+	r.MemberId = ""
+	r.ModeratedBy = ""
+	r.Flags = nil
+}
+
+func bounds(page *pb.PageRequest) (offset, size int, err error) {
+	size = DefaultPageSize
+	if page == nil {
+		return 0, size, nil
+	}
+	if page.PageSize > 0 {
+		size = min(int(page.PageSize), MaxPageSize)
+	}
+	if page.PageToken != "" {
+		offset, err = strconv.Atoi(page.PageToken)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("%w: invalid page token", ErrInvalid)
+		}
+	}
+	return offset, size, nil
+}
+
+func pageResponse(offset, count, total int) *pb.PageResponse {
+	resp := &pb.PageResponse{TotalCount: int32(total)}
+	if end := offset + count; end < total {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp
+}
@@ -0,0 +1,140 @@
+package review
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Claim is a member's claim with a provider, as a MemoryStore is seeded
+type Claim struct {
+	MemberID   string
+	ProviderID string
+	Status     pb.ClaimStatus
+}
+
+// MemoryStore is an in-process Store for demo mode. Members may review the
+// providers their seeded claims were paid to, and since demo providers never
+// change, their ratings stay as generated.
+type MemoryStore struct {
+	mu      sync.Mutex
+	reviews map[string]*pb.ProviderReview
+	// paid holds the providers each member has a paid claim with
+	paid map[string]map[string]bool
+}
+
+// NewMemoryStore creates an in-process store with no reviews over the
+// members' claims
+func NewMemoryStore(claims []Claim) *MemoryStore {
+	s := &MemoryStore{
+		reviews: make(map[string]*pb.ProviderReview),
+		paid:    make(map[string]map[string]bool),
+	}
+	for _, c := range claims {
+		if c.Status != pb.ClaimStatus_CLAIM_STATUS_PAID {
+			continue
+		}
+		if s.paid[c.MemberID] == nil {
+			s.paid[c.MemberID] = make(map[string]bool)
+		}
+		s.paid[c.MemberID][c.ProviderID] = true
+	}
+	return s
+}
+
+func (s *MemoryStore) PaidClaim(ctx context.Context, memberID, providerID string) (bool, error) {
+	return s.paid[memberID][providerID], nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, r *pb.ProviderReview) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.reviews {
+		if other.MemberId == r.MemberId && other.ProviderId == r.ProviderId &&
+			other.Status != pb.ReviewStatus_REVIEW_STATUS_REJECTED {
+			return ErrAlreadyReviewed
+		}
+	}
+	s.reviews[r.ReviewId] = proto.Clone(r).(*pb.ProviderReview)
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*pb.ProviderReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reviews[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return proto.Clone(r).(*pb.ProviderReview), nil
+}
+
+func (s *MemoryStore) Published(ctx context.Context, providerID string, offset, limit int) ([]*pb.ProviderReview, int, error) {
+	reviews := s.matching(func(r *pb.ProviderReview) bool {
+		return r.ProviderId == providerID && r.Status == pb.ReviewStatus_REVIEW_STATUS_APPROVED
+	})
+	sort.Slice(reviews, func(i, j int) bool {
+		return !created(reviews[i], reviews[j])
+	})
+	return slice(reviews, offset, limit), len(reviews), nil
+}
+
+func (s *MemoryStore) Pending(ctx context.Context, offset, limit int) ([]*pb.ProviderReview, int, error) {
+	reviews := s.matching(func(r *pb.ProviderReview) bool {
+		return r.Status == pb.ReviewStatus_REVIEW_STATUS_PENDING
+	})
+	sort.Slice(reviews, func(i, j int) bool {
+		return created(reviews[i], reviews[j])
+	})
+	return slice(reviews, offset, limit), len(reviews), nil
+}
+
+func (s *MemoryStore) Moderate(ctx context.Context, r *pb.ProviderReview, from pb.ReviewStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.reviews[r.ReviewId]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Status != from {
+		return ErrConflict
+	}
+	s.reviews[r.ReviewId] = proto.Clone(r).(*pb.ProviderReview)
+	return nil
+}
+
+// matching returns copies of the reviews keep accepts
+func (s *MemoryStore) matching(keep func(*pb.ProviderReview) bool) []*pb.ProviderReview {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reviews []*pb.ProviderReview
+	for _, r := range s.reviews {
+		if keep(r) {
+			reviews = append(reviews, proto.Clone(r).(*pb.ProviderReview))
+		}
+	}
+	return reviews
+}
+
+// created orders reviews oldest first, then by ID
+func created(a, b *pb.ProviderReview) bool {
+	if !a.CreatedAt.AsTime().Equal(b.CreatedAt.AsTime()) {
+		return a.CreatedAt.AsTime().Before(b.CreatedAt.AsTime())
+	}
+	return a.ReviewId < b.ReviewId
+}
+
+func slice(reviews []*pb.ProviderReview, offset, limit int) []*pb.ProviderReview {
+	if offset >= len(reviews) {
+		return nil
+	}
+	return reviews[offset:min(offset+limit, len(reviews))]
+}
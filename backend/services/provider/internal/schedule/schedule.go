@@ -0,0 +1,223 @@
+// Package schedule reads locations' recurring weekly hours, cutting them into
+// appointment slots and describing them for display.
+//
+// Hours are local times in the location's time zone, so a slot at 9:00 AM
+// stays at 9:00 AM across daylight saving changes.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// DefaultSlotMinutes is the slot length of schedules that don't give one
+const DefaultSlotMinutes = 30
+
+// ErrInvalid is returned, wrapped with the problem, for schedules that can't
+// be read
+var ErrInvalid = errors.New("invalid schedule")
+
+// Slot is an appointment slot at a location
+type Slot struct {
+	LocationID string
+	Start      time.Time
+	End        time.Time
+}
+
+// window is one day's hours, in minutes after midnight
+type window struct {
+	day    time.Weekday
+	opens  int
+	closes int
+}
+
+// parsed is a schedule ready to cut slots from
+type parsed struct {
+	zone    *time.Location
+	slot    time.Duration
+	windows []window
+}
+
+// Validate checks a schedule's time zone, slot length and hours
+func Validate(s *pb.LocationSchedule) error {
+	_, err := parse(s)
+	return err
+}
+
+// Slots returns the location's slots starting at or after from and ending by
+// to, earliest first. Locations without a schedule have none.
+func Slots(l *pb.ProviderLocation, from, to time.Time) ([]Slot, error) {
+	if l.Schedule == nil {
+		return nil, nil
+	}
+	p, err := parse(l.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("location %s: %w", l.LocationId, err)
+	}
+
+	var slots []Slot
+	first := from.In(p.zone)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, p.zone)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, w := range p.windows {
+			if w.day != day.Weekday() {
+				continue
+			}
+			closes := at(day, w.closes)
+			for start := at(day, w.opens); !start.Add(p.slot).After(closes); start = start.Add(p.slot) {
+				end := start.Add(p.slot)
+				if start.Before(from) || end.After(to) {
+					continue
+				}
+				slots = append(slots, Slot{LocationID: l.LocationId, Start: start, End: end})
+			}
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots, nil
+}
+
+// SlotAt returns the location's slot starting at start, reporting false when
+// no slot starts then
+func SlotAt(l *pb.ProviderLocation, start time.Time) (Slot, bool, error) {
+	if l.Schedule == nil {
+		return Slot{}, false, nil
+	}
+	minutes := l.Schedule.SlotMinutes
+	if minutes == 0 {
+		minutes = DefaultSlotMinutes
+	}
+	slots, err := Slots(l, start, start.Add(time.Duration(minutes)*time.Minute))
+	if err != nil || len(slots) == 0 || !slots[0].Start.Equal(start) {
+		return Slot{}, false, err
+	}
+	return slots[0], true, nil
+}
+
+// Zone returns the schedule's time zone
+func Zone(s *pb.LocationSchedule) (*time.Location, error) {
+	if s == nil || s.TimeZone == "" {
+		return nil, fmt.Errorf("%w: time_zone is required", ErrInvalid)
+	}
+	zone, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalid, s.TimeZone)
+	}
+	return zone, nil
+}
+
+// Describe returns display lines for the schedule's hours, Monday first,
+// with consecutive days of the same hours on one line, such as
+// "Mon-Fri: 9:00 AM - 5:00 PM"
+func Describe(s *pb.LocationSchedule) []string {
+	p, err := parse(s)
+	if err != nil {
+		return nil
+	}
+
+	// Each day's hours as text, Monday through Sunday
+	var days [7]string
+	for i := range days {
+		day := time.Weekday((i + 1) % 7)
+		var spans []string
+		for _, w := range p.windows {
+			if w.day == day {
+				spans = append(spans, clock(w.opens)+" - "+clock(w.closes))
+			}
+		}
+		days[i] = strings.Join(spans, ", ")
+	}
+
+	var lines []string
+	for i := 0; i < len(days); {
+		j := i
+		for j+1 < len(days) && days[j+1] == days[i] {
+			j++
+		}
+		if days[i] != "" {
+			name := dayName(i)
+			if j > i {
+				name += "-" + dayName(j)
+			}
+			lines = append(lines, name+": "+days[i])
+		}
+		i = j + 1
+	}
+	return lines
+}
+
+func parse(s *pb.LocationSchedule) (*parsed, error) {
+	zone, err := Zone(s)
+	if err != nil {
+		return nil, err
+	}
+	minutes := s.SlotMinutes
+	if minutes == 0 {
+		minutes = DefaultSlotMinutes
+	}
+	if minutes < 5 || minutes > 240 {
+		return nil, fmt.Errorf("%w: slot_minutes must be from 5 to 240", ErrInvalid)
+	}
+
+	p := &parsed{zone: zone, slot: time.Duration(minutes) * time.Minute}
+	for _, h := range s.Hours {
+		if h.DayOfWeek < 0 || h.DayOfWeek > 6 {
+			return nil, fmt.Errorf("%w: day_of_week must be from 0 (Sunday) to 6 (Saturday)", ErrInvalid)
+		}
+		opens, err := minutesOf(h.Opens)
+		if err != nil {
+			return nil, err
+		}
+		closes, err := minutesOf(h.Closes)
+		if err != nil {
+			return nil, err
+		}
+		if closes <= opens {
+			return nil, fmt.Errorf("%w: hours on %s close before they open", ErrInvalid, time.Weekday(h.DayOfWeek))
+		}
+		p.windows = append(p.windows, window{day: time.Weekday(h.DayOfWeek), opens: opens, closes: closes})
+	}
+	sort.Slice(p.windows, func(i, j int) bool {
+		if p.windows[i].day != p.windows[j].day {
+			return p.windows[i].day < p.windows[j].day
+		}
+		return p.windows[i].opens < p.windows[j].opens
+	})
+	for i := 1; i < len(p.windows); i++ {
+		if prev := p.windows[i-1]; prev.day == p.windows[i].day && p.windows[i].opens < prev.closes {
+			return nil, fmt.Errorf("%w: hours on %s overlap", ErrInvalid, prev.day)
+		}
+	}
+	return p, nil
+}
+
+// minutesOf reads "HH:MM" on a 24-hour clock, allowing "24:00" for midnight
+// at the end of the day
+func minutesOf(s string) (int, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 ||
+		h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%w: %q is not a time in HH:MM format", ErrInvalid, s)
+	}
+	return h*60 + m, nil
+}
+
+// at returns the time minutes after midnight on day, in day's time zone
+func at(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// clock formats minutes after midnight as "9:00 AM"
+func clock(minutes int) string {
+	return time.Date(2000, 1, 1, minutes/60, minutes%60, 0, 0, time.UTC).Format("3:04 PM")
+}
+
+// dayName names the day i days after Monday
+func dayName(i int) string {
+	return time.Weekday((i + 1) % 7).String()[:3]
+}
@@ -0,0 +1,294 @@
+// Package search is an in-process, typo-tolerant text index of the provider
+// directory over provider names, practice names, specialties and languages.
+//
+// Queries are tokenised, synonyms such as "heart doctor" are replaced by the
+// specialty they mean, and each remaining term matches indexed terms exactly,
+// as a prefix, or within an edit distance that grows with the term's length.
+// A provider must match every term; its relevance weighs how well each term
+// matched by the field it matched in.
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Field is a part of a provider that is indexed
+type Field int
+
+// Indexed fields
+const (
+	FieldName Field = iota
+	FieldPractice
+	FieldSpecialty
+	FieldLanguage
+	numFields
+)
+
+// fieldWeights rank matches by where they were found
+var fieldWeights = [numFields]float64{
+	FieldName:      1.0,
+	FieldPractice:  0.7,
+	FieldSpecialty: 0.9,
+	FieldLanguage:  0.5,
+}
+
+// Match qualities. Typos cost more the more there are.
+const (
+	exactMatch  = 1.0
+	prefixMatch = 0.8
+	typoPenalty = 0.25
+)
+
+// minPrefix is the shortest query term matched as a prefix, except for the
+// unfinished term of a suggestion
+const minPrefix = 2
+
+// Query is a search. Each non-empty part must match: Text in any field,
+// Name in the provider or practice name and Specialty in the specialties.
+type Query struct {
+	Text      string
+	Name      string
+	Specialty string
+}
+
+// Empty reports whether the query has no terms to match
+func (q Query) Empty() bool {
+	return len(expand(q.Text)) == 0 && len(expand(q.Name)) == 0 && len(expand(q.Specialty)) == 0
+}
+
+// Hit is a provider matching a query with its relevance, from 0 to 1. The
+// provider is the index's own and must not be modified; Put replaces it
+// rather than changing it, so it can be read after the index moves on.
+type Hit struct {
+	Provider  *pb.Provider
+	Relevance float64
+}
+
+// Index holds providers and the terms of their indexed fields. It is safe
+// for concurrent use and is updated one provider at a time.
+type Index struct {
+	mu        sync.RWMutex
+	providers map[string]*pb.Provider
+	// postings lists the providers holding each term in each field
+	postings [numFields]map[string]map[string]bool
+	// terms are the terms of each provider, and specialtyNames its
+	// specialties and their aliases, to unindex it on update
+	terms          map[string][numFields][]string
+	specialtyNames map[string][]string
+	// specialties counts the providers with each specialty, for suggestions
+	specialties map[string]int
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	idx := &Index{
+		providers:      make(map[string]*pb.Provider),
+		terms:          make(map[string][numFields][]string),
+		specialtyNames: make(map[string][]string),
+		specialties:    make(map[string]int),
+	}
+	for f := range idx.postings {
+		idx.postings[f] = make(map[string]map[string]bool)
+	}
+	return idx
+}
+
+// Len returns how many providers are indexed
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.providers)
+}
+
+// Put indexes a provider, replacing any earlier version of it. Aliases are
+// other names for its specialties, such as the broader specialties members
+// search by, and are matched and suggested like them.
+func (idx *Index) Put(p *pb.Provider, aliases ...string) {
+	p = proto.Clone(p).(*pb.Provider)
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, s := range append(append([]string{}, p.Specialties...), aliases...) {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			names = append(names, s)
+		}
+	}
+
+	var fields [numFields][]string
+	fields[FieldName] = terms(p.FirstName + " " + p.LastName)
+	fields[FieldPractice] = terms(p.PracticeName)
+	for _, s := range names {
+		fields[FieldSpecialty] = append(fields[FieldSpecialty], terms(s)...)
+	}
+	for _, l := range p.Languages {
+		fields[FieldLanguage] = append(fields[FieldLanguage], terms(l)...)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(p.ProviderId)
+	idx.providers[p.ProviderId] = p
+	idx.terms[p.ProviderId] = fields
+	for f, ts := range fields {
+		for _, t := range ts {
+			ids := idx.postings[f][t]
+			if ids == nil {
+				ids = make(map[string]bool)
+				idx.postings[f][t] = ids
+			}
+			ids[p.ProviderId] = true
+		}
+	}
+	idx.specialtyNames[p.ProviderId] = names
+	for _, s := range names {
+		idx.specialties[s]++
+	}
+}
+
+// Remove unindexes a provider
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id string) {
+	if _, ok := idx.providers[id]; !ok {
+		return
+	}
+	for f, ts := range idx.terms[id] {
+		for _, t := range ts {
+			delete(idx.postings[f][t], id)
+			if len(idx.postings[f][t]) == 0 {
+				delete(idx.postings[f], t)
+			}
+		}
+	}
+	for _, s := range idx.specialtyNames[id] {
+		if idx.specialties[s]--; idx.specialties[s] == 0 {
+			delete(idx.specialties, s)
+		}
+	}
+	delete(idx.providers, id)
+	delete(idx.terms, id)
+	delete(idx.specialtyNames, id)
+}
+
+// Search returns the providers matching every part of the query, most
+// relevant first. They aren't copied; callers copy the ones they return.
+func (idx *Index) Search(q Query) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := idx.match(q, false)
+	hits := make([]Hit, 0, len(scores))
+	for id, relevance := range scores {
+		hits = append(hits, Hit{Provider: idx.providers[id], Relevance: relevance})
+	}
+	sortHits(hits)
+	return hits
+}
+
+// group is a list of query terms matched against some fields
+type group struct {
+	terms  []string
+	fields []Field
+}
+
+// match returns the relevance of each provider matching the query. With
+// completing, the last term of the query text is taken as unfinished and
+// only matched as a prefix.
+func (idx *Index) match(q Query, completing bool) map[string]float64 {
+	groups := []group{
+		{expand(q.Text), []Field{FieldName, FieldPractice, FieldSpecialty, FieldLanguage}},
+		{expand(q.Name), []Field{FieldName, FieldPractice}},
+		{expand(q.Specialty), []Field{FieldSpecialty}},
+	}
+
+	var scores map[string]float64
+	n := 0
+	for gi, g := range groups {
+		for ti, term := range g.terms {
+			unfinished := completing && gi == 0 && ti == len(g.terms)-1
+			best := idx.matchTerm(term, g.fields, unfinished)
+
+			// Providers must match every term
+			if scores == nil {
+				scores = best
+			} else {
+				for id := range scores {
+					if s, ok := best[id]; ok {
+						scores[id] += s
+					} else {
+						delete(scores, id)
+					}
+				}
+			}
+			n++
+			if len(scores) == 0 {
+				return nil
+			}
+		}
+	}
+
+	for id := range scores {
+		scores[id] /= float64(n)
+	}
+	return scores
+}
+
+// matchTerm returns each provider's best weighted match of one query term in
+// the fields
+func (idx *Index) matchTerm(term string, fields []Field, unfinished bool) map[string]float64 {
+	best := make(map[string]float64)
+	limit := maxEdits(term)
+	for _, f := range fields {
+		for indexed, ids := range idx.postings[f] {
+			quality := 0.0
+			switch {
+			case indexed == term:
+				quality = exactMatch
+			case (unfinished || len(term) >= minPrefix) && strings.HasPrefix(indexed, term):
+				quality = prefixMatch
+			case !unfinished && limit > 0:
+				if d := editDistance(term, indexed, limit); d <= limit {
+					quality = exactMatch - typoPenalty*float64(d)
+				}
+			}
+			if quality == 0 {
+				continue
+			}
+
+			score := quality * fieldWeights[f]
+			for id := range ids {
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+	}
+	return best
+}
+
+// sortHits orders hits by relevance, then rating, then ID so equal hits keep
+// their order between pages
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Relevance != b.Relevance {
+			return a.Relevance > b.Relevance
+		}
+		if a.Provider.Rating != b.Provider.Rating {
+			return a.Provider.Rating > b.Provider.Rating
+		}
+		return a.Provider.ProviderId < b.Provider.ProviderId
+	})
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Suggestion is one autocompletion of what a member is typing
+type Suggestion struct {
+	Type       pb.ProviderSuggestionType
+	Text       string
+	ProviderID string
+	relevance  float64
+	rating     float64
+}
+
+// Suggest completes a partly typed query with up to limit specialties,
+// providers and practices, the last word being taken as unfinished.
+// Specialties come first, then the rest by relevance and rating.
+func (idx *Index) Suggest(text string, limit int) []Suggestion {
+	if len(expand(text)) == 0 || limit <= 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var suggestions []Suggestion
+	for specialty := range idx.specialties {
+		if relevance := completes(text, specialty); relevance > 0 {
+			suggestions = append(suggestions, Suggestion{
+				Type:      pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_SPECIALTY,
+				Text:      specialty,
+				relevance: relevance,
+			})
+		}
+	}
+	// Synonyms suggest their specialty as the member starts to type them
+	typed := strings.Join(tokenize(text), " ")
+	for phrase, specialty := range synonyms {
+		if idx.specialties[specialty] > 0 && strings.HasPrefix(phrase, typed) && !suggested(suggestions, specialty) {
+			suggestions = append(suggestions, Suggestion{
+				Type:      pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_SPECIALTY,
+				Text:      specialty,
+				relevance: prefixMatch,
+			})
+		}
+	}
+
+	practices := make(map[string]*Suggestion)
+	for id, relevance := range idx.match(Query{Text: text}, true) {
+		p := idx.providers[id]
+		if name := DisplayName(p); completes(text, name) > 0 {
+			suggestions = append(suggestions, Suggestion{
+				Type:       pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_PROVIDER,
+				Text:       name,
+				ProviderID: id,
+				relevance:  relevance,
+				rating:     p.Rating,
+			})
+		}
+		if p.PracticeName != "" && completes(text, p.PracticeName) > 0 {
+			s, ok := practices[p.PracticeName]
+			if !ok {
+				s = &Suggestion{
+					Type: pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_PRACTICE,
+					Text: p.PracticeName,
+				}
+				practices[p.PracticeName] = s
+			}
+			s.relevance = max(s.relevance, relevance)
+			s.rating = max(s.rating, p.Rating)
+		}
+	}
+	for _, s := range practices {
+		suggestions = append(suggestions, *s)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if (a.Type == pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_SPECIALTY) != (b.Type == pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_SPECIALTY) {
+			return a.Type == pb.ProviderSuggestionType_PROVIDER_SUGGESTION_TYPE_SPECIALTY
+		}
+		if a.relevance != b.relevance {
+			return a.relevance > b.relevance
+		}
+		if a.rating != b.rating {
+			return a.rating > b.rating
+		}
+		return a.Text < b.Text
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// completes returns how well every term of the typed text matches some term
+// of the candidate, the last as a prefix, or 0 if one doesn't
+func completes(text, candidate string) float64 {
+	typed, words := expand(text), terms(candidate)
+	if len(typed) == 0 || len(words) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for i, term := range typed {
+		unfinished := i == len(typed)-1
+		best := 0.0
+		for _, word := range words {
+			switch {
+			case word == term:
+				best = max(best, exactMatch)
+			case (unfinished || len(term) >= minPrefix) && strings.HasPrefix(word, term):
+				best = max(best, prefixMatch)
+			case !unfinished:
+				if limit := maxEdits(term); limit > 0 {
+					if d := editDistance(term, word, limit); d <= limit {
+						best = max(best, exactMatch-typoPenalty*float64(d))
+					}
+				}
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total / float64(len(typed))
+}
+
+func suggested(suggestions []Suggestion, text string) bool {
+	for _, s := range suggestions {
+		if s.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// DisplayName is how a provider is shown: their name, or their practice's
+// when the provider is a facility
+func DisplayName(p *pb.Provider) string {
+	if name := strings.TrimSpace(p.FirstName + " " + p.LastName); name != "" {
+		return name
+	}
+	return p.PracticeName
+}
@@ -0,0 +1,161 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are left out of queries and the index. Titles and credentials
+// would otherwise match every provider.
+var stopwords = map[string]bool{
+	"dr": true, "doctor": true, "md": true, "do": true, "np": true, "pa": true,
+	"the": true, "of": true, "and": true, "a": true, "for": true,
+}
+
+// synonyms map what members type to the specialty it means. Phrases are
+// matched on whole tokens before stopwords are dropped, so "heart doctor"
+// works though "doctor" alone is ignored.
+var synonyms = map[string]string{
+	"heart doctor":           "Cardiology",
+	"heart":                  "Cardiology",
+	"cardiologist":           "Cardiology",
+	"cardio":                 "Cardiology",
+	"skin doctor":            "Dermatology",
+	"skin":                   "Dermatology",
+	"dermatologist":          "Dermatology",
+	"derm":                   "Dermatology",
+	"obgyn":                  "OB/GYN",
+	"ob gyn":                 "OB/GYN",
+	"gyn":                    "OB/GYN",
+	"gynecologist":           "OB/GYN",
+	"gynecology":             "OB/GYN",
+	"obstetrician":           "OB/GYN",
+	"obstetrics":             "OB/GYN",
+	"womens health":          "OB/GYN",
+	"kids doctor":            "Pediatrics",
+	"childrens doctor":       "Pediatrics",
+	"pediatrician":           "Pediatrics",
+	"peds":                   "Pediatrics",
+	"bone doctor":            "Orthopedics",
+	"orthopedist":            "Orthopedics",
+	"orthopedic":             "Orthopedics",
+	"ortho":                  "Orthopedics",
+	"psychiatrist":           "Psychiatry",
+	"mental health":          "Psychiatry",
+	"neurologist":            "Neurology",
+	"brain doctor":           "Neurology",
+	"family doctor":          "Primary Care",
+	"family medicine":        "Primary Care",
+	"general practitioner":   "Primary Care",
+	"gp":                     "Primary Care",
+	"pcp":                    "Primary Care",
+	"primary care physician": "Primary Care",
+	"primary care provider":  "Primary Care",
+	"internist":              "Primary Care",
+}
+
+// longestSynonym is the most tokens in a synonym phrase
+const longestSynonym = 3
+
+// tokenize lower-cases text and splits it into letters and digits, dropping
+// apostrophes so "children's" is one token
+func tokenize(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "'", "")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// terms returns the indexed terms of text: its tokens without stopwords
+func terms(text string) []string {
+	var out []string
+	for _, t := range tokenize(text) {
+		if !stopwords[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// expand returns a query's terms, with synonym phrases replaced by the terms
+// of their specialty
+func expand(query string) []string {
+	tokens := tokenize(query)
+
+	var out []string
+	for i := 0; i < len(tokens); {
+		matched := 0
+		for n := min(longestSynonym, len(tokens)-i); n > 0; n-- {
+			if specialty, ok := synonyms[strings.Join(tokens[i:i+n], " ")]; ok {
+				out = append(out, terms(specialty)...)
+				matched = n
+				break
+			}
+		}
+		if matched > 0 {
+			i += matched
+			continue
+		}
+		if !stopwords[tokens[i]] {
+			out = append(out, tokens[i])
+		}
+		i++
+	}
+	return out
+}
+
+// Synonym returns the specialty a phrase such as "heart doctor" means
+func Synonym(phrase string) (string, bool) {
+	specialty, ok := synonyms[strings.Join(tokenize(phrase), " ")]
+	return specialty, ok
+}
+
+// maxEdits is how many typos a query term of this length tolerates
+func maxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, counting an insertion, deletion, substitution or swap of adjacent
+// letters as one edit. It gives up and returns limit+1 once the distance
+// must exceed limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			best = min(best, cur[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,407 @@
+// Package taxonomy models provider specialties with the NUCC Health Care
+// Provider Taxonomy code set: a hierarchy of groupings, classifications under
+// them and specializations under those, each classification and
+// specialization identified by a ten-character code.
+//
+// Members don't search by NUCC names, so the package also holds specialties
+// as members know them, each covering some taxonomy nodes: Primary Care
+// covers Family Medicine, General Practice and Internal Medicine. Searching
+// for a specialty, a node's name or a code matches the nodes it names and
+// every node beneath them.
+package taxonomy
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+//go:embed data/nucc_taxonomy.csv
+var bundledCodes []byte
+
+//go:embed data/specialties.csv
+var bundledSpecialties []byte
+
+// Level is a node's depth in the hierarchy
+type Level int
+
+// Levels
+const (
+	LevelGrouping Level = iota
+	LevelClassification
+	LevelSpecialization
+)
+
+// displayNames replace NUCC names members wouldn't recognize
+var displayNames = map[string]string{
+	"207RC0000X": "Cardiology",
+	"207RE0101X": "Endocrinology",
+	"207RP1001X": "Pulmonology",
+	"207V00000X": "OB/GYN",
+	"207X00000X": "Orthopedic Surgery",
+	"207XS0114X": "Joint Replacement Surgery",
+	"207XS0117X": "Spine Surgery",
+	"207Y00000X": "Ear, Nose & Throat",
+	"2084N0402X": "Child Neurology",
+	"101YM0800X": "Mental Health Counselor",
+	"103TC0700X": "Clinical Psychologist",
+	"1041C0700X": "Clinical Social Worker",
+	"1223G0001X": "General Dentist",
+	"1223X0400X": "Orthodontics",
+	"363AM0700X": "Physician Assistant",
+	"363LF0000X": "Family Nurse Practitioner",
+	"363LP0200X": "Pediatric Nurse Practitioner",
+	"363LP0808X": "Psychiatric Nurse Practitioner",
+	"261QP2300X": "Primary Care Clinic",
+	"261QU0200X": "Urgent Care",
+	"282N00000X": "Hospital",
+	"291U00000X": "Lab",
+	"3336C0003X": "Retail Pharmacy",
+}
+
+// Node is a grouping, classification or specialization. Groupings have no
+// code, and nor do classifications the code set lists only through their
+// specializations.
+type Node struct {
+	Code           string
+	Level          Level
+	Grouping       string
+	Classification string
+	Specialization string
+	// DisplayName is what members are shown
+	DisplayName string
+	Parent      *Node
+	Children    []*Node
+}
+
+// Name is the node's own NUCC name
+func (n *Node) Name() string {
+	switch n.Level {
+	case LevelGrouping:
+		return n.Grouping
+	case LevelClassification:
+		return n.Classification
+	}
+	return n.Specialization
+}
+
+// Codes returns the codes of the node and every node beneath it
+func (n *Node) Codes() []string {
+	var codes []string
+	if n.Code != "" {
+		codes = append(codes, n.Code)
+	}
+	for _, child := range n.Children {
+		codes = append(codes, child.Codes()...)
+	}
+	return codes
+}
+
+// Specialty is a specialty as members search for it
+type Specialty struct {
+	Name string
+	// Codes are every taxonomy code the specialty covers
+	Codes []string
+}
+
+// Taxonomy is a loaded code set and the specialties over it
+type Taxonomy struct {
+	groupings []*Node
+	codes     map[string]*Node
+	// names lists the nodes with each normalized name
+	names       map[string][]*Node
+	specialties []*Specialty
+	// specialtyNames indexes specialties by normalized name, and covering
+	// lists the specialties covering each code
+	specialtyNames map[string]*Specialty
+	covering       map[string][]string
+}
+
+// Open loads the NUCC CSV at path, or the bundled excerpt when path is
+// empty, with the bundled specialties. Specialty codes missing from the code
+// set are left out.
+func Open(path string) (*Taxonomy, error) {
+	var r io.Reader = bytes.NewReader(bundledCodes)
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open taxonomy: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	t, err := Load(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.LoadSpecialties(bytes.NewReader(bundledSpecialties)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Load reads a NUCC taxonomy CSV. Columns are found by their header names:
+// Code, Grouping, Classification and Specialization are required and Display
+// Name is used when present. Lines starting with # are skipped.
+func Load(r io.Reader) (*Taxonomy, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read taxonomy header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		// NUCC's files start with a byte order mark
+		name = strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")
+		columns[strings.ToLower(name)] = i
+	}
+	for _, required := range []string{"code", "grouping", "classification", "specialization"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("taxonomy is missing the %s column", required)
+		}
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	t := &Taxonomy{
+		codes:          make(map[string]*Node),
+		names:          make(map[string][]*Node),
+		specialtyNames: make(map[string]*Specialty),
+		covering:       make(map[string][]string),
+	}
+	groupings := make(map[string]*Node)
+	classifications := make(map[string]*Node)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read taxonomy: %w", err)
+		}
+
+		code := strings.ToUpper(field(record, "code"))
+		grouping, classification := field(record, "grouping"), field(record, "classification")
+		specialization := field(record, "specialization")
+		if len(code) != 10 || grouping == "" || classification == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("invalid taxonomy code %q on line %d", code, line)
+		}
+		if _, ok := t.codes[code]; ok {
+			return nil, fmt.Errorf("taxonomy code %s is listed twice", code)
+		}
+
+		g, ok := groupings[grouping]
+		if !ok {
+			g = &Node{Level: LevelGrouping, Grouping: grouping, DisplayName: grouping}
+			groupings[grouping] = g
+			t.groupings = append(t.groupings, g)
+		}
+		key := grouping + "|" + classification
+		c, ok := classifications[key]
+		if !ok {
+			c = &Node{Level: LevelClassification, Grouping: grouping, Classification: classification, DisplayName: classification, Parent: g}
+			classifications[key] = c
+			g.Children = append(g.Children, c)
+		}
+
+		n := c
+		if specialization != "" {
+			n = &Node{Level: LevelSpecialization, Grouping: grouping, Classification: classification, Specialization: specialization, DisplayName: specialization, Parent: c}
+			c.Children = append(c.Children, n)
+		}
+		n.Code = code
+		if name := field(record, "display name"); name != "" {
+			n.DisplayName = name
+		}
+		if name, ok := displayNames[code]; ok {
+			n.DisplayName = name
+		}
+		t.codes[code] = n
+	}
+
+	for _, g := range t.groupings {
+		t.indexNames(g)
+	}
+	return t, nil
+}
+
+func (t *Taxonomy) indexNames(n *Node) {
+	for _, name := range []string{n.Name(), n.DisplayName} {
+		key := normalize(name)
+		if nodes := t.names[key]; len(nodes) == 0 || nodes[len(nodes)-1] != n {
+			t.names[key] = append(nodes, n)
+		}
+	}
+	for _, child := range n.Children {
+		t.indexNames(child)
+	}
+}
+
+// LoadSpecialties reads specialties as a name,codes CSV, codes separated by
+// spaces. A code covers its node and those beneath it, or with a leading =
+// its node alone.
+func (t *Taxonomy) LoadSpecialties(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read specialties: %w", err)
+		}
+		name := strings.TrimSpace(record[0])
+		if strings.EqualFold(name, "name") {
+			continue
+		}
+
+		s := &Specialty{Name: name}
+		seen := make(map[string]bool)
+		for _, code := range strings.Fields(record[1]) {
+			only := strings.HasPrefix(code, "=")
+			n, ok := t.codes[strings.ToUpper(strings.TrimPrefix(code, "="))]
+			if !ok {
+				continue
+			}
+			codes := n.Codes()
+			if only {
+				codes = []string{n.Code}
+			}
+			for _, c := range codes {
+				if !seen[c] {
+					seen[c] = true
+					s.Codes = append(s.Codes, c)
+					t.covering[c] = append(t.covering[c], s.Name)
+				}
+			}
+		}
+		if len(s.Codes) == 0 {
+			continue
+		}
+		t.specialties = append(t.specialties, s)
+		t.specialtyNames[normalize(name)] = s
+	}
+	return nil
+}
+
+// Node returns the node with the code
+func (t *Taxonomy) Node(code string) (*Node, bool) {
+	n, ok := t.codes[strings.ToUpper(strings.TrimSpace(code))]
+	return n, ok
+}
+
+// Groupings returns the top of the hierarchy in code set order
+func (t *Taxonomy) Groupings() []*Node {
+	return t.groupings
+}
+
+// Specialties returns the specialties members search by
+func (t *Taxonomy) Specialties() []*Specialty {
+	return t.specialties
+}
+
+// Covering returns the names of the specialties covering the code
+func (t *Taxonomy) Covering(code string) []string {
+	return t.covering[strings.ToUpper(code)]
+}
+
+// Resolve returns the codes matched by a search for a specialty, a taxonomy
+// code, or a grouping, classification or specialization by name or display
+// name: the nodes it names and all beneath them. Names are matched ignoring
+// case and punctuation. It reports false when nothing is named.
+func (t *Taxonomy) Resolve(text string) ([]string, bool) {
+	if n, ok := t.Node(text); ok {
+		return n.Codes(), true
+	}
+
+	key := normalize(text)
+	if key == "" {
+		return nil, false
+	}
+	if s, ok := t.specialtyNames[key]; ok {
+		return s.Codes, true
+	}
+
+	nodes := t.names[key]
+	if len(nodes) == 0 {
+		return nil, false
+	}
+	seen := make(map[string]bool)
+	var codes []string
+	for _, n := range nodes {
+		for _, code := range n.Codes() {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Strings(codes)
+	return codes, len(codes) > 0
+}
+
+// Describe fills in the names of a provider's taxonomy codes and puts their
+// display names, primary first, ahead of any specialties without a code.
+// Unknown codes keep what they have.
+func (t *Taxonomy) Describe(p *pb.Provider) {
+	if len(p.Taxonomies) == 0 {
+		return
+	}
+
+	sort.SliceStable(p.Taxonomies, func(i, j int) bool {
+		return p.Taxonomies[i].Primary && !p.Taxonomies[j].Primary
+	})
+	var specialties []string
+	seen := make(map[string]bool)
+	for _, pt := range p.Taxonomies {
+		if n, ok := t.Node(pt.Code); ok {
+			pt.Code = n.Code
+			pt.DisplayName = n.DisplayName
+			pt.Grouping = n.Grouping
+			pt.Classification = n.Classification
+			pt.Specialization = n.Specialization
+		}
+		if pt.DisplayName != "" && !seen[pt.DisplayName] {
+			seen[pt.DisplayName] = true
+			specialties = append(specialties, pt.DisplayName)
+		}
+	}
+	for _, s := range p.Specialties {
+		if !seen[s] {
+			seen[s] = true
+			specialties = append(specialties, s)
+		}
+	}
+	p.Specialties = specialties
+}
+
+// normalize lower-cases a name and keeps only its letters and digits, so
+// "OB/GYN", "ob-gyn" and "obgyn" are the same
+func normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
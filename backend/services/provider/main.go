@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/joho/godotenv"
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/appointment"
+	"github.com/sydney-health-clone/backend/services/provider/internal/careteam"
+	"github.com/sydney-health-clone/backend/services/provider/internal/directory"
+	"github.com/sydney-health-clone/backend/services/provider/internal/geo"
+	"github.com/sydney-health-clone/backend/services/provider/internal/network"
+	"github.com/sydney-health-clone/backend/services/provider/internal/review"
+	"github.com/sydney-health-clone/backend/services/provider/internal/taxonomy"
+	"github.com/sydney-health-clone/backend/services/provider/repository"
+	"github.com/sydney-health-clone/backend/services/provider/service"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	"github.com/sydney-health-clone/backend/shared/mockdata"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load("../../.env.development"); err != nil {
+		log.Printf("Warning: .env.development file not found")
+	}
+
+	demoMode, _ := strconv.ParseBool(os.Getenv("PROVIDER_DEMO_MODE"))
+
+	// Searches are geocoded from the bundled table of ZIP centroids unless
+	// another is configured
+	places, err := geo.OpenGazetteer(os.Getenv("PROVIDER_GEO_DATA"))
+	if err != nil {
+		log.Fatalf("Failed to load ZIP centroids: %v", err)
+	}
+
+	// Specialties are NUCC taxonomy codes, named from the bundled excerpt of
+	// the code set unless the full NUCC file is configured
+	codes, err := taxonomy.Open(os.Getenv("PROVIDER_TAXONOMY_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load provider taxonomy: %v", err)
+	}
+
+	// Demo mode searches mock providers, contracted with the demo plan's
+	// networks, and keeps appointments, reviews and care teams in memory
+	// without a database
+	var store directory.Store
+	var networks network.Store
+	var appointments appointment.Store
+	var reviews review.Store
+	var careTeams careteam.Store
+	if demoMode {
+		log.Printf("Demo mode: searching mock providers")
+		generator := mockdata.NewMockDataGenerator()
+		providers := generator.GenerateProviders(200)
+		demoNetworks := network.NewMemoryStore()
+		demoNetworks.Enroll(providers)
+		store = directory.NewMemoryStore(providers)
+		networks = demoNetworks
+		appointments = appointment.NewMemoryStore()
+		reviews = review.NewMemoryStore(demoClaims(generator, providers))
+		careTeams = careteam.NewMemoryStore()
+	} else {
+		db, err := database.InitDB()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+		store = repository.NewProviderStore(db)
+		networks = repository.NewNetworkStore(db)
+		appointments = repository.NewAppointmentStore(db)
+		reviews = repository.NewReviewStore(db)
+		careTeams = repository.NewCareTeamStore(db)
+	}
+
+	// Name and specialty searches use an in-process index, brought up to
+	// date with changed providers at most this often
+	refresh := 5 * time.Minute
+	if v := os.Getenv("PROVIDER_INDEX_REFRESH"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid PROVIDER_INDEX_REFRESH %q: %v", v, err)
+		}
+		refresh = d
+	}
+
+	// Reviews wait for a support agent unless clean ones are to be published
+	// right away
+	autoPublish := false
+	if v := os.Getenv("PROVIDER_REVIEWS_AUTO_PUBLISH"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid PROVIDER_REVIEWS_AUTO_PUBLISH %q: %v", v, err)
+		}
+		autoPublish = b
+	}
+
+	// Publish AppointmentUpdate events when a topic is configured
+	var events appointment.Publisher
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("APPOINTMENT_UPDATES_TOPIC"); brokers != "" && topic != "" {
+		producer := kafka.NewProducer(strings.Split(brokers, ","), topic)
+		defer producer.Close()
+		events = producer
+	}
+
+	// Initialize service
+	providerDirectory := directory.NewDirectory(store, places, codes, refresh)
+	checker := network.NewChecker(networks)
+	scheduler := appointment.NewScheduler(providerDirectory, appointments, events)
+	providerService := service.NewProviderService(
+		providerDirectory,
+		checker,
+		scheduler,
+		review.NewModerator(providerDirectory, reviews, review.Options{AutoPublish: autoPublish}),
+		careteam.NewRoster(providerDirectory, checker, codes, careTeams),
+	)
+
+	// Publish appointment updates queued with each change, retrying those
+	// the broker refused
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Relay(ctx, 30*time.Second)
+
+	// Get port from environment
+	port := os.Getenv("PROVIDER_SERVICE_PORT")
+	if port == "" {
+		port = "50053"
+	}
+
+	// Create gRPC server
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+
+	// Register provider service
+	pb.RegisterProviderServiceServer(grpcServer, providerService)
+
+	// Register health service
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// Register reflection service for debugging
+	reflection.Register(grpcServer)
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Provider service listening on port %s", port)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	// Setup graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("Shutting down provider service...")
+	grpcServer.GracefulStop()
+	log.Println("Provider service stopped")
+}
+
+// demoMembers are the member service's mock family
+var demoMembers = []string{"M123456", "M123457", "M123458"}
+
+// demoClaims generates mock claims for the demo members, which decide who
+// may review which mock provider. Mock claims name no provider, so each
+// member's are attributed to a different run of the providers in turn.
+func demoClaims(generator *mockdata.MockDataGenerator, providers []*pb.Provider) []review.Claim {
+	var claims []review.Claim
+	for i, memberID := range demoMembers {
+		for j, c := range generator.GenerateClaims(memberID, 10) {
+			p := providers[(i*10+j)%len(providers)]
+			claims = append(claims, review.Claim{MemberID: memberID, ProviderID: p.ProviderId, Status: c.Status})
+		}
+	}
+	return claims
+}
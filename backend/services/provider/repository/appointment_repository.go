@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/appointment"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// AppointmentStore keeps appointments in appointments. Its exclusion
+// constraint refuses overlapping appointments the provider has booked or
+// holds, so reservations are safe against concurrent bookings.
+type AppointmentStore struct {
+	db *database.DB
+}
+
+// NewAppointmentStore creates a new appointment store
+func NewAppointmentStore(db *database.DB) *AppointmentStore {
+	return &AppointmentStore{db: db}
+}
+
+// exclusionViolation is the SQLSTATE of appointments_no_overlap refusing a
+// row
+const exclusionViolation = "23P01"
+
+// appointmentColumns are read by scanAppointment
+const appointmentColumns = `
+	appointment_id, member_id, provider_id, location_id, start_time, end_time, time_zone,
+	status, hold_expires_at, visit_reason, cancellation_reason, version, created_at, updated_at
+`
+
+// settledStatus is an appointment's status with holds that have run out by
+// $1 counted as expired
+const settledStatus = `CASE WHEN status = 'HELD' AND hold_expires_at <= $1 THEN 'EXPIRED' ELSE status END`
+
+func (s *AppointmentStore) Reserve(ctx context.Context, a *pb.Appointment, now time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := expireHolds(ctx, tx, a, now); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO appointments (
+			appointment_id, member_id, provider_id, location_id, start_time, end_time, time_zone,
+			status, hold_expires_at, visit_reason, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, a.AppointmentId, a.MemberId, a.ProviderId, a.LocationId, a.StartTime.AsTime(), a.EndTime.AsTime(),
+		a.TimeZone, appointment.StatusName(a.Status), nullTime(a.HoldExpiresAt), a.VisitReason,
+		a.Version, a.CreatedAt.AsTime(), a.UpdatedAt.AsTime())
+	if err != nil {
+		return slotError(err, "failed to insert appointment")
+	}
+	return tx.Commit()
+}
+
+func (s *AppointmentStore) Get(ctx context.Context, id string) (*pb.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments WHERE appointment_id = $1`
+
+	a, err := scanAppointment(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, appointment.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appointment: %w", err)
+	}
+	return a, nil
+}
+
+func (s *AppointmentStore) List(ctx context.Context, memberID string, statuses []pb.AppointmentStatus, from, now time.Time, offset, limit int) ([]*pb.Appointment, int, error) {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = appointment.StatusName(status)
+	}
+	filter := `member_id = $2 AND start_time >= $3
+		AND (cardinality($4::text[]) = 0 OR ` + settledStatus + ` = ANY($4))`
+	args := []interface{}{now, memberID, from, pq.Array(names)}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM appointments WHERE `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count appointments: %w", err)
+	}
+
+	query := `
+		SELECT ` + appointmentColumns + `
+		FROM appointments
+		WHERE ` + filter + `
+		ORDER BY start_time, appointment_id
+		OFFSET $5 LIMIT $6
+	`
+	appointments, err := s.query(ctx, query, append(args, offset, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return appointments, total, nil
+}
+
+func (s *AppointmentStore) Active(ctx context.Context, providerID string, from, to, now time.Time) ([]*pb.Appointment, error) {
+	query := `
+		SELECT ` + appointmentColumns + `
+		FROM appointments
+		WHERE provider_id = $2 AND start_time < $4 AND end_time > $3
+		AND (status = 'BOOKED' OR (status = 'HELD' AND hold_expires_at > $1))
+		ORDER BY start_time
+	`
+	return s.query(ctx, query, now, providerID, from, to)
+}
+
+func (s *AppointmentStore) Update(ctx context.Context, a *pb.Appointment, now time.Time, event *kafka.AppointmentUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if appointment.Active(a, now) {
+		if err := expireHolds(ctx, tx, a, now); err != nil {
+			return err
+		}
+	}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE appointments SET
+			location_id = $2,
+			start_time = $3,
+			end_time = $4,
+			time_zone = $5,
+			status = $6,
+			hold_expires_at = $7,
+			visit_reason = $8,
+			cancellation_reason = $9,
+			version = $10,
+			updated_at = $11
+		WHERE appointment_id = $1 AND version = $10 - 1
+	`, a.AppointmentId, a.LocationId, a.StartTime.AsTime(), a.EndTime.AsTime(), a.TimeZone,
+		appointment.StatusName(a.Status), nullTime(a.HoldExpiresAt), a.VisitReason, a.CancellationReason,
+		a.Version, a.UpdatedAt.AsTime())
+	if err != nil {
+		return slotError(err, "failed to update appointment")
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := s.Get(ctx, a.AppointmentId); err != nil {
+			return err
+		}
+		return appointment.ErrConflict
+	}
+
+	if event != nil {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode appointment update: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO appointment_outbox (appointment_id, version, payload)
+			VALUES ($1, $2, $3)
+		`, event.AppointmentID, event.Version, payload); err != nil {
+			return fmt.Errorf("failed to queue appointment update: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Unsent returns the oldest queued events not yet published
+func (s *AppointmentStore) Unsent(ctx context.Context, limit int) ([]appointment.Queued, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT event_id, payload
+		FROM appointment_outbox
+		WHERE sent_at IS NULL
+		ORDER BY event_id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointment outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var queued []appointment.Queued
+	for rows.Next() {
+		var (
+			q       appointment.Queued
+			payload []byte
+		)
+		if err := rows.Scan(&q.ID, &payload); err != nil {
+			return nil, fmt.Errorf("failed to scan appointment outbox: %w", err)
+		}
+		if err := json.Unmarshal(payload, &q.Event); err != nil {
+			return nil, fmt.Errorf("failed to decode queued update %d: %w", q.ID, err)
+		}
+		queued = append(queued, q)
+	}
+	return queued, rows.Err()
+}
+
+// MarkSent records that a queued event was published
+func (s *AppointmentStore) MarkSent(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE appointment_outbox SET sent_at = NOW() WHERE event_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to mark appointment update sent: %w", err)
+	}
+	return nil
+}
+
+// expireHolds marks the provider's holds that overlap a and have run out by
+// now as expired, so they don't keep a from its slot. Their versions are
+// raised, so a member booking one of them at the same moment gets a
+// conflict.
+func expireHolds(ctx context.Context, tx *sql.Tx, a *pb.Appointment, now time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE appointments SET status = 'EXPIRED', hold_expires_at = NULL, version = version + 1, updated_at = $5
+		WHERE provider_id = $1 AND appointment_id <> $2 AND status = 'HELD' AND hold_expires_at <= $5
+		AND start_time < $4 AND end_time > $3
+	`, a.ProviderId, a.AppointmentId, a.StartTime.AsTime(), a.EndTime.AsTime(), now)
+	if err != nil {
+		return fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return nil
+}
+
+func (s *AppointmentStore) query(ctx context.Context, query string, args ...interface{}) ([]*pb.Appointment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	var appointments []*pb.Appointment
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appointment: %w", err)
+		}
+		appointments = append(appointments, a)
+	}
+	return appointments, rows.Err()
+}
+
+func scanAppointment(row scanner) (*pb.Appointment, error) {
+	a := &pb.Appointment{}
+	var (
+		status                       string
+		start, end, created, updated time.Time
+		holdExpires                  sql.NullTime
+	)
+	if err := row.Scan(
+		&a.AppointmentId, &a.MemberId, &a.ProviderId, &a.LocationId, &start, &end, &a.TimeZone,
+		&status, &holdExpires, &a.VisitReason, &a.CancellationReason, &a.Version, &created, &updated,
+	); err != nil {
+		return nil, err
+	}
+	a.Status = appointment.ParseStatus(status)
+	a.StartTime = timestamppb.New(start)
+	a.EndTime = timestamppb.New(end)
+	a.CreatedAt = timestamppb.New(created)
+	a.UpdatedAt = timestamppb.New(updated)
+	if holdExpires.Valid {
+		a.HoldExpiresAt = timestamppb.New(holdExpires.Time)
+	}
+	return a, nil
+}
+
+// slotError reports an exclusion violation as ErrSlotTaken
+func slotError(err error, message string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == exclusionViolation {
+		return appointment.ErrSlotTaken
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func nullTime(t *timestamppb.Timestamp) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.AsTime(), Valid: true}
+}
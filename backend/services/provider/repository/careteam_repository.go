@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/careteam"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// CareTeamStore keeps members' primary care assignments and favorites in
+// member_pcp_assignments and member_favorite_providers, and reads recently
+// seen providers from claims
+type CareTeamStore struct {
+	db *database.DB
+}
+
+// NewCareTeamStore creates a new care team store
+func NewCareTeamStore(db *database.DB) *CareTeamStore {
+	return &CareTeamStore{db: db}
+}
+
+func (s *CareTeamStore) RequiresPCP(ctx context.Context, planID string) (bool, error) {
+	var required bool
+	err := s.db.QueryRowContext(ctx, `SELECT requires_pcp FROM plans WHERE plan_id = $1`, planID).Scan(&required)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get plan: %w", err)
+	}
+	return required, nil
+}
+
+func (s *CareTeamStore) Assignments(ctx context.Context, memberID string) ([]*pb.PcpAssignment, error) {
+	query := `
+		SELECT assignment_id, provider_id, location_id, plan_id, effective_date, termination_date, created_at
+		FROM member_pcp_assignments
+		WHERE member_id = $1
+		ORDER BY effective_date
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list primary care assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []*pb.PcpAssignment
+	for rows.Next() {
+		a := &pb.PcpAssignment{MemberId: memberID}
+		var (
+			effective   time.Time
+			termination sql.NullTime
+			created     time.Time
+		)
+		if err := rows.Scan(&a.AssignmentId, &a.ProviderId, &a.LocationId, &a.PlanId,
+			&effective, &termination, &created); err != nil {
+			return nil, fmt.Errorf("failed to scan primary care assignment: %w", err)
+		}
+		a.EffectiveDate = timestamppb.New(effective)
+		if termination.Valid {
+			a.TerminationDate = timestamppb.New(termination.Time)
+		}
+		a.CreatedAt = timestamppb.New(created)
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+func (s *CareTeamStore) Assign(ctx context.Context, a *pb.PcpAssignment) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	effective := a.EffectiveDate.AsTime()
+
+	// The new assignment replaces any that hadn't started by its effective
+	// date and ends the one in effect the day before
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM member_pcp_assignments WHERE member_id = $1 AND effective_date >= $2::date
+	`, a.MemberId, effective); err != nil {
+		return fmt.Errorf("failed to remove later assignments: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE member_pcp_assignments SET termination_date = $2::date - 1
+		WHERE member_id = $1 AND (termination_date IS NULL OR termination_date >= $2::date)
+	`, a.MemberId, effective); err != nil {
+		return assignError(err, "failed to terminate current assignment")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO member_pcp_assignments (
+			assignment_id, member_id, provider_id, location_id, plan_id, effective_date, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6::date, $7)
+	`, a.AssignmentId, a.MemberId, a.ProviderId, a.LocationId, a.PlanId, effective, a.CreatedAt.AsTime())
+	if err != nil {
+		return assignError(err, "failed to insert assignment")
+	}
+	return assignError(tx.Commit(), "failed to commit assignment")
+}
+
+// assignError reports an assignment overlapping one saved concurrently as
+// careteam.ErrConflict
+func assignError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == exclusionViolation {
+		return careteam.ErrConflict
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func (s *CareTeamStore) Favorites(ctx context.Context, memberID string) ([]*pb.FavoriteProvider, error) {
+	query := `
+		SELECT provider_id, note, created_at, updated_at
+		FROM member_favorite_providers
+		WHERE member_id = $1
+		ORDER BY created_at DESC, provider_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list favorites: %w", err)
+	}
+	defer rows.Close()
+
+	var favorites []*pb.FavoriteProvider
+	for rows.Next() {
+		f, err := scanFavorite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan favorite: %w", err)
+		}
+		favorites = append(favorites, f)
+	}
+	return favorites, rows.Err()
+}
+
+func (s *CareTeamStore) SaveFavorite(ctx context.Context, memberID string, f *pb.FavoriteProvider) (*pb.FavoriteProvider, error) {
+	saved, err := scanFavorite(s.db.QueryRowContext(ctx, `
+		INSERT INTO member_favorite_providers (member_id, provider_id, note, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (member_id, provider_id) DO UPDATE SET
+			note = EXCLUDED.note,
+			updated_at = EXCLUDED.updated_at
+		RETURNING provider_id, note, created_at, updated_at
+	`, memberID, f.ProviderId, f.Note, f.CreatedAt.AsTime(), f.UpdatedAt.AsTime()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save favorite: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *CareTeamStore) RemoveFavorite(ctx context.Context, memberID, providerID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM member_favorite_providers WHERE member_id = $1 AND provider_id = $2
+	`, memberID, providerID)
+	if err != nil {
+		return fmt.Errorf("failed to remove favorite: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return careteam.ErrNotFound
+	}
+	return nil
+}
+
+func (s *CareTeamStore) Visits(ctx context.Context, memberID string, since time.Time) ([]careteam.Visit, error) {
+	query := `
+		SELECT c.claim_id, c.provider_id, c.service_date, l.service_code
+		FROM claims c
+		LEFT JOIN claim_line_items l ON l.claim_id = c.claim_id
+		WHERE c.member_id = $1 AND c.provider_id IS NOT NULL AND c.service_date >= $2::date AND c.status <> 'DENIED'
+		ORDER BY c.claim_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recently seen providers: %w", err)
+	}
+	defer rows.Close()
+
+	var visits []careteam.Visit
+	for rows.Next() {
+		var v careteam.Visit
+		var code sql.NullString
+		if err := rows.Scan(&v.ClaimID, &v.ProviderID, &v.ServiceDate, &code); err != nil {
+			return nil, fmt.Errorf("failed to scan recently seen provider: %w", err)
+		}
+		// One row per claim line; the claim's lines are adjacent
+		if n := len(visits); n > 0 && visits[n-1].ClaimID == v.ClaimID {
+			v = visits[n-1]
+			visits = visits[:n-1]
+		}
+		if code.Valid && code.String != "" {
+			v.ServiceCodes = append(v.ServiceCodes, code.String)
+		}
+		visits = append(visits, v)
+	}
+	return visits, rows.Err()
+}
+
+func scanFavorite(row scanner) (*pb.FavoriteProvider, error) {
+	f := &pb.FavoriteProvider{}
+	var created, updated time.Time
+	if err := row.Scan(&f.ProviderId, &f.Note, &created, &updated); err != nil {
+		return nil, err
+	}
+	f.CreatedAt = timestamppb.New(created)
+	f.UpdatedAt = timestamppb.New(updated)
+	return f, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/network"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// NetworkStore reads members' coverage spans and the networks plans use from
+// member_coverage_spans and plan_networks
+type NetworkStore struct {
+	db *database.DB
+}
+
+// NewNetworkStore creates a new network store
+func NewNetworkStore(db *database.DB) *NetworkStore {
+	return &NetworkStore{db: db}
+}
+
+func (s *NetworkStore) CoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error) {
+	query := `
+		SELECT span_id, coverage_type, plan_id, plan_name, group_number, effective_date, termination_date
+		FROM member_coverage_spans
+		WHERE member_id = $1
+		ORDER BY coverage_type, effective_date
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coverage spans: %w", err)
+	}
+	defer rows.Close()
+
+	var spans []*pb.CoverageSpan
+	for rows.Next() {
+		var (
+			coverageType    string
+			effectiveDate   sql.NullTime
+			terminationDate sql.NullTime
+		)
+		span := &pb.CoverageSpan{MemberId: memberID}
+		if err := rows.Scan(&span.SpanId, &coverageType, &span.PlanId, &span.PlanName, &span.GroupNumber,
+			&effectiveDate, &terminationDate); err != nil {
+			return nil, fmt.Errorf("failed to scan coverage span: %w", err)
+		}
+		span.CoverageType, _ = coverage.ParseType(coverageType)
+		span.EffectiveDate = timestamppb.New(effectiveDate.Time)
+		if terminationDate.Valid {
+			span.TerminationDate = timestamppb.New(terminationDate.Time)
+		}
+		spans = append(spans, span)
+	}
+	return spans, rows.Err()
+}
+
+func (s *NetworkStore) PlanNetworks(ctx context.Context, planID string, coverageType pb.CoverageType) ([]network.PlanNetwork, error) {
+	query := `
+		SELECT network_id, tier, effective_date, termination_date
+		FROM plan_networks
+		WHERE plan_id = $1 AND coverage_type = $2
+		ORDER BY effective_date, network_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, planID, coverage.TypeName(coverageType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plan networks: %w", err)
+	}
+	defer rows.Close()
+
+	var networks []network.PlanNetwork
+	for rows.Next() {
+		n := network.PlanNetwork{PlanID: planID, CoverageType: coverageType}
+		var tier int
+		var termination sql.NullTime
+		if err := rows.Scan(&n.NetworkID, &tier, &n.Effective, &termination); err != nil {
+			return nil, fmt.Errorf("failed to scan plan network: %w", err)
+		}
+		n.Tier = planTier(tier)
+		if termination.Valid {
+			n.Termination = termination.Time
+		}
+		networks = append(networks, n)
+	}
+	return networks, rows.Err()
+}
+
+// planTier maps plan_networks.tier, 1 or 2, to a tier
+func planTier(tier int) network.Tier {
+	switch tier {
+	case 1:
+		return network.Tier1
+	case 2:
+		return network.Tier2
+	default:
+		return network.OutOfNetwork
+	}
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/nppes"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// NPPESStore writes NPPES registry imports to providers, provider_locations
+// and provider_specialties, with their checkpoints in nppes_imports
+type NPPESStore struct {
+	db *database.DB
+}
+
+// NewNPPESStore creates a new NPPES import store
+func NewNPPESStore(db *database.DB) *NPPESStore {
+	return &NPPESStore{db: db}
+}
+
+func (s *NPPESStore) Checkpoint(ctx context.Context, file string, size int64) (*nppes.Checkpoint, error) {
+	cp := &nppes.Checkpoint{File: file, Size: size}
+	var completed sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT byte_offset, rows_read, rows_upserted, rows_unchanged, rows_deactivated, rows_invalid,
+		       started_at, completed_at
+		FROM nppes_imports
+		WHERE file_name = $1 AND file_size = $2
+	`, file, size).Scan(
+		&cp.Offset, &cp.Rows, &cp.Upserted, &cp.Unchanged, &cp.Deactivated, &cp.Invalid,
+		&cp.StartedAt, &completed,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPPES import checkpoint: %w", err)
+	}
+	cp.CompletedAt = completed.Time
+	return cp, nil
+}
+
+func (s *NPPESStore) Apply(ctx context.Context, cp *nppes.Checkpoint, records []*nppes.Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rec := range records {
+		if !rec.Deactivated.IsZero() {
+			deactivated, err := deactivate(ctx, tx, rec)
+			if err != nil {
+				return err
+			}
+			if deactivated {
+				cp.Deactivated++
+			} else {
+				cp.Unchanged++
+			}
+			continue
+		}
+
+		upserted, err := upsertProvider(ctx, tx, rec)
+		if err != nil {
+			return err
+		}
+		if upserted {
+			cp.Upserted++
+		} else {
+			cp.Unchanged++
+		}
+	}
+
+	var completed sql.NullTime
+	if cp.Completed() {
+		completed = sql.NullTime{Time: cp.CompletedAt, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO nppes_imports (
+			file_name, file_size, byte_offset, rows_read, rows_upserted, rows_unchanged,
+			rows_deactivated, rows_invalid, started_at, updated_at, completed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, $10)
+		ON CONFLICT (file_name, file_size) DO UPDATE SET
+			byte_offset = EXCLUDED.byte_offset,
+			rows_read = EXCLUDED.rows_read,
+			rows_upserted = EXCLUDED.rows_upserted,
+			rows_unchanged = EXCLUDED.rows_unchanged,
+			rows_deactivated = EXCLUDED.rows_deactivated,
+			rows_invalid = EXCLUDED.rows_invalid,
+			started_at = EXCLUDED.started_at,
+			updated_at = CURRENT_TIMESTAMP,
+			completed_at = EXCLUDED.completed_at
+	`, cp.File, cp.Size, cp.Offset, cp.Rows, cp.Upserted, cp.Unchanged,
+		cp.Deactivated, cp.Invalid, cp.StartedAt, completed)
+	if err != nil {
+		return fmt.Errorf("failed to save NPPES import checkpoint: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit NPPES import batch: %w", err)
+	}
+	return nil
+}
+
+// deactivate takes a deactivated NPI's provider out of the directory,
+// reporting whether it was listed
+func deactivate(ctx context.Context, tx *sql.Tx, rec *nppes.Record) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE providers SET npi_deactivated_on = $2
+		WHERE npi = $1 AND npi_deactivated_on IS NULL
+	`, rec.NPI(), rec.Deactivated)
+	if err != nil {
+		return false, fmt.Errorf("failed to deactivate NPI %s: %w", rec.NPI(), err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// upsertProvider writes a provider, its practice location and its taxonomy
+// codes, reporting whether the provider was new or changed. Practice names
+// and genders the registry doesn't give are kept, and specialties without a
+// code are left alone.
+func upsertProvider(ctx context.Context, tx *sql.Tx, rec *nppes.Record) (bool, error) {
+	p := rec.Provider
+	entityType := "INDIVIDUAL"
+	if rec.EntityType == nppes.EntityOrganization {
+		entityType = "ORGANIZATION"
+	}
+
+	var providerID string
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO providers (
+			provider_id, npi, entity_type, first_name, last_name, practice_name, gender,
+			nppes_updated_on, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (npi) DO UPDATE SET
+			entity_type = EXCLUDED.entity_type,
+			first_name = EXCLUDED.first_name,
+			last_name = EXCLUDED.last_name,
+			practice_name = COALESCE(NULLIF(EXCLUDED.practice_name, ''), providers.practice_name),
+			gender = COALESCE(NULLIF(EXCLUDED.gender, ''), providers.gender),
+			nppes_updated_on = EXCLUDED.nppes_updated_on,
+			npi_deactivated_on = NULL
+		WHERE providers.npi_deactivated_on IS NOT NULL
+		   OR providers.nppes_updated_on IS NULL
+		   OR EXCLUDED.nppes_updated_on IS NULL
+		   OR providers.nppes_updated_on < EXCLUDED.nppes_updated_on
+		RETURNING provider_id
+	`, p.ProviderId, p.Npi, entityType, p.FirstName, p.LastName, p.PracticeName, p.Gender,
+		nullDate(rec.Updated)).Scan(&providerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert provider with NPI %s: %w", p.Npi, err)
+	}
+
+	if err := upsertPractice(ctx, tx, providerID, p); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM provider_specialties WHERE provider_id = $1 AND taxonomy_code IS NOT NULL
+	`, providerID); err != nil {
+		return false, fmt.Errorf("failed to replace taxonomy codes for NPI %s: %w", p.Npi, err)
+	}
+	for _, t := range p.Taxonomies {
+		name := t.DisplayName
+		if name == "" {
+			name = t.Code
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO provider_specialties (provider_id, specialty, taxonomy_code, is_primary)
+			VALUES ($1, $2, $3, $4)
+		`, providerID, name, t.Code, t.Primary); err != nil {
+			return false, fmt.Errorf("failed to insert taxonomy code %s for NPI %s: %w", t.Code, p.Npi, err)
+		}
+	}
+	return true, nil
+}
+
+// upsertPractice writes the provider's practice location from the registry,
+// or removes the one imported before when the registry no longer gives one
+// in the US. Locations not from the registry are left alone.
+func upsertPractice(ctx context.Context, tx *sql.Tx, providerID string, p *pb.Provider) error {
+	if len(p.Locations) == 0 {
+		_, err := tx.ExecContext(ctx, `DELETE FROM provider_locations WHERE location_id = $1`, nppes.PracticeID(p.Npi))
+		if err != nil {
+			return fmt.Errorf("failed to remove practice location for NPI %s: %w", p.Npi, err)
+		}
+		return nil
+	}
+
+	l := p.Locations[0]
+	var lat, lng sql.NullFloat64
+	if l.Latitude != 0 || l.Longitude != 0 {
+		lat = sql.NullFloat64{Float64: l.Latitude, Valid: true}
+		lng = sql.NullFloat64{Float64: l.Longitude, Valid: true}
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO provider_locations (
+			location_id, provider_id, street1, street2, city, state, zip_code, phone, fax, latitude, longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (location_id) DO UPDATE SET
+			provider_id = EXCLUDED.provider_id,
+			street1 = EXCLUDED.street1,
+			street2 = EXCLUDED.street2,
+			city = EXCLUDED.city,
+			state = EXCLUDED.state,
+			zip_code = EXCLUDED.zip_code,
+			phone = EXCLUDED.phone,
+			fax = EXCLUDED.fax,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude
+	`, l.LocationId, providerID, l.Address.Street1, l.Address.Street2, l.Address.City, l.Address.State,
+		l.Address.ZipCode, l.Phone, l.Fax, lat, lng)
+	if err != nil {
+		return fmt.Errorf("failed to upsert practice location for NPI %s: %w", p.Npi, err)
+	}
+	return nil
+}
+
+func nullDate(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
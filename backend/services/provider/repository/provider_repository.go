@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/directory"
+	"github.com/sydney-health-clone/backend/services/provider/internal/geo"
+	"github.com/sydney-health-clone/backend/services/provider/internal/schedule"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// ProviderStore reads the provider directory from providers,
+// provider_specialties and provider_locations
+type ProviderStore struct {
+	db *database.DB
+}
+
+// NewProviderStore creates a new provider store
+func NewProviderStore(db *database.DB) *ProviderStore {
+	return &ProviderStore{db: db}
+}
+
+// providerColumns are read by scanProvider
+const providerColumns = `
+	p.provider_id, COALESCE(p.npi, ''), COALESCE(p.first_name, ''), COALESCE(p.last_name, ''),
+	COALESCE(p.practice_name, ''), COALESCE(p.gender, ''), p.languages,
+	COALESCE(p.accepting_new_patients, FALSE), COALESCE(p.rating, 0), COALESCE(p.review_count, 0),
+	ARRAY(SELECT s.specialty FROM provider_specialties s
+	      WHERE s.provider_id = p.provider_id AND s.taxonomy_code IS NULL
+	      ORDER BY s.is_primary DESC, s.specialty) AS specialties,
+	ARRAY(SELECT s.taxonomy_code FROM provider_specialties s
+	      WHERE s.provider_id = p.provider_id AND s.taxonomy_code IS NOT NULL
+	      ORDER BY s.is_primary DESC, s.taxonomy_code) AS taxonomy_codes,
+	(SELECT s.taxonomy_code FROM provider_specialties s
+	 WHERE s.provider_id = p.provider_id AND s.taxonomy_code IS NOT NULL AND s.is_primary
+	 ORDER BY s.taxonomy_code LIMIT 1) AS primary_taxonomy
+`
+
+// listed keeps providers whose NPI hasn't been deactivated
+const listed = `p.npi_deactivated_on IS NULL`
+
+// criteriaFilter matches listed providers to the criteria given as $1 to $4.
+// Names are searched by the directory's text index, not here, and the
+// directory drops locations outside the networks.
+const criteriaFilter = listed + `
+	AND (NOT $1 OR p.accepting_new_patients)
+	AND (cardinality($2::text[]) = 0 OR EXISTS (
+		SELECT 1 FROM provider_specialties s
+		WHERE s.provider_id = p.provider_id AND s.taxonomy_code = ANY($2)))
+	AND (cardinality($3::text[]) = 0 OR EXISTS (
+		SELECT 1 FROM network_contracts c
+		WHERE c.provider_id = p.provider_id AND c.network_id = ANY($3)
+		AND c.effective_date <= $4::date AND (c.termination_date IS NULL OR c.termination_date >= $4::date)))
+`
+
+// changeLag is how far back Changed reads again for late commits
+const changeLag = 5 * time.Minute
+
+// boundsFilter keeps locations l inside bounds given as four parameters
+// starting at $first, which the latitude and longitude index answers
+func boundsFilter(first int) string {
+	return fmt.Sprintf("l.latitude BETWEEN $%d AND $%d AND l.longitude BETWEEN $%d AND $%d",
+		first, first+1, first+2, first+3)
+}
+
+func (s *ProviderStore) Provider(ctx context.Context, id string) (*pb.Provider, error) {
+	query := `SELECT ` + providerColumns + ` FROM providers p WHERE p.provider_id = $1 AND ` + listed
+
+	p, err := scanProvider(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, directory.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	if err := s.attachLocations(ctx, []*pb.Provider{p}, nil); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *ProviderStore) List(ctx context.Context, c directory.Criteria, offset, limit int) ([]*pb.Provider, int, error) {
+	args := criteriaArgs(c)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM providers p WHERE `+criteriaFilter, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count providers: %w", err)
+	}
+
+	query := `
+		SELECT ` + providerColumns + `
+		FROM providers p
+		WHERE ` + criteriaFilter + `
+		ORDER BY p.last_name, p.first_name, p.provider_id
+		OFFSET $5 LIMIT $6
+	`
+	providers, err := s.queryProviders(ctx, query, append(args, offset, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.attachLocations(ctx, providers, nil); err != nil {
+		return nil, 0, err
+	}
+	return providers, total, nil
+}
+
+func (s *ProviderStore) Within(ctx context.Context, c directory.Criteria, b geo.Bounds) ([]*pb.Provider, error) {
+	query := `
+		SELECT ` + providerColumns + `
+		FROM providers p
+		WHERE ` + criteriaFilter + `
+		AND EXISTS (
+			SELECT 1 FROM provider_locations l
+			WHERE l.provider_id = p.provider_id AND ` + boundsFilter(5) + `)
+	`
+	args := append(criteriaArgs(c), b.MinLat, b.MaxLat, b.MinLng, b.MaxLng)
+	providers, err := s.queryProviders(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachLocations(ctx, providers, &b); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// Changed reads providers by updated_at, which triggers bump when a
+// provider's specialties or locations change. updated_at is when the change's
+// transaction began, so a change may commit after later ones have been read;
+// the last few minutes are read again to catch it.
+func (s *ProviderStore) Changed(ctx context.Context, since time.Time) (*directory.Changes, error) {
+	var latest sql.NullTime
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(updated_at) FROM providers`).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to check provider changes: %w", err)
+	}
+	if !latest.Valid {
+		return &directory.Changes{Latest: since}, nil
+	}
+
+	from := since
+	if !from.IsZero() {
+		from = from.Add(-changeLag)
+	}
+	changed := `p.updated_at >= $1 AND p.updated_at <= $2`
+	query := `SELECT ` + providerColumns + ` FROM providers p WHERE ` + changed + ` AND ` + listed
+	providers, err := s.queryProviders(ctx, query, from, latest.Time)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachLocations(ctx, providers, nil); err != nil {
+		return nil, err
+	}
+
+	changes := &directory.Changes{Providers: providers, Latest: latest.Time}
+	if since.IsZero() {
+		return changes, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT p.provider_id FROM providers p WHERE `+changed+` AND NOT (`+listed+`)`, from, latest.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query removed providers: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan removed provider: %w", err)
+		}
+		changes.Removed = append(changes.Removed, id)
+	}
+	return changes, rows.Err()
+}
+
+func (s *ProviderStore) queryProviders(ctx context.Context, query string, args ...interface{}) ([]*pb.Provider, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query providers: %w", err)
+	}
+	defer rows.Close()
+
+	var providers []*pb.Provider
+	for rows.Next() {
+		p, err := scanProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan provider: %w", err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, rows.Err()
+}
+
+// attachLocations sets the providers' locations, only those inside the
+// bounds when given, with their schedules and network contracts
+func (s *ProviderStore) attachLocations(ctx context.Context, providers []*pb.Provider, b *geo.Bounds) error {
+	if len(providers) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*pb.Provider, len(providers))
+	ids := make([]string, len(providers))
+	for i, p := range providers {
+		byID[p.ProviderId] = p
+		ids[i] = p.ProviderId
+	}
+
+	query := `
+		SELECT l.provider_id, l.location_id, l.street1, COALESCE(l.street2, ''), l.city, l.state, l.zip_code,
+		       COALESCE(l.phone, ''), COALESCE(l.fax, ''), l.office_hours,
+		       COALESCE(l.latitude, 0), COALESCE(l.longitude, 0), COALESCE(l.time_zone, ''), l.slot_minutes
+		FROM provider_locations l
+		WHERE l.provider_id = ANY($1)`
+	args := []interface{}{pq.Array(ids)}
+	if b != nil {
+		query += ` AND ` + boundsFilter(2)
+		args = append(args, b.MinLat, b.MaxLat, b.MinLng, b.MaxLng)
+	}
+	query += ` ORDER BY l.provider_id, l.location_id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query provider locations: %w", err)
+	}
+	defer rows.Close()
+
+	locations := make(map[string][]*pb.ProviderLocation, len(providers))
+	scheduled := make(map[string]*pb.ProviderLocation)
+	for rows.Next() {
+		var providerID, timeZone string
+		var slotMinutes int32
+		l := &pb.ProviderLocation{Address: &pb.Address{Country: "USA"}}
+		if err := rows.Scan(
+			&providerID, &l.LocationId, &l.Address.Street1, &l.Address.Street2,
+			&l.Address.City, &l.Address.State, &l.Address.ZipCode,
+			&l.Phone, &l.Fax, pq.Array(&l.OfficeHours),
+			&l.Latitude, &l.Longitude, &timeZone, &slotMinutes,
+		); err != nil {
+			return fmt.Errorf("failed to scan provider location: %w", err)
+		}
+		byID[providerID].Locations = append(byID[providerID].Locations, l)
+		locations[providerID] = append(locations[providerID], l)
+		if timeZone != "" {
+			l.Schedule = &pb.LocationSchedule{TimeZone: timeZone, SlotMinutes: slotMinutes}
+			scheduled[l.LocationId] = l
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := s.attachHours(ctx, scheduled); err != nil {
+		return err
+	}
+	return s.attachContracts(ctx, ids, locations)
+}
+
+// attachHours adds their weekly hours to locations with a time zone. Their
+// office_hours are rendered from the hours; locations without any hours
+// offer no appointments and keep the office_hours stored.
+func (s *ProviderStore) attachHours(ctx context.Context, locations map[string]*pb.ProviderLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(locations))
+	for id := range locations {
+		ids = append(ids, id)
+	}
+
+	query := `
+		SELECT location_id, day_of_week, TO_CHAR(opens, 'HH24:MI'), TO_CHAR(closes, 'HH24:MI')
+		FROM location_hours
+		WHERE location_id = ANY($1)
+		ORDER BY location_id, day_of_week, opens
+	`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query location hours: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var locationID string
+		h := &pb.OfficeHours{}
+		if err := rows.Scan(&locationID, &h.DayOfWeek, &h.Opens, &h.Closes); err != nil {
+			return fmt.Errorf("failed to scan location hours: %w", err)
+		}
+		l := locations[locationID]
+		l.Schedule.Hours = append(l.Schedule.Hours, h)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range locations {
+		if len(l.Schedule.Hours) == 0 {
+			l.Schedule = nil
+			continue
+		}
+		l.OfficeHours = schedule.Describe(l.Schedule)
+	}
+	return nil
+}
+
+// attachContracts adds the providers' network contracts to their locations.
+// Contracts without a location cover every location.
+func (s *ProviderStore) attachContracts(ctx context.Context, ids []string, locations map[string][]*pb.ProviderLocation) error {
+	query := `
+		SELECT c.provider_id, COALESCE(c.location_id, ''), c.network_id, n.name,
+		       c.effective_date, c.termination_date, c.covered_services
+		FROM network_contracts c
+		JOIN provider_networks n ON n.network_id = c.network_id
+		WHERE c.provider_id = ANY($1)
+		ORDER BY c.provider_id, c.network_id, c.effective_date
+	`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query network contracts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var providerID, locationID string
+		var effective time.Time
+		var termination sql.NullTime
+		n := &pb.NetworkParticipation{}
+		if err := rows.Scan(
+			&providerID, &locationID, &n.NetworkId, &n.NetworkName,
+			&effective, &termination, pq.Array(&n.CoveredServices),
+		); err != nil {
+			return fmt.Errorf("failed to scan network contract: %w", err)
+		}
+		n.EffectiveDate = timestamppb.New(effective)
+		if termination.Valid {
+			n.TerminationDate = timestamppb.New(termination.Time)
+		}
+		for _, l := range locations[providerID] {
+			if locationID == "" || locationID == l.LocationId {
+				l.Networks = append(l.Networks, n)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProvider(row scanner) (*pb.Provider, error) {
+	p := &pb.Provider{}
+	var codes []string
+	var primary sql.NullString
+	err := row.Scan(
+		&p.ProviderId, &p.Npi, &p.FirstName, &p.LastName,
+		&p.PracticeName, &p.Gender, pq.Array(&p.Languages),
+		&p.AcceptingNewPatients, &p.Rating, &p.ReviewCount,
+		pq.Array(&p.Specialties), pq.Array(&codes), &primary,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		p.Taxonomies = append(p.Taxonomies, &pb.ProviderTaxonomy{
+			Code:    code,
+			Primary: primary.Valid && code == primary.String,
+		})
+	}
+	return p, nil
+}
+
+// criteriaArgs are the parameters of criteriaFilter
+func criteriaArgs(c directory.Criteria) []interface{} {
+	taxonomies, networks := c.Taxonomies, c.Networks
+	if taxonomies == nil {
+		taxonomies = []string{}
+	}
+	if networks == nil {
+		networks = []string{}
+	}
+	return []interface{}{c.AcceptingNewPatients, pq.Array(taxonomies), pq.Array(networks), c.On}
+}
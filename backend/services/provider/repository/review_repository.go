@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/provider/internal/review"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// ReviewStore keeps reviews in provider_reviews and providers' review totals
+// in providers, changing both in one transaction so the totals always match
+// the approved reviews
+type ReviewStore struct {
+	db *database.DB
+}
+
+// NewReviewStore creates a new review store
+func NewReviewStore(db *database.DB) *ReviewStore {
+	return &ReviewStore{db: db}
+}
+
+// uniqueViolation is the SQLSTATE of idx_provider_reviews_member refusing a
+// member's second review of a provider
+const uniqueViolation = "23505"
+
+// reviewColumns are read by scanReview
+const reviewColumns = `
+	review_id, provider_id, member_id, rating, title, body, status, flags,
+	rejection_reason, moderated_by, created_at, moderated_at
+`
+
+func (s *ReviewStore) PaidClaim(ctx context.Context, memberID, providerID string) (bool, error) {
+	var paid bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM claims WHERE member_id = $1 AND provider_id = $2 AND status = 'PAID'
+		)
+	`, memberID, providerID).Scan(&paid)
+	if err != nil {
+		return false, fmt.Errorf("failed to check paid claims: %w", err)
+	}
+	return paid, nil
+}
+
+func (s *ReviewStore) Create(ctx context.Context, r *pb.ProviderReview) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO provider_reviews (
+			review_id, provider_id, member_id, rating, title, body, status, flags, created_at, moderated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, r.ReviewId, r.ProviderId, r.MemberId, r.Rating, r.Title, r.Body, review.StatusName(r.Status),
+		pq.Array(r.Flags), r.CreatedAt.AsTime(), nullTime(r.ModeratedAt))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return review.ErrAlreadyReviewed
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert review: %w", err)
+	}
+
+	if review.Counted(r.Status) {
+		if err := adjustRating(ctx, tx, r.ProviderId, 1, int(r.Rating)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *ReviewStore) Get(ctx context.Context, id string) (*pb.ProviderReview, error) {
+	query := `SELECT ` + reviewColumns + ` FROM provider_reviews WHERE review_id = $1`
+
+	r, err := scanReview(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, review.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	return r, nil
+}
+
+func (s *ReviewStore) Published(ctx context.Context, providerID string, offset, limit int) ([]*pb.ProviderReview, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM provider_reviews WHERE provider_id = $1 AND status = 'APPROVED'`, providerID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM provider_reviews
+		WHERE provider_id = $1 AND status = 'APPROVED'
+		ORDER BY created_at DESC, review_id DESC
+		OFFSET $2 LIMIT $3
+	`
+	reviews, err := s.query(ctx, query, providerID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (s *ReviewStore) Pending(ctx context.Context, offset, limit int) ([]*pb.ProviderReview, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM provider_reviews WHERE status = 'PENDING'`,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM provider_reviews
+		WHERE status = 'PENDING'
+		ORDER BY created_at, review_id
+		OFFSET $1 LIMIT $2
+	`
+	reviews, err := s.query(ctx, query, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (s *ReviewStore) Moderate(ctx context.Context, r *pb.ProviderReview, from pb.ReviewStatus) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE provider_reviews SET
+			status = $2,
+			rejection_reason = $3,
+			moderated_by = $4,
+			moderated_at = $5
+		WHERE review_id = $1 AND status = $6
+	`, r.ReviewId, review.StatusName(r.Status), r.RejectionReason, r.ModeratedBy, nullTime(r.ModeratedAt),
+		review.StatusName(from))
+	if err != nil {
+		return fmt.Errorf("failed to update review: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := s.Get(ctx, r.ReviewId); err != nil {
+			return err
+		}
+		return review.ErrConflict
+	}
+
+	// Approving adds the review to the provider's totals, and rejecting a
+	// published review takes it back out
+	if was, is := review.Counted(from), review.Counted(r.Status); was != is {
+		count := 1
+		if was {
+			count = -1
+		}
+		if err := adjustRating(ctx, tx, r.ProviderId, count, count*int(r.Rating)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// adjustRating adds count reviews totalling stars to the provider's totals,
+// then sets its rating from the new totals. The first update locks the
+// provider's row, so concurrent adjustments apply one after the other.
+func adjustRating(ctx context.Context, tx *sql.Tx, providerID string, count, stars int) error {
+	var reviews, total int
+	err := tx.QueryRowContext(ctx, `
+		UPDATE providers SET
+			review_count = COALESCE(review_count, 0) + $2,
+			rating_total = rating_total + $3
+		WHERE provider_id = $1
+		RETURNING review_count, rating_total
+	`, providerID, count, stars).Scan(&reviews, &total)
+	if err != nil {
+		return fmt.Errorf("failed to update review totals: %w", err)
+	}
+
+	rating := sql.NullFloat64{Float64: review.Smoothed(total, reviews), Valid: reviews > 0}
+	if _, err := tx.ExecContext(ctx, `UPDATE providers SET rating = $2 WHERE provider_id = $1`, providerID, rating); err != nil {
+		return fmt.Errorf("failed to update rating: %w", err)
+	}
+	return nil
+}
+
+func (s *ReviewStore) query(ctx context.Context, query string, args ...interface{}) ([]*pb.ProviderReview, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []*pb.ProviderReview
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+func scanReview(row scanner) (*pb.ProviderReview, error) {
+	r := &pb.ProviderReview{}
+	var (
+		status    string
+		flags     pq.StringArray
+		created   time.Time
+		moderated sql.NullTime
+	)
+	if err := row.Scan(
+		&r.ReviewId, &r.ProviderId, &r.MemberId, &r.Rating, &r.Title, &r.Body, &status, &flags,
+		&r.RejectionReason, &r.ModeratedBy, &created, &moderated,
+	); err != nil {
+		return nil, err
+	}
+	r.Status = review.ParseStatus(status)
+	r.Flags = flags
+	r.CreatedAt = timestamppb.New(created)
+	if moderated.Valid {
+		r.ModeratedAt = timestamppb.New(moderated.Time)
+	}
+	return r, nil
+}
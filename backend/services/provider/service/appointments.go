@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/appointment"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// GetAvailability lists a provider's open appointment slots
+func (s *ProviderService) GetAvailability(ctx context.Context, req *pb.GetAvailabilityRequest) (*pb.GetAvailabilityResponse, error) {
+	log.Printf("GetAvailability called for provider ID: %s, location ID: %s", req.ProviderId, req.LocationId)
+
+	slots, err := s.scheduler.Availability(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to find open slots")
+	}
+	if slots == nil {
+		slots = []*pb.AppointmentSlot{}
+	}
+	return &pb.GetAvailabilityResponse{Slots: slots}, nil
+}
+
+// HoldAppointment holds an open slot for the member
+func (s *ProviderService) HoldAppointment(ctx context.Context, req *pb.HoldAppointmentRequest) (*pb.HoldAppointmentResponse, error) {
+	log.Printf("HoldAppointment called for member ID: %s, provider ID: %s", req.MemberId, req.ProviderId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.scheduler.Hold(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to hold appointment")
+	}
+	return &pb.HoldAppointmentResponse{Appointment: a}, nil
+}
+
+// BookAppointment books the member's held slot
+func (s *ProviderService) BookAppointment(ctx context.Context, req *pb.BookAppointmentRequest) (*pb.BookAppointmentResponse, error) {
+	log.Printf("BookAppointment called for appointment ID: %s", req.AppointmentId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.scheduler.Book(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to book appointment")
+	}
+	return &pb.BookAppointmentResponse{Appointment: a}, nil
+}
+
+// RescheduleAppointment moves the member's booked appointment to another
+// open slot
+func (s *ProviderService) RescheduleAppointment(ctx context.Context, req *pb.RescheduleAppointmentRequest) (*pb.RescheduleAppointmentResponse, error) {
+	log.Printf("RescheduleAppointment called for appointment ID: %s", req.AppointmentId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.scheduler.Reschedule(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to reschedule appointment")
+	}
+	return &pb.RescheduleAppointmentResponse{Appointment: a}, nil
+}
+
+// CancelAppointment cancels the member's booked appointment or releases
+// their hold
+func (s *ProviderService) CancelAppointment(ctx context.Context, req *pb.CancelAppointmentRequest) (*pb.CancelAppointmentResponse, error) {
+	log.Printf("CancelAppointment called for appointment ID: %s", req.AppointmentId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.scheduler.Cancel(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to cancel appointment")
+	}
+	return &pb.CancelAppointmentResponse{Appointment: a}, nil
+}
+
+// GetAppointment returns one appointment
+func (s *ProviderService) GetAppointment(ctx context.Context, req *pb.GetAppointmentRequest) (*pb.GetAppointmentResponse, error) {
+	log.Printf("GetAppointment called for appointment ID: %s", req.AppointmentId)
+
+	if req.AppointmentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "appointment_id is required")
+	}
+	if err := requireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.scheduler.Get(ctx, req.AppointmentId)
+	if err != nil {
+		return nil, statusError(err, "failed to retrieve appointment")
+	}
+	if req.MemberId != "" && a.MemberId != req.MemberId {
+		return nil, status.Error(codes.NotFound, "appointment not found")
+	}
+	return &pb.GetAppointmentResponse{Appointment: a}, nil
+}
+
+// ListAppointments lists the member's appointments, soonest first
+func (s *ProviderService) ListAppointments(ctx context.Context, req *pb.ListAppointmentsRequest) (*pb.ListAppointmentsResponse, error) {
+	log.Printf("ListAppointments called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+	var from time.Time
+	if req.StartTime != nil {
+		from = req.StartTime.AsTime()
+	}
+
+	appointments, page, err := s.scheduler.List(ctx, req.MemberId, req.Statuses, from, req.Page)
+	if err != nil {
+		return nil, statusError(err, "failed to list appointments")
+	}
+	if appointments == nil {
+		appointments = []*pb.Appointment{}
+	}
+	return &pb.ListAppointmentsResponse{Appointments: appointments, Page: page}, nil
+}
+
+// ExportAppointments renders the member's appointments as an iCalendar file
+func (s *ProviderService) ExportAppointments(ctx context.Context, req *pb.ExportAppointmentsRequest) (*pb.ExportAppointmentsResponse, error) {
+	log.Printf("ExportAppointments called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	data, err := s.scheduler.Export(ctx, req.MemberId)
+	if err != nil {
+		return nil, statusError(err, "failed to export appointments")
+	}
+	return &pb.ExportAppointmentsResponse{ContentType: appointment.CalendarContentType, Data: data}, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// GetCareTeam returns the member's primary care provider, favorites and
+// recently seen providers
+func (s *ProviderService) GetCareTeam(ctx context.Context, req *pb.GetCareTeamRequest) (*pb.GetCareTeamResponse, error) {
+	log.Printf("GetCareTeam called for member ID: %s", req.MemberId)
+
+	decision, err := access.AuthorizeMember(ctx, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.roster.Get(ctx, req.MemberId, decision.Level)
+	if err != nil {
+		return nil, statusError(err, "failed to get care team")
+	}
+	if team.Favorites == nil {
+		team.Favorites = []*pb.FavoriteProvider{}
+	}
+	if team.RecentlySeen == nil {
+		team.RecentlySeen = []*pb.RecentProvider{}
+	}
+	return team, nil
+}
+
+// AssignPrimaryCareProvider designates the member's primary care provider
+// from an effective date
+func (s *ProviderService) AssignPrimaryCareProvider(ctx context.Context, req *pb.AssignPrimaryCareProviderRequest) (*pb.AssignPrimaryCareProviderResponse, error) {
+	log.Printf("AssignPrimaryCareProvider called for member ID: %s, provider ID: %s", req.MemberId, req.ProviderId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.roster.AssignPCP(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to assign primary care provider")
+	}
+	return &pb.AssignPrimaryCareProviderResponse{Assignment: a}, nil
+}
+
+// SaveFavoriteProvider adds a provider to the member's favorites, or changes
+// its note
+func (s *ProviderService) SaveFavoriteProvider(ctx context.Context, req *pb.SaveFavoriteProviderRequest) (*pb.SaveFavoriteProviderResponse, error) {
+	log.Printf("SaveFavoriteProvider called for member ID: %s, provider ID: %s", req.MemberId, req.ProviderId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	f, err := s.roster.SaveFavorite(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to save favorite")
+	}
+	return &pb.SaveFavoriteProviderResponse{Favorite: f}, nil
+}
+
+// RemoveFavoriteProvider removes a provider from the member's favorites
+func (s *ProviderService) RemoveFavoriteProvider(ctx context.Context, req *pb.RemoveFavoriteProviderRequest) (*pb.RemoveFavoriteProviderResponse, error) {
+	log.Printf("RemoveFavoriteProvider called for member ID: %s, provider ID: %s", req.MemberId, req.ProviderId)
+
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	if err := s.roster.RemoveFavorite(ctx, req.MemberId, req.ProviderId); err != nil {
+		return nil, statusError(err, "failed to remove favorite")
+	}
+	return &pb.RemoveFavoriteProviderResponse{}, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/appointment"
+	"github.com/sydney-health-clone/backend/services/provider/internal/careteam"
+	"github.com/sydney-health-clone/backend/services/provider/internal/directory"
+	"github.com/sydney-health-clone/backend/services/provider/internal/network"
+	"github.com/sydney-health-clone/backend/services/provider/internal/review"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// ProviderService implements the gRPC ProviderService over the provider
+// directory, appointment book, reviews and care teams. Calls for a member
+// must come from the member, a caller holding the access the gateway signed
+// for them, or an internal caller.
+type ProviderService struct {
+	pb.UnimplementedProviderServiceServer
+	directory *directory.Directory
+	checker   *network.Checker
+	scheduler *appointment.Scheduler
+	moderator *review.Moderator
+	roster    *careteam.Roster
+}
+
+// NewProviderService creates a new provider service
+func NewProviderService(directory *directory.Directory, checker *network.Checker, scheduler *appointment.Scheduler, moderator *review.Moderator, roster *careteam.Roster) *ProviderService {
+	return &ProviderService{directory: directory, checker: checker, scheduler: scheduler, moderator: moderator, roster: roster}
+}
+
+// SearchProviders searches the directory, nearest first when a location is
+// given. In-network searches keep the providers, and their locations, in
+// network today for the member's plan. Searches for a member flag the
+// providers on their care team.
+func (s *ProviderService) SearchProviders(ctx context.Context, req *pb.SearchProvidersRequest) (*pb.SearchProvidersResponse, error) {
+	log.Printf("SearchProviders called for query: %q, specialty: %q, location: %q", req.Query, req.Specialty, req.Location)
+
+	var level pb.AccessLevel
+	if req.MemberId != "" {
+		decision, err := access.AuthorizeMember(ctx, req.MemberId)
+		if err != nil {
+			return nil, err
+		}
+		level = decision.Level
+	}
+
+	var plan *network.Plan
+	if req.InNetworkOnly {
+		if req.MemberId == "" {
+			return nil, status.Error(codes.InvalidArgument, "member_id is required for in-network search")
+		}
+		var err error
+		plan, err = s.checker.Plan(ctx, req.MemberId, coverageType(req.CoverageType), time.Now())
+		if err != nil {
+			return nil, statusError(err, "failed to find member's networks")
+		}
+	}
+
+	providers, page, err := s.directory.Search(ctx, req, plan)
+	if err != nil {
+		return nil, statusError(err, "failed to search providers")
+	}
+	if providers == nil {
+		providers = []*pb.Provider{}
+	}
+	// Results are still worth showing without the flags
+	if req.MemberId != "" {
+		if err := s.roster.Flag(ctx, req.MemberId, level, providers); err != nil {
+			log.Printf("Failed to flag care team providers for member %s: %v", req.MemberId, err)
+		}
+	}
+	return &pb.SearchProvidersResponse{Providers: providers, Page: page}, nil
+}
+
+// SuggestProviders autocompletes a partly typed search
+func (s *ProviderService) SuggestProviders(ctx context.Context, req *pb.SuggestProvidersRequest) (*pb.SuggestProvidersResponse, error) {
+	suggestions, err := s.directory.Suggest(ctx, req.Query, int(req.Limit))
+	if err != nil {
+		return nil, statusError(err, "failed to suggest providers")
+	}
+
+	resp := &pb.SuggestProvidersResponse{Suggestions: make([]*pb.ProviderSuggestion, len(suggestions))}
+	for i, suggestion := range suggestions {
+		resp.Suggestions[i] = &pb.ProviderSuggestion{
+			Type:       suggestion.Type,
+			Text:       suggestion.Text,
+			ProviderId: suggestion.ProviderID,
+		}
+	}
+	return resp, nil
+}
+
+// GetProvider returns a provider with all of its locations
+func (s *ProviderService) GetProvider(ctx context.Context, req *pb.GetProviderRequest) (*pb.GetProviderResponse, error) {
+	log.Printf("GetProvider called for provider ID: %s", req.ProviderId)
+
+	if req.ProviderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+
+	provider, err := s.directory.Provider(ctx, req.ProviderId)
+	if err != nil {
+		return nil, statusError(err, "failed to retrieve provider")
+	}
+	return &pb.GetProviderResponse{Provider: provider}, nil
+}
+
+// CheckNetworkStatus reports whether a provider is in network for the
+// member's coverage on the service date, at one location or at the
+// provider's best
+func (s *ProviderService) CheckNetworkStatus(ctx context.Context, req *pb.CheckNetworkStatusRequest) (*pb.CheckNetworkStatusResponse, error) {
+	log.Printf("CheckNetworkStatus called for provider ID: %s, member ID: %s", req.ProviderId, req.MemberId)
+
+	if req.ProviderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if _, err := access.AuthorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+	day := time.Now()
+	if req.ServiceDate != nil {
+		day = req.ServiceDate.AsTime()
+	}
+
+	provider, err := s.directory.Provider(ctx, req.ProviderId)
+	if err != nil {
+		return nil, statusError(err, "failed to retrieve provider")
+	}
+	plan, err := s.checker.Plan(ctx, req.MemberId, coverageType(req.CoverageType), day)
+	if err != nil {
+		return nil, statusError(err, "failed to find member's networks")
+	}
+	resp, err := plan.Status(provider, req.LocationId)
+	if err != nil {
+		return nil, statusError(err, "failed to check network status")
+	}
+	return resp, nil
+}
+
+// requireFullAccess checks the caller has full access to memberID's
+// records. Only internal callers may leave the member out to read anyone's.
+func requireFullAccess(ctx context.Context, memberID string) error {
+	if memberID == "" {
+		if _, ok := access.InternalFromContext(ctx); !ok {
+			return status.Error(codes.InvalidArgument, "member_id is required")
+		}
+		return nil
+	}
+	return access.RequireFullAccess(ctx, memberID)
+}
+
+// coverageType defaults an unspecified coverage type to medical
+func coverageType(t pb.CoverageType) pb.CoverageType {
+	if t == pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED {
+		return pb.CoverageType_COVERAGE_TYPE_MEDICAL
+	}
+	return t
+}
+
+// statusError maps directory, network, appointment, review and care team errors to gRPC statuses, logging unexpected ones
+func statusError(err error, message string) error {
+	switch {
+	case errors.Is(err, directory.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, directory.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, network.ErrMemberNotFound), errors.Is(err, network.ErrLocationNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, network.ErrNoCoverage):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, appointment.ErrNotFound), errors.Is(err, appointment.ErrLocationNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, appointment.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, appointment.ErrSlotTaken), errors.Is(err, appointment.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, appointment.ErrHoldExpired), errors.Is(err, appointment.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, review.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, review.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, review.ErrNotEligible):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, review.ErrAlreadyReviewed):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, review.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, review.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, careteam.ErrNotFound), errors.Is(err, careteam.ErrLocationNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, careteam.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, careteam.ErrNotPrimaryCare), errors.Is(err, careteam.ErrOutOfNetwork):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, careteam.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	log.Printf("Error: %s: %v", message, err)
+	return status.Error(codes.Internal, message)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health-clone/backend/services/provider/internal/review"
+	"github.com/sydney-health-clone/backend/shared/access"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// SubmitReview takes a member's review of a provider they have a paid claim
+// with
+func (s *ProviderService) SubmitReview(ctx context.Context, req *pb.SubmitReviewRequest) (*pb.SubmitReviewResponse, error) {
+	log.Printf("SubmitReview called for member ID: %s, provider ID: %s", req.MemberId, req.ProviderId)
+
+	// Only callers with full access learn whether the member is eligible,
+	// which would otherwise tell them the member had a paid visit
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, statusError(review.ErrNotEligible, "failed to submit review")
+	}
+
+	r, err := s.moderator.Submit(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to submit review")
+	}
+	return &pb.SubmitReviewResponse{Review: r}, nil
+}
+
+// ListReviews lists a provider's published reviews, newest first, with its
+// rating
+func (s *ProviderService) ListReviews(ctx context.Context, req *pb.ListReviewsRequest) (*pb.ListReviewsResponse, error) {
+	log.Printf("ListReviews called for provider ID: %s", req.ProviderId)
+
+	reviews, page, provider, err := s.moderator.List(ctx, req.ProviderId, req.Page)
+	if err != nil {
+		return nil, statusError(err, "failed to list reviews")
+	}
+	if reviews == nil {
+		reviews = []*pb.ProviderReview{}
+	}
+	return &pb.ListReviewsResponse{
+		Reviews:     reviews,
+		Page:        page,
+		Rating:      provider.Rating,
+		ReviewCount: provider.ReviewCount,
+	}, nil
+}
+
+// ListPendingReviews lists the moderation queue, oldest first, for support
+// agents
+func (s *ProviderService) ListPendingReviews(ctx context.Context, req *pb.ListPendingReviewsRequest) (*pb.ListPendingReviewsResponse, error) {
+	agentID, ok := access.AgentFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "review moderation is restricted to support agents")
+	}
+	log.Printf("ListPendingReviews called by agent %s", agentID)
+
+	reviews, page, err := s.moderator.Queue(ctx, req.Page)
+	if err != nil {
+		return nil, statusError(err, "failed to list pending reviews")
+	}
+	if reviews == nil {
+		reviews = []*pb.ProviderReview{}
+	}
+	return &pb.ListPendingReviewsResponse{Reviews: reviews, Page: page}, nil
+}
+
+// ModerateReview publishes or rejects a review for a support agent
+func (s *ProviderService) ModerateReview(ctx context.Context, req *pb.ModerateReviewRequest) (*pb.ModerateReviewResponse, error) {
+	agentID, ok := access.AgentFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "review moderation is restricted to support agents")
+	}
+	log.Printf("ModerateReview called by agent %s for review ID: %s, decision: %s", agentID, req.ReviewId, req.Decision)
+
+	r, err := s.moderator.Moderate(ctx, req, agentID)
+	if err != nil {
+		return nil, statusError(err, "failed to moderate review")
+	}
+	return &pb.ModerateReviewResponse{Review: r}, nil
+}
@@ -0,0 +1,538 @@
+// Package account keeps HSA, FSA and HRA accounts and their transaction
+// ledgers, and pays what members owe on adjudicated claims from them.
+//
+// Balances are never stored. Every contribution, purchase and reimbursement
+// is a ledger entry, and the store sums the ledger when asked, checking a new
+// entry against those sums before it is posted so concurrent purchases can't
+// overdraw an account. Contributions are measured against the IRS limit for
+// their tax year.
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Page sizes for Transactions
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Claim adjudication actions
+const (
+	ActionAdjudicated = "ADJUDICATED"
+	ActionAdjusted    = "ADJUSTED"
+	ActionReversed    = "REVERSED"
+)
+
+var (
+	// ErrNotFound is returned for an unknown account ID
+	ErrNotFound = errors.New("account not found")
+	// ErrClaimNotFound is returned for a claim that hasn't been adjudicated
+	ErrClaimNotFound = errors.New("claim not found")
+	// ErrInvalid is returned, wrapped with the problem, for requests that
+	// can't be accepted
+	ErrInvalid = errors.New("invalid spending account request")
+	// ErrInsufficientFunds is returned for spending beyond what is available
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrLimitExceeded is returned for contributions beyond the year's limit
+	ErrLimitExceeded = errors.New("contribution limit exceeded")
+	// ErrAlreadyPaid is returned when another payment toward the claim was
+	// posted from the account at the same time
+	ErrAlreadyPaid = errors.New("claim payment already posted from this account")
+	// ErrInvalidAdjudication is returned for claim events that can't be kept
+	ErrInvalidAdjudication = errors.New("invalid claim adjudication")
+)
+
+// Totals are sums over one account's ledger
+type Totals struct {
+	BalanceCents int64
+	// SpentCents is every purchase and reimbursement, as a positive amount
+	SpentCents int64
+	// ContributedCents counts contributions toward one tax year
+	ContributedCents int64
+	// ClaimPaidCents is what every account has paid toward one claim
+	ClaimPaidCents int64
+}
+
+// Claim is the latest version of an adjudicated claim as it can be paid
+type Claim struct {
+	ClaimID     string
+	Version     int64
+	MemberID    string
+	ServiceDate time.Time
+	// ResponsibilityCents is what the member owes; zero once reversed
+	ResponsibilityCents int64
+}
+
+// Filter narrows a transaction listing. No types means every type, zero
+// dates leave that end open, and an empty claim ID means any claim or none.
+type Filter struct {
+	Types   []pb.SpendingTransactionType
+	Start   time.Time
+	End     time.Time
+	ClaimID string
+}
+
+// Store keeps accounts, their ledgers, the IRS limits and the claims they
+// can pay
+type Store interface {
+	Create(ctx context.Context, a *pb.SpendingAccount) error
+	// Account returns the account without balances, or ErrNotFound
+	Account(ctx context.Context, id string) (*pb.SpendingAccount, error)
+	// Accounts returns the member's accounts without balances, oldest first
+	Accounts(ctx context.Context, memberID string) ([]*pb.SpendingAccount, error)
+	// Totals sums the account's ledger, counting contributions toward
+	// taxYear, or every contribution when taxYear is zero
+	Totals(ctx context.Context, accountID string, taxYear int32) (Totals, error)
+	// Transactions returns a page of the account's transactions, newest
+	// first, and how many match in all
+	Transactions(ctx context.Context, accountID string, f Filter, offset, limit int) ([]*pb.SpendingTransaction, int, error)
+	// Post saves t unless the account already has a transaction with
+	// externalID, which it returns instead with false. check is given the
+	// account's totals, counting contributions toward t's tax year and
+	// payments toward t's claim, with nothing else posted to the account or
+	// claim in between; its error stops the post.
+	Post(ctx context.Context, t *pb.SpendingTransaction, externalID string, check func(Totals) error) (*pb.SpendingTransaction, bool, error)
+	// Limit returns the IRS limit for the account type and year, or nil
+	Limit(ctx context.Context, accountType pb.SpendingAccountType, year int32) (*Limit, error)
+	// RecordClaim saves the claim unless it is already stored at the same or
+	// a later version, and reports whether it was saved
+	RecordClaim(ctx context.Context, c *Claim) (bool, error)
+	// Claim returns the latest version of the claim, or ErrClaimNotFound
+	Claim(ctx context.Context, claimID string) (*Claim, error)
+}
+
+// Ledger opens accounts, posts transactions and pays claims
+type Ledger struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewLedger creates a ledger over store
+func NewLedger(store Store) *Ledger {
+	return &Ledger{store: store, now: time.Now}
+}
+
+// Open validates and opens a new account
+func (l *Ledger) Open(ctx context.Context, req *pb.OpenAccountRequest) (*pb.SpendingAccount, error) {
+	if req.MemberId == "" {
+		return nil, fmt.Errorf("%w: member_id is required", ErrInvalid)
+	}
+	a := &pb.SpendingAccount{
+		AccountId:       uuid.NewString(),
+		MemberId:        req.MemberId,
+		AccountType:     req.AccountType,
+		FamilyCoverage:  req.FamilyCoverage,
+		CatchUpEligible: req.CatchUpEligible,
+		OpenedAt:        timestamppb.New(l.now()),
+	}
+
+	switch req.AccountType {
+	case pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA:
+		if req.PlanYearStart != nil || req.AnnualAmount.GetCents() != 0 {
+			return nil, fmt.Errorf("%w: HSAs have no plan year or annual amount", ErrInvalid)
+		}
+	case pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_FSA, pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HRA:
+		if req.PlanYearStart == nil {
+			return nil, fmt.Errorf("%w: plan_year_start is required", ErrInvalid)
+		}
+		start := coverage.Day(req.PlanYearStart.AsTime())
+		end := start.AddDate(1, 0, -1)
+		if req.PlanYearEnd != nil {
+			end = coverage.Day(req.PlanYearEnd.AsTime())
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("%w: plan_year_end is before plan_year_start", ErrInvalid)
+		}
+		if req.AnnualAmount.GetCents() <= 0 {
+			return nil, fmt.Errorf("%w: annual_amount is required", ErrInvalid)
+		}
+		a.PlanYearStart = timestamppb.New(start)
+		a.PlanYearEnd = timestamppb.New(end)
+		a.AnnualAmount = usd(req.AnnualAmount.Cents)
+		a.FamilyCoverage, a.CatchUpEligible = false, false
+
+		if req.AccountType == pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_FSA {
+			limit, err := l.store.Limit(ctx, req.AccountType, int32(start.Year()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load contribution limit: %w", err)
+			}
+			if limit == nil {
+				return nil, fmt.Errorf("%w: no FSA limit is configured for %d", ErrInvalid, start.Year())
+			}
+			if req.AnnualAmount.Cents > limit.For(a) {
+				return nil, fmt.Errorf("%w: the election is over the %d limit of %s", ErrLimitExceeded, start.Year(), dollars(limit.For(a)))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: account_type must be HSA, FSA or HRA", ErrInvalid)
+	}
+
+	if err := l.store.Create(ctx, a); err != nil {
+		return nil, fmt.Errorf("failed to save account: %w", err)
+	}
+	return l.withBalances(ctx, a)
+}
+
+// Account returns one account with its balances
+func (l *Ledger) Account(ctx context.Context, id string) (*pb.SpendingAccount, error) {
+	a, err := l.store.Account(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return l.withBalances(ctx, a)
+}
+
+// Accounts returns the member's accounts with their balances
+func (l *Ledger) Accounts(ctx context.Context, memberID string) ([]*pb.SpendingAccount, error) {
+	accounts, err := l.store.Accounts(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range accounts {
+		if accounts[i], err = l.withBalances(ctx, a); err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+// Transactions returns a page of the account's transactions, newest first.
+// Page tokens are the offset of the page's first transaction.
+func (l *Ledger) Transactions(ctx context.Context, accountID string, f Filter, page *pb.PageRequest) ([]*pb.SpendingTransaction, *pb.PageResponse, error) {
+	size, offset := DefaultPageSize, 0
+	if page != nil {
+		if page.PageSize > 0 {
+			size = min(int(page.PageSize), MaxPageSize)
+		}
+		if page.PageToken != "" {
+			n, err := strconv.Atoi(page.PageToken)
+			if err != nil || n < 0 {
+				return nil, nil, fmt.Errorf("%w: invalid page token", ErrInvalid)
+			}
+			offset = n
+		}
+	}
+	if !f.Start.IsZero() && !f.End.IsZero() && f.End.Before(f.Start) {
+		return nil, nil, fmt.Errorf("%w: end_date is before start_date", ErrInvalid)
+	}
+
+	transactions, total, err := l.store.Transactions(ctx, accountID, f, offset, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp := &pb.PageResponse{TotalCount: int32(total)}
+	if end := offset + len(transactions); end < total {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return transactions, resp, nil
+}
+
+// Record posts a contribution or card purchase from the account
+// administrator. Posting the same external ID again returns the original.
+func (l *Ledger) Record(ctx context.Context, req *pb.RecordTransactionRequest) (*pb.SpendingTransaction, *pb.SpendingAccount, error) {
+	if req.ExternalId == "" {
+		return nil, nil, fmt.Errorf("%w: external_id is required", ErrInvalid)
+	}
+	if req.Amount.GetCents() <= 0 {
+		return nil, nil, fmt.Errorf("%w: amount must be positive", ErrInvalid)
+	}
+	a, err := l.store.Account(ctx, req.AccountId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	day := coverage.Day(l.now())
+	if req.TransactionDate != nil {
+		day = coverage.Day(req.TransactionDate.AsTime())
+	}
+	t := &pb.SpendingTransaction{
+		TransactionId:   uuid.NewString(),
+		AccountId:       a.AccountId,
+		TransactionType: req.TransactionType,
+		Description:     strings.TrimSpace(req.Description),
+		TransactionDate: timestamppb.New(day),
+		PostedAt:        timestamppb.New(l.now()),
+	}
+
+	var check func(Totals) error
+	switch req.TransactionType {
+	case pb.SpendingTransactionType_SPENDING_TRANSACTION_TYPE_CONTRIBUTION:
+		if a.AccountType != pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA {
+			if err := eligible(a, day); err != nil {
+				return nil, nil, err
+			}
+		}
+		if t.TaxYear, err = taxYear(a, day, req.TaxYear); err != nil {
+			return nil, nil, err
+		}
+		limit, err := l.contributionLimit(ctx, a, t.TaxYear)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.Amount = usd(req.Amount.Cents)
+		check = func(totals Totals) error {
+			if totals.ContributedCents+req.Amount.Cents > limit {
+				return fmt.Errorf("%w: %s of the %d limit of %s is left", ErrLimitExceeded,
+					dollars(max(limit-totals.ContributedCents, 0)), t.TaxYear, dollars(limit))
+			}
+			return nil
+		}
+	case pb.SpendingTransactionType_SPENDING_TRANSACTION_TYPE_CARD_PURCHASE:
+		if err := eligible(a, day); err != nil {
+			return nil, nil, err
+		}
+		t.Amount = usd(-req.Amount.Cents)
+		check = func(totals Totals) error {
+			if req.Amount.Cents > available(a, totals) {
+				return fmt.Errorf("%w: %s is available", ErrInsufficientFunds, dollars(available(a, totals)))
+			}
+			return nil
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: transaction_type must be CONTRIBUTION or CARD_PURCHASE; pay claims with PayClaim", ErrInvalid)
+	}
+
+	posted, _, err := l.store.Post(ctx, t, req.ExternalId, check)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err = l.withBalances(ctx, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	return posted, a, nil
+}
+
+// PayClaim pays what the member owes on one of their own adjudicated claims
+// from the account, as a single reimbursement. A zero amount pays everything
+// still owed. It returns the reimbursement, the account and what is still
+// owed after it.
+func (l *Ledger) PayClaim(ctx context.Context, req *pb.PayClaimRequest) (*pb.SpendingTransaction, *pb.SpendingAccount, int64, error) {
+	if req.ClaimId == "" {
+		return nil, nil, 0, fmt.Errorf("%w: claim_id is required", ErrInvalid)
+	}
+	if req.Amount.GetCents() < 0 {
+		return nil, nil, 0, fmt.Errorf("%w: amount can't be negative", ErrInvalid)
+	}
+	a, err := l.store.Account(ctx, req.AccountId)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if req.MemberId != "" && a.MemberId != req.MemberId {
+		return nil, nil, 0, ErrNotFound
+	}
+	claim, err := l.store.Claim(ctx, req.ClaimId)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if claim.MemberID != a.MemberId {
+		return nil, nil, 0, ErrClaimNotFound
+	}
+	if err := eligible(a, claim.ServiceDate); err != nil {
+		return nil, nil, 0, err
+	}
+
+	t := &pb.SpendingTransaction{
+		TransactionId:   uuid.NewString(),
+		AccountId:       a.AccountId,
+		TransactionType: pb.SpendingTransactionType_SPENDING_TRANSACTION_TYPE_CLAIM_REIMBURSEMENT,
+		Description:     "Claim " + claim.ClaimID,
+		ClaimId:         claim.ClaimID,
+		TransactionDate: timestamppb.New(coverage.Day(l.now())),
+		PostedAt:        timestamppb.New(l.now()),
+	}
+	// Payments toward the claim are numbered, so the account can pay it in
+	// parts while two requests for the same payment still post only once
+	_, payments, err := l.store.Transactions(ctx, a.AccountId, Filter{ClaimID: claim.ClaimID}, 0, 0)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	externalID := fmt.Sprintf("claim:%s:%d", claim.ClaimID, payments+1)
+
+	var remaining int64
+	check := func(totals Totals) error {
+		owed := claim.ResponsibilityCents - totals.ClaimPaidCents
+		if owed <= 0 {
+			return fmt.Errorf("%w: nothing is owed on claim %s", ErrInvalid, claim.ClaimID)
+		}
+		amount := req.Amount.GetCents()
+		if amount == 0 {
+			amount = owed
+		}
+		if amount > owed {
+			return fmt.Errorf("%w: only %s is owed on claim %s", ErrInvalid, dollars(owed), claim.ClaimID)
+		}
+		if amount > available(a, totals) {
+			return fmt.Errorf("%w: %s is available", ErrInsufficientFunds, dollars(available(a, totals)))
+		}
+		t.Amount = usd(-amount)
+		remaining = owed - amount
+		return nil
+	}
+
+	posted, ok, err := l.store.Post(ctx, t, externalID, check)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if !ok {
+		return nil, nil, 0, fmt.Errorf("%w: transaction %s", ErrAlreadyPaid, posted.TransactionId)
+	}
+	a, err = l.withBalances(ctx, a)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return posted, a, remaining, nil
+}
+
+// ApplyClaim keeps what the member owes on an adjudicated claim so they can
+// pay it from an account, and reports whether it changed. Versions at or
+// below the one already kept are ignored.
+func (l *Ledger) ApplyClaim(ctx context.Context, adj *kafka.ClaimAdjudication) (bool, error) {
+	if adj.ClaimID == "" || adj.MemberID == "" || adj.Version <= 0 {
+		return false, fmt.Errorf("%w: claim_id, member_id and a positive version are required", ErrInvalidAdjudication)
+	}
+	serviceDate, err := time.Parse("2006-01-02", adj.ServiceDate)
+	if err != nil {
+		return false, fmt.Errorf("%w: service_date must be YYYY-MM-DD", ErrInvalidAdjudication)
+	}
+	if adj.DeductibleCents < 0 || adj.CopayCents < 0 || adj.CoinsuranceCents < 0 {
+		return false, fmt.Errorf("%w: amounts can't be negative", ErrInvalidAdjudication)
+	}
+
+	c := &Claim{
+		ClaimID:     adj.ClaimID,
+		Version:     adj.Version,
+		MemberID:    adj.MemberID,
+		ServiceDate: serviceDate,
+	}
+	switch strings.ToUpper(adj.Action) {
+	case ActionAdjudicated, ActionAdjusted:
+		c.ResponsibilityCents = adj.DeductibleCents + adj.CopayCents + adj.CoinsuranceCents
+	case ActionReversed:
+	default:
+		return false, fmt.Errorf("%w: unknown action %q", ErrInvalidAdjudication, adj.Action)
+	}
+	return l.store.RecordClaim(ctx, c)
+}
+
+// withBalances fills in the account's balance, what is available and its
+// contributions toward the current year
+func (l *Ledger) withBalances(ctx context.Context, a *pb.SpendingAccount) (*pb.SpendingAccount, error) {
+	year := int32(l.now().Year())
+	taxYear := year
+	if a.AccountType != pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA {
+		year, taxYear = int32(a.PlanYearStart.AsTime().Year()), 0
+	}
+	totals, err := l.store.Totals(ctx, a.AccountId, taxYear)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total account %s: %w", a.AccountId, err)
+	}
+
+	a.Balance = usd(totals.BalanceCents)
+	a.Available = usd(available(a, totals))
+	a.Contributions = &pb.ContributionSummary{
+		Year:        year,
+		Contributed: usd(totals.ContributedCents),
+	}
+	limit, err := l.contributionLimit(ctx, a, year)
+	if errors.Is(err, ErrInvalid) {
+		// No limit published for the year yet
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.Contributions.Limit = usd(limit)
+	a.Contributions.Remaining = usd(max(limit-totals.ContributedCents, 0))
+	return a, nil
+}
+
+// contributionLimit is the most the account can take in contributions for
+// the year: the IRS limit for HSAs, and the annual amount for FSAs and HRAs
+func (l *Ledger) contributionLimit(ctx context.Context, a *pb.SpendingAccount, year int32) (int64, error) {
+	if a.AccountType != pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA {
+		return a.AnnualAmount.GetCents(), nil
+	}
+	limit, err := l.store.Limit(ctx, a.AccountType, year)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load contribution limit: %w", err)
+	}
+	if limit == nil {
+		return 0, fmt.Errorf("%w: no HSA limit is configured for %d", ErrInvalid, year)
+	}
+	return limit.For(a), nil
+}
+
+// available is what the account can spend. An FSA's whole election is
+// available from the first day of its plan year, whatever has been
+// contributed so far.
+func available(a *pb.SpendingAccount, totals Totals) int64 {
+	if a.AccountType == pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_FSA {
+		return max(a.AnnualAmount.GetCents()-totals.SpentCents, 0)
+	}
+	return max(totals.BalanceCents, 0)
+}
+
+// eligible checks that the account can take a transaction on day: during the
+// plan year for FSAs and HRAs, and once the account was opened for HSAs
+func eligible(a *pb.SpendingAccount, day time.Time) error {
+	if a.AccountType == pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA {
+		if day.Before(coverage.Day(a.OpenedAt.AsTime())) {
+			return fmt.Errorf("%w: HSAs can't pay for services before the account was opened", ErrInvalid)
+		}
+		return nil
+	}
+	if day.Before(a.PlanYearStart.AsTime()) || day.After(a.PlanYearEnd.AsTime()) {
+		return fmt.Errorf("%w: %s is outside the plan year", ErrInvalid, day.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// taxYear is the year a contribution made on day counts toward. HSA
+// contributions made by April 15 may count toward the year before; FSA and
+// HRA contributions count toward their plan year.
+func taxYear(a *pb.SpendingAccount, day time.Time, requested int32) (int32, error) {
+	year := int32(day.Year())
+	if a.AccountType != pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA {
+		year = int32(a.PlanYearStart.AsTime().Year())
+	}
+	if requested == 0 || requested == year {
+		return year, nil
+	}
+	deadline := time.Date(day.Year(), time.April, 15, 0, 0, 0, 0, time.UTC)
+	if a.AccountType == pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA && requested == year-1 && !day.After(deadline) {
+		return requested, nil
+	}
+	return 0, fmt.Errorf("%w: a contribution on %s can't count toward %d", ErrInvalid, day.Format("2006-01-02"), requested)
+}
+
+func usd(cents int64) *pb.Money {
+	return &pb.Money{Cents: cents, Currency: "USD"}
+}
+
+// dollars formats cents for error messages, e.g. "$1,250.00"
+func dollars(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	whole := strconv.FormatInt(cents/100, 10)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("%s$%s.%02d", sign, whole, cents%100)
+}
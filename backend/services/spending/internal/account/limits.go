@@ -0,0 +1,62 @@
+package account
+
+import (
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Limit is the IRS contribution limit for one account type and tax year.
+// For FSAs it caps the election and FamilyCents and CatchUpCents are zero.
+type Limit struct {
+	Year          int32
+	AccountType   pb.SpendingAccountType
+	SelfOnlyCents int64
+	FamilyCents   int64
+	// CatchUpCents is added for HSA holders 55 or older
+	CatchUpCents int64
+}
+
+// DefaultLimits are the published IRS limits, as seeded in
+// spending_contribution_limits. Demo mode uses them as they are.
+var DefaultLimits = []Limit{
+	{Year: 2025, AccountType: pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA, SelfOnlyCents: 430000, FamilyCents: 855000, CatchUpCents: 100000},
+	{Year: 2026, AccountType: pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_HSA, SelfOnlyCents: 440000, FamilyCents: 875000, CatchUpCents: 100000},
+	{Year: 2025, AccountType: pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_FSA, SelfOnlyCents: 330000},
+	{Year: 2026, AccountType: pb.SpendingAccountType_SPENDING_ACCOUNT_TYPE_FSA, SelfOnlyCents: 340000},
+}
+
+// For returns the limit that applies to the account
+func (l Limit) For(a *pb.SpendingAccount) int64 {
+	cents := l.SelfOnlyCents
+	if a.FamilyCoverage && l.FamilyCents > 0 {
+		cents = l.FamilyCents
+	}
+	if a.CatchUpEligible {
+		cents += l.CatchUpCents
+	}
+	return cents
+}
+
+// TypeName returns the short name used in storage, e.g. "HSA"
+func TypeName(accountType pb.SpendingAccountType) string {
+	return strings.TrimPrefix(accountType.String(), "SPENDING_ACCOUNT_TYPE_")
+}
+
+// ParseType accepts either the short or the full enum name
+func ParseType(name string) pb.SpendingAccountType {
+	value := pb.SpendingAccountType_value["SPENDING_ACCOUNT_TYPE_"+strings.TrimPrefix(strings.ToUpper(name), "SPENDING_ACCOUNT_TYPE_")]
+	return pb.SpendingAccountType(value)
+}
+
+// TransactionTypeName returns the short name used in storage, e.g.
+// "CARD_PURCHASE"
+func TransactionTypeName(transactionType pb.SpendingTransactionType) string {
+	return strings.TrimPrefix(transactionType.String(), "SPENDING_TRANSACTION_TYPE_")
+}
+
+// ParseTransactionType accepts either the short or the full enum name
+func ParseTransactionType(name string) pb.SpendingTransactionType {
+	value := pb.SpendingTransactionType_value["SPENDING_TRANSACTION_TYPE_"+strings.TrimPrefix(strings.ToUpper(name), "SPENDING_TRANSACTION_TYPE_")]
+	return pb.SpendingTransactionType(value)
+}
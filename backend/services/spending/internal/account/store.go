@@ -0,0 +1,195 @@
+package account
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// MemoryStore is an in-process Store for demo mode, using DefaultLimits
+type MemoryStore struct {
+	mu           sync.Mutex
+	accounts     map[string]*pb.SpendingAccount
+	transactions []*posted
+	claims       map[string]*Claim
+}
+
+type posted struct {
+	transaction *pb.SpendingTransaction
+	externalID  string
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		accounts: make(map[string]*pb.SpendingAccount),
+		claims:   make(map[string]*Claim),
+	}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, a *pb.SpendingAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts[a.AccountId] = proto.Clone(a).(*pb.SpendingAccount)
+	return nil
+}
+
+func (s *MemoryStore) Account(ctx context.Context, id string) (*pb.SpendingAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return proto.Clone(a).(*pb.SpendingAccount), nil
+}
+
+func (s *MemoryStore) Accounts(ctx context.Context, memberID string) ([]*pb.SpendingAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var accounts []*pb.SpendingAccount
+	for _, a := range s.accounts {
+		if a.MemberId == memberID {
+			accounts = append(accounts, proto.Clone(a).(*pb.SpendingAccount))
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].OpenedAt.AsTime().Before(accounts[j].OpenedAt.AsTime())
+	})
+	return accounts, nil
+}
+
+func (s *MemoryStore) Totals(ctx context.Context, accountID string, taxYear int32) (Totals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.totals(accountID, taxYear, ""), nil
+}
+
+func (s *MemoryStore) Transactions(ctx context.Context, accountID string, f Filter, offset, limit int) ([]*pb.SpendingTransaction, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*pb.SpendingTransaction
+	for _, p := range s.transactions {
+		t := p.transaction
+		day := t.TransactionDate.AsTime()
+		if t.AccountId != accountID || (len(f.Types) > 0 && !hasType(f.Types, t.TransactionType)) ||
+			(!f.Start.IsZero() && day.Before(f.Start)) || (!f.End.IsZero() && day.After(f.End)) ||
+			(f.ClaimID != "" && t.ClaimId != f.ClaimID) {
+			continue
+		}
+		matched = append(matched, t)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !a.TransactionDate.AsTime().Equal(b.TransactionDate.AsTime()) {
+			return a.TransactionDate.AsTime().After(b.TransactionDate.AsTime())
+		}
+		return a.PostedAt.AsTime().After(b.PostedAt.AsTime())
+	})
+
+	total := len(matched)
+	if offset >= total {
+		return nil, total, nil
+	}
+	matched = matched[offset:min(offset+limit, total)]
+	page := make([]*pb.SpendingTransaction, len(matched))
+	for i, t := range matched {
+		page[i] = proto.Clone(t).(*pb.SpendingTransaction)
+	}
+	return page, total, nil
+}
+
+func (s *MemoryStore) Post(ctx context.Context, t *pb.SpendingTransaction, externalID string, check func(Totals) error) (*pb.SpendingTransaction, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[t.AccountId]; !ok {
+		return nil, false, ErrNotFound
+	}
+	for _, p := range s.transactions {
+		if p.transaction.AccountId == t.AccountId && p.externalID == externalID {
+			return proto.Clone(p.transaction).(*pb.SpendingTransaction), false, nil
+		}
+	}
+	if err := check(s.totals(t.AccountId, t.TaxYear, t.ClaimId)); err != nil {
+		return nil, false, err
+	}
+
+	saved := proto.Clone(t).(*pb.SpendingTransaction)
+	s.transactions = append(s.transactions, &posted{transaction: saved, externalID: externalID})
+	return proto.Clone(saved).(*pb.SpendingTransaction), true, nil
+}
+
+func (s *MemoryStore) Limit(ctx context.Context, accountType pb.SpendingAccountType, year int32) (*Limit, error) {
+	for _, limit := range DefaultLimits {
+		if limit.AccountType == accountType && limit.Year == year {
+			return &limit, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) RecordClaim(ctx context.Context, c *Claim) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.claims[c.ClaimID]; ok && existing.Version >= c.Version {
+		return false, nil
+	}
+	saved := *c
+	s.claims[c.ClaimID] = &saved
+	return true, nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, claimID string) (*Claim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.claims[claimID]
+	if !ok {
+		return nil, ErrClaimNotFound
+	}
+	found := *c
+	return &found, nil
+}
+
+// totals sums the account's ledger, and the payments toward claimID from
+// every account when it is set
+func (s *MemoryStore) totals(accountID string, taxYear int32, claimID string) Totals {
+	var totals Totals
+	for _, p := range s.transactions {
+		t := p.transaction
+		if claimID != "" && t.ClaimId == claimID {
+			totals.ClaimPaidCents -= t.Amount.Cents
+		}
+		if t.AccountId != accountID {
+			continue
+		}
+		totals.BalanceCents += t.Amount.Cents
+		if t.Amount.Cents < 0 {
+			totals.SpentCents -= t.Amount.Cents
+		}
+		if t.TransactionType == pb.SpendingTransactionType_SPENDING_TRANSACTION_TYPE_CONTRIBUTION && (taxYear == 0 || t.TaxYear == taxYear) {
+			totals.ContributedCents += t.Amount.Cents
+		}
+	}
+	return totals
+}
+
+func hasType(types []pb.SpendingTransactionType, transactionType pb.SpendingTransactionType) bool {
+	for _, t := range types {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
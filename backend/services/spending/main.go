@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/joho/godotenv"
+	"github.com/sydney-health-clone/backend/internal/database"
+	"github.com/sydney-health-clone/backend/services/spending/internal/account"
+	"github.com/sydney-health-clone/backend/services/spending/repository"
+	"github.com/sydney-health-clone/backend/services/spending/service"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load("../../.env.development"); err != nil {
+		log.Printf("Warning: .env.development file not found")
+	}
+
+	// Demo mode keeps accounts in memory without a database
+	var store account.Store
+	demoMode, _ := strconv.ParseBool(os.Getenv("SPENDING_DEMO_MODE"))
+	if demoMode {
+		log.Printf("Demo mode: keeping spending accounts in memory")
+		store = account.NewMemoryStore()
+	} else {
+		db, err := database.InitDB()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+		store = repository.NewAccountStore(db)
+	}
+
+	// Initialize service
+	spendingService := service.NewSpendingService(account.NewLedger(store))
+
+	// Keep what members owe on adjudicated claims so they can pay them. The
+	// benefits service reads the same topic, so this needs its own group.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if brokers, topic := os.Getenv("KAFKA_BROKERS"), os.Getenv("CLAIM_ADJUDICATIONS_TOPIC"); brokers != "" && topic != "" {
+		group := os.Getenv("SPENDING_CONSUMER_GROUP")
+		if group == "" {
+			group = "sydney-health-spending"
+		}
+		consumer := kafka.NewConsumer(strings.Split(brokers, ","), topic, group, spendingService.HandleClaimAdjudication)
+		consumer.SetDeadLetterTopic(os.Getenv("CLAIM_ADJUDICATIONS_DEAD_LETTER_TOPIC"))
+		defer consumer.Close()
+
+		go func() {
+			if err := consumer.Start(ctx); err != nil {
+				log.Printf("Claim adjudication consumer stopped: %v", err)
+			}
+		}()
+	}
+
+	// Get port from environment
+	port := os.Getenv("SPENDING_SERVICE_PORT")
+	if port == "" {
+		port = "50057"
+	}
+
+	// Create gRPC server
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+
+	// Register spending account service
+	pb.RegisterSpendingAccountServiceServer(grpcServer, spendingService)
+
+	// Register health service
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// Register reflection service for debugging
+	reflection.Register(grpcServer)
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Spending account service listening on port %s", port)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	// Setup graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("Shutting down spending account service...")
+	grpcServer.GracefulStop()
+	log.Println("Spending account service stopped")
+}
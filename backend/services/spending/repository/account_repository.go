@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sydney-health-clone/backend/pkg/database"
+	"github.com/sydney-health-clone/backend/services/spending/internal/account"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// AccountStore keeps accounts in spending_accounts, their ledgers in
+// spending_transactions and the claims they can pay in spending_claims
+type AccountStore struct {
+	db *database.DB
+}
+
+// NewAccountStore creates a new spending account store
+func NewAccountStore(db *database.DB) *AccountStore {
+	return &AccountStore{db: db}
+}
+
+const accountColumns = `
+	account_id, member_id, account_type, plan_year_start, plan_year_end,
+	annual_amount_cents, family_coverage, catch_up_eligible, opened_at
+`
+
+const transactionColumns = `
+	transaction_id, account_id, transaction_type, amount_cents, description,
+	COALESCE(claim_id, ''), COALESCE(tax_year, 0), transaction_date, posted_at
+`
+
+func (s *AccountStore) Create(ctx context.Context, a *pb.SpendingAccount) error {
+	query := `
+		INSERT INTO spending_accounts (` + accountColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		a.AccountId, a.MemberId, account.TypeName(a.AccountType), nullDate(a.PlanYearStart), nullDate(a.PlanYearEnd),
+		a.AnnualAmount.GetCents(), a.FamilyCoverage, a.CatchUpEligible, a.OpenedAt.AsTime(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert account: %w", err)
+	}
+	return nil
+}
+
+func (s *AccountStore) Account(ctx context.Context, id string) (*pb.SpendingAccount, error) {
+	query := `SELECT ` + accountColumns + ` FROM spending_accounts WHERE account_id = $1`
+
+	a, err := scanAccount(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, account.ErrNotFound
+	}
+	return a, err
+}
+
+func (s *AccountStore) Accounts(ctx context.Context, memberID string) ([]*pb.SpendingAccount, error) {
+	query := `SELECT ` + accountColumns + ` FROM spending_accounts WHERE member_id = $1 ORDER BY opened_at, account_id`
+
+	rows, err := s.db.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*pb.SpendingAccount
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func (s *AccountStore) Totals(ctx context.Context, accountID string, taxYear int32) (account.Totals, error) {
+	return totals(ctx, s.db, accountID, taxYear, "")
+}
+
+func (s *AccountStore) Transactions(ctx context.Context, accountID string, f account.Filter, offset, limit int) ([]*pb.SpendingTransaction, int, error) {
+	types := make([]string, len(f.Types))
+	for i, t := range f.Types {
+		types[i] = account.TransactionTypeName(t)
+	}
+	filter := `
+		account_id = $1
+		AND (cardinality($2::text[]) = 0 OR transaction_type = ANY($2))
+		AND ($3::date IS NULL OR transaction_date >= $3)
+		AND ($4::date IS NULL OR transaction_date <= $4)
+		AND ($5 = '' OR claim_id = $5)
+	`
+	args := []interface{}{accountID, pq.Array(types), nullTime(f.Start), nullTime(f.End), f.ClaimID}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM spending_transactions WHERE `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM spending_transactions
+		WHERE ` + filter + `
+		ORDER BY transaction_date DESC, posted_at DESC, transaction_id
+		OFFSET $6 LIMIT $7
+	`
+	rows, err := s.db.QueryContext(ctx, query, append(args, offset, limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*pb.SpendingTransaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// Post locks the claim for a reimbursement, then the account, so concurrent
+// posts are checked against each other's totals. Payments toward a claim can
+// come from any of the member's accounts, so the claim's lock is what keeps
+// them from paying more than is owed between them.
+func (s *AccountStore) Post(ctx context.Context, t *pb.SpendingTransaction, externalID string, check func(account.Totals) error) (*pb.SpendingTransaction, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked string
+	if t.ClaimId != "" {
+		err = tx.QueryRowContext(ctx, `SELECT claim_id FROM spending_claims WHERE claim_id = $1 FOR UPDATE`, t.ClaimId).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, account.ErrClaimNotFound
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to lock claim: %w", err)
+		}
+	}
+	err = tx.QueryRowContext(ctx, `SELECT account_id FROM spending_accounts WHERE account_id = $1 FOR UPDATE`, t.AccountId).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, account.ErrNotFound
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	existing, err := scanTransaction(tx.QueryRowContext(ctx,
+		`SELECT `+transactionColumns+` FROM spending_transactions WHERE account_id = $1 AND external_id = $2`,
+		t.AccountId, externalID,
+	))
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	sums, err := totals(ctx, tx, t.AccountId, t.TaxYear, t.ClaimId)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := check(sums); err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO spending_transactions (
+			transaction_id, account_id, transaction_type, amount_cents, description,
+			claim_id, tax_year, transaction_date, external_id, posted_at
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, 0), $8, $9, $10)
+	`,
+		t.TransactionId, t.AccountId, account.TransactionTypeName(t.TransactionType), t.Amount.GetCents(), t.Description,
+		t.ClaimId, t.TaxYear, t.TransactionDate.AsTime(), externalID, t.PostedAt.AsTime(),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert transaction: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return t, true, nil
+}
+
+func (s *AccountStore) Limit(ctx context.Context, accountType pb.SpendingAccountType, year int32) (*account.Limit, error) {
+	limit := account.Limit{Year: year, AccountType: accountType}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT self_only_cents, family_cents, catch_up_cents
+		FROM spending_contribution_limits
+		WHERE year = $1 AND account_type = $2
+	`, year, account.TypeName(accountType)).Scan(&limit.SelfOnlyCents, &limit.FamilyCents, &limit.CatchUpCents)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contribution limit: %w", err)
+	}
+	return &limit, nil
+}
+
+func (s *AccountStore) RecordClaim(ctx context.Context, c *account.Claim) (bool, error) {
+	query := `
+		INSERT INTO spending_claims (claim_id, version, member_id, service_date, responsibility_cents, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (claim_id) DO UPDATE SET
+			version = EXCLUDED.version,
+			member_id = EXCLUDED.member_id,
+			service_date = EXCLUDED.service_date,
+			responsibility_cents = EXCLUDED.responsibility_cents,
+			updated_at = NOW()
+		WHERE spending_claims.version < EXCLUDED.version
+	`
+	result, err := s.db.ExecContext(ctx, query, c.ClaimID, c.Version, c.MemberID, c.ServiceDate, c.ResponsibilityCents)
+	if err != nil {
+		return false, fmt.Errorf("failed to record claim: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record claim: %w", err)
+	}
+	return rows > 0, nil
+}
+
+func (s *AccountStore) Claim(ctx context.Context, claimID string) (*account.Claim, error) {
+	c := account.Claim{ClaimID: claimID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT version, member_id, service_date, responsibility_cents
+		FROM spending_claims
+		WHERE claim_id = $1
+	`, claimID).Scan(&c.Version, &c.MemberID, &c.ServiceDate, &c.ResponsibilityCents)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, account.ErrClaimNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query claim: %w", err)
+	}
+	return &c, nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// totals sums the account's ledger, and the payments toward claimID from
+// every account when it is set
+func totals(ctx context.Context, db queryRower, accountID string, taxYear int32, claimID string) (account.Totals, error) {
+	var t account.Totals
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(amount_cents) FILTER (WHERE account_id = $1), 0),
+			COALESCE(SUM(-amount_cents) FILTER (WHERE account_id = $1 AND amount_cents < 0), 0),
+			COALESCE(SUM(amount_cents) FILTER (WHERE account_id = $1 AND transaction_type = 'CONTRIBUTION' AND ($2 = 0 OR tax_year = $2)), 0),
+			COALESCE(SUM(-amount_cents) FILTER (WHERE claim_id = $3), 0)
+		FROM spending_transactions
+		WHERE account_id = $1 OR claim_id = $3
+	`, accountID, taxYear, claimID).Scan(&t.BalanceCents, &t.SpentCents, &t.ContributedCents, &t.ClaimPaidCents)
+	if err != nil {
+		return t, fmt.Errorf("failed to total account: %w", err)
+	}
+	return t, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row scanner) (*pb.SpendingAccount, error) {
+	var (
+		a                  pb.SpendingAccount
+		accountType        string
+		planStart, planEnd sql.NullTime
+		annualCents        int64
+		openedAt           time.Time
+	)
+	err := row.Scan(
+		&a.AccountId, &a.MemberId, &accountType, &planStart, &planEnd,
+		&annualCents, &a.FamilyCoverage, &a.CatchUpEligible, &openedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan account: %w", err)
+	}
+
+	a.AccountType = account.ParseType(accountType)
+	if planStart.Valid && planEnd.Valid {
+		a.PlanYearStart = timestamppb.New(planStart.Time)
+		a.PlanYearEnd = timestamppb.New(planEnd.Time)
+	}
+	if annualCents != 0 {
+		a.AnnualAmount = &pb.Money{Cents: annualCents, Currency: "USD"}
+	}
+	a.OpenedAt = timestamppb.New(openedAt)
+	return &a, nil
+}
+
+func scanTransaction(row scanner) (*pb.SpendingTransaction, error) {
+	var (
+		t                         pb.SpendingTransaction
+		transactionType           string
+		amountCents               int64
+		transactionDate, postedAt time.Time
+	)
+	err := row.Scan(
+		&t.TransactionId, &t.AccountId, &transactionType, &amountCents, &t.Description,
+		&t.ClaimId, &t.TaxYear, &transactionDate, &postedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan transaction: %w", err)
+	}
+
+	t.TransactionType = account.ParseTransactionType(transactionType)
+	t.Amount = &pb.Money{Cents: amountCents, Currency: "USD"}
+	t.TransactionDate = timestamppb.New(transactionDate)
+	t.PostedAt = timestamppb.New(postedAt)
+	return &t, nil
+}
+
+func nullDate(ts *timestamppb.Timestamp) sql.NullTime {
+	if ts == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: ts.AsTime(), Valid: true}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sydney-health-clone/backend/services/spending/internal/account"
+	"github.com/sydney-health-clone/backend/shared/access"
+	sharedkafka "github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// SpendingService implements the gRPC SpendingAccountService. Callers must be
+// the member, hold the access the gateway signed for them, or be internal;
+// accounts are opened and funded only by internal callers.
+type SpendingService struct {
+	pb.UnimplementedSpendingAccountServiceServer
+	ledger *account.Ledger
+}
+
+// NewSpendingService creates a new spending account service
+func NewSpendingService(ledger *account.Ledger) *SpendingService {
+	return &SpendingService{ledger: ledger}
+}
+
+// ListAccounts returns the member's accounts with their balances
+func (s *SpendingService) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	log.Printf("ListAccounts called for member ID: %s", req.MemberId)
+
+	if req.MemberId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id is required")
+	}
+	if _, err := access.AuthorizeMember(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.ledger.Accounts(ctx, req.MemberId)
+	if err != nil {
+		return nil, statusError(err, "failed to list accounts")
+	}
+	if accounts == nil {
+		accounts = []*pb.SpendingAccount{}
+	}
+	return &pb.ListAccountsResponse{Accounts: accounts}, nil
+}
+
+// GetAccount returns one account with its balances
+func (s *SpendingService) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	log.Printf("GetAccount called for account ID: %s", req.AccountId)
+
+	if req.AccountId == "" {
+		return nil, status.Error(codes.InvalidArgument, "account_id is required")
+	}
+	if err := authorize(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	a, err := s.ledger.Account(ctx, req.AccountId)
+	if err != nil {
+		return nil, statusError(err, "failed to retrieve account")
+	}
+	if req.MemberId != "" && a.MemberId != req.MemberId {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+	return &pb.GetAccountResponse{Account: a}, nil
+}
+
+// ListTransactions lists the account's transactions, newest first
+func (s *SpendingService) ListTransactions(ctx context.Context, req *pb.ListTransactionsRequest) (*pb.ListTransactionsResponse, error) {
+	log.Printf("ListTransactions called for account ID: %s", req.AccountId)
+
+	if err := s.checkOwner(ctx, req.AccountId, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	var start, end time.Time
+	if req.StartDate != nil {
+		start = req.StartDate.AsTime()
+	}
+	if req.EndDate != nil {
+		end = req.EndDate.AsTime()
+	}
+	transactions, page, err := s.ledger.Transactions(ctx, req.AccountId, account.Filter{
+		Types: req.TransactionTypes,
+		Start: start,
+		End:   end,
+	}, req.Page)
+	if err != nil {
+		return nil, statusError(err, "failed to list transactions")
+	}
+	if transactions == nil {
+		transactions = []*pb.SpendingTransaction{}
+	}
+	return &pb.ListTransactionsResponse{Transactions: transactions, Page: page}, nil
+}
+
+// OpenAccount opens an account for the account administrator
+func (s *SpendingService) OpenAccount(ctx context.Context, req *pb.OpenAccountRequest) (*pb.OpenAccountResponse, error) {
+	log.Printf("OpenAccount called for member ID: %s, type: %s", req.MemberId, req.AccountType)
+
+	if err := internalOnly(ctx); err != nil {
+		return nil, err
+	}
+
+	a, err := s.ledger.Open(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to open account")
+	}
+	return &pb.OpenAccountResponse{Account: a}, nil
+}
+
+// RecordTransaction posts a contribution or card purchase from the account
+// administrator
+func (s *SpendingService) RecordTransaction(ctx context.Context, req *pb.RecordTransactionRequest) (*pb.RecordTransactionResponse, error) {
+	log.Printf("RecordTransaction called for account ID: %s, type: %s", req.AccountId, req.TransactionType)
+
+	if err := internalOnly(ctx); err != nil {
+		return nil, err
+	}
+
+	t, a, err := s.ledger.Record(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to record transaction")
+	}
+	return &pb.RecordTransactionResponse{Transaction: t, Account: a}, nil
+}
+
+// PayClaim pays what the member owes on a claim from one of their accounts
+func (s *SpendingService) PayClaim(ctx context.Context, req *pb.PayClaimRequest) (*pb.PayClaimResponse, error) {
+	log.Printf("PayClaim called for account ID: %s, claim ID: %s", req.AccountId, req.ClaimId)
+
+	if req.MemberId == "" || req.AccountId == "" {
+		return nil, status.Error(codes.InvalidArgument, "member_id and account_id are required")
+	}
+	// Paying acts on the member's behalf
+	if err := access.RequireFullAccess(ctx, req.MemberId); err != nil {
+		return nil, err
+	}
+
+	t, a, remaining, err := s.ledger.PayClaim(ctx, req)
+	if err != nil {
+		return nil, statusError(err, "failed to pay claim")
+	}
+	return &pb.PayClaimResponse{
+		Transaction:             t,
+		Account:                 a,
+		RemainingResponsibility: &pb.Money{Cents: remaining, Currency: "USD"},
+	}, nil
+}
+
+// HandleClaimAdjudication keeps what the member owes on a claim from the
+// claims system. Events arrive at least once and possibly out of order; only
+// the latest version of each claim is kept.
+func (s *SpendingService) HandleClaimAdjudication(ctx context.Context, message kafka.Message) error {
+	adjudication, err := sharedkafka.UnmarshalClaimAdjudication(message.Value)
+	if err != nil {
+		return err
+	}
+
+	applied, err := s.ledger.ApplyClaim(ctx, adjudication)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		log.Printf("Ignoring claim %s version %d: already applied", adjudication.ClaimID, adjudication.Version)
+	}
+	return nil
+}
+
+// checkOwner checks the caller has full access to the member and that the
+// account belongs to that member. Transactions include payments for every
+// claim, sensitive services included, which restricted access would reveal.
+func (s *SpendingService) checkOwner(ctx context.Context, accountID, memberID string) error {
+	if accountID == "" {
+		return status.Error(codes.InvalidArgument, "account_id is required")
+	}
+	if err := authorize(ctx, memberID); err != nil {
+		return err
+	}
+	if memberID == "" {
+		return nil
+	}
+	if err := access.RequireFullAccess(ctx, memberID); err != nil {
+		return err
+	}
+
+	a, err := s.ledger.Account(ctx, accountID)
+	if err != nil {
+		return statusError(err, "failed to retrieve account")
+	}
+	if a.MemberId != memberID {
+		return status.Error(codes.NotFound, "account not found")
+	}
+	return nil
+}
+
+// authorize checks the caller may see memberID's accounts. Only internal
+// callers may leave the member out to read any account.
+func authorize(ctx context.Context, memberID string) error {
+	if memberID == "" {
+		if _, ok := access.InternalFromContext(ctx); !ok {
+			return status.Error(codes.InvalidArgument, "member_id is required")
+		}
+		return nil
+	}
+	_, err := access.AuthorizeMember(ctx, memberID)
+	return err
+}
+
+// internalOnly rejects calls from anyone but internal callers. A call without
+// a member session isn't internal for that alone; it must carry a backend
+// service's signature.
+func internalOnly(ctx context.Context) error {
+	if _, ok := access.InternalFromContext(ctx); !ok {
+		return status.Error(codes.PermissionDenied, "restricted to internal callers")
+	}
+	return nil
+}
+
+// statusError maps ledger errors to gRPC statuses, logging unexpected ones
+func statusError(err error, message string) error {
+	switch {
+	case errors.Is(err, account.ErrNotFound), errors.Is(err, account.ErrClaimNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, account.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, account.ErrInsufficientFunds), errors.Is(err, account.ErrLimitExceeded):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, account.ErrAlreadyPaid):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	log.Printf("Error: %s: %v", message, err)
+	return status.Error(codes.Internal, message)
+}
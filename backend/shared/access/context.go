@@ -0,0 +1,200 @@
+package access
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequesterMetadataKey carries the authenticated member ID from the gateway to backend services
+const RequesterMetadataKey = "x-requester-member-id"
+
+// MemberAccessMetadataKey carries the access the gateway granted the
+// requester to another member's record, as "<member ID>:<access level>"
+const MemberAccessMetadataKey = "x-member-access"
+
+// IdentitySignatureKey proves the gateway set the requester, agent and member
+// access on a call: the Unix time the call was signed and an HMAC-SHA256 over
+// them and that time, keyed with ServiceSecretEnv
+const IdentitySignatureKey = "x-identity-signature"
+
+// WithRequester attaches the requesting member ID to outgoing gRPC metadata
+func WithRequester(ctx context.Context, memberID string) context.Context {
+	if memberID == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequesterMetadataKey, memberID)
+}
+
+// RequesterFromContext returns the requesting member ID from incoming gRPC
+// metadata, when the gateway's identity signature over it is valid and recent.
+// Calls without one are not trusted for that alone; see InternalFromContext.
+func RequesterFromContext(ctx context.Context) (string, bool) {
+	id, ok := identityFromContext(ctx)
+	if !ok || id.requesterID == "" {
+		return "", false
+	}
+	return id.requesterID, true
+}
+
+// WithMemberAccess attaches the access the requester was granted to another
+// member's record to outgoing gRPC metadata
+func WithMemberAccess(ctx context.Context, memberID string, level pb.AccessLevel) context.Context {
+	if memberID == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MemberAccessMetadataKey, memberID+":"+level.String())
+}
+
+// MemberAccessFromContext decides what the caller may see of memberID's
+// record. A member session has full access to its own record and the access
+// the gateway signed for any other; internal callers have full access; anyone
+// else has none.
+func MemberAccessFromContext(ctx context.Context, memberID string) Decision {
+	if id, ok := identityFromContext(ctx); ok && id.requesterID != "" {
+		if id.requesterID == memberID {
+			return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "self"}
+		}
+		for _, grant := range id.grants {
+			if granted, level, _ := strings.Cut(grant, ":"); granted == memberID {
+				return Decision{Level: pb.AccessLevel(pb.AccessLevel_value[level]), Reason: "granted by gateway"}
+			}
+		}
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "no access granted to member"}
+	}
+	if caller, ok := InternalFromContext(ctx); ok {
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "internal caller " + caller}
+	}
+	return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "caller not identified"}
+}
+
+// AuthorizeMember returns the caller's access to memberID, or a
+// PermissionDenied status when the caller may not see the member at all
+func AuthorizeMember(ctx context.Context, memberID string) (Decision, error) {
+	decision := MemberAccessFromContext(ctx, memberID)
+	if !decision.Allowed() {
+		return decision, status.Errorf(codes.PermissionDenied, "access denied: %s", decision.Reason)
+	}
+	return decision, nil
+}
+
+// RequireFullAccess returns a PermissionDenied status unless the caller has
+// full access to memberID: restricted access hides sensitive services, and
+// what the caller would learn can't be filtered
+func RequireFullAccess(ctx context.Context, memberID string) error {
+	decision, err := AuthorizeMember(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	if !decision.Full() {
+		return status.Error(codes.PermissionDenied, "access denied: full access to the member is required")
+	}
+	return nil
+}
+
+// AgentRole is the JWT role claim that identifies a support agent
+const AgentRole = "agent"
+
+// AgentMetadataKey carries the authenticated support agent's ID from the gateway to backend services
+const AgentMetadataKey = "x-agent-id"
+
+// WithAgent attaches the support agent's ID to outgoing gRPC metadata
+func WithAgent(ctx context.Context, agentID string) context.Context {
+	if agentID == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, AgentMetadataKey, agentID)
+}
+
+// AgentFromContext returns the support agent's ID from incoming gRPC metadata,
+// when the gateway's identity signature over it is valid and recent. Only the
+// gateway sets it, and only for tokens carrying the agent role.
+func AgentFromContext(ctx context.Context) (string, bool) {
+	id, ok := identityFromContext(ctx)
+	if !ok || id.agentID == "" {
+		return "", false
+	}
+	return id.agentID, true
+}
+
+// IdentityInterceptor signs the requester and agent IDs on every call made
+// over a connection, so backend services can trust them. Only the gateway,
+// which authenticates members and agents itself, installs it. Calls are left
+// unsigned when no secret is configured.
+func IdentityInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(signIdentity(ctx, time.Now()), method, req, reply, cc, opts...)
+	}
+}
+
+// identity is who a call is for, as the gateway signed it
+type identity struct {
+	requesterID string
+	agentID     string
+	grants      []string
+}
+
+func signIdentity(ctx context.Context, now time.Time) context.Context {
+	secret := os.Getenv(ServiceSecretEnv)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	id := identity{
+		requesterID: single(md.Get(RequesterMetadataKey)),
+		agentID:     single(md.Get(AgentMetadataKey)),
+		grants:      md.Get(MemberAccessMetadataKey),
+	}
+	if secret == "" || (id.requesterID == "" && id.agentID == "") {
+		return ctx
+	}
+	signedAt := strconv.FormatInt(now.Unix(), 10)
+	return metadata.AppendToOutgoingContext(ctx,
+		IdentitySignatureKey, signedAt+"."+id.signature(secret, signedAt),
+	)
+}
+
+// identityFromContext returns who the call is for from incoming gRPC metadata
+// when the identity signature covers exactly that metadata. A repeated
+// requester or agent is rejected rather than guessing which one was signed.
+func identityFromContext(ctx context.Context) (identity, bool) {
+	secret := os.Getenv(ServiceSecretEnv)
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || secret == "" {
+		return identity{}, false
+	}
+
+	requesters, agents, signatures := md.Get(RequesterMetadataKey), md.Get(AgentMetadataKey), md.Get(IdentitySignatureKey)
+	if len(requesters) > 1 || len(agents) > 1 || len(signatures) != 1 {
+		return identity{}, false
+	}
+	id := identity{requesterID: single(requesters), agentID: single(agents), grants: md.Get(MemberAccessMetadataKey)}
+	err := verifySignature(signatures[0], time.Now(), func(signedAt string) string {
+		return id.signature(secret, signedAt)
+	})
+	if err != nil {
+		return identity{}, false
+	}
+	return id, true
+}
+
+func (id identity) signature(secret, signedAt string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("identity\n" + signedAt + "\n" + id.requesterID + "\n" + id.agentID + "\n" + strings.Join(id.grants, "\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func single(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
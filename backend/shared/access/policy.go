@@ -0,0 +1,101 @@
+package access
+
+import (
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+const (
+	// Subscribers see dependents younger than this without restriction.
+	MinorAge = 13
+	// Dependents at or above this age must grant proxy access explicitly.
+	AdultAge = 18
+)
+
+// Decision is the outcome of evaluating one member's access to another's record
+type Decision struct {
+	Level  pb.AccessLevel
+	Reason string
+}
+
+// Allowed reports whether the decision permits any access at all
+func (d Decision) Allowed() bool {
+	return d.Level == pb.AccessLevel_ACCESS_LEVEL_RESTRICTED || d.Level == pb.AccessLevel_ACCESS_LEVEL_FULL
+}
+
+// Full reports whether the decision permits unrestricted access
+func (d Decision) Full() bool {
+	return d.Level == pb.AccessLevel_ACCESS_LEVEL_FULL
+}
+
+// Evaluate decides what requesterID may see of subject at the given time.
+// Grants are the proxy grants stored against the subject; inactive grants are ignored.
+func Evaluate(requesterID string, subject *pb.Member, grants []*pb.ProxyGrant, at time.Time) Decision {
+	if subject == nil || requesterID == "" {
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "unknown requester or member"}
+	}
+
+	if requesterID == subject.MemberId {
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "self"}
+	}
+
+	for _, grant := range grants {
+		if grant.MemberId == subject.MemberId && grant.ProxyMemberId == requesterID && GrantActive(grant, at) {
+			return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "proxy grant " + grant.GrantId}
+		}
+	}
+
+	if subject.SubscriberMemberId == "" || subject.SubscriberMemberId != requesterID {
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "no relationship to member"}
+	}
+
+	// Without a date of birth we cannot prove the dependent is a minor
+	if subject.DateOfBirth == nil {
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "dependent age unknown; proxy grant required"}
+	}
+
+	age := AgeOn(subject.DateOfBirth.AsTime(), at)
+	switch {
+	case age < MinorAge:
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_FULL, Reason: "subscriber of minor dependent"}
+	case age < AdultAge:
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_RESTRICTED, Reason: "subscriber of adolescent dependent"}
+	default:
+		return Decision{Level: pb.AccessLevel_ACCESS_LEVEL_NONE, Reason: "adult dependent; proxy grant required"}
+	}
+}
+
+// GrantActive reports whether a grant is neither revoked nor expired at the given time
+func GrantActive(grant *pb.ProxyGrant, at time.Time) bool {
+	if grant == nil || grant.RevokedAt != nil {
+		return false
+	}
+	if grant.ExpiresAt != nil && !at.Before(grant.ExpiresAt.AsTime()) {
+		return false
+	}
+	return true
+}
+
+// AgeOn returns the age in whole years of someone born on dob at the given time
+func AgeOn(dob, at time.Time) int {
+	dob = dob.UTC()
+	at = at.UTC()
+
+	age := at.Year() - dob.Year()
+	if at.Month() < dob.Month() || (at.Month() == dob.Month() && at.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// Redact strips a member record down to the identity fields a subscriber may
+// always see for a dependent on their policy.
+func Redact(member *pb.Member) *pb.Member {
+	return &pb.Member{
+		MemberId:           member.MemberId,
+		FirstName:          member.FirstName,
+		LastName:           member.LastName,
+		SubscriberMemberId: member.SubscriberMemberId,
+	}
+}
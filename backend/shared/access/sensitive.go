@@ -0,0 +1,94 @@
+package access
+
+import (
+	"strconv"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Sensitive claim categories that are confidential to adolescent members
+const (
+	CategoryBehavioralHealth = "behavioral_health"
+	CategorySubstanceUse     = "substance_use"
+	CategoryReproductive     = "reproductive_health"
+	CategorySexualHealth     = "sexual_health"
+)
+
+type codeRange struct {
+	prefix   string
+	low      int
+	high     int
+	category string
+}
+
+// Service code ranges (CPT/HCPCS) that fall into a sensitive category.
+// The prefix is the non-numeric lead character for HCPCS codes; empty for CPT.
+var sensitiveCodeRanges = []codeRange{
+	{"", 90785, 90899, CategoryBehavioralHealth},
+	{"", 99408, 99409, CategorySubstanceUse},
+	{"H", 1, 50, CategorySubstanceUse},
+	{"", 59840, 59857, CategoryReproductive},
+	{"", 58300, 58301, CategoryReproductive},
+	{"", 81025, 81025, CategoryReproductive},
+	{"J", 7296, 7307, CategoryReproductive},
+	{"", 86689, 86703, CategorySexualHealth},
+	{"", 87389, 87390, CategorySexualHealth},
+	{"", 87490, 87492, CategorySexualHealth},
+	{"", 87590, 87592, CategorySexualHealth},
+}
+
+// SensitiveCategory returns the sensitive category for a service code, or "" if none
+func SensitiveCategory(serviceCode string) string {
+	code := strings.ToUpper(strings.TrimSpace(serviceCode))
+	if code == "" {
+		return ""
+	}
+
+	prefix := ""
+	if code[0] < '0' || code[0] > '9' {
+		prefix = code[:1]
+		code = code[1:]
+	}
+
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return ""
+	}
+
+	for _, r := range sensitiveCodeRanges {
+		if r.prefix == prefix && n >= r.low && n <= r.high {
+			return r.category
+		}
+	}
+	return ""
+}
+
+// IsSensitiveClaim reports whether any line on the claim is in a sensitive category
+func IsSensitiveClaim(claim *pb.Claim) bool {
+	for _, line := range claim.LineItems {
+		if SensitiveCategory(line.ServiceCode) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterClaims drops claims the access level does not permit.
+// Restricted access hides sensitive claims; full access returns the list unchanged.
+func FilterClaims(claims []*pb.Claim, level pb.AccessLevel) []*pb.Claim {
+	if level == pb.AccessLevel_ACCESS_LEVEL_FULL {
+		return claims
+	}
+	if level != pb.AccessLevel_ACCESS_LEVEL_RESTRICTED {
+		return []*pb.Claim{}
+	}
+
+	filtered := make([]*pb.Claim, 0, len(claims))
+	for _, claim := range claims {
+		if !IsSensitiveClaim(claim) {
+			filtered = append(filtered, claim)
+		}
+	}
+	return filtered
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +14,8 @@ type Config struct {
 	Services ServicesConfig `mapstructure:"services"`
 	Auth     AuthConfig     `mapstructure:"auth"`
 	Metrics  MetricsConfig  `mapstructure:"metrics"`
+	Wallet   WalletConfig   `mapstructure:"wallet"`
+	Contact  ContactConfig  `mapstructure:"contact"`
 }
 
 type ServerConfig struct {
@@ -33,11 +37,12 @@ type DatabaseConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers          []string `mapstructure:"brokers"`
-	GroupID          string   `mapstructure:"group_id"`
-	ClaimsTopic      string   `mapstructure:"claims_topic"`
-	MessagesTopic    string   `mapstructure:"messages_topic"`
-	AuditTopic       string   `mapstructure:"audit_topic"`
+	Brokers            []string `mapstructure:"brokers"`
+	GroupID            string   `mapstructure:"group_id"`
+	ClaimsTopic        string   `mapstructure:"claims_topic"`
+	MessagesTopic      string   `mapstructure:"messages_topic"`
+	AuditTopic         string   `mapstructure:"audit_topic"`
+	MemberUpdatesTopic string   `mapstructure:"member_updates_topic"`
 }
 
 type ServicesConfig struct {
@@ -46,6 +51,8 @@ type ServicesConfig struct {
 	ProviderService  ServiceEndpoint `mapstructure:"provider_service"`
 	ClaimsService    ServiceEndpoint `mapstructure:"claims_service"`
 	MessagingService ServiceEndpoint `mapstructure:"messaging_service"`
+	PriorAuthService ServiceEndpoint `mapstructure:"prior_auth_service"`
+	SpendingService  ServiceEndpoint `mapstructure:"spending_service"`
 }
 
 type ServiceEndpoint struct {
@@ -58,6 +65,31 @@ type AuthConfig struct {
 	TokenDuration int    `mapstructure:"token_duration"`
 }
 
+// WalletConfig holds the credentials for issuing Apple and Google wallet passes
+type WalletConfig struct {
+	PassTypeIdentifier        string   `mapstructure:"pass_type_identifier"`
+	TeamIdentifier            string   `mapstructure:"team_identifier"`
+	OrganizationName          string   `mapstructure:"organization_name"`
+	WebServiceURL             string   `mapstructure:"web_service_url"`
+	CertFile                  string   `mapstructure:"cert_file"`
+	KeyFile                   string   `mapstructure:"key_file"`
+	WWDRFile                  string   `mapstructure:"wwdr_file"`
+	AuthSecret                string   `mapstructure:"auth_secret"`
+	GoogleIssuerID            string   `mapstructure:"google_issuer_id"`
+	GoogleClassSuffix         string   `mapstructure:"google_class_suffix"`
+	GoogleServiceAccountEmail string   `mapstructure:"google_service_account_email"`
+	GoogleKeyFile             string   `mapstructure:"google_key_file"`
+	Origins                   []string `mapstructure:"origins"`
+}
+
+// ContactConfig controls verification of email and phone changes. Codes
+// are written to OutboxDir when it is set, and logged otherwise.
+type ContactConfig struct {
+	OutboxDir   string        `mapstructure:"outbox_dir"`
+	CodeTTL     time.Duration `mapstructure:"code_ttl"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+}
+
 type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
 	Port    int    `mapstructure:"port"`
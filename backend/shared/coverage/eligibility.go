@@ -0,0 +1,236 @@
+package coverage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Day truncates t to its calendar day in UTC. Coverage dates carry no time of day.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Void reports whether the span was terminated before it took effect, as
+// with a rescission or a retroactive cancellation back to the start.
+func Void(span *pb.CoverageSpan) bool {
+	if span.TerminationDate == nil || span.EffectiveDate == nil {
+		return false
+	}
+	return Day(span.TerminationDate.AsTime()).Before(Day(span.EffectiveDate.AsTime()))
+}
+
+// Covers reports whether the span covers the given day
+func Covers(span *pb.CoverageSpan, on time.Time) bool {
+	if span.EffectiveDate == nil || Void(span) {
+		return false
+	}
+
+	day := Day(on)
+	if day.Before(Day(span.EffectiveDate.AsTime())) {
+		return false
+	}
+	return span.TerminationDate == nil || !day.After(Day(span.TerminationDate.AsTime()))
+}
+
+// Overlaps reports whether two non-void spans of the same coverage type share a day
+func Overlaps(a, b *pb.CoverageSpan) bool {
+	if a.CoverageType != b.CoverageType || Void(a) || Void(b) {
+		return false
+	}
+
+	aStart, bStart := Day(a.EffectiveDate.AsTime()), Day(b.EffectiveDate.AsTime())
+	if a.TerminationDate != nil && Day(a.TerminationDate.AsTime()).Before(bStart) {
+		return false
+	}
+	if b.TerminationDate != nil && Day(b.TerminationDate.AsTime()).Before(aStart) {
+		return false
+	}
+	return true
+}
+
+// Sort orders spans by coverage type, then effective date
+func Sort(spans []*pb.CoverageSpan) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].CoverageType != spans[j].CoverageType {
+			return spans[i].CoverageType < spans[j].CoverageType
+		}
+		return spans[i].EffectiveDate.AsTime().Before(spans[j].EffectiveDate.AsTime())
+	})
+}
+
+// Find returns the span of the coverage type covering the given day. When
+// spans overlap, the one that took effect last wins.
+func Find(spans []*pb.CoverageSpan, coverageType pb.CoverageType, on time.Time) *pb.CoverageSpan {
+	var found *pb.CoverageSpan
+	for _, span := range spans {
+		if span.CoverageType != coverageType || !Covers(span, on) {
+			continue
+		}
+		if found == nil || span.EffectiveDate.AsTime().After(found.EffectiveDate.AsTime()) {
+			found = span
+		}
+	}
+	return found
+}
+
+// Ended returns the span of the coverage type that ended most recently
+// before the given day, or nil when none has
+func Ended(spans []*pb.CoverageSpan, coverageType pb.CoverageType, on time.Time) *pb.CoverageSpan {
+	day := Day(on)
+	var found *pb.CoverageSpan
+	for _, span := range spans {
+		if span.CoverageType != coverageType || span.TerminationDate == nil || Void(span) {
+			continue
+		}
+		end := Day(span.TerminationDate.AsTime())
+		if !end.Before(day) {
+			continue
+		}
+		if found == nil || end.After(found.TerminationDate.AsTime()) {
+			found = span
+		}
+	}
+	return found
+}
+
+// ActiveTypes lists the coverage types in effect on the given day
+func ActiveTypes(spans []*pb.CoverageSpan, on time.Time) []pb.CoverageType {
+	seen := make(map[pb.CoverageType]bool)
+	var types []pb.CoverageType
+	for _, span := range spans {
+		if !seen[span.CoverageType] && Covers(span, on) {
+			seen[span.CoverageType] = true
+			types = append(types, span.CoverageType)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Check answers whether the member held the coverage type on the date of
+// service. When not eligible, the nearest span of the type is returned to
+// explain why.
+func Check(spans []*pb.CoverageSpan, coverageType pb.CoverageType, dateOfService time.Time) (*pb.CoverageSpan, bool, string) {
+	if span := Find(spans, coverageType, dateOfService); span != nil {
+		return span, true, "covered under " + span.PlanName
+	}
+
+	day := Day(dateOfService)
+	var before, after *pb.CoverageSpan
+	for _, span := range spans {
+		if span.CoverageType != coverageType || Void(span) {
+			continue
+		}
+		start := Day(span.EffectiveDate.AsTime())
+		if start.After(day) {
+			if after == nil || start.Before(after.EffectiveDate.AsTime()) {
+				after = span
+			}
+		} else if before == nil || start.After(before.EffectiveDate.AsTime()) {
+			before = span
+		}
+	}
+
+	switch {
+	case before != nil:
+		return before, false, fmt.Sprintf("coverage terminated %s (%s)",
+			FormatDate(before.TerminationDate), ReasonName(before.TerminationReason))
+	case after != nil:
+		return after, false, "coverage not effective until " + FormatDate(after.EffectiveDate)
+	default:
+		return nil, false, "no " + TypeName(coverageType) + " coverage on record"
+	}
+}
+
+// ValidateTermination checks a requested termination against the span. The
+// date may be in the past; the day before the effective date voids the span.
+func ValidateTermination(span *pb.CoverageSpan, terminationDate time.Time) error {
+	if Void(span) {
+		return fmt.Errorf("coverage span %s is void", span.SpanId)
+	}
+
+	earliest := Day(span.EffectiveDate.AsTime()).AddDate(0, 0, -1)
+	if Day(terminationDate).Before(earliest) {
+		return fmt.Errorf("termination date is before the day before coverage took effect")
+	}
+	return nil
+}
+
+// Terminate sets the span's last covered day and reason
+func Terminate(span *pb.CoverageSpan, terminationDate time.Time, reason pb.TerminationReason, at time.Time) {
+	span.TerminationDate = timestamppb.New(Day(terminationDate))
+	span.TerminationReason = reason
+	span.UpdatedAt = timestamppb.New(at)
+}
+
+// ValidateReinstatement checks that a terminated span can be reopened
+// without overlapping the member's other spans of the same type.
+func ValidateReinstatement(spans []*pb.CoverageSpan, span *pb.CoverageSpan) error {
+	if span.TerminationDate == nil {
+		return fmt.Errorf("coverage span %s is not terminated", span.SpanId)
+	}
+
+	reopened := &pb.CoverageSpan{CoverageType: span.CoverageType, EffectiveDate: span.EffectiveDate}
+	for _, other := range spans {
+		if other.SpanId != span.SpanId && Overlaps(reopened, other) {
+			return fmt.Errorf("coverage span %s overlaps %s", span.SpanId, other.SpanId)
+		}
+	}
+	return nil
+}
+
+// Reinstate reopens a terminated span so coverage continues without a gap
+func Reinstate(span *pb.CoverageSpan, at time.Time) {
+	span.TerminationDate = nil
+	span.TerminationReason = pb.TerminationReason_TERMINATION_REASON_UNSPECIFIED
+	span.ReinstatedAt = timestamppb.New(at)
+	span.UpdatedAt = timestamppb.New(at)
+}
+
+// TypeName returns the short name used in storage, e.g. "DENTAL"
+func TypeName(coverageType pb.CoverageType) string {
+	return strings.TrimPrefix(coverageType.String(), "COVERAGE_TYPE_")
+}
+
+// ParseType accepts either the short or the full enum name
+func ParseType(name string) (pb.CoverageType, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	value, ok := pb.CoverageType_value["COVERAGE_TYPE_"+strings.TrimPrefix(name, "COVERAGE_TYPE_")]
+	return pb.CoverageType(value), ok
+}
+
+// ReasonName returns the short name used in storage, e.g. "NON_PAYMENT"
+func ReasonName(reason pb.TerminationReason) string {
+	return strings.TrimPrefix(reason.String(), "TERMINATION_REASON_")
+}
+
+// ParseReason accepts either the short or the full enum name
+func ParseReason(name string) pb.TerminationReason {
+	value := pb.TerminationReason_value["TERMINATION_REASON_"+strings.TrimPrefix(strings.ToUpper(name), "TERMINATION_REASON_")]
+	return pb.TerminationReason(value)
+}
+
+// TierName returns the short name used in storage, e.g. "FAMILY"
+func TierName(tier pb.CoverageTier) string {
+	return strings.TrimPrefix(tier.String(), "COVERAGE_TIER_")
+}
+
+// ParseTier accepts either the short or the full enum name
+func ParseTier(name string) pb.CoverageTier {
+	value := pb.CoverageTier_value["COVERAGE_TIER_"+strings.TrimPrefix(strings.ToUpper(name), "COVERAGE_TIER_")]
+	return pb.CoverageTier(value)
+}
+
+// FormatDate renders a coverage date as YYYY-MM-DD
+func FormatDate(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return Day(ts.AsTime()).Format("2006-01-02")
+}
@@ -3,7 +3,10 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/sydney-health-clone/backend/shared/logger"
@@ -12,11 +15,35 @@ import (
 
 type MessageHandler func(ctx context.Context, message kafka.Message) error
 
+// A message's offset is only committed once it has been handled. Handler
+// failures are retried, waiting RetryBackoff and doubling up to
+// MaxRetryBackoff between attempts. A message still failing after
+// MaxAttempts goes to the dead-letter topic, if the consumer has one, and is
+// committed; without one it is retried until it succeeds, holding back the
+// rest of its partition, so handlers must be idempotent.
+const (
+	MaxAttempts     = 5
+	RetryBackoff    = time.Second
+	MaxRetryBackoff = time.Minute
+)
+
+// Headers set on dead-lettered messages, recording where they came from and
+// why they failed, so they can be replayed
+const (
+	HeaderSourceTopic     = "x-source-topic"
+	HeaderSourcePartition = "x-source-partition"
+	HeaderSourceOffset    = "x-source-offset"
+	HeaderConsumerGroup   = "x-consumer-group"
+	HeaderError           = "x-error"
+)
+
 type Consumer struct {
-	reader  *kafka.Reader
-	topic   string
-	groupID string
-	handler MessageHandler
+	reader     *kafka.Reader
+	brokers    []string
+	topic      string
+	groupID    string
+	handler    MessageHandler
+	deadLetter *kafka.Writer
 }
 
 func NewConsumer(brokers []string, topic, groupID string, handler MessageHandler) *Consumer {
@@ -31,12 +58,27 @@ func NewConsumer(brokers []string, topic, groupID string, handler MessageHandler
 	
 	return &Consumer{
 		reader:  reader,
+		brokers: brokers,
 		topic:   topic,
 		groupID: groupID,
 		handler: handler,
 	}
 }
 
+// SetDeadLetterTopic sends messages the handler keeps failing on to topic
+// instead of retrying them forever. An empty topic leaves it unset.
+func (c *Consumer) SetDeadLetterTopic(topic string) {
+	if topic == "" {
+		return
+	}
+	c.deadLetter = &kafka.Writer{
+		Addr:         kafka.TCP(c.brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireAll,
+	}
+}
+
 func (c *Consumer) Start(ctx context.Context) error {
 	logger.Info("Starting Kafka consumer",
 		zap.String("topic", c.topic),
@@ -64,14 +106,9 @@ func (c *Consumer) Start(ctx context.Context) error {
 				continue
 			}
 			
-			// Process message
-			if err := c.handler(ctx, msg); err != nil {
-				logger.Error("Failed to handle message",
-					zap.String("topic", c.topic),
-					zap.String("key", string(msg.Key)),
-					zap.Error(err),
-				)
-				// Continue processing other messages
+			// Process message; it is only left unhandled when ctx ends
+			if !c.handle(ctx, msg) {
+				return ctx.Err()
 			}
 			
 			// Commit message
@@ -85,8 +122,70 @@ func (c *Consumer) Start(ctx context.Context) error {
 	}
 }
 
+// handle runs the handler until it succeeds or the message is
+// dead-lettered, and reports whether the message may be committed. It only
+// gives up when ctx ends.
+func (c *Consumer) handle(ctx context.Context, msg kafka.Message) bool {
+	backoff := RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := c.handler(ctx, msg)
+		if err == nil {
+			return true
+		}
+		
+		logger.Error("Failed to handle message",
+			zap.String("topic", c.topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.String("key", string(msg.Key)),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+		
+		if attempt >= MaxAttempts && c.deadLetter != nil {
+			dlqErr := c.sendDeadLetter(ctx, msg, err)
+			if dlqErr == nil {
+				return true
+			}
+			logger.Error("Failed to dead-letter message",
+				zap.String("topic", c.topic),
+				zap.Int64("offset", msg.Offset),
+				zap.Error(dlqErr),
+			)
+		}
+		
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, MaxRetryBackoff)
+	}
+}
+
+func (c *Consumer) sendDeadLetter(ctx context.Context, msg kafka.Message, cause error) error {
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderSourceTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderSourcePartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderSourceOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderConsumerGroup, Value: []byte(c.groupID)},
+		kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+	)
+	
+	return c.deadLetter.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
 func (c *Consumer) Close() error {
-	return c.reader.Close()
+	err := c.reader.Close()
+	if c.deadLetter != nil {
+		err = errors.Join(err, c.deadLetter.Close())
+	}
+	return err
 }
 
 // Helper functions for common message types
@@ -121,4 +220,20 @@ func UnmarshalMemberUpdate(data []byte) (*MemberUpdate, error) {
 		return nil, fmt.Errorf("failed to unmarshal member update: %w", err)
 	}
 	return &msg, nil
+}
+
+func UnmarshalClaimAdjudication(data []byte) (*ClaimAdjudication, error) {
+	var msg ClaimAdjudication
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal claim adjudication: %w", err)
+	}
+	return &msg, nil
+}
+
+func UnmarshalAuthorizationUpdate(data []byte) (*AuthorizationUpdate, error) {
+	var msg AuthorizationUpdate
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal authorization update: %w", err)
+	}
+	return &msg, nil
 }
\ No newline at end of file
@@ -98,4 +98,71 @@ type MemberUpdate struct {
 	UpdateType   string   `json:"update_type"`
 	UpdatedFields []string `json:"updated_fields"`
 	Timestamp    int64    `json:"timestamp"`
+}
+
+// ClaimAdjudication is published each time a claim is adjudicated, adjusted
+// or reversed. Version increases with each change to the claim; consumers
+// keep the latest and ignore the rest.
+type ClaimAdjudication struct {
+	ClaimID          string `json:"claim_id"`
+	Version          int64  `json:"version"`
+	Action           string `json:"action"` // ADJUDICATED, ADJUSTED or REVERSED
+	MemberID         string `json:"member_id"`
+	PlanID           string `json:"plan_id"`
+	CoverageType     string `json:"coverage_type"`
+	NetworkType      string `json:"network_type"` // IN_NETWORK or OUT_OF_NETWORK
+	ServiceDate      string `json:"service_date"` // YYYY-MM-DD
+	DeductibleCents  int64  `json:"deductible_cents"`
+	CopayCents       int64  `json:"copay_cents"`
+	CoinsuranceCents int64  `json:"coinsurance_cents"`
+	// Services count toward the member's benefit usage limits
+	Services  []ClaimService `json:"services,omitempty"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// ClaimService is one adjudicated service on a claim
+type ClaimService struct {
+	BenefitCode string `json:"benefit_code"`
+	Units       int32  `json:"units"`      // visits or services; 1 when unset
+	PaidCents   int64  `json:"paid_cents"` // what the plan paid
+}
+
+// AuthorizationUpdate is published each time a prior authorization is
+// submitted or changes status. Version increases with each change;
+// consumers keep the latest and ignore the rest.
+type AuthorizationUpdate struct {
+	AuthorizationID     string `json:"authorization_id"`
+	Version             int64  `json:"version"`
+	MemberID            string `json:"member_id"`
+	CoverageType        string `json:"coverage_type"`
+	ProcedureCode       string `json:"procedure_code"`
+	ServicingProviderID string `json:"servicing_provider_id,omitempty"`
+	// SUBMITTED, PENDED, APPROVED, PARTIALLY_APPROVED, DENIED or EXPIRED
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status,omitempty"` // empty on submission
+	// Set once approved or partially approved; dates are YYYY-MM-DD
+	ApprovedUnits     int32  `json:"approved_units,omitempty"`
+	ApprovedStartDate string `json:"approved_start_date,omitempty"`
+	ApprovedEndDate   string `json:"approved_end_date,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	Timestamp         int64  `json:"timestamp"`
+}
+
+// AppointmentUpdate is published each time an appointment is booked,
+// rescheduled or cancelled. Version increases with each change; consumers
+// keep the latest and ignore the rest.
+type AppointmentUpdate struct {
+	AppointmentID string `json:"appointment_id"`
+	Version       int64  `json:"version"`
+	Action        string `json:"action"` // BOOKED, RESCHEDULED or CANCELLED
+	MemberID      string `json:"member_id"`
+	ProviderID    string `json:"provider_id"`
+	LocationID    string `json:"location_id"`
+	// Times are RFC 3339; time_zone is the location's, for showing them
+	StartTime         string `json:"start_time"`
+	EndTime           string `json:"end_time"`
+	TimeZone          string `json:"time_zone"`
+	PreviousStartTime string `json:"previous_start_time,omitempty"` // set on reschedules
+	Reason            string `json:"reason,omitempty"`              // set on cancellations
+	Timestamp         int64  `json:"timestamp"`
 }
\ No newline at end of file
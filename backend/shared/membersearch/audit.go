@@ -0,0 +1,62 @@
+package membersearch
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// AuditEventType identifies member search audit events
+const AuditEventType = "MEMBER_SEARCH"
+
+// AuditEvent records who searched for what and which members they were shown.
+// Each search gets its own entity ID so the pages of one search can't be
+// confused with another's.
+func AuditEvent(agentID string, c Criteria, results []*pb.MemberSearchResult, page *pb.PageResponse) *kafka.AuditEvent {
+	criteria := map[string]interface{}{}
+	if c.FirstName != "" {
+		criteria[FieldFirstName] = c.FirstName
+	}
+	if c.LastName != "" {
+		criteria[FieldLastName] = c.LastName
+	}
+	if len(c.Names) > 0 {
+		criteria[FieldName] = c.Names
+	}
+	if !c.DateOfBirth.IsZero() {
+		criteria[FieldDateOfBirth] = c.DateOfBirth.Format("2006-01-02")
+	}
+	if c.SubscriberID != "" {
+		criteria[FieldSubscriberID] = c.SubscriberID
+	}
+	if c.GroupNumber != "" {
+		criteria[FieldGroupNumber] = c.GroupNumber
+	}
+	if c.Email != "" {
+		criteria[FieldEmail] = c.Email
+	}
+	if c.Phone != "" {
+		criteria[FieldPhone] = c.Phone
+	}
+
+	memberIDs := make([]string, 0, len(results))
+	for _, result := range results {
+		memberIDs = append(memberIDs, result.Member.MemberId)
+	}
+
+	return &kafka.AuditEvent{
+		EventType:  AuditEventType,
+		EntityType: "member_search",
+		EntityID:   uuid.NewString(),
+		UserID:     agentID,
+		EventData: map[string]interface{}{
+			"criteria":    criteria,
+			"fuzzy":       c.Fuzzy,
+			"total_count": page.TotalCount,
+			"member_ids":  memberIDs,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+}
@@ -0,0 +1,438 @@
+// Package membersearch matches member records against the criteria support
+// agents search by, so every member store ranks and pages results the same way.
+//
+// Every criterion given must match. Exact matching compares names, IDs and
+// emails case-insensitively and phone numbers by their digits. Fuzzy matching
+// also accepts:
+//
+//   - names with a typo or two after the first letter, or a prefix of the name
+//   - subscriber IDs containing the given ID, and group numbers starting with it
+//   - emails whose mailbox starts with the given one, at any domain
+//   - phone numbers ending in the given digits (at least four)
+//   - dates of birth with the day and month transposed
+package membersearch
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Fields reported in MemberSearchResult.matched_fields
+const (
+	FieldFirstName    = "first_name"
+	FieldLastName     = "last_name"
+	FieldName         = "name"
+	FieldDateOfBirth  = "date_of_birth"
+	FieldSubscriberID = "subscriber_id"
+	FieldGroupNumber  = "group_number"
+	FieldEmail        = "email"
+	FieldPhone        = "phone"
+)
+
+// How much each criterion counts towards a result's score
+var weights = map[string]float64{
+	FieldSubscriberID: 40,
+	FieldEmail:        30,
+	FieldPhone:        25,
+	FieldDateOfBirth:  20,
+	FieldLastName:     15,
+	FieldFirstName:    10,
+	FieldName:         10,
+	FieldGroupNumber:  5,
+}
+
+// Criteria is a normalized search request
+type Criteria struct {
+	FirstName    string   // lower case
+	LastName     string   // lower case
+	Names        []string // lower case; each must match the first, middle or last name
+	DateOfBirth  time.Time
+	SubscriberID string // upper case
+	GroupNumber  string // upper case
+	Email        string // lower case
+	Phone        string // digits only, without a leading country code
+	Fuzzy        bool
+}
+
+var (
+	// ErrNoCriteria is returned for a request that gives nothing to search by
+	ErrNoCriteria = errors.New("at least one search criterion is required")
+	// ErrInvalidPageToken is returned for a page token Page did not issue
+	ErrInvalidPageToken = errors.New("invalid page token")
+	// ErrTooBroad is returned by stores that cap the candidates they score,
+	// when a search reaches the cap and its results would be incomplete
+	ErrTooBroad = errors.New("search matches too many members; add criteria to narrow it")
+)
+
+var subscriberIDPattern = regexp.MustCompile(`^[A-Za-z]*[0-9][A-Za-z0-9-]*$`)
+
+// FromRequest normalizes a search request. The free-text query is read as an
+// email if it contains @, a phone number if it is mostly digits and has no
+// letters, a subscriber ID if it looks like one ("SUB123456-01", "A1234567"),
+// and otherwise as a name: "Doe, Jane" or "Jane Doe".
+func FromRequest(req *pb.SearchMembersRequest) (Criteria, error) {
+	c := Criteria{
+		FirstName:    foldName(req.FirstName),
+		LastName:     foldName(req.LastName),
+		SubscriberID: strings.ToUpper(strings.TrimSpace(req.SubscriberId)),
+		GroupNumber:  strings.ToUpper(strings.TrimSpace(req.GroupNumber)),
+		Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+		Phone:        Digits(req.Phone),
+		Fuzzy:        req.Fuzzy,
+	}
+	if req.DateOfBirth != nil {
+		c.DateOfBirth = coverage.Day(req.DateOfBirth.AsTime())
+	}
+
+	query := strings.TrimSpace(req.Query)
+	digits := Digits(query)
+	switch {
+	case query == "":
+	case strings.Contains(query, "@"):
+		if c.Email == "" {
+			c.Email = strings.ToLower(query)
+		}
+	case len(digits) >= 7 && len(digits)*2 > len(query) && !strings.ContainsFunc(query, unicode.IsLetter):
+		if c.Phone == "" {
+			c.Phone = digits
+		}
+	case subscriberIDPattern.MatchString(query):
+		if c.SubscriberID == "" {
+			c.SubscriberID = strings.ToUpper(query)
+		}
+	default:
+		if last, first, ok := strings.Cut(query, ","); ok {
+			c.Names = append(c.Names, nameTokens(last)...)
+			c.Names = append(c.Names, nameTokens(first)...)
+		} else {
+			c.Names = nameTokens(query)
+		}
+	}
+
+	if c.Empty() {
+		return c, ErrNoCriteria
+	}
+	return c, nil
+}
+
+// Empty reports whether there is nothing to search by
+func (c Criteria) Empty() bool {
+	return c.FirstName == "" && c.LastName == "" && len(c.Names) == 0 && c.DateOfBirth.IsZero() &&
+		c.SubscriberID == "" && c.GroupNumber == "" && c.Email == "" && c.Phone == ""
+}
+
+// Fields lists the criteria given, for audit records
+func (c Criteria) Fields() []string {
+	var fields []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{FieldFirstName, c.FirstName != ""},
+		{FieldLastName, c.LastName != ""},
+		{FieldName, len(c.Names) > 0},
+		{FieldDateOfBirth, !c.DateOfBirth.IsZero()},
+		{FieldSubscriberID, c.SubscriberID != ""},
+		{FieldGroupNumber, c.GroupNumber != ""},
+		{FieldEmail, c.Email != ""},
+		{FieldPhone, c.Phone != ""},
+	} {
+		if f.set {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
+
+// SwappedDateOfBirth is the date of birth with day and month transposed, or
+// the zero time when they can't be
+func (c Criteria) SwappedDateOfBirth() time.Time {
+	if c.DateOfBirth.IsZero() || c.DateOfBirth.Day() > 12 || c.DateOfBirth.Day() == int(c.DateOfBirth.Month()) {
+		return time.Time{}
+	}
+	return time.Date(c.DateOfBirth.Year(), time.Month(c.DateOfBirth.Day()), int(c.DateOfBirth.Month()), 0, 0, 0, 0, time.UTC)
+}
+
+// Match scores a member against the criteria. ok is false unless every
+// criterion matched.
+func Match(member *pb.Member, c Criteria) (result *pb.MemberSearchResult, ok bool) {
+	var total, score float64
+	var matched []string
+
+	check := func(field string, similarity float64) bool {
+		if similarity <= 0 {
+			return false
+		}
+		total += weights[field]
+		score += weights[field] * similarity
+		matched = append(matched, field)
+		return true
+	}
+
+	first, middle, last := foldName(member.FirstName), foldName(member.MiddleName), foldName(member.LastName)
+
+	if c.FirstName != "" && !check(FieldFirstName, c.name(first, c.FirstName)) {
+		return nil, false
+	}
+	if c.LastName != "" && !check(FieldLastName, c.name(last, c.LastName)) {
+		return nil, false
+	}
+	if len(c.Names) > 0 {
+		var sum float64
+		for _, token := range c.Names {
+			best := max(c.name(first, token), c.name(middle, token), c.name(last, token))
+			if best <= 0 {
+				return nil, false
+			}
+			sum += best
+		}
+		check(FieldName, sum/float64(len(c.Names)))
+	}
+	if !c.DateOfBirth.IsZero() && !check(FieldDateOfBirth, c.dateOfBirth(member)) {
+		return nil, false
+	}
+	if c.SubscriberID != "" && !check(FieldSubscriberID, c.subscriberID(member.SubscriberId)) {
+		return nil, false
+	}
+	if c.GroupNumber != "" && !check(FieldGroupNumber, c.groupNumber(member.GroupNumber)) {
+		return nil, false
+	}
+	if c.Email != "" && !check(FieldEmail, c.email(member.Email)) {
+		return nil, false
+	}
+	if c.Phone != "" && !check(FieldPhone, c.phone(member.Phone)) {
+		return nil, false
+	}
+
+	return &pb.MemberSearchResult{
+		Member:        member,
+		Score:         score / total,
+		MatchedFields: matched,
+	}, true
+}
+
+func (c Criteria) name(name, want string) float64 {
+	switch {
+	case name == "":
+		return 0
+	case name == want:
+		return 1
+	case !c.Fuzzy:
+		return 0
+	case len(want) >= 2 && strings.HasPrefix(name, want):
+		return 0.8
+	case FirstLetter(name) != FirstLetter(want):
+		// Agents hear and read names wrong, but rarely the first letter
+		return 0
+	}
+
+	distance := editDistance(name, want)
+	allowed := 1
+	if len(want) > 5 {
+		allowed = 2
+	}
+	if distance > allowed {
+		return 0
+	}
+	return 1 - float64(distance)/float64(max(len(name), len(want)))
+}
+
+func (c Criteria) dateOfBirth(member *pb.Member) float64 {
+	if member.DateOfBirth == nil {
+		return 0
+	}
+	dob := coverage.Day(member.DateOfBirth.AsTime())
+	switch {
+	case dob.Equal(c.DateOfBirth):
+		return 1
+	case c.Fuzzy && dob.Equal(c.SwappedDateOfBirth()):
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+func (c Criteria) subscriberID(id string) float64 {
+	id = strings.ToUpper(id)
+	switch {
+	case id == "":
+		return 0
+	case id == c.SubscriberID:
+		return 1
+	case c.Fuzzy && strings.Contains(id, c.SubscriberID):
+		return 0.7
+	default:
+		return 0
+	}
+}
+
+func (c Criteria) groupNumber(group string) float64 {
+	group = strings.ToUpper(group)
+	switch {
+	case group == "":
+		return 0
+	case group == c.GroupNumber:
+		return 1
+	case c.Fuzzy && strings.HasPrefix(group, c.GroupNumber):
+		return 0.7
+	default:
+		return 0
+	}
+}
+
+func (c Criteria) email(email string) float64 {
+	email = strings.ToLower(email)
+	switch {
+	case email == "":
+		return 0
+	case email == c.Email:
+		return 1
+	case c.Fuzzy && strings.HasPrefix(Mailbox(email), Mailbox(c.Email)):
+		return 0.6
+	default:
+		return 0
+	}
+}
+
+func (c Criteria) phone(phone string) float64 {
+	phone = Digits(phone)
+	switch {
+	case phone == "":
+		return 0
+	case phone == c.Phone:
+		return 1
+	case c.Fuzzy && len(c.Phone) >= 4 && strings.HasSuffix(phone, c.Phone):
+		return 0.6
+	default:
+		return 0
+	}
+}
+
+// Sort orders results best first, then by name
+func Sort(results []*pb.MemberSearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if an, bn := foldName(a.Member.LastName), foldName(b.Member.LastName); an != bn {
+			return an < bn
+		}
+		if an, bn := foldName(a.Member.FirstName), foldName(b.Member.FirstName); an != bn {
+			return an < bn
+		}
+		return a.Member.MemberId < b.Member.MemberId
+	})
+}
+
+// Page returns the page of sorted results the request asks for. Page tokens
+// are the offset of the page's first result.
+func Page(results []*pb.MemberSearchResult, page *pb.PageRequest) ([]*pb.MemberSearchResult, *pb.PageResponse, error) {
+	size := DefaultPageSize
+	offset := 0
+	if page != nil {
+		if page.PageSize > 0 {
+			size = min(int(page.PageSize), MaxPageSize)
+		}
+		if page.PageToken != "" {
+			n, err := strconv.Atoi(page.PageToken)
+			if err != nil || n < 0 {
+				return nil, nil, ErrInvalidPageToken
+			}
+			offset = n
+		}
+	}
+
+	resp := &pb.PageResponse{TotalCount: int32(len(results))}
+	if offset >= len(results) {
+		return nil, resp, nil
+	}
+	end := min(offset+size, len(results))
+	if end < len(results) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return results[offset:end], resp, nil
+}
+
+// Digits strips everything but digits from a phone number, and the country
+// code from a full North American one
+func Digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if len(digits) == 11 && digits[0] == '1' {
+		return digits[1:]
+	}
+	return digits
+}
+
+// FirstLetter is the first character of a folded name, which fuzzy matching
+// requires to be right
+func FirstLetter(name string) string {
+	_, size := utf8.DecodeRuneInString(name)
+	return name[:size]
+}
+
+// Mailbox is the part of an email address before the @
+func Mailbox(email string) string {
+	mailbox, _, _ := strings.Cut(email, "@")
+	return mailbox
+}
+
+func foldName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func nameTokens(s string) []string {
+	var tokens []string
+	for _, token := range strings.Fields(s) {
+		if token = strings.Trim(foldName(token), ".,"); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// editDistance counts the insertions, deletions, substitutions and adjacent
+// transpositions that turn a into b
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/sydney-health-clone/backend/shared/npi"
 	pb "github.com/sydney-health-clone/backend/shared/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -65,6 +66,7 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 	benefits := []*pb.Benefit{
 		{
 			BenefitId:    "BEN001",
+			BenefitCode:  "PCP",
 			Name:         "Primary Care Visit",
 			Description:  "Visits to your primary care physician for routine care",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_MEDICAL,
@@ -80,6 +82,7 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 		},
 		{
 			BenefitId:    "BEN002",
+			BenefitCode:  "SPECIALIST",
 			Name:         "Specialist Visit",
 			Description:  "Visits to medical specialists",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_MEDICAL,
@@ -95,6 +98,7 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 		},
 		{
 			BenefitId:    "BEN003",
+			BenefitCode:  "ER",
 			Name:         "Emergency Room",
 			Description:  "Emergency room visits",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_MEDICAL,
@@ -103,9 +107,11 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 				Copay: &pb.Money{Cents: 15000, Currency: "USD"},
 				CoinsurancePercentage: 20,
 			},
+			Limitations:  []string{"Copay waived if admitted"},
 		},
 		{
 			BenefitId:    "BEN004",
+			BenefitCode:  "PREVENTIVE",
 			Name:         "Preventive Care",
 			Description:  "Annual checkups, immunizations, and screenings",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_MEDICAL,
@@ -114,9 +120,15 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 				Copay: &pb.Money{Cents: 0, Currency: "USD"},
 				CoinsurancePercentage: 0,
 			},
+			Limitations:  []string{"One routine physical per plan year"},
+			Limits: []*pb.BenefitLimit{
+				{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 1, Window: pb.LimitWindow_LIMIT_WINDOW_PLAN_YEAR, Description: "One routine physical per plan year"},
+			},
+			Exclusions:   []string{"Diagnostic services during a preventive visit are billed separately"},
 		},
 		{
 			BenefitId:    "BEN005",
+			BenefitCode:  "ROUTINE_EXAM",
 			Name:         "Routine Dental Exam",
 			Description:  "Dental cleanings and exams (twice per year)",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_DENTAL,
@@ -125,9 +137,15 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 				Copay: &pb.Money{Cents: 0, Currency: "USD"},
 				CoinsurancePercentage: 0,
 			},
+			Limitations:  []string{"Two cleanings and exams per plan year"},
+			Limits: []*pb.BenefitLimit{
+				{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 2, Window: pb.LimitWindow_LIMIT_WINDOW_PLAN_YEAR, Description: "Two cleanings and exams per plan year"},
+				{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 1, Window: pb.LimitWindow_LIMIT_WINDOW_ROLLING, WindowMonths: 6, Description: "One cleaning every 6 months"},
+			},
 		},
 		{
 			BenefitId:    "BEN006",
+			BenefitCode:  "BASIC",
 			Name:         "Basic Dental Services",
 			Description:  "Fillings, extractions, and basic procedures",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_DENTAL,
@@ -135,9 +153,11 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 			InNetwork: &pb.CoverageLevel{
 				CoinsurancePercentage: 20,
 			},
+			Limitations:  []string{"6-month waiting period for new enrollees"},
 		},
 		{
 			BenefitId:    "BEN007",
+			BenefitCode:  "EYE_EXAM",
 			Name:         "Eye Exam",
 			Description:  "Annual comprehensive eye examination",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_VISION,
@@ -145,9 +165,14 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 			InNetwork: &pb.CoverageLevel{
 				Copay: &pb.Money{Cents: 1000, Currency: "USD"},
 			},
+			Limitations:  []string{"One exam every 12 months"},
+			Limits: []*pb.BenefitLimit{
+				{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 1, Window: pb.LimitWindow_LIMIT_WINDOW_ROLLING, WindowMonths: 12, Description: "One exam every 12 months"},
+			},
 		},
 		{
 			BenefitId:    "BEN008",
+			BenefitCode:  "EYEWEAR",
 			Name:         "Prescription Eyewear",
 			Description:  "Eyeglasses or contact lenses",
 			CoverageType: pb.CoverageType_COVERAGE_TYPE_VISION,
@@ -155,6 +180,11 @@ func (g *MockDataGenerator) GenerateBenefits() []*pb.Benefit {
 			InNetwork: &pb.CoverageLevel{
 				AnnualLimit: &pb.Money{Cents: 15000, Currency: "USD"},
 			},
+			Limitations:  []string{"Frames or contacts once every 24 months"},
+			Limits: []*pb.BenefitLimit{
+				{Kind: pb.LimitKind_LIMIT_KIND_COUNT, Count: 1, Window: pb.LimitWindow_LIMIT_WINDOW_ROLLING, WindowMonths: 24, Description: "Frames or contacts once every 24 months"},
+			},
+			Exclusions:   []string{"Non-prescription sunglasses"},
 		},
 	}
 	
@@ -169,9 +199,15 @@ func (g *MockDataGenerator) GenerateProviders(count int) []*pb.Provider {
 		"Dr. Jennifer Martinez", "Dr. David Anderson", "Dr. Lisa Thompson", "Dr. James Lee",
 	}
 	
-	specialties := []string{
-		"Primary Care", "Cardiology", "Dermatology", "Orthopedics",
-		"Pediatrics", "OB/GYN", "Psychiatry", "Neurology",
+	// NUCC taxonomy codes with their display names
+	specialties := []struct {
+		code string
+		name string
+	}{
+		{"207Q00000X", "Family Medicine"}, {"207R00000X", "Internal Medicine"},
+		{"207RC0000X", "Cardiology"}, {"207N00000X", "Dermatology"},
+		{"207X00000X", "Orthopedic Surgery"}, {"208000000X", "Pediatrics"},
+		{"207V00000X", "OB/GYN"}, {"2084P0800X", "Psychiatry"}, {"2084N0400X", "Neurology"},
 	}
 	
 	practiceNames := []string{
@@ -187,11 +223,14 @@ func (g *MockDataGenerator) GenerateProviders(count int) []*pb.Provider {
 		
 		provider := &pb.Provider{
 			ProviderId:   fmt.Sprintf("PRV%06d", i+1),
-			Npi:          fmt.Sprintf("%010d", g.rand.Intn(9999999999)),
+			Npi:          g.randomNPI(),
 			FirstName:    name,
 			LastName:     "",
 			PracticeName: practiceNames[g.rand.Intn(len(practiceNames))],
-			Specialties:  []string{specialty},
+			Specialties:  []string{specialty.name},
+			Taxonomies: []*pb.ProviderTaxonomy{
+				{Code: specialty.code, DisplayName: specialty.name, Primary: true},
+			},
 			Locations: []*pb.ProviderLocation{
 				g.generateProviderLocation(),
 			},
@@ -203,32 +242,74 @@ func (g *MockDataGenerator) GenerateProviders(count int) []*pb.Provider {
 			ReviewCount:          int32(g.rand.Intn(200)),
 		}
 		
+		// Ratings come from reviews, so providers without any have none
+		if provider.ReviewCount == 0 {
+			provider.Rating = 0
+		}
+		
 		providers[i] = provider
 	}
 	
 	return providers
 }
 
+// providerSites are ZIP codes in the cities mock providers practice in, with
+// their centroids. Locations are scattered up to about half a mile from them.
+var providerSites = []struct {
+	city      string
+	zip       string
+	latitude  float64
+	longitude float64
+}{
+	{"San Francisco", "94103", 37.7725, -122.4109},
+	{"San Francisco", "94105", 37.7897, -122.3942},
+	{"San Francisco", "94110", 37.7502, -122.4153},
+	{"San Francisco", "94115", 37.7858, -122.4371},
+	{"San Francisco", "94122", 37.7590, -122.4848},
+	{"Oakland", "94607", 37.8070, -122.2875},
+	{"Oakland", "94610", 37.8122, -122.2409},
+	{"Oakland", "94612", 37.8085, -122.2687},
+	{"San Jose", "95110", 37.3446, -121.9069},
+	{"San Jose", "95125", 37.2958, -121.8940},
+	{"San Jose", "95128", 37.3164, -121.9357},
+	{"Berkeley", "94704", 37.8662, -122.2570},
+	{"Berkeley", "94710", 37.8678, -122.3012},
+	{"Palo Alto", "94301", 37.4442, -122.1502},
+	{"Palo Alto", "94306", 37.4173, -122.1273},
+}
+
 func (g *MockDataGenerator) generateProviderLocation() *pb.ProviderLocation {
 	streets := []string{"Market St", "Mission St", "Broadway", "Main St", "First Ave"}
-	cities := []string{"San Francisco", "Oakland", "San Jose", "Berkeley", "Palo Alto"}
+	site := providerSites[g.rand.Intn(len(providerSites))]
 	
 	return &pb.ProviderLocation{
 		LocationId: fmt.Sprintf("LOC%06d", g.rand.Intn(999999)),
 		Address: &pb.Address{
 			Street1: fmt.Sprintf("%d %s", g.rand.Intn(9999)+1, streets[g.rand.Intn(len(streets))]),
-			City:    cities[g.rand.Intn(len(cities))],
+			City:    site.city,
 			State:   "CA",
-			ZipCode: fmt.Sprintf("94%03d", g.rand.Intn(999)),
+			ZipCode: site.zip,
 			Country: "USA",
 		},
-		Phone:         g.randomPhone(),
-		Fax:           g.randomPhone(),
-		OfficeHours:   []string{"Mon-Fri: 9:00 AM - 5:00 PM", "Sat: 9:00 AM - 1:00 PM"},
-		DistanceMiles: float64(g.rand.Intn(50)) / 10.0,
+		Phone:       g.randomPhone(),
+		Fax:         g.randomPhone(),
+		OfficeHours: []string{"Mon-Fri: 9:00 AM - 5:00 PM", "Sat: 9:00 AM - 1:00 PM"},
+		Schedule:    mockSchedule(),
+		Latitude:    site.latitude + (g.rand.Float64()-0.5)*0.014,
+		Longitude:   site.longitude + (g.rand.Float64()-0.5)*0.018,
 	}
 }
 
+// mockSchedule offers 30-minute appointments in the hours office_hours shows
+func mockSchedule() *pb.LocationSchedule {
+	schedule := &pb.LocationSchedule{TimeZone: "America/Los_Angeles", SlotMinutes: 30}
+	for day := int32(1); day <= 5; day++ {
+		schedule.Hours = append(schedule.Hours, &pb.OfficeHours{DayOfWeek: day, Opens: "09:00", Closes: "17:00"})
+	}
+	schedule.Hours = append(schedule.Hours, &pb.OfficeHours{DayOfWeek: 6, Opens: "09:00", Closes: "13:00"})
+	return schedule
+}
+
 // Claims Data Generators
 
 func (g *MockDataGenerator) GenerateClaims(memberID string, count int) []*pb.Claim {
@@ -246,7 +327,7 @@ func (g *MockDataGenerator) GenerateClaims(memberID string, count int) []*pb.Cla
 	claims := make([]*pb.Claim, count)
 	
 	for i := 0; i < count; i++ {
-		serviceDate := g.randomDateRecent(180) // Last 6 months
+		serviceDate := g.randomDateRecent(180).AsTime() // Last 6 months
 		processedDate := serviceDate.Add(time.Duration(g.rand.Intn(30)) * 24 * time.Hour)
 		
 		totalCharged := int64(g.rand.Intn(50000) + 5000) // $50 to $500
@@ -379,4 +460,11 @@ func (g *MockDataGenerator) randomPhone() string {
 		g.rand.Intn(899)+100,
 		g.rand.Intn(899)+100,
 		g.rand.Intn(9999))
+}
+
+// randomNPI returns an NPI with a valid check digit
+func (g *MockDataGenerator) randomNPI() string {
+	base := fmt.Sprintf("%d%08d", g.rand.Intn(2)+1, g.rand.Intn(100000000))
+	check, _ := npi.CheckDigit(base)
+	return base + string(check)
 }
\ No newline at end of file
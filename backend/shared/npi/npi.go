@@ -0,0 +1,62 @@
+// Package npi validates National Provider Identifiers.
+//
+// An NPI is nine digits and a check digit. The check digit is the Luhn
+// check digit of the nine digits prefixed with 80840, the card issuer prefix
+// assigned to US health identifiers, which is the same as adding 24 to the
+// Luhn sum of the nine digits alone.
+package npi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalid is returned, wrapped with the problem, for NPIs that fail
+// validation
+var ErrInvalid = errors.New("invalid NPI")
+
+// prefixSum is the Luhn sum contributed by the 80840 prefix
+const prefixSum = 24
+
+// Validate checks an NPI is ten digits starting with 1 or 2, the only first
+// digits issued, with a correct check digit
+func Validate(npi string) error {
+	if len(npi) != 10 || strings.Trim(npi, "0123456789") != "" {
+		return fmt.Errorf("%w: %q is not 10 digits", ErrInvalid, npi)
+	}
+	if npi[0] != '1' && npi[0] != '2' {
+		return fmt.Errorf("%w: %q does not start with 1 or 2", ErrInvalid, npi)
+	}
+	if check, _ := CheckDigit(npi[:9]); npi[9] != check {
+		return fmt.Errorf("%w: %q has the wrong check digit", ErrInvalid, npi)
+	}
+	return nil
+}
+
+// Valid reports whether the NPI passes Validate
+func Valid(npi string) bool {
+	return Validate(npi) == nil
+}
+
+// CheckDigit returns the check digit for the first nine digits of an NPI
+func CheckDigit(base string) (byte, error) {
+	if len(base) != 9 || strings.Trim(base, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not 9 digits", ErrInvalid, base)
+	}
+
+	sum := prefixSum
+	for i := 0; i < 9; i++ {
+		d := int(base[8-i] - '0')
+		// Digits are doubled from the rightmost, which the check digit
+		// will follow
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
@@ -0,0 +1,356 @@
+// Package preferences validates member communication preferences and answers
+// whether a notification may be sent, for the member service that stores
+// them and the services that notify members.
+package preferences
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultLanguage is used until the member picks one
+const DefaultLanguage = "en"
+
+// Categories lists every notification category in display order
+var Categories = []pb.NotificationCategory{
+	pb.NotificationCategory_NOTIFICATION_CATEGORY_CLAIMS,
+	pb.NotificationCategory_NOTIFICATION_CATEGORY_EOB,
+	pb.NotificationCategory_NOTIFICATION_CATEGORY_MESSAGES,
+	pb.NotificationCategory_NOTIFICATION_CATEGORY_PLAN_DOCUMENTS,
+	pb.NotificationCategory_NOTIFICATION_CATEGORY_MARKETING,
+}
+
+// The channels each category can be sent through, and the defaults for a
+// member who has not chosen
+var (
+	allowed = map[pb.NotificationCategory][]pb.NotificationChannel{
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_CLAIMS:         {email, sms, push},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_EOB:            {mail, email},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_MESSAGES:       {email, sms, push},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_PLAN_DOCUMENTS: {mail, email},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_MARKETING:      {mail, email, sms, push},
+	}
+	defaults = map[pb.NotificationCategory][]pb.NotificationChannel{
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_CLAIMS:         {email},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_EOB:            {mail},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_MESSAGES:       {email, push},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_PLAN_DOCUMENTS: {mail},
+		pb.NotificationCategory_NOTIFICATION_CATEGORY_MARKETING:      {},
+	}
+)
+
+const (
+	mail  = pb.NotificationChannel_NOTIFICATION_CHANNEL_MAIL
+	email = pb.NotificationChannel_NOTIFICATION_CHANNEL_EMAIL
+	sms   = pb.NotificationChannel_NOTIFICATION_CHANNEL_SMS
+	push  = pb.NotificationChannel_NOTIFICATION_CHANNEL_PUSH
+)
+
+var (
+	ErrEmailRequired = errors.New("an email address is required for email notifications and paperless delivery")
+	ErrPhoneRequired = errors.New("a phone number is required for SMS notifications")
+	// ErrPaperlessRequired is returned for updates that leave paperless
+	// consent out rather than risk revoking it
+	ErrPaperlessRequired = errors.New("paperless consent is required; send consented false to revoke it")
+)
+
+var languagePattern = regexp.MustCompile(`^([A-Za-z]{2,3})(?:[-_]([A-Za-z]{2}|[0-9]{3}))?$`)
+
+// Defaults returns the preferences of a member who has not set any
+func Defaults(memberID string) *pb.MemberPreferences {
+	prefs := &pb.MemberPreferences{
+		MemberId:   memberID,
+		QuietHours: &pb.QuietHours{},
+		Language:   DefaultLanguage,
+		Paperless:  &pb.PaperlessConsent{},
+	}
+	for _, category := range Categories {
+		prefs.Channels = append(prefs.Channels, &pb.ChannelPreference{
+			Category: category,
+			Channels: append([]pb.NotificationChannel(nil), defaults[category]...),
+		})
+	}
+	return prefs
+}
+
+// Update validates the requested preferences and returns them as they
+// should be stored: one entry per category with categories left out at their
+// defaults, and paperless consent stamped when it changes. existing may be nil.
+// Paperless consent must be stated every time, so that leaving it out never
+// revokes it.
+//
+// EOBs and plan documents go by mail unless the member has consented to
+// paperless delivery, and by email instead of mail once they have.
+func Update(existing, requested *pb.MemberPreferences, now time.Time) (*pb.MemberPreferences, error) {
+	if existing == nil {
+		existing = Defaults(requested.MemberId)
+	}
+
+	prefs := &pb.MemberPreferences{
+		MemberId:  existing.MemberId,
+		Language:  DefaultLanguage,
+		Paperless: proto.Clone(existingPaperless(existing)).(*pb.PaperlessConsent),
+		UpdatedAt: timestamppb.New(now),
+	}
+
+	if requested.Language != "" {
+		language, err := canonicalLanguage(requested.Language)
+		if err != nil {
+			return nil, err
+		}
+		prefs.Language = language
+	}
+
+	quiet, err := quietHours(requested.QuietHours)
+	if err != nil {
+		return nil, err
+	}
+	prefs.QuietHours = quiet
+
+	if requested.Paperless == nil {
+		return nil, ErrPaperlessRequired
+	}
+	consented := requested.Paperless.Consented
+	switch {
+	case consented && !prefs.Paperless.Consented:
+		prefs.Paperless.Consented = true
+		prefs.Paperless.ConsentedAt = timestamppb.New(now)
+		prefs.Paperless.RevokedAt = nil
+	case !consented && prefs.Paperless.Consented:
+		prefs.Paperless.Consented = false
+		prefs.Paperless.RevokedAt = timestamppb.New(now)
+	}
+
+	chosen := make(map[pb.NotificationCategory][]pb.NotificationChannel)
+	for _, pref := range requested.Channels {
+		if _, ok := allowed[pref.Category]; !ok {
+			return nil, fmt.Errorf("unknown notification category: %v", pref.Category)
+		}
+		if _, dup := chosen[pref.Category]; dup {
+			return nil, fmt.Errorf("%s is listed more than once", CategoryName(pref.Category))
+		}
+		for _, channel := range pref.Channels {
+			if !contains(allowed[pref.Category], channel) {
+				return nil, fmt.Errorf("%s notifications can't be sent by %s", CategoryName(pref.Category), ChannelName(channel))
+			}
+		}
+		chosen[pref.Category] = pref.Channels
+	}
+
+	for _, category := range Categories {
+		channels, ok := chosen[category]
+		if !ok {
+			channels = defaults[category]
+		}
+		channels = dedupe(channels)
+
+		if isDocument(category) {
+			if prefs.Paperless.Consented {
+				channels = add(remove(channels, mail), email)
+			} else {
+				channels = add(channels, mail)
+			}
+		}
+
+		prefs.Channels = append(prefs.Channels, &pb.ChannelPreference{
+			Category: category,
+			Channels: channels,
+		})
+	}
+
+	return prefs, nil
+}
+
+// CheckContact reports whether the member has the contact details the
+// preferences rely on
+func CheckContact(prefs *pb.MemberPreferences, member *pb.Member) error {
+	needEmail := prefs.Paperless.GetConsented()
+	needPhone := false
+	for _, pref := range prefs.Channels {
+		needEmail = needEmail || contains(pref.Channels, email)
+		needPhone = needPhone || contains(pref.Channels, sms)
+	}
+
+	if needEmail && strings.TrimSpace(member.Email) == "" {
+		return ErrEmailRequired
+	}
+	if needPhone && strings.TrimSpace(member.Phone) == "" {
+		return ErrPhoneRequired
+	}
+	return nil
+}
+
+// Channels returns the channels the member has opted in to for the category
+func Channels(prefs *pb.MemberPreferences, category pb.NotificationCategory) []pb.NotificationChannel {
+	for _, pref := range prefs.GetChannels() {
+		if pref.Category == category {
+			return pref.Channels
+		}
+	}
+	return defaults[category]
+}
+
+// Allows reports whether a notification may be sent to the member through
+// the channel at the given time. SMS and push notifications that fall in
+// quiet hours should be held until they end.
+func Allows(prefs *pb.MemberPreferences, category pb.NotificationCategory, channel pb.NotificationChannel, at time.Time) bool {
+	if !contains(Channels(prefs, category), channel) {
+		return false
+	}
+	if channel == sms || channel == push {
+		return !InQuietHours(prefs.GetQuietHours(), at)
+	}
+	return true
+}
+
+// InQuietHours reports whether the time falls in the member's quiet hours
+func InQuietHours(quiet *pb.QuietHours, at time.Time) bool {
+	if !quiet.GetEnabled() {
+		return false
+	}
+	loc, err := time.LoadLocation(quiet.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, errStart := time.Parse("15:04", quiet.Start)
+	end, errEnd := time.Parse("15:04", quiet.End)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	local := at.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	// The window crosses midnight
+	return minute >= from || minute < to
+}
+
+// CategoryName returns the short name used in storage, e.g. "EOB"
+func CategoryName(category pb.NotificationCategory) string {
+	return strings.TrimPrefix(category.String(), "NOTIFICATION_CATEGORY_")
+}
+
+// ParseCategory accepts either the short or the full enum name
+func ParseCategory(name string) (pb.NotificationCategory, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	value, ok := pb.NotificationCategory_value["NOTIFICATION_CATEGORY_"+strings.TrimPrefix(name, "NOTIFICATION_CATEGORY_")]
+	return pb.NotificationCategory(value), ok && value != 0
+}
+
+// ChannelName returns the short name used in storage, e.g. "SMS"
+func ChannelName(channel pb.NotificationChannel) string {
+	return strings.TrimPrefix(channel.String(), "NOTIFICATION_CHANNEL_")
+}
+
+// ParseChannel accepts either the short or the full enum name
+func ParseChannel(name string) (pb.NotificationChannel, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	value, ok := pb.NotificationChannel_value["NOTIFICATION_CHANNEL_"+strings.TrimPrefix(name, "NOTIFICATION_CHANNEL_")]
+	return pb.NotificationChannel(value), ok && value != 0
+}
+
+func quietHours(quiet *pb.QuietHours) (*pb.QuietHours, error) {
+	if !quiet.GetEnabled() {
+		return &pb.QuietHours{}, nil
+	}
+
+	start, err := time.Parse("15:04", quiet.Start)
+	if err != nil {
+		return nil, errors.New("quiet hours start must be HH:MM")
+	}
+	end, err := time.Parse("15:04", quiet.End)
+	if err != nil {
+		return nil, errors.New("quiet hours end must be HH:MM")
+	}
+	if start.Equal(end) {
+		return nil, errors.New("quiet hours must start and end at different times")
+	}
+	if quiet.TimeZone == "" {
+		return nil, errors.New("quiet hours need a time zone")
+	}
+	if _, err := time.LoadLocation(quiet.TimeZone); err != nil {
+		return nil, fmt.Errorf("unknown time zone: %s", quiet.TimeZone)
+	}
+
+	return &pb.QuietHours{
+		Enabled:  true,
+		Start:    start.Format("15:04"),
+		End:      end.Format("15:04"),
+		TimeZone: quiet.TimeZone,
+	}, nil
+}
+
+// canonicalLanguage accepts a language with an optional region, e.g. es_us,
+// and returns it as es-US
+func canonicalLanguage(tag string) (string, error) {
+	m := languagePattern.FindStringSubmatch(strings.TrimSpace(tag))
+	if m == nil {
+		return "", fmt.Errorf("language must be a tag such as en or es-US, not %q", tag)
+	}
+	if m[2] == "" {
+		return strings.ToLower(m[1]), nil
+	}
+	return strings.ToLower(m[1]) + "-" + strings.ToUpper(m[2]), nil
+}
+
+func existingPaperless(prefs *pb.MemberPreferences) *pb.PaperlessConsent {
+	if prefs.Paperless == nil {
+		return &pb.PaperlessConsent{}
+	}
+	return prefs.Paperless
+}
+
+func isDocument(category pb.NotificationCategory) bool {
+	return category == pb.NotificationCategory_NOTIFICATION_CATEGORY_EOB ||
+		category == pb.NotificationCategory_NOTIFICATION_CATEGORY_PLAN_DOCUMENTS
+}
+
+func contains(channels []pb.NotificationChannel, channel pb.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupe copies the channels without repeats, in enum order
+func dedupe(channels []pb.NotificationChannel) []pb.NotificationChannel {
+	out := []pb.NotificationChannel{}
+	for _, c := range channels {
+		if !contains(out, c) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func add(channels []pb.NotificationChannel, channel pb.NotificationChannel) []pb.NotificationChannel {
+	if contains(channels, channel) {
+		return channels
+	}
+	return dedupe(append(channels, channel))
+}
+
+func remove(channels []pb.NotificationChannel, channel pb.NotificationChannel) []pb.NotificationChannel {
+	out := []pb.NotificationChannel{}
+	for _, c := range channels {
+		if c != channel {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+// Package procedure validates the codes that identify medical services.
+package procedure
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	cptCode   = regexp.MustCompile(`^[0-9]{4}[0-9FTU]$`)
+	hcpcsCode = regexp.MustCompile(`^[A-V][0-9]{4}$`)
+)
+
+// Normalize upper-cases a CPT or HCPCS Level II code and checks its form:
+// five digits, four digits and a category II, III or PLA letter, or a letter
+// and four digits. CDT dental codes are HCPCS codes starting with D.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !cptCode.MatchString(code) && !hcpcsCode.MatchString(code) {
+		return "", fmt.Errorf("%q is not a CPT or HCPCS code", code)
+	}
+	return code, nil
+}
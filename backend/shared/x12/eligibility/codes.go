@@ -0,0 +1,197 @@
+package eligibility
+
+import (
+	"strconv"
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// Service type codes (EQ-01, EB-03) we answer for
+const (
+	ServiceHealthPlan = "30"
+	ServiceMedical    = "1"
+	ServiceEmergency  = "86"
+	ServicePharmacy   = "88"
+	ServiceOffice     = "98"
+	ServiceDental     = "35"
+	ServiceVision     = "AL"
+)
+
+// coverageServiceTypes is the EB-03 reported for active coverage of each type
+var coverageServiceTypes = map[pb.CoverageType]string{
+	pb.CoverageType_COVERAGE_TYPE_MEDICAL:  ServiceHealthPlan,
+	pb.CoverageType_COVERAGE_TYPE_DENTAL:   ServiceDental,
+	pb.CoverageType_COVERAGE_TYPE_VISION:   ServiceVision,
+	pb.CoverageType_COVERAGE_TYPE_PHARMACY: ServicePharmacy,
+}
+
+// serviceCoverage maps the service types a provider may ask about to the
+// coverage that pays for them. Anything not listed is medical.
+var serviceCoverage = map[string]pb.CoverageType{
+	// Dental: diagnostic, periodontics, restorative, endodontics, maxillofacial
+	// prosthetics, adjunctive, dental care, crowns, accident, orthodontics,
+	// prosthodontics, oral surgery, routine preventive
+	"23": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"24": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"25": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"26": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"27": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"28": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"35": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"36": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"37": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"38": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"39": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"40": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	"41": pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	// Vision: optometry, frames, routine exam, lenses
+	"AL": pb.CoverageType_COVERAGE_TYPE_VISION,
+	"AM": pb.CoverageType_COVERAGE_TYPE_VISION,
+	"AN": pb.CoverageType_COVERAGE_TYPE_VISION,
+	"AO": pb.CoverageType_COVERAGE_TYPE_VISION,
+	// Pharmacy: pharmacy, free-standing and mail order prescription drugs,
+	// brand and generic formulary and non-formulary
+	"88": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"89": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"90": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"91": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"B2": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"B3": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"GF": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	"GN": pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+}
+
+// CoverageFor returns the coverage type that pays for a service type
+func CoverageFor(serviceType string) pb.CoverageType {
+	if coverageType, ok := serviceCoverage[serviceType]; ok {
+		return coverageType
+	}
+	return pb.CoverageType_COVERAGE_TYPE_MEDICAL
+}
+
+// CoverageLevel maps a coverage tier to EB-02
+func CoverageLevel(tier pb.CoverageTier) string {
+	switch tier {
+	case pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_ONLY:
+		return "EMP"
+	case pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_SPOUSE:
+		return "ESP"
+	case pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_CHILDREN:
+		return "ECH"
+	case pb.CoverageTier_COVERAGE_TIER_FAMILY:
+		return "FAM"
+	default:
+		return "IND"
+	}
+}
+
+// InsuranceType maps the card's provider network to EB-04
+func InsuranceType(network string) string {
+	switch strings.ToUpper(network) {
+	case "PPO":
+		return "PR"
+	case "HMO":
+		return "HM"
+	case "EPO":
+		return "EP"
+	case "POS":
+		return "PS"
+	default:
+		return "C1" // commercial
+	}
+}
+
+// cardBenefit describes where a member card entry belongs in a 271
+type cardBenefit struct {
+	key         string
+	serviceType string
+	message     string
+}
+
+// cardBenefits lists the card entries reported as copays or coinsurance, in
+// the order they appear on the card
+var cardBenefits = map[pb.CoverageType][]cardBenefit{
+	pb.CoverageType_COVERAGE_TYPE_MEDICAL: {
+		{key: "copay_primary", serviceType: ServiceOffice, message: "PRIMARY CARE PHYSICIAN"},
+		{key: "copay_specialist", serviceType: ServiceOffice, message: "SPECIALIST"},
+		{key: "emergency_room", serviceType: ServiceEmergency},
+	},
+	pb.CoverageType_COVERAGE_TYPE_PHARMACY: {
+		{key: "generic_copay", serviceType: "GF"},
+		{key: "brand_copay", serviceType: "B2"},
+		{key: "specialty_copay", serviceType: ServicePharmacy, message: "SPECIALTY"},
+	},
+	pb.CoverageType_COVERAGE_TYPE_DENTAL: {
+		{key: "preventive", serviceType: "41"},
+		{key: "basic", serviceType: "25"},
+		{key: "major", serviceType: "36"},
+		{key: "orthodontia", serviceType: "38"},
+	},
+	pb.CoverageType_COVERAGE_TYPE_VISION: {
+		{key: "eye_exam", serviceType: "AN"},
+		{key: "lenses", serviceType: "AO"},
+	},
+}
+
+// CardBenefits turns a member card's copay and coinsurance entries into
+// in-network EB segments. Entries that are neither, such as allowances,
+// are left out.
+func CardBenefits(card *pb.MemberCard, coverageType pb.CoverageType) []Benefit {
+	var benefits []Benefit
+	for _, entry := range cardBenefits[coverageType] {
+		value, ok := card.AdditionalInfo[entry.key]
+		if !ok {
+			continue
+		}
+
+		benefit := Benefit{
+			CoverageLevel: "IND",
+			ServiceTypes:  []string{entry.serviceType},
+			InNetwork:     "Y",
+		}
+		if entry.message != "" {
+			benefit.Messages = []string{entry.message}
+		}
+
+		if cents, ok := parseCopay(value); ok {
+			benefit.Code = CoPayment
+			benefit.TimePeriod = "27" // per visit
+			benefit.Amount = &pb.Money{Cents: cents, Currency: "USD"}
+		} else if share, ok := parseCoinsurance(value); ok {
+			benefit.Code = Coinsurance
+			benefit.Percent = share
+		} else {
+			continue
+		}
+		benefits = append(benefits, benefit)
+	}
+	return benefits
+}
+
+// parseCopay reads card values like "$20" or "$10 copay"
+func parseCopay(value string) (int64, bool) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "copay"))
+	if !strings.HasPrefix(value, "$") {
+		return 0, false
+	}
+	dollars, err := strconv.ParseFloat(strings.ReplaceAll(value[1:], ",", ""), 64)
+	if err != nil || dollars < 0 {
+		return 0, false
+	}
+	return int64(dollars*100 + 0.5), true
+}
+
+// parseCoinsurance reads card values like "80% covered" and returns the
+// member's share as EB-08 expects, e.g. "0.2"
+func parseCoinsurance(value string) (string, bool) {
+	covered, ok := strings.CutSuffix(strings.TrimSpace(value), "% covered")
+	if !ok {
+		return "", false
+	}
+	pct, err := strconv.Atoi(covered)
+	if err != nil || pct < 0 || pct > 100 {
+		return "", false
+	}
+	return strconv.FormatFloat(float64(100-pct)/100, 'f', -1, 64), true
+}
@@ -0,0 +1,218 @@
+// Package eligibility handles the HIPAA 270/271 eligibility transactions
+// (005010X279A1): it parses 270 inquiries, answers them from the member and
+// benefits services, and builds the 271 responses.
+package eligibility
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/x12"
+)
+
+// Version is the implementation guide this package follows
+const Version = "005010X279A1"
+
+// Hierarchical level codes (HL-03)
+const (
+	levelSource     = "20"
+	levelReceiver   = "21"
+	levelSubscriber = "22"
+	levelDependent  = "23"
+)
+
+// Inquiry is a parsed 270 transaction set
+type Inquiry struct {
+	ControlNumber string // ST-02
+	Reference     string // BHT-03, echoed in the 271
+	Sources       []*Source
+}
+
+// Source is loop 2000A, the payer being asked
+type Source struct {
+	HL        string
+	Name      x12.Segment // NM1*PR
+	Receivers []*Receiver
+}
+
+// Receiver is loop 2000B, the provider asking
+type Receiver struct {
+	HL          string
+	Name        x12.Segment // NM1*1P, *FA, ...
+	Subscribers []*Person
+}
+
+// Person is a subscriber (loop 2000C) or dependent (loop 2000D) being
+// asked about
+type Person struct {
+	HL     string
+	Traces []x12.Segment // TRN, echoed in the 271
+
+	LastName   string
+	FirstName  string
+	MiddleName string
+	MemberID   string // NM1-09 when NM1-08 is MI
+	BirthDate  time.Time
+	Gender     string
+
+	// invalidBirthDate is set when DMG-02 is present but unreadable, which
+	// is answered with AAA rather than failing the whole transaction
+	invalidBirthDate bool
+
+	// DateOfService is DTP*291, or zero when the inquiry is for today
+	DateOfService time.Time
+	// ServiceTypes are the EQ-01 codes asked about; empty means 30
+	ServiceTypes []string
+
+	Dependents []*Person
+}
+
+// ParseInquiry reads a 270 transaction set
+func ParseInquiry(tx *x12.Transaction, d x12.Delimiters) (*Inquiry, error) {
+	if tx.Type() != "270" {
+		return nil, fmt.Errorf("eligibility: transaction %s is a %s, not a 270", tx.ControlNumber(), tx.Type())
+	}
+
+	inq := &Inquiry{ControlNumber: tx.ControlNumber()}
+	sources := make(map[string]*Source)
+	receivers := make(map[string]*Receiver)
+	people := make(map[string]*Person)
+
+	var source *Source
+	var receiver *Receiver
+	var person *Person
+
+	for i, seg := range tx.Segments {
+		fail := func(format string, args ...interface{}) (*Inquiry, error) {
+			return nil, fmt.Errorf("eligibility: transaction %s segment %d (%s): %s",
+				tx.ControlNumber(), i+2, seg.ID(), fmt.Sprintf(format, args...))
+		}
+
+		switch seg.ID() {
+		case "BHT":
+			inq.Reference = seg.Element(3)
+
+		case "HL":
+			id, parent := seg.Element(1), seg.Element(2)
+			source, receiver, person = nil, nil, nil
+
+			switch seg.Element(3) {
+			case levelSource:
+				source = &Source{HL: id}
+				sources[id] = source
+				inq.Sources = append(inq.Sources, source)
+			case levelReceiver:
+				parentSource, ok := sources[parent]
+				if !ok {
+					return fail("receiver HL %s has no information source parent", id)
+				}
+				receiver = &Receiver{HL: id}
+				receivers[id] = receiver
+				parentSource.Receivers = append(parentSource.Receivers, receiver)
+			case levelSubscriber:
+				parentReceiver, ok := receivers[parent]
+				if !ok {
+					return fail("subscriber HL %s has no receiver parent", id)
+				}
+				person = &Person{HL: id}
+				people[id] = person
+				parentReceiver.Subscribers = append(parentReceiver.Subscribers, person)
+			case levelDependent:
+				subscriber, ok := people[parent]
+				if !ok {
+					return fail("dependent HL %s has no subscriber parent", id)
+				}
+				person = &Person{HL: id}
+				subscriber.Dependents = append(subscriber.Dependents, person)
+			default:
+				return fail("unsupported hierarchical level %q", seg.Element(3))
+			}
+
+		case "NM1":
+			switch {
+			case source != nil:
+				source.Name = seg
+			case receiver != nil:
+				receiver.Name = seg
+			case person != nil:
+				person.LastName = seg.Element(3)
+				person.FirstName = seg.Element(4)
+				person.MiddleName = seg.Element(5)
+				if seg.Element(8) == "MI" {
+					person.MemberID = seg.Element(9)
+				}
+			default:
+				return fail("NM1 outside a hierarchical level")
+			}
+
+		case "TRN":
+			if person == nil {
+				return fail("TRN outside a subscriber or dependent")
+			}
+			person.Traces = append(person.Traces, seg)
+
+		case "DMG":
+			if person == nil {
+				return fail("DMG outside a subscriber or dependent")
+			}
+			if seg.Element(1) == "D8" && seg.Element(2) != "" {
+				dob, err := time.Parse("20060102", seg.Element(2))
+				person.BirthDate, person.invalidBirthDate = dob, err != nil
+			}
+			person.Gender = seg.Element(3)
+
+		case "DTP":
+			if person == nil || seg.Element(1) != "291" {
+				continue
+			}
+			dos, err := parseDate(seg.Element(2), seg.Element(3))
+			if err != nil {
+				return fail("%v", err)
+			}
+			person.DateOfService = dos
+
+		case "EQ":
+			if person == nil {
+				return fail("EQ outside a subscriber or dependent")
+			}
+			person.ServiceTypes = append(person.ServiceTypes, seg.Repeats(1, d)...)
+
+		default:
+			// REF, N3, N4, PRV, INS, HI, III and AMT refine the inquiry but
+			// don't change how we answer it
+		}
+	}
+
+	if len(inq.Sources) == 0 {
+		return nil, fmt.Errorf("eligibility: transaction %s has no information source", tx.ControlNumber())
+	}
+	for _, source := range inq.Sources {
+		if source.Name == nil {
+			return nil, fmt.Errorf("eligibility: transaction %s: information source HL %s has no NM1", tx.ControlNumber(), source.HL)
+		}
+		for _, receiver := range source.Receivers {
+			if receiver.Name == nil {
+				return nil, fmt.Errorf("eligibility: transaction %s: receiver HL %s has no NM1", tx.ControlNumber(), receiver.HL)
+			}
+		}
+	}
+	return inq, nil
+}
+
+// parseDate reads a D8 date or the start of an RD8 range
+func parseDate(format, value string) (time.Time, error) {
+	switch format {
+	case "D8":
+	case "RD8":
+		value, _, _ = strings.Cut(value, "-")
+	default:
+		return time.Time{}, fmt.Errorf("unsupported date format %q", format)
+	}
+
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return t, nil
+}
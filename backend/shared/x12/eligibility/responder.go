@@ -0,0 +1,450 @@
+package eligibility
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/logger"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/x12"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// coverageTypes are checked in this order when asked about the health plan (30)
+var coverageTypes = []pb.CoverageType{
+	pb.CoverageType_COVERAGE_TYPE_MEDICAL,
+	pb.CoverageType_COVERAGE_TYPE_DENTAL,
+	pb.CoverageType_COVERAGE_TYPE_VISION,
+	pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+}
+
+// Options configures a Responder
+type Options struct {
+	// FirstControlNumber is the ISA-13 of the first 271 interchange; later
+	// ones count up from it. Defaults to 1.
+	FirstControlNumber int
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Responder answers 270 inquiries from the member and benefits services.
+// Calls are made without a requester, so the clients' connections must sign
+// them as an internal service (see access.ServiceInterceptor).
+type Responder struct {
+	members  pb.MemberServiceClient
+	benefits pb.BenefitsServiceClient
+	now      func() time.Time
+
+	mu      sync.Mutex
+	control int
+}
+
+// NewResponder creates a responder. benefits may be nil, in which case
+// deductible and out-of-pocket amounts are left out of the 271s.
+func NewResponder(members pb.MemberServiceClient, benefits pb.BenefitsServiceClient, opts Options) *Responder {
+	if opts.FirstControlNumber <= 0 {
+		opts.FirstControlNumber = 1
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Responder{
+		members:  members,
+		benefits: benefits,
+		now:      opts.Now,
+		control:  opts.FirstControlNumber - 1,
+	}
+}
+
+// nextControlNumber returns the next interchange control number. ISA-13 is
+// nine digits, so it wraps after 999999999.
+func (r *Responder) nextControlNumber() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.control = r.control%999999999 + 1
+	return r.control
+}
+
+// Respond answers every 270 in the interchange with a 271 interchange
+// addressed back to its sender
+func (r *Responder) Respond(ctx context.Context, ic *x12.Interchange) (*x12.Interchange, error) {
+	at := r.now()
+	reply := ic.Reply(r.nextControlNumber(), at)
+
+	for i, group := range ic.Groups {
+		if id := group.Header.Element(1); id != "HS" {
+			return nil, fmt.Errorf("eligibility: group %s is %s, not an HS eligibility inquiry", group.ControlNumber(), id)
+		}
+
+		out := group.Reply("HB", i+1, at)
+		for j, tx := range group.Transactions {
+			inq, err := ParseInquiry(tx, ic.Delimiters)
+			if err != nil {
+				return nil, err
+			}
+
+			answers := r.Answer(ctx, inq, at)
+			out.Transactions = append(out.Transactions, BuildResponse(inq, answers, ControlNumber(j+1), ic.Delimiters, at))
+		}
+		reply.Groups = append(reply.Groups, out)
+	}
+
+	if err := reply.Validate(); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Answer resolves everyone asked about in the inquiry. Lookup failures are
+// answered with AAA segments rather than returned as errors.
+func (r *Responder) Answer(ctx context.Context, inq *Inquiry, at time.Time) map[*Person]*Answer {
+	answers := make(map[*Person]*Answer)
+
+	for _, source := range inq.Sources {
+		for _, receiver := range source.Receivers {
+			for _, subscriber := range receiver.Subscribers {
+				dateOfService := subscriber.DateOfService
+				if dateOfService.IsZero() {
+					dateOfService = at
+				}
+
+				answer := r.answerSubscriber(ctx, subscriber, len(subscriber.Dependents) == 0, dateOfService)
+				answers[subscriber] = answer
+				if answer.Reject != nil {
+					continue
+				}
+
+				for _, dependent := range subscriber.Dependents {
+					dependentDate := dependent.DateOfService
+					if dependentDate.IsZero() {
+						dependentDate = dateOfService
+					}
+					answers[dependent] = r.answerDependent(ctx, answer.Member, dependent, dependentDate)
+				}
+			}
+		}
+	}
+
+	return answers
+}
+
+func reject(reason, followUp string) *Answer {
+	return &Answer{Reject: &Rejection{Reason: reason, FollowUp: followUp}}
+}
+
+// answerSubscriber finds the subscriber by member ID and checks the
+// demographics sent with it. Benefits are only reported when the
+// subscriber is the patient.
+func (r *Responder) answerSubscriber(ctx context.Context, p *Person, patient bool, dateOfService time.Time) *Answer {
+	switch {
+	case p.MemberID == "":
+		return reject(RejectInvalidMemberID, FollowUpCorrect)
+	case p.invalidBirthDate:
+		return reject(RejectInvalidBirthDate, FollowUpCorrect)
+	case p.LastName == "" && p.FirstName == "" && p.BirthDate.IsZero():
+		return reject(RejectInvalidName, FollowUpCorrect)
+	}
+
+	resp, err := r.members.LookupMember(ctx, &pb.LookupMemberRequest{SubscriberId: p.MemberID})
+	if status.Code(err) == codes.NotFound {
+		return reject(RejectSubscriberNotFound, FollowUpCorrect)
+	}
+	if err != nil {
+		logger.Warn("Failed to look up subscriber", zap.String("member_id", p.MemberID), zap.Error(err))
+		return reject(RejectUnableToRespond, FollowUpRetry)
+	}
+
+	member := resp.Member
+	if !sameName(p.LastName, member.LastName) || !sameFirstName(p.FirstName, member.FirstName) {
+		return reject(RejectInvalidName, FollowUpCorrect)
+	}
+	if !sameBirthDate(p.BirthDate, member) {
+		return reject(RejectBirthDateMismatch, FollowUpCorrect)
+	}
+
+	answer := &Answer{Member: member}
+	if patient {
+		if answer.Benefits, err = r.benefitsFor(ctx, member, p.ServiceTypes, dateOfService); err != nil {
+			logger.Warn("Failed to check eligibility", zap.String("member_id", member.MemberId), zap.Error(err))
+			return reject(RejectUnableToRespond, FollowUpRetry)
+		}
+	}
+	return answer
+}
+
+// answerDependent matches the dependent by name and birth date among the
+// subscriber's dependents. 5010 inquiries carry no dependent member ID.
+func (r *Responder) answerDependent(ctx context.Context, subscriber *pb.Member, p *Person, dateOfService time.Time) *Answer {
+	switch {
+	case p.invalidBirthDate:
+		return reject(RejectInvalidBirthDate, FollowUpCorrect)
+	case p.FirstName == "" && p.BirthDate.IsZero():
+		return reject(RejectInvalidPatientName, FollowUpCorrect)
+	}
+
+	resp, err := r.members.ListDependents(ctx, &pb.ListDependentsRequest{MemberId: subscriber.MemberId})
+	if err != nil {
+		logger.Warn("Failed to list dependents", zap.String("member_id", subscriber.MemberId), zap.Error(err))
+		return reject(RejectUnableToRespond, FollowUpRetry)
+	}
+
+	var match *pb.Member
+	for _, dependent := range resp.Dependents {
+		if !sameName(p.LastName, dependent.LastName) || !sameFirstName(p.FirstName, dependent.FirstName) || !sameBirthDate(p.BirthDate, dependent) {
+			continue
+		}
+		if match != nil {
+			// Twins sent without a birth date, say; we can't tell which
+			return reject(RejectPatientNotFound, FollowUpCorrect)
+		}
+		match = dependent
+	}
+	if match == nil {
+		return reject(RejectPatientNotFound, FollowUpCorrect)
+	}
+
+	benefits, err := r.benefitsFor(ctx, match, p.ServiceTypes, dateOfService)
+	if err != nil {
+		logger.Warn("Failed to check eligibility", zap.String("member_id", match.MemberId), zap.Error(err))
+		return reject(RejectUnableToRespond, FollowUpRetry)
+	}
+	return &Answer{Member: match, Benefits: benefits}
+}
+
+// benefitsFor builds the EB loops for the service types asked about. Asking
+// about 30 covers every coverage type the member holds; coverage types asked
+// about directly are reported inactive when not held.
+func (r *Responder) benefitsFor(ctx context.Context, member *pb.Member, serviceTypes []string, dateOfService time.Time) ([]Benefit, error) {
+	if len(serviceTypes) == 0 {
+		serviceTypes = []string{ServiceHealthPlan}
+	}
+
+	asked := make(map[pb.CoverageType][]string)
+	general := false
+	for _, code := range serviceTypes {
+		if code == ServiceHealthPlan {
+			general = true
+			continue
+		}
+		coverageType := CoverageFor(code)
+		asked[coverageType] = append(asked[coverageType], code)
+	}
+
+	var benefits []Benefit
+	for _, coverageType := range coverageTypes {
+		requested, direct := asked[coverageType]
+		if !general && !direct {
+			continue
+		}
+
+		resp, err := r.members.CheckEligibility(ctx, &pb.CheckEligibilityRequest{
+			MemberId:      member.MemberId,
+			CoverageType:  coverageType,
+			DateOfService: timestamppb.New(dateOfService),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		reported := []string{coverageServiceTypes[coverageType]}
+		for _, code := range requested {
+			if code != reported[0] {
+				reported = append(reported, code)
+			}
+		}
+		if !resp.Eligible {
+			// The general inquiry answers for the plan as a whole, so only a
+			// lapse in medical coverage is worth reporting there
+			if direct || coverageType == pb.CoverageType_COVERAGE_TYPE_MEDICAL {
+				benefits = append(benefits, Benefit{
+					Code:         InactiveCoverage,
+					ServiceTypes: reported,
+					Messages:     []string{resp.Reason},
+				})
+			}
+			continue
+		}
+
+		covered, err := r.coveredBenefits(ctx, member, coverageType, resp.Span, reported, dateOfService)
+		if err != nil {
+			return nil, err
+		}
+		benefits = append(benefits, covered...)
+	}
+
+	return benefits, nil
+}
+
+// coveredBenefits reports active coverage under span, then the copays and
+// coinsurance from the member's card and the deductible and out-of-pocket
+// amounts from the benefits service for the plan year of the date of service
+func (r *Responder) coveredBenefits(ctx context.Context, member *pb.Member, coverageType pb.CoverageType, span *pb.CoverageSpan, serviceTypes []string, dateOfService time.Time) ([]Benefit, error) {
+	resp, err := r.members.GetMemberCard(ctx, &pb.GetMemberCardRequest{
+		MemberId:      member.MemberId,
+		CoverageType:  coverageType,
+		DateOfService: timestamppb.New(dateOfService),
+	})
+	if err != nil {
+		return nil, err
+	}
+	card := resp.Card
+
+	active := Benefit{
+		Code:            ActiveCoverage,
+		CoverageLevel:   CoverageLevel(span.GetTier()),
+		ServiceTypes:    serviceTypes,
+		PlanDescription: span.GetPlanName(),
+	}
+	if coverageType == pb.CoverageType_COVERAGE_TYPE_MEDICAL {
+		active.InsuranceType = InsuranceType(card.AdditionalInfo["provider_network"])
+	}
+	if span.GetEffectiveDate() != nil {
+		active.EligibilityBegin = coverage.Day(span.EffectiveDate.AsTime())
+	}
+	if span.GetTerminationDate() != nil {
+		active.EligibilityEnd = coverage.Day(span.TerminationDate.AsTime())
+	}
+
+	benefits := []Benefit{active}
+	benefits = append(benefits, CardBenefits(card, coverageType)...)
+	benefits = append(benefits, r.accumulators(ctx, member.MemberId, coverageType, dateOfService)...)
+	return benefits, nil
+}
+
+// accumulators reports the deductible and out-of-pocket maximums with what
+// remains of each in the period containing the date of service. The benefits
+// service is optional: when it can't answer, the 271 goes out without these
+// rather than not at all.
+func (r *Responder) accumulators(ctx context.Context, memberID string, coverageType pb.CoverageType, dateOfService time.Time) []Benefit {
+	if r.benefits == nil {
+		return nil
+	}
+
+	serviceTypes := []string{coverageServiceTypes[coverageType]}
+	var benefits []Benefit
+
+	deductible, err := r.benefits.GetDeductibleStatus(ctx, &pb.GetDeductibleStatusRequest{
+		MemberId:     memberID,
+		CoverageType: coverageType,
+		AsOf:         timestamppb.New(dateOfService),
+	})
+	if err == nil && deductible.Status != nil {
+		s := deductible.Status
+		period := timePeriod(s.PeriodStart)
+		benefits = append(benefits, accumulator(Deductible, "IND", serviceTypes, period, s.IndividualDeductible, s.IndividualMet)...)
+		benefits = append(benefits, accumulator(Deductible, "FAM", serviceTypes, period, s.FamilyDeductible, s.FamilyMet)...)
+	} else if err != nil && !notTracked(err) {
+		logger.Warn("Failed to get deductible status", zap.String("member_id", memberID), zap.Error(err))
+	}
+
+	outOfPocket, err := r.benefits.GetOutOfPocketStatus(ctx, &pb.GetOutOfPocketStatusRequest{
+		MemberId:     memberID,
+		CoverageType: coverageType,
+		AsOf:         timestamppb.New(dateOfService),
+	})
+	if err == nil && outOfPocket.Status != nil {
+		s := outOfPocket.Status
+		period := timePeriod(s.PeriodStart)
+		benefits = append(benefits, accumulator(OutOfPocket, "IND", serviceTypes, period, s.IndividualLimit, s.IndividualSpent)...)
+		benefits = append(benefits, accumulator(OutOfPocket, "FAM", serviceTypes, period, s.FamilyLimit, s.FamilySpent)...)
+	} else if err != nil && !notTracked(err) {
+		logger.Warn("Failed to get out-of-pocket status", zap.String("member_id", memberID), zap.Error(err))
+	}
+
+	return benefits
+}
+
+// notTracked reports errors meaning the benefits service keeps no such
+// accumulator, as opposed to failing to answer
+func notTracked(err error) bool {
+	switch status.Code(err) {
+	case codes.NotFound, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// accumulator reports a limit for the period and what remains of it (29)
+func accumulator(code, level string, serviceTypes []string, period string, limit, used *pb.Money) []Benefit {
+	if limit.GetCents() <= 0 {
+		return nil
+	}
+
+	remaining := limit.GetCents() - used.GetCents()
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return []Benefit{
+		{
+			Code:          code,
+			CoverageLevel: level,
+			ServiceTypes:  serviceTypes,
+			TimePeriod:    period,
+			Amount:        limit,
+			InNetwork:     "Y",
+		},
+		{
+			Code:          code,
+			CoverageLevel: level,
+			ServiceTypes:  serviceTypes,
+			TimePeriod:    "29",
+			Amount:        &pb.Money{Cents: remaining, Currency: limit.Currency},
+			InNetwork:     "Y",
+		},
+	}
+}
+
+// timePeriod is calendar year (23) for periods starting January 1 and
+// service year (22) otherwise
+func timePeriod(start *timestamppb.Timestamp) string {
+	if start == nil {
+		return "23"
+	}
+	if _, month, day := start.AsTime().UTC().Date(); month == time.January && day == 1 {
+		return "23"
+	}
+	return "22"
+}
+
+// sameName compares a name from the inquiry, if one was sent, with ours,
+// ignoring case, spacing and punctuation
+func sameName(theirs, ours string) bool {
+	return theirs == "" || normalizeName(theirs) == normalizeName(ours)
+}
+
+// sameFirstName also accepts a truncated first name, e.g. JIM for JIMMY,
+// since provider systems often cut names short
+func sameFirstName(theirs, ours string) bool {
+	if theirs == "" {
+		return true
+	}
+	theirs, ours = normalizeName(theirs), normalizeName(ours)
+	return theirs != "" && strings.HasPrefix(ours, theirs)
+}
+
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, name)
+}
+
+func sameBirthDate(theirs time.Time, member *pb.Member) bool {
+	if theirs.IsZero() {
+		return true
+	}
+	return member.DateOfBirth != nil && coverage.Day(member.DateOfBirth.AsTime()).Equal(coverage.Day(theirs))
+}
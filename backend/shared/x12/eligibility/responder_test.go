@@ -0,0 +1,266 @@
+package eligibility
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/x12"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden 271s in testdata")
+
+func date(year int, month time.Month, day int) *timestamppb.Timestamp {
+	return timestamppb.New(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// fakeMembers answers from the Doe family the fixtures are written against,
+// the same people as the member service's mock data
+type fakeMembers struct {
+	pb.MemberServiceClient
+
+	members map[string]*pb.Member
+	spans   map[string][]*pb.CoverageSpan
+}
+
+func newFakeMembers() *fakeMembers {
+	f := &fakeMembers{
+		members: map[string]*pb.Member{
+			"M123456": {MemberId: "M123456", FirstName: "John", LastName: "Doe", DateOfBirth: date(1985, time.June, 15), SubscriberId: "SUB123456", GroupNumber: "GRP001234"},
+			"M123457": {MemberId: "M123457", FirstName: "Jane", LastName: "Doe", DateOfBirth: date(1987, time.March, 22), SubscriberId: "SUB123456-01", GroupNumber: "GRP001234", SubscriberMemberId: "M123456"},
+			"M123458": {MemberId: "M123458", FirstName: "Jimmy", LastName: "Doe", DateOfBirth: date(2010, time.July, 10), SubscriberId: "SUB123456-02", GroupNumber: "GRP001234", SubscriberMemberId: "M123456"},
+		},
+		spans: make(map[string][]*pb.CoverageSpan),
+	}
+
+	span := func(memberID string, coverageType pb.CoverageType, plan string, from, to *timestamppb.Timestamp) {
+		f.spans[memberID] = append(f.spans[memberID], &pb.CoverageSpan{
+			SpanId:          memberID + "-" + coverage.TypeName(coverageType) + "-" + from.AsTime().Format("2006"),
+			MemberId:        memberID,
+			CoverageType:    coverageType,
+			PlanName:        plan,
+			GroupNumber:     "GRP001234",
+			Tier:            pb.CoverageTier_COVERAGE_TIER_FAMILY,
+			EffectiveDate:   from,
+			TerminationDate: to,
+		})
+	}
+	for _, coverageType := range coverageTypes[1:] {
+		span("M123456", coverageType, "Premium Health Plan", date(2020, time.January, 1), nil)
+		span("M123457", coverageType, "Premium Health Plan", date(2020, time.January, 1), nil)
+		if coverageType != pb.CoverageType_COVERAGE_TYPE_PHARMACY {
+			span("M123458", coverageType, "Premium Health Plan", date(2020, time.January, 1), nil)
+		}
+	}
+	// The subscriber moved from the standard to the premium medical plan in 2024
+	span("M123456", pb.CoverageType_COVERAGE_TYPE_MEDICAL, "Standard Health Plan", date(2020, time.January, 1), date(2023, time.December, 31))
+	span("M123456", pb.CoverageType_COVERAGE_TYPE_MEDICAL, "Premium Health Plan", date(2024, time.January, 1), nil)
+	span("M123457", pb.CoverageType_COVERAGE_TYPE_MEDICAL, "Premium Health Plan", date(2020, time.January, 1), nil)
+	span("M123458", pb.CoverageType_COVERAGE_TYPE_MEDICAL, "Premium Health Plan", date(2020, time.January, 1), nil)
+
+	for _, spans := range f.spans {
+		coverage.Sort(spans)
+	}
+	return f
+}
+
+func (f *fakeMembers) LookupMember(ctx context.Context, req *pb.LookupMemberRequest, opts ...grpc.CallOption) (*pb.LookupMemberResponse, error) {
+	for _, member := range f.members {
+		if strings.EqualFold(member.SubscriberId, req.SubscriberId) {
+			return &pb.LookupMemberResponse{Member: member}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "member not found: %s", req.SubscriberId)
+}
+
+func (f *fakeMembers) ListDependents(ctx context.Context, req *pb.ListDependentsRequest, opts ...grpc.CallOption) (*pb.ListDependentsResponse, error) {
+	resp := &pb.ListDependentsResponse{}
+	for _, id := range []string{"M123457", "M123458"} {
+		if member := f.members[id]; member.SubscriberMemberId == req.MemberId {
+			resp.Dependents = append(resp.Dependents, member)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeMembers) CheckEligibility(ctx context.Context, req *pb.CheckEligibilityRequest, opts ...grpc.CallOption) (*pb.CheckEligibilityResponse, error) {
+	span, eligible, reason := coverage.Check(f.spans[req.MemberId], req.CoverageType, req.DateOfService.AsTime())
+	return &pb.CheckEligibilityResponse{Eligible: eligible, Span: span, Reason: reason}, nil
+}
+
+func (f *fakeMembers) GetMemberCard(ctx context.Context, req *pb.GetMemberCardRequest, opts ...grpc.CallOption) (*pb.GetMemberCardResponse, error) {
+	member := f.members[req.MemberId]
+	card := &pb.MemberCard{
+		MemberId:       member.MemberId,
+		MemberNumber:   member.SubscriberId,
+		GroupNumber:    member.GroupNumber,
+		AdditionalInfo: make(map[string]string),
+	}
+	span := coverage.Find(f.spans[req.MemberId], req.CoverageType, req.DateOfService.AsTime())
+	if span != nil {
+		card.PlanName = span.PlanName
+	}
+
+	switch req.CoverageType {
+	case pb.CoverageType_COVERAGE_TYPE_MEDICAL:
+		card.AdditionalInfo["copay_primary"] = "$20"
+		card.AdditionalInfo["copay_specialist"] = "$40"
+		card.AdditionalInfo["emergency_room"] = "$150"
+		card.AdditionalInfo["provider_network"] = "PPO"
+	case pb.CoverageType_COVERAGE_TYPE_PHARMACY:
+		card.BinNumber = "610502"
+		card.AdditionalInfo["generic_copay"] = "$10"
+		card.AdditionalInfo["brand_copay"] = "$35"
+	case pb.CoverageType_COVERAGE_TYPE_DENTAL:
+		card.AdditionalInfo["preventive"] = "100%"
+		card.AdditionalInfo["basic"] = "80%"
+		card.AdditionalInfo["major"] = "50%"
+	case pb.CoverageType_COVERAGE_TYPE_VISION:
+		card.AdditionalInfo["eye_exam"] = "$10 copay"
+		card.AdditionalInfo["frames"] = "$150 allowance"
+	}
+	// The standard plan's copays differ, so a 271 for a date under it shows
+	// whether the card came from the right span
+	if card.PlanName == "Standard Health Plan" {
+		card.AdditionalInfo["copay_primary"] = "$30"
+		card.AdditionalInfo["copay_specialist"] = "$60"
+		card.AdditionalInfo["emergency_room"] = "$250"
+	}
+	return &pb.GetMemberCardResponse{Card: card}, nil
+}
+
+// fakeBenefits tracks medical accumulators only. Amounts differ by plan
+// year, so a 271 reporting the wrong year's shows up in the goldens.
+type fakeBenefits struct {
+	pb.BenefitsServiceClient
+	t *testing.T
+}
+
+// usedCents is what the family has put toward the limit in the plan year
+func usedCents(asOf *timestamppb.Timestamp, limit int64) int64 {
+	if asOf.AsTime().Year() < 2024 {
+		return limit
+	}
+	return limit / 3
+}
+
+func (f *fakeBenefits) periodStart(asOf *timestamppb.Timestamp) *timestamppb.Timestamp {
+	f.t.Helper()
+	if asOf == nil {
+		f.t.Errorf("accumulators requested without a date of service")
+		asOf = timestamppb.Now()
+	}
+	return date(asOf.AsTime().Year(), time.January, 1)
+}
+
+func usd(cents int64) *pb.Money {
+	return &pb.Money{Cents: cents, Currency: "USD"}
+}
+
+func (f *fakeBenefits) GetDeductibleStatus(ctx context.Context, req *pb.GetDeductibleStatusRequest, opts ...grpc.CallOption) (*pb.GetDeductibleStatusResponse, error) {
+	start := f.periodStart(req.AsOf)
+	if req.CoverageType != pb.CoverageType_COVERAGE_TYPE_MEDICAL {
+		return nil, status.Error(codes.NotFound, "no deductible for coverage")
+	}
+	return &pb.GetDeductibleStatusResponse{Status: &pb.DeductibleStatus{
+		CoverageType:         req.CoverageType,
+		IndividualDeductible: usd(150000),
+		IndividualMet:        usd(usedCents(req.AsOf, 150000)),
+		FamilyDeductible:     usd(300000),
+		FamilyMet:            usd(usedCents(req.AsOf, 300000)),
+		PeriodStart:          start,
+	}}, nil
+}
+
+func (f *fakeBenefits) GetOutOfPocketStatus(ctx context.Context, req *pb.GetOutOfPocketStatusRequest, opts ...grpc.CallOption) (*pb.GetOutOfPocketStatusResponse, error) {
+	start := f.periodStart(req.AsOf)
+	if req.CoverageType != pb.CoverageType_COVERAGE_TYPE_MEDICAL {
+		return nil, status.Error(codes.NotFound, "no out-of-pocket maximum for coverage")
+	}
+	return &pb.GetOutOfPocketStatusResponse{Status: &pb.OutOfPocketStatus{
+		CoverageType:    req.CoverageType,
+		IndividualLimit: usd(600000),
+		IndividualSpent: usd(usedCents(req.AsOf, 600000)),
+		FamilyLimit:     usd(1200000),
+		FamilySpent:     usd(usedCents(req.AsOf, 1200000)),
+		PeriodStart:     start,
+	}}, nil
+}
+
+// TestRespondGolden answers every fixture in testdata and compares the 271s
+// with the checked-in goldens next to them. Run with -update to rewrite the
+// goldens after an intended change, then review them.
+func TestRespondGolden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.x12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".x12")
+
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interchanges, err := x12.Parse(data)
+			if err != nil {
+				t.Fatalf("parsing %s: %v", fixture, err)
+			}
+
+			responder := NewResponder(newFakeMembers(), &fakeBenefits{t: t}, Options{
+				FirstControlNumber: 1,
+				Now: func() time.Time {
+					return time.Date(2024, time.January, 15, 9, 30, 0, 0, time.UTC)
+				},
+			})
+
+			var got bytes.Buffer
+			for _, ic := range interchanges {
+				reply, err := responder.Respond(context.Background(), ic)
+				if err != nil {
+					t.Fatalf("interchange %s: %v", ic.ControlNumber(), err)
+				}
+				if _, err := reply.WriteTo(&got); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			compareGolden(t, filepath.Join("testdata", name+".271"), got.Bytes())
+		})
+	}
+}
+
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from its golden\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
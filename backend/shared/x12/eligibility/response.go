@@ -0,0 +1,224 @@
+package eligibility
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/x12"
+)
+
+// Eligibility or benefit information codes (EB-01)
+const (
+	ActiveCoverage   = "1"
+	InactiveCoverage = "6"
+	Coinsurance      = "A"
+	CoPayment        = "B"
+	Deductible       = "C"
+	OutOfPocket      = "G"
+)
+
+// AAA reject reason codes (AAA-03) and follow-up actions (AAA-04)
+const (
+	RejectUnableToRespond    = "42"
+	RejectInvalidBirthDate   = "58"
+	RejectInvalidPatientName = "65"
+	RejectPatientNotFound    = "67"
+	RejectBirthDateMismatch  = "71"
+	RejectInvalidMemberID    = "72"
+	RejectInvalidName        = "73"
+	RejectSubscriberNotFound = "75"
+
+	FollowUpCorrect = "C" // please correct and resubmit
+	FollowUpRetry   = "R" // resubmission allowed
+)
+
+// Rejection is an AAA segment explaining why a request could not be answered
+type Rejection struct {
+	Reason   string
+	FollowUp string
+}
+
+// Benefit is one EB segment of a 271 with the dates and messages that qualify it
+type Benefit struct {
+	Code            string   // EB-01
+	CoverageLevel   string   // EB-02, e.g. IND or FAM
+	ServiceTypes    []string // EB-03, repeating
+	InsuranceType   string   // EB-04
+	PlanDescription string   // EB-05
+	TimePeriod      string   // EB-06, e.g. 23 calendar year, 29 remaining
+	Amount          *pb.Money
+	Percent         string // EB-08, the member's share as a decimal
+	InNetwork       string // EB-12: Y, N or W (not applicable)
+
+	EligibilityBegin time.Time // DTP*356
+	EligibilityEnd   time.Time // DTP*357
+	Messages         []string  // MSG
+}
+
+// Answer is the payer's reply about one subscriber or dependent. Member is
+// nil when the request was rejected.
+type Answer struct {
+	Member   *pb.Member
+	Reject   *Rejection
+	Benefits []Benefit
+}
+
+// BuildResponse builds the 271 answering inq. Hierarchical levels mirror the
+// inquiry's, and answers are keyed by the person they describe.
+func BuildResponse(inq *Inquiry, answers map[*Person]*Answer, controlNumber string, d x12.Delimiters, at time.Time) *x12.Transaction {
+	b := &builder{d: d}
+
+	b.add("BHT", "0022", "11", inq.Reference, at.Format("20060102"), at.Format("1504"))
+
+	for _, source := range inq.Sources {
+		b.hl(source.HL, "", levelSource, len(source.Receivers) > 0)
+		b.segs = append(b.segs, source.Name)
+
+		for _, receiver := range source.Receivers {
+			b.hl(receiver.HL, source.HL, levelReceiver, len(receiver.Subscribers) > 0)
+			b.segs = append(b.segs, receiver.Name)
+
+			for _, subscriber := range receiver.Subscribers {
+				answer := answers[subscriber]
+				answered := answer != nil && answer.Member != nil && answer.Reject == nil
+				b.hl(subscriber.HL, receiver.HL, levelSubscriber, answered && len(subscriber.Dependents) > 0)
+				b.person(subscriber, answer, "IL")
+
+				// Dependents are only reported once their subscriber was found
+				if !answered {
+					continue
+				}
+				for _, dependent := range subscriber.Dependents {
+					b.hl(dependent.HL, subscriber.HL, levelDependent, false)
+					b.person(dependent, answers[dependent], "03")
+				}
+			}
+		}
+	}
+
+	return &x12.Transaction{
+		Header:   x12.NewSegment("ST", "271", controlNumber, Version),
+		Segments: b.segs,
+	}
+}
+
+type builder struct {
+	d    x12.Delimiters
+	segs []x12.Segment
+}
+
+func (b *builder) add(id string, elements ...string) {
+	for i := range elements {
+		elements[i] = x12.Clean(elements[i], b.d)
+	}
+	b.segs = append(b.segs, x12.NewSegment(id, elements...))
+}
+
+func (b *builder) hl(id, parent, level string, hasChildren bool) {
+	child := "0"
+	if hasChildren {
+		child = "1"
+	}
+	b.add("HL", id, parent, level, child)
+}
+
+// person writes loop 2000C/2000D after its HL: the trace numbers, the name
+// (ours when found, theirs when not), and then either AAA or the benefits
+func (b *builder) person(p *Person, answer *Answer, entity string) {
+	// Our answer references their trace; ours would be TRN*1
+	for _, trn := range p.Traces {
+		b.add("TRN", "2", trn.Element(2), trn.Element(3), trn.Element(4))
+	}
+
+	if answer == nil || answer.Member == nil || answer.Reject != nil {
+		reject := &Rejection{Reason: RejectUnableToRespond, FollowUp: FollowUpRetry}
+		if answer != nil && answer.Reject != nil {
+			reject = answer.Reject
+		}
+		b.name(entity, p.LastName, p.FirstName, p.MiddleName, p.MemberID)
+		b.add("AAA", "Y", "", reject.Reason, reject.FollowUp)
+		return
+	}
+
+	member := answer.Member
+	memberID := member.SubscriberId
+	if memberID == "" {
+		memberID = p.MemberID
+	}
+	b.name(entity, member.LastName, member.FirstName, member.MiddleName, memberID)
+
+	if addr := member.Address; addr != nil && addr.Street1 != "" {
+		b.add("N3", upper(addr.Street1), upper(addr.Street2))
+		b.add("N4", upper(addr.City), upper(addr.State), strings.ReplaceAll(addr.ZipCode, "-", ""))
+	}
+	if member.DateOfBirth != nil {
+		b.add("DMG", "D8", member.DateOfBirth.AsTime().UTC().Format("20060102"))
+	}
+
+	for _, benefit := range answer.Benefits {
+		b.benefit(benefit)
+	}
+}
+
+// name writes NM1. Dependents (03) carry no identifier in 5010.
+func (b *builder) name(entity, last, first, middle, memberID string) {
+	if entity != "IL" {
+		memberID = ""
+	}
+	qualifier := ""
+	if memberID != "" {
+		qualifier = "MI"
+	}
+	b.add("NM1", entity, "1", upper(last), upper(first), upper(middle), "", "", qualifier, memberID)
+}
+
+func (b *builder) benefit(eb Benefit) {
+	amount := ""
+	if eb.Amount != nil {
+		amount = formatAmount(eb.Amount)
+	}
+	elements := []string{eb.Code, eb.CoverageLevel, "", eb.InsuranceType, upper(eb.PlanDescription),
+		eb.TimePeriod, amount, eb.Percent, "", "", "", eb.InNetwork}
+	for i := range elements {
+		elements[i] = x12.Clean(elements[i], b.d)
+	}
+
+	// EB-03 repeats, so its codes are cleaned one by one and then joined
+	serviceTypes := make([]string, len(eb.ServiceTypes))
+	for i, code := range eb.ServiceTypes {
+		serviceTypes[i] = x12.Clean(code, b.d)
+	}
+	elements[2] = strings.Join(serviceTypes, string(b.d.Repetition))
+	b.segs = append(b.segs, x12.NewSegment("EB", elements...))
+
+	if !eb.EligibilityBegin.IsZero() {
+		b.add("DTP", "356", "D8", eb.EligibilityBegin.Format("20060102"))
+	}
+	if !eb.EligibilityEnd.IsZero() {
+		b.add("DTP", "357", "D8", eb.EligibilityEnd.Format("20060102"))
+	}
+	for _, msg := range eb.Messages {
+		// MSG-01 is limited to 264 characters
+		if len(msg) > 264 {
+			msg = msg[:264]
+		}
+		b.add("MSG", msg)
+	}
+}
+
+// formatAmount renders money as an X12 decimal, e.g. 1500 or 20.5
+func formatAmount(m *pb.Money) string {
+	return strconv.FormatFloat(float64(m.Cents)/100, 'f', -1, 64)
+}
+
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// ControlNumber formats a transaction set control number, e.g. "0001"
+func ControlNumber(n int) string {
+	return fmt.Sprintf("%04d", n)
+}
@@ -0,0 +1,75 @@
+package enrollment
+
+import (
+	"strings"
+
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+)
+
+// CoverageType maps an insurance line code (HD-03) to the coverage it enrolls
+// the member in
+func CoverageType(insuranceLine string) (pb.CoverageType, bool) {
+	switch strings.ToUpper(insuranceLine) {
+	case "HLT", "HMO", "PPO", "EPO", "POS":
+		return pb.CoverageType_COVERAGE_TYPE_MEDICAL, true
+	case "DEN":
+		return pb.CoverageType_COVERAGE_TYPE_DENTAL, true
+	case "VIS":
+		return pb.CoverageType_COVERAGE_TYPE_VISION, true
+	case "PDG":
+		return pb.CoverageType_COVERAGE_TYPE_PHARMACY, true
+	default:
+		return pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED, false
+	}
+}
+
+// Tier maps a coverage level code (HD-05) to a coverage tier
+func Tier(level string) pb.CoverageTier {
+	switch strings.ToUpper(level) {
+	case "EMP", "IND":
+		return pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_ONLY
+	case "ESP":
+		return pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_SPOUSE
+	case "ECH":
+		return pb.CoverageTier_COVERAGE_TIER_EMPLOYEE_CHILDREN
+	case "FAM":
+		return pb.CoverageTier_COVERAGE_TIER_FAMILY
+	default:
+		return pb.CoverageTier_COVERAGE_TIER_UNSPECIFIED
+	}
+}
+
+// TerminationReason maps a maintenance reason code (INS-04) sent with a
+// termination to the reason recorded on the span
+func TerminationReason(reason string) pb.TerminationReason {
+	switch reason {
+	case "03":
+		return pb.TerminationReason_TERMINATION_REASON_DEATH
+	case "14":
+		return pb.TerminationReason_TERMINATION_REASON_VOLUNTARY
+	case "59":
+		return pb.TerminationReason_TERMINATION_REASON_NON_PAYMENT
+	case "07", "08", "09", "10", "AI":
+		// Termination of benefits, termination of employment, divorce,
+		// dissolution of partnership, no reason given
+		return pb.TerminationReason_TERMINATION_REASON_LOSS_OF_ELIGIBILITY
+	default:
+		return pb.TerminationReason_TERMINATION_REASON_OTHER
+	}
+}
+
+// Relationship maps an individual relationship code (INS-02) to the
+// relationship stored for a dependent
+func Relationship(code string) string {
+	switch code {
+	case "01":
+		return "SPOUSE"
+	case "53":
+		return "DOMESTIC_PARTNER"
+	case "19", "17", "10", "15":
+		// Child, stepchild, foster child, ward
+		return "CHILD"
+	default:
+		return "OTHER"
+	}
+}
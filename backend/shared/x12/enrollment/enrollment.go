@@ -0,0 +1,316 @@
+// Package enrollment handles the HIPAA 834 benefit enrollment and
+// maintenance transaction (005010X220A1): it parses the member loops an
+// employer group sends and reconciles them with the members, dependents and
+// coverage spans on record.
+package enrollment
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/x12"
+)
+
+// Version is the implementation guide this package follows
+const Version = "005010X220A1"
+
+// Maintenance type codes (INS-03, HD-01)
+const (
+	MaintenanceChange    = "001"
+	MaintenanceAdd       = "021"
+	MaintenanceTerm      = "024"
+	MaintenanceReinstate = "025"
+	MaintenanceAudit     = "030"
+)
+
+// Transaction set action codes (BGN-08)
+const (
+	ActionChange  = "2"
+	ActionVerify  = "4"
+	ActionReplace = "RX"
+)
+
+// Enrollment is a parsed 834 transaction set
+type Enrollment struct {
+	ControlNumber string // ST-02
+	Reference     string // BGN-02
+	Action        string // BGN-08
+	PolicyNumber  string // REF*38, the sponsor's master policy
+	Sponsor       Party  // N1*P5
+	Payer         Party  // N1*IN
+	Members       []*Member
+}
+
+// Party is a sponsor or payer named in the 834 header
+type Party struct {
+	Name string
+	ID   string
+}
+
+// Member is loop 2000, one subscriber or dependent and their coverages
+type Member struct {
+	Position     int    // segment number of the INS within the transaction
+	Subscriber   bool   // INS-01
+	Relationship string // INS-02, e.g. 18 self, 01 spouse, 19 child
+	Maintenance  string // INS-03
+	Reason       string // INS-04
+
+	SubscriberID string // REF*0F, the subscriber's card number
+	MemberID     string // REF*23, the member's own card number when sent
+	GroupNumber  string // REF*1L
+
+	LastName   string
+	FirstName  string
+	MiddleName string
+	BirthDate  time.Time
+	Gender     string // DMG-03: M, F or U
+	Street1    string
+	Street2    string
+	City       string
+	State      string
+	ZipCode    string
+	Phone      string
+	Email      string
+
+	EligibilityBegin time.Time // DTP*356
+	EligibilityEnd   time.Time // DTP*357
+
+	Coverages []*Coverage
+
+	// Problems are loop-level errors found while parsing, such as an
+	// unreadable date. The loop is reported as errored rather than failing
+	// the whole transaction.
+	Problems []string
+}
+
+// Coverage is loop 2300, one health coverage of a member
+type Coverage struct {
+	Maintenance   string    // HD-01
+	InsuranceLine string    // HD-03: HLT, DEN, VIS or PDG
+	Plan          string    // HD-04
+	Level         string    // HD-05, e.g. EMP or FAM
+	PlanID        string    // REF*CE
+	GroupNumber   string    // REF*1L
+	Begin         time.Time // DTP*348
+	End           time.Time // DTP*349
+}
+
+// Name formats the member's name for reports, e.g. "DOE, JANE"
+func (m *Member) Name() string {
+	if m.FirstName == "" {
+		return strings.ToUpper(m.LastName)
+	}
+	return strings.ToUpper(m.LastName + ", " + m.FirstName)
+}
+
+// Loops tracked while parsing, so REF, DTP, N3 and N4 land on the record
+// they describe
+const (
+	loopMember   = "2000"
+	loopName     = "2100A"
+	loopOther    = "2100"
+	loopCoverage = "2300"
+	loopDetail   = "2310"
+)
+
+// ParseEnrollment reads an 834 transaction set
+func ParseEnrollment(tx *x12.Transaction) (*Enrollment, error) {
+	if tx.Type() != "834" {
+		return nil, fmt.Errorf("enrollment: transaction %s is a %s, not an 834", tx.ControlNumber(), tx.Type())
+	}
+
+	e := &Enrollment{ControlNumber: tx.ControlNumber()}
+	var member *Member
+	var cov *Coverage
+	loop := ""
+
+	for i, seg := range tx.Segments {
+		position := i + 2 // ST is segment 1
+		fail := func(format string, args ...interface{}) (*Enrollment, error) {
+			return nil, fmt.Errorf("enrollment: transaction %s segment %d (%s): %s",
+				tx.ControlNumber(), position, seg.ID(), fmt.Sprintf(format, args...))
+		}
+
+		if seg.ID() != "INS" && member == nil {
+			// Header: BGN, REF, DTP, QTY and the N1 loops
+			switch seg.ID() {
+			case "BGN":
+				e.Reference = seg.Element(2)
+				e.Action = seg.Element(8)
+			case "REF":
+				if seg.Element(1) == "38" {
+					e.PolicyNumber = seg.Element(2)
+				}
+			case "N1":
+				party := Party{Name: seg.Element(2), ID: seg.Element(4)}
+				switch seg.Element(1) {
+				case "P5":
+					e.Sponsor = party
+				case "IN":
+					e.Payer = party
+				}
+			}
+			continue
+		}
+
+		switch seg.ID() {
+		case "INS":
+			if seg.Element(1) == "" || seg.Element(3) == "" {
+				return fail("INS-01 and INS-03 are required")
+			}
+			member = &Member{
+				Position:     position,
+				Subscriber:   seg.Element(1) == "Y",
+				Relationship: seg.Element(2),
+				Maintenance:  seg.Element(3),
+				Reason:       seg.Element(4),
+			}
+			e.Members = append(e.Members, member)
+			cov = nil
+			loop = loopMember
+
+		case "REF":
+			switch loop {
+			case loopMember:
+				switch seg.Element(1) {
+				case "0F":
+					member.SubscriberID = seg.Element(2)
+				case "23":
+					member.MemberID = seg.Element(2)
+				case "1L":
+					member.GroupNumber = seg.Element(2)
+				}
+			case loopCoverage:
+				switch seg.Element(1) {
+				case "CE":
+					cov.PlanID = seg.Element(2)
+				case "1L":
+					cov.GroupNumber = seg.Element(2)
+				}
+			}
+
+		case "DTP":
+			switch loop {
+			case loopMember:
+				switch seg.Element(1) {
+				case "356":
+					member.EligibilityBegin = member.date(seg)
+				case "357":
+					member.EligibilityEnd = member.date(seg)
+				}
+			case loopCoverage:
+				switch seg.Element(1) {
+				case "348":
+					cov.Begin = member.date(seg)
+				case "349":
+					cov.End = member.date(seg)
+				}
+			}
+
+		case "NM1":
+			if loop == loopDetail {
+				// Provider (2310) names belong to the coverage
+				continue
+			}
+			if seg.Element(1) != "IL" {
+				// Incorrect member name, mailing address, employer, school,
+				// custodial parent and responsible person loops
+				loop = loopOther
+				continue
+			}
+			if cov != nil {
+				return fail("member name after a coverage loop")
+			}
+			loop = loopName
+			member.LastName = seg.Element(3)
+			member.FirstName = seg.Element(4)
+			member.MiddleName = seg.Element(5)
+
+		case "PER":
+			if loop != loopName {
+				continue
+			}
+			// Up to three qualifier/number pairs
+			for j := 3; j <= 7; j += 2 {
+				number := seg.Element(j + 1)
+				switch seg.Element(j) {
+				case "TE", "HP", "CP", "WP":
+					if member.Phone == "" {
+						member.Phone = number
+					}
+				case "EM":
+					member.Email = number
+				}
+			}
+
+		case "N3":
+			if loop == loopName {
+				member.Street1 = seg.Element(1)
+				member.Street2 = seg.Element(2)
+			}
+
+		case "N4":
+			if loop == loopName {
+				member.City = seg.Element(1)
+				member.State = seg.Element(2)
+				member.ZipCode = seg.Element(3)
+			}
+
+		case "DMG":
+			if loop != loopName {
+				continue
+			}
+			if seg.Element(1) == "D8" && seg.Element(2) != "" {
+				dob, err := time.Parse("20060102", seg.Element(2))
+				if err != nil {
+					member.problem("invalid birth date %q", seg.Element(2))
+				}
+				member.BirthDate = dob
+			}
+			member.Gender = seg.Element(3)
+
+		case "HD":
+			cov = &Coverage{
+				Maintenance:   seg.Element(1),
+				InsuranceLine: seg.Element(3),
+				Plan:          seg.Element(4),
+				Level:         seg.Element(5),
+			}
+			member.Coverages = append(member.Coverages, cov)
+			loop = loopCoverage
+
+		case "LX", "COB", "LS":
+			// Provider (2310), coordination of benefits (2320) and
+			// reporting category (2700) loops
+			loop = loopDetail
+
+		default:
+			// DSB, EC, ICM, AMT, HLH, LUI, IDC and LE refine the loop but
+			// aren't kept on the member record
+		}
+	}
+
+	if e.Reference == "" {
+		return nil, fmt.Errorf("enrollment: transaction %s has no BGN", tx.ControlNumber())
+	}
+	return e, nil
+}
+
+// date reads a D8 date, recording a problem when it is unreadable
+func (m *Member) date(seg x12.Segment) time.Time {
+	if seg.Element(2) != "D8" {
+		m.problem("DTP*%s: unsupported date format %q", seg.Element(1), seg.Element(2))
+		return time.Time{}
+	}
+	t, err := time.Parse("20060102", seg.Element(3))
+	if err != nil {
+		m.problem("DTP*%s: invalid date %q", seg.Element(1), seg.Element(3))
+		return time.Time{}
+	}
+	return t
+}
+
+func (m *Member) problem(format string, args ...interface{}) {
+	m.Problems = append(m.Problems, fmt.Sprintf(format, args...))
+}
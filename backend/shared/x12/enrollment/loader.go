@@ -0,0 +1,757 @@
+package enrollment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/x12"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Enrollee is a member on record, with the fields an 834 maintains that the
+// Member message doesn't carry
+type Enrollee struct {
+	Member *pb.Member
+	Gender string
+}
+
+// Store is the member data enrollments are reconciled against
+type Store interface {
+	// FindMember returns the member whose card carries the subscriber ID, or
+	// nil when there is none
+	FindMember(ctx context.Context, subscriberID string) (*Enrollee, error)
+	// ListDependents returns the members covered under a subscriber
+	ListDependents(ctx context.Context, memberID string) ([]*Enrollee, error)
+	// ListCoverageSpans returns all of a member's coverage spans
+	ListCoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error)
+	// Apply writes one member loop's change atomically
+	Apply(ctx context.Context, change *Change) error
+}
+
+// Publisher sends member update events; *kafka.Producer satisfies it
+type Publisher interface {
+	SendMessage(ctx context.Context, key string, value interface{}) error
+}
+
+// Change is what applying one member loop does to the records
+type Change struct {
+	// Enrollee is the member as they should be stored
+	Enrollee *Enrollee
+	// Created is set when the member is new. A new dependent is linked to
+	// Enrollee.Member.SubscriberMemberId with Relationship.
+	Created      bool
+	Relationship string
+	// Fields names the member fields that changed, e.g. "last_name"
+	Fields []string
+	// Spans are the coverage spans added or changed, as they should be stored
+	Spans []*pb.CoverageSpan
+	// Diff describes the change line by line
+	Diff []string
+}
+
+// Empty reports whether the loop matches the records already
+func (c *Change) Empty() bool {
+	return !c.Created && len(c.Fields) == 0 && len(c.Spans) == 0
+}
+
+// Options configures a Loader
+type Options struct {
+	// DryRun works out and reports every change without writing it or
+	// sending events
+	DryRun bool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Loader applies 834 enrollments to the member records. Loops are
+// reconciled against what is on record, so a re-sent file changes nothing
+// and is reported as skipped.
+type Loader struct {
+	store     Store
+	publisher Publisher
+	dryRun    bool
+	now       func() time.Time
+}
+
+// NewLoader creates a loader. publisher may be nil, in which case no member
+// update events are sent.
+func NewLoader(store Store, publisher Publisher, opts Options) *Loader {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Loader{
+		store:     store,
+		publisher: publisher,
+		dryRun:    opts.DryRun,
+		now:       opts.Now,
+	}
+}
+
+// Load applies every 834 in the interchange, loop by loop. A loop that
+// can't be applied is reported as errored and the rest carry on; an error
+// is returned only when ctx ends first.
+func (l *Loader) Load(ctx context.Context, ic *x12.Interchange) (*Report, error) {
+	report := &Report{
+		Interchange: ic.ControlNumber(),
+		Sender:      strings.TrimSpace(ic.Header.Element(6)),
+		DryRun:      l.dryRun,
+	}
+	pending := &pending{
+		members:    make(map[string]*Enrollee),
+		dependents: make(map[string][]*Enrollee),
+	}
+
+	for _, group := range ic.Groups {
+		for _, tx := range group.Transactions {
+			e, err := ParseEnrollment(tx)
+			if err != nil {
+				report.add(Result{Transaction: tx.ControlNumber(), Outcome: Errored, Reason: err.Error()})
+				continue
+			}
+			for _, m := range e.Members {
+				if err := ctx.Err(); err != nil {
+					return report, err
+				}
+				report.add(l.loop(ctx, pending, e, m))
+			}
+		}
+	}
+	return report, nil
+}
+
+// pending holds members added by loops that were not written, during a dry
+// run or a verify-only transaction, so their dependents later in the file
+// can still be checked
+type pending struct {
+	members    map[string]*Enrollee   // by card number
+	dependents map[string][]*Enrollee // by subscriber member ID
+}
+
+func (p *pending) add(enrollee *Enrollee) {
+	member := enrollee.Member
+	p.members[strings.ToUpper(member.SubscriberId)] = enrollee
+	if member.SubscriberMemberId != "" {
+		p.dependents[member.SubscriberMemberId] = append(p.dependents[member.SubscriberMemberId], enrollee)
+	}
+}
+
+func (l *Loader) findMember(ctx context.Context, p *pending, subscriberID string) (*Enrollee, error) {
+	if enrollee, ok := p.members[strings.ToUpper(subscriberID)]; ok {
+		return enrollee, nil
+	}
+	return l.store.FindMember(ctx, subscriberID)
+}
+
+func (l *Loader) listDependents(ctx context.Context, p *pending, memberID string) ([]*Enrollee, error) {
+	dependents, err := l.store.ListDependents(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	return append(dependents, p.dependents[memberID]...), nil
+}
+
+// loop reconciles one member loop and applies the difference
+func (l *Loader) loop(ctx context.Context, p *pending, e *Enrollment, m *Member) Result {
+	result := Result{
+		Transaction:  e.ControlNumber,
+		Position:     m.Position,
+		SubscriberID: m.SubscriberID,
+		Name:         m.Name(),
+		Maintenance:  m.Maintenance,
+	}
+
+	change, err := l.plan(ctx, p, e, m)
+	if err != nil {
+		result.Outcome, result.Reason = Errored, err.Error()
+		return result
+	}
+	result.Diff = change.Diff
+
+	switch {
+	case change.Empty():
+		result.Outcome, result.Reason = Skipped, "already up to date"
+		return result
+	case e.Action == ActionVerify:
+		result.Outcome, result.Reason = Skipped, "verify-only transaction"
+	case l.dryRun:
+		result.Outcome = Applied
+	default:
+		if err := l.store.Apply(ctx, change); err != nil {
+			result.Outcome, result.Reason = Errored, fmt.Sprintf("failed to apply: %v", err)
+			return result
+		}
+		result.Outcome = Applied
+		result.Warnings = l.publish(ctx, change)
+		return result
+	}
+
+	// Nothing was written
+	if change.Created {
+		p.add(change.Enrollee)
+	}
+	return result
+}
+
+// plan works out the change a member loop makes to the records
+func (l *Loader) plan(ctx context.Context, p *pending, e *Enrollment, m *Member) (*Change, error) {
+	if len(m.Problems) > 0 {
+		return nil, errors.New(strings.Join(m.Problems, "; "))
+	}
+	if m.SubscriberID == "" {
+		return nil, errors.New("subscriber number (REF*0F) is missing")
+	}
+	switch m.Maintenance {
+	case MaintenanceAdd, MaintenanceChange, MaintenanceTerm, MaintenanceReinstate, MaintenanceAudit:
+	default:
+		return nil, fmt.Errorf("unsupported maintenance type %q", m.Maintenance)
+	}
+
+	existing, subscriber, err := l.find(ctx, p, m)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := &planner{change: &Change{}, touched: make(map[*pb.CoverageSpan]bool), now: l.now()}
+	if existing == nil {
+		if m.Maintenance != MaintenanceAdd && m.Maintenance != MaintenanceAudit {
+			return nil, fmt.Errorf("%s is not enrolled; an addition (021) must come first", m.Name())
+		}
+		enrollee, err := l.newEnrollee(ctx, p, e, m, subscriber)
+		if err != nil {
+			return nil, err
+		}
+		pl.change.Enrollee = enrollee
+		pl.change.Created = true
+		if subscriber != nil {
+			pl.change.Relationship = Relationship(m.Relationship)
+		}
+		pl.change.Diff = append(pl.change.Diff, fmt.Sprintf("add member %s as %s", m.Name(), enrollee.Member.SubscriberId))
+	} else {
+		pl.change.Enrollee = &Enrollee{
+			Member: proto.Clone(existing.Member).(*pb.Member),
+			Gender: existing.Gender,
+		}
+		pl.spans, err = l.store.ListCoverageSpans(ctx, existing.Member.MemberId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list coverage spans: %w", err)
+		}
+		if m.Maintenance != MaintenanceTerm && m.Maintenance != MaintenanceReinstate {
+			pl.demographics(e, m)
+		}
+	}
+
+	switch m.Maintenance {
+	case MaintenanceTerm:
+		if len(m.Coverages) == 0 {
+			if err := pl.terminate(m, pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED, m.EligibilityEnd); err != nil {
+				return nil, err
+			}
+		}
+		for _, cov := range m.Coverages {
+			coverageType, err := coverageType(cov)
+			if err != nil {
+				return nil, err
+			}
+			if err := pl.terminate(m, coverageType, firstDate(cov.End, m.EligibilityEnd)); err != nil {
+				return nil, err
+			}
+		}
+
+	case MaintenanceReinstate:
+		if len(m.Coverages) == 0 {
+			if err := pl.reinstate(pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED); err != nil {
+				return nil, err
+			}
+		}
+		for _, cov := range m.Coverages {
+			coverageType, err := coverageType(cov)
+			if err != nil {
+				return nil, err
+			}
+			if err := pl.reinstate(coverageType); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		for _, cov := range m.Coverages {
+			if err := pl.coverage(e, m, cov); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pl.change, nil
+}
+
+// find returns the member a loop is about, or nil when they are not on
+// record yet. For a dependent it also returns their subscriber.
+func (l *Loader) find(ctx context.Context, p *pending, m *Member) (*Enrollee, *Enrollee, error) {
+	subscriber, err := l.findMember(ctx, p, m.SubscriberID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up subscriber: %w", err)
+	}
+	if m.Subscriber {
+		return subscriber, nil, nil
+	}
+	if subscriber == nil {
+		return nil, nil, fmt.Errorf("subscriber %s is not enrolled", m.SubscriberID)
+	}
+
+	dependents, err := l.listDependents(ctx, p, subscriber.Member.MemberId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list dependents: %w", err)
+	}
+
+	// A dependent's own card number identifies them outright
+	if m.MemberID != "" {
+		for _, dependent := range dependents {
+			if strings.EqualFold(dependent.Member.SubscriberId, m.MemberID) {
+				return dependent, subscriber, nil
+			}
+		}
+		other, err := l.findMember(ctx, p, m.MemberID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to look up member: %w", err)
+		}
+		if other != nil {
+			return nil, nil, fmt.Errorf("member %s is not a dependent of subscriber %s", m.MemberID, m.SubscriberID)
+		}
+		return nil, subscriber, nil
+	}
+
+	// Otherwise by birth date, then first name when twins share it. Matching
+	// on birth date first keeps name corrections from adding a second person.
+	if m.BirthDate.IsZero() {
+		return nil, nil, errors.New("birth date (DMG) is required to identify a dependent")
+	}
+	var matches []*Enrollee
+	for _, dependent := range dependents {
+		if dob := dependent.Member.DateOfBirth; dob != nil && coverage.Day(dob.AsTime()).Equal(m.BirthDate) {
+			matches = append(matches, dependent)
+		}
+	}
+	if len(matches) > 1 {
+		var named []*Enrollee
+		for _, dependent := range matches {
+			if strings.EqualFold(dependent.Member.FirstName, m.FirstName) {
+				named = append(named, dependent)
+			}
+		}
+		matches = named
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, subscriber, nil
+	case 1:
+		return matches[0], subscriber, nil
+	default:
+		return nil, nil, fmt.Errorf("%s matches more than one dependent of subscriber %s", m.Name(), m.SubscriberID)
+	}
+}
+
+// newEnrollee builds the record for a member being added. A dependent sent
+// without a card number of their own gets the subscriber's with the next
+// free suffix, e.g. SUB123456-03.
+func (l *Loader) newEnrollee(ctx context.Context, p *pending, e *Enrollment, m *Member, subscriber *Enrollee) (*Enrollee, error) {
+	if m.LastName == "" || m.FirstName == "" {
+		return nil, errors.New("member name (NM1*IL) is required to add a member")
+	}
+	if m.BirthDate.IsZero() {
+		return nil, errors.New("birth date (DMG) is required to add a member")
+	}
+
+	enrollmentDate := m.EligibilityBegin
+	for _, cov := range m.Coverages {
+		if !cov.Begin.IsZero() && (enrollmentDate.IsZero() || cov.Begin.Before(enrollmentDate)) {
+			enrollmentDate = cov.Begin
+		}
+	}
+	if enrollmentDate.IsZero() {
+		enrollmentDate = coverage.Day(l.now())
+	}
+
+	member := &pb.Member{
+		MemberId:       uuid.New().String(),
+		FirstName:      m.FirstName,
+		LastName:       m.LastName,
+		MiddleName:     m.MiddleName,
+		DateOfBirth:    timestamppb.New(m.BirthDate),
+		Email:          m.Email,
+		Phone:          m.Phone,
+		GroupNumber:    groupNumber(e, m, nil),
+		SubscriberId:   m.SubscriberID,
+		EnrollmentDate: timestamppb.New(enrollmentDate),
+	}
+	if m.Street1 != "" {
+		member.Address = address(m)
+	}
+
+	if subscriber != nil {
+		member.SubscriberMemberId = subscriber.Member.MemberId
+		member.SubscriberId = m.MemberID
+		if member.SubscriberId == "" {
+			dependents, err := l.listDependents(ctx, p, subscriber.Member.MemberId)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list dependents: %w", err)
+			}
+			member.SubscriberId = nextDependentID(subscriber.Member.SubscriberId, dependents)
+		}
+	}
+
+	return &Enrollee{Member: member, Gender: m.Gender}, nil
+}
+
+// nextDependentID returns the subscriber's card number with the next unused
+// two-digit suffix
+func nextDependentID(subscriberID string, dependents []*Enrollee) string {
+	last := 0
+	prefix := strings.ToUpper(subscriberID) + "-"
+	for _, dependent := range dependents {
+		suffix, ok := strings.CutPrefix(strings.ToUpper(dependent.Member.SubscriberId), prefix)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(suffix); err == nil && n > last {
+			last = n
+		}
+	}
+	return fmt.Sprintf("%s-%02d", subscriberID, last+1)
+}
+
+// planner accumulates the change for one member loop against their spans
+type planner struct {
+	change  *Change
+	spans   []*pb.CoverageSpan
+	touched map[*pb.CoverageSpan]bool
+	now     time.Time
+}
+
+// set records a changed member field. Names and codes are compared without
+// regard to case, since 834s are usually upper-case throughout.
+func (pl *planner) set(field string, current *string, value string) {
+	if value == "" || strings.EqualFold(*current, value) {
+		return
+	}
+	pl.change.Diff = append(pl.change.Diff, fmt.Sprintf("%s: %q -> %q", field, *current, value))
+	pl.change.Fields = append(pl.change.Fields, field)
+	*current = value
+}
+
+// demographics updates the fields the loop sends; fields it leaves out are
+// kept as they are
+func (pl *planner) demographics(e *Enrollment, m *Member) {
+	enrollee := pl.change.Enrollee
+	member := enrollee.Member
+
+	pl.set("first_name", &member.FirstName, m.FirstName)
+	pl.set("last_name", &member.LastName, m.LastName)
+	pl.set("middle_name", &member.MiddleName, m.MiddleName)
+	if !m.BirthDate.IsZero() && (member.DateOfBirth == nil || !coverage.Day(member.DateOfBirth.AsTime()).Equal(m.BirthDate)) {
+		pl.change.Diff = append(pl.change.Diff, fmt.Sprintf("date_of_birth: %q -> %q",
+			coverage.FormatDate(member.DateOfBirth), m.BirthDate.Format("2006-01-02")))
+		pl.change.Fields = append(pl.change.Fields, "date_of_birth")
+		member.DateOfBirth = timestamppb.New(m.BirthDate)
+	}
+	pl.set("gender", &enrollee.Gender, m.Gender)
+	pl.set("email", &member.Email, m.Email)
+	pl.set("phone", &member.Phone, m.Phone)
+	pl.set("group_number", &member.GroupNumber, groupNumber(e, m, nil))
+
+	if m.Street1 != "" {
+		current := formatAddress(member.Address)
+		sent := formatAddress(address(m))
+		if !strings.EqualFold(current, sent) {
+			pl.change.Diff = append(pl.change.Diff, fmt.Sprintf("address: %q -> %q", current, sent))
+			pl.change.Fields = append(pl.change.Fields, "address")
+			member.Address = address(m)
+		}
+	}
+}
+
+// coverage reconciles an added, changed or audited coverage (loop 2300) with
+// the member's spans. A span starting on the same day is the same coverage,
+// possibly corrected; a later start is a plan change, ending the span in
+// force the day before.
+func (pl *planner) coverage(e *Enrollment, m *Member, cov *Coverage) error {
+	switch cov.Maintenance {
+	case MaintenanceTerm, MaintenanceReinstate:
+		coverageType, err := coverageType(cov)
+		if err != nil {
+			return err
+		}
+		if cov.Maintenance == MaintenanceTerm {
+			return pl.terminate(m, coverageType, firstDate(cov.End, m.EligibilityEnd))
+		}
+		return pl.reinstate(coverageType)
+	}
+
+	coverageType, err := coverageType(cov)
+	if err != nil {
+		return err
+	}
+	begin := firstDate(cov.Begin, m.EligibilityBegin)
+	if begin.IsZero() {
+		return fmt.Errorf("HD*%s*%s: benefit begin date (DTP*348) is required", cov.Maintenance, cov.InsuranceLine)
+	}
+
+	member := pl.change.Enrollee.Member
+	want := &pb.CoverageSpan{
+		SpanId:        fmt.Sprintf("CS-%s-%s-%s", member.MemberId, coverage.TypeName(coverageType), begin.Format("20060102")),
+		MemberId:      member.MemberId,
+		CoverageType:  coverageType,
+		PlanId:        cov.PlanID,
+		PlanName:      cov.Plan,
+		GroupNumber:   groupNumber(e, m, cov),
+		Tier:          Tier(cov.Level),
+		EffectiveDate: timestamppb.New(begin),
+		UpdatedAt:     timestamppb.New(pl.now),
+	}
+	if !cov.End.IsZero() {
+		coverage.Terminate(want, cov.End, TerminationReason(m.Reason), pl.now)
+	}
+
+	for _, span := range pl.spans {
+		if span.CoverageType == coverageType && coverage.Day(span.EffectiveDate.AsTime()).Equal(begin) {
+			return pl.correct(span, want)
+		}
+	}
+
+	if prior := coverage.Find(pl.spans, coverageType, begin.AddDate(0, 0, -1)); prior != nil {
+		end := begin.AddDate(0, 0, -1)
+		if prior.TerminationDate == nil || coverage.Day(prior.TerminationDate.AsTime()).After(end) {
+			coverage.Terminate(prior, end, pb.TerminationReason_TERMINATION_REASON_PLAN_CHANGE, pl.now)
+			pl.touch(prior, fmt.Sprintf("end %s on %s (%s)", describe(prior), end.Format("2006-01-02"),
+				coverage.ReasonName(prior.TerminationReason)))
+		}
+	}
+	for _, span := range pl.spans {
+		if coverage.Overlaps(want, span) {
+			return fmt.Errorf("%s overlaps %s", describe(want), describe(span))
+		}
+	}
+
+	pl.spans = append(pl.spans, want)
+	diff := "add " + describe(want)
+	if want.PlanName != "" {
+		diff += " under " + want.PlanName
+	}
+	if want.Tier != pb.CoverageTier_COVERAGE_TIER_UNSPECIFIED {
+		diff += ", " + coverage.TierName(want.Tier)
+	}
+	pl.touch(want, diff)
+	return nil
+}
+
+// correct updates a span re-sent with the same start date. Fields the loop
+// leaves out are kept.
+func (pl *planner) correct(span, want *pb.CoverageSpan) error {
+	var diffs []string
+	update := func(field string, current *string, value string) {
+		if value != "" && !strings.EqualFold(*current, value) {
+			diffs = append(diffs, fmt.Sprintf("%s %q -> %q", field, *current, value))
+			*current = value
+		}
+	}
+	update("plan_id", &span.PlanId, want.PlanId)
+	update("plan_name", &span.PlanName, want.PlanName)
+	update("group_number", &span.GroupNumber, want.GroupNumber)
+	if want.Tier != pb.CoverageTier_COVERAGE_TIER_UNSPECIFIED && span.Tier != want.Tier {
+		diffs = append(diffs, fmt.Sprintf("tier %s -> %s", coverage.TierName(span.Tier), coverage.TierName(want.Tier)))
+		span.Tier = want.Tier
+	}
+
+	if want.TerminationDate != nil && coverage.FormatDate(span.TerminationDate) != coverage.FormatDate(want.TerminationDate) {
+		if err := coverage.ValidateTermination(span, want.TerminationDate.AsTime()); err != nil {
+			return err
+		}
+		diffs = append(diffs, fmt.Sprintf("ends %s", coverage.FormatDate(want.TerminationDate)))
+		coverage.Terminate(span, want.TerminationDate.AsTime(), want.TerminationReason, pl.now)
+	}
+
+	if len(diffs) > 0 {
+		span.UpdatedAt = timestamppb.New(pl.now)
+		pl.touch(span, fmt.Sprintf("change %s: %s", describe(span), strings.Join(diffs, ", ")))
+	}
+	return nil
+}
+
+// terminate ends the member's spans of a coverage type (all types when
+// unspecified) on the given day. Spans that start later are voided, and
+// spans already ending by then are left alone.
+func (pl *planner) terminate(m *Member, coverageType pb.CoverageType, end time.Time) error {
+	if end.IsZero() {
+		return errors.New("eligibility end date (DTP*357 or DTP*349) is required to terminate coverage")
+	}
+	reason := TerminationReason(m.Reason)
+
+	for _, span := range pl.spans {
+		if coverageType != pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED && span.CoverageType != coverageType {
+			continue
+		}
+		if coverage.Void(span) {
+			continue
+		}
+		if span.TerminationDate != nil && !coverage.Day(span.TerminationDate.AsTime()).After(end) {
+			continue
+		}
+
+		last := end
+		if start := coverage.Day(span.EffectiveDate.AsTime()); start.After(end) {
+			last = start.AddDate(0, 0, -1)
+		}
+		coverage.Terminate(span, last, reason, pl.now)
+		pl.touch(span, fmt.Sprintf("end %s on %s (%s)", describe(span), last.Format("2006-01-02"), coverage.ReasonName(reason)))
+	}
+	return nil
+}
+
+// reinstate reopens the latest span of a coverage type (each type when
+// unspecified) if it was terminated
+func (pl *planner) reinstate(coverageType pb.CoverageType) error {
+	latest := make(map[pb.CoverageType]*pb.CoverageSpan)
+	for _, span := range pl.spans {
+		if coverageType != pb.CoverageType_COVERAGE_TYPE_UNSPECIFIED && span.CoverageType != coverageType {
+			continue
+		}
+		if current := latest[span.CoverageType]; current == nil || span.EffectiveDate.AsTime().After(current.EffectiveDate.AsTime()) {
+			latest[span.CoverageType] = span
+		}
+	}
+	if len(latest) == 0 {
+		return errors.New("no coverage on record to reinstate")
+	}
+
+	types := make([]pb.CoverageType, 0, len(latest))
+	for t := range latest {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	for _, t := range types {
+		span := latest[t]
+		if span.TerminationDate == nil {
+			continue
+		}
+		if err := coverage.ValidateReinstatement(pl.spans, span); err != nil {
+			return err
+		}
+		coverage.Reinstate(span, pl.now)
+		pl.touch(span, "reinstate "+describe(span))
+	}
+	return nil
+}
+
+// touch records a span as added or changed
+func (pl *planner) touch(span *pb.CoverageSpan, diff string) {
+	pl.change.Diff = append(pl.change.Diff, diff)
+	if !pl.touched[span] {
+		pl.touched[span] = true
+		pl.change.Spans = append(pl.change.Spans, span)
+	}
+}
+
+// publish sends the member update events for an applied change. Failures
+// don't undo the change; they are returned as warnings for the report.
+func (l *Loader) publish(ctx context.Context, change *Change) []string {
+	if l.publisher == nil {
+		return nil
+	}
+
+	memberID := change.Enrollee.Member.MemberId
+	at := l.now().Unix()
+
+	var updates []kafka.MemberUpdate
+	switch {
+	case change.Created:
+		updates = append(updates, kafka.MemberUpdate{MemberID: memberID, UpdateType: "enrollment", Timestamp: at})
+	case len(change.Fields) > 0:
+		updates = append(updates, kafka.MemberUpdate{MemberID: memberID, UpdateType: "demographics", UpdatedFields: change.Fields, Timestamp: at})
+	}
+	if len(change.Spans) > 0 {
+		var types []string
+		seen := make(map[pb.CoverageType]bool)
+		for _, span := range change.Spans {
+			if !seen[span.CoverageType] {
+				seen[span.CoverageType] = true
+				types = append(types, coverage.TypeName(span.CoverageType))
+			}
+		}
+		updates = append(updates, kafka.MemberUpdate{MemberID: memberID, UpdateType: "coverage", UpdatedFields: types, Timestamp: at})
+	}
+
+	var warnings []string
+	for _, update := range updates {
+		if err := l.publisher.SendMessage(ctx, memberID, update); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s event not sent: %v", update.UpdateType, err))
+		}
+	}
+	return warnings
+}
+
+func coverageType(cov *Coverage) (pb.CoverageType, error) {
+	coverageType, ok := CoverageType(cov.InsuranceLine)
+	if !ok {
+		return coverageType, fmt.Errorf("unsupported insurance line code %q", cov.InsuranceLine)
+	}
+	return coverageType, nil
+}
+
+// groupNumber prefers the coverage's policy number, then the member's, then
+// the sponsor's master policy
+func groupNumber(e *Enrollment, m *Member, cov *Coverage) string {
+	if cov != nil && cov.GroupNumber != "" {
+		return cov.GroupNumber
+	}
+	if m.GroupNumber != "" {
+		return m.GroupNumber
+	}
+	return e.PolicyNumber
+}
+
+func firstDate(dates ...time.Time) time.Time {
+	for _, d := range dates {
+		if !d.IsZero() {
+			return d
+		}
+	}
+	return time.Time{}
+}
+
+func address(m *Member) *pb.Address {
+	return &pb.Address{
+		Street1: m.Street1,
+		Street2: m.Street2,
+		City:    m.City,
+		State:   m.State,
+		ZipCode: m.ZipCode,
+	}
+}
+
+func formatAddress(a *pb.Address) string {
+	if a == nil {
+		return ""
+	}
+	var parts []string
+	for _, part := range []string{a.Street1, a.Street2, a.City, a.State, a.ZipCode} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describe names a span in diffs, e.g. "MEDICAL coverage from 2024-01-01"
+func describe(span *pb.CoverageSpan) string {
+	return fmt.Sprintf("%s coverage from %s", coverage.TypeName(span.CoverageType), coverage.FormatDate(span.EffectiveDate))
+}
@@ -0,0 +1,317 @@
+package enrollment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sydney-health-clone/backend/shared/coverage"
+	"github.com/sydney-health-clone/backend/shared/kafka"
+	pb "github.com/sydney-health-clone/backend/shared/pb"
+	"github.com/sydney-health-clone/backend/shared/x12"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func date(year int, month time.Month, day int) *timestamppb.Timestamp {
+	return timestamppb.New(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// memStore holds members and spans the way the member service's tables do.
+// Everything handed out is a copy, so a plan can't change the records
+// without going through Apply.
+type memStore struct {
+	members map[string]*Enrollee
+	spans   map[string][]*pb.CoverageSpan
+	applied int
+}
+
+// newDoeFamily seeds the Doe family the fixtures are written against, the
+// same people as the member service's mock data
+func newDoeFamily() *memStore {
+	s := &memStore{
+		members: make(map[string]*Enrollee),
+		spans:   make(map[string][]*pb.CoverageSpan),
+	}
+
+	address := &pb.Address{Street1: "123 Main Street", Street2: "Apt 4B", City: "San Francisco", State: "CA", ZipCode: "94105", Country: "USA"}
+	add := func(id, first, gender, cardNumber, subscriber string, born *timestamppb.Timestamp, types ...pb.CoverageType) {
+		s.members[id] = &Enrollee{
+			Member: &pb.Member{
+				MemberId:           id,
+				FirstName:          first,
+				LastName:           "Doe",
+				DateOfBirth:        born,
+				Address:            proto.Clone(address).(*pb.Address),
+				GroupNumber:        "GRP001234",
+				SubscriberId:       cardNumber,
+				SubscriberMemberId: subscriber,
+				EnrollmentDate:     date(2020, time.January, 1),
+			},
+			Gender: gender,
+		}
+		for _, coverageType := range types {
+			s.spans[id] = append(s.spans[id], &pb.CoverageSpan{
+				SpanId:        fmt.Sprintf("CS-%s-%s", id, coverage.TypeName(coverageType)),
+				MemberId:      id,
+				CoverageType:  coverageType,
+				PlanId:        "PLN-PREM-" + coverage.TypeName(coverageType),
+				PlanName:      "Premium Health Plan",
+				GroupNumber:   "GRP001234",
+				Tier:          pb.CoverageTier_COVERAGE_TIER_FAMILY,
+				EffectiveDate: date(2020, time.January, 1),
+			})
+		}
+	}
+
+	all := []pb.CoverageType{
+		pb.CoverageType_COVERAGE_TYPE_MEDICAL,
+		pb.CoverageType_COVERAGE_TYPE_DENTAL,
+		pb.CoverageType_COVERAGE_TYPE_VISION,
+		pb.CoverageType_COVERAGE_TYPE_PHARMACY,
+	}
+	add("M123456", "John", "M", "SUB123456", "", date(1985, time.June, 15), all...)
+	add("M123457", "Jane", "F", "SUB123456-01", "M123456", date(1987, time.March, 22), all...)
+	add("M123458", "Jimmy", "M", "SUB123456-02", "M123456", date(2010, time.July, 10), all[:3]...)
+	s.members["M123456"].Member.MiddleName = "Michael"
+	s.members["M123456"].Member.Email = "john.doe@email.com"
+	s.members["M123456"].Member.Phone = "+1-555-123-4567"
+	s.members["M123457"].Member.Email = "jane.doe@email.com"
+	s.members["M123457"].Member.Phone = "+1-555-123-4568"
+	return s
+}
+
+func cloneEnrollee(e *Enrollee) *Enrollee {
+	return &Enrollee{Member: proto.Clone(e.Member).(*pb.Member), Gender: e.Gender}
+}
+
+func (s *memStore) FindMember(ctx context.Context, subscriberID string) (*Enrollee, error) {
+	for _, e := range s.members {
+		if strings.EqualFold(e.Member.SubscriberId, subscriberID) {
+			return cloneEnrollee(e), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) ListDependents(ctx context.Context, memberID string) ([]*Enrollee, error) {
+	var dependents []*Enrollee
+	for _, e := range s.members {
+		if e.Member.SubscriberMemberId == memberID {
+			dependents = append(dependents, cloneEnrollee(e))
+		}
+	}
+	sort.Slice(dependents, func(i, j int) bool {
+		return dependents[i].Member.SubscriberId < dependents[j].Member.SubscriberId
+	})
+	return dependents, nil
+}
+
+func (s *memStore) ListCoverageSpans(ctx context.Context, memberID string) ([]*pb.CoverageSpan, error) {
+	spans := make([]*pb.CoverageSpan, 0, len(s.spans[memberID]))
+	for _, span := range s.spans[memberID] {
+		spans = append(spans, proto.Clone(span).(*pb.CoverageSpan))
+	}
+	return spans, nil
+}
+
+func (s *memStore) Apply(ctx context.Context, change *Change) error {
+	s.applied++
+	memberID := change.Enrollee.Member.MemberId
+	s.members[memberID] = cloneEnrollee(change.Enrollee)
+
+next:
+	for _, span := range change.Spans {
+		span = proto.Clone(span).(*pb.CoverageSpan)
+		for i, existing := range s.spans[memberID] {
+			if existing.SpanId == span.SpanId {
+				s.spans[memberID][i] = span
+				continue next
+			}
+		}
+		s.spans[memberID] = append(s.spans[memberID], span)
+	}
+	coverage.Sort(s.spans[memberID])
+	return nil
+}
+
+// fakePublisher records the update types it was asked to send
+type fakePublisher struct {
+	sent []string
+}
+
+func (p *fakePublisher) SendMessage(ctx context.Context, key string, value interface{}) error {
+	if update, ok := value.(kafka.MemberUpdate); ok {
+		p.sent = append(p.sent, update.UpdateType)
+	}
+	return nil
+}
+
+func load(t *testing.T, store Store, publisher Publisher, dryRun bool, file string) *Report {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interchanges, err := x12.Parse(data)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", file, err)
+	}
+	if len(interchanges) != 1 {
+		t.Fatalf("%s has %d interchanges, want 1", file, len(interchanges))
+	}
+
+	loader := NewLoader(store, publisher, Options{
+		DryRun: dryRun,
+		Now: func() time.Time {
+			return time.Date(2025, time.September, 1, 12, 0, 0, 0, time.UTC)
+		},
+	})
+	report, err := loader.Load(context.Background(), interchanges[0])
+	if err != nil {
+		t.Fatalf("loading %s: %v", file, err)
+	}
+	return report
+}
+
+func text(report *Report) string {
+	var b bytes.Buffer
+	report.WriteTo(&b)
+	return b.String()
+}
+
+// TestLoadFixtures loads the fixtures in the order the README lists them.
+// Each is dry run first, which must report what loading it then does
+// without writing anything, and re-sent afterwards, which must change
+// nothing.
+func TestLoadFixtures(t *testing.T) {
+	tests := []struct {
+		file                      string
+		applied, skipped, errored int
+		// resentSkipped is how many loops a re-send skips; the rest error
+		// again the same way
+		resentSkipped int
+		events        []string
+		report        []string // lines the report must contain
+	}{
+		{
+			file:          "new_family.x12",
+			applied:       3,
+			resentSkipped: 3,
+			events:        []string{"enrollment", "coverage", "enrollment", "coverage", "enrollment", "coverage"},
+			report:        []string{"add member", "as SUB200100-01", "as SUB200100-02"},
+		},
+		{
+			file:          "newborn_dependent.x12",
+			applied:       1,
+			resentSkipped: 1,
+			events:        []string{"enrollment", "coverage"},
+			report:        []string{"add member DOE, JULIA as SUB123456-03"},
+		},
+		{
+			file:          "address_change.x12",
+			applied:       1,
+			resentSkipped: 1,
+			events:        []string{"demographics"},
+			report:        []string{"address:"},
+		},
+		{
+			file:          "plan_change.x12",
+			applied:       1,
+			resentSkipped: 1,
+			events:        []string{"coverage"},
+			report:        []string{"end MEDICAL coverage from 2020-01-01 on 2025-12-31 (PLAN_CHANGE)", "add MEDICAL coverage from 2026-01-01"},
+		},
+		{
+			file:          "dependent_termination.x12",
+			applied:       1,
+			resentSkipped: 1,
+			events:        []string{"coverage"},
+			report: []string{
+				"end MEDICAL coverage from 2020-01-01 on 2025-06-30",
+				"end DENTAL coverage from 2020-01-01 on 2025-06-30",
+				"end VISION coverage from 2020-01-01 on 2025-06-30",
+			},
+		},
+		{
+			file:          "dependent_reinstatement.x12",
+			applied:       1,
+			resentSkipped: 1,
+			events:        []string{"coverage"},
+			report:        []string{"reinstate MEDICAL", "reinstate DENTAL", "reinstate VISION"},
+		},
+		{
+			file:          "verify_only.x12",
+			skipped:       1,
+			resentSkipped: 1,
+			report:        []string{"verify-only transaction", "email:"},
+		},
+		{
+			file:          "rejected_loops.x12",
+			applied:       1,
+			errored:       5,
+			resentSkipped: 1,
+			events:        []string{"demographics"},
+			report: []string{
+				"subscriber SUB999999 is not enrolled",
+				"subscriber number (REF*0F) is missing",
+				"an addition (021) must come first",
+				`unsupported insurance line code "LTC"`,
+				"phone:",
+			},
+		},
+	}
+
+	store := newDoeFamily()
+	for _, tt := range tests {
+		t.Run(strings.TrimSuffix(tt.file, ".x12"), func(t *testing.T) {
+			publisher := &fakePublisher{}
+
+			before := store.applied
+			dryRun := load(t, store, publisher, true, tt.file)
+			if !dryRun.DryRun || store.applied != before || len(publisher.sent) > 0 {
+				t.Fatalf("dry run wrote %d changes and sent %v", store.applied-before, publisher.sent)
+			}
+
+			loaded := load(t, store, publisher, false, tt.file)
+			for _, report := range []*Report{dryRun, loaded} {
+				if report.Applied != tt.applied || report.Skipped != tt.skipped || report.Errored != tt.errored {
+					t.Errorf("%d applied, %d skipped, %d errored; want %d, %d, %d\n%s",
+						report.Applied, report.Skipped, report.Errored, tt.applied, tt.skipped, tt.errored, text(report))
+				}
+			}
+			if got, want := text(dryRun), text(loaded); strings.Replace(got, " (dry run: nothing was written)", "", 1) != want {
+				t.Errorf("dry run reported differently from the load:\n%s\nloaded:\n%s", got, want)
+			}
+			if got := store.applied - before; got != tt.applied {
+				t.Errorf("wrote %d changes, want %d", got, tt.applied)
+			}
+			if strings.Join(publisher.sent, ",") != strings.Join(tt.events, ",") {
+				t.Errorf("sent %v, want %v", publisher.sent, tt.events)
+			}
+			for _, line := range tt.report {
+				if !strings.Contains(text(loaded), line) {
+					t.Errorf("report is missing %q:\n%s", line, text(loaded))
+				}
+			}
+
+			// Re-sending the file is a no-op
+			before = store.applied
+			resent := load(t, store, publisher, false, tt.file)
+			if resent.Applied != 0 || resent.Skipped != tt.resentSkipped || resent.Errored != tt.errored {
+				t.Errorf("re-sent: %d applied, %d skipped, %d errored; want 0, %d, %d\n%s",
+					resent.Applied, resent.Skipped, resent.Errored, tt.resentSkipped, tt.errored, text(resent))
+			}
+			if store.applied != before {
+				t.Errorf("re-sent file wrote %d changes", store.applied-before)
+			}
+		})
+	}
+}
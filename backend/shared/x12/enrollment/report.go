@@ -0,0 +1,87 @@
+package enrollment
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Outcome is what became of one member loop
+type Outcome string
+
+const (
+	Applied Outcome = "applied"
+	Skipped Outcome = "skipped"
+	Errored Outcome = "errored"
+)
+
+// Result is the reconciliation of one member loop
+type Result struct {
+	Transaction  string // ST-02
+	Position     int    // segment number of the INS; zero when the whole transaction failed
+	SubscriberID string
+	Name         string
+	Maintenance  string
+	Outcome      Outcome
+	Reason       string   // why the loop was skipped or errored
+	Diff         []string // the changes made, or that a dry run would make
+	Warnings     []string // problems after the change was written, such as unsent events
+}
+
+// Report reconciles one interchange: every member loop and what became of it
+type Report struct {
+	Interchange string // ISA-13
+	Sender      string // ISA-06
+	DryRun      bool
+	Results     []Result
+
+	Applied int
+	Skipped int
+	Errored int
+}
+
+func (r *Report) add(result Result) {
+	r.Results = append(r.Results, result)
+	switch result.Outcome {
+	case Applied:
+		r.Applied++
+	case Skipped:
+		r.Skipped++
+	case Errored:
+		r.Errored++
+	}
+}
+
+// WriteTo writes the report as text: a line per loop with its changes
+// indented beneath it, then the totals
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "Interchange %s from %s", r.Interchange, r.Sender)
+	if r.DryRun {
+		fmt.Fprintf(&b, " (dry run: nothing was written)")
+	}
+	fmt.Fprintln(&b)
+
+	for _, result := range r.Results {
+		var line string
+		if result.Position == 0 {
+			line = fmt.Sprintf("ST %-6s %-7s %s", result.Transaction, result.Outcome, result.Reason)
+		} else {
+			line = fmt.Sprintf("ST %-6s INS %-4d %-16s %-24s %-3s  %-7s %s",
+				result.Transaction, result.Position, result.SubscriberID, result.Name,
+				result.Maintenance, result.Outcome, result.Reason)
+		}
+		fmt.Fprintln(&b, strings.TrimRight(line, " "))
+		for _, diff := range result.Diff {
+			fmt.Fprintf(&b, "    + %s\n", diff)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(&b, "    ! %s\n", warning)
+		}
+	}
+
+	fmt.Fprintf(&b, "%d applied, %d skipped, %d errored\n", r.Applied, r.Skipped, r.Errored)
+	return b.WriteTo(w)
+}
@@ -0,0 +1,165 @@
+package x12
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// isaLength is the fixed length of an ISA segment including its terminator
+const isaLength = 106
+
+// SyntaxError reports a malformed envelope. Segment is the 1-based position
+// of the offending segment within the file.
+type SyntaxError struct {
+	Segment int
+	ID      string
+	Msg     string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("x12: segment %d (%s): %s", e.Segment, e.ID, e.Msg)
+}
+
+// Parse reads every interchange in data. Envelope counts and control numbers
+// are checked; the contents of each transaction set are left to its parser.
+func Parse(data []byte) ([]*Interchange, error) {
+	var interchanges []*Interchange
+	pos := 0
+
+	for {
+		data = bytes.TrimLeft(data, " \t\r\n")
+		if len(data) == 0 {
+			break
+		}
+
+		ic, n, next, err := parseInterchange(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		interchanges = append(interchanges, ic)
+		data = data[n:]
+		pos = next
+	}
+
+	if len(interchanges) == 0 {
+		return nil, &SyntaxError{Segment: 1, Msg: "no interchange found"}
+	}
+	return interchanges, nil
+}
+
+// parseInterchange parses the interchange at the start of data, which begins
+// after segment pos. It returns the bytes consumed and the new position.
+func parseInterchange(data []byte, pos int) (*Interchange, int, int, error) {
+	if len(data) < isaLength || string(data[:3]) != "ISA" {
+		return nil, 0, 0, &SyntaxError{Segment: pos + 1, ID: "ISA", Msg: "interchange must start with a 106-character ISA segment"}
+	}
+
+	d := Delimiters{
+		Element:    data[3],
+		Repetition: data[82],
+		Component:  data[104],
+		Segment:    data[105],
+	}
+	if d.Element == d.Segment || d.Component == d.Segment || d.Element == d.Component {
+		return nil, 0, 0, &SyntaxError{Segment: pos + 1, ID: "ISA", Msg: "delimiters must be distinct"}
+	}
+
+	ic := &Interchange{Delimiters: d}
+	if end := isaLength; end < len(data) && (data[end] == '\n' || data[end] == '\r') {
+		ic.LineBreaks = true
+	}
+
+	var group *Group
+	var tx *Transaction
+	offset := 0
+
+	for {
+		i := bytes.IndexByte(data[offset:], d.Segment)
+		if i < 0 {
+			return nil, 0, 0, &SyntaxError{Segment: pos + 1, Msg: "missing IEA"}
+		}
+		raw := strings.Trim(string(data[offset:offset+i]), " \t\r\n")
+		offset += i + 1
+		if raw == "" {
+			continue
+		}
+
+		pos++
+		seg := Segment(strings.Split(raw, string(d.Element)))
+		fail := func(format string, args ...interface{}) (*Interchange, int, int, error) {
+			return nil, 0, 0, &SyntaxError{Segment: pos, ID: seg.ID(), Msg: fmt.Sprintf(format, args...)}
+		}
+
+		switch seg.ID() {
+		case "ISA":
+			if ic.Header != nil {
+				return fail("ISA inside an interchange")
+			}
+			if len(seg) != 17 {
+				return fail("ISA has %d elements, want 16", len(seg)-1)
+			}
+			ic.Header = seg
+
+		case "GS":
+			if group != nil {
+				return fail("GS before GE of group %s", group.ControlNumber())
+			}
+			group = &Group{Header: seg}
+
+		case "ST":
+			if group == nil {
+				return fail("ST outside a functional group")
+			}
+			if tx != nil {
+				return fail("ST before SE of transaction %s", tx.ControlNumber())
+			}
+			tx = &Transaction{Header: seg}
+
+		case "SE":
+			if tx == nil {
+				return fail("SE without ST")
+			}
+			if n, err := strconv.Atoi(seg.Element(1)); err != nil || n != len(tx.Segments)+2 {
+				return fail("SE-01 is %q, transaction has %d segments", seg.Element(1), len(tx.Segments)+2)
+			}
+			if seg.Element(2) != tx.ControlNumber() {
+				return fail("SE-02 %q does not match ST-02 %q", seg.Element(2), tx.ControlNumber())
+			}
+			group.Transactions = append(group.Transactions, tx)
+			tx = nil
+
+		case "GE":
+			if group == nil || tx != nil {
+				return fail("GE out of place")
+			}
+			if n, err := strconv.Atoi(seg.Element(1)); err != nil || n != len(group.Transactions) {
+				return fail("GE-01 is %q, group has %d transactions", seg.Element(1), len(group.Transactions))
+			}
+			if seg.Element(2) != group.ControlNumber() {
+				return fail("GE-02 %q does not match GS-06 %q", seg.Element(2), group.ControlNumber())
+			}
+			ic.Groups = append(ic.Groups, group)
+			group = nil
+
+		case "IEA":
+			if group != nil {
+				return fail("IEA before GE of group %s", group.ControlNumber())
+			}
+			if n, err := strconv.Atoi(seg.Element(1)); err != nil || n != len(ic.Groups) {
+				return fail("IEA-01 is %q, interchange has %d groups", seg.Element(1), len(ic.Groups))
+			}
+			if seg.Element(2) != ic.ControlNumber() {
+				return fail("IEA-02 %q does not match ISA-13 %q", seg.Element(2), ic.ControlNumber())
+			}
+			return ic, offset, pos, nil
+
+		default:
+			if tx == nil {
+				return fail("segment outside a transaction set")
+			}
+			tx.Segments = append(tx.Segments, seg)
+		}
+	}
+}
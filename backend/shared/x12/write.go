@@ -0,0 +1,108 @@
+package x12
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteTo encodes the interchange. Trailer counts are computed from the
+// contents; control numbers are taken from the headers.
+func (ic *Interchange) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	write := func(seg Segment) {
+		if cw.err != nil {
+			return
+		}
+		for i, element := range seg {
+			if i > 0 {
+				cw.writeByte(ic.Delimiters.Element)
+			}
+			cw.writeString(element)
+		}
+		cw.writeByte(ic.Delimiters.Segment)
+		if ic.LineBreaks {
+			cw.writeByte('\n')
+		}
+	}
+
+	write(ic.Header)
+	for _, group := range ic.Groups {
+		write(group.Header)
+		for _, tx := range group.Transactions {
+			write(tx.Header)
+			for _, seg := range tx.Segments {
+				write(seg)
+			}
+			write(NewSegment("SE", strconv.Itoa(len(tx.Segments)+2), tx.ControlNumber()))
+		}
+		write(NewSegment("GE", strconv.Itoa(len(group.Transactions)), group.ControlNumber()))
+	}
+	write(NewSegment("IEA", strconv.Itoa(len(ic.Groups)), ic.ControlNumber()))
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// Validate checks that no element contains one of the interchange's
+// delimiters, which would corrupt the encoding
+func (ic *Interchange) Validate() error {
+	reserved := string([]byte{ic.Delimiters.Element, ic.Delimiters.Segment, ic.Delimiters.Component})
+
+	for _, group := range ic.Groups {
+		for _, tx := range group.Transactions {
+			for _, seg := range tx.Segments {
+				for i, element := range seg {
+					if strings.ContainsAny(element, reserved) {
+						return fmt.Errorf("x12: %s-%02d contains a delimiter: %q", seg.ID(), i, element)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Clean strips delimiters and other characters outside the X12 basic and
+// extended character sets from free text, such as names from the member store
+func Clean(s string, d Delimiters) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == rune(d.Element), r == rune(d.Segment), r == rune(d.Component), r == rune(d.Repetition):
+			return -1
+		case r < ' ' || r > '~':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) writeString(s string) {
+	if c.err != nil {
+		return
+	}
+	n, err := c.w.WriteString(s)
+	c.n += int64(n)
+	c.err = err
+}
+
+func (c *countingWriter) writeByte(b byte) {
+	if c.err != nil {
+		return
+	}
+	c.err = c.w.WriteByte(b)
+	if c.err == nil {
+		c.n++
+	}
+}
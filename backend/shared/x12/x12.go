@@ -0,0 +1,161 @@
+// Package x12 reads and writes ASC X12 interchanges: the ISA/GS/ST envelopes
+// and the segments inside them. Transaction sets such as the 270/271 live in
+// their own packages and work on the parsed segments.
+package x12
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Delimiters are declared by each interchange's ISA segment
+type Delimiters struct {
+	Element    byte
+	Component  byte
+	Repetition byte
+	Segment    byte
+}
+
+// DefaultDelimiters are the ones most trading partners use
+var DefaultDelimiters = Delimiters{Element: '*', Component: ':', Repetition: '^', Segment: '~'}
+
+// Segment is a segment ID followed by its elements. Element positions are
+// 1-based as in the implementation guides, so NM1-03 is Element(3).
+type Segment []string
+
+// NewSegment builds a segment, dropping trailing empty elements as X12 requires
+func NewSegment(id string, elements ...string) Segment {
+	for len(elements) > 0 && elements[len(elements)-1] == "" {
+		elements = elements[:len(elements)-1]
+	}
+	return append(Segment{id}, elements...)
+}
+
+// ID returns the segment identifier, e.g. "NM1"
+func (s Segment) ID() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+// Element returns the element at the 1-based position, or "" if absent
+func (s Segment) Element(i int) string {
+	if i <= 0 || i >= len(s) {
+		return ""
+	}
+	return s[i]
+}
+
+// Repeats splits a repeating element, e.g. EQ-01 "30^1^88"
+func (s Segment) Repeats(i int, d Delimiters) []string {
+	v := s.Element(i)
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, string(d.Repetition))
+}
+
+// Component returns the 1-based component of a composite element
+func (s Segment) Component(i, j int, d Delimiters) string {
+	parts := strings.Split(s.Element(i), string(d.Component))
+	if j <= 0 || j > len(parts) {
+		return ""
+	}
+	return parts[j-1]
+}
+
+// Interchange is one ISA/IEA envelope
+type Interchange struct {
+	Delimiters Delimiters
+	// LineBreaks writes a newline after each segment terminator, as many
+	// partners do to keep files readable
+	LineBreaks bool
+	Header     Segment // ISA
+	Groups     []*Group
+}
+
+// Group is one GS/GE functional group
+type Group struct {
+	Header       Segment // GS
+	Transactions []*Transaction
+}
+
+// Transaction is one ST/SE transaction set. Segments holds everything
+// between the ST and SE.
+type Transaction struct {
+	Header   Segment // ST
+	Segments []Segment
+}
+
+// ControlNumber returns ISA-13
+func (ic *Interchange) ControlNumber() string {
+	return ic.Header.Element(13)
+}
+
+// ControlNumber returns GS-06
+func (g *Group) ControlNumber() string {
+	return g.Header.Element(6)
+}
+
+// Type returns the transaction set identifier, e.g. "270"
+func (t *Transaction) Type() string {
+	return t.Header.Element(1)
+}
+
+// ControlNumber returns ST-02
+func (t *Transaction) ControlNumber() string {
+	return t.Header.Element(2)
+}
+
+// NewInterchangeHeader builds an ISA segment with its fixed-width fields padded
+func NewInterchangeHeader(senderQualifier, senderID, receiverQualifier, receiverID string, controlNumber int, usage string, at time.Time, d Delimiters) Segment {
+	return Segment{
+		"ISA",
+		"00", pad("", 10),
+		"00", pad("", 10),
+		pad(senderQualifier, 2), pad(senderID, 15),
+		pad(receiverQualifier, 2), pad(receiverID, 15),
+		at.Format("060102"), at.Format("1504"),
+		string(d.Repetition), "00501",
+		fmt.Sprintf("%09d", controlNumber),
+		"0", usage,
+		string(d.Component),
+	}
+}
+
+// Reply builds the ISA for a response to ic: sender and receiver are
+// swapped and the usage indicator is kept.
+func (ic *Interchange) Reply(controlNumber int, at time.Time) *Interchange {
+	h := ic.Header
+	return &Interchange{
+		Delimiters: ic.Delimiters,
+		LineBreaks: ic.LineBreaks,
+		Header: NewInterchangeHeader(
+			strings.TrimSpace(h.Element(7)), strings.TrimSpace(h.Element(8)),
+			strings.TrimSpace(h.Element(5)), strings.TrimSpace(h.Element(6)),
+			controlNumber, h.Element(15), at, ic.Delimiters),
+	}
+}
+
+// NewGroupHeader builds a GS segment for the 5010 version of the given guide,
+// e.g. "005010X279A1"
+func NewGroupHeader(functionalID, sender, receiver string, controlNumber int, version string, at time.Time) Segment {
+	return NewSegment("GS", functionalID, sender, receiver,
+		at.Format("20060102"), at.Format("1504"), fmt.Sprintf("%d", controlNumber), "X", version)
+}
+
+// Reply builds the GS for a response group with sender and receiver swapped
+func (g *Group) Reply(functionalID string, controlNumber int, at time.Time) *Group {
+	return &Group{
+		Header: NewGroupHeader(functionalID, g.Header.Element(3), g.Header.Element(2), controlNumber, g.Header.Element(8), at),
+	}
+}
+
+func pad(s string, n int) string {
+	if len(s) >= n {
+		return s[:n]
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
@@ -225,9 +225,11 @@ JWT_SECRET=dev_secret_key_change_in_production
 GATEWAY_PORT=8080
 MEMBER_SERVICE_PORT=50051
 BENEFITS_SERVICE_PORT=50052
-CLAIMS_SERVICE_PORT=50053
-PROVIDER_SERVICE_PORT=50054
+CLAIMS_SERVICE_PORT=50054
+PROVIDER_SERVICE_PORT=50053
 MESSAGING_SERVICE_PORT=50055
+PRIOR_AUTH_SERVICE_PORT=50056
+SPENDING_SERVICE_PORT=50057
 
 # Metrics
 METRICS_PORT=9091